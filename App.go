@@ -47,8 +47,10 @@ type App struct {
 	db           *pg.DB
 	pubsubClient *pubsub.PubSubClient
 	// used for local dev only
-	serveTls        bool
-	sessionManager2 *authMiddleware.SessionManager
+	serveTls              bool
+	sessionManager2       *authMiddleware.SessionManager
+	auditLogMiddleware    middleware.AuditLogMiddleware
+	userSessionMiddleware middleware.UserSessionMiddleware
 }
 
 func NewApp(router *router.MuxRouter,
@@ -59,6 +61,8 @@ func NewApp(router *router.MuxRouter,
 	db *pg.DB,
 	pubsubClient *pubsub.PubSubClient,
 	sessionManager2 *authMiddleware.SessionManager,
+	auditLogMiddleware middleware.AuditLogMiddleware,
+	userSessionMiddleware middleware.UserSessionMiddleware,
 ) *App {
 	//check argo connection
 	err := versionService.CheckVersion()
@@ -66,14 +70,16 @@ func NewApp(router *router.MuxRouter,
 		log.Panic(err)
 	}
 	app := &App{
-		MuxRouter:       router,
-		Logger:          Logger,
-		SSE:             sse,
-		Enforcer:        enforcer,
-		db:              db,
-		pubsubClient:    pubsubClient,
-		serveTls:        false,
-		sessionManager2: sessionManager2,
+		MuxRouter:             router,
+		Logger:                Logger,
+		SSE:                   sse,
+		Enforcer:              enforcer,
+		db:                    db,
+		pubsubClient:          pubsubClient,
+		serveTls:              false,
+		sessionManager2:       sessionManager2,
+		auditLogMiddleware:    auditLogMiddleware,
+		userSessionMiddleware: userSessionMiddleware,
 	}
 	return app
 }
@@ -96,8 +102,10 @@ func (app *App) Start() {
 	app.MuxRouter.Init()
 	//authEnforcer := casbin2.Create()
 
-	server := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: authMiddleware.Authorizer(app.sessionManager2, user.WhitelistChecker)(app.MuxRouter.Router)}
+	server := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: middleware.ScimBearerToken(authMiddleware.Authorizer(app.sessionManager2, user.WhitelistChecker)(app.MuxRouter.Router))}
 	app.MuxRouter.Router.Use(middleware.PrometheusMiddleware)
+	app.MuxRouter.Router.Use(app.userSessionMiddleware.ValidateSession)
+	app.MuxRouter.Router.Use(app.auditLogMiddleware.Audit)
 	app.server = server
 	var err error
 	if app.serveTls {
@@ -44,6 +44,7 @@ import (
 	"github.com/devtron-labs/devtron/client/lens"
 	pubsub2 "github.com/devtron-labs/devtron/client/pubsub"
 	"github.com/devtron-labs/devtron/client/telemetry"
+	"github.com/devtron-labs/devtron/internal/middleware"
 	"github.com/devtron-labs/devtron/internal/sql/repository"
 	app2 "github.com/devtron-labs/devtron/internal/sql/repository/app"
 	appWorkflow2 "github.com/devtron-labs/devtron/internal/sql/repository/appWorkflow"
@@ -62,20 +63,30 @@ import (
 	"github.com/devtron-labs/devtron/pkg/appWorkflow"
 	"github.com/devtron-labs/devtron/pkg/appstore"
 	"github.com/devtron-labs/devtron/pkg/attributes"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
+	repository4 "github.com/devtron-labs/devtron/pkg/auditLog/repository"
 	"github.com/devtron-labs/devtron/pkg/commonService"
+	"github.com/devtron-labs/devtron/pkg/cost"
+	repository6 "github.com/devtron-labs/devtron/pkg/cost/repository"
 	"github.com/devtron-labs/devtron/pkg/deploymentGroup"
 	"github.com/devtron-labs/devtron/pkg/dex"
 	"github.com/devtron-labs/devtron/pkg/event"
 	"github.com/devtron-labs/devtron/pkg/git"
 	"github.com/devtron-labs/devtron/pkg/gitops"
+	"github.com/devtron-labs/devtron/pkg/hibernation"
+	repository7 "github.com/devtron-labs/devtron/pkg/hibernation/repository"
 	jira2 "github.com/devtron-labs/devtron/pkg/jira"
 	"github.com/devtron-labs/devtron/pkg/notifier"
 	"github.com/devtron-labs/devtron/pkg/pipeline"
+	"github.com/devtron-labs/devtron/pkg/previewEnvironment"
 	"github.com/devtron-labs/devtron/pkg/projectManagementService/jira"
 	"github.com/devtron-labs/devtron/pkg/security"
 	"github.com/devtron-labs/devtron/pkg/sql"
 	"github.com/devtron-labs/devtron/pkg/terminal"
+	repository5 "github.com/devtron-labs/devtron/pkg/terminal/repository"
 	util3 "github.com/devtron-labs/devtron/pkg/util"
+	"github.com/devtron-labs/devtron/pkg/variables"
+	repository3 "github.com/devtron-labs/devtron/pkg/variables/repository"
 	util2 "github.com/devtron-labs/devtron/util"
 	"github.com/devtron-labs/devtron/util/rbac"
 	"github.com/devtron-labs/devtron/util/session"
@@ -378,6 +389,18 @@ func InitializeApp() (*App, error) {
 		wire.Bind(new(pipeline.ConfigMapService), new(*pipeline.ConfigMapServiceImpl)),
 		chartConfig.NewConfigMapRepositoryImpl,
 		wire.Bind(new(chartConfig.ConfigMapRepository), new(*chartConfig.ConfigMapRepositoryImpl)),
+		pipeline.NewSecretRotationServiceImpl,
+		wire.Bind(new(pipeline.SecretRotationService), new(*pipeline.SecretRotationServiceImpl)),
+		chartConfig.NewSecretRotationRepositoryImpl,
+		wire.Bind(new(chartConfig.SecretRotationRepository), new(*chartConfig.SecretRotationRepositoryImpl)),
+		pipeline.NewConfigMapHistoryServiceImpl,
+		wire.Bind(new(pipeline.ConfigMapHistoryService), new(*pipeline.ConfigMapHistoryServiceImpl)),
+		chartConfig.NewConfigMapHistoryRepositoryImpl,
+		wire.Bind(new(chartConfig.ConfigMapHistoryRepository), new(*chartConfig.ConfigMapHistoryRepositoryImpl)),
+		pipeline.NewDeploymentTemplateHistoryServiceImpl,
+		wire.Bind(new(pipeline.DeploymentTemplateHistoryService), new(*pipeline.DeploymentTemplateHistoryServiceImpl)),
+		chartConfig.NewDeploymentTemplateHistoryRepositoryImpl,
+		wire.Bind(new(chartConfig.DeploymentTemplateHistoryRepository), new(*chartConfig.DeploymentTemplateHistoryRepositoryImpl)),
 
 		notifier.NewSESNotificationServiceImpl,
 		wire.Bind(new(notifier.SESNotificationService), new(*notifier.SESNotificationServiceImpl)),
@@ -460,6 +483,8 @@ func InitializeApp() (*App, error) {
 		wire.Bind(new(pipeline.WorkflowDagExecutor), new(*pipeline.WorkflowDagExecutorImpl)),
 		appClone.NewAppCloneServiceImpl,
 		wire.Bind(new(appClone.AppCloneService), new(*appClone.AppCloneServiceImpl)),
+		appClone.NewEnvironmentCloneServiceImpl,
+		wire.Bind(new(appClone.EnvironmentCloneService), new(*appClone.EnvironmentCloneServiceImpl)),
 		pipeline.GetCdConfig,
 
 		router.NewDeploymentGroupRouterImpl,
@@ -550,6 +575,21 @@ func InitializeApp() (*App, error) {
 		wire.Bind(new(appstore2.ClusterInstalledAppsRepository), new(*appstore2.ClusterInstalledAppsRepositoryImpl)),
 		terminal.NewTerminalSessionHandlerImpl,
 		wire.Bind(new(terminal.TerminalSessionHandler), new(*terminal.TerminalSessionHandlerImpl)),
+		terminal.GetRecordingConfig,
+		terminal.NewTerminalRecordingServiceImpl,
+		wire.Bind(new(terminal.TerminalRecordingService), new(*terminal.TerminalRecordingServiceImpl)),
+		terminal.NewRecordingStoreImpl,
+		wire.Bind(new(terminal.RecordingStore), new(*terminal.RecordingStoreImpl)),
+		repository5.NewTerminalRecordingRepositoryImpl,
+		wire.Bind(new(repository5.TerminalRecordingRepository), new(*repository5.TerminalRecordingRepositoryImpl)),
+		router.NewTerminalRecordingRouterImpl,
+		wire.Bind(new(router.TerminalRecordingRouter), new(*router.TerminalRecordingRouterImpl)),
+		restHandler.NewTerminalRecordingRestHandlerImpl,
+		wire.Bind(new(restHandler.TerminalRecordingRestHandler), new(*restHandler.TerminalRecordingRestHandlerImpl)),
+		router.NewK8sResourceRouterImpl,
+		wire.Bind(new(router.K8sResourceRouter), new(*router.K8sResourceRouterImpl)),
+		restHandler.NewK8sResourceRestHandlerImpl,
+		wire.Bind(new(restHandler.K8sResourceRestHandler), new(*restHandler.K8sResourceRestHandlerImpl)),
 		argocdServer.NewArgoK8sClientImpl,
 		wire.Bind(new(argocdServer.ArgoK8sClient), new(*argocdServer.ArgoK8sClientImpl)),
 
@@ -600,6 +640,59 @@ func InitializeApp() (*App, error) {
 		restHandler.NewCoreAppRestHandlerImpl,
 		wire.Bind(new(restHandler.CoreAppRestHandler), new(*restHandler.CoreAppRestHandlerImpl)),
 
+		router.NewGlobalVariableRouterImpl,
+		wire.Bind(new(router.GlobalVariableRouter), new(*router.GlobalVariableRouterImpl)),
+
+		pipelineConfig.NewPreviewEnvironmentRepositoryImpl,
+		wire.Bind(new(pipelineConfig.PreviewEnvironmentRepository), new(*pipelineConfig.PreviewEnvironmentRepositoryImpl)),
+		previewEnvironment.NewPreviewEnvironmentServiceImpl,
+		wire.Bind(new(previewEnvironment.PreviewEnvironmentService), new(*previewEnvironment.PreviewEnvironmentServiceImpl)),
+		restHandler.NewPreviewEnvironmentRestHandlerImpl,
+		wire.Bind(new(restHandler.PreviewEnvironmentRestHandler), new(*restHandler.PreviewEnvironmentRestHandlerImpl)),
+		router.NewPreviewEnvironmentRouterImpl,
+		wire.Bind(new(router.PreviewEnvironmentRouter), new(*router.PreviewEnvironmentRouterImpl)),
+
+		repository6.NewClusterCostPriceRepositoryImpl,
+		wire.Bind(new(repository6.ClusterCostPriceRepository), new(*repository6.ClusterCostPriceRepositoryImpl)),
+		repository6.NewCostAllocationRepositoryImpl,
+		wire.Bind(new(repository6.CostAllocationRepository), new(*repository6.CostAllocationRepositoryImpl)),
+		cost.NewCostAllocationServiceImpl,
+		wire.Bind(new(cost.CostAllocationService), new(*cost.CostAllocationServiceImpl)),
+		restHandler.NewCostAllocationRestHandlerImpl,
+		wire.Bind(new(restHandler.CostAllocationRestHandler), new(*restHandler.CostAllocationRestHandlerImpl)),
+		router.NewCostAllocationRouterImpl,
+		wire.Bind(new(router.CostAllocationRouter), new(*router.CostAllocationRouterImpl)),
+
+		repository7.NewHibernationScheduleRepositoryImpl,
+		wire.Bind(new(repository7.HibernationScheduleRepository), new(*repository7.HibernationScheduleRepositoryImpl)),
+		repository7.NewHibernationEventRepositoryImpl,
+		wire.Bind(new(repository7.HibernationEventRepository), new(*repository7.HibernationEventRepositoryImpl)),
+		hibernation.NewHibernationScheduleServiceImpl,
+		wire.Bind(new(hibernation.HibernationScheduleService), new(*hibernation.HibernationScheduleServiceImpl)),
+		restHandler.NewHibernationScheduleRestHandlerImpl,
+		wire.Bind(new(restHandler.HibernationScheduleRestHandler), new(*restHandler.HibernationScheduleRestHandlerImpl)),
+		router.NewHibernationScheduleRouterImpl,
+		wire.Bind(new(router.HibernationScheduleRouter), new(*router.HibernationScheduleRouterImpl)),
+		restHandler.NewGlobalVariableRestHandlerImpl,
+		wire.Bind(new(restHandler.GlobalVariableRestHandler), new(*restHandler.GlobalVariableRestHandlerImpl)),
+		variables.NewVariableServiceImpl,
+		wire.Bind(new(variables.VariableService), new(*variables.VariableServiceImpl)),
+		repository3.NewVariableRepositoryImpl,
+		wire.Bind(new(repository3.VariableRepository), new(*repository3.VariableRepositoryImpl)),
+
+		router.NewAuditLogRouterImpl,
+		wire.Bind(new(router.AuditLogRouter), new(*router.AuditLogRouterImpl)),
+		restHandler.NewAuditLogRestHandlerImpl,
+		wire.Bind(new(restHandler.AuditLogRestHandler), new(*restHandler.AuditLogRestHandlerImpl)),
+		auditLog.NewAuditLogServiceImpl,
+		wire.Bind(new(auditLog.AuditLogService), new(*auditLog.AuditLogServiceImpl)),
+		repository4.NewAuditLogRepositoryImpl,
+		wire.Bind(new(repository4.AuditLogRepository), new(*repository4.AuditLogRepositoryImpl)),
+		middleware.NewAuditLogMiddlewareImpl,
+		wire.Bind(new(middleware.AuditLogMiddleware), new(*middleware.AuditLogMiddlewareImpl)),
+		middleware.NewUserSessionMiddlewareImpl,
+		wire.Bind(new(middleware.UserSessionMiddleware), new(*middleware.UserSessionMiddlewareImpl)),
+
 		// Webhook
 		repository.NewGitHostRepositoryImpl,
 		wire.Bind(new(repository.GitHostRepository), new(*repository.GitHostRepositoryImpl)),
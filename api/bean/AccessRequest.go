@@ -0,0 +1,43 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package bean
+
+import "time"
+
+// AccessRequest asks for the role filter to be granted to the requesting user for DurationMinutes once approved
+type AccessRequest struct {
+	Id              int        `json:"id"`
+	RoleFilter      RoleFilter `json:"roleFilter" validate:"required"`
+	Reason          string     `json:"reason" validate:"required"`
+	DurationMinutes int        `json:"durationMinutes" validate:"required,min=1"`
+	Status          string     `json:"status"`
+	RequestedBy     string     `json:"requestedBy"`
+	ApprovedBy      string     `json:"approvedBy,omitempty"`
+	Comment         string     `json:"comment,omitempty"`
+	ApprovedOn      *time.Time `json:"approvedOn,omitempty"`
+	ExpiresOn       *time.Time `json:"expiresOn,omitempty"`
+	CreatedOn       time.Time  `json:"createdOn"`
+	UserId          int32      `json:"userId"` // requesting user id
+}
+
+// AccessRequestAction approves, rejects or revokes an access request
+type AccessRequestAction struct {
+	Id      int    `json:"id"`
+	Comment string `json:"comment"`
+	UserId  int32  `json:"-"`
+}
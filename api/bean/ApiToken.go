@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package bean
+
+import "time"
+
+type ApiTokenRequest struct {
+	Name        string       `json:"name" validate:"required"`
+	Description string       `json:"description"`
+	ExpiresOn   time.Time    `json:"expiresOn" validate:"required"`
+	RoleFilters []RoleFilter `json:"roleFilters"`
+	UserId      int32        `json:"-"` // created or modified user id
+}
+
+type ApiToken struct {
+	Id          int          `json:"id"`
+	UserId      int32        `json:"userId"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	ExpiresOn   time.Time    `json:"expiresOn"`
+	LastUsedOn  *time.Time   `json:"lastUsedOn,omitempty"`
+	Owner       string       `json:"owner"`
+	CreatedOn   time.Time    `json:"createdOn"`
+	RoleFilters []RoleFilter `json:"roleFilters"`
+	// Token is only returned once, on creation
+	Token string `json:"token,omitempty"`
+}
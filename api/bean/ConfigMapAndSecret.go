@@ -51,6 +51,21 @@ type Map struct {
 	ExternalType   string          `json:"externalType"`
 	RoleARN        string          `json:"roleARN"`
 	SecretData     json.RawMessage `json:"secretData,omitempty"`
+	SecretStore    *SecretStore    `json:"secretStore,omitempty"`
 	SubPath        bool            `json:"subPath"`
 	FilePermission string          `json:"filePermission"`
 }
+
+// SecretStore holds the provider specific settings and credential references used for
+// GCPSecretManager and AzureKeyVault external secrets. Credentials are never stored here,
+// only the name of the service account (workload identity) or the kubernetes secret
+// (service account key / service principal) which already exists in the target namespace.
+type SecretStore struct {
+	ProjectId            string `json:"projectId,omitempty"`
+	ClusterLocation      string `json:"clusterLocation,omitempty"`
+	ClusterName          string `json:"clusterName,omitempty"`
+	VaultUrl             string `json:"vaultUrl,omitempty"`
+	TenantId             string `json:"tenantId,omitempty"`
+	ServiceAccountName   string `json:"serviceAccountName,omitempty"`
+	CredentialSecretName string `json:"credentialSecretName,omitempty"`
+}
@@ -0,0 +1,35 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package bean
+
+// CustomRole is a named bundle of casbin permissions, assigned like the built-in roles by using its name as
+// the action of a team scoped role filter
+type CustomRole struct {
+	Id          int                    `json:"id" validate:"number"`
+	Name        string                 `json:"name" validate:"required,max=100"`
+	Description string                 `json:"description"`
+	Permissions []CustomRolePermission `json:"permissions" validate:"required,min=1,dive"`
+	UserId      int32                  `json:"-"` // created or modified user id
+}
+
+// CustomRolePermission is a casbin resource and action, the object is derived from the team/env/app of the
+// role filter the custom role is assigned with
+type CustomRolePermission struct {
+	Resource string `json:"resource" validate:"required"`
+	Action   string `json:"action" validate:"required"`
+}
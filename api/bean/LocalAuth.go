@@ -0,0 +1,38 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package bean
+
+// LocalPasswordRequest sets the local login password of a user, CurrentPassword is needed when users change
+// their own password
+type LocalPasswordRequest struct {
+	UserId          int32  `json:"userId"`
+	CurrentPassword string `json:"currentPassword,omitempty"`
+	Password        string `json:"password" validate:"required"`
+	ActionUserId    int32  `json:"-"`
+}
+
+// MfaEnrollment is the TOTP secret to add to an authenticator app, OtpAuthUrl is meant to be shown as a QR code
+type MfaEnrollment struct {
+	Secret     string `json:"secret"`
+	OtpAuthUrl string `json:"otpAuthUrl"`
+}
+
+type MfaVerifyRequest struct {
+	Otp    string `json:"otp" validate:"required"`
+	UserId int32  `json:"-"`
+}
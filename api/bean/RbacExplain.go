@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package bean
+
+// RbacExplainRequest asks why a user or api token is allowed or denied. Without emailId and apiTokenId the
+// logged in user is explained, appId adds checks of every app and environment level permission of the app
+type RbacExplainRequest struct {
+	EmailId    string      `json:"emailId"`
+	ApiTokenId int         `json:"apiTokenId"`
+	AppId      int         `json:"appId"`
+	Checks     []RbacCheck `json:"checks" validate:"dive"`
+	UserId     int32       `json:"-"` // logged in user id
+}
+
+// RbacCheck is a casbin request, object being the team/app, env/app, team or env name as built by EnforcerUtil
+type RbacCheck struct {
+	Resource string `json:"resource" validate:"required"`
+	Action   string `json:"action" validate:"required"`
+	Object   string `json:"object" validate:"required"`
+}
+
+type RbacExplainResponse struct {
+	EmailId string             `json:"emailId"`
+	Roles   []RbacExplainRole  `json:"roles"`
+	Results []*RbacCheckResult `json:"results"`
+}
+
+// RbacExplainRole is a casbin role held by the subject, directly or through a role group
+type RbacExplainRole struct {
+	Role      string `json:"role"`
+	RoleGroup string `json:"roleGroup,omitempty"`
+}
+
+// RbacCheckResult lists the allow and deny policies matching a check, a matching deny policy overrides every
+// allow policy and is reported in deniedBy
+type RbacCheckResult struct {
+	RbacCheck
+	Allowed         bool                `json:"allowed"`
+	MatchedPolicies []RbacMatchedPolicy `json:"matchedPolicies"`
+	DeniedBy        []RbacMatchedPolicy `json:"deniedBy,omitempty"`
+}
+
+type RbacMatchedPolicy struct {
+	Role      string `json:"role"`
+	RoleGroup string `json:"roleGroup,omitempty"`
+	Resource  string `json:"resource"`
+	Action    string `json:"action"`
+	Object    string `json:"object"`
+	Effect    string `json:"effect"`
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package bean
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	ScimUserSchema        = "urn:ietf:params:scim:schemas:core:2.0:User"
+	ScimGroupSchema       = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	ScimListSchema        = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	ScimPatchSchema       = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	ScimErrorSchema       = "urn:ietf:params:scim:api:messages:2.0:Error"
+	ScimSpConfigSchema    = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	ScimContentType       = "application/scim+json"
+	ScimTypeUniqueness    = "uniqueness"
+	ScimTypeMutability    = "mutability"
+	ScimTypeInvalidFilter = "invalidFilter"
+	ScimTypeInvalidValue  = "invalidValue"
+	ScimTypeInvalidPath   = "invalidPath"
+)
+
+type ScimMeta struct {
+	ResourceType string `json:"resourceType"`
+	Location     string `json:"location,omitempty"`
+}
+
+type ScimEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type ScimMember struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type ScimUser struct {
+	Schemas    []string     `json:"schemas"`
+	Id         string       `json:"id,omitempty"`
+	ExternalId string       `json:"externalId,omitempty"`
+	UserName   string       `json:"userName"`
+	Emails     []ScimEmail  `json:"emails,omitempty"`
+	Active     *bool        `json:"active,omitempty"`
+	Groups     []ScimMember `json:"groups,omitempty"`
+	Meta       *ScimMeta    `json:"meta,omitempty"`
+}
+
+type ScimGroup struct {
+	Schemas     []string     `json:"schemas"`
+	Id          string       `json:"id,omitempty"`
+	ExternalId  string       `json:"externalId,omitempty"`
+	DisplayName string       `json:"displayName"`
+	Members     []ScimMember `json:"members"`
+	Meta        *ScimMeta    `json:"meta,omitempty"`
+}
+
+type ScimListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+type ScimPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type ScimPatchRequest struct {
+	Schemas    []string              `json:"schemas"`
+	Operations []*ScimPatchOperation `json:"Operations"`
+}
+
+// ScimError is returned by scim apis in the error format of rfc7644
+type ScimError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+func NewScimError(status int, scimType string, detail string) *ScimError {
+	return &ScimError{Schemas: []string{ScimErrorSchema}, Status: fmt.Sprint(status), ScimType: scimType, Detail: detail}
+}
+
+func (e *ScimError) Error() string {
+	return e.Detail
+}
@@ -0,0 +1,26 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package bean
+
+type SsoGroupMapping struct {
+	Id            int    `json:"id"`
+	SsoGroup      string `json:"ssoGroup" validate:"required"`
+	RoleGroupId   int32  `json:"roleGroupId" validate:"required"`
+	RoleGroupName string `json:"roleGroupName,omitempty"`
+	UserId        int32  `json:"-"` // created or modified user id
+}
@@ -55,6 +55,14 @@ type RoleFilter struct {
 	Action      string `json:"action"`
 }
 
+// fine grained actions of team scoped role filters, next to manager, admin, trigger and view. Each grants view
+// access plus a single permission on secret values, deployment templates or environment overrides
+const (
+	RoleActionSecretView   = "secret-view"
+	RoleActionTemplateEdit = "template-edit"
+	RoleActionOverrideEdit = "override-edit"
+)
+
 type Role struct {
 	Id   int    `json:"id" validate:"number"`
 	Role string `json:"role" validate:"required"`
@@ -0,0 +1,32 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package bean
+
+import "time"
+
+// UserSession is an active login of a user, Current marks the session of the request listing it
+type UserSession struct {
+	Id         int        `json:"id"`
+	UserId     int32      `json:"userId"`
+	Ip         string     `json:"ip"`
+	UserAgent  string     `json:"userAgent"`
+	IssuedOn   *time.Time `json:"issuedOn,omitempty"`
+	ExpiresOn  *time.Time `json:"expiresOn,omitempty"`
+	LastSeenOn time.Time  `json:"lastSeenOn"`
+	Current    bool       `json:"current"`
+}
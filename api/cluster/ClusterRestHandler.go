@@ -43,28 +43,41 @@ type ClusterRestHandler interface {
 	Update(w http.ResponseWriter, r *http.Request)
 
 	FindAllForAutoComplete(w http.ResponseWriter, r *http.Request)
+
+	GetClustersHealth(w http.ResponseWriter, r *http.Request)
+	GetClusterHealthHistory(w http.ResponseWriter, r *http.Request)
+	ProbeCluster(w http.ResponseWriter, r *http.Request)
+
+	GetKubeconfigContexts(w http.ResponseWriter, r *http.Request)
+	ImportKubeconfig(w http.ResponseWriter, r *http.Request)
 }
 
 type ClusterRestHandlerImpl struct {
-	clusterService cluster.ClusterService
-	logger         *zap.SugaredLogger
-	userService    user.UserService
-	validator      *validator.Validate
-	enforcer       casbin.Enforcer
+	clusterService          cluster.ClusterService
+	clusterHealthService    cluster.ClusterHealthService
+	kubeconfigImportService cluster.KubeconfigImportService
+	logger                  *zap.SugaredLogger
+	userService             user.UserService
+	validator               *validator.Validate
+	enforcer                casbin.Enforcer
 }
 
 func NewClusterRestHandlerImpl(clusterService cluster.ClusterService,
+	clusterHealthService cluster.ClusterHealthService,
+	kubeconfigImportService cluster.KubeconfigImportService,
 	logger *zap.SugaredLogger,
 	userService user.UserService,
 	validator *validator.Validate,
 	enforcer casbin.Enforcer,
 ) *ClusterRestHandlerImpl {
 	return &ClusterRestHandlerImpl{
-		clusterService: clusterService,
-		logger:         logger,
-		userService:    userService,
-		validator:      validator,
-		enforcer:       enforcer,
+		clusterService:          clusterService,
+		clusterHealthService:    clusterHealthService,
+		kubeconfigImportService: kubeconfigImportService,
+		logger:                  logger,
+		userService:             userService,
+		validator:               validator,
+		enforcer:                enforcer,
 	}
 }
 
@@ -284,3 +297,160 @@ func (impl ClusterRestHandlerImpl) FindAllForAutoComplete(w http.ResponseWriter,
 	}
 	common.WriteJsonResp(w, err, result, http.StatusOK)
 }
+
+func (impl ClusterRestHandlerImpl) GetClustersHealth(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("token")
+	healthList, err := impl.clusterHealthService.GetClustersHealth()
+	if err != nil {
+		impl.logger.Errorw("service err, GetClustersHealth", "err", err)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+
+	// RBAC enforcer applying
+	result := make([]*cluster.ClusterHealthBean, 0, len(healthList))
+	for _, item := range healthList {
+		if ok := impl.enforcer.Enforce(token, casbin.ResourceCluster, casbin.ActionGet, strings.ToLower(item.ClusterName)); ok {
+			result = append(result, item)
+		}
+	}
+	//RBAC enforcer Ends
+
+	common.WriteJsonResp(w, nil, result, http.StatusOK)
+}
+
+func (impl ClusterRestHandlerImpl) GetClusterHealthHistory(w http.ResponseWriter, r *http.Request) {
+	clusterId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		impl.logger.Errorw("request err, GetClusterHealthHistory", "err", err, "clusterId", mux.Vars(r)["id"])
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	size := 0
+	if sizeParam := r.URL.Query().Get("size"); len(sizeParam) > 0 {
+		size, err = strconv.Atoi(sizeParam)
+		if err != nil {
+			common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+			return
+		}
+	}
+	if !impl.enforceCluster(w, r, clusterId, casbin.ActionGet) {
+		return
+	}
+	history, err := impl.clusterHealthService.GetClusterHealthHistory(clusterId, size)
+	if err != nil {
+		impl.logger.Errorw("service err, GetClusterHealthHistory", "err", err, "clusterId", clusterId)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, history, http.StatusOK)
+}
+
+func (impl ClusterRestHandlerImpl) ProbeCluster(w http.ResponseWriter, r *http.Request) {
+	clusterId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		impl.logger.Errorw("request err, ProbeCluster", "err", err, "clusterId", mux.Vars(r)["id"])
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	// a probe records a check and may notify, so it needs the same access as editing the cluster
+	if !impl.enforceCluster(w, r, clusterId, casbin.ActionUpdate) {
+		return
+	}
+	health, err := impl.clusterHealthService.ProbeCluster(clusterId)
+	if err != nil {
+		impl.logger.Errorw("service err, ProbeCluster", "err", err, "clusterId", clusterId)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, health, http.StatusOK)
+}
+
+// enforceCluster writes the error response and returns false when the user cannot perform action on the cluster
+func (impl ClusterRestHandlerImpl) enforceCluster(w http.ResponseWriter, r *http.Request, clusterId int, action string) bool {
+	bean, err := impl.clusterService.FindById(clusterId)
+	if err != nil {
+		impl.logger.Errorw("service err, FindById", "err", err, "clusterId", clusterId)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return false
+	}
+	token := r.Header.Get("token")
+	if ok := impl.enforcer.Enforce(token, casbin.ResourceCluster, action, strings.ToLower(bean.ClusterName)); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func (impl ClusterRestHandlerImpl) GetKubeconfigContexts(w http.ResponseWriter, r *http.Request) {
+	userId, err := impl.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	var request cluster.KubeconfigRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		impl.logger.Errorw("request err, GetKubeconfigContexts", "err", err)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	err = impl.validator.Struct(request)
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+
+	// RBAC enforcer applying
+	token := r.Header.Get("token")
+	if ok := impl.enforcer.Enforce(token, casbin.ResourceCluster, casbin.ActionCreate, "*"); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+	//RBAC enforcer Ends
+
+	contexts, err := impl.kubeconfigImportService.GetContexts(request.Kubeconfig)
+	if err != nil {
+		impl.logger.Errorw("service err, GetKubeconfigContexts", "err", err)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, contexts, http.StatusOK)
+}
+
+func (impl ClusterRestHandlerImpl) ImportKubeconfig(w http.ResponseWriter, r *http.Request) {
+	userId, err := impl.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	var request cluster.KubeconfigImportRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		impl.logger.Errorw("request err, ImportKubeconfig", "err", err)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	err = impl.validator.Struct(request)
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+
+	// RBAC enforcer applying
+	token := r.Header.Get("token")
+	if ok := impl.enforcer.Enforce(token, casbin.ResourceCluster, casbin.ActionCreate, "*"); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+	//RBAC enforcer Ends
+
+	ctx := context.WithValue(r.Context(), "token", token)
+	results, err := impl.kubeconfigImportService.ImportContexts(ctx, &request, userId)
+	if err != nil {
+		impl.logger.Errorw("service err, ImportKubeconfig", "err", err)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, results, http.StatusOK)
+}
@@ -57,4 +57,24 @@ func (impl ClusterRouterImpl) InitClusterRouter(clusterRouter *mux.Router) {
 		Methods("GET").
 		HandlerFunc(impl.clusterRestHandler.FindAllForAutoComplete)
 
+	clusterRouter.Path("/health").
+		Methods("GET").
+		HandlerFunc(impl.clusterRestHandler.GetClustersHealth)
+
+	clusterRouter.Path("/{id}/health/history").
+		Methods("GET").
+		HandlerFunc(impl.clusterRestHandler.GetClusterHealthHistory)
+
+	clusterRouter.Path("/{id}/health/probe").
+		Methods("POST").
+		HandlerFunc(impl.clusterRestHandler.ProbeCluster)
+
+	clusterRouter.Path("/kubeconfig/contexts").
+		Methods("POST").
+		HandlerFunc(impl.clusterRestHandler.GetKubeconfigContexts)
+
+	clusterRouter.Path("/kubeconfig/import").
+		Methods("POST").
+		HandlerFunc(impl.clusterRestHandler.ImportKubeconfig)
+
 }
@@ -20,9 +20,11 @@ package cluster
 import (
 	"encoding/json"
 	"github.com/devtron-labs/devtron/api/restHandler/common"
+	"github.com/devtron-labs/devtron/pkg/appClone"
 	request "github.com/devtron-labs/devtron/pkg/cluster"
 	"github.com/devtron-labs/devtron/pkg/user"
 	"github.com/devtron-labs/devtron/pkg/user/casbin"
+	"github.com/devtron-labs/devtron/util/rbac"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
@@ -41,6 +43,8 @@ type EnvironmentRestHandler interface {
 	Update(w http.ResponseWriter, r *http.Request)
 	FindById(w http.ResponseWriter, r *http.Request)
 	GetEnvironmentListForAutocomplete(w http.ResponseWriter, r *http.Request)
+	CloneEnvironment(w http.ResponseWriter, r *http.Request)
+	GetNamespaceDrift(w http.ResponseWriter, r *http.Request)
 }
 
 type EnvironmentRestHandlerImpl struct {
@@ -49,16 +53,24 @@ type EnvironmentRestHandlerImpl struct {
 	userService                       user.UserService
 	validator                         *validator.Validate
 	enforcer                          casbin.Enforcer
+	enforcerUtil                      rbac.EnforcerUtil
+	environmentCloneService           appClone.EnvironmentCloneService
+	namespaceConfigService            request.NamespaceConfigService
 }
 
 func NewEnvironmentRestHandlerImpl(svc request.EnvironmentService, logger *zap.SugaredLogger, userService user.UserService,
-	validator *validator.Validate, enforcer casbin.Enforcer) *EnvironmentRestHandlerImpl {
+	validator *validator.Validate, enforcer casbin.Enforcer, enforcerUtil rbac.EnforcerUtil,
+	environmentCloneService appClone.EnvironmentCloneService,
+	namespaceConfigService request.NamespaceConfigService) *EnvironmentRestHandlerImpl {
 	return &EnvironmentRestHandlerImpl{
 		environmentClusterMappingsService: svc,
 		logger:                            logger,
 		userService:                       userService,
 		validator:                         validator,
 		enforcer:                          enforcer,
+		enforcerUtil:                      enforcerUtil,
+		environmentCloneService:           environmentCloneService,
+		namespaceConfigService:            namespaceConfigService,
 	}
 }
 
@@ -284,3 +296,111 @@ func (impl EnvironmentRestHandlerImpl) GetEnvironmentListForAutocomplete(w http.
 	}
 	common.WriteJsonResp(w, err, grantedEnvironment, http.StatusOK)
 }
+
+func (impl EnvironmentRestHandlerImpl) CloneEnvironment(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	userId, err := impl.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	var bean appClone.EnvironmentCloneRequest
+	err = decoder.Decode(&bean)
+	if err != nil {
+		impl.logger.Errorw("request err, CloneEnvironment", "err", err, "payload", bean)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	bean.UserId = userId
+	impl.logger.Infow("request payload, CloneEnvironment", "payload", bean)
+	err = impl.validator.Struct(bean)
+	if err != nil {
+		impl.logger.Errorw("validation err, CloneEnvironment", "err", err, "payload", bean)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	if !impl.validateNamespace(bean.Namespace) {
+		impl.logger.Errorw("validation err, CloneEnvironment", "namespace", bean.Namespace)
+		common.WriteJsonResp(w, errors.New("invalid ns"), nil, http.StatusBadRequest)
+		return
+	}
+
+	// RBAC enforcer applying
+	token := r.Header.Get("token")
+	if ok := impl.enforcer.Enforce(token, casbin.ResourceGlobalEnvironment, casbin.ActionCreate, "*"); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+	// the pipelines are created in every cloned app, so the dry run decides which apps need to be checked
+	dryRun := bean.DryRun
+	bean.DryRun = true
+	plan, err := impl.environmentCloneService.CloneEnvironment(r.Context(), &bean)
+	if err != nil {
+		impl.logger.Errorw("service err, CloneEnvironment", "err", err, "payload", bean)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	rbacObjects := impl.enforcerUtil.GetRbacObjectsForAllApps()
+	for _, app := range plan.Apps {
+		if ok := impl.enforcer.Enforce(token, casbin.ResourceApplications, casbin.ActionCreate, rbacObjects[app.AppId]); !ok {
+			common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+			return
+		}
+		// secret values are read from the source environment and written to the new one
+		if len(app.Secrets) > 0 {
+			if ok := impl.enforcer.Enforce(token, casbin.ResourceSecret, casbin.ActionGet, rbacObjects[app.AppId]); !ok {
+				common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+				return
+			}
+			if ok := impl.enforcer.Enforce(token, casbin.ResourceSecret, casbin.ActionUpdate, rbacObjects[app.AppId]); !ok {
+				common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+				return
+			}
+		}
+	}
+	//RBAC enforcer Ends
+
+	if dryRun {
+		common.WriteJsonResp(w, nil, plan, http.StatusOK)
+		return
+	}
+	bean.DryRun = false
+	res, err := impl.environmentCloneService.CloneEnvironment(r.Context(), &bean)
+	if err != nil {
+		impl.logger.Errorw("service err, CloneEnvironment", "err", err, "payload", bean)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+func (impl EnvironmentRestHandlerImpl) GetNamespaceDrift(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	envId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	bean, err := impl.environmentClusterMappingsService.FindById(envId)
+	if err != nil {
+		impl.logger.Errorw("service err, GetNamespaceDrift", "err", err, "envId", envId)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+
+	// RBAC enforcer applying
+	token := r.Header.Get("token")
+	if ok := impl.enforcer.Enforce(token, casbin.ResourceGlobalEnvironment, casbin.ActionGet, strings.ToLower(bean.Environment)); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+	//RBAC enforcer Ends
+
+	res, err := impl.namespaceConfigService.GetDrift(envId)
+	if err != nil {
+		impl.logger.Errorw("service err, GetNamespaceDrift", "err", err, "envId", envId)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
@@ -62,5 +62,12 @@ func (impl EnvironmentRouterImpl) InitEnvironmentClusterMappingsRouter(environme
 	environmentClusterMappingsRouter.Path("/autocomplete").
 		Methods("GET").
 		HandlerFunc(impl.environmentClusterMappingsRestHandler.GetEnvironmentListForAutocomplete)
+	environmentClusterMappingsRouter.Path("/clone").
+		Methods("POST").
+		HandlerFunc(impl.environmentClusterMappingsRestHandler.CloneEnvironment)
+	environmentClusterMappingsRouter.Path("/namespace/drift").
+		Methods("GET").
+		Queries("id", "{id}").
+		HandlerFunc(impl.environmentClusterMappingsRestHandler.GetNamespaceDrift)
 
 }
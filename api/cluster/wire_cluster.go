@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"github.com/devtron-labs/devtron/pkg/app"
 	"github.com/devtron-labs/devtron/pkg/cluster"
 	"github.com/devtron-labs/devtron/pkg/cluster/repository"
 	"github.com/google/wire"
@@ -13,6 +14,12 @@ var ClusterWireSet = wire.NewSet(
 	wire.Bind(new(repository.ClusterRepository), new(*repository.ClusterRepositoryImpl)),
 	cluster.NewClusterServiceImplExtended,
 	wire.Bind(new(cluster.ClusterService), new(*cluster.ClusterServiceImplExtended)),
+	repository.NewClusterHealthRepositoryImpl,
+	wire.Bind(new(repository.ClusterHealthRepository), new(*repository.ClusterHealthRepositoryImpl)),
+	cluster.NewClusterHealthServiceImpl,
+	wire.Bind(new(cluster.ClusterHealthService), new(*cluster.ClusterHealthServiceImpl)),
+	cluster.NewKubeconfigImportServiceImpl,
+	wire.Bind(new(cluster.KubeconfigImportService), new(*cluster.KubeconfigImportServiceImpl)),
 	NewClusterRestHandlerImpl,
 	wire.Bind(new(ClusterRestHandler), new(*ClusterRestHandlerImpl)),
 	NewClusterRouterImpl,
@@ -22,10 +29,18 @@ var ClusterWireSet = wire.NewSet(
 	wire.Bind(new(repository.EnvironmentRepository), new(*repository.EnvironmentRepositoryImpl)),
 	cluster.NewEnvironmentServiceImpl,
 	wire.Bind(new(cluster.EnvironmentService), new(*cluster.EnvironmentServiceImpl)),
+	repository.NewEnvironmentNamespaceConfigRepositoryImpl,
+	wire.Bind(new(repository.EnvironmentNamespaceConfigRepository), new(*repository.EnvironmentNamespaceConfigRepositoryImpl)),
+	cluster.NewNamespaceConfigServiceImpl,
+	wire.Bind(new(cluster.NamespaceConfigService), new(*cluster.NamespaceConfigServiceImpl)),
+	wire.Bind(new(app.NamespaceReconciler), new(*cluster.NamespaceConfigServiceImpl)),
 	NewEnvironmentRestHandlerImpl,
 	wire.Bind(new(EnvironmentRestHandler), new(*EnvironmentRestHandlerImpl)),
 	NewEnvironmentRouterImpl,
 	wire.Bind(new(EnvironmentRouter), new(*EnvironmentRouterImpl)),
+
+	cluster.NewK8sResourceServiceImpl,
+	wire.Bind(new(cluster.K8sResourceService), new(*cluster.K8sResourceServiceImpl)),
 )
 
 //minimal wire to be used with EA
@@ -34,6 +49,12 @@ var ClusterWireSetEa = wire.NewSet(
 	wire.Bind(new(repository.ClusterRepository), new(*repository.ClusterRepositoryImpl)),
 	cluster.NewClusterServiceImpl,
 	wire.Bind(new(cluster.ClusterService), new(*cluster.ClusterServiceImpl)),
+	repository.NewClusterHealthRepositoryImpl,
+	wire.Bind(new(repository.ClusterHealthRepository), new(*repository.ClusterHealthRepositoryImpl)),
+	cluster.NewClusterHealthServiceImpl,
+	wire.Bind(new(cluster.ClusterHealthService), new(*cluster.ClusterHealthServiceImpl)),
+	cluster.NewKubeconfigImportServiceImpl,
+	wire.Bind(new(cluster.KubeconfigImportService), new(*cluster.KubeconfigImportServiceImpl)),
 	NewClusterRestHandlerImpl,
 	wire.Bind(new(ClusterRestHandler), new(*ClusterRestHandlerImpl)),
 	NewClusterRouterImpl,
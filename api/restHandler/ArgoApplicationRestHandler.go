@@ -28,6 +28,7 @@ import (
 	"github.com/devtron-labs/devtron/pkg/cluster"
 	"github.com/devtron-labs/devtron/pkg/team"
 	"github.com/devtron-labs/devtron/pkg/terminal"
+	"github.com/devtron-labs/devtron/pkg/user"
 	"github.com/devtron-labs/devtron/pkg/user/casbin"
 	"github.com/devtron-labs/devtron/util"
 	"github.com/devtron-labs/devtron/util/rbac"
@@ -71,6 +72,7 @@ type ArgoApplicationRestHandlerImpl struct {
 	environmentService     cluster.EnvironmentService
 	enforcerUtil           rbac.EnforcerUtil
 	terminalSessionHandler terminal.TerminalSessionHandler
+	userService            user.UserService
 }
 
 func NewArgoApplicationRestHandlerImpl(client application.ServiceClient,
@@ -80,7 +82,8 @@ func NewArgoApplicationRestHandlerImpl(client application.ServiceClient,
 	environmentService cluster.EnvironmentService,
 	logger *zap.SugaredLogger,
 	enforcerUtil rbac.EnforcerUtil,
-	terminalSessionHandler terminal.TerminalSessionHandler) *ArgoApplicationRestHandlerImpl {
+	terminalSessionHandler terminal.TerminalSessionHandler,
+	userService user.UserService) *ArgoApplicationRestHandlerImpl {
 	return &ArgoApplicationRestHandlerImpl{
 		client:                 client,
 		logger:                 logger,
@@ -90,12 +93,18 @@ func NewArgoApplicationRestHandlerImpl(client application.ServiceClient,
 		environmentService:     environmentService,
 		enforcerUtil:           enforcerUtil,
 		terminalSessionHandler: terminalSessionHandler,
+		userService:            userService,
 	}
 }
 
 func (impl ArgoApplicationRestHandlerImpl) GetTerminalSession(w http.ResponseWriter, r *http.Request) {
+	userId, err := impl.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
 	token := r.Header.Get("token")
-	request := &terminal.TerminalSessionRequest{}
+	request := &terminal.TerminalSessionRequest{UserId: userId}
 	vars := mux.Vars(r)
 	request.ContainerName = vars["container"]
 	request.Namespace = vars["namespace"]
@@ -134,6 +143,19 @@ func (impl ArgoApplicationRestHandlerImpl) GetTerminalSession(w http.ResponseWri
 		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
 		return
 	}
+	// debug containers run an image of the user's choice in the pod, which needs its own action
+	if debug, _ := strconv.ParseBool(r.URL.Query().Get("debug")); debug {
+		if ok := impl.enforcer.Enforce(token, casbin.ResourceApplications, casbin.ActionDebug, appRbacObject); !ok {
+			common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
+			return
+		}
+		if ok := impl.enforcer.Enforce(token, casbin.ResourceEnvironment, casbin.ActionDebug, envRbacObject); !ok {
+			common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
+			return
+		}
+		request.Debug = true
+		request.DebugImage = r.URL.Query().Get("image")
+	}
 	//---------auth end
 	//TODO apply validation
 	status, message, err := impl.terminalSessionHandler.GetTerminalSession(request)
@@ -593,11 +615,16 @@ func (impl ArgoApplicationRestHandlerImpl) DeleteResource(w http.ResponseWriter,
 		common.WriteJsonResp(w, fmt.Errorf("envId is incorrect"), nil, http.StatusBadRequest)
 		return
 	}
-	if ok := impl.enforcer.Enforce(token, casbin.ResourceApplications, casbin.ActionTrigger, appRbacObject); !ok {
+	// deleting a pod restarts it, which roles granting restart without trigger are allowed to do
+	action := casbin.ActionTrigger
+	if kind == "Pod" && !impl.enforcer.Enforce(token, casbin.ResourceApplications, casbin.ActionTrigger, appRbacObject) {
+		action = casbin.ActionRestart
+	}
+	if ok := impl.enforcer.Enforce(token, casbin.ResourceApplications, action, appRbacObject); !ok {
 		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
 		return
 	}
-	if ok := impl.enforcer.Enforce(token, casbin.ResourceEnvironment, casbin.ActionTrigger, envRbacObject); !ok {
+	if ok := impl.enforcer.Enforce(token, casbin.ResourceEnvironment, action, envRbacObject); !ok {
 		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
 		return
 	}
@@ -0,0 +1,153 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package restHandler
+
+import (
+	"errors"
+	"github.com/devtron-labs/devtron/api/restHandler/common"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
+	"github.com/devtron-labs/devtron/pkg/auditLog/repository"
+	"github.com/devtron-labs/devtron/pkg/user"
+	"github.com/devtron-labs/devtron/pkg/user/casbin"
+	"github.com/go-pg/pg"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type AuditLogRestHandler interface {
+	GetAuditLogs(w http.ResponseWriter, r *http.Request)
+	GetAuditLogById(w http.ResponseWriter, r *http.Request)
+}
+
+type AuditLogRestHandlerImpl struct {
+	logger          *zap.SugaredLogger
+	enforcer        casbin.Enforcer
+	userService     user.UserService
+	auditLogService auditLog.AuditLogService
+}
+
+func NewAuditLogRestHandlerImpl(logger *zap.SugaredLogger, enforcer casbin.Enforcer,
+	userService user.UserService, auditLogService auditLog.AuditLogService) *AuditLogRestHandlerImpl {
+	return &AuditLogRestHandlerImpl{
+		logger:          logger,
+		enforcer:        enforcer,
+		userService:     userService,
+		auditLogService: auditLogService,
+	}
+}
+
+func (handler AuditLogRestHandlerImpl) GetAuditLogs(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	token := r.Header.Get("token")
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceGlobal, casbin.ActionGet, "*"); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+
+	filter, err := parseAuditLogFilter(r)
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	res, err := handler.auditLogService.GetAuditLogs(filter)
+	if err != nil {
+		handler.logger.Errorw("service err, GetAuditLogs", "err", err, "filter", filter)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+func (handler AuditLogRestHandlerImpl) GetAuditLogById(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	token := r.Header.Get("token")
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceGlobal, casbin.ActionGet, "*"); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+
+	res, err := handler.auditLogService.GetAuditLogById(id)
+	if err == pg.ErrNoRows {
+		common.WriteJsonResp(w, err, nil, http.StatusNotFound)
+		return
+	} else if err != nil {
+		handler.logger.Errorw("service err, GetAuditLogById", "err", err, "id", id)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+func parseAuditLogFilter(r *http.Request) (*repository.AuditLogFilter, error) {
+	v := r.URL.Query()
+	filter := &repository.AuditLogFilter{
+		ResourceType: v.Get("resourceType"),
+		ResourceId:   v.Get("resourceId"),
+		Action:       v.Get("action"),
+	}
+	var err error
+	if userId := v.Get("userId"); userId != "" {
+		id, err := strconv.ParseInt(userId, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		filter.UserId = int32(id)
+	}
+	if appId := v.Get("appId"); appId != "" {
+		if filter.AppId, err = strconv.Atoi(appId); err != nil {
+			return nil, err
+		}
+	}
+	if from := v.Get("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return nil, err
+		}
+	}
+	if to := v.Get("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return nil, err
+		}
+	}
+	if offset := v.Get("offset"); offset != "" {
+		if filter.Offset, err = strconv.Atoi(offset); err != nil {
+			return nil, err
+		}
+	}
+	if size := v.Get("size"); size != "" {
+		if filter.Size, err = strconv.Atoi(size); err != nil {
+			return nil, err
+		}
+	}
+	return filter, nil
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"github.com/devtron-labs/devtron/api/bean"
 	"github.com/devtron-labs/devtron/api/restHandler/common"
+	"github.com/devtron-labs/devtron/internal/sql/repository/chartConfig"
 	"github.com/devtron-labs/devtron/internal/sql/repository/pipelineConfig"
 	"github.com/devtron-labs/devtron/pkg/pipeline"
 	"github.com/devtron-labs/devtron/pkg/team"
@@ -53,34 +54,49 @@ type ConfigMapRestHandler interface {
 	CSGlobalFetchForEdit(w http.ResponseWriter, r *http.Request)
 	CSEnvironmentFetchForEdit(w http.ResponseWriter, r *http.Request)
 	ConfigSecretBulkPatch(w http.ResponseWriter, r *http.Request)
+
+	CSRotate(w http.ResponseWriter, r *http.Request)
+	CSRotationCancel(w http.ResponseWriter, r *http.Request)
+	CSRotationHistory(w http.ResponseWriter, r *http.Request)
+
+	ConfigHistoryList(w http.ResponseWriter, r *http.Request)
+	ConfigHistoryGet(w http.ResponseWriter, r *http.Request)
+	ConfigHistoryDiff(w http.ResponseWriter, r *http.Request)
+	ConfigHistoryRestore(w http.ResponseWriter, r *http.Request)
 }
 
 type ConfigMapRestHandlerImpl struct {
-	pipelineBuilder    pipeline.PipelineBuilder
-	Logger             *zap.SugaredLogger
-	chartService       pipeline.ChartService
-	userAuthService    user.UserService
-	teamService        team.TeamService
-	enforcer           casbin.Enforcer
-	pipelineRepository pipelineConfig.PipelineRepository
-	enforcerUtil       rbac.EnforcerUtil
-	configMapService   pipeline.ConfigMapService
+	pipelineBuilder         pipeline.PipelineBuilder
+	Logger                  *zap.SugaredLogger
+	chartService            pipeline.ChartService
+	userAuthService         user.UserService
+	teamService             team.TeamService
+	enforcer                casbin.Enforcer
+	pipelineRepository      pipelineConfig.PipelineRepository
+	enforcerUtil            rbac.EnforcerUtil
+	configMapService        pipeline.ConfigMapService
+	secretRotationService   pipeline.SecretRotationService
+	configMapHistoryService pipeline.ConfigMapHistoryService
 }
 
 func NewConfigMapRestHandlerImpl(pipelineBuilder pipeline.PipelineBuilder, Logger *zap.SugaredLogger,
 	chartService pipeline.ChartService, userAuthService user.UserService, teamService team.TeamService,
 	enforcer casbin.Enforcer, pipelineRepository pipelineConfig.PipelineRepository,
-	enforcerUtil rbac.EnforcerUtil, configMapService pipeline.ConfigMapService) *ConfigMapRestHandlerImpl {
+	enforcerUtil rbac.EnforcerUtil, configMapService pipeline.ConfigMapService,
+	secretRotationService pipeline.SecretRotationService,
+	configMapHistoryService pipeline.ConfigMapHistoryService) *ConfigMapRestHandlerImpl {
 	return &ConfigMapRestHandlerImpl{
-		pipelineBuilder:    pipelineBuilder,
-		Logger:             Logger,
-		chartService:       chartService,
-		userAuthService:    userAuthService,
-		teamService:        teamService,
-		enforcer:           enforcer,
-		pipelineRepository: pipelineRepository,
-		enforcerUtil:       enforcerUtil,
-		configMapService:   configMapService,
+		pipelineBuilder:         pipelineBuilder,
+		Logger:                  Logger,
+		chartService:            chartService,
+		userAuthService:         userAuthService,
+		teamService:             teamService,
+		enforcer:                enforcer,
+		pipelineRepository:      pipelineRepository,
+		enforcerUtil:            enforcerUtil,
+		configMapService:        configMapService,
+		secretRotationService:   secretRotationService,
+		configMapHistoryService: configMapHistoryService,
 	}
 }
 
@@ -139,13 +155,8 @@ func (handler ConfigMapRestHandlerImpl) CMEnvironmentAddUpdate(w http.ResponseWr
 
 	//RBAC START
 	token := r.Header.Get("token")
-	object := handler.enforcerUtil.GetAppRBACNameByAppId(configMapRequest.AppId)
-	if ok := handler.enforcer.Enforce(token, casbin.ResourceApplications, casbin.ActionCreate, object); !ok {
-		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
-		return
-	}
-	object = handler.enforcerUtil.GetEnvRBACNameByAppId(configMapRequest.AppId, configMapRequest.EnvironmentId)
-	if ok := handler.enforcer.Enforce(token, casbin.ResourceEnvironment, casbin.ActionCreate, object); !ok {
+	object := handler.enforcerUtil.GetEnvRBACNameByAppId(configMapRequest.AppId, configMapRequest.EnvironmentId)
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceEnvOverride, casbin.ActionCreate, object); !ok {
 		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
 		return
 	}
@@ -255,6 +266,10 @@ func (handler ConfigMapRestHandlerImpl) CSGlobalAddUpdate(w http.ResponseWriter,
 		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
 		return
 	}
+	if ok := handler.enforceSecretUpdate(token, configMapRequest.AppId, 0); !ok {
+		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
+		return
+	}
 	//RBAC END
 
 	res, err := handler.configMapService.CSGlobalAddUpdate(&configMapRequest)
@@ -286,13 +301,12 @@ func (handler ConfigMapRestHandlerImpl) CSEnvironmentAddUpdate(w http.ResponseWr
 
 	//RBAC START
 	token := r.Header.Get("token")
-	object := handler.enforcerUtil.GetAppRBACNameByAppId(configMapRequest.AppId)
-	if ok := handler.enforcer.Enforce(token, casbin.ResourceApplications, casbin.ActionCreate, object); !ok {
+	object := handler.enforcerUtil.GetEnvRBACNameByAppId(configMapRequest.AppId, configMapRequest.EnvironmentId)
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceEnvOverride, casbin.ActionCreate, object); !ok {
 		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
 		return
 	}
-	object = handler.enforcerUtil.GetEnvRBACNameByAppId(configMapRequest.AppId, configMapRequest.EnvironmentId)
-	if ok := handler.enforcer.Enforce(token, casbin.ResourceEnvironment, casbin.ActionCreate, object); !ok {
+	if ok := handler.enforceSecretUpdate(token, configMapRequest.AppId, configMapRequest.EnvironmentId); !ok {
 		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
 		return
 	}
@@ -447,13 +461,8 @@ func (handler ConfigMapRestHandlerImpl) CMEnvironmentDelete(w http.ResponseWrite
 
 	//RBAC START
 	token := r.Header.Get("token")
-	object := handler.enforcerUtil.GetAppRBACNameByAppId(appId)
-	if ok := handler.enforcer.Enforce(token, casbin.ResourceApplications, casbin.ActionDelete, object); !ok {
-		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), nil, http.StatusForbidden)
-		return
-	}
-	object = handler.enforcerUtil.GetEnvRBACNameByAppId(appId, envId)
-	if ok := handler.enforcer.Enforce(token, casbin.ResourceEnvironment, casbin.ActionDelete, object); !ok {
+	object := handler.enforcerUtil.GetEnvRBACNameByAppId(appId, envId)
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceEnvOverride, casbin.ActionDelete, object); !ok {
 		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), nil, http.StatusForbidden)
 		return
 	}
@@ -497,6 +506,10 @@ func (handler ConfigMapRestHandlerImpl) CSGlobalDelete(w http.ResponseWriter, r
 		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), nil, http.StatusForbidden)
 		return
 	}
+	if ok := handler.enforceSecretUpdate(token, appId, 0); !ok {
+		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), nil, http.StatusForbidden)
+		return
+	}
 	//RBAC END
 
 	res, err := handler.configMapService.CSGlobalDelete(name, id, userId)
@@ -538,13 +551,12 @@ func (handler ConfigMapRestHandlerImpl) CSEnvironmentDelete(w http.ResponseWrite
 
 	//RBAC START
 	token := r.Header.Get("token")
-	object := handler.enforcerUtil.GetAppRBACNameByAppId(appId)
-	if ok := handler.enforcer.Enforce(token, casbin.ResourceApplications, casbin.ActionDelete, object); !ok {
+	object := handler.enforcerUtil.GetEnvRBACNameByAppId(appId, envId)
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceEnvOverride, casbin.ActionDelete, object); !ok {
 		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), nil, http.StatusForbidden)
 		return
 	}
-	object = handler.enforcerUtil.GetEnvRBACNameByAppId(appId, envId)
-	if ok := handler.enforcer.Enforce(token, casbin.ResourceEnvironment, casbin.ActionDelete, object); !ok {
+	if ok := handler.enforceSecretUpdate(token, appId, envId); !ok {
 		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), nil, http.StatusForbidden)
 		return
 	}
@@ -584,7 +596,7 @@ func (handler ConfigMapRestHandlerImpl) CSGlobalFetchForEdit(w http.ResponseWrit
 	//RBAC START
 	token := r.Header.Get("token")
 	object := handler.enforcerUtil.GetAppRBACNameByAppId(appId)
-	if ok := handler.enforcer.Enforce(token, casbin.ResourceApplications, casbin.ActionUpdate, object); !ok {
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceSecret, casbin.ActionGet, object); !ok {
 		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), nil, http.StatusForbidden)
 		return
 	}
@@ -630,12 +642,12 @@ func (handler ConfigMapRestHandlerImpl) CSEnvironmentFetchForEdit(w http.Respons
 	//RBAC START
 	token := r.Header.Get("token")
 	object := handler.enforcerUtil.GetAppRBACNameByAppId(appId)
-	if ok := handler.enforcer.Enforce(token, casbin.ResourceApplications, casbin.ActionUpdate, object); !ok {
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceSecret, casbin.ActionGet, object); !ok {
 		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), nil, http.StatusForbidden)
 		return
 	}
 	object = handler.enforcerUtil.GetEnvRBACNameByAppId(appId, envId)
-	if ok := handler.enforcer.Enforce(token, casbin.ResourceEnvironment, casbin.ActionUpdate, object); !ok {
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceEnvironment, casbin.ActionGet, object); !ok {
 		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), nil, http.StatusForbidden)
 		return
 	}
@@ -702,3 +714,355 @@ func (handler ConfigMapRestHandlerImpl) ConfigSecretBulkPatch(w http.ResponseWri
 	}
 	common.WriteJsonResp(w, err, true, http.StatusOK)
 }
+
+func (handler ConfigMapRestHandlerImpl) CSRotate(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	userId, err := handler.userAuthService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	var rotationRequest pipeline.SecretRotationRequest
+	err = decoder.Decode(&rotationRequest)
+	if err != nil {
+		handler.Logger.Errorw("request err, CSRotate", "err", err, "appId", rotationRequest.AppId, "secretName", rotationRequest.SecretName)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	rotationRequest.UserId = userId
+	handler.Logger.Infow("request payload, CSRotate", "appId", rotationRequest.AppId, "envId", rotationRequest.EnvironmentId, "secretName", rotationRequest.SecretName)
+
+	//RBAC START
+	token := r.Header.Get("token")
+	if ok := handler.enforceSecretUpdate(token, rotationRequest.AppId, rotationRequest.EnvironmentId); !ok {
+		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
+		return
+	}
+	object := handler.enforcerUtil.GetAppRBACNameByAppId(rotationRequest.AppId)
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceApplications, casbin.ActionTrigger, object); !ok {
+		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
+		return
+	}
+	// every consuming pipeline is redeployed, so trigger access is needed on each of their environments
+	pipelines, err := handler.secretRotationService.GetAffectedPipelines(rotationRequest.AppId, rotationRequest.EnvironmentId, rotationRequest.SecretName)
+	if err != nil {
+		handler.Logger.Errorw("service err, CSRotate", "err", err, "appId", rotationRequest.AppId, "secretName", rotationRequest.SecretName)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	for _, cdPipeline := range pipelines {
+		object = handler.enforcerUtil.GetAppRBACByAppIdAndPipelineId(rotationRequest.AppId, cdPipeline.Id)
+		if ok := handler.enforcer.Enforce(token, casbin.ResourceEnvironment, casbin.ActionTrigger, object); !ok {
+			common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
+			return
+		}
+	}
+	//RBAC END
+
+	res, err := handler.secretRotationService.RotateSecret(&rotationRequest)
+	if err != nil {
+		handler.Logger.Errorw("service err, CSRotate", "err", err, "appId", rotationRequest.AppId, "secretName", rotationRequest.SecretName)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, err, res, http.StatusOK)
+}
+
+func (handler ConfigMapRestHandlerImpl) CSRotationCancel(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userAuthService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	appId, err := strconv.Atoi(vars["appId"])
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+
+	//RBAC START
+	token := r.Header.Get("token")
+	object := handler.enforcerUtil.GetAppRBACNameByAppId(appId)
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceSecret, casbin.ActionUpdate, object); !ok {
+		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
+		return
+	}
+	//RBAC END
+
+	res, err := handler.secretRotationService.CancelRotation(appId, id, userId)
+	if err != nil {
+		handler.Logger.Errorw("service err, CSRotationCancel", "err", err, "appId", appId, "id", id)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, err, res, http.StatusOK)
+}
+
+func (handler ConfigMapRestHandlerImpl) CSRotationHistory(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userAuthService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	appId, err := strconv.Atoi(vars["appId"])
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	envId := 0
+	if v := r.URL.Query().Get("envId"); len(v) > 0 {
+		envId, err = strconv.Atoi(v)
+		if err != nil {
+			common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+			return
+		}
+	}
+
+	//RBAC START
+	token := r.Header.Get("token")
+	object := handler.enforcerUtil.GetAppRBACNameByAppId(appId)
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceApplications, casbin.ActionGet, object); !ok {
+		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
+		return
+	}
+	//RBAC END
+
+	res, err := handler.secretRotationService.GetRotationHistory(appId, envId)
+	if err != nil {
+		handler.Logger.Errorw("service err, CSRotationHistory", "err", err, "appId", appId, "envId", envId)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, err, res, http.StatusOK)
+}
+
+// getHistoryEnvId reads the optional envId query param, 0 refers to app level versions
+func getHistoryEnvId(r *http.Request) (int, error) {
+	envId := 0
+	var err error
+	if v := r.URL.Query().Get("envId"); len(v) > 0 {
+		envId, err = strconv.Atoi(v)
+	}
+	return envId, err
+}
+
+// enforceConfigHistory checks action on the app and, for env level versions, on the environment
+func (handler ConfigMapRestHandlerImpl) enforceConfigHistory(token string, appId int, envId int, action string) bool {
+	object := handler.enforcerUtil.GetAppRBACNameByAppId(appId)
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceApplications, action, object); !ok {
+		return false
+	}
+	if envId > 0 {
+		object = handler.enforcerUtil.GetEnvRBACNameByAppId(appId, envId)
+		if ok := handler.enforcer.Enforce(token, casbin.ResourceEnvironment, action, object); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// enforceSecretView tells whether secret values of the app may be shown, environment access is checked by the caller
+func (handler ConfigMapRestHandlerImpl) enforceSecretView(token string, appId int) bool {
+	object := handler.enforcerUtil.GetAppRBACNameByAppId(appId)
+	return handler.enforcer.Enforce(token, casbin.ResourceSecret, casbin.ActionGet, object)
+}
+
+// enforceSecretUpdate checks update on the secrets of the app and, for env level secrets, edit of the env override
+func (handler ConfigMapRestHandlerImpl) enforceSecretUpdate(token string, appId int, envId int) bool {
+	object := handler.enforcerUtil.GetAppRBACNameByAppId(appId)
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceSecret, casbin.ActionUpdate, object); !ok {
+		return false
+	}
+	if envId > 0 {
+		object = handler.enforcerUtil.GetEnvRBACNameByAppId(appId, envId)
+		if ok := handler.enforcer.Enforce(token, casbin.ResourceEnvOverride, casbin.ActionCreate, object); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (handler ConfigMapRestHandlerImpl) ConfigHistoryList(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userAuthService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	appId, err := strconv.Atoi(vars["appId"])
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	envId, err := getHistoryEnvId(r)
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	dataType := r.URL.Query().Get("type")
+	if dataType != chartConfig.ConfigMapHistoryTypeCM && dataType != chartConfig.ConfigMapHistoryTypeCS {
+		common.WriteJsonResp(w, fmt.Errorf("type must be CM or CS"), nil, http.StatusBadRequest)
+		return
+	}
+
+	//RBAC START
+	token := r.Header.Get("token")
+	if ok := handler.enforceConfigHistory(token, appId, envId, casbin.ActionGet); !ok {
+		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
+		return
+	}
+	//RBAC END
+
+	res, err := handler.configMapHistoryService.GetVersions(appId, envId, dataType)
+	if err != nil {
+		handler.Logger.Errorw("service err, ConfigHistoryList", "err", err, "appId", appId, "envId", envId)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, err, res, http.StatusOK)
+}
+
+func (handler ConfigMapRestHandlerImpl) ConfigHistoryGet(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userAuthService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	appId, err := strconv.Atoi(vars["appId"])
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	envId, err := getHistoryEnvId(r)
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+
+	//RBAC START
+	token := r.Header.Get("token")
+	if ok := handler.enforceConfigHistory(token, appId, envId, casbin.ActionGet); !ok {
+		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
+		return
+	}
+	// secret values are shown only to users who can view secrets, same as CSGlobalFetchForEdit
+	showSecretValues := handler.enforceSecretView(token, appId)
+	//RBAC END
+
+	res, err := handler.configMapHistoryService.GetVersion(appId, envId, id, showSecretValues)
+	if err != nil {
+		handler.Logger.Errorw("service err, ConfigHistoryGet", "err", err, "appId", appId, "id", id)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, err, res, http.StatusOK)
+}
+
+func (handler ConfigMapRestHandlerImpl) ConfigHistoryDiff(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userAuthService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	appId, err := strconv.Atoi(vars["appId"])
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	fromId, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	toId, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	envId, err := getHistoryEnvId(r)
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+
+	//RBAC START
+	token := r.Header.Get("token")
+	if ok := handler.enforceConfigHistory(token, appId, envId, casbin.ActionGet); !ok {
+		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
+		return
+	}
+	showSecretValues := handler.enforceSecretView(token, appId)
+	//RBAC END
+
+	res, err := handler.configMapHistoryService.Diff(appId, envId, fromId, toId, showSecretValues)
+	if err != nil {
+		handler.Logger.Errorw("service err, ConfigHistoryDiff", "err", err, "appId", appId, "from", fromId, "to", toId)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, err, res, http.StatusOK)
+}
+
+func (handler ConfigMapRestHandlerImpl) ConfigHistoryRestore(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	userId, err := handler.userAuthService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	var restoreRequest pipeline.ConfigMapHistoryRestoreRequest
+	err = decoder.Decode(&restoreRequest)
+	if err != nil {
+		handler.Logger.Errorw("request err, ConfigHistoryRestore", "err", err, "payload", restoreRequest)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	restoreRequest.UserId = userId
+	handler.Logger.Infow("request payload, ConfigHistoryRestore", "payload", restoreRequest)
+
+	//RBAC START
+	token := r.Header.Get("token")
+	if ok := handler.enforceConfigHistory(token, restoreRequest.AppId, restoreRequest.EnvironmentId, casbin.ActionGet); !ok {
+		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
+		return
+	}
+	version, err := handler.configMapHistoryService.GetVersion(restoreRequest.AppId, restoreRequest.EnvironmentId, restoreRequest.Id, false)
+	if err != nil {
+		handler.Logger.Errorw("service err, ConfigHistoryRestore", "err", err, "payload", restoreRequest)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	// restoring secrets writes secret values, so it needs the same access as editing them
+	if version.Type == chartConfig.ConfigMapHistoryTypeCS {
+		if ok := handler.enforceSecretUpdate(token, restoreRequest.AppId, restoreRequest.EnvironmentId); !ok {
+			common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
+			return
+		}
+	} else if ok := handler.enforceConfigHistory(token, restoreRequest.AppId, restoreRequest.EnvironmentId, casbin.ActionCreate); !ok {
+		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
+		return
+	}
+	//RBAC END
+
+	res, err := handler.configMapService.ConfigHistoryRestore(&restoreRequest)
+	if err != nil {
+		handler.Logger.Errorw("service err, ConfigHistoryRestore", "err", err, "payload", restoreRequest)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, err, res, http.StatusOK)
+}
@@ -0,0 +1,253 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package restHandler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/devtron-labs/devtron/api/restHandler/common"
+	"github.com/devtron-labs/devtron/pkg/cost"
+	"github.com/devtron-labs/devtron/pkg/user"
+	"github.com/devtron-labs/devtron/pkg/user/casbin"
+	"github.com/devtron-labs/devtron/util/rbac"
+	"go.uber.org/zap"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// default range of the cost trend when the request does not give one
+const costTrendDefaultDays = 30
+
+type CostAllocationRestHandler interface {
+	GetPrices(w http.ResponseWriter, r *http.Request)
+	SavePrice(w http.ResponseWriter, r *http.Request)
+	ComputeCost(w http.ResponseWriter, r *http.Request)
+	GetCostTrend(w http.ResponseWriter, r *http.Request)
+	GetMonthlyReport(w http.ResponseWriter, r *http.Request)
+}
+
+type CostAllocationRestHandlerImpl struct {
+	logger                *zap.SugaredLogger
+	enforcer              casbin.Enforcer
+	enforcerUtil          rbac.EnforcerUtil
+	userService           user.UserService
+	validator             *validator.Validate
+	costAllocationService cost.CostAllocationService
+}
+
+func NewCostAllocationRestHandlerImpl(logger *zap.SugaredLogger, enforcer casbin.Enforcer, enforcerUtil rbac.EnforcerUtil,
+	userService user.UserService, validator *validator.Validate,
+	costAllocationService cost.CostAllocationService) *CostAllocationRestHandlerImpl {
+	return &CostAllocationRestHandlerImpl{
+		logger:                logger,
+		enforcer:              enforcer,
+		enforcerUtil:          enforcerUtil,
+		userService:           userService,
+		validator:             validator,
+		costAllocationService: costAllocationService,
+	}
+}
+
+func (handler CostAllocationRestHandlerImpl) GetPrices(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	prices, err := handler.costAllocationService.GetPrices()
+	if err != nil {
+		handler.logger.Errorw("service err, GetPrices", "err", err)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	token := r.Header.Get("token")
+	result := make([]*cost.ClusterCostPriceBean, 0)
+	for _, price := range prices {
+		if ok := handler.enforcer.Enforce(token, casbin.ResourceCluster, casbin.ActionGet, strings.ToLower(price.ClusterName)); ok {
+			result = append(result, price)
+		}
+	}
+	common.WriteJsonResp(w, nil, result, http.StatusOK)
+}
+
+func (handler CostAllocationRestHandlerImpl) SavePrice(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	var bean cost.ClusterCostPriceBean
+	err = decoder.Decode(&bean)
+	if err != nil {
+		handler.logger.Errorw("request err, SavePrice", "err", err, "payload", bean)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	bean.UserId = userId
+	err = handler.validator.Struct(bean)
+	if err != nil {
+		handler.logger.Errorw("validation err, SavePrice", "err", err, "payload", bean)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	token := r.Header.Get("token")
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceCluster, casbin.ActionCreate, "*"); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+	handler.logger.Infow("request payload, SavePrice", "payload", bean)
+	resp, err := handler.costAllocationService.SavePrice(&bean)
+	if err != nil {
+		handler.logger.Errorw("service err, SavePrice", "err", err, "payload", bean)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, resp, http.StatusOK)
+}
+
+// ComputeCost computes a day again in the background, e.g. after its prices were corrected, the day is yesterday
+// unless given
+func (handler CostAllocationRestHandlerImpl) ComputeCost(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	day := time.Now().UTC().AddDate(0, 0, -1)
+	if value := r.URL.Query().Get("date"); len(value) > 0 {
+		day, err = cost.ParseCostDate(value)
+		if err != nil {
+			common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+			return
+		}
+	}
+	token := r.Header.Get("token")
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceCluster, casbin.ActionCreate, "*"); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+	if started := handler.costAllocationService.StartComputeDailyCost(day); !started {
+		common.WriteJsonResp(w, errors.New("cost computation already running, try again later"), nil, http.StatusConflict)
+		return
+	}
+	common.WriteJsonResp(w, nil, day.Format("2006-01-02"), http.StatusAccepted)
+}
+
+func (handler CostAllocationRestHandlerImpl) GetCostTrend(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	v := r.URL.Query()
+	request := &cost.CostTrendRequest{
+		GroupBy: v.Get("groupBy"),
+		To:      time.Now().UTC(),
+	}
+	if len(v.Get("to")) > 0 {
+		request.To, err = cost.ParseCostDate(v.Get("to"))
+		if err != nil {
+			common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+			return
+		}
+	}
+	request.From = request.To.AddDate(0, 0, -costTrendDefaultDays)
+	if len(v.Get("from")) > 0 {
+		request.From, err = cost.ParseCostDate(v.Get("from"))
+		if err != nil {
+			common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+			return
+		}
+	}
+	resp, err := handler.costAllocationService.GetCostTrend(request)
+	if err != nil {
+		handler.logger.Errorw("service err, GetCostTrend", "err", err, "request", request)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	allowed := handler.costGroupFilter(r.Header.Get("token"), resp.GroupBy)
+	series := make([]*cost.CostTrendSeries, 0)
+	for _, s := range resp.Series {
+		if allowed(s.Id, s.Name) {
+			series = append(series, s)
+		}
+	}
+	resp.Series = series
+	common.WriteJsonResp(w, nil, resp, http.StatusOK)
+}
+
+func (handler CostAllocationRestHandlerImpl) GetMonthlyReport(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	v := r.URL.Query()
+	month := time.Now().UTC()
+	if len(v.Get("month")) > 0 {
+		month, err = cost.ParseCostMonth(v.Get("month"))
+		if err != nil {
+			common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+			return
+		}
+	}
+	resp, err := handler.costAllocationService.GetMonthlyReport(month, v.Get("groupBy"))
+	if err != nil {
+		handler.logger.Errorw("service err, GetMonthlyReport", "err", err, "month", month)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	allowed := handler.costGroupFilter(r.Header.Get("token"), resp.GroupBy)
+	rows := make([]*cost.CostReportRow, 0)
+	resp.TotalCost = 0
+	for _, row := range resp.Rows {
+		if allowed(row.Id, row.Name) {
+			rows = append(rows, row)
+			resp.TotalCost = cost.RoundCost(resp.TotalCost + row.TotalCost)
+		}
+	}
+	resp.Rows = rows
+	common.WriteJsonResp(w, nil, resp, http.StatusOK)
+}
+
+// costGroupFilter tells which apps, environments or teams the user may see the cost of, unallocated cost is only
+// shown to cluster admins
+func (handler CostAllocationRestHandlerImpl) costGroupFilter(token string, groupBy string) func(id int, name string) bool {
+	var appObjects map[int]string
+	if groupBy == cost.CostGroupByApp {
+		appObjects = handler.enforcerUtil.GetRbacObjectsForAllApps()
+	}
+	return func(id int, name string) bool {
+		if id == 0 {
+			return handler.enforcer.Enforce(token, casbin.ResourceCluster, casbin.ActionCreate, "*")
+		}
+		switch groupBy {
+		case cost.CostGroupByApp:
+			return handler.enforcer.Enforce(token, casbin.ResourceApplications, casbin.ActionGet, appObjects[id])
+		case cost.CostGroupByEnvironment:
+			return handler.enforcer.Enforce(token, casbin.ResourceGlobalEnvironment, casbin.ActionGet, strings.ToLower(name))
+		case cost.CostGroupByTeam:
+			return handler.enforcer.Enforce(token, casbin.ResourceTeam, casbin.ActionGet, strings.ToLower(name))
+		}
+		return false
+	}
+}
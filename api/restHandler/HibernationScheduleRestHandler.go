@@ -0,0 +1,340 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package restHandler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devtron-labs/devtron/api/restHandler/common"
+	"github.com/devtron-labs/devtron/pkg/cluster"
+	"github.com/devtron-labs/devtron/pkg/cost"
+	"github.com/devtron-labs/devtron/pkg/hibernation"
+	"github.com/devtron-labs/devtron/pkg/user"
+	"github.com/devtron-labs/devtron/pkg/user/casbin"
+	"github.com/devtron-labs/devtron/util/rbac"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// default range of the savings report when the request does not give one
+const hibernationSavingsDefaultDays = 30
+
+type HibernationScheduleRestHandler interface {
+	GetSchedules(w http.ResponseWriter, r *http.Request)
+	GetSchedule(w http.ResponseWriter, r *http.Request)
+	SaveSchedule(w http.ResponseWriter, r *http.Request)
+	DeleteSchedule(w http.ResponseWriter, r *http.Request)
+	KeepAwake(w http.ResponseWriter, r *http.Request)
+	GetSavings(w http.ResponseWriter, r *http.Request)
+}
+
+type HibernationScheduleRestHandlerImpl struct {
+	logger                     *zap.SugaredLogger
+	enforcer                   casbin.Enforcer
+	enforcerUtil               rbac.EnforcerUtil
+	userService                user.UserService
+	validator                  *validator.Validate
+	environmentService         cluster.EnvironmentService
+	hibernationScheduleService hibernation.HibernationScheduleService
+}
+
+func NewHibernationScheduleRestHandlerImpl(logger *zap.SugaredLogger, enforcer casbin.Enforcer, enforcerUtil rbac.EnforcerUtil,
+	userService user.UserService, validator *validator.Validate, environmentService cluster.EnvironmentService,
+	hibernationScheduleService hibernation.HibernationScheduleService) *HibernationScheduleRestHandlerImpl {
+	return &HibernationScheduleRestHandlerImpl{
+		logger:                     logger,
+		enforcer:                   enforcer,
+		enforcerUtil:               enforcerUtil,
+		userService:                userService,
+		validator:                  validator,
+		environmentService:         environmentService,
+		hibernationScheduleService: hibernationScheduleService,
+	}
+}
+
+func (handler HibernationScheduleRestHandlerImpl) GetSchedules(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	envId := 0
+	if value := r.URL.Query().Get("envId"); len(value) > 0 {
+		envId, err = strconv.Atoi(value)
+		if err != nil {
+			common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+			return
+		}
+	}
+	schedules, err := handler.hibernationScheduleService.GetSchedules(envId)
+	if err != nil {
+		handler.logger.Errorw("service err, GetSchedules", "err", err, "envId", envId)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	token := r.Header.Get("token")
+	result := make([]*hibernation.HibernationScheduleDto, 0)
+	for _, schedule := range schedules {
+		if handler.canViewSchedule(token, schedule) {
+			result = append(result, schedule)
+		}
+	}
+	common.WriteJsonResp(w, nil, result, http.StatusOK)
+}
+
+func (handler HibernationScheduleRestHandlerImpl) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	schedule, err := handler.hibernationScheduleService.GetSchedule(id)
+	if err != nil {
+		handler.logger.Errorw("service err, GetSchedule", "err", err, "id", id)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	if ok := handler.canViewSchedule(r.Header.Get("token"), schedule); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+	common.WriteJsonResp(w, nil, schedule, http.StatusOK)
+}
+
+func (handler HibernationScheduleRestHandlerImpl) SaveSchedule(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	var dto hibernation.HibernationScheduleDto
+	err = decoder.Decode(&dto)
+	if err != nil {
+		handler.logger.Errorw("request err, SaveSchedule", "err", err, "payload", dto)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	dto.UserId = userId
+	err = handler.validator.Struct(dto)
+	if err != nil {
+		handler.logger.Errorw("validation err, SaveSchedule", "err", err, "payload", dto)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	environment, err := handler.environmentService.FindById(dto.EnvironmentId)
+	if err != nil {
+		handler.logger.Errorw("service err, SaveSchedule", "err", err, "payload", dto)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	dto.EnvironmentName = environment.Environment
+	token := r.Header.Get("token")
+	if ok := handler.canManageSchedule(token, &dto); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+	if dto.Id > 0 {
+		// moving a schedule requires access to what it covered so far as well
+		existing, err := handler.hibernationScheduleService.GetSchedule(dto.Id)
+		if err != nil {
+			handler.logger.Errorw("service err, SaveSchedule", "err", err, "payload", dto)
+			common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+			return
+		}
+		if ok := handler.canManageSchedule(token, existing); !ok {
+			common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+			return
+		}
+	}
+	handler.logger.Infow("request payload, SaveSchedule", "payload", dto)
+	resp, err := handler.hibernationScheduleService.SaveSchedule(&dto)
+	if err != nil {
+		handler.logger.Errorw("service err, SaveSchedule", "err", err, "payload", dto)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	common.WriteJsonResp(w, nil, resp, http.StatusOK)
+}
+
+func (handler HibernationScheduleRestHandlerImpl) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	schedule, err := handler.hibernationScheduleService.GetSchedule(id)
+	if err != nil {
+		handler.logger.Errorw("service err, DeleteSchedule", "err", err, "id", id)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	if ok := handler.canManageSchedule(r.Header.Get("token"), schedule); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+	err = handler.hibernationScheduleService.DeleteSchedule(id, userId)
+	if err != nil {
+		handler.logger.Errorw("service err, DeleteSchedule", "err", err, "id", id)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, "schedule deleted", http.StatusOK)
+}
+
+// KeepAwake suspends a schedule until the given time, e.g. for a late release or a weekend demo
+func (handler HibernationScheduleRestHandlerImpl) KeepAwake(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	var request hibernation.KeepAwakeRequest
+	err = decoder.Decode(&request)
+	if err != nil {
+		handler.logger.Errorw("request err, KeepAwake", "err", err, "payload", request)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	err = handler.validator.Struct(request)
+	if err != nil {
+		handler.logger.Errorw("validation err, KeepAwake", "err", err, "payload", request)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	schedule, err := handler.hibernationScheduleService.GetSchedule(id)
+	if err != nil {
+		handler.logger.Errorw("service err, KeepAwake", "err", err, "id", id)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	if ok := handler.canManageSchedule(r.Header.Get("token"), schedule); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+	handler.logger.Infow("request payload, KeepAwake", "id", id, "payload", request)
+	resp, err := handler.hibernationScheduleService.KeepAwake(id, request.Until, userId)
+	if err != nil {
+		handler.logger.Errorw("service err, KeepAwake", "err", err, "id", id, "payload", request)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, resp, http.StatusOK)
+}
+
+func (handler HibernationScheduleRestHandlerImpl) GetSavings(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	v := r.URL.Query()
+	now := time.Now().UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if len(v.Get("to")) > 0 {
+		to, err = cost.ParseCostDate(v.Get("to"))
+		if err != nil {
+			common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+			return
+		}
+	}
+	from := to.AddDate(0, 0, -hibernationSavingsDefaultDays)
+	if len(v.Get("from")) > 0 {
+		from, err = cost.ParseCostDate(v.Get("from"))
+		if err != nil {
+			common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+			return
+		}
+	}
+	resp, err := handler.hibernationScheduleService.GetSavings(from, to)
+	if err != nil {
+		handler.logger.Errorw("service err, GetSavings", "err", err, "from", from, "to", to)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	token := r.Header.Get("token")
+	appObjects, envObjects := handler.enforcerUtil.GetRbacObjectsForAllAppsAndEnvironments()
+	rows := make([]*hibernation.HibernationSavingsRow, 0)
+	resp.TotalHibernatedHours = 0
+	resp.TotalEstimatedSavings = 0
+	for _, row := range resp.Rows {
+		if ok := handler.enforcer.Enforce(token, casbin.ResourceApplications, casbin.ActionGet, appObjects[row.AppId]); !ok {
+			continue
+		}
+		envObject := envObjects[strconv.Itoa(row.EnvironmentId)+"-"+strconv.Itoa(row.AppId)]
+		if ok := handler.enforcer.Enforce(token, casbin.ResourceEnvironment, casbin.ActionGet, envObject); !ok {
+			continue
+		}
+		rows = append(rows, row)
+		resp.TotalHibernatedHours += row.HibernatedHours
+		resp.TotalEstimatedSavings += row.EstimatedSavings
+	}
+	resp.Rows = rows
+	common.WriteJsonResp(w, nil, resp, http.StatusOK)
+}
+
+// canViewSchedule allows schedules of an app to those who can see the app in the environment, and schedules of a
+// whole environment to those who can see the environment
+func (handler HibernationScheduleRestHandlerImpl) canViewSchedule(token string, schedule *hibernation.HibernationScheduleDto) bool {
+	if schedule.AppId == 0 {
+		return handler.enforcer.Enforce(token, casbin.ResourceGlobalEnvironment, casbin.ActionGet, strings.ToLower(schedule.EnvironmentName))
+	}
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceApplications, casbin.ActionGet, handler.enforcerUtil.GetAppRBACNameByAppId(schedule.AppId)); !ok {
+		return false
+	}
+	return handler.enforcer.Enforce(token, casbin.ResourceEnvironment, casbin.ActionGet, handler.enforcerUtil.GetEnvRBACNameByAppId(schedule.AppId, schedule.EnvironmentId))
+}
+
+// canManageSchedule allows schedules of an app to those who may hibernate it by hand, schedules of a whole
+// environment hibernate apps of several teams and need update on the environment
+func (handler HibernationScheduleRestHandlerImpl) canManageSchedule(token string, schedule *hibernation.HibernationScheduleDto) bool {
+	if schedule.AppId == 0 {
+		return handler.enforcer.Enforce(token, casbin.ResourceGlobalEnvironment, casbin.ActionUpdate, strings.ToLower(schedule.EnvironmentName))
+	}
+	object := handler.enforcerUtil.GetAppRBACNameByAppId(schedule.AppId)
+	action := casbin.ActionTrigger
+	if !handler.enforcer.Enforce(token, casbin.ResourceApplications, casbin.ActionTrigger, object) {
+		action = casbin.ActionHibernate
+	}
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceApplications, action, object); !ok {
+		return false
+	}
+	return handler.enforcer.Enforce(token, casbin.ResourceEnvironment, action, handler.enforcerUtil.GetEnvRBACNameByAppId(schedule.AppId, schedule.EnvironmentId))
+}
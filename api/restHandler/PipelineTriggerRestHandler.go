@@ -144,12 +144,17 @@ func (handler PipelineTriggerRestHandlerImpl) StartStopApp(w http.ResponseWriter
 	token := r.Header.Get("token")
 	//rbac block starts from here
 	object := handler.enforcerUtil.GetAppRBACNameByAppId(overrideRequest.AppId)
-	if ok := handler.enforcer.Enforce(token, casbin.ResourceApplications, casbin.ActionTrigger, object); !ok {
+	// stopping and starting is also allowed to roles granting hibernate without trigger
+	action := casbin.ActionTrigger
+	if !handler.enforcer.Enforce(token, casbin.ResourceApplications, casbin.ActionTrigger, object) {
+		action = casbin.ActionHibernate
+	}
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceApplications, action, object); !ok {
 		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
 		return
 	}
 	object = handler.enforcerUtil.GetEnvRBACNameByAppId(overrideRequest.AppId, overrideRequest.EnvironmentId)
-	if ok := handler.enforcer.Enforce(token, casbin.ResourceEnvironment, casbin.ActionTrigger, object); !ok {
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceEnvironment, action, object); !ok {
 		common.WriteJsonResp(w, fmt.Errorf("unauthorized user"), "Unauthorized User", http.StatusForbidden)
 		return
 	}
@@ -0,0 +1,246 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package restHandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/devtron-labs/devtron/api/restHandler/common"
+	"github.com/devtron-labs/devtron/pkg/terminal"
+	"github.com/devtron-labs/devtron/pkg/terminal/repository"
+	"github.com/devtron-labs/devtron/pkg/user"
+	"github.com/devtron-labs/devtron/pkg/user/casbin"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"gopkg.in/go-playground/validator.v9"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+const defaultTerminalRecordingPageSize = 20
+
+type TerminalRecordingRestHandler interface {
+	GetRecordings(w http.ResponseWriter, r *http.Request)
+	GetRecordingById(w http.ResponseWriter, r *http.Request)
+	ReplayRecording(w http.ResponseWriter, r *http.Request)
+	DownloadRecording(w http.ResponseWriter, r *http.Request)
+	GetConfigs(w http.ResponseWriter, r *http.Request)
+	SaveConfig(w http.ResponseWriter, r *http.Request)
+}
+
+type TerminalRecordingRestHandlerImpl struct {
+	logger                   *zap.SugaredLogger
+	enforcer                 casbin.Enforcer
+	userService              user.UserService
+	terminalRecordingService terminal.TerminalRecordingService
+	validator                *validator.Validate
+}
+
+func NewTerminalRecordingRestHandlerImpl(logger *zap.SugaredLogger, enforcer casbin.Enforcer, userService user.UserService,
+	terminalRecordingService terminal.TerminalRecordingService, validator *validator.Validate) *TerminalRecordingRestHandlerImpl {
+	return &TerminalRecordingRestHandlerImpl{
+		logger:                   logger,
+		enforcer:                 enforcer,
+		userService:              userService,
+		terminalRecordingService: terminalRecordingService,
+		validator:                validator,
+	}
+}
+
+// GetRecordings lists recordings of all users for global admins and the logged in user's own recordings otherwise
+func (handler TerminalRecordingRestHandlerImpl) GetRecordings(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	filter, err := parseTerminalRecordingFilter(r)
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	token := r.Header.Get("token")
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceGlobal, casbin.ActionGet, "*"); !ok {
+		filter.UserId = userId
+	}
+	res, err := handler.terminalRecordingService.GetRecordings(filter)
+	if err != nil {
+		handler.logger.Errorw("service err, GetRecordings", "err", err, "filter", filter)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+func (handler TerminalRecordingRestHandlerImpl) GetRecordingById(w http.ResponseWriter, r *http.Request) {
+	recording, ok := handler.getAuthorizedRecording(w, r)
+	if !ok {
+		return
+	}
+	common.WriteJsonResp(w, nil, recording, http.StatusOK)
+}
+
+// ReplayRecording serves the asciicast inline for a player, DownloadRecording serves it as an attachment
+func (handler TerminalRecordingRestHandlerImpl) ReplayRecording(w http.ResponseWriter, r *http.Request) {
+	handler.writeRecording(w, r, "inline")
+}
+
+func (handler TerminalRecordingRestHandlerImpl) DownloadRecording(w http.ResponseWriter, r *http.Request) {
+	handler.writeRecording(w, r, "attachment")
+}
+
+func (handler TerminalRecordingRestHandlerImpl) writeRecording(w http.ResponseWriter, r *http.Request, disposition string) {
+	recording, ok := handler.getAuthorizedRecording(w, r)
+	if !ok {
+		return
+	}
+	file, cleanUp, err := handler.terminalRecordingService.DownloadRecording(recording.Id)
+	if err != nil {
+		handler.logger.Errorw("service err, DownloadRecording", "err", err, "id", recording.Id)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	defer cleanUp()
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		handler.logger.Errorw("service err, DownloadRecording", "err", err, "id", recording.Id)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("%s; filename=%s.cast", disposition, recording.SessionId))
+	w.Header().Set("Content-Type", terminal.AsciicastContentType)
+	_, err = io.Copy(w, file)
+	if err != nil {
+		handler.logger.Errorw("service err, DownloadRecording", "err", err, "id", recording.Id)
+	}
+}
+
+// getAuthorizedRecording writes the error response itself, recordings are visible to their user and global admins
+func (handler TerminalRecordingRestHandlerImpl) getAuthorizedRecording(w http.ResponseWriter, r *http.Request) (*terminal.TerminalRecordingDto, bool) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return nil, false
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return nil, false
+	}
+	recording, err := handler.terminalRecordingService.GetRecordingById(id)
+	if err != nil {
+		handler.logger.Errorw("service err, GetRecordingById", "err", err, "id", id)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return nil, false
+	}
+	token := r.Header.Get("token")
+	if recording.UserId != userId {
+		if ok := handler.enforcer.Enforce(token, casbin.ResourceGlobal, casbin.ActionGet, "*"); !ok {
+			common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+			return nil, false
+		}
+	}
+	return recording, true
+}
+
+func (handler TerminalRecordingRestHandlerImpl) GetConfigs(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	res, err := handler.terminalRecordingService.GetConfigs()
+	if err != nil {
+		handler.logger.Errorw("service err, GetConfigs", "err", err)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+func (handler TerminalRecordingRestHandlerImpl) SaveConfig(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	var request terminal.TerminalRecordingConfigDto
+	err = decoder.Decode(&request)
+	if err != nil {
+		handler.logger.Errorw("request err, SaveConfig", "err", err, "payload", request)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	request.UserId = userId
+	err = handler.validator.Struct(request)
+	if err != nil {
+		handler.logger.Errorw("validation err, SaveConfig", "err", err, "payload", request)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	token := r.Header.Get("token")
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceGlobal, casbin.ActionUpdate, "*"); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+	res, err := handler.terminalRecordingService.SaveConfig(&request)
+	if err != nil {
+		handler.logger.Errorw("service err, SaveConfig", "err", err, "payload", request)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+func parseTerminalRecordingFilter(r *http.Request) (*repository.TerminalRecordingFilter, error) {
+	v := r.URL.Query()
+	filter := &repository.TerminalRecordingFilter{Size: defaultTerminalRecordingPageSize}
+	var err error
+	if userId := v.Get("userId"); userId != "" {
+		id, err := strconv.ParseInt(userId, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		filter.UserId = int32(id)
+	}
+	if appId := v.Get("appId"); appId != "" {
+		if filter.AppId, err = strconv.Atoi(appId); err != nil {
+			return nil, err
+		}
+	}
+	if envId := v.Get("envId"); envId != "" {
+		if filter.EnvironmentId, err = strconv.Atoi(envId); err != nil {
+			return nil, err
+		}
+	}
+	if offset := v.Get("offset"); offset != "" {
+		if filter.Offset, err = strconv.Atoi(offset); err != nil {
+			return nil, err
+		}
+	}
+	if size := v.Get("size"); size != "" {
+		if filter.Size, err = strconv.Atoi(size); err != nil {
+			return nil, err
+		}
+	}
+	return filter, nil
+}
@@ -109,6 +109,8 @@ type PipelineConfigRestHandlerImpl struct {
 	policyService           security2.PolicyService
 	scanResultRepository    security.ImageScanResultRepository
 	gitProviderRepo         repository.GitProviderRepository
+
+	deploymentTemplateHistoryService pipeline.DeploymentTemplateHistoryService
 }
 
 func NewPipelineRestHandlerImpl(pipelineBuilder pipeline.PipelineBuilder, Logger *zap.SugaredLogger,
@@ -129,7 +131,8 @@ func NewPipelineRestHandlerImpl(pipelineBuilder pipeline.PipelineBuilder, Logger
 	appCloneService appClone.AppCloneService,
 	appWorkflowService appWorkflow.AppWorkflowService,
 	materialRepository pipelineConfig.MaterialRepository, policyService security2.PolicyService,
-	scanResultRepository security.ImageScanResultRepository, gitProviderRepo repository.GitProviderRepository) *PipelineConfigRestHandlerImpl {
+	scanResultRepository security.ImageScanResultRepository, gitProviderRepo repository.GitProviderRepository,
+	deploymentTemplateHistoryService pipeline.DeploymentTemplateHistoryService) *PipelineConfigRestHandlerImpl {
 	return &PipelineConfigRestHandlerImpl{
 		pipelineBuilder:         pipelineBuilder,
 		Logger:                  Logger,
@@ -156,6 +159,8 @@ func NewPipelineRestHandlerImpl(pipelineBuilder pipeline.PipelineBuilder, Logger
 		policyService:           policyService,
 		scanResultRepository:    scanResultRepository,
 		gitProviderRepo:         gitProviderRepo,
+
+		deploymentTemplateHistoryService: deploymentTemplateHistoryService,
 	}
 }
 
@@ -16,3 +16,204 @@
  */
 
 package restHandler
+
+import (
+	"errors"
+	"github.com/devtron-labs/devtron/api/restHandler/common"
+	"github.com/devtron-labs/devtron/pkg/cluster"
+	"github.com/devtron-labs/devtron/pkg/user"
+	"github.com/devtron-labs/devtron/pkg/user/casbin"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type K8sResourceRestHandler interface {
+	GetApiResources(w http.ResponseWriter, r *http.Request)
+	ListResources(w http.ResponseWriter, r *http.Request)
+	GetResource(w http.ResponseWriter, r *http.Request)
+	GetResourceEvents(w http.ResponseWriter, r *http.Request)
+	UpdateResource(w http.ResponseWriter, r *http.Request)
+	DeleteResource(w http.ResponseWriter, r *http.Request)
+}
+
+type K8sResourceRestHandlerImpl struct {
+	logger             *zap.SugaredLogger
+	enforcer           casbin.Enforcer
+	userService        user.UserService
+	clusterService     cluster.ClusterService
+	k8sResourceService cluster.K8sResourceService
+}
+
+func NewK8sResourceRestHandlerImpl(logger *zap.SugaredLogger, enforcer casbin.Enforcer, userService user.UserService,
+	clusterService cluster.ClusterService, k8sResourceService cluster.K8sResourceService) *K8sResourceRestHandlerImpl {
+	return &K8sResourceRestHandlerImpl{
+		logger:             logger,
+		enforcer:           enforcer,
+		userService:        userService,
+		clusterService:     clusterService,
+		k8sResourceService: k8sResourceService,
+	}
+}
+
+func (handler K8sResourceRestHandlerImpl) GetApiResources(w http.ResponseWriter, r *http.Request) {
+	clusterId, ok := handler.authorize(w, r, casbin.ActionGet)
+	if !ok {
+		return
+	}
+	res, err := handler.k8sResourceService.GetApiResources(clusterId)
+	if err != nil {
+		handler.logger.Errorw("service err, GetApiResources", "err", err, "clusterId", clusterId)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+func (handler K8sResourceRestHandlerImpl) ListResources(w http.ResponseWriter, r *http.Request) {
+	clusterId, ok := handler.authorize(w, r, casbin.ActionGet)
+	if !ok {
+		return
+	}
+	v := r.URL.Query()
+	request := &cluster.K8sResourceListRequest{
+		K8sResourceIdentifier: parseK8sResourceIdentifier(v),
+		LabelSelector:         v.Get("labelSelector"),
+		FieldSelector:         v.Get("fieldSelector"),
+		Continue:              v.Get("continue"),
+	}
+	if limit := v.Get("limit"); limit != "" {
+		var err error
+		if request.Limit, err = strconv.ParseInt(limit, 10, 64); err != nil {
+			common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+			return
+		}
+	}
+	res, err := handler.k8sResourceService.ListResources(clusterId, request, !handler.canReadSecrets(r))
+	if err != nil {
+		handler.logger.Errorw("service err, ListResources", "err", err, "clusterId", clusterId, "request", request)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+func (handler K8sResourceRestHandlerImpl) GetResource(w http.ResponseWriter, r *http.Request) {
+	clusterId, ok := handler.authorize(w, r, casbin.ActionGet)
+	if !ok {
+		return
+	}
+	identifier := parseK8sResourceIdentifier(r.URL.Query())
+	res, err := handler.k8sResourceService.GetResource(clusterId, &identifier, !handler.canReadSecrets(r))
+	if err != nil {
+		handler.logger.Errorw("service err, GetResource", "err", err, "clusterId", clusterId, "identifier", identifier)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+func (handler K8sResourceRestHandlerImpl) GetResourceEvents(w http.ResponseWriter, r *http.Request) {
+	clusterId, ok := handler.authorize(w, r, casbin.ActionGet)
+	if !ok {
+		return
+	}
+	identifier := parseK8sResourceIdentifier(r.URL.Query())
+	res, err := handler.k8sResourceService.GetResourceEvents(clusterId, &identifier)
+	if err != nil {
+		handler.logger.Errorw("service err, GetResourceEvents", "err", err, "clusterId", clusterId, "identifier", identifier)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+func (handler K8sResourceRestHandlerImpl) UpdateResource(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	clusterId, ok := handler.authorize(w, r, casbin.ActionUpdate)
+	if !ok {
+		return
+	}
+	manifest, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	identifier := parseK8sResourceIdentifier(r.URL.Query())
+	res, err := handler.k8sResourceService.UpdateResource(clusterId, &identifier, manifest, userId)
+	if err != nil {
+		handler.logger.Errorw("service err, UpdateResource", "err", err, "clusterId", clusterId, "identifier", identifier)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+func (handler K8sResourceRestHandlerImpl) DeleteResource(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	clusterId, ok := handler.authorize(w, r, casbin.ActionDelete)
+	if !ok {
+		return
+	}
+	identifier := parseK8sResourceIdentifier(r.URL.Query())
+	err = handler.k8sResourceService.DeleteResource(clusterId, &identifier, userId)
+	if err != nil {
+		handler.logger.Errorw("service err, DeleteResource", "err", err, "clusterId", clusterId, "identifier", identifier)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, true, http.StatusOK)
+}
+
+// authorize enforces the action on the cluster of the request, it writes the error response itself
+func (handler K8sResourceRestHandlerImpl) authorize(w http.ResponseWriter, r *http.Request, action string) (int, bool) {
+	clusterId, err := strconv.Atoi(mux.Vars(r)["clusterId"])
+	if err != nil {
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return 0, false
+	}
+	clusterBean, err := handler.clusterService.FindById(clusterId)
+	if err != nil {
+		handler.logger.Errorw("service err, FindById", "err", err, "clusterId", clusterId)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return 0, false
+	}
+	token := r.Header.Get("token")
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceCluster, action, strings.ToLower(clusterBean.ClusterName)); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return 0, false
+	}
+	return clusterId, true
+}
+
+// canReadSecrets allows unmasked secrets only to users who can edit the cluster's objects
+func (handler K8sResourceRestHandlerImpl) canReadSecrets(r *http.Request) bool {
+	clusterId, _ := strconv.Atoi(mux.Vars(r)["clusterId"])
+	clusterBean, err := handler.clusterService.FindById(clusterId)
+	if err != nil {
+		return false
+	}
+	return handler.enforcer.Enforce(r.Header.Get("token"), casbin.ResourceCluster, casbin.ActionUpdate, strings.ToLower(clusterBean.ClusterName))
+}
+
+func parseK8sResourceIdentifier(v url.Values) cluster.K8sResourceIdentifier {
+	return cluster.K8sResourceIdentifier{
+		Group:     v.Get("group"),
+		Version:   v.Get("version"),
+		Resource:  v.Get("resource"),
+		Namespace: v.Get("namespace"),
+		Name:      v.Get("name"),
+	}
+}
@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package router
+
+import (
+	"github.com/devtron-labs/devtron/api/restHandler"
+	"github.com/gorilla/mux"
+)
+
+type AuditLogRouter interface {
+	initAuditLogRouter(auditLogRouter *mux.Router)
+}
+
+type AuditLogRouterImpl struct {
+	auditLogRestHandler restHandler.AuditLogRestHandler
+}
+
+func NewAuditLogRouterImpl(auditLogRestHandler restHandler.AuditLogRestHandler) *AuditLogRouterImpl {
+	return &AuditLogRouterImpl{auditLogRestHandler: auditLogRestHandler}
+}
+
+func (router AuditLogRouterImpl) initAuditLogRouter(auditLogRouter *mux.Router) {
+	auditLogRouter.Path("").
+		HandlerFunc(router.auditLogRestHandler.GetAuditLogs).Methods("GET")
+	auditLogRouter.Path("/{id}").
+		HandlerFunc(router.auditLogRestHandler.GetAuditLogById).Methods("GET")
+}
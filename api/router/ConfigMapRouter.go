@@ -77,4 +77,20 @@ func (router ConfigMapRouterImpl) initConfigMapRouter(configRouter *mux.Router)
 
 	configRouter.Path("/bulk/patch").HandlerFunc(router.restHandler.ConfigSecretBulkPatch).Methods("POST")
 
+	configRouter.Path("/cs/rotate").
+		HandlerFunc(router.restHandler.CSRotate).Methods("POST")
+	configRouter.Path("/cs/rotate/{appId}/{id}").
+		HandlerFunc(router.restHandler.CSRotationCancel).Methods("DELETE")
+	configRouter.Path("/cs/rotate/history/{appId}").
+		HandlerFunc(router.restHandler.CSRotationHistory).Methods("GET")
+
+	configRouter.Path("/history/diff/{appId}").
+		HandlerFunc(router.restHandler.ConfigHistoryDiff).Methods("GET")
+	configRouter.Path("/history/restore").
+		HandlerFunc(router.restHandler.ConfigHistoryRestore).Methods("POST")
+	configRouter.Path("/history/{appId}/{id}").
+		HandlerFunc(router.restHandler.ConfigHistoryGet).Methods("GET")
+	configRouter.Path("/history/{appId}").
+		HandlerFunc(router.restHandler.ConfigHistoryList).Methods("GET")
+
 }
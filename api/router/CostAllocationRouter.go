@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package router
+
+import (
+	"github.com/devtron-labs/devtron/api/restHandler"
+	"github.com/gorilla/mux"
+)
+
+type CostAllocationRouter interface {
+	initCostAllocationRouter(costAllocationRouter *mux.Router)
+}
+
+type CostAllocationRouterImpl struct {
+	costAllocationRestHandler restHandler.CostAllocationRestHandler
+}
+
+func NewCostAllocationRouterImpl(costAllocationRestHandler restHandler.CostAllocationRestHandler) *CostAllocationRouterImpl {
+	router := &CostAllocationRouterImpl{
+		costAllocationRestHandler: costAllocationRestHandler,
+	}
+	return router
+}
+
+func (router CostAllocationRouterImpl) initCostAllocationRouter(costAllocationRouter *mux.Router) {
+	costAllocationRouter.Path("/price").
+		HandlerFunc(router.costAllocationRestHandler.GetPrices).Methods("GET")
+	costAllocationRouter.Path("/price").
+		HandlerFunc(router.costAllocationRestHandler.SavePrice).Methods("POST")
+	costAllocationRouter.Path("/compute").
+		HandlerFunc(router.costAllocationRestHandler.ComputeCost).Methods("POST")
+	costAllocationRouter.Path("/trend").
+		HandlerFunc(router.costAllocationRestHandler.GetCostTrend).Methods("GET")
+	costAllocationRouter.Path("/report").
+		HandlerFunc(router.costAllocationRestHandler.GetMonthlyReport).Methods("GET")
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package router
+
+import (
+	"github.com/devtron-labs/devtron/api/restHandler"
+	"github.com/gorilla/mux"
+)
+
+type GlobalVariableRouter interface {
+	initGlobalVariableRouter(globalVariableRouter *mux.Router)
+}
+
+type GlobalVariableRouterImpl struct {
+	globalVariableRestHandler restHandler.GlobalVariableRestHandler
+}
+
+func NewGlobalVariableRouterImpl(globalVariableRestHandler restHandler.GlobalVariableRestHandler) *GlobalVariableRouterImpl {
+	router := &GlobalVariableRouterImpl{
+		globalVariableRestHandler: globalVariableRestHandler,
+	}
+	return router
+}
+
+func (router GlobalVariableRouterImpl) initGlobalVariableRouter(globalVariableRouter *mux.Router) {
+	globalVariableRouter.Path("").
+		HandlerFunc(router.globalVariableRestHandler.CreateVariable).Methods("POST")
+	globalVariableRouter.Path("").
+		HandlerFunc(router.globalVariableRestHandler.UpdateVariable).Methods("PUT")
+	globalVariableRouter.Path("").
+		HandlerFunc(router.globalVariableRestHandler.GetAllVariables).Methods("GET")
+	globalVariableRouter.Path("/resolve").
+		HandlerFunc(router.globalVariableRestHandler.ResolveVariables).Methods("POST")
+	globalVariableRouter.Path("/{id}").
+		HandlerFunc(router.globalVariableRestHandler.GetVariableById).Methods("GET")
+	globalVariableRouter.Path("/{id}").
+		HandlerFunc(router.globalVariableRestHandler.DeleteVariable).Methods("DELETE")
+}
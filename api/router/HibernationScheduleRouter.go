@@ -0,0 +1,53 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package router
+
+import (
+	"github.com/devtron-labs/devtron/api/restHandler"
+	"github.com/gorilla/mux"
+)
+
+type HibernationScheduleRouter interface {
+	initHibernationScheduleRouter(hibernationScheduleRouter *mux.Router)
+}
+
+type HibernationScheduleRouterImpl struct {
+	hibernationScheduleRestHandler restHandler.HibernationScheduleRestHandler
+}
+
+func NewHibernationScheduleRouterImpl(hibernationScheduleRestHandler restHandler.HibernationScheduleRestHandler) *HibernationScheduleRouterImpl {
+	router := &HibernationScheduleRouterImpl{
+		hibernationScheduleRestHandler: hibernationScheduleRestHandler,
+	}
+	return router
+}
+
+func (router HibernationScheduleRouterImpl) initHibernationScheduleRouter(hibernationScheduleRouter *mux.Router) {
+	hibernationScheduleRouter.Path("/schedule").
+		HandlerFunc(router.hibernationScheduleRestHandler.GetSchedules).Methods("GET")
+	hibernationScheduleRouter.Path("/schedule").
+		HandlerFunc(router.hibernationScheduleRestHandler.SaveSchedule).Methods("POST")
+	hibernationScheduleRouter.Path("/schedule/{id}").
+		HandlerFunc(router.hibernationScheduleRestHandler.GetSchedule).Methods("GET")
+	hibernationScheduleRouter.Path("/schedule/{id}").
+		HandlerFunc(router.hibernationScheduleRestHandler.DeleteSchedule).Methods("DELETE")
+	hibernationScheduleRouter.Path("/schedule/{id}/keep-awake").
+		HandlerFunc(router.hibernationScheduleRestHandler.KeepAwake).Methods("POST")
+	hibernationScheduleRouter.Path("/savings").
+		HandlerFunc(router.hibernationScheduleRestHandler.GetSavings).Methods("GET")
+}
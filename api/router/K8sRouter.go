@@ -16,3 +16,36 @@
  */
 
 package router
+
+import (
+	"github.com/devtron-labs/devtron/api/restHandler"
+	"github.com/gorilla/mux"
+)
+
+type K8sResourceRouter interface {
+	initK8sResourceRouter(k8sResourceRouter *mux.Router)
+}
+
+type K8sResourceRouterImpl struct {
+	k8sResourceRestHandler restHandler.K8sResourceRestHandler
+}
+
+func NewK8sResourceRouterImpl(k8sResourceRestHandler restHandler.K8sResourceRestHandler) *K8sResourceRouterImpl {
+	return &K8sResourceRouterImpl{k8sResourceRestHandler: k8sResourceRestHandler}
+}
+
+// resources are addressed by the group, version, resource, namespace and name query params
+func (router K8sResourceRouterImpl) initK8sResourceRouter(k8sResourceRouter *mux.Router) {
+	k8sResourceRouter.Path("/{clusterId}/api-resources").
+		HandlerFunc(router.k8sResourceRestHandler.GetApiResources).Methods("GET")
+	k8sResourceRouter.Path("/{clusterId}/resources").
+		HandlerFunc(router.k8sResourceRestHandler.ListResources).Methods("GET")
+	k8sResourceRouter.Path("/{clusterId}/resource").
+		HandlerFunc(router.k8sResourceRestHandler.GetResource).Methods("GET")
+	k8sResourceRouter.Path("/{clusterId}/resource").
+		HandlerFunc(router.k8sResourceRestHandler.UpdateResource).Methods("PUT")
+	k8sResourceRouter.Path("/{clusterId}/resource").
+		HandlerFunc(router.k8sResourceRestHandler.DeleteResource).Methods("DELETE")
+	k8sResourceRouter.Path("/{clusterId}/resource/events").
+		HandlerFunc(router.k8sResourceRestHandler.GetResourceEvents).Methods("GET")
+}
@@ -50,6 +50,10 @@ func (router PipelineConfigRouterImpl) initPipelineConfigRouter(configRouter *mu
 	configRouter.Path("/template/{appId}/default/{chartRefId}").HandlerFunc(router.restHandler.GetAppOverrideForDefaultTemplate).Methods("GET")
 
 	configRouter.Path("/template").HandlerFunc(router.restHandler.ConfigureDeploymentTemplateForApp).Methods("POST")
+	configRouter.Path("/template/history/diff/{appId}").HandlerFunc(router.restHandler.GetDeploymentTemplateHistoryDiff).Methods("GET")
+	configRouter.Path("/template/history/{appId}/{id}").HandlerFunc(router.restHandler.GetDeploymentTemplateHistoryVersion).Methods("GET")
+	configRouter.Path("/template/history/{appId}").HandlerFunc(router.restHandler.GetDeploymentTemplateHistory).Methods("GET")
+	configRouter.Path("/template/env-diff/{appId}").HandlerFunc(router.restHandler.GetDeploymentTemplateEnvDiff).Methods("GET")
 	configRouter.Path("/template/{appId}/{chartRefId}").HandlerFunc(router.restHandler.GetDeploymentTemplate).Methods("GET")
 	configRouter.Path("/template/update").HandlerFunc(router.restHandler.UpdateAppOverride).Methods("POST")
 
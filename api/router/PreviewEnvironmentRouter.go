@@ -0,0 +1,53 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package router
+
+import (
+	"github.com/devtron-labs/devtron/api/restHandler"
+	"github.com/gorilla/mux"
+)
+
+type PreviewEnvironmentRouter interface {
+	initPreviewEnvironmentRouter(previewEnvironmentRouter *mux.Router)
+}
+
+type PreviewEnvironmentRouterImpl struct {
+	previewEnvironmentRestHandler restHandler.PreviewEnvironmentRestHandler
+}
+
+func NewPreviewEnvironmentRouterImpl(previewEnvironmentRestHandler restHandler.PreviewEnvironmentRestHandler) *PreviewEnvironmentRouterImpl {
+	router := &PreviewEnvironmentRouterImpl{
+		previewEnvironmentRestHandler: previewEnvironmentRestHandler,
+	}
+	return router
+}
+
+func (router PreviewEnvironmentRouterImpl) initPreviewEnvironmentRouter(previewEnvironmentRouter *mux.Router) {
+	previewEnvironmentRouter.Path("/app/{appId}").
+		HandlerFunc(router.previewEnvironmentRestHandler.GetPreviewEnvironments).Methods("GET")
+	previewEnvironmentRouter.Path("/app/{appId}/config").
+		HandlerFunc(router.previewEnvironmentRestHandler.GetConfigs).Methods("GET")
+	previewEnvironmentRouter.Path("/app/{appId}/config").
+		HandlerFunc(router.previewEnvironmentRestHandler.SaveConfig).Methods("POST")
+	previewEnvironmentRouter.Path("/app/{appId}/config/{id}").
+		HandlerFunc(router.previewEnvironmentRestHandler.DeleteConfig).Methods("DELETE")
+	previewEnvironmentRouter.Path("/{id}").
+		HandlerFunc(router.previewEnvironmentRestHandler.GetPreviewEnvironment).Methods("GET")
+	previewEnvironmentRouter.Path("/{id}").
+		HandlerFunc(router.previewEnvironmentRestHandler.DeletePreviewEnvironment).Methods("DELETE")
+}
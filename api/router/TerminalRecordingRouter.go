@@ -0,0 +1,50 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package router
+
+import (
+	"github.com/devtron-labs/devtron/api/restHandler"
+	"github.com/gorilla/mux"
+)
+
+type TerminalRecordingRouter interface {
+	initTerminalRecordingRouter(terminalRecordingRouter *mux.Router)
+}
+
+type TerminalRecordingRouterImpl struct {
+	terminalRecordingRestHandler restHandler.TerminalRecordingRestHandler
+}
+
+func NewTerminalRecordingRouterImpl(terminalRecordingRestHandler restHandler.TerminalRecordingRestHandler) *TerminalRecordingRouterImpl {
+	return &TerminalRecordingRouterImpl{terminalRecordingRestHandler: terminalRecordingRestHandler}
+}
+
+func (router TerminalRecordingRouterImpl) initTerminalRecordingRouter(terminalRecordingRouter *mux.Router) {
+	terminalRecordingRouter.Path("").
+		HandlerFunc(router.terminalRecordingRestHandler.GetRecordings).Methods("GET")
+	terminalRecordingRouter.Path("/config").
+		HandlerFunc(router.terminalRecordingRestHandler.GetConfigs).Methods("GET")
+	terminalRecordingRouter.Path("/config").
+		HandlerFunc(router.terminalRecordingRestHandler.SaveConfig).Methods("PUT")
+	terminalRecordingRouter.Path("/{id}").
+		HandlerFunc(router.terminalRecordingRestHandler.GetRecordingById).Methods("GET")
+	terminalRecordingRouter.Path("/{id}/replay").
+		HandlerFunc(router.terminalRecordingRestHandler.ReplayRecording).Methods("GET")
+	terminalRecordingRouter.Path("/{id}/download").
+		HandlerFunc(router.terminalRecordingRestHandler.DownloadRecording).Methods("GET")
+}
@@ -84,6 +84,20 @@ type MuxRouter struct {
 	WebhookListenerRouter            WebhookListenerRouter
 	appLabelsRouter                  AppLabelRouter
 	coreAppRouter                    CoreAppRouter
+	globalVariableRouter             GlobalVariableRouter
+	apiTokenRouter                   user.ApiTokenRouter
+	auditLogRouter                   AuditLogRouter
+	scimRouter                       user.ScimRouter
+	customRoleRouter                 user.CustomRoleRouter
+	rbacExplainRouter                user.RbacExplainRouter
+	accessRequestRouter              user.AccessRequestRouter
+	localUserAuthRouter              user.LocalUserAuthRouter
+	userSessionRouter                user.UserSessionRouter
+	terminalRecordingRouter          TerminalRecordingRouter
+	k8sResourceRouter                K8sResourceRouter
+	previewEnvironmentRouter         PreviewEnvironmentRouter
+	costAllocationRouter             CostAllocationRouter
+	hibernationScheduleRouter        HibernationScheduleRouter
 }
 
 func NewMuxRouter(logger *zap.SugaredLogger, HelmRouter HelmRouter, PipelineConfigRouter PipelineConfigRouter,
@@ -103,7 +117,14 @@ func NewMuxRouter(logger *zap.SugaredLogger, HelmRouter HelmRouter, PipelineConf
 	ReleaseMetricsRouter ReleaseMetricsRouter, deploymentGroupRouter DeploymentGroupRouter, batchOperationRouter BatchOperationRouter,
 	chartGroupRouter ChartGroupRouter, testSuitRouter TestSuitRouter, imageScanRouter ImageScanRouter,
 	policyRouter PolicyRouter, gitOpsConfigRouter GitOpsConfigRouter, dashboardRouter dashboard.DashboardRouter, attributesRouter AttributesRouter,
-	commonRouter CommonRouter, grafanaRouter GrafanaRouter, ssoLoginRouter sso.SsoLoginRouter, telemetryRouter TelemetryRouter, telemetryWatcher telemetry.TelemetryEventClient, bulkUpdateRouter BulkUpdateRouter, webhookListenerRouter WebhookListenerRouter, appLabelsRouter AppLabelRouter, coreAppRouter CoreAppRouter) *MuxRouter {
+	commonRouter CommonRouter, grafanaRouter GrafanaRouter, ssoLoginRouter sso.SsoLoginRouter, telemetryRouter TelemetryRouter, telemetryWatcher telemetry.TelemetryEventClient, bulkUpdateRouter BulkUpdateRouter, webhookListenerRouter WebhookListenerRouter, appLabelsRouter AppLabelRouter, coreAppRouter CoreAppRouter,
+	globalVariableRouter GlobalVariableRouter, apiTokenRouter user.ApiTokenRouter,
+	auditLogRouter AuditLogRouter, scimRouter user.ScimRouter, customRoleRouter user.CustomRoleRouter,
+	rbacExplainRouter user.RbacExplainRouter, accessRequestRouter user.AccessRequestRouter,
+	localUserAuthRouter user.LocalUserAuthRouter, userSessionRouter user.UserSessionRouter,
+	terminalRecordingRouter TerminalRecordingRouter, k8sResourceRouter K8sResourceRouter,
+	previewEnvironmentRouter PreviewEnvironmentRouter, costAllocationRouter CostAllocationRouter,
+	hibernationScheduleRouter HibernationScheduleRouter) *MuxRouter {
 	r := &MuxRouter{
 		Router:                           mux.NewRouter(),
 		HelmRouter:                       HelmRouter,
@@ -152,6 +173,20 @@ func NewMuxRouter(logger *zap.SugaredLogger, HelmRouter HelmRouter, PipelineConf
 		WebhookListenerRouter:            webhookListenerRouter,
 		appLabelsRouter:                  appLabelsRouter,
 		coreAppRouter:                    coreAppRouter,
+		globalVariableRouter:             globalVariableRouter,
+		apiTokenRouter:                   apiTokenRouter,
+		auditLogRouter:                   auditLogRouter,
+		scimRouter:                       scimRouter,
+		customRoleRouter:                 customRoleRouter,
+		rbacExplainRouter:                rbacExplainRouter,
+		accessRequestRouter:              accessRequestRouter,
+		localUserAuthRouter:              localUserAuthRouter,
+		userSessionRouter:                userSessionRouter,
+		terminalRecordingRouter:          terminalRecordingRouter,
+		k8sResourceRouter:                k8sResourceRouter,
+		previewEnvironmentRouter:         previewEnvironmentRouter,
+		costAllocationRouter:             costAllocationRouter,
+		hibernationScheduleRouter:        hibernationScheduleRouter,
 	}
 	return r
 }
@@ -237,6 +272,9 @@ func (r MuxRouter) Init() {
 	userRouter := r.Router.PathPrefix("/orchestrator/user").Subrouter()
 	r.UserRouter.InitUserRouter(userRouter)
 
+	apiTokenRouter := r.Router.PathPrefix("/orchestrator/api-token").Subrouter()
+	r.apiTokenRouter.InitApiTokenRouter(apiTokenRouter)
+
 	chartRefRouter := r.Router.PathPrefix("/orchestrator/chartref").Subrouter()
 	r.ChartRefRouter.initChartRefRouter(chartRefRouter)
 
@@ -271,6 +309,45 @@ func (r MuxRouter) Init() {
 	attributeRouter := r.Router.PathPrefix("/orchestrator/attributes").Subrouter()
 	r.attributesRouter.initAttributesRouter(attributeRouter)
 
+	globalVariableRouter := r.Router.PathPrefix("/orchestrator/variables").Subrouter()
+	r.globalVariableRouter.initGlobalVariableRouter(globalVariableRouter)
+
+	auditLogRouter := r.Router.PathPrefix("/orchestrator/audit-log").Subrouter()
+	r.auditLogRouter.initAuditLogRouter(auditLogRouter)
+
+	terminalRecordingRouter := r.Router.PathPrefix("/orchestrator/terminal-recording").Subrouter()
+	r.terminalRecordingRouter.initTerminalRecordingRouter(terminalRecordingRouter)
+
+	k8sResourceRouter := r.Router.PathPrefix("/orchestrator/k8s/cluster").Subrouter()
+	r.k8sResourceRouter.initK8sResourceRouter(k8sResourceRouter)
+
+	previewEnvironmentRouter := r.Router.PathPrefix("/orchestrator/preview-environment").Subrouter()
+	r.previewEnvironmentRouter.initPreviewEnvironmentRouter(previewEnvironmentRouter)
+
+	costAllocationRouter := r.Router.PathPrefix("/orchestrator/cost").Subrouter()
+	r.costAllocationRouter.initCostAllocationRouter(costAllocationRouter)
+
+	hibernationScheduleRouter := r.Router.PathPrefix("/orchestrator/hibernation").Subrouter()
+	r.hibernationScheduleRouter.initHibernationScheduleRouter(hibernationScheduleRouter)
+
+	scimRouter := r.Router.PathPrefix("/orchestrator/scim/v2").Subrouter()
+	r.scimRouter.InitScimRouter(scimRouter)
+
+	customRoleRouter := r.Router.PathPrefix("/orchestrator/custom-role").Subrouter()
+	r.customRoleRouter.InitCustomRoleRouter(customRoleRouter)
+
+	rbacRouter := r.Router.PathPrefix("/orchestrator/rbac").Subrouter()
+	r.rbacExplainRouter.InitRbacExplainRouter(rbacRouter)
+
+	accessRequestRouter := r.Router.PathPrefix("/orchestrator/access-request").Subrouter()
+	r.accessRequestRouter.InitAccessRequestRouter(accessRequestRouter)
+
+	localUserAuthRouter := r.Router.PathPrefix("/orchestrator/local-auth").Subrouter()
+	r.localUserAuthRouter.InitLocalUserAuthRouter(localUserAuthRouter)
+
+	userSessionRouter := r.Router.PathPrefix("/orchestrator/user-session").Subrouter()
+	r.userSessionRouter.InitUserSessionRouter(userSessionRouter)
+
 	dashboardRouter := r.Router.PathPrefix("/dashboard").Subrouter()
 	r.dashboardRouter.InitDashboardRouter(dashboardRouter)
 
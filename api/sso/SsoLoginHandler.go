@@ -38,6 +38,11 @@ type SsoLoginRestHandler interface {
 	GetAllSSOLoginConfig(w http.ResponseWriter, r *http.Request)
 	GetSSOLoginConfig(w http.ResponseWriter, r *http.Request)
 	GetSSOLoginConfigByName(w http.ResponseWriter, r *http.Request)
+
+	CreateSsoGroupMapping(w http.ResponseWriter, r *http.Request)
+	UpdateSsoGroupMapping(w http.ResponseWriter, r *http.Request)
+	GetAllSsoGroupMappings(w http.ResponseWriter, r *http.Request)
+	DeleteSsoGroupMapping(w http.ResponseWriter, r *http.Request)
 }
 
 type SsoLoginRestHandlerImpl struct {
@@ -46,13 +51,16 @@ type SsoLoginRestHandlerImpl struct {
 	enforcer        casbin.Enforcer
 	userService     user.UserService
 	ssoLoginService sso.SSOLoginService
+
+	ssoGroupMappingService user.SsoGroupMappingService
 }
 
 func NewSsoLoginRestHandlerImpl(validator *validator.Validate,
 	logger *zap.SugaredLogger, enforcer casbin.Enforcer, userService user.UserService,
-	ssoLoginService sso.SSOLoginService) *SsoLoginRestHandlerImpl {
+	ssoLoginService sso.SSOLoginService, ssoGroupMappingService user.SsoGroupMappingService) *SsoLoginRestHandlerImpl {
 	handler := &SsoLoginRestHandlerImpl{validator: validator, logger: logger,
-		enforcer: enforcer, userService: userService, ssoLoginService: ssoLoginService}
+		enforcer: enforcer, userService: userService, ssoLoginService: ssoLoginService,
+		ssoGroupMappingService: ssoGroupMappingService}
 	return handler
 }
 
@@ -182,3 +190,130 @@ func (handler SsoLoginRestHandlerImpl) GetSSOLoginConfigByName(w http.ResponseWr
 	}
 	common.WriteJsonResp(w, nil, res, http.StatusOK)
 }
+
+func (handler SsoLoginRestHandlerImpl) CreateSsoGroupMapping(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	var dto bean.SsoGroupMapping
+	err = decoder.Decode(&dto)
+	if err != nil {
+		handler.logger.Errorw("request err, CreateSsoGroupMapping", "err", err, "payload", dto)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	err = handler.validator.Struct(dto)
+	if err != nil {
+		handler.logger.Errorw("validation err, CreateSsoGroupMapping", "err", err, "payload", dto)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	dto.UserId = userId
+
+	token := r.Header.Get("token")
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceGlobal, casbin.ActionCreate, "*"); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+
+	handler.logger.Infow("request payload, CreateSsoGroupMapping", "payload", dto)
+	res, err := handler.ssoGroupMappingService.CreateMapping(&dto)
+	if err != nil {
+		handler.logger.Errorw("service err, CreateSsoGroupMapping", "err", err, "payload", dto)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+func (handler SsoLoginRestHandlerImpl) UpdateSsoGroupMapping(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	var dto bean.SsoGroupMapping
+	err = decoder.Decode(&dto)
+	if err != nil {
+		handler.logger.Errorw("request err, UpdateSsoGroupMapping", "err", err, "payload", dto)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	err = handler.validator.Struct(dto)
+	if err != nil {
+		handler.logger.Errorw("validation err, UpdateSsoGroupMapping", "err", err, "payload", dto)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	dto.UserId = userId
+
+	token := r.Header.Get("token")
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceGlobal, casbin.ActionUpdate, "*"); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+
+	handler.logger.Infow("request payload, UpdateSsoGroupMapping", "payload", dto)
+	res, err := handler.ssoGroupMappingService.UpdateMapping(&dto)
+	if err != nil {
+		handler.logger.Errorw("service err, UpdateSsoGroupMapping", "err", err, "payload", dto)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+func (handler SsoLoginRestHandlerImpl) GetAllSsoGroupMappings(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+
+	token := r.Header.Get("token")
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceGlobal, casbin.ActionGet, "*"); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+
+	res, err := handler.ssoGroupMappingService.GetAllMappings()
+	if err != nil {
+		handler.logger.Errorw("service err, GetAllSsoGroupMappings", "err", err)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+func (handler SsoLoginRestHandlerImpl) DeleteSsoGroupMapping(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		handler.logger.Errorw("request err, DeleteSsoGroupMapping", "err", err, "id", vars["id"])
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+
+	token := r.Header.Get("token")
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceGlobal, casbin.ActionDelete, "*"); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+
+	err = handler.ssoGroupMappingService.DeleteMapping(id, userId)
+	if err != nil {
+		handler.logger.Errorw("service err, DeleteSsoGroupMapping", "err", err, "id", id)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, id, http.StatusOK)
+}
@@ -43,6 +43,14 @@ func (router SsoLoginRouterImpl) InitSsoLoginRouter(userAuthRouter *mux.Router)
 		HandlerFunc(router.handler.UpdateSSOLoginConfig).Methods("PUT")
 	userAuthRouter.Path("/list").
 		HandlerFunc(router.handler.GetAllSSOLoginConfig).Methods("GET")
+	userAuthRouter.Path("/group-mapping").
+		HandlerFunc(router.handler.CreateSsoGroupMapping).Methods("POST")
+	userAuthRouter.Path("/group-mapping").
+		HandlerFunc(router.handler.UpdateSsoGroupMapping).Methods("PUT")
+	userAuthRouter.Path("/group-mapping").
+		HandlerFunc(router.handler.GetAllSsoGroupMappings).Methods("GET")
+	userAuthRouter.Path("/group-mapping/{id}").
+		HandlerFunc(router.handler.DeleteSsoGroupMapping).Methods("DELETE")
 	userAuthRouter.Path("/{id}").
 		HandlerFunc(router.handler.GetSSOLoginConfig).Methods("GET")
 	userAuthRouter.Path("").Methods("GET").
@@ -0,0 +1,236 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/devtron-labs/devtron/api/bean"
+	"github.com/devtron-labs/devtron/api/restHandler/common"
+	"github.com/devtron-labs/devtron/pkg/user"
+	"github.com/devtron-labs/devtron/pkg/user/casbin"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"gopkg.in/go-playground/validator.v9"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type AccessRequestRestHandler interface {
+	CreateAccessRequest(w http.ResponseWriter, r *http.Request)
+	GetMyAccessRequests(w http.ResponseWriter, r *http.Request)
+	GetPendingAccessRequests(w http.ResponseWriter, r *http.Request)
+	GetAccessRequestById(w http.ResponseWriter, r *http.Request)
+	ApproveAccessRequest(w http.ResponseWriter, r *http.Request)
+	RejectAccessRequest(w http.ResponseWriter, r *http.Request)
+	RevokeAccessRequest(w http.ResponseWriter, r *http.Request)
+}
+
+type AccessRequestRestHandlerImpl struct {
+	logger               *zap.SugaredLogger
+	accessRequestService user.AccessRequestService
+	userService          user.UserService
+	enforcer             casbin.Enforcer
+	validator            *validator.Validate
+}
+
+func NewAccessRequestRestHandlerImpl(logger *zap.SugaredLogger, accessRequestService user.AccessRequestService, userService user.UserService,
+	enforcer casbin.Enforcer, validator *validator.Validate) *AccessRequestRestHandlerImpl {
+	return &AccessRequestRestHandlerImpl{
+		logger:               logger,
+		accessRequestService: accessRequestService,
+		userService:          userService,
+		enforcer:             enforcer,
+		validator:            validator,
+	}
+}
+
+// CreateAccessRequest is open to all users, access is only granted once approved
+func (handler AccessRequestRestHandlerImpl) CreateAccessRequest(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	var request bean.AccessRequest
+	err = decoder.Decode(&request)
+	if err != nil {
+		handler.logger.Errorw("request err, CreateAccessRequest", "err", err, "payload", request)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	request.UserId = userId
+	err = handler.validator.Struct(request)
+	if err != nil {
+		handler.logger.Errorw("validation err, CreateAccessRequest", "err", err, "payload", request)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	handler.logger.Infow("request payload, CreateAccessRequest", "payload", request)
+	res, err := handler.accessRequestService.CreateAccessRequest(&request)
+	if err != nil {
+		handler.logger.Errorw("service err, CreateAccessRequest", "err", err, "payload", request)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+func (handler AccessRequestRestHandlerImpl) GetMyAccessRequests(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	res, err := handler.accessRequestService.GetAccessRequestsByUserId(userId)
+	if err != nil {
+		handler.logger.Errorw("service err, GetMyAccessRequests", "err", err, "userId", userId)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+// GetPendingAccessRequests returns the pending requests the logged in user can approve
+func (handler AccessRequestRestHandlerImpl) GetPendingAccessRequests(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	requests, err := handler.accessRequestService.GetPendingAccessRequests()
+	if err != nil {
+		handler.logger.Errorw("service err, GetPendingAccessRequests", "err", err)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	// RBAC enforcer applying
+	token := r.Header.Get("token")
+	res := make([]*bean.AccessRequest, 0)
+	for _, request := range requests {
+		if request.UserId != userId && handler.canApprove(token, request.RoleFilter) {
+			res = append(res, request)
+		}
+	}
+	//RBAC enforcer Ends
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+func (handler AccessRequestRestHandlerImpl) GetAccessRequestById(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		handler.logger.Errorw("request err, GetAccessRequestById", "err", err, "id", id)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	res, err := handler.accessRequestService.GetAccessRequestById(id)
+	if err != nil {
+		handler.logger.Errorw("service err, GetAccessRequestById", "err", err, "id", id)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+
+	// RBAC enforcer applying
+	token := r.Header.Get("token")
+	if res.UserId != userId && !handler.canApprove(token, res.RoleFilter) {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+	//RBAC enforcer Ends
+
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+func (handler AccessRequestRestHandlerImpl) ApproveAccessRequest(w http.ResponseWriter, r *http.Request) {
+	handler.changeAccessRequest(w, r, "ApproveAccessRequest", false, handler.accessRequestService.ApproveAccessRequest)
+}
+
+func (handler AccessRequestRestHandlerImpl) RejectAccessRequest(w http.ResponseWriter, r *http.Request) {
+	handler.changeAccessRequest(w, r, "RejectAccessRequest", false, handler.accessRequestService.RejectAccessRequest)
+}
+
+// RevokeAccessRequest lets requesting users cancel or give up their own access, others need approver access
+func (handler AccessRequestRestHandlerImpl) RevokeAccessRequest(w http.ResponseWriter, r *http.Request) {
+	handler.changeAccessRequest(w, r, "RevokeAccessRequest", true, handler.accessRequestService.RevokeAccessRequest)
+}
+
+func (handler AccessRequestRestHandlerImpl) changeAccessRequest(w http.ResponseWriter, r *http.Request, name string, allowRequester bool,
+	change func(action *bean.AccessRequestAction) (*bean.AccessRequest, error)) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		handler.logger.Errorw("request err, "+name, "err", err, "id", id)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	var action bean.AccessRequestAction
+	if r.ContentLength != 0 {
+		err = json.NewDecoder(r.Body).Decode(&action)
+		if err != nil {
+			handler.logger.Errorw("request err, "+name, "err", err, "id", id)
+			common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+			return
+		}
+	}
+	action.Id = id
+	action.UserId = userId
+	request, err := handler.accessRequestService.GetAccessRequestById(id)
+	if err != nil {
+		handler.logger.Errorw("service err, "+name, "err", err, "id", id)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+
+	// RBAC enforcer applying
+	token := r.Header.Get("token")
+	if !(allowRequester && request.UserId == userId) && !handler.canApprove(token, request.RoleFilter) {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+	//RBAC enforcer Ends
+
+	handler.logger.Infow("request payload, "+name, "payload", action)
+	res, err := change(&action)
+	if err != nil {
+		handler.logger.Errorw("service err, "+name, "err", err, "payload", action)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+// canApprove applies the same check as assigning the role filter to a user directly
+func (handler AccessRequestRestHandlerImpl) canApprove(token string, roleFilter bean.RoleFilter) bool {
+	if len(roleFilter.Team) > 0 {
+		return handler.enforcer.Enforce(token, casbin.ResourceUser, casbin.ActionUpdate, strings.ToLower(roleFilter.Team))
+	}
+	return handler.enforcer.Enforce(token, casbin.ResourceUser, casbin.ActionUpdate, "*")
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"github.com/gorilla/mux"
+)
+
+type AccessRequestRouter interface {
+	InitAccessRequestRouter(accessRequestRouter *mux.Router)
+}
+
+type AccessRequestRouterImpl struct {
+	accessRequestRestHandler AccessRequestRestHandler
+}
+
+func NewAccessRequestRouterImpl(accessRequestRestHandler AccessRequestRestHandler) *AccessRequestRouterImpl {
+	return &AccessRequestRouterImpl{accessRequestRestHandler: accessRequestRestHandler}
+}
+
+func (router AccessRequestRouterImpl) InitAccessRequestRouter(accessRequestRouter *mux.Router) {
+	accessRequestRouter.Path("").
+		HandlerFunc(router.accessRequestRestHandler.CreateAccessRequest).Methods("POST")
+	accessRequestRouter.Path("").
+		HandlerFunc(router.accessRequestRestHandler.GetMyAccessRequests).Methods("GET")
+	accessRequestRouter.Path("/pending").
+		HandlerFunc(router.accessRequestRestHandler.GetPendingAccessRequests).Methods("GET")
+	accessRequestRouter.Path("/{id}").
+		HandlerFunc(router.accessRequestRestHandler.GetAccessRequestById).Methods("GET")
+	accessRequestRouter.Path("/{id}/approve").
+		HandlerFunc(router.accessRequestRestHandler.ApproveAccessRequest).Methods("PUT")
+	accessRequestRouter.Path("/{id}/reject").
+		HandlerFunc(router.accessRequestRestHandler.RejectAccessRequest).Methods("PUT")
+	accessRequestRouter.Path("/{id}/revoke").
+		HandlerFunc(router.accessRequestRestHandler.RevokeAccessRequest).Methods("PUT")
+}
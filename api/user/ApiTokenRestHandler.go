@@ -0,0 +1,177 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/devtron-labs/devtron/api/bean"
+	"github.com/devtron-labs/devtron/api/restHandler/common"
+	"github.com/devtron-labs/devtron/pkg/user"
+	"github.com/devtron-labs/devtron/pkg/user/casbin"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"gopkg.in/go-playground/validator.v9"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type ApiTokenRestHandler interface {
+	CreateApiToken(w http.ResponseWriter, r *http.Request)
+	GetAllApiTokens(w http.ResponseWriter, r *http.Request)
+	RevokeApiToken(w http.ResponseWriter, r *http.Request)
+}
+
+type ApiTokenRestHandlerImpl struct {
+	logger          *zap.SugaredLogger
+	apiTokenService user.ApiTokenService
+	userService     user.UserService
+	enforcer        casbin.Enforcer
+	validator       *validator.Validate
+}
+
+func NewApiTokenRestHandlerImpl(logger *zap.SugaredLogger, apiTokenService user.ApiTokenService, userService user.UserService,
+	enforcer casbin.Enforcer, validator *validator.Validate) *ApiTokenRestHandlerImpl {
+	return &ApiTokenRestHandlerImpl{
+		logger:          logger,
+		apiTokenService: apiTokenService,
+		userService:     userService,
+		enforcer:        enforcer,
+		validator:       validator,
+	}
+}
+
+func (handler ApiTokenRestHandlerImpl) CreateApiToken(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	var request bean.ApiTokenRequest
+	err = decoder.Decode(&request)
+	if err != nil {
+		handler.logger.Errorw("request err, CreateApiToken", "err", err, "payload", request)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	request.UserId = userId
+	err = handler.validator.Struct(request)
+	if err != nil {
+		handler.logger.Errorw("validation err, CreateApiToken", "err", err, "payload", request)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+
+	// RBAC enforcer applying
+	token := r.Header.Get("token")
+	if !handler.isAuthorized(token, casbin.ActionCreate, request.RoleFilters) {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+	//RBAC enforcer Ends
+
+	handler.logger.Infow("request payload, CreateApiToken", "name", request.Name, "expiresOn", request.ExpiresOn)
+	res, err := handler.apiTokenService.CreateApiToken(&request)
+	if err != nil {
+		handler.logger.Errorw("service err, CreateApiToken", "err", err, "name", request.Name)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+// GetAllApiTokens lists the active tokens whose roles the logged in user is allowed to view
+func (handler ApiTokenRestHandlerImpl) GetAllApiTokens(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	res, err := handler.apiTokenService.GetAllActiveApiTokens()
+	if err != nil {
+		handler.logger.Errorw("service err, GetAllApiTokens", "err", err)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+
+	token := r.Header.Get("token")
+	authorized := make([]*bean.ApiToken, 0)
+	for _, apiToken := range res {
+		if handler.isAuthorized(token, casbin.ActionGet, apiToken.RoleFilters) {
+			authorized = append(authorized, apiToken)
+		}
+	}
+	common.WriteJsonResp(w, nil, authorized, http.StatusOK)
+}
+
+func (handler ApiTokenRestHandlerImpl) RevokeApiToken(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		handler.logger.Errorw("request err, RevokeApiToken", "err", err, "id", id)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	apiToken, err := handler.apiTokenService.GetActiveApiTokenById(id)
+	if err != nil {
+		handler.logger.Errorw("service err, RevokeApiToken", "err", err, "id", id)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+
+	// RBAC enforcer applying
+	token := r.Header.Get("token")
+	if !handler.isAuthorized(token, casbin.ActionDelete, apiToken.RoleFilters) {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+	//RBAC enforcer Ends
+
+	err = handler.apiTokenService.RevokeApiToken(id, userId)
+	if err != nil {
+		handler.logger.Errorw("service err, RevokeApiToken", "err", err, "id", id)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, true, http.StatusOK)
+}
+
+// isAuthorized requires user management access on the team of every role filter,
+// role filters without a team can only be managed with access on all teams
+func (handler ApiTokenRestHandlerImpl) isAuthorized(token string, action string, roleFilters []bean.RoleFilter) bool {
+	if len(roleFilters) == 0 {
+		return handler.enforcer.Enforce(token, casbin.ResourceUser, action, "*")
+	}
+	for _, filter := range roleFilters {
+		team := "*"
+		if len(filter.Team) > 0 {
+			team = strings.ToLower(filter.Team)
+		}
+		if ok := handler.enforcer.Enforce(token, casbin.ResourceUser, action, team); !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,43 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"github.com/gorilla/mux"
+)
+
+type ApiTokenRouter interface {
+	InitApiTokenRouter(apiTokenRouter *mux.Router)
+}
+
+type ApiTokenRouterImpl struct {
+	apiTokenRestHandler ApiTokenRestHandler
+}
+
+func NewApiTokenRouterImpl(apiTokenRestHandler ApiTokenRestHandler) *ApiTokenRouterImpl {
+	return &ApiTokenRouterImpl{apiTokenRestHandler: apiTokenRestHandler}
+}
+
+func (router ApiTokenRouterImpl) InitApiTokenRouter(apiTokenRouter *mux.Router) {
+	apiTokenRouter.Path("").
+		HandlerFunc(router.apiTokenRestHandler.CreateApiToken).Methods("POST")
+	apiTokenRouter.Path("").
+		HandlerFunc(router.apiTokenRestHandler.GetAllApiTokens).Methods("GET")
+	apiTokenRouter.Path("/{id}").
+		HandlerFunc(router.apiTokenRestHandler.RevokeApiToken).Methods("DELETE")
+}
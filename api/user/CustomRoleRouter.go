@@ -0,0 +1,47 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"github.com/gorilla/mux"
+)
+
+type CustomRoleRouter interface {
+	InitCustomRoleRouter(customRoleRouter *mux.Router)
+}
+
+type CustomRoleRouterImpl struct {
+	customRoleRestHandler CustomRoleRestHandler
+}
+
+func NewCustomRoleRouterImpl(customRoleRestHandler CustomRoleRestHandler) *CustomRoleRouterImpl {
+	return &CustomRoleRouterImpl{customRoleRestHandler: customRoleRestHandler}
+}
+
+func (router CustomRoleRouterImpl) InitCustomRoleRouter(customRoleRouter *mux.Router) {
+	customRoleRouter.Path("").
+		HandlerFunc(router.customRoleRestHandler.CreateCustomRole).Methods("POST")
+	customRoleRouter.Path("").
+		HandlerFunc(router.customRoleRestHandler.UpdateCustomRole).Methods("PUT")
+	customRoleRouter.Path("").
+		HandlerFunc(router.customRoleRestHandler.GetAllCustomRoles).Methods("GET")
+	customRoleRouter.Path("/{id}").
+		HandlerFunc(router.customRoleRestHandler.GetCustomRoleById).Methods("GET")
+	customRoleRouter.Path("/{id}").
+		HandlerFunc(router.customRoleRestHandler.DeleteCustomRole).Methods("DELETE")
+}
@@ -0,0 +1,215 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/devtron-labs/devtron/api/bean"
+	"github.com/devtron-labs/devtron/api/restHandler/common"
+	"github.com/devtron-labs/devtron/pkg/user"
+	"github.com/devtron-labs/devtron/pkg/user/casbin"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"gopkg.in/go-playground/validator.v9"
+	"net/http"
+	"strconv"
+)
+
+type LocalUserAuthRestHandler interface {
+	SetPassword(w http.ResponseWriter, r *http.Request)
+	ChangePassword(w http.ResponseWriter, r *http.Request)
+	EnrollMfa(w http.ResponseWriter, r *http.Request)
+	VerifyMfa(w http.ResponseWriter, r *http.Request)
+	ResetMfa(w http.ResponseWriter, r *http.Request)
+	Unlock(w http.ResponseWriter, r *http.Request)
+}
+
+type LocalUserAuthRestHandlerImpl struct {
+	logger               *zap.SugaredLogger
+	localUserAuthService user.LocalUserAuthService
+	userService          user.UserService
+	enforcer             casbin.Enforcer
+	validator            *validator.Validate
+}
+
+func NewLocalUserAuthRestHandlerImpl(logger *zap.SugaredLogger, localUserAuthService user.LocalUserAuthService, userService user.UserService,
+	enforcer casbin.Enforcer, validator *validator.Validate) *LocalUserAuthRestHandlerImpl {
+	return &LocalUserAuthRestHandlerImpl{
+		logger:               logger,
+		localUserAuthService: localUserAuthService,
+		userService:          userService,
+		enforcer:             enforcer,
+		validator:            validator,
+	}
+}
+
+// SetPassword sets the password of any user, it needs user update access on all teams
+func (handler LocalUserAuthRestHandlerImpl) SetPassword(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	var request bean.LocalPasswordRequest
+	err = decoder.Decode(&request)
+	if err != nil {
+		handler.logger.Errorw("request err, SetPassword", "err", err, "userId", request.UserId)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	request.ActionUserId = userId
+	err = handler.validator.Struct(request)
+	if err != nil {
+		handler.logger.Errorw("validation err, SetPassword", "err", err, "userId", request.UserId)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+
+	// RBAC enforcer applying
+	token := r.Header.Get("token")
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceUser, casbin.ActionUpdate, "*"); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+	//RBAC enforcer Ends
+
+	err = handler.localUserAuthService.SetPassword(&request)
+	if err != nil {
+		handler.logger.Errorw("service err, SetPassword", "err", err, "userId", request.UserId)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, true, http.StatusOK)
+}
+
+func (handler LocalUserAuthRestHandlerImpl) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	var request bean.LocalPasswordRequest
+	err = decoder.Decode(&request)
+	if err != nil {
+		handler.logger.Errorw("request err, ChangePassword", "err", err, "userId", userId)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	request.UserId = userId
+	request.ActionUserId = userId
+	err = handler.validator.Struct(request)
+	if err != nil {
+		handler.logger.Errorw("validation err, ChangePassword", "err", err, "userId", userId)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	err = handler.localUserAuthService.ChangePassword(&request)
+	if err != nil {
+		handler.logger.Errorw("service err, ChangePassword", "err", err, "userId", userId)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, true, http.StatusOK)
+}
+
+func (handler LocalUserAuthRestHandlerImpl) EnrollMfa(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	res, err := handler.localUserAuthService.EnrollMfa(userId)
+	if err != nil {
+		handler.logger.Errorw("service err, EnrollMfa", "err", err, "userId", userId)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+func (handler LocalUserAuthRestHandlerImpl) VerifyMfa(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	var request bean.MfaVerifyRequest
+	err = decoder.Decode(&request)
+	if err != nil {
+		handler.logger.Errorw("request err, VerifyMfa", "err", err, "userId", userId)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	request.UserId = userId
+	err = handler.validator.Struct(request)
+	if err != nil {
+		handler.logger.Errorw("validation err, VerifyMfa", "err", err, "userId", userId)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	err = handler.localUserAuthService.VerifyMfa(&request)
+	if err != nil {
+		handler.logger.Errorw("service err, VerifyMfa", "err", err, "userId", userId)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, true, http.StatusOK)
+}
+
+func (handler LocalUserAuthRestHandlerImpl) ResetMfa(w http.ResponseWriter, r *http.Request) {
+	handler.adminAction(w, r, "ResetMfa", handler.localUserAuthService.ResetMfa)
+}
+
+func (handler LocalUserAuthRestHandlerImpl) Unlock(w http.ResponseWriter, r *http.Request) {
+	handler.adminAction(w, r, "Unlock", handler.localUserAuthService.Unlock)
+}
+
+func (handler LocalUserAuthRestHandlerImpl) adminAction(w http.ResponseWriter, r *http.Request, name string, action func(userId int32, actionUserId int32) error) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["userId"])
+	if err != nil {
+		handler.logger.Errorw("request err, "+name, "err", err, "userId", vars["userId"])
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+
+	// RBAC enforcer applying
+	token := r.Header.Get("token")
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceUser, casbin.ActionUpdate, "*"); !ok {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+	//RBAC enforcer Ends
+
+	err = action(int32(id), userId)
+	if err != nil {
+		handler.logger.Errorw("service err, "+name, "err", err, "userId", id)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, true, http.StatusOK)
+}
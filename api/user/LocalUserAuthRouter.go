@@ -0,0 +1,49 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"github.com/gorilla/mux"
+)
+
+type LocalUserAuthRouter interface {
+	InitLocalUserAuthRouter(localUserAuthRouter *mux.Router)
+}
+
+type LocalUserAuthRouterImpl struct {
+	localUserAuthRestHandler LocalUserAuthRestHandler
+}
+
+func NewLocalUserAuthRouterImpl(localUserAuthRestHandler LocalUserAuthRestHandler) *LocalUserAuthRouterImpl {
+	return &LocalUserAuthRouterImpl{localUserAuthRestHandler: localUserAuthRestHandler}
+}
+
+func (router LocalUserAuthRouterImpl) InitLocalUserAuthRouter(localUserAuthRouter *mux.Router) {
+	localUserAuthRouter.Path("/password").
+		HandlerFunc(router.localUserAuthRestHandler.SetPassword).Methods("PUT")
+	localUserAuthRouter.Path("/password/change").
+		HandlerFunc(router.localUserAuthRestHandler.ChangePassword).Methods("PUT")
+	localUserAuthRouter.Path("/mfa/enroll").
+		HandlerFunc(router.localUserAuthRestHandler.EnrollMfa).Methods("POST")
+	localUserAuthRouter.Path("/mfa/verify").
+		HandlerFunc(router.localUserAuthRestHandler.VerifyMfa).Methods("POST")
+	localUserAuthRouter.Path("/mfa/{userId}").
+		HandlerFunc(router.localUserAuthRestHandler.ResetMfa).Methods("DELETE")
+	localUserAuthRouter.Path("/unlock/{userId}").
+		HandlerFunc(router.localUserAuthRestHandler.Unlock).Methods("PUT")
+}
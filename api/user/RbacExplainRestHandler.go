@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/devtron-labs/devtron/api/bean"
+	"github.com/devtron-labs/devtron/api/restHandler/common"
+	"github.com/devtron-labs/devtron/pkg/user"
+	"github.com/devtron-labs/devtron/pkg/user/casbin"
+	"go.uber.org/zap"
+	"gopkg.in/go-playground/validator.v9"
+	"net/http"
+)
+
+type RbacExplainRestHandler interface {
+	Explain(w http.ResponseWriter, r *http.Request)
+}
+
+type RbacExplainRestHandlerImpl struct {
+	logger             *zap.SugaredLogger
+	rbacExplainService user.RbacExplainService
+	userService        user.UserService
+	enforcer           casbin.Enforcer
+	validator          *validator.Validate
+}
+
+func NewRbacExplainRestHandlerImpl(logger *zap.SugaredLogger, rbacExplainService user.RbacExplainService, userService user.UserService,
+	enforcer casbin.Enforcer, validator *validator.Validate) *RbacExplainRestHandlerImpl {
+	return &RbacExplainRestHandlerImpl{
+		logger:             logger,
+		rbacExplainService: rbacExplainService,
+		userService:        userService,
+		enforcer:           enforcer,
+		validator:          validator,
+	}
+}
+
+// Explain lets every user explain their own permissions, explaining another user or an api token needs
+// user management access on all teams
+func (handler RbacExplainRestHandlerImpl) Explain(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	var request bean.RbacExplainRequest
+	err = decoder.Decode(&request)
+	if err != nil {
+		handler.logger.Errorw("request err, Explain", "err", err, "payload", request)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	request.UserId = userId
+	err = handler.validator.Struct(request)
+	if err != nil {
+		handler.logger.Errorw("validation err, Explain", "err", err, "payload", request)
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+
+	// RBAC enforcer applying
+	if len(request.EmailId) > 0 || request.ApiTokenId > 0 {
+		token := r.Header.Get("token")
+		if ok := handler.enforcer.Enforce(token, casbin.ResourceUser, casbin.ActionGet, "*"); !ok {
+			common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+			return
+		}
+	}
+	//RBAC enforcer Ends
+
+	res, err := handler.rbacExplainService.Explain(&request)
+	if err != nil {
+		handler.logger.Errorw("service err, Explain", "err", err, "payload", request)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
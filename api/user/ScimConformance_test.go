@@ -0,0 +1,199 @@
+package user
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
+	repository3 "github.com/devtron-labs/devtron/pkg/auditLog/repository"
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/devtron-labs/devtron/pkg/user"
+	"github.com/devtron-labs/devtron/pkg/user/casbin"
+	"github.com/devtron-labs/devtron/pkg/user/repository"
+	"github.com/go-pg/pg"
+	"github.com/gorilla/mux"
+)
+
+// scimStep is one request of an idp provisioning sequence recorded in testdata, {{user}} and {{group}} are
+// replaced with the ids captured from earlier responses
+type scimStep struct {
+	Name      string                 `json:"name"`
+	Method    string                 `json:"method"`
+	Path      string                 `json:"path"`
+	Body      json.RawMessage        `json:"body"`
+	Status    int                    `json:"status"`
+	Capture   string                 `json:"capture"`
+	Expect    map[string]interface{} `json:"expect"`
+	ExpectLen map[string]int         `json:"expectLen"`
+}
+
+type scimUserServiceStub struct {
+	user.UserService
+}
+
+func (impl scimUserServiceStub) GetLoggedInUser(r *http.Request) (int32, error) {
+	return 1, nil
+}
+
+type scimEnforcerStub struct {
+	casbin.Enforcer
+}
+
+func (impl scimEnforcerStub) Enforce(rvals ...interface{}) bool {
+	return true
+}
+
+type scimUserSessionServiceStub struct {
+	user.UserSessionService
+}
+
+func (impl scimUserSessionServiceStub) RevokeAllSessions(userId int32, actionUserId int32) error {
+	return nil
+}
+
+// scimTestDb connects to the database of PG_* env like the repository tests, the test is skipped without one
+func scimTestDb(t *testing.T) *pg.DB {
+	cfg, err := sql.GetConfig()
+	if err != nil || cfg.User == "" {
+		t.Skip("no test database configured")
+	}
+	dbConnection, err := sql.NewDbConnection(cfg, util.NewSugardLogger())
+	if err != nil {
+		t.Skipf("test database not reachable: %v", err)
+	}
+	// the casbin enforcer loads the model relative to the repository root
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(filepath.Join("..", "..")); err != nil {
+		t.Fatal(err)
+	}
+	casbin.Create()
+	if err = os.Chdir(wd); err != nil {
+		t.Fatal(err)
+	}
+	return dbConnection
+}
+
+// scimTestRouter wires the scim handlers with the real user and role group services on the test database,
+// only the authentication of the idp token and session revocation are stubbed
+func scimTestRouter(t *testing.T, dbConnection *pg.DB) (*mux.Router, repository.UserRepository, repository.RoleGroupRepository) {
+	logger := util.NewSugardLogger()
+	userRepository := repository.NewUserRepositoryImpl(dbConnection)
+	roleGroupRepository := repository.NewRoleGroupRepositoryImpl(dbConnection, logger)
+	userAuthRepository := repository.NewUserAuthRepositoryImpl(dbConnection, logger)
+	auditLogService, err := auditLog.NewAuditLogServiceImpl(logger, repository3.NewAuditLogRepositoryImpl(dbConnection, logger), userRepository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	customRoleService := user.NewCustomRoleServiceImpl(logger, repository.NewCustomRoleRepositoryImpl(dbConnection, logger), userAuthRepository, auditLogService)
+	userService := user.NewUserServiceImpl(userAuthRepository, logger, userRepository, roleGroupRepository, nil,
+		repository.NewApiTokenRepositoryImpl(dbConnection, logger), auditLogService, nil, customRoleService, nil, scimUserSessionServiceStub{})
+	roleGroupService := user.NewRoleGroupServiceImpl(userAuthRepository, logger, userRepository, roleGroupRepository, auditLogService, customRoleService)
+	scimService := user.NewScimServiceImpl(logger, userService, roleGroupService, userRepository, roleGroupRepository)
+	router := mux.NewRouter()
+	NewScimRouterImpl(NewScimRestHandlerImpl(logger, scimService, scimUserServiceStub{}, scimEnforcerStub{})).InitScimRouter(router)
+	return router, userRepository, roleGroupRepository
+}
+
+func runScimRecording(t *testing.T, router *mux.Router, file string, vars map[string]string) {
+	content, err := ioutil.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var steps []*scimStep
+	if err = json.Unmarshal(content, &steps); err != nil {
+		t.Fatal(err)
+	}
+	replace := func(s string) string {
+		for key, value := range vars {
+			s = strings.ReplaceAll(s, "{{"+key+"}}", value)
+		}
+		return s
+	}
+	for _, step := range steps {
+		var body *bytes.Reader
+		if len(step.Body) > 0 {
+			body = bytes.NewReader([]byte(replace(string(step.Body))))
+		} else {
+			body = bytes.NewReader(nil)
+		}
+		req := httptest.NewRequest(step.Method, replace(step.Path), body)
+		req.Header.Set("Content-Type", "application/scim+json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != step.Status {
+			t.Fatalf("%s: status = %d, want %d, body %s", step.Name, rec.Code, step.Status, rec.Body.String())
+		}
+		if rec.Code == http.StatusNoContent {
+			continue
+		}
+		res := make(map[string]interface{})
+		if err = json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+			t.Fatalf("%s: invalid response %s", step.Name, rec.Body.String())
+		}
+		if step.Capture != "" {
+			vars[step.Capture] = fmt.Sprint(res["id"])
+		}
+		for key, want := range step.Expect {
+			if s, ok := want.(string); ok {
+				want = replace(s)
+			}
+			if got := res[key]; !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: %s = %v, want %v", step.Name, key, got, want)
+			}
+		}
+		for key, want := range step.ExpectLen {
+			values, _ := res[key].([]interface{})
+			if len(values) != want {
+				t.Errorf("%s: %d %s, want %d", step.Name, len(values), key, want)
+			}
+		}
+	}
+}
+
+func TestScimConformance(t *testing.T) {
+	dbConnection := scimTestDb(t)
+	router, userRepository, roleGroupRepository := scimTestRouter(t, dbConnection)
+	for _, file := range []string{"scim_okta.json", "scim_azure_ad.json"} {
+		t.Run(file, func(t *testing.T) {
+			run := fmt.Sprintf("%s-%d", strings.TrimSuffix(file, ".json"), time.Now().UnixNano())
+			vars := map[string]string{
+				"userName":  strings.ReplaceAll(run, "_", "-") + "@example.com",
+				"groupName": strings.ReplaceAll(run, "_", "-"),
+			}
+			runScimRecording(t, router, file, vars)
+
+			// both sequences end with the user deprovisioned and the group deleted
+			model, err := userRepository.FetchActiveOrDeletedUserByEmail(vars["userName"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if model.Active {
+				t.Errorf("user %s is still active", vars["userName"])
+			}
+			roles, err := casbin.GetRolesForUser(vars["userName"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(roles) != 0 {
+				t.Errorf("deprovisioned user keeps roles %v", roles)
+			}
+			if _, err = roleGroupRepository.GetRoleGroupByName(vars["groupName"]); err != pg.ErrNoRows {
+				t.Errorf("group %s is still active, err %v", vars["groupName"], err)
+			}
+		})
+	}
+}
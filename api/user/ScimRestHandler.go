@@ -0,0 +1,396 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/devtron-labs/devtron/api/bean"
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/user"
+	"github.com/devtron-labs/devtron/pkg/user/casbin"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ScimRestHandler serves the scim 2.0 apis used by identity providers to provision users and role groups.
+// Clients authenticate with an api token having user management access on all teams.
+type ScimRestHandler interface {
+	GetServiceProviderConfig(w http.ResponseWriter, r *http.Request)
+
+	ListUsers(w http.ResponseWriter, r *http.Request)
+	GetUser(w http.ResponseWriter, r *http.Request)
+	CreateUser(w http.ResponseWriter, r *http.Request)
+	ReplaceUser(w http.ResponseWriter, r *http.Request)
+	PatchUser(w http.ResponseWriter, r *http.Request)
+	DeleteUser(w http.ResponseWriter, r *http.Request)
+
+	ListGroups(w http.ResponseWriter, r *http.Request)
+	GetGroup(w http.ResponseWriter, r *http.Request)
+	CreateGroup(w http.ResponseWriter, r *http.Request)
+	ReplaceGroup(w http.ResponseWriter, r *http.Request)
+	PatchGroup(w http.ResponseWriter, r *http.Request)
+	DeleteGroup(w http.ResponseWriter, r *http.Request)
+}
+
+type ScimRestHandlerImpl struct {
+	logger      *zap.SugaredLogger
+	scimService user.ScimService
+	userService user.UserService
+	enforcer    casbin.Enforcer
+}
+
+func NewScimRestHandlerImpl(logger *zap.SugaredLogger, scimService user.ScimService, userService user.UserService,
+	enforcer casbin.Enforcer) *ScimRestHandlerImpl {
+	return &ScimRestHandlerImpl{
+		logger:      logger,
+		scimService: scimService,
+		userService: userService,
+		enforcer:    enforcer,
+	}
+}
+
+func (handler ScimRestHandlerImpl) GetServiceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	if _, ok := handler.authorize(w, r, casbin.ActionGet); !ok {
+		return
+	}
+	config := map[string]interface{}{
+		"schemas":        []string{bean.ScimSpConfigSchema},
+		"patch":          map[string]bool{"supported": true},
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": 100},
+		"changePassword": map[string]bool{"supported": false},
+		"sort":           map[string]bool{"supported": false},
+		"etag":           map[string]bool{"supported": false},
+		"authenticationSchemes": []map[string]interface{}{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer Token",
+			"description": "Devtron api token with user management access",
+			"primary":     true,
+		}},
+	}
+	writeScimResponse(w, http.StatusOK, config)
+}
+
+func (handler ScimRestHandlerImpl) ListUsers(w http.ResponseWriter, r *http.Request) {
+	if _, ok := handler.authorize(w, r, casbin.ActionGet); !ok {
+		return
+	}
+	startIndex, count := scimPagination(r)
+	res, err := handler.scimService.ListUsers(r.URL.Query().Get("filter"), startIndex, count)
+	if err != nil {
+		handler.writeScimError(w, "ListUsers", err)
+		return
+	}
+	writeScimResponse(w, http.StatusOK, res)
+}
+
+func (handler ScimRestHandlerImpl) GetUser(w http.ResponseWriter, r *http.Request) {
+	if _, ok := handler.authorize(w, r, casbin.ActionGet); !ok {
+		return
+	}
+	id, err := scimResourceId(r)
+	if err != nil {
+		handler.writeScimError(w, "GetUser", err)
+		return
+	}
+	res, err := handler.scimService.GetUser(id)
+	if err != nil {
+		handler.writeScimError(w, "GetUser", err)
+		return
+	}
+	writeScimResponse(w, http.StatusOK, res)
+}
+
+func (handler ScimRestHandlerImpl) CreateUser(w http.ResponseWriter, r *http.Request) {
+	userId, ok := handler.authorize(w, r, casbin.ActionCreate)
+	if !ok {
+		return
+	}
+	var request bean.ScimUser
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		handler.writeScimError(w, "CreateUser", bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidValue, err.Error()))
+		return
+	}
+	handler.logger.Infow("request payload, scim CreateUser", "userName", request.UserName)
+	res, err := handler.scimService.CreateUser(&request, userId)
+	if err != nil {
+		handler.writeScimError(w, "CreateUser", err)
+		return
+	}
+	writeScimResponse(w, http.StatusCreated, res)
+}
+
+func (handler ScimRestHandlerImpl) ReplaceUser(w http.ResponseWriter, r *http.Request) {
+	userId, ok := handler.authorize(w, r, casbin.ActionUpdate)
+	if !ok {
+		return
+	}
+	id, err := scimResourceId(r)
+	if err != nil {
+		handler.writeScimError(w, "ReplaceUser", err)
+		return
+	}
+	var request bean.ScimUser
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		handler.writeScimError(w, "ReplaceUser", bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidValue, err.Error()))
+		return
+	}
+	res, err := handler.scimService.ReplaceUser(id, &request, userId)
+	if err != nil {
+		handler.writeScimError(w, "ReplaceUser", err)
+		return
+	}
+	writeScimResponse(w, http.StatusOK, res)
+}
+
+func (handler ScimRestHandlerImpl) PatchUser(w http.ResponseWriter, r *http.Request) {
+	userId, ok := handler.authorize(w, r, casbin.ActionUpdate)
+	if !ok {
+		return
+	}
+	id, err := scimResourceId(r)
+	if err != nil {
+		handler.writeScimError(w, "PatchUser", err)
+		return
+	}
+	var request bean.ScimPatchRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		handler.writeScimError(w, "PatchUser", bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidValue, err.Error()))
+		return
+	}
+	res, err := handler.scimService.PatchUser(id, &request, userId)
+	if err != nil {
+		handler.writeScimError(w, "PatchUser", err)
+		return
+	}
+	writeScimResponse(w, http.StatusOK, res)
+}
+
+func (handler ScimRestHandlerImpl) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	userId, ok := handler.authorize(w, r, casbin.ActionDelete)
+	if !ok {
+		return
+	}
+	id, err := scimResourceId(r)
+	if err != nil {
+		handler.writeScimError(w, "DeleteUser", err)
+		return
+	}
+	err = handler.scimService.DeleteUser(id, userId)
+	if err != nil {
+		handler.writeScimError(w, "DeleteUser", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (handler ScimRestHandlerImpl) ListGroups(w http.ResponseWriter, r *http.Request) {
+	if _, ok := handler.authorize(w, r, casbin.ActionGet); !ok {
+		return
+	}
+	startIndex, count := scimPagination(r)
+	res, err := handler.scimService.ListGroups(r.URL.Query().Get("filter"), startIndex, count, scimExcludeMembers(r))
+	if err != nil {
+		handler.writeScimError(w, "ListGroups", err)
+		return
+	}
+	writeScimResponse(w, http.StatusOK, res)
+}
+
+func (handler ScimRestHandlerImpl) GetGroup(w http.ResponseWriter, r *http.Request) {
+	if _, ok := handler.authorize(w, r, casbin.ActionGet); !ok {
+		return
+	}
+	id, err := scimResourceId(r)
+	if err != nil {
+		handler.writeScimError(w, "GetGroup", err)
+		return
+	}
+	res, err := handler.scimService.GetGroup(id, scimExcludeMembers(r))
+	if err != nil {
+		handler.writeScimError(w, "GetGroup", err)
+		return
+	}
+	writeScimResponse(w, http.StatusOK, res)
+}
+
+func (handler ScimRestHandlerImpl) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	userId, ok := handler.authorize(w, r, casbin.ActionCreate)
+	if !ok {
+		return
+	}
+	var request bean.ScimGroup
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		handler.writeScimError(w, "CreateGroup", bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidValue, err.Error()))
+		return
+	}
+	handler.logger.Infow("request payload, scim CreateGroup", "displayName", request.DisplayName)
+	res, err := handler.scimService.CreateGroup(&request, userId)
+	if err != nil {
+		handler.writeScimError(w, "CreateGroup", err)
+		return
+	}
+	writeScimResponse(w, http.StatusCreated, res)
+}
+
+func (handler ScimRestHandlerImpl) ReplaceGroup(w http.ResponseWriter, r *http.Request) {
+	userId, ok := handler.authorize(w, r, casbin.ActionUpdate)
+	if !ok {
+		return
+	}
+	id, err := scimResourceId(r)
+	if err != nil {
+		handler.writeScimError(w, "ReplaceGroup", err)
+		return
+	}
+	var request bean.ScimGroup
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		handler.writeScimError(w, "ReplaceGroup", bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidValue, err.Error()))
+		return
+	}
+	res, err := handler.scimService.ReplaceGroup(id, &request, userId)
+	if err != nil {
+		handler.writeScimError(w, "ReplaceGroup", err)
+		return
+	}
+	writeScimResponse(w, http.StatusOK, res)
+}
+
+func (handler ScimRestHandlerImpl) PatchGroup(w http.ResponseWriter, r *http.Request) {
+	userId, ok := handler.authorize(w, r, casbin.ActionUpdate)
+	if !ok {
+		return
+	}
+	id, err := scimResourceId(r)
+	if err != nil {
+		handler.writeScimError(w, "PatchGroup", err)
+		return
+	}
+	var request bean.ScimPatchRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		handler.writeScimError(w, "PatchGroup", bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidValue, err.Error()))
+		return
+	}
+	res, err := handler.scimService.PatchGroup(id, &request, userId)
+	if err != nil {
+		handler.writeScimError(w, "PatchGroup", err)
+		return
+	}
+	writeScimResponse(w, http.StatusOK, res)
+}
+
+func (handler ScimRestHandlerImpl) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	userId, ok := handler.authorize(w, r, casbin.ActionDelete)
+	if !ok {
+		return
+	}
+	id, err := scimResourceId(r)
+	if err != nil {
+		handler.writeScimError(w, "DeleteGroup", err)
+		return
+	}
+	err = handler.scimService.DeleteGroup(id, userId)
+	if err != nil {
+		handler.writeScimError(w, "DeleteGroup", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorize resolves the caller and checks it can manage users of all teams, errors are written in scim format
+func (handler ScimRestHandlerImpl) authorize(w http.ResponseWriter, r *http.Request, action string) (int32, bool) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		writeScimResponse(w, http.StatusUnauthorized, bean.NewScimError(http.StatusUnauthorized, "", "invalid bearer token"))
+		return 0, false
+	}
+	token := r.Header.Get("token")
+	if ok := handler.enforcer.Enforce(token, casbin.ResourceUser, action, "*"); !ok {
+		writeScimResponse(w, http.StatusForbidden, bean.NewScimError(http.StatusForbidden, "", "token is not allowed to manage users"))
+		return 0, false
+	}
+	return userId, true
+}
+
+func (handler ScimRestHandlerImpl) writeScimError(w http.ResponseWriter, method string, err error) {
+	scimErr, ok := err.(*bean.ScimError)
+	if !ok {
+		status := http.StatusInternalServerError
+		detail := err.Error()
+		if apiErr, ok := err.(*util.ApiError); ok {
+			if apiErr.HttpStatusCode != 0 {
+				status = apiErr.HttpStatusCode
+			}
+			if apiErr.UserMessage != nil {
+				detail = fmt.Sprint(apiErr.UserMessage)
+			}
+		}
+		scimErr = bean.NewScimError(status, "", detail)
+	}
+	status, _ := strconv.Atoi(scimErr.Status)
+	if status >= http.StatusInternalServerError {
+		handler.logger.Errorw("service err, scim "+method, "err", err)
+	}
+	writeScimResponse(w, status, scimErr)
+}
+
+func writeScimResponse(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", bean.ScimContentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func scimResourceId(r *http.Request) (int32, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		return 0, bean.NewScimError(http.StatusNotFound, "", "resource not found")
+	}
+	return int32(id), nil
+}
+
+// scimPagination reads startIndex and count, count is -1 when not requested
+func scimPagination(r *http.Request) (int, int) {
+	startIndex, err := strconv.Atoi(r.URL.Query().Get("startIndex"))
+	if err != nil {
+		startIndex = 1
+	}
+	count, err := strconv.Atoi(r.URL.Query().Get("count"))
+	if err != nil {
+		count = -1
+	}
+	return startIndex, count
+}
+
+func scimExcludeMembers(r *http.Request) bool {
+	for _, attribute := range strings.Split(r.URL.Query().Get("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attribute), "members") {
+			return true
+		}
+	}
+	return false
+}
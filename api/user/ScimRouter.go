@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"github.com/gorilla/mux"
+)
+
+type ScimRouter interface {
+	InitScimRouter(scimRouter *mux.Router)
+}
+
+type ScimRouterImpl struct {
+	scimRestHandler ScimRestHandler
+}
+
+func NewScimRouterImpl(scimRestHandler ScimRestHandler) *ScimRouterImpl {
+	return &ScimRouterImpl{scimRestHandler: scimRestHandler}
+}
+
+func (router ScimRouterImpl) InitScimRouter(scimRouter *mux.Router) {
+	scimRouter.Path("/ServiceProviderConfig").
+		HandlerFunc(router.scimRestHandler.GetServiceProviderConfig).Methods("GET")
+
+	scimRouter.Path("/Users").
+		HandlerFunc(router.scimRestHandler.ListUsers).Methods("GET")
+	scimRouter.Path("/Users").
+		HandlerFunc(router.scimRestHandler.CreateUser).Methods("POST")
+	scimRouter.Path("/Users/{id}").
+		HandlerFunc(router.scimRestHandler.GetUser).Methods("GET")
+	scimRouter.Path("/Users/{id}").
+		HandlerFunc(router.scimRestHandler.ReplaceUser).Methods("PUT")
+	scimRouter.Path("/Users/{id}").
+		HandlerFunc(router.scimRestHandler.PatchUser).Methods("PATCH")
+	scimRouter.Path("/Users/{id}").
+		HandlerFunc(router.scimRestHandler.DeleteUser).Methods("DELETE")
+
+	scimRouter.Path("/Groups").
+		HandlerFunc(router.scimRestHandler.ListGroups).Methods("GET")
+	scimRouter.Path("/Groups").
+		HandlerFunc(router.scimRestHandler.CreateGroup).Methods("POST")
+	scimRouter.Path("/Groups/{id}").
+		HandlerFunc(router.scimRestHandler.GetGroup).Methods("GET")
+	scimRouter.Path("/Groups/{id}").
+		HandlerFunc(router.scimRestHandler.ReplaceGroup).Methods("PUT")
+	scimRouter.Path("/Groups/{id}").
+		HandlerFunc(router.scimRestHandler.PatchGroup).Methods("PATCH")
+	scimRouter.Path("/Groups/{id}").
+		HandlerFunc(router.scimRestHandler.DeleteGroup).Methods("DELETE")
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"github.com/devtron-labs/devtron/api/bean"
 	"github.com/devtron-labs/devtron/api/restHandler/common"
+	"github.com/devtron-labs/devtron/internal/util"
 	"github.com/devtron-labs/devtron/pkg/user"
 	"github.com/devtron-labs/devtron/pkg/user/casbin"
 	"github.com/gorilla/mux"
@@ -73,8 +74,12 @@ func (handler UserAuthHandlerImpl) LoginHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 	//token, err := handler.loginService.CreateLoginSession(up.Username, up.Password)
-	token, err := handler.userAuthService.HandleLogin(up.Username, up.Password)
-	if err != nil {
+	token, err := handler.userAuthService.HandleUserLogin(up.Username, up.Password, up.Otp)
+	if apiErr, ok := err.(*util.ApiError); ok {
+		// mfa and lockout errors let the ui prompt for an otp or tell the user to wait
+		common.WriteJsonResp(w, apiErr, nil, apiErr.HttpStatusCode)
+		return
+	} else if err != nil {
 		common.WriteJsonResp(w, fmt.Errorf("invalid username or password"), nil, http.StatusForbidden)
 		return
 	}
@@ -19,9 +19,11 @@ package user
 
 import (
 	"github.com/devtron-labs/authenticator/client"
+	jwt2 "github.com/devtron-labs/authenticator/jwt"
 	"github.com/devtron-labs/authenticator/oidc"
 	"github.com/devtron-labs/devtron/client/argocdServer"
 	"github.com/devtron-labs/devtron/pkg/user"
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 	"net/http"
@@ -37,15 +39,26 @@ type UserAuthRouterImpl struct {
 	userAuthHandler UserAuthHandler
 	dexProxy        func(writer http.ResponseWriter, request *http.Request)
 	clientApp       *oidc.ClientApp
+
+	userService            user.UserService
+	ssoGroupMappingService user.SsoGroupMappingService
 }
 
-func NewUserAuthRouterImpl(logger *zap.SugaredLogger, userAuthHandler UserAuthHandler, userService user.UserService, dexConfig *client.DexConfig) (*UserAuthRouterImpl, error) {
+func NewUserAuthRouterImpl(logger *zap.SugaredLogger, userAuthHandler UserAuthHandler, userService user.UserService, dexConfig *client.DexConfig,
+	ssoGroupMappingService user.SsoGroupMappingService) (*UserAuthRouterImpl, error) {
 	router := &UserAuthRouterImpl{
-		userAuthHandler: userAuthHandler,
-		logger:          logger,
+		userAuthHandler:        userAuthHandler,
+		logger:                 logger,
+		userService:            userService,
+		ssoGroupMappingService: ssoGroupMappingService,
 	}
 	logger.Infow("auth starting with dex conf", "conf", dexConfig)
-	oidcClient, dexProxy, err := client.GetOidcClient(dexConfig, userService.UserExists, router.RedirectUrlSanitiser)
+	// the verifier only sees the email, unknown users are let through when sso groups are mapped and
+	// handleCallback checks their groups claim before their session is handed out
+	userVerifier := func(email string) bool {
+		return userService.UserExists(email) || ssoGroupMappingService.HasMappings()
+	}
+	oidcClient, dexProxy, err := client.GetOidcClient(dexConfig, userVerifier, router.RedirectUrlSanitiser)
 	if err != nil {
 		return nil, err
 	}
@@ -71,7 +84,7 @@ func (router UserAuthRouterImpl) InitUserAuthRouter(userAuthRouter *mux.Router)
 	userAuthRouter.PathPrefix("/api/dex").HandlerFunc(router.dexProxy)
 	userAuthRouter.Path("/login").HandlerFunc(router.clientApp.HandleLogin)
 	userAuthRouter.Path("/auth/login").HandlerFunc(router.clientApp.HandleLogin)
-	userAuthRouter.Path("/auth/callback").HandlerFunc(router.clientApp.HandleCallback)
+	userAuthRouter.Path("/auth/callback").HandlerFunc(router.handleCallback)
 	userAuthRouter.Path("/api/v1/session").HandlerFunc(router.userAuthHandler.LoginHandler)
 	userAuthRouter.Path("/refresh").HandlerFunc(router.userAuthHandler.RefreshTokenHandler)
 	// Policies mapping in orchestrator
@@ -89,3 +102,66 @@ func (router UserAuthRouterImpl) writeSuccess(message string, w http.ResponseWri
 		router.logger.Error(err)
 	}
 }
+
+// handleCallback completes the sso login and drops the session of users unknown to devtron whose
+// groups claim matches no sso group mapping, they are redirected like users failing the verifier. Unknown
+// users with a mapped group are provisioned here
+func (router UserAuthRouterImpl) handleCallback(w http.ResponseWriter, r *http.Request) {
+	router.clientApp.HandleCallback(&ssoCallbackWriter{ResponseWriter: w, router: router}, r)
+}
+
+func (router UserAuthRouterImpl) canLogin(token string) bool {
+	claims := jwt.MapClaims{}
+	// the token was verified by the oidc client just before it is set as cookie
+	_, _, err := new(jwt.Parser).ParseUnverified(token, claims)
+	if err != nil {
+		router.logger.Errorw("error in parsing sso login token", "err", err)
+		return false
+	}
+	email := jwt2.GetField(claims, "email")
+	if email == "" && jwt2.GetField(claims, "sub") == "admin" {
+		email = "admin"
+	}
+	if router.userService.UserExists(email) {
+		return true
+	}
+	groups := jwt2.GetScopeValues(claims, []string{"groups"})
+	if !router.ssoGroupMappingService.MatchesMapping(groups) {
+		return false
+	}
+	// the user is provisioned before its first request, sessions of unknown users are refused
+	err = router.ssoGroupMappingService.SyncUserGroups(email, groups)
+	if err != nil {
+		router.logger.Errorw("error in provisioning sso user", "email", email, "err", err)
+		return false
+	}
+	return true
+}
+
+// ssoCallbackWriter checks the session cookie set by the oidc client before the response is sent
+type ssoCallbackWriter struct {
+	http.ResponseWriter
+	router  UserAuthRouterImpl
+	checked bool
+}
+
+func (w *ssoCallbackWriter) WriteHeader(statusCode int) {
+	if !w.checked {
+		w.checked = true
+		header := w.ResponseWriter.Header()
+		cookies := (&http.Response{Header: http.Header{"Set-Cookie": header["Set-Cookie"]}}).Cookies()
+		token, err := oidc.JoinCookies(oidc.AuthCookieName, cookies)
+		if err == nil && token != "" && !w.router.canLogin(token) {
+			header.Del("Set-Cookie")
+			header.Set("Location", w.router.RedirectUrlSanitiser(oidc.NoUserLocation))
+		}
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *ssoCallbackWriter) Write(data []byte) (int, error) {
+	if !w.checked {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(data)
+}
@@ -56,6 +56,7 @@ type UserRestHandler interface {
 type userNamePassword struct {
 	Username string `json:"username" validate:"required"`
 	Password string `json:"password" validate:"required,min=6"`
+	Otp      string `json:"otp"`
 }
 
 type UserRestHandlerImpl struct {
@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"errors"
+	"github.com/devtron-labs/devtron/api/restHandler/common"
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/user"
+	"github.com/devtron-labs/devtron/pkg/user/casbin"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type UserSessionRestHandler interface {
+	GetMySessions(w http.ResponseWriter, r *http.Request)
+	GetSessionsByUserId(w http.ResponseWriter, r *http.Request)
+	RevokeSession(w http.ResponseWriter, r *http.Request)
+	RevokeAllSessions(w http.ResponseWriter, r *http.Request)
+}
+
+type UserSessionRestHandlerImpl struct {
+	logger             *zap.SugaredLogger
+	userSessionService user.UserSessionService
+	userService        user.UserService
+	enforcer           casbin.Enforcer
+}
+
+func NewUserSessionRestHandlerImpl(logger *zap.SugaredLogger, userSessionService user.UserSessionService, userService user.UserService,
+	enforcer casbin.Enforcer) *UserSessionRestHandlerImpl {
+	return &UserSessionRestHandlerImpl{
+		logger:             logger,
+		userSessionService: userSessionService,
+		userService:        userService,
+		enforcer:           enforcer,
+	}
+}
+
+func (handler UserSessionRestHandlerImpl) GetMySessions(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	res, err := handler.userSessionService.GetActiveSessions(userId, user.SessionToken(r))
+	if err != nil {
+		handler.logger.Errorw("service err, GetMySessions", "err", err, "userId", userId)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+// GetSessionsByUserId lists the sessions of any user, it needs user get access on all teams
+func (handler UserSessionRestHandlerImpl) GetSessionsByUserId(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["userId"])
+	if err != nil {
+		handler.logger.Errorw("request err, GetSessionsByUserId", "err", err, "userId", vars["userId"])
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+
+	// RBAC enforcer applying
+	token := r.Header.Get("token")
+	if int32(id) != userId {
+		if ok := handler.enforcer.Enforce(token, casbin.ResourceUser, casbin.ActionGet, "*"); !ok {
+			common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+			return
+		}
+	}
+	//RBAC enforcer Ends
+
+	res, err := handler.userSessionService.GetActiveSessions(int32(id), user.SessionToken(r))
+	if err != nil {
+		handler.logger.Errorw("service err, GetSessionsByUserId", "err", err, "userId", id)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, res, http.StatusOK)
+}
+
+// RevokeSession revokes a session of the logged in user, sessions of other users need user delete access on all teams
+func (handler UserSessionRestHandlerImpl) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		handler.logger.Errorw("request err, RevokeSession", "err", err, "id", vars["id"])
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+
+	// RBAC enforcer applying
+	// sessions of other users look the same as missing ones to non admins, ids of sessions must not leak
+	if !handler.isUserAdmin(r) {
+		session, err := handler.userSessionService.GetSessionById(id)
+		if err != nil {
+			if apiErr, ok := err.(*util.ApiError); !ok || apiErr.HttpStatusCode != http.StatusNotFound {
+				handler.logger.Errorw("service err, RevokeSession", "err", err, "id", id)
+				common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+				return
+			}
+		}
+		if err != nil || session.UserId != userId {
+			common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+			return
+		}
+	}
+	//RBAC enforcer Ends
+
+	err = handler.userSessionService.RevokeSession(id, userId)
+	if err != nil {
+		handler.logger.Errorw("service err, RevokeSession", "err", err, "id", id)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, true, http.StatusOK)
+}
+
+// RevokeAllSessions logs a user out everywhere, for users other than the logged in one it needs user delete access on all teams
+func (handler UserSessionRestHandlerImpl) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userId, err := handler.userService.GetLoggedInUser(r)
+	if userId == 0 || err != nil {
+		common.WriteJsonResp(w, err, "Unauthorized User", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["userId"])
+	if err != nil {
+		handler.logger.Errorw("request err, RevokeAllSessions", "err", err, "userId", vars["userId"])
+		common.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+
+	// RBAC enforcer applying
+	if int32(id) != userId && !handler.isUserAdmin(r) {
+		common.WriteJsonResp(w, errors.New("unauthorized"), nil, http.StatusForbidden)
+		return
+	}
+	//RBAC enforcer Ends
+
+	err = handler.userSessionService.RevokeAllSessions(int32(id), userId)
+	if err != nil {
+		handler.logger.Errorw("service err, RevokeAllSessions", "err", err, "userId", id)
+		common.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	common.WriteJsonResp(w, nil, true, http.StatusOK)
+}
+
+func (handler UserSessionRestHandlerImpl) isUserAdmin(r *http.Request) bool {
+	return handler.enforcer.Enforce(r.Header.Get("token"), casbin.ResourceUser, casbin.ActionDelete, "*")
+}
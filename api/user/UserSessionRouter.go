@@ -0,0 +1,45 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"github.com/gorilla/mux"
+)
+
+type UserSessionRouter interface {
+	InitUserSessionRouter(userSessionRouter *mux.Router)
+}
+
+type UserSessionRouterImpl struct {
+	userSessionRestHandler UserSessionRestHandler
+}
+
+func NewUserSessionRouterImpl(userSessionRestHandler UserSessionRestHandler) *UserSessionRouterImpl {
+	return &UserSessionRouterImpl{userSessionRestHandler: userSessionRestHandler}
+}
+
+func (router UserSessionRouterImpl) InitUserSessionRouter(userSessionRouter *mux.Router) {
+	userSessionRouter.Path("").
+		HandlerFunc(router.userSessionRestHandler.GetMySessions).Methods("GET")
+	userSessionRouter.Path("/user/{userId}").
+		HandlerFunc(router.userSessionRestHandler.GetSessionsByUserId).Methods("GET")
+	userSessionRouter.Path("/user/{userId}").
+		HandlerFunc(router.userSessionRestHandler.RevokeAllSessions).Methods("DELETE")
+	userSessionRouter.Path("/{id}").
+		HandlerFunc(router.userSessionRestHandler.RevokeSession).Methods("DELETE")
+}
@@ -32,6 +32,72 @@ var UserWireSet = wire.NewSet(
 	repository.NewRoleGroupRepositoryImpl,
 	wire.Bind(new(repository.RoleGroupRepository), new(*repository.RoleGroupRepositoryImpl)),
 
+	NewApiTokenRouterImpl,
+	wire.Bind(new(ApiTokenRouter), new(*ApiTokenRouterImpl)),
+	NewApiTokenRestHandlerImpl,
+	wire.Bind(new(ApiTokenRestHandler), new(*ApiTokenRestHandlerImpl)),
+	user.NewApiTokenServiceImpl,
+	wire.Bind(new(user.ApiTokenService), new(*user.ApiTokenServiceImpl)),
+
+	NewScimRouterImpl,
+	wire.Bind(new(ScimRouter), new(*ScimRouterImpl)),
+	NewScimRestHandlerImpl,
+	wire.Bind(new(ScimRestHandler), new(*ScimRestHandlerImpl)),
+	user.NewScimServiceImpl,
+	wire.Bind(new(user.ScimService), new(*user.ScimServiceImpl)),
+
+	NewCustomRoleRouterImpl,
+	wire.Bind(new(CustomRoleRouter), new(*CustomRoleRouterImpl)),
+	NewCustomRoleRestHandlerImpl,
+	wire.Bind(new(CustomRoleRestHandler), new(*CustomRoleRestHandlerImpl)),
+	user.NewCustomRoleServiceImpl,
+	wire.Bind(new(user.CustomRoleService), new(*user.CustomRoleServiceImpl)),
+	repository.NewCustomRoleRepositoryImpl,
+	wire.Bind(new(repository.CustomRoleRepository), new(*repository.CustomRoleRepositoryImpl)),
+
+	NewRbacExplainRouterImpl,
+	wire.Bind(new(RbacExplainRouter), new(*RbacExplainRouterImpl)),
+	NewRbacExplainRestHandlerImpl,
+	wire.Bind(new(RbacExplainRestHandler), new(*RbacExplainRestHandlerImpl)),
+	user.NewRbacExplainServiceImpl,
+	wire.Bind(new(user.RbacExplainService), new(*user.RbacExplainServiceImpl)),
+
+	NewAccessRequestRouterImpl,
+	wire.Bind(new(AccessRequestRouter), new(*AccessRequestRouterImpl)),
+	NewAccessRequestRestHandlerImpl,
+	wire.Bind(new(AccessRequestRestHandler), new(*AccessRequestRestHandlerImpl)),
+	user.NewAccessRequestServiceImpl,
+	wire.Bind(new(user.AccessRequestService), new(*user.AccessRequestServiceImpl)),
+	repository.NewAccessRequestRepositoryImpl,
+	wire.Bind(new(repository.AccessRequestRepository), new(*repository.AccessRequestRepositoryImpl)),
+
+	NewLocalUserAuthRouterImpl,
+	wire.Bind(new(LocalUserAuthRouter), new(*LocalUserAuthRouterImpl)),
+	NewLocalUserAuthRestHandlerImpl,
+	wire.Bind(new(LocalUserAuthRestHandler), new(*LocalUserAuthRestHandlerImpl)),
+	user.NewLocalUserAuthServiceImpl,
+	wire.Bind(new(user.LocalUserAuthService), new(*user.LocalUserAuthServiceImpl)),
+
+	NewUserSessionRouterImpl,
+	wire.Bind(new(UserSessionRouter), new(*UserSessionRouterImpl)),
+	NewUserSessionRestHandlerImpl,
+	wire.Bind(new(UserSessionRestHandler), new(*UserSessionRestHandlerImpl)),
+	user.NewUserSessionServiceImpl,
+	wire.Bind(new(user.UserSessionService), new(*user.UserSessionServiceImpl)),
+	repository.NewUserSessionRepositoryImpl,
+	wire.Bind(new(repository.UserSessionRepository), new(*repository.UserSessionRepositoryImpl)),
+	repository.NewUserCredentialRepositoryImpl,
+	wire.Bind(new(repository.UserCredentialRepository), new(*repository.UserCredentialRepositoryImpl)),
+	repository.NewApiTokenRepositoryImpl,
+	wire.Bind(new(repository.ApiTokenRepository), new(*repository.ApiTokenRepositoryImpl)),
+
+	user.NewSsoGroupMappingServiceImpl,
+	wire.Bind(new(user.SsoGroupMappingService), new(*user.SsoGroupMappingServiceImpl)),
+	repository.NewSsoGroupMappingRepositoryImpl,
+	wire.Bind(new(repository.SsoGroupMappingRepository), new(*repository.SsoGroupMappingRepositoryImpl)),
+	repository.NewSsoGroupMembershipRepositoryImpl,
+	wire.Bind(new(repository.SsoGroupMembershipRepository), new(*repository.SsoGroupMembershipRepositoryImpl)),
+
 	casbin.NewEnforcerImpl,
 	wire.Bind(new(casbin.Enforcer), new(*casbin.EnforcerImpl)),
 	casbin.Create,
@@ -87,6 +87,8 @@ type Payload struct {
 	DownloadLink          string               `json:"downloadLink"`
 	BuildHistoryLink      string               `json:"buildHistoryLink"`
 	MaterialTriggerInfo   *MaterialTriggerInfo `json:"material"`
+	AccessRequest         *AccessRequestInfo   `json:"accessRequest,omitempty"`
+	ClusterHealth         *ClusterHealthInfo   `json:"clusterHealth,omitempty"`
 }
 
 type CiPipelineMaterialResponse struct {
@@ -106,6 +108,30 @@ type CiPipelineMaterialResponse struct {
 	Url             string                 `json:"url"`
 }
 
+// AccessRequestInfo is the payload of access request events, Scope is the team/env/app the access is for
+type AccessRequestInfo struct {
+	Id              int    `json:"id"`
+	RequestedBy     string `json:"requestedBy"`
+	ApprovedBy      string `json:"approvedBy,omitempty"`
+	Action          string `json:"action"`
+	Scope           string `json:"scope"`
+	Reason          string `json:"reason"`
+	Comment         string `json:"comment,omitempty"`
+	DurationMinutes int    `json:"durationMinutes"`
+	Status          string `json:"status"`
+	ExpiresOn       string `json:"expiresOn,omitempty"`
+}
+
+// ClusterHealthInfo is the payload of cluster health events
+type ClusterHealthInfo struct {
+	ClusterId      int    `json:"clusterId"`
+	ClusterName    string `json:"clusterName"`
+	ServerUrl      string `json:"serverUrl"`
+	Error          string `json:"error,omitempty"`
+	CheckedOn      string `json:"checkedOn"`
+	TokenExpiresOn string `json:"tokenExpiresOn,omitempty"`
+}
+
 type MaterialTriggerInfo struct {
 	GitTriggers map[int]pipelineConfig.GitCommit `json:"gitTriggers"`
 	CiMaterials []CiPipelineMaterialResponse     `json:"ciMaterials"`
@@ -140,7 +166,9 @@ func (impl *EventRESTClientImpl) buildFinalPayload(event Event, cdPipeline *pipe
 			payload.EnvName = cdPipeline.Environment.Name
 			payload.PipelineName = cdPipeline.Name
 		}
-		payload.Stage = string(event.CdWorkflowType)
+		if event.CdWorkflowType != "" {
+			payload.Stage = string(event.CdWorkflowType)
+		}
 		payload.DeploymentHistoryLink = fmt.Sprintf("/dashboard/app/%d/cd-details/%d/%d/%d/source-code", event.AppId, event.EnvId, event.PipelineId, event.CdWorkflowRunnerId)
 		payload.AppDetailLink = fmt.Sprintf("/dashboard/app/%d/details/%d/pod", event.AppId, event.EnvId)
 		if event.CdWorkflowType != bean.CD_WORKFLOW_TYPE_DEPLOY {
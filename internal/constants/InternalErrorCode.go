@@ -71,6 +71,9 @@ const (
 	UserNotFoundForToken                 string = "6006"
 	UserCreateFetchRoleFailed            string = "6007"
 	UserUpdateFetchRoleFailed            string = "6008"
+	UserLoginMfaRequired                 string = "6009"
+	UserLoginLocked                      string = "6010"
+	UserSessionRevoked                   string = "6011"
 
 	AppDetailResourceTreeNotFound string = "7000"
 
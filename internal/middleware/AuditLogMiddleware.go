@@ -0,0 +1,213 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
+	"github.com/devtron-labs/devtron/pkg/auditLog/repository"
+	"github.com/devtron-labs/devtron/pkg/user"
+	"github.com/gorilla/mux"
+	"github.com/satori/go.uuid"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	RequestIdHeader            = "X-Request-Id"
+	maxAuditPayloadSize        = 64 * 1024
+	redactedAuditValue         = "******"
+	maxAuditResourceTypeLength = 50
+)
+
+var sensitiveAuditKeyRegex = regexp.MustCompile(`(?i)(password|secret|token|privatekey|sshkey|credential|apikey|accesskey|secretkey|kubeconfig|cert_?data|key_?data|ca_?data)`)
+
+// sensitiveAuditSegments are route segments of apis whose whole payload is secret, kubeconfig imports carry cluster
+// credentials and k8s resource updates may be secret manifests
+var sensitiveAuditSegments = map[string]bool{"cs": true, "bulk": true, "kubeconfig": true, "k8s": true}
+
+var resourceIdVars = []string{"id", "pipelineId", "ciPipelineId", "cdPipelineId", "clusterId", "envId", "environmentId", "appId", "name"}
+
+type AuditLogMiddleware interface {
+	Audit(next http.Handler) http.Handler
+}
+
+type AuditLogMiddlewareImpl struct {
+	logger          *zap.SugaredLogger
+	auditLogService auditLog.AuditLogService
+	userService     user.UserService
+}
+
+func NewAuditLogMiddlewareImpl(logger *zap.SugaredLogger, auditLogService auditLog.AuditLogService,
+	userService user.UserService) *AuditLogMiddlewareImpl {
+	return &AuditLogMiddlewareImpl{
+		logger:          logger,
+		auditLogService: auditLogService,
+		userService:     userService,
+	}
+}
+
+// Audit implements mux.MiddlewareFunc, it records every mutating api call of a logged in user along with its
+// payload and response status. Each request gets a request id which is returned in X-Request-Id.
+func (impl AuditLogMiddlewareImpl) Audit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		requestId := r.Header.Get(RequestIdHeader)
+		if requestId == "" {
+			requestId = uuid.NewV4().String()
+			r.Header.Set(RequestIdHeader, requestId)
+		}
+		w.Header().Set(RequestIdHeader, requestId)
+
+		var payload []byte
+		if r.Body != nil {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				impl.logger.Warnw("error in reading request body for audit", "requestId", requestId, "err", err)
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			payload = body
+		}
+
+		d := newDelegator(w, nil)
+		next.ServeHTTP(d, r)
+
+		userId, err := impl.userService.GetLoggedInUser(r)
+		if err != nil || userId == 0 {
+			return
+		}
+		path := r.URL.Path
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				path = template
+			}
+		}
+		vars := mux.Vars(r)
+		model := &repository.AuditLog{
+			RequestId:    requestId,
+			UserId:       userId,
+			ResourceType: auditResourceType(path),
+			ResourceId:   auditResourceId(vars),
+			Action:       r.Method,
+			Method:       r.Method,
+			Path:         r.URL.RequestURI(),
+			StatusCode:   d.Status(),
+			After:        redactAuditPayload(path, payload),
+		}
+		model.AppId, _ = strconv.Atoi(vars["appId"])
+		if model.AppId == 0 {
+			model.AppId = payloadAppId(payload)
+		}
+		if envId, ok := vars["envId"]; ok {
+			model.EnvironmentId, _ = strconv.Atoi(envId)
+		} else if envId, ok := vars["environmentId"]; ok {
+			model.EnvironmentId, _ = strconv.Atoi(envId)
+		}
+		impl.auditLogService.SaveApiLog(model)
+	})
+}
+
+// auditResourceType is the first two literal segments of the route, eg app/cd-pipeline for /orchestrator/app/cd-pipeline/{appId}
+func auditResourceType(pathTemplate string) string {
+	var segments []string
+	for _, segment := range strings.Split(strings.TrimPrefix(pathTemplate, "/orchestrator"), "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, "{") || len(segments) == 2 {
+			break
+		}
+		segments = append(segments, segment)
+	}
+	resourceType := strings.Join(segments, "/")
+	if len(resourceType) > maxAuditResourceTypeLength {
+		resourceType = resourceType[:maxAuditResourceTypeLength]
+	}
+	return resourceType
+}
+
+func auditResourceId(vars map[string]string) string {
+	for _, key := range resourceIdVars {
+		if value, ok := vars[key]; ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func payloadAppId(payload []byte) int {
+	request := struct {
+		AppId int `json:"appId"`
+	}{}
+	_ = json.Unmarshal(payload, &request)
+	return request.AppId
+}
+
+// redactAuditPayload masks values of sensitive keys, payloads of secret apis and payloads which are not json are dropped
+func redactAuditPayload(pathTemplate string, payload []byte) string {
+	if len(payload) == 0 {
+		return ""
+	}
+	for _, segment := range strings.Split(pathTemplate, "/") {
+		if sensitiveAuditSegments[segment] {
+			return redactedAuditValue
+		}
+	}
+	if len(payload) > maxAuditPayloadSize {
+		return redactedAuditValue
+	}
+	var value interface{}
+	err := json.Unmarshal(payload, &value)
+	if err != nil {
+		return redactedAuditValue
+	}
+	data, err := json.Marshal(redactAuditValue(value))
+	if err != nil {
+		return redactedAuditValue
+	}
+	return string(data)
+}
+
+func redactAuditValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if sensitiveAuditKeyRegex.MatchString(key) {
+				v[key] = redactedAuditValue
+			} else {
+				v[key] = redactAuditValue(item)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactAuditValue(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package middleware
+
+import "testing"
+
+func TestAuditResourceType(t *testing.T) {
+	tests := []struct {
+		pathTemplate string
+		want         string
+	}{
+		{pathTemplate: "/orchestrator/app/cd-pipeline/patch", want: "app/cd-pipeline"},
+		{pathTemplate: "/orchestrator/cluster", want: "cluster"},
+		{pathTemplate: "/orchestrator/user/{id}", want: "user"},
+		{pathTemplate: "/orchestrator/config/global/cm", want: "config/global"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pathTemplate, func(t *testing.T) {
+			if got := auditResourceType(tt.pathTemplate); got != tt.want {
+				t.Errorf("auditResourceType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactAuditPayload(t *testing.T) {
+	tests := []struct {
+		name         string
+		pathTemplate string
+		payload      string
+		want         string
+	}{
+		{name: "empty", pathTemplate: "/orchestrator/cluster", payload: "", want: ""},
+		{name: "nested sensitive keys",
+			pathTemplate: "/orchestrator/cluster",
+			payload:      `{"name":"c1","config":{"bearer_token":"abc"},"users":[{"password":"p"}]}`,
+			want:         `{"config":{"bearer_token":"******"},"name":"c1","users":[{"password":"******"}]}`,
+		},
+		{name: "secret api", pathTemplate: "/orchestrator/config/global/cs", payload: `{"appId":1}`, want: redactedAuditValue},
+		{name: "not json", pathTemplate: "/orchestrator/cluster", payload: "a=b", want: redactedAuditValue},
+		{name: "cluster certificates",
+			pathTemplate: "/orchestrator/cluster",
+			payload:      `{"cluster_name":"c1","config":{"cert_data":"c","key_data":"k","ca_data":"ca"}}`,
+			want:         `{"cluster_name":"c1","config":{"ca_data":"******","cert_data":"******","key_data":"******"}}`,
+		},
+		{name: "kubeconfig contexts", pathTemplate: "/orchestrator/cluster/kubeconfig/contexts",
+			payload: `{"kubeconfig":"apiVersion: v1"}`, want: redactedAuditValue},
+		{name: "kubeconfig import", pathTemplate: "/orchestrator/cluster/kubeconfig/import",
+			payload: `{"kubeconfig":"apiVersion: v1","contexts":["c1"]}`, want: redactedAuditValue},
+		{name: "kubeconfig key", pathTemplate: "/orchestrator/cluster",
+			payload: `{"kubeconfig":"apiVersion: v1"}`, want: `{"kubeconfig":"******"}`},
+		{name: "k8s secret manifest", pathTemplate: "/orchestrator/k8s/cluster/{clusterId}/resource",
+			payload: `{"kind":"Secret","data":{"password":"cA=="}}`, want: redactedAuditValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactAuditPayload(tt.pathTemplate, []byte(tt.payload)); got != tt.want {
+				t.Errorf("redactAuditPayload() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
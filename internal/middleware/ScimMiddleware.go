@@ -0,0 +1,39 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+const ScimPathPrefix = "/orchestrator/scim/v2/"
+
+// ScimBearerToken passes the bearer token of scim clients on as the token header, which is where the
+// authorizer and handlers look for it. Identity providers only support the Authorization header.
+func ScimBearerToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, ScimPathPrefix) && r.Header.Get("token") == "" {
+			authorization := r.Header.Get("Authorization")
+			if len(authorization) > 7 && strings.EqualFold(authorization[:7], "Bearer ") {
+				r.Header.Set("token", strings.TrimSpace(authorization[7:]))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package middleware
+
+import (
+	"encoding/json"
+	"github.com/devtron-labs/devtron/pkg/user"
+	util2 "github.com/devtron-labs/devtron/util"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+const tokenCookieName = "argocd.token"
+
+type UserSessionMiddleware interface {
+	ValidateSession(next http.Handler) http.Handler
+}
+
+type UserSessionMiddlewareImpl struct {
+	logger             *zap.SugaredLogger
+	userSessionService user.UserSessionService
+}
+
+func NewUserSessionMiddlewareImpl(logger *zap.SugaredLogger, userSessionService user.UserSessionService) *UserSessionMiddlewareImpl {
+	return &UserSessionMiddlewareImpl{
+		logger:             logger,
+		userSessionService: userSessionService,
+	}
+}
+
+// ValidateSession implements mux.MiddlewareFunc, it rejects requests made with a revoked token. The token is
+// verified by the authorizer before this runs, here it is only checked against the revocation list.
+func (impl UserSessionMiddlewareImpl) ValidateSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := user.SessionToken(r)
+		if token == "" || user.WhitelistChecker(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		valid, err := impl.userSessionService.ValidateSession(token, util2.GetClientIP(r), r.UserAgent())
+		if err != nil {
+			// the token itself was verified already, an unavailable revocation list does not lock everyone out
+			impl.logger.Errorw("error in validating session", "path", r.URL.Path, "err", err)
+		} else if !valid {
+			http.SetCookie(w, &http.Cookie{Name: tokenCookieName, Value: "", Path: "/", MaxAge: -1})
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": http.StatusUnauthorized, "result": "session revoked"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package chartConfig
+
+import (
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+)
+
+const (
+	ConfigMapHistoryTypeCM = "CM"
+	ConfigMapHistoryTypeCS = "CS"
+)
+
+// ConfigMapHistory is a snapshot of config_map_data (CM) or secret_data (CS) of an app (environment_id 0)
+// or env level config map row, taken after every change
+type ConfigMapHistory struct {
+	TableName     struct{} `sql:"config_map_history" pg:",discard_unknown_columns"`
+	Id            int      `sql:"id,pk"`
+	AppId         int      `sql:"app_id,notnull"`
+	EnvironmentId int      `sql:"environment_id,notnull"`
+	DataType      string   `sql:"data_type,notnull"`
+	Version       int      `sql:"version,notnull"`
+	Data          string   `sql:"data"`
+	Comment       string   `sql:"comment"`
+	sql.AuditLog
+}
+
+type ConfigMapHistoryRepository interface {
+	Save(model *ConfigMapHistory) error
+	FindById(id int) (*ConfigMapHistory, error)
+	FindLatest(appId int, envId int, dataType string) (*ConfigMapHistory, error)
+	FindAll(appId int, envId int, dataType string) ([]*ConfigMapHistory, error)
+}
+
+type ConfigMapHistoryRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func NewConfigMapHistoryRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *ConfigMapHistoryRepositoryImpl {
+	return &ConfigMapHistoryRepositoryImpl{dbConnection: dbConnection, logger: logger}
+}
+
+func (impl ConfigMapHistoryRepositoryImpl) Save(model *ConfigMapHistory) error {
+	return impl.dbConnection.Insert(model)
+}
+
+func (impl ConfigMapHistoryRepositoryImpl) FindById(id int) (*ConfigMapHistory, error) {
+	model := &ConfigMapHistory{}
+	err := impl.dbConnection.Model(model).Where("id = ?", id).Select()
+	return model, err
+}
+
+func (impl ConfigMapHistoryRepositoryImpl) FindLatest(appId int, envId int, dataType string) (*ConfigMapHistory, error) {
+	model := &ConfigMapHistory{}
+	err := impl.dbConnection.Model(model).
+		Where("app_id = ?", appId).
+		Where("environment_id = ?", envId).
+		Where("data_type = ?", dataType).
+		Order("version DESC").
+		Limit(1).
+		Select()
+	return model, err
+}
+
+// FindAll returns versions latest first, without data
+func (impl ConfigMapHistoryRepositoryImpl) FindAll(appId int, envId int, dataType string) ([]*ConfigMapHistory, error) {
+	var models []*ConfigMapHistory
+	err := impl.dbConnection.Model(&models).
+		Column("id", "app_id", "environment_id", "data_type", "version", "comment", "created_on", "created_by", "updated_on", "updated_by").
+		Where("app_id = ?", appId).
+		Where("environment_id = ?", envId).
+		Where("data_type = ?", dataType).
+		Order("version DESC").
+		Select()
+	return models, err
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package chartConfig
+
+import (
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+)
+
+// DeploymentTemplateHistory is a snapshot of the global override of a chart (environment_id 0) or of
+// an env override, taken after every change
+type DeploymentTemplateHistory struct {
+	TableName     struct{} `sql:"deployment_template_history" pg:",discard_unknown_columns"`
+	Id            int      `sql:"id,pk"`
+	AppId         int      `sql:"app_id,notnull"`
+	EnvironmentId int      `sql:"environment_id,notnull"`
+	ChartId       int      `sql:"chart_id,notnull"`
+	ChartVersion  string   `sql:"chart_version"`
+	IsOverride    bool     `sql:"is_override,notnull"`
+	Version       int      `sql:"version,notnull"`
+	Values        string   `sql:"values"`
+	Comment       string   `sql:"comment"`
+	sql.AuditLog
+}
+
+type DeploymentTemplateHistoryRepository interface {
+	Save(model *DeploymentTemplateHistory) error
+	FindById(id int) (*DeploymentTemplateHistory, error)
+	FindLatest(appId int, envId int) (*DeploymentTemplateHistory, error)
+	FindAll(appId int, envId int) ([]*DeploymentTemplateHistory, error)
+}
+
+type DeploymentTemplateHistoryRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func NewDeploymentTemplateHistoryRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *DeploymentTemplateHistoryRepositoryImpl {
+	return &DeploymentTemplateHistoryRepositoryImpl{dbConnection: dbConnection, logger: logger}
+}
+
+func (impl DeploymentTemplateHistoryRepositoryImpl) Save(model *DeploymentTemplateHistory) error {
+	return impl.dbConnection.Insert(model)
+}
+
+func (impl DeploymentTemplateHistoryRepositoryImpl) FindById(id int) (*DeploymentTemplateHistory, error) {
+	model := &DeploymentTemplateHistory{}
+	err := impl.dbConnection.Model(model).Where("id = ?", id).Select()
+	return model, err
+}
+
+func (impl DeploymentTemplateHistoryRepositoryImpl) FindLatest(appId int, envId int) (*DeploymentTemplateHistory, error) {
+	model := &DeploymentTemplateHistory{}
+	err := impl.dbConnection.Model(model).
+		Where("app_id = ?", appId).
+		Where("environment_id = ?", envId).
+		Order("version DESC").
+		Limit(1).
+		Select()
+	return model, err
+}
+
+// FindAll returns versions latest first, without values
+func (impl DeploymentTemplateHistoryRepositoryImpl) FindAll(appId int, envId int) ([]*DeploymentTemplateHistory, error) {
+	var models []*DeploymentTemplateHistory
+	err := impl.dbConnection.Model(&models).
+		Column("id", "app_id", "environment_id", "chart_id", "chart_version", "is_override", "version", "comment", "created_on", "created_by", "updated_on", "updated_by").
+		Where("app_id = ?", appId).
+		Where("environment_id = ?", envId).
+		Order("version DESC").
+		Select()
+	return models, err
+}
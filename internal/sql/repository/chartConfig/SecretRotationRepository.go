@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package chartConfig
+
+import (
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"time"
+)
+
+type SecretRotationStatus string
+
+const (
+	SecretRotationScheduled  SecretRotationStatus = "SCHEDULED"
+	SecretRotationInProgress SecretRotationStatus = "IN_PROGRESS"
+	SecretRotationSucceeded  SecretRotationStatus = "SUCCEEDED"
+	SecretRotationFailed     SecretRotationStatus = "FAILED"
+	SecretRotationCancelled  SecretRotationStatus = "CANCELLED"
+)
+
+type SecretRotation struct {
+	TableName     struct{}             `sql:"secret_rotation" pg:",discard_unknown_columns"`
+	Id            int                  `sql:"id,pk"`
+	AppId         int                  `sql:"app_id,notnull"`
+	EnvironmentId int                  `sql:"environment_id,notnull"`
+	SecretName    string               `sql:"secret_name,notnull"`
+	SecretData    string               `sql:"secret_data"`
+	Status        SecretRotationStatus `sql:"status,notnull"`
+	ScheduledOn   time.Time            `sql:"scheduled_on"`
+	ExecutedOn    time.Time            `sql:"executed_on"`
+	PipelineIds   []int                `sql:"pipeline_ids" pg:",array"`
+	Comment       string               `sql:"comment"`
+	Message       string               `sql:"message"`
+	sql.AuditLog
+}
+
+type SecretRotationRepository interface {
+	Save(model *SecretRotation) error
+	Update(model *SecretRotation) error
+	FindById(id int) (*SecretRotation, error)
+	FindByAppIdAndEnvId(appId int, envId int) ([]*SecretRotation, error)
+	FindDueScheduled(now time.Time) ([]*SecretRotation, error)
+	MarkInProgress(id int, userId int32) (bool, error)
+}
+
+type SecretRotationRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func NewSecretRotationRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *SecretRotationRepositoryImpl {
+	return &SecretRotationRepositoryImpl{dbConnection: dbConnection, logger: logger}
+}
+
+func (impl SecretRotationRepositoryImpl) Save(model *SecretRotation) error {
+	return impl.dbConnection.Insert(model)
+}
+
+func (impl SecretRotationRepositoryImpl) Update(model *SecretRotation) error {
+	return impl.dbConnection.Update(model)
+}
+
+func (impl SecretRotationRepositoryImpl) FindById(id int) (*SecretRotation, error) {
+	model := &SecretRotation{}
+	err := impl.dbConnection.Model(model).Where("id = ?", id).Select()
+	return model, err
+}
+
+// FindByAppIdAndEnvId returns rotation history of an app, envId 0 returns history of app level secrets
+func (impl SecretRotationRepositoryImpl) FindByAppIdAndEnvId(appId int, envId int) ([]*SecretRotation, error) {
+	var models []*SecretRotation
+	err := impl.dbConnection.Model(&models).
+		Where("app_id = ?", appId).
+		Where("environment_id = ?", envId).
+		Order("id DESC").
+		Select()
+	return models, err
+}
+
+func (impl SecretRotationRepositoryImpl) FindDueScheduled(now time.Time) ([]*SecretRotation, error) {
+	var models []*SecretRotation
+	err := impl.dbConnection.Model(&models).
+		Where("status = ?", SecretRotationScheduled).
+		Where("scheduled_on <= ?", now).
+		Order("scheduled_on ASC").
+		Select()
+	return models, err
+}
+
+// MarkInProgress moves a scheduled rotation to in progress, returns false if it was already picked
+// by another orchestrator instance or cancelled in between
+func (impl SecretRotationRepositoryImpl) MarkInProgress(id int, userId int32) (bool, error) {
+	res, err := impl.dbConnection.Model(&SecretRotation{}).
+		Set("status = ?", SecretRotationInProgress).
+		Set("updated_on = ?", time.Now()).
+		Set("updated_by = ?", userId).
+		Where("id = ?", id).
+		Where("status = ?", SecretRotationScheduled).
+		Update()
+	if err != nil {
+		impl.logger.Errorw("error in marking secret rotation in progress", "id", id, "err", err)
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
@@ -75,6 +75,7 @@ type PipelineRepository interface {
 	FindAutomaticByCiPipelineId(ciPipelineId int) (pipelines []*Pipeline, err error)
 	GetByEnvOverrideId(envOverrideId int) ([]Pipeline, error)
 	FindActiveByAppIdAndEnvironmentId(appId int, environmentId int) (pipelines []*Pipeline, err error)
+	FindActiveByEnvId(envId int) (pipelines []*Pipeline, err error)
 	UndoDelete(id int) error
 	UniqueAppEnvironmentPipelines() ([]*Pipeline, error)
 	FindByCiPipelineId(ciPipelineId int) (pipelines []*Pipeline, err error)
@@ -209,6 +210,17 @@ func (impl PipelineRepositoryImpl) FindActiveByAppIdAndEnvironmentId(appId int,
 	return pipelines, err
 }
 
+func (impl PipelineRepositoryImpl) FindActiveByEnvId(envId int) (pipelines []*Pipeline, err error) {
+	err = impl.dbConnection.Model(&pipelines).
+		Column("pipeline.*", "App").
+		Where("pipeline.environment_id = ?", envId).
+		Where("pipeline.deleted = ?", false).
+		Where("app.active = ?", true).
+		Order("pipeline.app_id").
+		Select()
+	return pipelines, err
+}
+
 func (impl PipelineRepositoryImpl) FindActiveByAppIdAndEnvironmentIdV2() (pipelines []*Pipeline, err error) {
 	err = impl.dbConnection.Model(&pipelines).
 		Where("deleted = ?", false).
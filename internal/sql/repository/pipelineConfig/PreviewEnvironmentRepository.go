@@ -0,0 +1,198 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pipelineConfig
+
+import (
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"time"
+)
+
+type PreviewEnvironmentStatus string
+
+const (
+	PREVIEW_ENVIRONMENT_ACTIVE  PreviewEnvironmentStatus = "ACTIVE"
+	PREVIEW_ENVIRONMENT_FAILED  PreviewEnvironmentStatus = "FAILED"
+	PREVIEW_ENVIRONMENT_DELETED PreviewEnvironmentStatus = "DELETED"
+)
+
+// PreviewEnvironmentConfig enables preview environments for the pull requests built by a ci pipeline
+type PreviewEnvironmentConfig struct {
+	tableName            struct{} `sql:"preview_environment_config" pg:",discard_unknown_columns"`
+	Id                   int      `sql:"id,pk"`
+	AppId                int      `sql:"app_id,notnull"`
+	CiPipelineId         int      `sql:"ci_pipeline_id,notnull"`
+	ReferencePipelineId  int      `sql:"reference_pipeline_id,notnull"` //cd pipeline whose strategy and template the previews start from
+	ClusterId            int      `sql:"cluster_id,notnull"`
+	ValuesOverride       string   `sql:"values_override"` //json patch applied on the reference values
+	UrlTemplate          string   `sql:"url_template"`
+	TtlHours             int      `sql:"ttl_hours,notnull"`
+	CommentOnPullRequest bool     `sql:"comment_on_pull_request,notnull"`
+	Active               bool     `sql:"active,notnull"`
+	sql.AuditLog
+}
+
+// PreviewEnvironment is the environment created for one pull request
+type PreviewEnvironment struct {
+	tableName      struct{}                 `sql:"preview_environment" pg:",discard_unknown_columns"`
+	Id             int                      `sql:"id,pk"`
+	ConfigId       int                      `sql:"config_id,notnull"`
+	AppId          int                      `sql:"app_id,notnull"`
+	PullRequestId  string                   `sql:"pull_request_id,notnull"`
+	PullRequestUrl string                   `sql:"pull_request_url"`
+	SourceBranch   string                   `sql:"source_branch"`
+	EnvironmentId  int                      `sql:"environment_id"`
+	CdPipelineId   int                      `sql:"cd_pipeline_id"`
+	Namespace      string                   `sql:"namespace"`
+	Url            string                   `sql:"url"`
+	Status         PreviewEnvironmentStatus `sql:"status,notnull"`
+	Message        string                   `sql:"message"`
+	ExpiresOn      time.Time                `sql:"expires_on,notnull"`
+	sql.AuditLog
+}
+
+type PreviewEnvironmentRepository interface {
+	SaveConfig(config *PreviewEnvironmentConfig) error
+	UpdateConfig(config *PreviewEnvironmentConfig) error
+	FindConfigById(id int) (*PreviewEnvironmentConfig, error)
+	FindActiveConfigByCiPipelineId(ciPipelineId int) (*PreviewEnvironmentConfig, error)
+	FindActiveConfigsByAppId(appId int) ([]*PreviewEnvironmentConfig, error)
+
+	Save(previewEnvironment *PreviewEnvironment) error
+	Update(previewEnvironment *PreviewEnvironment) error
+	FindById(id int) (*PreviewEnvironment, error)
+	FindActiveByConfigIdAndPullRequestId(configId int, pullRequestId string) (*PreviewEnvironment, error)
+	FindActiveByCdPipelineId(cdPipelineId int) (*PreviewEnvironment, error)
+	FindActiveByConfigId(configId int) ([]*PreviewEnvironment, error)
+	FindActiveByAppId(appId int) ([]*PreviewEnvironment, error)
+	FindActiveExpiredBefore(expiresOn time.Time) ([]*PreviewEnvironment, error)
+}
+
+type PreviewEnvironmentRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func NewPreviewEnvironmentRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *PreviewEnvironmentRepositoryImpl {
+	return &PreviewEnvironmentRepositoryImpl{
+		dbConnection: dbConnection,
+		logger:       logger,
+	}
+}
+
+func (impl PreviewEnvironmentRepositoryImpl) SaveConfig(config *PreviewEnvironmentConfig) error {
+	return impl.dbConnection.Insert(config)
+}
+
+func (impl PreviewEnvironmentRepositoryImpl) UpdateConfig(config *PreviewEnvironmentConfig) error {
+	return impl.dbConnection.Update(config)
+}
+
+func (impl PreviewEnvironmentRepositoryImpl) FindConfigById(id int) (*PreviewEnvironmentConfig, error) {
+	config := &PreviewEnvironmentConfig{}
+	err := impl.dbConnection.Model(config).
+		Where("id = ?", id).
+		Where("active = ?", true).
+		Select()
+	return config, err
+}
+
+func (impl PreviewEnvironmentRepositoryImpl) FindActiveConfigByCiPipelineId(ciPipelineId int) (*PreviewEnvironmentConfig, error) {
+	config := &PreviewEnvironmentConfig{}
+	err := impl.dbConnection.Model(config).
+		Where("ci_pipeline_id = ?", ciPipelineId).
+		Where("active = ?", true).
+		Select()
+	return config, err
+}
+
+func (impl PreviewEnvironmentRepositoryImpl) FindActiveConfigsByAppId(appId int) ([]*PreviewEnvironmentConfig, error) {
+	var configs []*PreviewEnvironmentConfig
+	err := impl.dbConnection.Model(&configs).
+		Where("app_id = ?", appId).
+		Where("active = ?", true).
+		Order("id").
+		Select()
+	return configs, err
+}
+
+func (impl PreviewEnvironmentRepositoryImpl) Save(previewEnvironment *PreviewEnvironment) error {
+	return impl.dbConnection.Insert(previewEnvironment)
+}
+
+func (impl PreviewEnvironmentRepositoryImpl) Update(previewEnvironment *PreviewEnvironment) error {
+	return impl.dbConnection.Update(previewEnvironment)
+}
+
+func (impl PreviewEnvironmentRepositoryImpl) FindById(id int) (*PreviewEnvironment, error) {
+	previewEnvironment := &PreviewEnvironment{}
+	err := impl.dbConnection.Model(previewEnvironment).
+		Where("id = ?", id).
+		Select()
+	return previewEnvironment, err
+}
+
+func (impl PreviewEnvironmentRepositoryImpl) FindActiveByConfigIdAndPullRequestId(configId int, pullRequestId string) (*PreviewEnvironment, error) {
+	previewEnvironment := &PreviewEnvironment{}
+	err := impl.dbConnection.Model(previewEnvironment).
+		Where("config_id = ?", configId).
+		Where("pull_request_id = ?", pullRequestId).
+		Where("status = ?", PREVIEW_ENVIRONMENT_ACTIVE).
+		Limit(1).
+		Select()
+	return previewEnvironment, err
+}
+
+func (impl PreviewEnvironmentRepositoryImpl) FindActiveByCdPipelineId(cdPipelineId int) (*PreviewEnvironment, error) {
+	previewEnvironment := &PreviewEnvironment{}
+	err := impl.dbConnection.Model(previewEnvironment).
+		Where("cd_pipeline_id = ?", cdPipelineId).
+		Where("status = ?", PREVIEW_ENVIRONMENT_ACTIVE).
+		Limit(1).
+		Select()
+	return previewEnvironment, err
+}
+
+func (impl PreviewEnvironmentRepositoryImpl) FindActiveByConfigId(configId int) ([]*PreviewEnvironment, error) {
+	var previewEnvironments []*PreviewEnvironment
+	err := impl.dbConnection.Model(&previewEnvironments).
+		Where("config_id = ?", configId).
+		Where("status = ?", PREVIEW_ENVIRONMENT_ACTIVE).
+		Select()
+	return previewEnvironments, err
+}
+
+func (impl PreviewEnvironmentRepositoryImpl) FindActiveByAppId(appId int) ([]*PreviewEnvironment, error) {
+	var previewEnvironments []*PreviewEnvironment
+	err := impl.dbConnection.Model(&previewEnvironments).
+		Where("app_id = ?", appId).
+		Where("status = ?", PREVIEW_ENVIRONMENT_ACTIVE).
+		Order("id DESC").
+		Select()
+	return previewEnvironments, err
+}
+
+func (impl PreviewEnvironmentRepositoryImpl) FindActiveExpiredBefore(expiresOn time.Time) ([]*PreviewEnvironment, error) {
+	var previewEnvironments []*PreviewEnvironment
+	err := impl.dbConnection.Model(&previewEnvironments).
+		Where("status = ?", PREVIEW_ENVIRONMENT_ACTIVE).
+		Where("expires_on < ?", expiresOn).
+		Select()
+	return previewEnvironments, err
+}
@@ -28,6 +28,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	v12 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
@@ -41,26 +42,61 @@ type K8sUtil struct {
 type ClusterConfig struct {
 	Host        string
 	BearerToken string
+	// CertData and KeyData are the PEM encoded client certificate and key, CAData is the PEM encoded CA of the api server
+	CertData string
+	KeyData  string
+	CAData   string
+	// Timeout of requests made by clients of this config, zero means no timeout
+	Timeout time.Duration
 }
 
-func NewK8sUtil(logger *zap.SugaredLogger) *K8sUtil {
-	return &K8sUtil{logger: logger}
+// keys of the credentials kept in the config of a cluster, certificates and keys are PEM encoded
+const (
+	BearerTokenKey = "bearer_token"
+	CertDataKey    = "cert_data"
+	KeyDataKey     = "key_data"
+	CADataKey      = "ca_data"
+)
+
+// NewClusterConfig builds the config of a cluster from the credentials kept in its config
+func NewClusterConfig(host string, config map[string]string) *ClusterConfig {
+	return &ClusterConfig{
+		Host:        host,
+		BearerToken: config[BearerTokenKey],
+		CertData:    config[CertDataKey],
+		KeyData:     config[KeyDataKey],
+		CAData:      config[CADataKey],
+	}
 }
 
-func (impl K8sUtil) GetClient(clusterConfig *ClusterConfig) (*v12.CoreV1Client, error) {
+// RestConfig builds the client config of a cluster. TLS verification of the api server is skipped unless its CA is known
+func (clusterConfig *ClusterConfig) RestConfig() *rest.Config {
 	cfg := &rest.Config{}
 	cfg.Host = clusterConfig.Host
 	cfg.BearerToken = clusterConfig.BearerToken
-	cfg.Insecure = true
+	cfg.CertData = []byte(clusterConfig.CertData)
+	cfg.KeyData = []byte(clusterConfig.KeyData)
+	if len(clusterConfig.CAData) > 0 {
+		cfg.CAData = []byte(clusterConfig.CAData)
+	} else {
+		cfg.Insecure = true
+	}
+	cfg.Timeout = clusterConfig.Timeout
+	return cfg
+}
+
+func NewK8sUtil(logger *zap.SugaredLogger) *K8sUtil {
+	return &K8sUtil{logger: logger}
+}
+
+func (impl K8sUtil) GetClient(clusterConfig *ClusterConfig) (*v12.CoreV1Client, error) {
+	cfg := clusterConfig.RestConfig()
 	client, err := v12.NewForConfig(cfg)
 	return client, err
 }
 
 func (impl K8sUtil) GetClientSet(clusterConfig *ClusterConfig) (*kubernetes.Clientset, error) {
-	cfg := &rest.Config{}
-	cfg.Host = clusterConfig.Host
-	cfg.BearerToken = clusterConfig.BearerToken
-	cfg.Insecure = true
+	cfg := clusterConfig.RestConfig()
 	client, err := kubernetes.NewForConfig(cfg)
 	return client, err
 }
@@ -82,10 +118,7 @@ func (impl K8sUtil) GetClientForInCluster() (*v12.CoreV1Client, error) {
 }
 
 func (impl K8sUtil) GetK8sDiscoveryClient(clusterConfig *ClusterConfig) (*discovery.DiscoveryClient, error) {
-	cfg := &rest.Config{}
-	cfg.Host = clusterConfig.Host
-	cfg.BearerToken = clusterConfig.BearerToken
-	cfg.Insecure = true
+	cfg := clusterConfig.RestConfig()
 	client, err := discovery.NewDiscoveryClientForConfig(cfg)
 	if err != nil {
 		impl.logger.Errorw("error", "error", err, "clusterConfig", clusterConfig)
@@ -94,6 +127,16 @@ func (impl K8sUtil) GetK8sDiscoveryClient(clusterConfig *ClusterConfig) (*discov
 	return client, err
 }
 
+func (impl K8sUtil) GetDynamicClient(clusterConfig *ClusterConfig) (dynamic.Interface, error) {
+	cfg := clusterConfig.RestConfig()
+	client, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		impl.logger.Errorw("error", "error", err, "host", clusterConfig.Host)
+		return nil, err
+	}
+	return client, err
+}
+
 func (impl K8sUtil) GetK8sDiscoveryClientInCluster() (*discovery.DiscoveryClient, error) {
 	config, err := rest.InClusterConfig()
 	if err != nil {
@@ -127,6 +170,19 @@ func (impl K8sUtil) CreateNsIfNotExists(namespace string, clusterConfig *Cluster
 	return err
 }
 
+func (impl K8sUtil) DeleteNsIfExists(namespace string, clusterConfig *ClusterConfig) error {
+	client, err := impl.GetClient(clusterConfig)
+	if err != nil {
+		impl.logger.Errorw("error", "error", err, "clusterConfig", clusterConfig)
+		return err
+	}
+	err = impl.deleteNs(namespace, client)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
 func (impl K8sUtil) checkIfNsExists(namespace string, client *v12.CoreV1Client) (exists bool, err error) {
 	ns, err := client.Namespaces().Get(namespace, metav1.GetOptions{})
 	//ns, err := impl.k8sClient.CoreV1().Namespaces().Get(namespace, metav1.GetOptions{})
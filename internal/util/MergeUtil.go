@@ -165,8 +165,11 @@ func (m MergeUtil) ConfigSecretMerge(appLevelSecretJson string, envLevelSecretJs
 	}
 	for _, item := range appLevelSecret.Secrets {
 		//else ignoring this value as override from configB
-		if _, ok := commonSecrets[item.Name]; !ok {
+		if envItem, ok := commonSecrets[item.Name]; !ok {
 			commonSecrets[item.Name] = item
+		} else if envItem.ExternalType == item.ExternalType && (item.ExternalType == util.GCPSecretManager || item.ExternalType == util.AzureKeyVault) {
+			//env level only carries its own credential references, rest of the store is inherited from app level
+			envItem.SecretStore = MergeSecretStore(item.SecretStore, envItem.SecretStore)
 		}
 	}
 
@@ -204,3 +207,35 @@ func (m MergeUtil) ConfigSecretMerge(appLevelSecretJson string, envLevelSecretJs
 	}
 	return string(byteData), err
 }
+
+// MergeSecretStore overlays the settings given at env level on the secret store of the app level secret,
+// credential references are replaced as a whole
+func MergeSecretStore(appLevelStore *bean.SecretStore, envLevelStore *bean.SecretStore) *bean.SecretStore {
+	if appLevelStore == nil {
+		return envLevelStore
+	}
+	if envLevelStore == nil {
+		return appLevelStore
+	}
+	merged := *appLevelStore
+	if len(envLevelStore.ProjectId) > 0 {
+		merged.ProjectId = envLevelStore.ProjectId
+	}
+	if len(envLevelStore.ClusterLocation) > 0 {
+		merged.ClusterLocation = envLevelStore.ClusterLocation
+	}
+	if len(envLevelStore.ClusterName) > 0 {
+		merged.ClusterName = envLevelStore.ClusterName
+	}
+	if len(envLevelStore.VaultUrl) > 0 {
+		merged.VaultUrl = envLevelStore.VaultUrl
+	}
+	if len(envLevelStore.TenantId) > 0 {
+		merged.TenantId = envLevelStore.TenantId
+	}
+	if len(envLevelStore.ServiceAccountName) > 0 || len(envLevelStore.CredentialSecretName) > 0 {
+		merged.ServiceAccountName = envLevelStore.ServiceAccountName
+		merged.CredentialSecretName = envLevelStore.CredentialSecretName
+	}
+	return &merged
+}
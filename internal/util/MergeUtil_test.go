@@ -0,0 +1,101 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package util
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/devtron-labs/devtron/api/bean"
+)
+
+func TestMergeSecretStore(t *testing.T) {
+	type args struct {
+		appLevelStore *bean.SecretStore
+		envLevelStore *bean.SecretStore
+	}
+	tests := []struct {
+		name string
+		args args
+		want *bean.SecretStore
+	}{
+		{name: "env level only",
+			args: args{
+				envLevelStore: &bean.SecretStore{ProjectId: "p1"},
+			},
+			want: &bean.SecretStore{ProjectId: "p1"},
+		},
+		{name: "inherit app level",
+			args: args{
+				appLevelStore: &bean.SecretStore{ProjectId: "p1", ServiceAccountName: "sa"},
+			},
+			want: &bean.SecretStore{ProjectId: "p1", ServiceAccountName: "sa"},
+		},
+		{name: "env level credential replaces app level credential",
+			args: args{
+				appLevelStore: &bean.SecretStore{VaultUrl: "https://kv", TenantId: "t1", ServiceAccountName: "sa"},
+				envLevelStore: &bean.SecretStore{CredentialSecretName: "sp-prod"},
+			},
+			want: &bean.SecretStore{VaultUrl: "https://kv", TenantId: "t1", CredentialSecretName: "sp-prod"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MergeSecretStore(tt.args.appLevelStore, tt.args.envLevelStore); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergeSecretStore() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigSecretMerge(t *testing.T) {
+	appLevel := `{"enabled":true,"secrets":[
+		{"name":"gcp","external":true,"externalType":"GCPSecretManager","secretStore":{"projectId":"p1","serviceAccountName":"sa"}},
+		{"name":"azure","external":true,"externalType":"AzureKeyVault","secretStore":{"vaultUrl":"https://kv","tenantId":"t1"}},
+		{"name":"app-only","type":"environment","data":{"A":"YQ=="}}]}`
+	envLevel := `{"enabled":true,"secrets":[
+		{"name":"gcp","external":true,"externalType":"GCPSecretManager","secretStore":{"credentialSecretName":"gcp-prod"}},
+		{"name":"azure","external":true,"externalType":"KubernetesSecret"},
+		{"name":"env-only","type":"environment","data":{"B":"Yg=="}}]}`
+	merge := MergeUtil{Logger: NewSugardLogger()}
+	data, err := merge.ConfigSecretMerge(appLevel, envLevel, 4, 11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := bean.ConfigSecretJson{}
+	err = json.Unmarshal([]byte(data), &merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := make(map[string]*bean.Map)
+	for _, secret := range merged.Secrets {
+		secrets[secret.Name] = secret
+	}
+	if len(secrets) != 4 || secrets["app-only"] == nil || secrets["env-only"] == nil {
+		t.Fatalf("expected app level and env level secrets to be merged, got %s", data)
+	}
+	// env level credential replaces the app level one, rest of the store is inherited
+	if want := (&bean.SecretStore{ProjectId: "p1", CredentialSecretName: "gcp-prod"}); !reflect.DeepEqual(secrets["gcp"].SecretStore, want) {
+		t.Errorf("gcp secret store = %+v, want %+v", secrets["gcp"].SecretStore, want)
+	}
+	// env level secret of another type overrides the app level secret as a whole
+	if secrets["azure"].ExternalType != "KubernetesSecret" || secrets["azure"].SecretStore != nil {
+		t.Errorf("azure secret should be overridden by env level, got %+v", secrets["azure"])
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/devtron-labs/devtron/pkg/sql"
 	"github.com/devtron-labs/devtron/pkg/user/casbin"
 	util3 "github.com/devtron-labs/devtron/pkg/util"
+	"github.com/devtron-labs/devtron/pkg/variables"
 	"net/url"
 	"strconv"
 	"strings"
@@ -92,6 +93,8 @@ type AppServiceImpl struct {
 	imageScanHistoryRepository    security.ImageScanHistoryRepository
 	ArgoK8sClient                 argocdServer.ArgoK8sClient
 	gitOpsRepository              repository.GitOpsConfigRepository
+	variableService               variables.VariableService
+	namespaceReconciler           NamespaceReconciler
 }
 
 type AppService interface {
@@ -105,6 +108,11 @@ type AppService interface {
 	MarkImageScanDeployed(appId int, envId int, imageDigest string, clusterId int) error
 }
 
+// NamespaceReconciler applies the namespace config of an environment, it is called before every release
+type NamespaceReconciler interface {
+	ReconcileNamespace(environmentId int) error
+}
+
 func NewAppService(
 	environmentConfigRepository chartConfig.EnvConfigOverrideRepository,
 	pipelineOverrideRepository chartConfig.PipelineOverrideRepository,
@@ -127,7 +135,8 @@ func NewAppService(
 	cdWorkflowRepository pipelineConfig.CdWorkflowRepository, commonService commonService.CommonService,
 	imageScanDeployInfoRepository security.ImageScanDeployInfoRepository, imageScanHistoryRepository security.ImageScanHistoryRepository,
 	ArgoK8sClient argocdServer.ArgoK8sClient,
-	gitFactory *GitFactory, gitOpsRepository repository.GitOpsConfigRepository) *AppServiceImpl {
+	gitFactory *GitFactory, gitOpsRepository repository.GitOpsConfigRepository,
+	variableService variables.VariableService, namespaceReconciler NamespaceReconciler) *AppServiceImpl {
 	appServiceImpl := &AppServiceImpl{
 		environmentConfigRepository:   environmentConfigRepository,
 		mergeUtil:                     mergeUtil,
@@ -160,6 +169,8 @@ func NewAppService(
 		ArgoK8sClient:                 ArgoK8sClient,
 		gitFactory:                    gitFactory,
 		gitOpsRepository:              gitOpsRepository,
+		variableService:               variableService,
+		namespaceReconciler:           namespaceReconciler,
 	}
 	return appServiceImpl
 }
@@ -562,6 +573,11 @@ func (impl AppServiceImpl) TriggerRelease(overrideRequest *bean.ValuesOverrideRe
 		configMapJson = nil
 	}
 
+	//quota and limits are applied before the release, a failure is not allowed to block the deployment
+	if err := impl.namespaceReconciler.ReconcileNamespace(envOverride.TargetEnvironment); err != nil {
+		impl.logger.Errorw("error in reconciling namespace", "envId", envOverride.TargetEnvironment, "err", err)
+	}
+
 	releaseId, pipelineOverrideId, saveErr := impl.mergeAndSave(envOverride, overrideRequest, dbMigrationOverride, artifact, pipeline, configMapJson, strategy, ctx)
 	if releaseId != 0 {
 		flag, err := impl.updateArgoPipeline(overrideRequest.AppId, pipeline.Name, envOverride, ctx)
@@ -1072,7 +1088,19 @@ func (impl AppServiceImpl) mergeAndSave(envOverride *chartConfig.EnvConfigOverri
 		return 0, 0, err
 	}
 
+	//resolve @{{NAME}} placeholders of template and CM/CS data against variables of app, env and cluster
+	variableScope := variables.Scope{AppId: pipeline.AppId, EnvironmentId: envOverride.TargetEnvironment, ClusterId: envOverride.Environment.ClusterId}
+	merged, err = impl.variableService.ResolveJson(merged, variableScope)
+	if err != nil {
+		impl.logger.Errorw("error in resolving variables of deployment template", "pipelineId", pipeline.Id, "err", err)
+		return 0, 0, err
+	}
 	if configMapJson != nil {
+		configMapJson, err = impl.variableService.ResolveConfigMapSecretJson(configMapJson, variableScope)
+		if err != nil {
+			impl.logger.Errorw("error in resolving variables of config maps and secrets", "pipelineId", pipeline.Id, "err", err)
+			return 0, 0, err
+		}
 		merged, err = impl.mergeUtil.JsonPatch(merged, configMapJson)
 		if err != nil {
 			return 0, 0, err
@@ -0,0 +1,513 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package appClone
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	bean2 "github.com/devtron-labs/devtron/api/bean"
+	appWorkflow2 "github.com/devtron-labs/devtron/internal/sql/repository/appWorkflow"
+	"github.com/devtron-labs/devtron/internal/sql/repository/pipelineConfig"
+	"github.com/devtron-labs/devtron/internal/sql/repository/security"
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/bean"
+	"github.com/devtron-labs/devtron/pkg/cluster"
+	"github.com/devtron-labs/devtron/pkg/pipeline"
+	security2 "github.com/devtron-labs/devtron/pkg/security"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+)
+
+type EnvironmentCloneRequest struct {
+	SourceEnvironmentId int    `json:"sourceEnvironmentId" validate:"number,required"`
+	Environment         string `json:"environment" validate:"required,max=50"`
+	ClusterId           int    `json:"clusterId" validate:"number,required"`
+	Namespace           string `json:"namespace" validate:"required,max=50"`
+	Default             bool   `json:"default"`
+	// AppIds limits the clone to these apps of the source environment, every app is cloned when empty
+	AppIds []int `json:"appIds"`
+	DryRun bool  `json:"dryRun"`
+	UserId int32 `json:"-"`
+}
+
+type EnvironmentCloneResponse struct {
+	EnvironmentId int    `json:"environmentId,omitempty"`
+	Environment   string `json:"environment"`
+	DryRun        bool   `json:"dryRun"`
+	// Policies is the number of environment level vulnerability policies
+	Policies int                    `json:"policies"`
+	Apps     []*AppEnvironmentClone `json:"apps"`
+}
+
+// AppEnvironmentClone is what is (or would be on dry run) created for an app in the new environment
+type AppEnvironmentClone struct {
+	AppId                      int      `json:"appId"`
+	AppName                    string   `json:"appName"`
+	CdPipeline                 string   `json:"cdPipeline"`
+	DeploymentTemplateOverride bool     `json:"deploymentTemplateOverride"`
+	ConfigMaps                 []string `json:"configMaps"`
+	Secrets                    []string `json:"secrets"`
+	AppMetrics                 bool     `json:"appMetrics"`
+	Policies                   int      `json:"policies"`
+	Error                      string   `json:"error,omitempty"`
+}
+
+// EnvironmentCloneService creates an environment as a copy of another one. Each app is cloned on its own, a
+// failing app is reported in the response and does not stop the others
+type EnvironmentCloneService interface {
+	CloneEnvironment(ctx context.Context, request *EnvironmentCloneRequest) (*EnvironmentCloneResponse, error)
+}
+
+type EnvironmentCloneServiceImpl struct {
+	logger                  *zap.SugaredLogger
+	environmentService      cluster.EnvironmentService
+	pipelineRepository      pipelineConfig.PipelineRepository
+	appWorkflowRepository   appWorkflow2.AppWorkflowRepository
+	pipelineBuilder         pipeline.PipelineBuilder
+	chartService            pipeline.ChartService
+	propertiesConfigService pipeline.PropertiesConfigService
+	configMapService        pipeline.ConfigMapService
+	cvePolicyRepository     security.CvePolicyRepository
+	policyService           security2.PolicyService
+}
+
+func NewEnvironmentCloneServiceImpl(logger *zap.SugaredLogger, environmentService cluster.EnvironmentService,
+	pipelineRepository pipelineConfig.PipelineRepository, appWorkflowRepository appWorkflow2.AppWorkflowRepository,
+	pipelineBuilder pipeline.PipelineBuilder, chartService pipeline.ChartService,
+	propertiesConfigService pipeline.PropertiesConfigService, configMapService pipeline.ConfigMapService,
+	cvePolicyRepository security.CvePolicyRepository, policyService security2.PolicyService) *EnvironmentCloneServiceImpl {
+	return &EnvironmentCloneServiceImpl{
+		logger:                  logger,
+		environmentService:      environmentService,
+		pipelineRepository:      pipelineRepository,
+		appWorkflowRepository:   appWorkflowRepository,
+		pipelineBuilder:         pipelineBuilder,
+		chartService:            chartService,
+		propertiesConfigService: propertiesConfigService,
+		configMapService:        configMapService,
+		cvePolicyRepository:     cvePolicyRepository,
+		policyService:           policyService,
+	}
+}
+
+// appClonePlan holds the source config of an app which is copied to the new environment
+type appClonePlan struct {
+	result           *AppEnvironmentClone
+	sourcePipelineId int
+	envProperties    *pipeline.EnvironmentProperties
+	configMaps       *pipeline.ConfigDataRequest
+	secrets          *pipeline.ConfigDataRequest
+	policies         []*security.CvePolicy
+}
+
+func (impl *EnvironmentCloneServiceImpl) CloneEnvironment(ctx context.Context, request *EnvironmentCloneRequest) (*EnvironmentCloneResponse, error) {
+	sourceEnv, err := impl.environmentService.FindById(request.SourceEnvironmentId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching source environment", "envId", request.SourceEnvironmentId, "err", err)
+		return nil, err
+	}
+	existingEnv, err := impl.environmentService.FindOne(request.Environment)
+	if err != nil && err != pg.ErrNoRows {
+		return nil, err
+	}
+	if err == nil && existingEnv.Id > 0 {
+		return nil, &util.ApiError{
+			HttpStatusCode:  http.StatusConflict,
+			InternalMessage: "environment already exists",
+			UserMessage:     fmt.Sprintf("environment %s already exists", request.Environment),
+		}
+	}
+	pipelines, err := impl.pipelineRepository.FindActiveByEnvId(sourceEnv.Id)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching cd pipelines of environment", "envId", sourceEnv.Id, "err", err)
+		return nil, err
+	}
+	policies, err := impl.cvePolicyRepository.GetEnvPolicies(sourceEnv.ClusterId, sourceEnv.Id)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching policies of environment", "envId", sourceEnv.Id, "err", err)
+		return nil, err
+	}
+	var envPolicies []*security.CvePolicy
+	for _, policy := range policies {
+		if policy.EnvironmentId == sourceEnv.Id && policy.AppId == 0 {
+			envPolicies = append(envPolicies, policy)
+		}
+	}
+	response := &EnvironmentCloneResponse{
+		Environment: request.Environment,
+		DryRun:      request.DryRun,
+		Policies:    len(envPolicies),
+		Apps:        make([]*AppEnvironmentClone, 0, len(pipelines)),
+	}
+	var plans []*appClonePlan
+	for _, cdPipeline := range pipelines {
+		if len(request.AppIds) > 0 && !containsInt(request.AppIds, cdPipeline.AppId) {
+			continue
+		}
+		plan := impl.planApp(cdPipeline, sourceEnv, request.Environment, policies)
+		response.Apps = append(response.Apps, plan.result)
+		plans = append(plans, plan)
+	}
+	if request.DryRun {
+		return response, nil
+	}
+
+	env, err := impl.environmentService.Create(&cluster.EnvironmentBean{
+		Environment: request.Environment,
+		ClusterId:   request.ClusterId,
+		Namespace:   request.Namespace,
+		Active:      true,
+		Default:     request.Default,
+	}, request.UserId)
+	if err != nil {
+		impl.logger.Errorw("error in creating environment", "environment", request.Environment, "err", err)
+		return nil, err
+	}
+	response.EnvironmentId = env.Id
+	rollback := &cloneRollback{}
+	err = impl.clonePolicies(envPolicies, env.Id, request.UserId, rollback)
+	if err != nil {
+		impl.logger.Errorw("error in cloning environment policies", "envId", env.Id, "err", err)
+		impl.rollback(rollback, 0, env.Id)
+		env.Active = false
+		if _, deactivateErr := impl.environmentService.Update(env, request.UserId); deactivateErr != nil {
+			impl.logger.Errorw("error in deactivating environment of failed clone", "envId", env.Id, "err", deactivateErr)
+		}
+		return nil, err
+	}
+	for _, plan := range plans {
+		if len(plan.result.Error) > 0 {
+			continue
+		}
+		rollback = &cloneRollback{}
+		err = impl.cloneApp(ctx, plan, env, request.UserId, rollback)
+		if err != nil {
+			impl.logger.Errorw("error in cloning app to environment", "appId", plan.result.AppId, "envId", env.Id, "err", err)
+			plan.result.Error = err.Error()
+			// the app is left without any config in the new environment rather than half cloned
+			impl.rollback(rollback, plan.result.AppId, env.Id)
+		}
+	}
+	return response, nil
+}
+
+// cloneRollback collects the undo steps of what a clone created so far, the config services each commit on their
+// own so a failed clone is undone step by step instead of in one transaction
+type cloneRollback struct {
+	steps []func() error
+}
+
+func (r *cloneRollback) add(step func() error) {
+	r.steps = append(r.steps, step)
+}
+
+// rollback runs the undo steps in reverse order, a failing step is logged and the others still run
+func (impl *EnvironmentCloneServiceImpl) rollback(rollback *cloneRollback, appId int, envId int) {
+	for i := len(rollback.steps) - 1; i >= 0; i-- {
+		if err := rollback.steps[i](); err != nil {
+			impl.logger.Errorw("error in rolling back environment clone", "appId", appId, "envId", envId, "err", err)
+		}
+	}
+}
+
+// planApp lists the config of an app in the source environment, an app whose config cannot be read is reported
+// with its error and skipped
+func (impl *EnvironmentCloneServiceImpl) planApp(cdPipeline *pipelineConfig.Pipeline, sourceEnv *cluster.EnvironmentBean, environment string,
+	policies []*security.CvePolicy) *appClonePlan {
+	plan := &appClonePlan{
+		sourcePipelineId: cdPipeline.Id,
+		result: &AppEnvironmentClone{
+			AppId:      cdPipeline.AppId,
+			AppName:    cdPipeline.App.AppName,
+			CdPipeline: clonedPipelineName(cdPipeline.Name, sourceEnv.Environment, environment),
+			ConfigMaps: []string{},
+			Secrets:    []string{},
+		},
+	}
+	for _, policy := range policies {
+		if policy.EnvironmentId == sourceEnv.Id && policy.AppId == cdPipeline.AppId {
+			plan.policies = append(plan.policies, policy)
+		}
+	}
+	plan.result.Policies = len(plan.policies)
+	err := impl.planAppConfig(plan, sourceEnv.Id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching app config of environment", "appId", cdPipeline.AppId, "envId", sourceEnv.Id, "err", err)
+		plan.result.Error = err.Error()
+	}
+	return plan
+}
+
+func (impl *EnvironmentCloneServiceImpl) planAppConfig(plan *appClonePlan, sourceEnvId int) error {
+	appId := plan.result.AppId
+	chartRefRes, err := impl.chartService.ChartRefAutocompleteForAppOrEnv(appId, sourceEnvId)
+	if err != nil {
+		return err
+	}
+	envProperties, err := impl.propertiesConfigService.GetEnvironmentProperties(appId, sourceEnvId, chartRefRes.LatestEnvChartRef)
+	if err != nil {
+		return err
+	}
+	if envProperties.IsOverride {
+		plan.envProperties = &envProperties.EnvironmentConfig
+		plan.result.DeploymentTemplateOverride = true
+	}
+	plan.result.AppMetrics = envProperties.AppMetrics != nil && *envProperties.AppMetrics
+	plan.configMaps, err = impl.configMapService.CMEnvironmentFetch(appId, sourceEnvId)
+	if err != nil {
+		return err
+	}
+	plan.configMaps.ConfigData = envLevelConfigData(plan.configMaps.ConfigData)
+	for _, configData := range plan.configMaps.ConfigData {
+		plan.result.ConfigMaps = append(plan.result.ConfigMaps, configData.Name)
+	}
+	plan.secrets, err = impl.configMapService.CSEnvironmentFetch(appId, sourceEnvId)
+	if err != nil {
+		return err
+	}
+	plan.secrets.ConfigData = envLevelConfigData(plan.secrets.ConfigData)
+	for _, configData := range plan.secrets.ConfigData {
+		plan.result.Secrets = append(plan.result.Secrets, configData.Name)
+	}
+	return nil
+}
+
+// cloneApp copies the config in the same order as an app clone, overrides and config before the pipeline so that
+// its first deployment already has them. Undo steps of everything created are added to rollback
+func (impl *EnvironmentCloneServiceImpl) cloneApp(ctx context.Context, plan *appClonePlan, env *cluster.EnvironmentBean, userId int32,
+	rollback *cloneRollback) error {
+	appId := plan.result.AppId
+	if plan.envProperties != nil {
+		envPropertiesReq := &pipeline.EnvironmentProperties{
+			EnvOverrideValues: plan.envProperties.EnvOverrideValues,
+			Status:            plan.envProperties.Status,
+			ManualReviewed:    plan.envProperties.ManualReviewed,
+			Active:            plan.envProperties.Active,
+			Namespace:         env.Namespace,
+			EnvironmentId:     env.Id,
+			EnvironmentName:   env.Environment,
+			UserId:            userId,
+			ChartRefId:        plan.envProperties.ChartRefId,
+			IsOverride:        true,
+			Comment:           fmt.Sprintf("cloned from environment %d", plan.envProperties.EnvironmentId),
+		}
+		envProperties, err := impl.propertiesConfigService.CreateEnvironmentProperties(appId, envPropertiesReq)
+		if err != nil {
+			return fmt.Errorf("error in cloning deployment template override: %s", err.Error())
+		}
+		rollback.add(func() error {
+			_, err := impl.propertiesConfigService.ResetEnvironmentProperties(envProperties.Id, userId)
+			return err
+		})
+	}
+	err := impl.cloneConfigData(appId, env.Id, plan.configMaps.ConfigData, userId, impl.configMapService.CMEnvironmentAddUpdate,
+		impl.configMapService.CMEnvironmentDelete, rollback)
+	if err != nil {
+		return fmt.Errorf("error in cloning config maps: %s", err.Error())
+	}
+	secrets, err := impl.secretsForClone(appId, plan.secrets)
+	if err != nil {
+		return fmt.Errorf("error in fetching secrets: %s", err.Error())
+	}
+	err = impl.cloneConfigData(appId, env.Id, secrets, userId, impl.configMapService.CSEnvironmentAddUpdate,
+		impl.configMapService.CSEnvironmentDelete, rollback)
+	if err != nil {
+		return fmt.Errorf("error in cloning secrets: %s", err.Error())
+	}
+	err = impl.cloneCdPipeline(ctx, plan, env, userId, rollback)
+	if err != nil {
+		return fmt.Errorf("error in cloning cd pipeline: %s", err.Error())
+	}
+	if plan.result.AppMetrics {
+		_, err = impl.propertiesConfigService.EnvMetricsEnableDisable(&pipeline.AppMetricEnableDisableRequest{
+			AppId:               appId,
+			EnvironmentId:       env.Id,
+			IsAppMetricsEnabled: true,
+			UserId:              userId,
+		})
+		if err != nil {
+			return fmt.Errorf("error in enabling app metrics: %s", err.Error())
+		}
+	}
+	return impl.clonePolicies(plan.policies, env.Id, userId, rollback)
+}
+
+func (impl *EnvironmentCloneServiceImpl) cloneCdPipeline(ctx context.Context, plan *appClonePlan, env *cluster.EnvironmentBean, userId int32,
+	rollback *cloneRollback) error {
+	appId := plan.result.AppId
+	refPipelines, err := impl.pipelineBuilder.GetCdPipelinesForApp(appId)
+	if err != nil {
+		return err
+	}
+	var refCdPipeline *bean.CDPipelineConfigObject
+	for _, refPipeline := range refPipelines.Pipelines {
+		if refPipeline.Id == plan.sourcePipelineId {
+			refCdPipeline = refPipeline
+			break
+		}
+	}
+	if refCdPipeline == nil {
+		return fmt.Errorf("no cd pipeline found")
+	}
+	cdPipeline := &bean.CDPipelineConfigObject{
+		EnvironmentId:                 env.Id,
+		CiPipelineId:                  refCdPipeline.CiPipelineId,
+		TriggerType:                   refCdPipeline.TriggerType,
+		Name:                          plan.result.CdPipeline,
+		Strategies:                    refCdPipeline.Strategies,
+		Namespace:                     env.Namespace,
+		DeploymentTemplate:            refCdPipeline.DeploymentTemplate,
+		PreStage:                      refCdPipeline.PreStage,
+		PostStage:                     refCdPipeline.PostStage,
+		PreStageConfigMapSecretNames:  refCdPipeline.PreStageConfigMapSecretNames,
+		PostStageConfigMapSecretNames: refCdPipeline.PostStageConfigMapSecretNames,
+		RunPostStageInEnv:             refCdPipeline.RunPostStageInEnv,
+		RunPreStageInEnv:              refCdPipeline.RunPreStageInEnv,
+	}
+	// the new pipeline is a sibling of the source one, it goes in the same workflow after the same parent
+	mappings, err := impl.appWorkflowRepository.FindWFCDMappingByCDPipelineId(plan.sourcePipelineId)
+	if err != nil && err != pg.ErrNoRows {
+		return err
+	}
+	if len(mappings) > 0 {
+		cdPipeline.AppWorkflowId = mappings[0].AppWorkflowId
+		if mappings[0].ParentType == appWorkflow2.CDPIPELINE {
+			cdPipeline.ParentPipelineId = mappings[0].ParentId
+			cdPipeline.ParentPipelineType = mappings[0].ParentType
+		}
+	}
+	created, err := impl.pipelineBuilder.CreateCdPipelines(&bean.CdPipelines{
+		Pipelines: []*bean.CDPipelineConfigObject{cdPipeline},
+		AppId:     appId,
+		UserId:    userId,
+	}, ctx)
+	if err != nil {
+		return err
+	}
+	for _, createdPipeline := range created.Pipelines {
+		pipelineId := createdPipeline.Id
+		rollback.add(func() error {
+			_, err := impl.pipelineBuilder.PatchCdPipelines(&bean.CDPatchRequest{
+				Pipeline: &bean.CDPipelineConfigObject{Id: pipelineId},
+				AppId:    appId,
+				Action:   bean.CD_DELETE,
+				UserId:   userId,
+			}, ctx)
+			return err
+		})
+	}
+	return nil
+}
+
+// secretsForClone reads the values of the secrets, the env fetch only returns their keys
+func (impl *EnvironmentCloneServiceImpl) secretsForClone(appId int, secrets *pipeline.ConfigDataRequest) ([]*pipeline.ConfigData, error) {
+	var result []*pipeline.ConfigData
+	for _, secret := range secrets.ConfigData {
+		secretForEdit, err := impl.configMapService.CSEnvironmentFetchForEdit(secret.Name, secrets.Id, appId, secrets.EnvironmentId)
+		if err != nil {
+			return nil, err
+		}
+		for _, configData := range secretForEdit.ConfigData {
+			configData.Global = secret.Global
+			result = append(result, configData)
+		}
+	}
+	return result, nil
+}
+
+func (impl *EnvironmentCloneServiceImpl) cloneConfigData(appId int, envId int, configData []*pipeline.ConfigData, userId int32,
+	addUpdate func(*pipeline.ConfigDataRequest) (*pipeline.ConfigDataRequest, error),
+	remove func(name string, id int, userId int32) (bool, error), rollback *cloneRollback) error {
+	id := 0
+	for _, item := range configData {
+		copied := *item
+		response, err := addUpdate(&pipeline.ConfigDataRequest{
+			Id:            id,
+			AppId:         appId,
+			EnvironmentId: envId,
+			ConfigData:    []*pipeline.ConfigData{&copied},
+			UserId:        userId,
+		})
+		if err != nil {
+			return err
+		}
+		id = response.Id
+		name, configId := copied.Name, id
+		rollback.add(func() error {
+			_, err := remove(name, configId, userId)
+			return err
+		})
+	}
+	return nil
+}
+
+func (impl *EnvironmentCloneServiceImpl) clonePolicies(policies []*security.CvePolicy, envId int, userId int32, rollback *cloneRollback) error {
+	for _, policy := range policies {
+		action := bean2.VulnerabilityAction(policy.Action.String())
+		request := bean2.CreateVulnerabilityPolicyRequest{
+			Action: &action,
+			AppId:  policy.AppId,
+			EnvId:  envId,
+			CveId:  policy.CVEStoreId,
+		}
+		if len(policy.CVEStoreId) == 0 && policy.Severity != nil {
+			request.Severity = policy.Severity.String()
+		}
+		res, err := impl.policyService.SavePolicy(request, userId)
+		if err != nil {
+			return err
+		}
+		policyId := res.Id
+		rollback.add(func() error {
+			_, err := impl.policyService.DeletePolicy(policyId, userId)
+			return err
+		})
+	}
+	return nil
+}
+
+// envLevelConfigData drops the items which are only inherited from the app level
+func envLevelConfigData(configData []*pipeline.ConfigData) []*pipeline.ConfigData {
+	var result []*pipeline.ConfigData
+	for _, item := range configData {
+		if !item.Global || item.Data != nil || item.ExternalSecret != nil {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// clonedPipelineName replaces the source environment in the pipeline name, or suffixes the new one when absent
+func clonedPipelineName(name string, sourceEnv string, environment string) string {
+	if strings.Contains(name, sourceEnv) {
+		return strings.Replace(name, sourceEnv, environment, 1)
+	}
+	return fmt.Sprintf("%s-%s", name, environment)
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package appClone
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	bean2 "github.com/devtron-labs/devtron/api/bean"
+	"github.com/devtron-labs/devtron/internal/sql/repository/security"
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/bean"
+	"github.com/devtron-labs/devtron/pkg/cluster"
+	"github.com/devtron-labs/devtron/pkg/pipeline"
+	security2 "github.com/devtron-labs/devtron/pkg/security"
+)
+
+// cloneCalls records the calls of the stubs below in order
+type cloneCalls []string
+
+func (c *cloneCalls) add(call string) {
+	*c = append(*c, call)
+}
+
+type propertiesConfigServiceStub struct {
+	pipeline.PropertiesConfigService
+	calls *cloneCalls
+}
+
+func (impl propertiesConfigServiceStub) CreateEnvironmentProperties(appId int, propertiesRequest *pipeline.EnvironmentProperties) (*pipeline.EnvironmentProperties, error) {
+	impl.calls.add("create override")
+	return &pipeline.EnvironmentProperties{Id: 41, EnvironmentId: propertiesRequest.EnvironmentId}, nil
+}
+
+func (impl propertiesConfigServiceStub) ResetEnvironmentProperties(id int, userId int32) (bool, error) {
+	impl.calls.add(fmt.Sprintf("reset override %d", id))
+	return true, nil
+}
+
+type configMapServiceStub struct {
+	pipeline.ConfigMapService
+	calls *cloneCalls
+}
+
+func (impl configMapServiceStub) CMEnvironmentAddUpdate(request *pipeline.ConfigDataRequest) (*pipeline.ConfigDataRequest, error) {
+	impl.calls.add("add cm " + request.ConfigData[0].Name)
+	return &pipeline.ConfigDataRequest{Id: 51}, nil
+}
+
+func (impl configMapServiceStub) CSEnvironmentAddUpdate(request *pipeline.ConfigDataRequest) (*pipeline.ConfigDataRequest, error) {
+	impl.calls.add("add cs " + request.ConfigData[0].Name)
+	return &pipeline.ConfigDataRequest{Id: 52}, nil
+}
+
+func (impl configMapServiceStub) CSEnvironmentFetchForEdit(name string, id int, appId int, envId int) (*pipeline.ConfigDataRequest, error) {
+	return &pipeline.ConfigDataRequest{ConfigData: []*pipeline.ConfigData{{Name: name, Data: json.RawMessage(`{"password":"c2VjcmV0"}`)}}}, nil
+}
+
+func (impl configMapServiceStub) CMEnvironmentDelete(name string, id int, userId int32) (bool, error) {
+	impl.calls.add(fmt.Sprintf("delete cm %s %d", name, id))
+	return true, nil
+}
+
+func (impl configMapServiceStub) CSEnvironmentDelete(name string, id int, userId int32) (bool, error) {
+	impl.calls.add(fmt.Sprintf("delete cs %s %d", name, id))
+	return true, nil
+}
+
+type pipelineBuilderStub struct {
+	pipeline.PipelineBuilder
+}
+
+func (impl pipelineBuilderStub) GetCdPipelinesForApp(appId int) (*bean.CdPipelines, error) {
+	return nil, errors.New("connection refused")
+}
+
+type policyServiceStub struct {
+	security2.PolicyService
+	calls *cloneCalls
+	fail  string
+}
+
+func (impl policyServiceStub) SavePolicy(request bean2.CreateVulnerabilityPolicyRequest, userId int32) (*bean2.IdVulnerabilityPolicyResult, error) {
+	if request.CveId == impl.fail {
+		return nil, errors.New("connection refused")
+	}
+	impl.calls.add("save policy " + request.CveId)
+	return &bean2.IdVulnerabilityPolicyResult{Id: len(*impl.calls)}, nil
+}
+
+func (impl policyServiceStub) DeletePolicy(id int, userId int32) (*bean2.IdVulnerabilityPolicyResult, error) {
+	impl.calls.add(fmt.Sprintf("delete policy %d", id))
+	return &bean2.IdVulnerabilityPolicyResult{Id: id}, nil
+}
+
+func TestClonedPipelineName(t *testing.T) {
+	tests := []struct {
+		name      string
+		sourceEnv string
+		want      string
+	}{
+		{name: "cd-qa", sourceEnv: "qa", want: "cd-qa-2"},
+		{name: "payments-qa-deploy", sourceEnv: "qa", want: "payments-qa-2-deploy"},
+		{name: "cd-pipeline", sourceEnv: "staging", want: "cd-pipeline-qa-2"},
+	}
+	for _, tt := range tests {
+		if got := clonedPipelineName(tt.name, tt.sourceEnv, "qa-2"); got != tt.want {
+			t.Errorf("clonedPipelineName(%s) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEnvLevelConfigData(t *testing.T) {
+	configData := []*pipeline.ConfigData{
+		{Name: "inherited", Global: true},
+		{Name: "overridden", Global: true, Data: json.RawMessage(`{"key":"value"}`)},
+		{Name: "env-only", Global: false},
+	}
+	result := envLevelConfigData(configData)
+	if len(result) != 2 || result[0].Name != "overridden" || result[1].Name != "env-only" {
+		t.Errorf("unexpected env level config data %v", result)
+	}
+}
+
+func TestCloneAppRollsBackOnFailure(t *testing.T) {
+	calls := &cloneCalls{}
+	impl := &EnvironmentCloneServiceImpl{
+		logger:                  util.NewSugardLogger(),
+		propertiesConfigService: propertiesConfigServiceStub{calls: calls},
+		configMapService:        configMapServiceStub{calls: calls},
+		pipelineBuilder:         pipelineBuilderStub{},
+	}
+	plan := &appClonePlan{
+		result:           &AppEnvironmentClone{AppId: 7, AppName: "payments", CdPipeline: "cd-qa-2"},
+		sourcePipelineId: 3,
+		envProperties:    &pipeline.EnvironmentProperties{EnvironmentId: 1, ChartRefId: 10},
+		configMaps:       &pipeline.ConfigDataRequest{ConfigData: []*pipeline.ConfigData{{Name: "app-config"}, {Name: "feature-flags"}}},
+		secrets:          &pipeline.ConfigDataRequest{Id: 5, EnvironmentId: 1, ConfigData: []*pipeline.ConfigData{{Name: "db-credentials"}}},
+	}
+	rollback := &cloneRollback{}
+	err := impl.cloneApp(context.Background(), plan, &cluster.EnvironmentBean{Id: 2, Environment: "qa-2", Namespace: "qa-2"}, 1, rollback)
+	if err == nil {
+		t.Fatal("expected the cd pipeline clone to fail")
+	}
+	impl.rollback(rollback, 7, 2)
+	want := cloneCalls{
+		"create override", "add cm app-config", "add cm feature-flags", "add cs db-credentials",
+		"delete cs db-credentials 52", "delete cm feature-flags 51", "delete cm app-config 51", "reset override 41",
+	}
+	if !reflect.DeepEqual(*calls, want) {
+		t.Errorf("calls = %v, want %v", *calls, want)
+	}
+}
+
+func TestClonePoliciesRollsBackOnFailure(t *testing.T) {
+	calls := &cloneCalls{}
+	impl := &EnvironmentCloneServiceImpl{
+		logger:        util.NewSugardLogger(),
+		policyService: policyServiceStub{calls: calls, fail: "CVE-2021-3"},
+	}
+	policies := []*security.CvePolicy{{CVEStoreId: "CVE-2021-1"}, {CVEStoreId: "CVE-2021-2"}, {CVEStoreId: "CVE-2021-3"}}
+	rollback := &cloneRollback{}
+	if err := impl.clonePolicies(policies, 2, 1, rollback); err == nil {
+		t.Fatal("expected the third policy to fail")
+	}
+	impl.rollback(rollback, 0, 2)
+	want := cloneCalls{"save policy CVE-2021-1", "save policy CVE-2021-2", "delete policy 2", "delete policy 1"}
+	if !reflect.DeepEqual(*calls, want) {
+		t.Errorf("calls = %v, want %v", *calls, want)
+	}
+}
@@ -422,6 +422,9 @@ func (impl PipelineRepositoryMock) GetByEnvOverrideId(envOverrideId int) ([]pipe
 func (impl PipelineRepositoryMock) FindActiveByAppIdAndEnvironmentId(appId int, environmentId int) (pipelines []*pipelineConfig.Pipeline, err error) {
 	panic("implement me")
 }
+func (impl PipelineRepositoryMock) FindActiveByEnvId(envId int) (pipelines []*pipelineConfig.Pipeline, err error) {
+	panic("implement me")
+}
 func (impl PipelineRepositoryMock) UndoDelete(id int) error {
 	panic("implement me")
 }
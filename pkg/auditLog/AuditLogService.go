@@ -0,0 +1,259 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package auditLog
+
+import (
+	"encoding/json"
+	"github.com/caarlos0/env"
+	"github.com/devtron-labs/devtron/pkg/auditLog/repository"
+	repository2 "github.com/devtron-labs/devtron/pkg/user/repository"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+const (
+	ResourcePipeline           = "pipeline"
+	ResourceDeploymentTemplate = "deployment-template"
+	ResourceConfigMap          = "config-map"
+	ResourceSecret             = "secret"
+	ResourceUser               = "user"
+	ResourceRoleGroup          = "role-group"
+	ResourceCustomRole         = "custom-role"
+	ResourceAccessRequest      = "access-request"
+	ResourceCluster            = "cluster"
+	ResourcePolicy             = "policy"
+	ResourceTerminalSession    = "terminal-session"
+	ResourceK8sResource        = "k8s-resource"
+)
+
+const (
+	ActionCreate = "CREATE"
+	ActionUpdate = "UPDATE"
+	ActionDelete = "DELETE"
+)
+
+const (
+	auditLogRetentionCronExpr = "@daily"
+	defaultAuditLogPageSize   = 20
+	maxAuditLogPageSize       = 100
+)
+
+type AuditLogConfig struct {
+	// RetentionDays is the number of days audit logs are kept for, 0 keeps them forever
+	RetentionDays int `env:"AUDIT_LOG_RETENTION_DAYS" envDefault:"90"`
+}
+
+// AuditEvent is a change made by a service, Before and After are marshalled to json unless they already are strings
+type AuditEvent struct {
+	UserId        int32
+	ResourceType  string
+	ResourceId    string
+	AppId         int
+	EnvironmentId int
+	Action        string
+	Before        interface{}
+	After         interface{}
+}
+
+type AuditLogDto struct {
+	Id            int64     `json:"id"`
+	RequestId     string    `json:"requestId,omitempty"`
+	UserId        int32     `json:"userId"`
+	EmailId       string    `json:"emailId"`
+	ApiToken      bool      `json:"apiToken"`
+	Source        string    `json:"source"`
+	ResourceType  string    `json:"resourceType"`
+	ResourceId    string    `json:"resourceId"`
+	AppId         int       `json:"appId,omitempty"`
+	EnvironmentId int       `json:"environmentId,omitempty"`
+	Action        string    `json:"action"`
+	Method        string    `json:"method,omitempty"`
+	Path          string    `json:"path,omitempty"`
+	StatusCode    int       `json:"statusCode,omitempty"`
+	Before        string    `json:"before,omitempty"`
+	After         string    `json:"after,omitempty"`
+	CreatedOn     time.Time `json:"createdOn"`
+}
+
+type AuditLogListResponse struct {
+	Total int            `json:"total"`
+	Logs  []*AuditLogDto `json:"logs"`
+}
+
+type AuditLogService interface {
+	SaveEvent(event *AuditEvent)
+	SaveApiLog(model *repository.AuditLog)
+	GetAuditLogs(filter *repository.AuditLogFilter) (*AuditLogListResponse, error)
+	GetAuditLogById(id int64) (*AuditLogDto, error)
+}
+
+type AuditLogServiceImpl struct {
+	logger             *zap.SugaredLogger
+	cron               *cron.Cron
+	config             *AuditLogConfig
+	auditLogRepository repository.AuditLogRepository
+	userRepository     repository2.UserRepository
+}
+
+func NewAuditLogServiceImpl(logger *zap.SugaredLogger, auditLogRepository repository.AuditLogRepository,
+	userRepository repository2.UserRepository) (*AuditLogServiceImpl, error) {
+	config := &AuditLogConfig{}
+	err := env.Parse(config)
+	if err != nil {
+		logger.Errorw("error in parsing audit log config", "err", err)
+		return nil, err
+	}
+	cron := cron.New(
+		cron.WithChain())
+	cron.Start()
+	impl := &AuditLogServiceImpl{
+		logger:             logger,
+		cron:               cron,
+		config:             config,
+		auditLogRepository: auditLogRepository,
+		userRepository:     userRepository,
+	}
+	if config.RetentionDays > 0 {
+		_, err = cron.AddFunc(auditLogRetentionCronExpr, impl.deleteExpiredLogs)
+		if err != nil {
+			logger.Errorw("error in starting audit log retention cron", "err", err)
+			return nil, err
+		}
+	}
+	return impl, nil
+}
+
+// SaveEvent never fails the change being audited, errors are only logged
+func (impl AuditLogServiceImpl) SaveEvent(event *AuditEvent) {
+	model := &repository.AuditLog{
+		UserId:        event.UserId,
+		Source:        repository.AuditLogSourceService,
+		ResourceType:  event.ResourceType,
+		ResourceId:    event.ResourceId,
+		AppId:         event.AppId,
+		EnvironmentId: event.EnvironmentId,
+		Action:        event.Action,
+		Before:        impl.toJson(event.Before),
+		After:         impl.toJson(event.After),
+		CreatedOn:     time.Now(),
+	}
+	impl.setUser(model)
+	err := impl.auditLogRepository.Save(model)
+	if err != nil {
+		impl.logger.Errorw("error in saving audit event", "resourceType", event.ResourceType, "resourceId", event.ResourceId, "err", err)
+	}
+}
+
+func (impl AuditLogServiceImpl) SaveApiLog(model *repository.AuditLog) {
+	model.Source = repository.AuditLogSourceApi
+	model.CreatedOn = time.Now()
+	impl.setUser(model)
+	err := impl.auditLogRepository.Save(model)
+	if err != nil {
+		impl.logger.Errorw("error in saving audit log", "requestId", model.RequestId, "path", model.Path, "err", err)
+	}
+}
+
+// setUser fills email of the acting user, users deleted since are resolved too so that their logs stay readable
+func (impl AuditLogServiceImpl) setUser(model *repository.AuditLog) {
+	if model.UserId <= 0 {
+		return
+	}
+	user, err := impl.userRepository.GetByIdIncludeDeleted(model.UserId)
+	if err != nil {
+		impl.logger.Warnw("error in fetching user of audit log", "userId", model.UserId, "err", err)
+		return
+	}
+	model.EmailId = user.EmailId
+	model.ApiToken = strings.HasPrefix(user.EmailId, repository2.ApiTokenUserPrefix)
+}
+
+func (impl AuditLogServiceImpl) toJson(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if str, ok := value.(string); ok {
+		return str
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		impl.logger.Warnw("error in marshalling audit event state", "err", err)
+		return ""
+	}
+	return string(data)
+}
+
+func (impl AuditLogServiceImpl) GetAuditLogs(filter *repository.AuditLogFilter) (*AuditLogListResponse, error) {
+	if filter.Size <= 0 {
+		filter.Size = defaultAuditLogPageSize
+	} else if filter.Size > maxAuditLogPageSize {
+		filter.Size = maxAuditLogPageSize
+	}
+	models, total, err := impl.auditLogRepository.FindByFilter(filter)
+	if err != nil {
+		impl.logger.Errorw("error in fetching audit logs", "filter", filter, "err", err)
+		return nil, err
+	}
+	response := &AuditLogListResponse{Total: total, Logs: make([]*AuditLogDto, 0)}
+	for _, model := range models {
+		response.Logs = append(response.Logs, adaptAuditLog(model))
+	}
+	return response, nil
+}
+
+func (impl AuditLogServiceImpl) GetAuditLogById(id int64) (*AuditLogDto, error) {
+	model, err := impl.auditLogRepository.FindById(id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching audit log", "id", id, "err", err)
+		return nil, err
+	}
+	return adaptAuditLog(model), nil
+}
+
+func (impl AuditLogServiceImpl) deleteExpiredLogs() {
+	deleted, err := impl.auditLogRepository.DeleteOlderThan(time.Now().AddDate(0, 0, -impl.config.RetentionDays))
+	if err != nil {
+		impl.logger.Errorw("error in deleting expired audit logs", "retentionDays", impl.config.RetentionDays, "err", err)
+		return
+	}
+	impl.logger.Infow("deleted expired audit logs", "count", deleted, "retentionDays", impl.config.RetentionDays)
+}
+
+func adaptAuditLog(model *repository.AuditLog) *AuditLogDto {
+	return &AuditLogDto{
+		Id:            model.Id,
+		RequestId:     model.RequestId,
+		UserId:        model.UserId,
+		EmailId:       model.EmailId,
+		ApiToken:      model.ApiToken,
+		Source:        model.Source,
+		ResourceType:  model.ResourceType,
+		ResourceId:    model.ResourceId,
+		AppId:         model.AppId,
+		EnvironmentId: model.EnvironmentId,
+		Action:        model.Action,
+		Method:        model.Method,
+		Path:          model.Path,
+		StatusCode:    model.StatusCode,
+		Before:        model.Before,
+		After:         model.After,
+		CreatedOn:     model.CreatedOn,
+	}
+}
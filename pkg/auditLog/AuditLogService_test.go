@@ -0,0 +1,67 @@
+package auditLog
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/auditLog/repository"
+	repository2 "github.com/devtron-labs/devtron/pkg/user/repository"
+)
+
+type auditLogRepositoryStub struct {
+	repository.AuditLogRepository
+	saved []*repository.AuditLog
+}
+
+func (stub *auditLogRepositoryStub) Save(model *repository.AuditLog) error {
+	stub.saved = append(stub.saved, model)
+	return nil
+}
+
+type userRepositoryStub struct {
+	repository2.UserRepository
+	users map[int32]string
+}
+
+func (stub userRepositoryStub) GetByIdIncludeDeleted(id int32) (*repository2.UserModel, error) {
+	emailId, ok := stub.users[id]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	return &repository2.UserModel{Id: id, EmailId: emailId}, nil
+}
+
+func TestSaveApiLogResolvesUser(t *testing.T) {
+	auditLogRepository := &auditLogRepositoryStub{}
+	impl := AuditLogServiceImpl{
+		logger:             util.NewSugardLogger(),
+		auditLogRepository: auditLogRepository,
+		userRepository: userRepositoryStub{users: map[int32]string{
+			2: "admin@example.com",
+			3: repository2.ApiTokenUserPrefix + "ci-bot",
+		}},
+	}
+	tests := []struct {
+		name         string
+		userId       int32
+		wantEmailId  string
+		wantApiToken bool
+	}{
+		{name: "user", userId: 2, wantEmailId: "admin@example.com"},
+		{name: "api token", userId: 3, wantEmailId: repository2.ApiTokenUserPrefix + "ci-bot", wantApiToken: true},
+		{name: "unknown user", userId: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			impl.SaveApiLog(&repository.AuditLog{UserId: tt.userId, Method: "POST"})
+			saved := auditLogRepository.saved[len(auditLogRepository.saved)-1]
+			if saved.EmailId != tt.wantEmailId || saved.ApiToken != tt.wantApiToken {
+				t.Errorf("SaveApiLog() saved emailId %q apiToken %v, want %q %v", saved.EmailId, saved.ApiToken, tt.wantEmailId, tt.wantApiToken)
+			}
+			if saved.Source != repository.AuditLogSourceApi || saved.CreatedOn.IsZero() {
+				t.Errorf("SaveApiLog() saved source %q createdOn %v", saved.Source, saved.CreatedOn)
+			}
+		})
+	}
+}
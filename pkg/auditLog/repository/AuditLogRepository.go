@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package repository
+
+import (
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	AuditLogSourceApi     = "API"
+	AuditLogSourceService = "SERVICE"
+)
+
+// AuditLog is an immutable record of a mutating api call (source API) or of a change made by a service
+// with its state before and after (source SERVICE)
+type AuditLog struct {
+	tableName     struct{}  `sql:"audit_log" pg:",discard_unknown_columns"`
+	Id            int64     `sql:"id,pk"`
+	RequestId     string    `sql:"request_id"`
+	UserId        int32     `sql:"user_id,notnull"`
+	EmailId       string    `sql:"email_id"`
+	ApiToken      bool      `sql:"api_token,notnull"`
+	Source        string    `sql:"source,notnull"`
+	ResourceType  string    `sql:"resource_type,notnull"`
+	ResourceId    string    `sql:"resource_id"`
+	AppId         int       `sql:"app_id,notnull"`
+	EnvironmentId int       `sql:"environment_id,notnull"`
+	Action        string    `sql:"action,notnull"`
+	Method        string    `sql:"method"`
+	Path          string    `sql:"path"`
+	StatusCode    int       `sql:"status_code,notnull"`
+	Before        string    `sql:"before"`
+	After         string    `sql:"after"`
+	CreatedOn     time.Time `sql:"created_on,notnull"`
+}
+
+type AuditLogFilter struct {
+	UserId       int32
+	AppId        int
+	ResourceType string
+	ResourceId   string
+	Action       string
+	From         time.Time
+	To           time.Time
+	Offset       int
+	Size         int
+}
+
+type AuditLogRepository interface {
+	Save(model *AuditLog) error
+	FindById(id int64) (*AuditLog, error)
+	FindByFilter(filter *AuditLogFilter) ([]*AuditLog, int, error)
+	DeleteOlderThan(createdOn time.Time) (int, error)
+}
+
+type AuditLogRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func NewAuditLogRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *AuditLogRepositoryImpl {
+	return &AuditLogRepositoryImpl{
+		dbConnection: dbConnection,
+		logger:       logger,
+	}
+}
+
+func (impl AuditLogRepositoryImpl) Save(model *AuditLog) error {
+	return impl.dbConnection.Insert(model)
+}
+
+func (impl AuditLogRepositoryImpl) FindById(id int64) (*AuditLog, error) {
+	model := &AuditLog{}
+	err := impl.dbConnection.Model(model).Where("id = ?", id).Select()
+	return model, err
+}
+
+// FindByFilter returns a page of logs latest first, without before and after, along with the total count
+func (impl AuditLogRepositoryImpl) FindByFilter(filter *AuditLogFilter) ([]*AuditLog, int, error) {
+	var models []*AuditLog
+	query := impl.dbConnection.Model(&models).
+		Column("id", "request_id", "user_id", "email_id", "api_token", "source", "resource_type", "resource_id",
+			"app_id", "environment_id", "action", "method", "path", "status_code", "created_on").
+		Apply(func(q *orm.Query) (*orm.Query, error) {
+			if filter.UserId > 0 {
+				q = q.Where("user_id = ?", filter.UserId)
+			}
+			if filter.AppId > 0 {
+				q = q.Where("app_id = ?", filter.AppId)
+			}
+			if len(filter.ResourceType) > 0 {
+				q = q.Where("resource_type = ?", filter.ResourceType)
+			}
+			if len(filter.ResourceId) > 0 {
+				q = q.Where("resource_id = ?", filter.ResourceId)
+			}
+			if len(filter.Action) > 0 {
+				q = q.Where("action = ?", filter.Action)
+			}
+			if !filter.From.IsZero() {
+				q = q.Where("created_on >= ?", filter.From)
+			}
+			if !filter.To.IsZero() {
+				q = q.Where("created_on <= ?", filter.To)
+			}
+			return q, nil
+		}).
+		Order("id DESC").
+		Offset(filter.Offset).
+		Limit(filter.Size)
+	count, err := query.SelectAndCount()
+	return models, count, err
+}
+
+func (impl AuditLogRepositoryImpl) DeleteOlderThan(createdOn time.Time) (int, error) {
+	res, err := impl.dbConnection.Model(&AuditLog{}).Where("created_on < ?", createdOn).Delete()
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
@@ -0,0 +1,356 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/caarlos0/env"
+	client "github.com/devtron-labs/devtron/client/events"
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/cluster/repository"
+	util2 "github.com/devtron-labs/devtron/util/event"
+	"github.com/go-pg/pg"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/robfig/cron/v3"
+	"github.com/satori/go.uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	ClusterHealthHealthy     = "HEALTHY"
+	ClusterHealthUnreachable = "UNREACHABLE"
+	// ClusterHealthUnknown is the status of a cluster which has not been probed yet
+	ClusterHealthUnknown = "UNKNOWN"
+
+	clusterHealthHistoryMaxSize = 500
+)
+
+type ClusterHealthConfig struct {
+	ProbeCronExpr       string `env:"CLUSTER_HEALTH_PROBE_INTERVAL" envDefault:"@every 5m"`
+	ProbeTimeoutSeconds int    `env:"CLUSTER_HEALTH_PROBE_TIMEOUT_SECONDS" envDefault:"10"`
+	RetentionDays       int    `env:"CLUSTER_HEALTH_RETENTION_DAYS" envDefault:"7"`
+	// ProbeLockSeconds is how long the replica running the periodic probe keeps it before another replica may take
+	// over, it has to be longer than the probe interval so that the running replica renews it in time
+	ProbeLockSeconds int `env:"CLUSTER_HEALTH_PROBE_LOCK_SECONDS" envDefault:"900"`
+	// TokenExpiryWarnDays is how long before the expiry of a cluster's bearer token a notification is raised
+	TokenExpiryWarnDays int `env:"CLUSTER_HEALTH_TOKEN_EXPIRY_WARN_DAYS" envDefault:"7"`
+}
+
+type ClusterHealthBean struct {
+	ClusterId      int        `json:"clusterId"`
+	ClusterName    string     `json:"clusterName"`
+	ServerUrl      string     `json:"serverUrl"`
+	Status         string     `json:"status"`
+	ServerVersion  string     `json:"serverVersion,omitempty"`
+	LatencyMs      int64      `json:"latencyMs"`
+	Error          string     `json:"error,omitempty"`
+	TokenExpiresOn *time.Time `json:"tokenExpiresOn,omitempty"`
+	CheckedOn      *time.Time `json:"checkedOn,omitempty"`
+}
+
+// ClusterHealthService periodically calls the discovery api of every active cluster and keeps the results as
+// status history. K8sVersion of a cluster is kept in sync with the version its api server reports
+type ClusterHealthService interface {
+	ProbeClusters()
+	ProbeCluster(clusterId int) (*ClusterHealthBean, error)
+	GetClustersHealth() ([]*ClusterHealthBean, error)
+	GetClusterHealthHistory(clusterId int, size int) ([]*ClusterHealthBean, error)
+}
+
+type ClusterHealthServiceImpl struct {
+	logger                  *zap.SugaredLogger
+	cron                    *cron.Cron
+	config                  *ClusterHealthConfig
+	lockHolder              string
+	clusterService          ClusterService
+	clusterRepository       repository.ClusterRepository
+	clusterHealthRepository repository.ClusterHealthRepository
+	K8sUtil                 *util.K8sUtil
+	eventFactory            client.EventFactory
+	eventClient             client.EventClient
+}
+
+func NewClusterHealthServiceImpl(logger *zap.SugaredLogger, clusterService ClusterService,
+	clusterRepository repository.ClusterRepository, clusterHealthRepository repository.ClusterHealthRepository,
+	K8sUtil *util.K8sUtil, eventFactory client.EventFactory, eventClient client.EventClient) (*ClusterHealthServiceImpl, error) {
+	config := &ClusterHealthConfig{}
+	err := env.Parse(config)
+	if err != nil {
+		logger.Errorw("error in parsing cluster health config", "err", err)
+		return nil, err
+	}
+	cron := cron.New(
+		cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)))
+	cron.Start()
+	hostname, _ := os.Hostname()
+	impl := &ClusterHealthServiceImpl{
+		logger:                  logger,
+		cron:                    cron,
+		config:                  config,
+		lockHolder:              fmt.Sprintf("%s-%s", hostname, uuid.NewV4().String()),
+		clusterService:          clusterService,
+		clusterRepository:       clusterRepository,
+		clusterHealthRepository: clusterHealthRepository,
+		K8sUtil:                 K8sUtil,
+		eventFactory:            eventFactory,
+		eventClient:             eventClient,
+	}
+	_, err = cron.AddFunc(config.ProbeCronExpr, impl.probeClustersIfLockHolder)
+	if err != nil {
+		logger.Errorw("error in starting cluster health cron", "err", err)
+		return nil, err
+	}
+	return impl, nil
+}
+
+// probeClustersIfLockHolder runs the periodic probe on one replica only, every replica runs the cron but only the one
+// holding the probe lock probes, so clusters are not probed and alerted on once per replica
+func (impl *ClusterHealthServiceImpl) probeClustersIfLockHolder() {
+	acquired, err := impl.clusterHealthRepository.AcquireProbeLock(impl.lockHolder, time.Duration(impl.config.ProbeLockSeconds)*time.Second)
+	if err != nil {
+		impl.logger.Errorw("error in acquiring cluster health probe lock", "err", err)
+		return
+	}
+	if !acquired {
+		impl.logger.Debugw("cluster health probe lock held by another replica, skipping probe")
+		return
+	}
+	impl.ProbeClusters()
+}
+
+func (impl *ClusterHealthServiceImpl) ProbeClusters() {
+	clusters, err := impl.clusterService.FindAllActive()
+	if err != nil {
+		impl.logger.Errorw("error in fetching clusters for health probe", "err", err)
+		return
+	}
+	var wg sync.WaitGroup
+	for i := range clusters {
+		wg.Add(1)
+		go func(cluster *ClusterBean) {
+			defer wg.Done()
+			_, err := impl.probe(cluster)
+			if err != nil {
+				impl.logger.Errorw("error in probing cluster", "clusterId", cluster.Id, "err", err)
+			}
+		}(&clusters[i])
+	}
+	wg.Wait()
+	retention := time.Duration(impl.config.RetentionDays) * 24 * time.Hour
+	err = impl.clusterHealthRepository.DeleteOlderThan(time.Now().Add(-retention))
+	if err != nil {
+		impl.logger.Errorw("error in pruning cluster health history", "err", err)
+	}
+}
+
+func (impl *ClusterHealthServiceImpl) ProbeCluster(clusterId int) (*ClusterHealthBean, error) {
+	cluster, err := impl.clusterService.FindById(clusterId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching cluster", "clusterId", clusterId, "err", err)
+		return nil, err
+	}
+	return impl.probe(cluster)
+}
+
+// probe records the reachability of a cluster and notifies when it turns unreachable or when its token enters
+// the warning window. Notifications are raised only on these transitions, not on every probe
+func (impl *ClusterHealthServiceImpl) probe(cluster *ClusterBean) (*ClusterHealthBean, error) {
+	previous, err := impl.clusterHealthRepository.FindLatestByClusterId(cluster.Id)
+	if err != nil && err != pg.ErrNoRows {
+		return nil, err
+	}
+	if err == pg.ErrNoRows {
+		previous = nil
+	}
+	check := &repository.ClusterHealthCheck{ClusterId: cluster.Id}
+	cfg, err := impl.clusterService.GetClusterConfig(cluster)
+	if err == nil {
+		cfg.Timeout = time.Duration(impl.config.ProbeTimeoutSeconds) * time.Second
+		check.TokenExpiresOn = tokenExpiry(cfg.BearerToken)
+		start := time.Now()
+		version, probeErr := impl.serverVersion(cfg)
+		check.LatencyMs = time.Since(start).Milliseconds()
+		err = probeErr
+		check.ServerVersion = version
+	}
+	check.CheckedOn = time.Now()
+	check.Reachable = err == nil
+	if err != nil {
+		check.Error = err.Error()
+	}
+	err = impl.clusterHealthRepository.Save(check)
+	if err != nil {
+		impl.logger.Errorw("error in saving cluster health check", "clusterId", cluster.Id, "err", err)
+		return nil, err
+	}
+	if check.Reachable && check.ServerVersion != cluster.K8sVersion {
+		impl.updateK8sVersion(cluster.Id, check.ServerVersion)
+	}
+	if !check.Reachable && (previous == nil || previous.Reachable) {
+		impl.notify(util2.ClusterUnreachable, cluster, check)
+	}
+	warnWindow := time.Duration(impl.config.TokenExpiryWarnDays) * 24 * time.Hour
+	if isTokenExpiring(check.TokenExpiresOn, check.CheckedOn, warnWindow) && (previous == nil ||
+		!previous.TokenExpiresOn.Equal(check.TokenExpiresOn) ||
+		!isTokenExpiring(previous.TokenExpiresOn, previous.CheckedOn, warnWindow)) {
+		impl.notify(util2.ClusterTokenExpiring, cluster, check)
+	}
+	return adaptClusterHealth(cluster, check), nil
+}
+
+func (impl *ClusterHealthServiceImpl) serverVersion(cfg *util.ClusterConfig) (string, error) {
+	client, err := impl.K8sUtil.GetK8sDiscoveryClient(cfg)
+	if err != nil {
+		return "", err
+	}
+	version, err := client.ServerVersion()
+	if err != nil {
+		return "", err
+	}
+	return version.String(), nil
+}
+
+func (impl *ClusterHealthServiceImpl) updateK8sVersion(clusterId int, version string) {
+	model, err := impl.clusterRepository.FindById(clusterId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching cluster", "clusterId", clusterId, "err", err)
+		return
+	}
+	model.K8sVersion = version
+	model.UpdatedOn = time.Now()
+	err = impl.clusterRepository.Update(model)
+	if err != nil {
+		impl.logger.Errorw("error in updating k8s version of cluster", "clusterId", clusterId, "err", err)
+	}
+}
+
+func (impl *ClusterHealthServiceImpl) notify(eventType util2.EventType, cluster *ClusterBean, check *repository.ClusterHealthCheck) {
+	event := impl.eventFactory.Build(eventType, nil, 0, nil, util2.Cluster)
+	event.Payload = &client.Payload{ClusterHealth: &client.ClusterHealthInfo{
+		ClusterId:   cluster.Id,
+		ClusterName: cluster.ClusterName,
+		ServerUrl:   cluster.ServerUrl,
+		Error:       check.Error,
+		CheckedOn:   check.CheckedOn.Format(time.RFC1123),
+	}}
+	if !check.TokenExpiresOn.IsZero() {
+		event.Payload.ClusterHealth.TokenExpiresOn = check.TokenExpiresOn.Format(time.RFC1123)
+	}
+	_, err := impl.eventClient.WriteEvent(event)
+	if err != nil {
+		impl.logger.Errorw("error in writing cluster health event", "clusterId", cluster.Id, "eventType", eventType, "err", err)
+	}
+}
+
+func (impl *ClusterHealthServiceImpl) GetClustersHealth() ([]*ClusterHealthBean, error) {
+	clusters, err := impl.clusterService.FindAllActive()
+	if err != nil {
+		impl.logger.Errorw("error in fetching clusters", "err", err)
+		return nil, err
+	}
+	checks, err := impl.clusterHealthRepository.FindLatestForAllClusters()
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching latest cluster health checks", "err", err)
+		return nil, err
+	}
+	latest := make(map[int]*repository.ClusterHealthCheck, len(checks))
+	for _, check := range checks {
+		latest[check.ClusterId] = check
+	}
+	result := make([]*ClusterHealthBean, 0, len(clusters))
+	for i := range clusters {
+		result = append(result, adaptClusterHealth(&clusters[i], latest[clusters[i].Id]))
+	}
+	return result, nil
+}
+
+func (impl *ClusterHealthServiceImpl) GetClusterHealthHistory(clusterId int, size int) ([]*ClusterHealthBean, error) {
+	cluster, err := impl.clusterService.FindById(clusterId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching cluster", "clusterId", clusterId, "err", err)
+		return nil, err
+	}
+	if size <= 0 || size > clusterHealthHistoryMaxSize {
+		size = clusterHealthHistoryMaxSize
+	}
+	checks, err := impl.clusterHealthRepository.FindByClusterId(clusterId, size)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching cluster health history", "clusterId", clusterId, "err", err)
+		return nil, err
+	}
+	result := make([]*ClusterHealthBean, 0, len(checks))
+	for _, check := range checks {
+		result = append(result, adaptClusterHealth(cluster, check))
+	}
+	return result, nil
+}
+
+func adaptClusterHealth(cluster *ClusterBean, check *repository.ClusterHealthCheck) *ClusterHealthBean {
+	bean := &ClusterHealthBean{
+		ClusterId:   cluster.Id,
+		ClusterName: cluster.ClusterName,
+		ServerUrl:   cluster.ServerUrl,
+		Status:      ClusterHealthUnknown,
+	}
+	if check == nil {
+		return bean
+	}
+	bean.Status = ClusterHealthUnreachable
+	if check.Reachable {
+		bean.Status = ClusterHealthHealthy
+	}
+	bean.ServerVersion = check.ServerVersion
+	bean.LatencyMs = check.LatencyMs
+	bean.Error = check.Error
+	checkedOn := check.CheckedOn
+	bean.CheckedOn = &checkedOn
+	if !check.TokenExpiresOn.IsZero() {
+		tokenExpiresOn := check.TokenExpiresOn
+		bean.TokenExpiresOn = &tokenExpiresOn
+	}
+	return bean
+}
+
+// tokenExpiry reads the exp claim of a bearer token without verifying it. Tokens which are not jwt or carry no
+// exp, like legacy service account tokens, never expire and get a zero time
+func tokenExpiry(token string) time.Time {
+	claims := jwt.MapClaims{}
+	_, _, err := new(jwt.Parser).ParseUnverified(token, claims)
+	if err != nil {
+		return time.Time{}
+	}
+	switch exp := claims["exp"].(type) {
+	case float64:
+		return time.Unix(int64(exp), 0)
+	case string:
+		seconds, err := strconv.ParseInt(exp, 10, 64)
+		if err == nil {
+			return time.Unix(seconds, 0)
+		}
+	}
+	return time.Time{}
+}
+
+func isTokenExpiring(expiresOn time.Time, at time.Time, warnWindow time.Duration) bool {
+	return !expiresOn.IsZero() && expiresOn.Sub(at) < warnWindow
+}
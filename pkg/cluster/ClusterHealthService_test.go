@@ -0,0 +1,91 @@
+package cluster
+
+import (
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/cluster/repository"
+	"github.com/golang-jwt/jwt/v4"
+	"testing"
+	"time"
+)
+
+func TestTokenExpiry(t *testing.T) {
+	expiresOn := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": expiresOn.Unix()}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tokenExpiry(token); !got.Equal(expiresOn) {
+		t.Errorf("tokenExpiry() = %v, want %v", got, expiresOn)
+	}
+	noExp, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "devtron"}).SignedString([]byte("secret"))
+	for _, token := range []string{noExp, "not-a-jwt", ""} {
+		if got := tokenExpiry(token); !got.IsZero() {
+			t.Errorf("tokenExpiry(%q) = %v, want zero", token, got)
+		}
+	}
+}
+
+func TestIsTokenExpiring(t *testing.T) {
+	now := time.Now()
+	window := 7 * 24 * time.Hour
+	tests := []struct {
+		expiresOn time.Time
+		want      bool
+	}{
+		{time.Time{}, false},
+		{now.Add(30 * 24 * time.Hour), false},
+		{now.Add(24 * time.Hour), true},
+		{now.Add(-time.Hour), true},
+	}
+	for _, tt := range tests {
+		if got := isTokenExpiring(tt.expiresOn, now, window); got != tt.want {
+			t.Errorf("isTokenExpiring(%v) = %v, want %v", tt.expiresOn, got, tt.want)
+		}
+	}
+}
+
+type probeLockRepositoryStub struct {
+	repository.ClusterHealthRepository
+	holder string
+}
+
+// AcquireProbeLock hands the lock to the first holder, it never expires in the test
+func (impl *probeLockRepositoryStub) AcquireProbeLock(holder string, ttl time.Duration) (bool, error) {
+	if impl.holder == "" {
+		impl.holder = holder
+	}
+	return impl.holder == holder, nil
+}
+
+func (impl *probeLockRepositoryStub) DeleteOlderThan(checkedOn time.Time) error {
+	return nil
+}
+
+type probeClusterServiceStub struct {
+	ClusterService
+	probes int
+}
+
+func (impl *probeClusterServiceStub) FindAllActive() ([]ClusterBean, error) {
+	impl.probes++
+	return nil, nil
+}
+
+func TestProbeClustersOnLockHolderOnly(t *testing.T) {
+	lockRepository := &probeLockRepositoryStub{}
+	config := &ClusterHealthConfig{ProbeLockSeconds: 900}
+	first := &ClusterHealthServiceImpl{logger: util.NewSugardLogger(), config: config, lockHolder: "replica-1",
+		clusterService: &probeClusterServiceStub{}, clusterHealthRepository: lockRepository}
+	second := &ClusterHealthServiceImpl{logger: util.NewSugardLogger(), config: config, lockHolder: "replica-2",
+		clusterService: &probeClusterServiceStub{}, clusterHealthRepository: lockRepository}
+	for i := 0; i < 2; i++ {
+		first.probeClustersIfLockHolder()
+		second.probeClustersIfLockHolder()
+	}
+	if probes := first.clusterService.(*probeClusterServiceStub).probes; probes != 2 {
+		t.Errorf("lock holder probed %d times, want 2", probes)
+	}
+	if probes := second.clusterService.(*probeClusterServiceStub).probes; probes != 0 {
+		t.Errorf("replica without the lock probed %d times, want 0", probes)
+	}
+}
@@ -22,11 +22,13 @@ import (
 	"fmt"
 	"github.com/devtron-labs/devtron/internal/constants"
 	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
 	"github.com/devtron-labs/devtron/pkg/cluster/repository"
 	"github.com/go-pg/pg"
 	"go.uber.org/zap"
 	"io/ioutil"
 	"os"
+	"strconv"
 	"time"
 )
 
@@ -80,24 +82,32 @@ type ClusterServiceImpl struct {
 	clusterRepository repository.ClusterRepository
 	logger            *zap.SugaredLogger
 	K8sUtil           *util.K8sUtil
+	auditLogService   auditLog.AuditLogService
 }
 
 func NewClusterServiceImpl(repository repository.ClusterRepository, logger *zap.SugaredLogger,
-	K8sUtil *util.K8sUtil) *ClusterServiceImpl {
+	K8sUtil *util.K8sUtil, auditLogService auditLog.AuditLogService) *ClusterServiceImpl {
 	return &ClusterServiceImpl{
 		clusterRepository: repository,
 		logger:            logger,
 		K8sUtil:           K8sUtil,
+		auditLogService:   auditLogService,
 	}
 }
 
 const ClusterName = "default_cluster"
 const TokenFilePath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
 
+// keys of the credentials kept in ClusterBean.Config, certificates and keys are PEM encoded
+const (
+	BearerTokenKey = util.BearerTokenKey
+	CertDataKey    = util.CertDataKey
+	KeyDataKey     = util.KeyDataKey
+	CADataKey      = util.CADataKey
+)
+
 func (impl *ClusterServiceImpl) GetClusterConfig(cluster *ClusterBean) (*util.ClusterConfig, error) {
-	host := cluster.ServerUrl
-	configMap := cluster.Config
-	bearerToken := configMap["bearer_token"]
+	clusterCfg := util.NewClusterConfig(cluster.ServerUrl, cluster.Config)
 	if cluster.Id == 1 && cluster.ClusterName == ClusterName {
 		if _, err := os.Stat(TokenFilePath); os.IsNotExist(err) {
 			impl.logger.Errorw("no directory or file exists", "TOKEN_FILE_PATH", TokenFilePath, "err", err)
@@ -108,10 +118,9 @@ func (impl *ClusterServiceImpl) GetClusterConfig(cluster *ClusterBean) (*util.Cl
 				impl.logger.Errorw("error on reading file", "err", err)
 				return nil, err
 			}
-			bearerToken = string(content)
+			clusterCfg.BearerToken = string(content)
 		}
 	}
-	clusterCfg := &util.ClusterConfig{Host: host, BearerToken: bearerToken}
 	return clusterCfg, nil
 }
 
@@ -170,6 +179,9 @@ func (impl *ClusterServiceImpl) Save(parent context.Context, bean *ClusterBean,
 		}
 	}
 	bean.Id = model.Id
+	if err == nil {
+		impl.saveAuditEvent(auditLog.ActionCreate, nil, model, userId)
+	}
 	return bean, err
 }
 
@@ -303,6 +315,7 @@ func (impl *ClusterServiceImpl) Update(ctx context.Context, bean *ClusterBean, u
 		impl.logger.Error(err)
 		return nil, err
 	}
+	before := auditClusterBean(model)
 
 	existingModel, err := impl.clusterRepository.FindOne(bean.ClusterName)
 	if err != nil && err != pg.ErrNoRows {
@@ -354,6 +367,7 @@ func (impl *ClusterServiceImpl) Update(ctx context.Context, bean *ClusterBean, u
 		}
 		return bean, err
 	}
+	impl.saveAuditEvent(auditLog.ActionUpdate, before, model, userId)
 
 	bean.Id = model.Id
 	return bean, err
@@ -364,7 +378,41 @@ func (impl *ClusterServiceImpl) Delete(bean *ClusterBean, userId int32) error {
 	if err != nil {
 		return err
 	}
-	return impl.clusterRepository.Delete(model)
+	err = impl.clusterRepository.Delete(model)
+	if err != nil {
+		return err
+	}
+	impl.saveAuditEvent(auditLog.ActionDelete, auditClusterBean(model), nil, userId)
+	return nil
+}
+
+// auditClusterBean leaves out cluster config and prometheus auth which hold credentials
+func auditClusterBean(model *repository.Cluster) *ClusterBean {
+	return &ClusterBean{
+		Id:            model.Id,
+		ClusterName:   model.ClusterName,
+		ServerUrl:     model.ServerUrl,
+		PrometheusUrl: model.PrometheusEndpoint,
+		Active:        model.Active,
+		K8sVersion:    model.K8sVersion,
+	}
+}
+
+func (impl *ClusterServiceImpl) saveAuditEvent(action string, before *ClusterBean, after *repository.Cluster, userId int32) {
+	event := &auditLog.AuditEvent{
+		UserId:       userId,
+		ResourceType: auditLog.ResourceCluster,
+		Action:       action,
+	}
+	if before != nil {
+		event.ResourceId = strconv.Itoa(before.Id)
+		event.Before = before
+	}
+	if after != nil {
+		event.ResourceId = strconv.Itoa(after.Id)
+		event.After = auditClusterBean(after)
+	}
+	impl.auditLogService.SaveEvent(event)
 }
 
 func (impl *ClusterServiceImpl) FindAllForAutoComplete() ([]ClusterBean, error) {
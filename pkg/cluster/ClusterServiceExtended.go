@@ -10,6 +10,7 @@ import (
 	"github.com/devtron-labs/devtron/internal/constants"
 	"github.com/devtron-labs/devtron/internal/sql/repository/appstore"
 	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
 	"github.com/devtron-labs/devtron/pkg/cluster/repository"
 	"go.uber.org/zap"
 	"net/http"
@@ -28,7 +29,7 @@ type ClusterServiceImplExtended struct {
 func NewClusterServiceImplExtended(repository repository.ClusterRepository, environmentRepository repository.EnvironmentRepository,
 	grafanaClient grafana.GrafanaClient, logger *zap.SugaredLogger, installedAppRepository appstore.InstalledAppRepository,
 	K8sUtil *util.K8sUtil,
-	clusterServiceCD cluster2.ServiceClient) *ClusterServiceImplExtended {
+	clusterServiceCD cluster2.ServiceClient, auditLogService auditLog.AuditLogService) *ClusterServiceImplExtended {
 	return &ClusterServiceImplExtended{
 		environmentRepository:  environmentRepository,
 		grafanaClient:          grafanaClient,
@@ -38,6 +39,7 @@ func NewClusterServiceImplExtended(repository repository.ClusterRepository, envi
 			clusterRepository: repository,
 			logger:            logger,
 			K8sUtil:           K8sUtil,
+			auditLogService:   auditLogService,
 		},
 	}
 }
@@ -181,20 +183,9 @@ func (impl *ClusterServiceImplExtended) Update(ctx context.Context, bean *Cluste
 			return nil, err
 		}
 	}
-	configMap := bean.Config
 	serverUrl := bean.ServerUrl
-	bearerToken := ""
-	if configMap["bearer_token"] != "" {
-		bearerToken = configMap["bearer_token"]
-	}
 
-	tlsConfig := v1alpha1.TLSClientConfig{
-		Insecure: true,
-	}
-	cdClusterConfig := v1alpha1.ClusterConfig{
-		BearerToken:     bearerToken,
-		TLSClientConfig: tlsConfig,
-	}
+	cdClusterConfig := argoClusterConfig(bean.Config)
 
 	cl := &v1alpha1.Cluster{
 		Name:   bean.ClusterName,
@@ -276,19 +267,8 @@ func (impl *ClusterServiceImplExtended) Save(ctx context.Context, bean *ClusterB
 	}
 
 	//create it into argo cd as well
-	configMap := bean.Config
 	serverUrl := bean.ServerUrl
-	bearerToken := ""
-	if configMap["bearer_token"] != "" {
-		bearerToken = configMap["bearer_token"]
-	}
-	tlsConfig := v1alpha1.TLSClientConfig{
-		Insecure: true,
-	}
-	cdClusterConfig := v1alpha1.ClusterConfig{
-		BearerToken:     bearerToken,
-		TLSClientConfig: tlsConfig,
-	}
+	cdClusterConfig := argoClusterConfig(bean.Config)
 
 	cl := &v1alpha1.Cluster{
 		Name:   bean.ClusterName,
@@ -320,3 +300,22 @@ func (impl *ClusterServiceImplExtended) Save(ctx context.Context, bean *ClusterB
 	}
 	return clusterBean, nil
 }
+
+// argoClusterConfig maps the credentials of a cluster to its argo cd config, TLS verification is skipped unless the CA
+// of the api server is known
+func argoClusterConfig(configMap map[string]string) v1alpha1.ClusterConfig {
+	tlsConfig := v1alpha1.TLSClientConfig{
+		Insecure: len(configMap[CADataKey]) == 0,
+	}
+	if len(configMap[CertDataKey]) > 0 {
+		tlsConfig.CertData = []byte(configMap[CertDataKey])
+		tlsConfig.KeyData = []byte(configMap[KeyDataKey])
+	}
+	if len(configMap[CADataKey]) > 0 {
+		tlsConfig.CAData = []byte(configMap[CADataKey])
+	}
+	return v1alpha1.ClusterConfig{
+		BearerToken:     configMap[BearerTokenKey],
+		TLSClientConfig: tlsConfig,
+	}
+}
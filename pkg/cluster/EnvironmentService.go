@@ -38,6 +38,8 @@ type EnvironmentBean struct {
 	PrometheusEndpoint string `json:"prometheus_endpoint,omitempty"`
 	Namespace          string `json:"namespace,omitempty" validate:"max=50"`
 	CdArgoSetup        bool   `json:"isClusterCdActive"`
+	// NamespaceConfig is reconciled on the namespace on create, update and every deployment, nil keeps the saved config
+	NamespaceConfig *NamespaceConfigBean `json:"namespaceConfig,omitempty"`
 }
 
 type EnvironmentService interface {
@@ -61,12 +63,14 @@ type EnvironmentServiceImpl struct {
 	clusterService          ClusterService
 	K8sUtil                 *util.K8sUtil
 	propertiesConfigService pipeline.PropertiesConfigService
+	namespaceConfigService  NamespaceConfigService
 }
 
 func NewEnvironmentServiceImpl(environmentRepository repository.EnvironmentRepository,
 	clusterService ClusterService, logger *zap.SugaredLogger,
 	K8sUtil *util.K8sUtil,
 	propertiesConfigService pipeline.PropertiesConfigService,
+	namespaceConfigService NamespaceConfigService,
 ) *EnvironmentServiceImpl {
 	return &EnvironmentServiceImpl{
 		environmentRepository:   environmentRepository,
@@ -74,6 +78,7 @@ func NewEnvironmentServiceImpl(environmentRepository repository.EnvironmentRepos
 		clusterService:          clusterService,
 		K8sUtil:                 K8sUtil,
 		propertiesConfigService: propertiesConfigService,
+		namespaceConfigService:  namespaceConfigService,
 	}
 }
 
@@ -87,6 +92,10 @@ func (impl EnvironmentServiceImpl) Create(mappings *EnvironmentBean, userId int3
 	if err != nil {
 		return nil, err
 	}
+	err = ValidateNamespaceConfig(mappings.NamespaceConfig)
+	if err != nil {
+		return nil, err
+	}
 
 	clusterBean, err := impl.clusterService.FindById(mappings.ClusterId)
 	if err != nil {
@@ -129,6 +138,13 @@ func (impl EnvironmentServiceImpl) Create(mappings *EnvironmentBean, userId int3
 		}
 
 	}
+	if mappings.NamespaceConfig != nil {
+		err = impl.namespaceConfigService.SaveConfig(model.Id, mappings.NamespaceConfig, userId)
+		if err != nil {
+			return mappings, err
+		}
+		impl.reconcileNamespace(model.Id)
+	}
 
 	//ignore grafana if no prometheus url found
 	if len(clusterBean.PrometheusUrl) > 0 {
@@ -216,6 +232,10 @@ func (impl EnvironmentServiceImpl) FindById(id int) (*EnvironmentBean, error) {
 		Namespace:          model.Namespace,
 		Default:            model.Default,
 	}
+	bean.NamespaceConfig, err = impl.namespaceConfigService.GetConfig(model.Id)
+	if err != nil {
+		return nil, err
+	}
 
 	/*clusterBean := &ClusterBean{
 		id:model.Cluster.id,
@@ -231,6 +251,10 @@ func (impl EnvironmentServiceImpl) Update(mappings *EnvironmentBean, userId int3
 		impl.logger.Errorw("error in finding environment for update", "err", err)
 		return mappings, err
 	}
+	err = ValidateNamespaceConfig(mappings.NamespaceConfig)
+	if err != nil {
+		return mappings, err
+	}
 	isNamespaceChange := false
 	if model.Namespace != mappings.Namespace {
 		isNamespaceChange = true
@@ -290,6 +314,13 @@ func (impl EnvironmentServiceImpl) Update(mappings *EnvironmentBean, userId int3
 		impl.logger.Errorw("error in updating environment", "err", err)
 		return mappings, err
 	}
+	if mappings.NamespaceConfig != nil {
+		err = impl.namespaceConfigService.SaveConfig(model.Id, mappings.NamespaceConfig, userId)
+		if err != nil {
+			return mappings, err
+		}
+	}
+	impl.reconcileNamespace(model.Id)
 
 	mappings.Id = model.Id
 	return mappings, nil
@@ -329,6 +360,13 @@ func (impl EnvironmentServiceImpl) GetEnvironmentListForAutocomplete() ([]Enviro
 	return beans, nil
 }
 
+// reconcileNamespace only logs failures, the config is saved and is applied again on the next deployment
+func (impl EnvironmentServiceImpl) reconcileNamespace(environmentId int) {
+	if err := impl.namespaceConfigService.ReconcileNamespace(environmentId); err != nil {
+		impl.logger.Errorw("error in reconciling namespace of environment", "environmentId", environmentId, "err", err)
+	}
+}
+
 func (impl EnvironmentServiceImpl) validateNamespaces(namespace string, envs []*repository.Environment) error {
 	if len(envs) >= 1 {
 		if namespace == "" {
@@ -0,0 +1,326 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cluster
+
+import (
+	"fmt"
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"net/http"
+	"strings"
+)
+
+const redactedSecretValue = "******"
+
+type K8sApiResource struct {
+	Group      string   `json:"group"`
+	Version    string   `json:"version"`
+	Kind       string   `json:"kind"`
+	Resource   string   `json:"resource"`
+	Namespaced bool     `json:"namespaced"`
+	Verbs      []string `json:"verbs"`
+}
+
+// K8sResourceIdentifier addresses objects of any api resource, Group is empty for the core group and Namespace
+// is empty for cluster scoped resources
+type K8sResourceIdentifier struct {
+	Group     string `json:"group"`
+	Version   string `json:"version"`
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+type K8sResourceListRequest struct {
+	K8sResourceIdentifier
+	LabelSelector string `json:"labelSelector,omitempty"`
+	FieldSelector string `json:"fieldSelector,omitempty"`
+	Limit         int64  `json:"limit,omitempty"`
+	Continue      string `json:"continue,omitempty"`
+}
+
+// K8sResourceService browses objects of a cluster through discovery and the dynamic client, so it works for
+// crds and workloads not deployed by devtron. Secret data is masked unless redactSecrets is false.
+type K8sResourceService interface {
+	GetApiResources(clusterId int) ([]*K8sApiResource, error)
+	ListResources(clusterId int, request *K8sResourceListRequest, redactSecrets bool) (*unstructured.UnstructuredList, error)
+	GetResource(clusterId int, identifier *K8sResourceIdentifier, redactSecrets bool) (*unstructured.Unstructured, error)
+	GetResourceEvents(clusterId int, identifier *K8sResourceIdentifier) (*v1.EventList, error)
+	UpdateResource(clusterId int, identifier *K8sResourceIdentifier, manifest []byte, userId int32) (*unstructured.Unstructured, error)
+	DeleteResource(clusterId int, identifier *K8sResourceIdentifier, userId int32) error
+}
+
+type K8sResourceServiceImpl struct {
+	logger          *zap.SugaredLogger
+	clusterService  ClusterService
+	k8sUtil         *util.K8sUtil
+	auditLogService auditLog.AuditLogService
+}
+
+func NewK8sResourceServiceImpl(logger *zap.SugaredLogger, clusterService ClusterService, k8sUtil *util.K8sUtil,
+	auditLogService auditLog.AuditLogService) *K8sResourceServiceImpl {
+	return &K8sResourceServiceImpl{
+		logger:          logger,
+		clusterService:  clusterService,
+		k8sUtil:         k8sUtil,
+		auditLogService: auditLogService,
+	}
+}
+
+func (impl K8sResourceServiceImpl) GetApiResources(clusterId int) ([]*K8sApiResource, error) {
+	clusterConfig, err := impl.getClusterConfig(clusterId)
+	if err != nil {
+		return nil, err
+	}
+	client, err := impl.k8sUtil.GetK8sDiscoveryClient(clusterConfig)
+	if err != nil {
+		return nil, err
+	}
+	resourceLists, err := client.ServerPreferredResources()
+	if err != nil {
+		// groups of unavailable aggregated apis fail discovery, the rest are still listed
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			impl.logger.Errorw("error in discovering api resources", "clusterId", clusterId, "err", err)
+			return nil, k8sApiError(err)
+		}
+		impl.logger.Warnw("api resource discovery failed for some groups", "clusterId", clusterId, "err", err)
+	}
+	var resources []*K8sApiResource
+	for _, resourceList := range resourceLists {
+		groupVersion, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, apiResource := range resourceList.APIResources {
+			// subresources like pods/log are not objects of their own
+			if strings.Contains(apiResource.Name, "/") {
+				continue
+			}
+			resources = append(resources, &K8sApiResource{
+				Group:      groupVersion.Group,
+				Version:    groupVersion.Version,
+				Kind:       apiResource.Kind,
+				Resource:   apiResource.Name,
+				Namespaced: apiResource.Namespaced,
+				Verbs:      apiResource.Verbs,
+			})
+		}
+	}
+	return resources, nil
+}
+
+func (impl K8sResourceServiceImpl) ListResources(clusterId int, request *K8sResourceListRequest, redactSecrets bool) (*unstructured.UnstructuredList, error) {
+	resourceClient, err := impl.getResourceClient(clusterId, &request.K8sResourceIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	list, err := resourceClient.List(metav1.ListOptions{
+		LabelSelector: request.LabelSelector,
+		FieldSelector: request.FieldSelector,
+		Limit:         request.Limit,
+		Continue:      request.Continue,
+	})
+	if err != nil {
+		impl.logger.Errorw("error in listing resources", "clusterId", clusterId, "request", request, "err", err)
+		return nil, k8sApiError(err)
+	}
+	if redactSecrets && isSecretResource(&request.K8sResourceIdentifier) {
+		for i := range list.Items {
+			redactSecretData(&list.Items[i])
+		}
+	}
+	return list, nil
+}
+
+func (impl K8sResourceServiceImpl) GetResource(clusterId int, identifier *K8sResourceIdentifier, redactSecrets bool) (*unstructured.Unstructured, error) {
+	resourceClient, err := impl.getResourceClient(clusterId, identifier)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := resourceClient.Get(identifier.Name, metav1.GetOptions{})
+	if err != nil {
+		impl.logger.Errorw("error in getting resource", "clusterId", clusterId, "identifier", identifier, "err", err)
+		return nil, k8sApiError(err)
+	}
+	if redactSecrets && isSecretResource(identifier) {
+		redactSecretData(obj)
+	}
+	return obj, nil
+}
+
+func (impl K8sResourceServiceImpl) GetResourceEvents(clusterId int, identifier *K8sResourceIdentifier) (*v1.EventList, error) {
+	obj, err := impl.GetResource(clusterId, identifier, true)
+	if err != nil {
+		return nil, err
+	}
+	clusterConfig, err := impl.getClusterConfig(clusterId)
+	if err != nil {
+		return nil, err
+	}
+	client, err := impl.k8sUtil.GetClient(clusterConfig)
+	if err != nil {
+		return nil, err
+	}
+	selector := fields.Set{"involvedObject.name": obj.GetName(), "involvedObject.uid": string(obj.GetUID())}
+	events, err := client.Events(identifier.Namespace).List(metav1.ListOptions{FieldSelector: selector.AsSelector().String()})
+	if err != nil {
+		impl.logger.Errorw("error in listing events", "clusterId", clusterId, "identifier", identifier, "err", err)
+		return nil, k8sApiError(err)
+	}
+	return events, nil
+}
+
+func (impl K8sResourceServiceImpl) UpdateResource(clusterId int, identifier *K8sResourceIdentifier, manifest []byte, userId int32) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	err := obj.UnmarshalJSON(manifest)
+	if err != nil {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: fmt.Sprintf("invalid manifest: %v", err)}
+	}
+	if obj.GetName() != identifier.Name || obj.GetNamespace() != identifier.Namespace {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "name and namespace of the manifest must match the resource"}
+	}
+	resourceClient, err := impl.getResourceClient(clusterId, identifier)
+	if err != nil {
+		return nil, err
+	}
+	before, err := resourceClient.Get(identifier.Name, metav1.GetOptions{})
+	if err != nil {
+		impl.logger.Errorw("error in getting resource", "clusterId", clusterId, "identifier", identifier, "err", err)
+		return nil, k8sApiError(err)
+	}
+	updated, err := resourceClient.Update(obj, metav1.UpdateOptions{})
+	if err != nil {
+		impl.logger.Errorw("error in updating resource", "clusterId", clusterId, "identifier", identifier, "err", err)
+		return nil, k8sApiError(err)
+	}
+	after := updated.DeepCopy()
+	if isSecretResource(identifier) {
+		redactSecretData(before)
+		redactSecretData(after)
+		redactSecretData(updated)
+	}
+	impl.saveAuditEvent(clusterId, identifier, auditLog.ActionUpdate, before.Object, after.Object, userId)
+	return updated, nil
+}
+
+func (impl K8sResourceServiceImpl) DeleteResource(clusterId int, identifier *K8sResourceIdentifier, userId int32) error {
+	resourceClient, err := impl.getResourceClient(clusterId, identifier)
+	if err != nil {
+		return err
+	}
+	before, err := resourceClient.Get(identifier.Name, metav1.GetOptions{})
+	if err != nil {
+		impl.logger.Errorw("error in getting resource", "clusterId", clusterId, "identifier", identifier, "err", err)
+		return k8sApiError(err)
+	}
+	propagation := metav1.DeletePropagationBackground
+	err = resourceClient.Delete(identifier.Name, &metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil {
+		impl.logger.Errorw("error in deleting resource", "clusterId", clusterId, "identifier", identifier, "err", err)
+		return k8sApiError(err)
+	}
+	if isSecretResource(identifier) {
+		redactSecretData(before)
+	}
+	impl.saveAuditEvent(clusterId, identifier, auditLog.ActionDelete, before.Object, nil, userId)
+	return nil
+}
+
+func (impl K8sResourceServiceImpl) getClusterConfig(clusterId int) (*util.ClusterConfig, error) {
+	cluster, err := impl.clusterService.FindById(clusterId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching cluster", "clusterId", clusterId, "err", err)
+		return nil, err
+	}
+	return impl.clusterService.GetClusterConfig(cluster)
+}
+
+func (impl K8sResourceServiceImpl) getResourceClient(clusterId int, identifier *K8sResourceIdentifier) (dynamic.ResourceInterface, error) {
+	if identifier.Version == "" || identifier.Resource == "" {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "version and resource are required"}
+	}
+	clusterConfig, err := impl.getClusterConfig(clusterId)
+	if err != nil {
+		return nil, err
+	}
+	client, err := impl.k8sUtil.GetDynamicClient(clusterConfig)
+	if err != nil {
+		return nil, err
+	}
+	resourceClient := client.Resource(schema.GroupVersionResource{Group: identifier.Group, Version: identifier.Version, Resource: identifier.Resource})
+	if identifier.Namespace != "" {
+		return resourceClient.Namespace(identifier.Namespace), nil
+	}
+	return resourceClient, nil
+}
+
+func (impl K8sResourceServiceImpl) saveAuditEvent(clusterId int, identifier *K8sResourceIdentifier, action string, before interface{}, after interface{}, userId int32) {
+	impl.auditLogService.SaveEvent(&auditLog.AuditEvent{
+		UserId:       userId,
+		ResourceType: auditLog.ResourceK8sResource,
+		ResourceId:   fmt.Sprintf("%d/%s/%s/%s", clusterId, identifier.Namespace, identifier.Resource, identifier.Name),
+		Action:       action,
+		Before:       before,
+		After:        after,
+	})
+}
+
+func isSecretResource(identifier *K8sResourceIdentifier) bool {
+	return identifier.Group == "" && identifier.Resource == "secrets"
+}
+
+// redactSecretData masks the values of a secret, keys are kept so that the secret can still be inspected
+func redactSecretData(obj *unstructured.Unstructured) {
+	for _, field := range []string{"data", "stringData"} {
+		data, found, err := unstructured.NestedMap(obj.Object, field)
+		if err != nil || !found {
+			continue
+		}
+		for key := range data {
+			data[key] = redactedSecretValue
+		}
+		_ = unstructured.SetNestedMap(obj.Object, data, field)
+	}
+	// the last applied configuration annotation holds the secret in plain text
+	annotations := obj.GetAnnotations()
+	if _, ok := annotations[v1.LastAppliedConfigAnnotation]; ok {
+		annotations[v1.LastAppliedConfigAnnotation] = redactedSecretValue
+		obj.SetAnnotations(annotations)
+	}
+}
+
+// k8sApiError keeps the status code of errors returned by the cluster
+func k8sApiError(err error) error {
+	if statusError, ok := err.(*errors.StatusError); ok && statusError.Status().Code > 0 {
+		return &util.ApiError{
+			HttpStatusCode:  int(statusError.Status().Code),
+			UserMessage:     statusError.Status().Message,
+			InternalMessage: err.Error(),
+		}
+	}
+	return err
+}
@@ -0,0 +1,42 @@
+package cluster
+
+import (
+	"github.com/devtron-labs/devtron/internal/util"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"net/http"
+	"testing"
+)
+
+func TestRedactSecretData(t *testing.T) {
+	secret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name": "db",
+			"annotations": map[string]interface{}{
+				"kubectl.kubernetes.io/last-applied-configuration": `{"data":{"password":"c2VjcmV0"}}`,
+				"owner": "payments",
+			},
+		},
+		"data": map[string]interface{}{"password": "c2VjcmV0", "user": "YWRtaW4="},
+	}}
+	redactSecretData(secret)
+	data, _, _ := unstructured.NestedStringMap(secret.Object, "data")
+	if len(data) != 2 || data["password"] != redactedSecretValue || data["user"] != redactedSecretValue {
+		t.Errorf("data not redacted: %v", data)
+	}
+	annotations := secret.GetAnnotations()
+	if annotations["kubectl.kubernetes.io/last-applied-configuration"] != redactedSecretValue || annotations["owner"] != "payments" {
+		t.Errorf("unexpected annotations: %v", annotations)
+	}
+}
+
+func TestK8sApiError(t *testing.T) {
+	err := k8sApiError(errors.NewNotFound(schema.GroupResource{Resource: "widgets"}, "foo"))
+	apiError, ok := err.(*util.ApiError)
+	if !ok || apiError.HttpStatusCode != http.StatusNotFound {
+		t.Errorf("k8sApiError() = %v, want a 404 api error", err)
+	}
+}
@@ -0,0 +1,394 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/caarlos0/env"
+	"github.com/devtron-labs/devtron/internal/util"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// auth types of kubeconfig users, only token and client certificate can be imported as the others need plugins
+// or files that are not available to the orchestrator
+const (
+	KubeconfigAuthToken             = "token"
+	KubeconfigAuthClientCertificate = "client-certificate"
+	KubeconfigAuthBasic             = "basic"
+	KubeconfigAuthExec              = "exec"
+	KubeconfigAuthProvider          = "auth-provider"
+	KubeconfigAuthNone              = "none"
+)
+
+const clusterAdminRole = "cluster-admin"
+
+type KubeconfigImportConfig struct {
+	// ServiceAccountNamespace and ServiceAccountName are of the cluster admin service account created on import
+	ServiceAccountNamespace string `env:"KUBECONFIG_IMPORT_SA_NAMESPACE" envDefault:"devtroncd"`
+	ServiceAccountName      string `env:"KUBECONFIG_IMPORT_SA_NAME" envDefault:"cd-user"`
+	TokenTimeoutSeconds     int    `env:"KUBECONFIG_IMPORT_SA_TOKEN_TIMEOUT_SECONDS" envDefault:"30"`
+	ConnectTimeoutSeconds   int    `env:"KUBECONFIG_IMPORT_CONNECT_TIMEOUT_SECONDS" envDefault:"10"`
+}
+
+type KubeconfigRequest struct {
+	Kubeconfig string `json:"kubeconfig" validate:"required"`
+}
+
+type KubeconfigContext struct {
+	ContextName string `json:"contextName"`
+	ClusterName string `json:"clusterName"`
+	ServerUrl   string `json:"serverUrl"`
+	UserName    string `json:"userName"`
+	Namespace   string `json:"namespace,omitempty"`
+	AuthType    string `json:"authType"`
+	Current     bool   `json:"current"`
+	Importable  bool   `json:"importable"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+type KubeconfigImportRequest struct {
+	Kubeconfig string                     `json:"kubeconfig" validate:"required"`
+	Contexts   []*KubeconfigContextImport `json:"contexts" validate:"required,min=1,dive"`
+}
+
+type KubeconfigContextImport struct {
+	ContextName string `json:"contextName" validate:"required"`
+	// ClusterName is the name of the cluster in devtron, the context name is used when empty
+	ClusterName string `json:"clusterName"`
+	// CreateServiceAccount replaces the credentials of the context by the token of a cluster admin service account
+	CreateServiceAccount bool `json:"createServiceAccount"`
+}
+
+type KubeconfigImportResult struct {
+	ContextName string `json:"contextName"`
+	ClusterName string `json:"clusterName"`
+	ClusterId   int    `json:"clusterId,omitempty"`
+	Imported    bool   `json:"imported"`
+	Error       string `json:"error,omitempty"`
+}
+
+// KubeconfigImportService registers the contexts of a kubeconfig as clusters. Each context is imported on its own,
+// a failing one does not stop the others
+type KubeconfigImportService interface {
+	GetContexts(kubeconfig string) ([]*KubeconfigContext, error)
+	ImportContexts(ctx context.Context, request *KubeconfigImportRequest, userId int32) ([]*KubeconfigImportResult, error)
+}
+
+type KubeconfigImportServiceImpl struct {
+	logger         *zap.SugaredLogger
+	config         *KubeconfigImportConfig
+	clusterService ClusterService
+	K8sUtil        *util.K8sUtil
+}
+
+func NewKubeconfigImportServiceImpl(logger *zap.SugaredLogger, clusterService ClusterService, K8sUtil *util.K8sUtil) (*KubeconfigImportServiceImpl, error) {
+	config := &KubeconfigImportConfig{}
+	err := env.Parse(config)
+	if err != nil {
+		logger.Errorw("error in parsing kubeconfig import config", "err", err)
+		return nil, err
+	}
+	return &KubeconfigImportServiceImpl{
+		logger:         logger,
+		config:         config,
+		clusterService: clusterService,
+		K8sUtil:        K8sUtil,
+	}, nil
+}
+
+func (impl *KubeconfigImportServiceImpl) GetContexts(kubeconfig string) ([]*KubeconfigContext, error) {
+	config, err := loadKubeconfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	return kubeconfigContexts(config), nil
+}
+
+func (impl *KubeconfigImportServiceImpl) ImportContexts(ctx context.Context, request *KubeconfigImportRequest, userId int32) ([]*KubeconfigImportResult, error) {
+	config, err := loadKubeconfig(request.Kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*KubeconfigImportResult, 0, len(request.Contexts))
+	for _, contextImport := range request.Contexts {
+		result := &KubeconfigImportResult{ContextName: contextImport.ContextName, ClusterName: contextImport.ClusterName}
+		if len(result.ClusterName) == 0 {
+			result.ClusterName = contextImport.ContextName
+		}
+		clusterId, err := impl.importContext(ctx, config, contextImport, result.ClusterName, userId)
+		if err != nil {
+			impl.logger.Errorw("error in importing kubeconfig context", "context", contextImport.ContextName, "err", err)
+			result.Error = err.Error()
+			if apiErr, ok := err.(*util.ApiError); ok {
+				if userMessage, ok := apiErr.UserMessage.(string); ok && len(userMessage) > 0 {
+					result.Error = userMessage
+				}
+			}
+		} else {
+			result.ClusterId = clusterId
+			result.Imported = true
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (impl *KubeconfigImportServiceImpl) importContext(ctx context.Context, config *clientcmdapi.Config, contextImport *KubeconfigContextImport,
+	clusterName string, userId int32) (int, error) {
+	clusterConfig, _, err := contextClusterConfig(config, contextImport.ContextName)
+	if err != nil {
+		return 0, err
+	}
+	clusterConfig.Timeout = time.Duration(impl.config.ConnectTimeoutSeconds) * time.Second
+	client, err := impl.K8sUtil.GetK8sDiscoveryClient(clusterConfig)
+	if err != nil {
+		return 0, err
+	}
+	_, err = client.ServerVersion()
+	if err != nil {
+		return 0, fmt.Errorf("cluster is not reachable: %s", err.Error())
+	}
+	if contextImport.CreateServiceAccount {
+		token, err := impl.createServiceAccountToken(clusterConfig)
+		if err != nil {
+			return 0, fmt.Errorf("error in creating service account: %s", err.Error())
+		}
+		clusterConfig.BearerToken = token
+		clusterConfig.CertData = ""
+		clusterConfig.KeyData = ""
+	}
+	bean := &ClusterBean{
+		ClusterName: clusterName,
+		ServerUrl:   clusterConfig.Host,
+		Active:      true,
+		Config:      clusterConfigMap(clusterConfig),
+	}
+	bean, err = impl.clusterService.Save(ctx, bean, userId)
+	if err != nil {
+		return 0, err
+	}
+	return bean.Id, nil
+}
+
+// createServiceAccountToken binds a service account to cluster-admin and returns its token. The token is read from
+// a service account token secret so that it does not expire like the projected ones
+func (impl *KubeconfigImportServiceImpl) createServiceAccountToken(clusterConfig *util.ClusterConfig) (string, error) {
+	client, err := impl.K8sUtil.GetClientSet(clusterConfig)
+	if err != nil {
+		return "", err
+	}
+	namespace := impl.config.ServiceAccountNamespace
+	name := impl.config.ServiceAccountName
+	_, err = client.CoreV1().Namespaces().Create(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return "", err
+	}
+	serviceAccount := &v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	_, err = client.CoreV1().ServiceAccounts(namespace).Create(serviceAccount)
+	if errors.IsAlreadyExists(err) {
+		impl.logger.Infow("service account of cluster import already exists, verifying its binding", "namespace", namespace, "name", name)
+	} else if err != nil {
+		return "", err
+	}
+	// existing bindings and secrets of the same name are only used when they are what would have been created
+	binding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: clusterAdminRole},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: name, Namespace: namespace}},
+	}
+	_, err = client.RbacV1().ClusterRoleBindings().Create(binding)
+	if errors.IsAlreadyExists(err) {
+		binding, err = client.RbacV1().ClusterRoleBindings().Get(name, metav1.GetOptions{})
+		if err == nil {
+			err = verifyClusterAdminBinding(binding, name, namespace)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	secretName := name + "-token"
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        secretName,
+			Namespace:   namespace,
+			Annotations: map[string]string{v1.ServiceAccountNameKey: name},
+		},
+		Type: v1.SecretTypeServiceAccountToken,
+	}
+	_, err = client.CoreV1().Secrets(namespace).Create(secret)
+	if errors.IsAlreadyExists(err) {
+		secret, err = client.CoreV1().Secrets(namespace).Get(secretName, metav1.GetOptions{})
+		if err == nil {
+			err = verifyServiceAccountTokenSecret(secret, name)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	// the token controller fills the secret asynchronously
+	var token string
+	timeout := time.Duration(impl.config.TokenTimeoutSeconds) * time.Second
+	err = wait.PollImmediate(time.Second, timeout, func() (bool, error) {
+		secret, err := client.CoreV1().Secrets(namespace).Get(secretName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		token = string(secret.Data[v1.ServiceAccountTokenKey])
+		return len(token) > 0, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func verifyClusterAdminBinding(binding *rbacv1.ClusterRoleBinding, name string, namespace string) error {
+	if binding.RoleRef.Kind != "ClusterRole" || binding.RoleRef.Name != clusterAdminRole {
+		return fmt.Errorf("cluster role binding %s already exists and does not bind %s", binding.Name, clusterAdminRole)
+	}
+	for _, subject := range binding.Subjects {
+		if subject.Kind == rbacv1.ServiceAccountKind && subject.Name == name && subject.Namespace == namespace {
+			return nil
+		}
+	}
+	return fmt.Errorf("cluster role binding %s already exists and does not bind service account %s/%s", binding.Name, namespace, name)
+}
+
+func verifyServiceAccountTokenSecret(secret *v1.Secret, name string) error {
+	if secret.Type != v1.SecretTypeServiceAccountToken || secret.Annotations[v1.ServiceAccountNameKey] != name {
+		return fmt.Errorf("secret %s/%s already exists and is not a token of service account %s", secret.Namespace, secret.Name, name)
+	}
+	return nil
+}
+
+func loadKubeconfig(kubeconfig string) (*clientcmdapi.Config, error) {
+	config, err := clientcmd.Load([]byte(kubeconfig))
+	if err != nil {
+		return nil, &util.ApiError{
+			HttpStatusCode:  http.StatusBadRequest,
+			InternalMessage: err.Error(),
+			UserMessage:     fmt.Sprintf("invalid kubeconfig: %s", err.Error()),
+		}
+	}
+	if len(config.Contexts) == 0 {
+		return nil, &util.ApiError{
+			HttpStatusCode:  http.StatusBadRequest,
+			InternalMessage: "no contexts in kubeconfig",
+			UserMessage:     "kubeconfig has no contexts",
+		}
+	}
+	return config, nil
+}
+
+func kubeconfigContexts(config *clientcmdapi.Config) []*KubeconfigContext {
+	names := make([]string, 0, len(config.Contexts))
+	for name := range config.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	contexts := make([]*KubeconfigContext, 0, len(names))
+	for _, name := range names {
+		kubeContext := config.Contexts[name]
+		result := &KubeconfigContext{
+			ContextName: name,
+			ClusterName: kubeContext.Cluster,
+			UserName:    kubeContext.AuthInfo,
+			Namespace:   kubeContext.Namespace,
+			Current:     name == config.CurrentContext,
+		}
+		if cluster, ok := config.Clusters[kubeContext.Cluster]; ok {
+			result.ServerUrl = cluster.Server
+		}
+		_, authType, err := contextClusterConfig(config, name)
+		result.AuthType = authType
+		result.Importable = err == nil
+		if err != nil {
+			result.Reason = err.Error()
+		}
+		contexts = append(contexts, result)
+	}
+	return contexts
+}
+
+// contextClusterConfig returns the credentials of a context along with its auth type. Files referenced by the
+// kubeconfig are not readable here so only embedded certificates and tokens are accepted
+func contextClusterConfig(config *clientcmdapi.Config, contextName string) (*util.ClusterConfig, string, error) {
+	kubeContext, ok := config.Contexts[contextName]
+	if !ok {
+		return nil, "", fmt.Errorf("context %s not found in kubeconfig", contextName)
+	}
+	cluster, ok := config.Clusters[kubeContext.Cluster]
+	if !ok || len(cluster.Server) == 0 {
+		return nil, "", fmt.Errorf("cluster %s of context %s has no server", kubeContext.Cluster, contextName)
+	}
+	authInfo, ok := config.AuthInfos[kubeContext.AuthInfo]
+	if !ok {
+		return nil, KubeconfigAuthNone, fmt.Errorf("user %s of context %s not found in kubeconfig", kubeContext.AuthInfo, contextName)
+	}
+	clusterConfig := &util.ClusterConfig{Host: cluster.Server}
+	if !cluster.InsecureSkipTLSVerify {
+		clusterConfig.CAData = string(cluster.CertificateAuthorityData)
+	}
+	switch {
+	case authInfo.Exec != nil:
+		return nil, KubeconfigAuthExec, fmt.Errorf("exec auth is not supported, use a token or a client certificate")
+	case authInfo.AuthProvider != nil:
+		return nil, KubeconfigAuthProvider, fmt.Errorf("auth provider %s is not supported, use a token or a client certificate", authInfo.AuthProvider.Name)
+	case len(authInfo.Token) > 0:
+		clusterConfig.BearerToken = authInfo.Token
+		return clusterConfig, KubeconfigAuthToken, nil
+	case len(authInfo.TokenFile) > 0:
+		return nil, KubeconfigAuthToken, fmt.Errorf("token file %s is not embedded in the kubeconfig", authInfo.TokenFile)
+	case len(authInfo.ClientCertificateData) > 0 && len(authInfo.ClientKeyData) > 0:
+		clusterConfig.CertData = string(authInfo.ClientCertificateData)
+		clusterConfig.KeyData = string(authInfo.ClientKeyData)
+		return clusterConfig, KubeconfigAuthClientCertificate, nil
+	case len(authInfo.ClientCertificate) > 0 || len(authInfo.ClientKey) > 0 || len(authInfo.ClientCertificateData) > 0:
+		return nil, KubeconfigAuthClientCertificate, fmt.Errorf("client certificate and key must both be embedded in the kubeconfig")
+	case len(authInfo.Username) > 0:
+		return nil, KubeconfigAuthBasic, fmt.Errorf("basic auth is not supported, use a token or a client certificate")
+	}
+	return nil, KubeconfigAuthNone, fmt.Errorf("user %s of context %s has no credentials", kubeContext.AuthInfo, contextName)
+}
+
+func clusterConfigMap(clusterConfig *util.ClusterConfig) map[string]string {
+	configMap := make(map[string]string)
+	for key, value := range map[string]string{
+		BearerTokenKey: clusterConfig.BearerToken,
+		CertDataKey:    clusterConfig.CertData,
+		KeyDataKey:     clusterConfig.KeyData,
+		CADataKey:      clusterConfig.CAData,
+	} {
+		if len(value) > 0 {
+			configMap[key] = value
+		}
+	}
+	return configMap
+}
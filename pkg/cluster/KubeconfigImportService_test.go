@@ -0,0 +1,147 @@
+package cluster
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testKubeconfig = `
+apiVersion: v1
+kind: Config
+current-context: prod
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com
+    certificate-authority-data: Y2E=
+- name: dev
+  cluster:
+    server: https://dev.example.com
+    insecure-skip-tls-verify: true
+users:
+- name: admin
+  user:
+    token: abc
+- name: cert
+  user:
+    client-certificate-data: Y2VydA==
+    client-key-data: a2V5
+- name: cert-file
+  user:
+    client-certificate: /home/me/cert.pem
+    client-key: /home/me/key.pem
+- name: eks
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: aws
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: admin
+- name: dev
+  context:
+    cluster: dev
+    user: cert
+    namespace: apps
+- name: dev-file
+  context:
+    cluster: dev
+    user: cert-file
+- name: eks
+  context:
+    cluster: prod
+    user: eks
+`
+
+func TestKubeconfigContexts(t *testing.T) {
+	config, err := loadKubeconfig(testKubeconfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contexts := kubeconfigContexts(config)
+	want := []struct {
+		name       string
+		authType   string
+		importable bool
+	}{
+		{"dev", KubeconfigAuthClientCertificate, true},
+		{"dev-file", KubeconfigAuthClientCertificate, false},
+		{"eks", KubeconfigAuthExec, false},
+		{"prod", KubeconfigAuthToken, true},
+	}
+	if len(contexts) != len(want) {
+		t.Fatalf("got %d contexts, want %d", len(contexts), len(want))
+	}
+	for i, w := range want {
+		c := contexts[i]
+		if c.ContextName != w.name || c.AuthType != w.authType || c.Importable != w.importable {
+			t.Errorf("context %d = %+v, want %+v", i, c, w)
+		}
+	}
+	if !contexts[3].Current || contexts[3].ServerUrl != "https://prod.example.com" {
+		t.Errorf("unexpected prod context %+v", contexts[3])
+	}
+}
+
+func TestContextClusterConfig(t *testing.T) {
+	config, err := loadKubeconfig(testKubeconfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prod, _, err := contextClusterConfig(config, "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prod.BearerToken != "abc" || prod.CAData != "ca" {
+		t.Errorf("unexpected prod config %+v", prod)
+	}
+	dev, _, err := contextClusterConfig(config, "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dev.CertData != "cert" || dev.KeyData != "key" || dev.CAData != "" {
+		t.Errorf("unexpected dev config %+v", dev)
+	}
+	configMap := clusterConfigMap(dev)
+	if len(configMap) != 2 || configMap[CertDataKey] != "cert" || configMap[KeyDataKey] != "key" {
+		t.Errorf("unexpected config map %v", configMap)
+	}
+	if _, err := loadKubeconfig("not: [a kubeconfig"); err == nil {
+		t.Error("expected error for invalid kubeconfig")
+	}
+}
+
+func TestVerifyExistingServiceAccountObjects(t *testing.T) {
+	binding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "cd-user"},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: clusterAdminRole},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "cd-user", Namespace: "devtroncd"}},
+	}
+	if err := verifyClusterAdminBinding(binding, "cd-user", "devtroncd"); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := verifyClusterAdminBinding(binding, "cd-user", "default"); err == nil {
+		t.Error("expected error for binding of another service account")
+	}
+	binding.RoleRef.Name = "view"
+	if err := verifyClusterAdminBinding(binding, "cd-user", "devtroncd"); err == nil {
+		t.Error("expected error for binding of another role")
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cd-user-token", Namespace: "devtroncd", Annotations: map[string]string{v1.ServiceAccountNameKey: "cd-user"}},
+		Type:       v1.SecretTypeServiceAccountToken,
+	}
+	if err := verifyServiceAccountTokenSecret(secret, "cd-user"); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	secret.Type = v1.SecretTypeOpaque
+	if err := verifyServiceAccountTokenSecret(secret, "cd-user"); err == nil {
+		t.Error("expected error for secret which is not a service account token")
+	}
+}
@@ -0,0 +1,670 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/cluster/repository"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+type NetworkPolicyMode string
+
+const (
+	NetworkPolicyNone               NetworkPolicyMode = "NONE"
+	NetworkPolicyDenyAllIngress     NetworkPolicyMode = "DENY_ALL_INGRESS"
+	NetworkPolicyAllowSameNamespace NetworkPolicyMode = "ALLOW_SAME_NAMESPACE"
+	// networkPolicyCustom is reported for a live policy which was changed to none of the modes above
+	networkPolicyCustom NetworkPolicyMode = "CUSTOM"
+)
+
+// names of the objects devtron owns in the namespace of an environment
+const (
+	NamespaceResourceQuotaName = "devtron-quota"
+	NamespaceLimitRangeName    = "devtron-limits"
+	NamespaceNetworkPolicyName = "devtron-default"
+)
+
+const (
+	managedByLabelKey   = "app.kubernetes.io/managed-by"
+	managedByLabelValue = "devtron"
+	driftAbsent         = "<absent>"
+	driftPresent        = "<present>"
+)
+
+// LimitRangeBean holds the container limits of a namespace, values are resource quantities keyed by resource name
+type LimitRangeBean struct {
+	Default        map[string]string `json:"default,omitempty"`
+	DefaultRequest map[string]string `json:"defaultRequest,omitempty"`
+	Max            map[string]string `json:"max,omitempty"`
+	Min            map[string]string `json:"min,omitempty"`
+}
+
+// NamespaceConfigBean is the desired state of the namespace of an environment. ResourceQuota holds the hard limits
+// keyed by resource name, e.g. requests.cpu, an empty quota or limit range removes the object from the namespace
+type NamespaceConfigBean struct {
+	ResourceQuota map[string]string `json:"resourceQuota,omitempty"`
+	LimitRange    *LimitRangeBean   `json:"limitRange,omitempty"`
+	NetworkPolicy NetworkPolicyMode `json:"networkPolicy,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// NamespaceDrift is one difference between the config of an environment and its namespace, Field is empty when the
+// whole object is missing or unexpected
+type NamespaceDrift struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Field    string `json:"field,omitempty"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+type NamespaceDriftResponse struct {
+	EnvironmentId int              `json:"environmentId"`
+	Namespace     string           `json:"namespace"`
+	InSync        bool             `json:"inSync"`
+	Drifts        []NamespaceDrift `json:"drifts"`
+}
+
+type NamespaceConfigService interface {
+	GetConfig(environmentId int) (*NamespaceConfigBean, error)
+	SaveConfig(environmentId int, config *NamespaceConfigBean, userId int32) error
+	// ReconcileNamespace creates or updates the quota, limit range, network policy and metadata of the namespace of
+	// an environment, environments without a config are left untouched
+	ReconcileNamespace(environmentId int) error
+	GetDrift(environmentId int) (*NamespaceDriftResponse, error)
+}
+
+type NamespaceConfigServiceImpl struct {
+	logger                    *zap.SugaredLogger
+	namespaceConfigRepository repository.EnvironmentNamespaceConfigRepository
+	environmentRepository     repository.EnvironmentRepository
+	clusterService            ClusterService
+	K8sUtil                   *util.K8sUtil
+}
+
+func NewNamespaceConfigServiceImpl(logger *zap.SugaredLogger,
+	namespaceConfigRepository repository.EnvironmentNamespaceConfigRepository,
+	environmentRepository repository.EnvironmentRepository,
+	clusterService ClusterService, K8sUtil *util.K8sUtil) *NamespaceConfigServiceImpl {
+	return &NamespaceConfigServiceImpl{
+		logger:                    logger,
+		namespaceConfigRepository: namespaceConfigRepository,
+		environmentRepository:     environmentRepository,
+		clusterService:            clusterService,
+		K8sUtil:                   K8sUtil,
+	}
+}
+
+func (impl NamespaceConfigServiceImpl) GetConfig(environmentId int) (*NamespaceConfigBean, error) {
+	model, err := impl.namespaceConfigRepository.FindActiveByEnvironmentId(environmentId)
+	if util.IsErrNoRows(err) {
+		return nil, nil
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching namespace config", "environmentId", environmentId, "err", err)
+		return nil, err
+	}
+	config := &NamespaceConfigBean{NetworkPolicy: NetworkPolicyMode(model.NetworkPolicy)}
+	fields := []struct {
+		value string
+		field interface{}
+	}{
+		{model.ResourceQuota, &config.ResourceQuota},
+		{model.LimitRange, &config.LimitRange},
+		{model.Labels, &config.Labels},
+		{model.Annotations, &config.Annotations},
+	}
+	for _, f := range fields {
+		if len(f.value) == 0 {
+			continue
+		}
+		if err := json.Unmarshal([]byte(f.value), f.field); err != nil {
+			impl.logger.Errorw("error in parsing namespace config", "environmentId", environmentId, "err", err)
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+func (impl NamespaceConfigServiceImpl) SaveConfig(environmentId int, config *NamespaceConfigBean, userId int32) error {
+	if err := ValidateNamespaceConfig(config); err != nil {
+		return err
+	}
+	model, err := impl.namespaceConfigRepository.FindActiveByEnvironmentId(environmentId)
+	if err != nil && !util.IsErrNoRows(err) {
+		impl.logger.Errorw("error in fetching namespace config", "environmentId", environmentId, "err", err)
+		return err
+	}
+	if model.Id == 0 {
+		model.EnvironmentId = environmentId
+		model.Active = true
+		model.CreatedOn = time.Now()
+		model.CreatedBy = userId
+	}
+	model.NetworkPolicy = string(config.NetworkPolicy)
+	if len(model.NetworkPolicy) == 0 {
+		model.NetworkPolicy = string(NetworkPolicyNone)
+	}
+	model.ResourceQuota = marshalNamespaceConfigField(config.ResourceQuota)
+	model.LimitRange = marshalNamespaceConfigField(config.LimitRange)
+	model.Labels = marshalNamespaceConfigField(config.Labels)
+	model.Annotations = marshalNamespaceConfigField(config.Annotations)
+	model.UpdatedOn = time.Now()
+	model.UpdatedBy = userId
+	if model.Id == 0 {
+		err = impl.namespaceConfigRepository.Save(model)
+	} else {
+		err = impl.namespaceConfigRepository.Update(model)
+	}
+	if err != nil {
+		impl.logger.Errorw("error in saving namespace config", "environmentId", environmentId, "err", err)
+		return err
+	}
+	return nil
+}
+
+func (impl NamespaceConfigServiceImpl) ReconcileNamespace(environmentId int) error {
+	config, err := impl.GetConfig(environmentId)
+	if err != nil || config == nil {
+		return err
+	}
+	env, err := impl.environmentRepository.FindById(environmentId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching environment", "environmentId", environmentId, "err", err)
+		return err
+	}
+	if len(env.Namespace) == 0 {
+		return nil
+	}
+	clientSet, err := impl.getClientSet(env, true)
+	if err != nil {
+		return err
+	}
+	if err := impl.applyNamespaceMetadata(clientSet, env.Namespace, config); err != nil {
+		impl.logger.Errorw("error in updating namespace metadata", "namespace", env.Namespace, "err", err)
+		return err
+	}
+	if err := impl.applyResourceQuota(clientSet, env.Namespace, desiredResourceQuota(env.Namespace, config)); err != nil {
+		impl.logger.Errorw("error in applying resource quota", "namespace", env.Namespace, "err", err)
+		return err
+	}
+	if err := impl.applyLimitRange(clientSet, env.Namespace, desiredLimitRange(env.Namespace, config)); err != nil {
+		impl.logger.Errorw("error in applying limit range", "namespace", env.Namespace, "err", err)
+		return err
+	}
+	if err := impl.applyNetworkPolicy(clientSet, env.Namespace, desiredNetworkPolicy(env.Namespace, config.NetworkPolicy)); err != nil {
+		impl.logger.Errorw("error in applying network policy", "namespace", env.Namespace, "err", err)
+		return err
+	}
+	impl.logger.Infow("namespace reconciled", "environmentId", environmentId, "namespace", env.Namespace)
+	return nil
+}
+
+func (impl NamespaceConfigServiceImpl) GetDrift(environmentId int) (*NamespaceDriftResponse, error) {
+	config, err := impl.GetConfig(environmentId)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		config = &NamespaceConfigBean{}
+	}
+	env, err := impl.environmentRepository.FindById(environmentId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching environment", "environmentId", environmentId, "err", err)
+		return nil, err
+	}
+	response := &NamespaceDriftResponse{EnvironmentId: environmentId, Namespace: env.Namespace, Drifts: []NamespaceDrift{}}
+	if len(env.Namespace) == 0 {
+		response.InSync = true
+		return response, nil
+	}
+	clientSet, err := impl.getClientSet(env, false)
+	if err != nil {
+		return nil, err
+	}
+	ns, err := clientSet.CoreV1().Namespaces().Get(env.Namespace, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		response.Drifts = append(response.Drifts, NamespaceDrift{Kind: "Namespace", Name: env.Namespace, Expected: driftPresent, Actual: driftAbsent})
+		return response, nil
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching namespace", "namespace", env.Namespace, "err", err)
+		return nil, err
+	}
+	response.Drifts = append(response.Drifts, namespaceMetadataDrift(ns, config)...)
+
+	quota, err := clientSet.CoreV1().ResourceQuotas(env.Namespace).Get(NamespaceResourceQuotaName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		quota = nil
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching namespace object", "namespace", env.Namespace, "err", err)
+		return nil, err
+	}
+	response.Drifts = append(response.Drifts, resourceQuotaDrift(desiredResourceQuota(env.Namespace, config), quota)...)
+
+	limitRange, err := clientSet.CoreV1().LimitRanges(env.Namespace).Get(NamespaceLimitRangeName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		limitRange = nil
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching namespace object", "namespace", env.Namespace, "err", err)
+		return nil, err
+	}
+	response.Drifts = append(response.Drifts, limitRangeDrift(desiredLimitRange(env.Namespace, config), limitRange)...)
+
+	policy, err := clientSet.NetworkingV1().NetworkPolicies(env.Namespace).Get(NamespaceNetworkPolicyName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		policy = nil
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching namespace object", "namespace", env.Namespace, "err", err)
+		return nil, err
+	}
+	response.Drifts = append(response.Drifts, networkPolicyDrift(config.NetworkPolicy, policy)...)
+	response.InSync = len(response.Drifts) == 0
+	return response, nil
+}
+
+func (impl NamespaceConfigServiceImpl) getClientSet(env *repository.Environment, createNamespace bool) (*kubernetes.Clientset, error) {
+	clusterBean, err := impl.clusterService.FindById(env.ClusterId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching cluster", "clusterId", env.ClusterId, "err", err)
+		return nil, err
+	}
+	cfg, err := impl.clusterService.GetClusterConfig(clusterBean)
+	if err != nil {
+		return nil, err
+	}
+	if createNamespace {
+		if err := impl.K8sUtil.CreateNsIfNotExists(env.Namespace, cfg); err != nil {
+			impl.logger.Errorw("error in creating ns", "ns", env.Namespace, "err", err)
+			return nil, err
+		}
+	}
+	return impl.K8sUtil.GetClientSet(cfg)
+}
+
+// applyNamespaceMetadata adds the configured labels and annotations, keys which are not configured are left as they are
+func (impl NamespaceConfigServiceImpl) applyNamespaceMetadata(clientSet *kubernetes.Clientset, namespace string, config *NamespaceConfigBean) error {
+	ns, err := clientSet.CoreV1().Namespaces().Get(namespace, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if len(namespaceMetadataDrift(ns, config)) == 0 {
+		return nil
+	}
+	if ns.Labels == nil {
+		ns.Labels = map[string]string{}
+	}
+	for k, v := range config.Labels {
+		ns.Labels[k] = v
+	}
+	if ns.Annotations == nil {
+		ns.Annotations = map[string]string{}
+	}
+	for k, v := range config.Annotations {
+		ns.Annotations[k] = v
+	}
+	_, err = clientSet.CoreV1().Namespaces().Update(ns)
+	return err
+}
+
+func (impl NamespaceConfigServiceImpl) applyResourceQuota(clientSet *kubernetes.Clientset, namespace string, desired *v1.ResourceQuota) error {
+	client := clientSet.CoreV1().ResourceQuotas(namespace)
+	live, err := client.Get(NamespaceResourceQuotaName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		live = nil
+	} else if err != nil {
+		return err
+	}
+	switch {
+	case desired == nil && live == nil:
+		return nil
+	case desired == nil:
+		return client.Delete(NamespaceResourceQuotaName, &metav1.DeleteOptions{})
+	case live == nil:
+		_, err = client.Create(desired)
+	case len(resourceQuotaDrift(desired, live)) > 0:
+		live.Labels = desired.Labels
+		live.Spec = desired.Spec
+		_, err = client.Update(live)
+	}
+	return err
+}
+
+func (impl NamespaceConfigServiceImpl) applyLimitRange(clientSet *kubernetes.Clientset, namespace string, desired *v1.LimitRange) error {
+	client := clientSet.CoreV1().LimitRanges(namespace)
+	live, err := client.Get(NamespaceLimitRangeName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		live = nil
+	} else if err != nil {
+		return err
+	}
+	switch {
+	case desired == nil && live == nil:
+		return nil
+	case desired == nil:
+		return client.Delete(NamespaceLimitRangeName, &metav1.DeleteOptions{})
+	case live == nil:
+		_, err = client.Create(desired)
+	case len(limitRangeDrift(desired, live)) > 0:
+		live.Labels = desired.Labels
+		live.Spec = desired.Spec
+		_, err = client.Update(live)
+	}
+	return err
+}
+
+func (impl NamespaceConfigServiceImpl) applyNetworkPolicy(clientSet *kubernetes.Clientset, namespace string, desired *networkingv1.NetworkPolicy) error {
+	client := clientSet.NetworkingV1().NetworkPolicies(namespace)
+	live, err := client.Get(NamespaceNetworkPolicyName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		live = nil
+	} else if err != nil {
+		return err
+	}
+	switch {
+	case desired == nil && live == nil:
+		return nil
+	case desired == nil:
+		return client.Delete(NamespaceNetworkPolicyName, &metav1.DeleteOptions{})
+	case live == nil:
+		_, err = client.Create(desired)
+	case networkPolicyModeOf(live) != networkPolicyModeOf(desired):
+		live.Labels = desired.Labels
+		live.Spec = desired.Spec
+		_, err = client.Update(live)
+	}
+	return err
+}
+
+// ValidateNamespaceConfig checks the quantities, network policy mode and metadata keys of a config
+func ValidateNamespaceConfig(config *NamespaceConfigBean) error {
+	if config == nil {
+		return nil
+	}
+	switch config.NetworkPolicy {
+	case "", NetworkPolicyNone, NetworkPolicyDenyAllIngress, NetworkPolicyAllowSameNamespace:
+	default:
+		return fmt.Errorf("unknown network policy %s", config.NetworkPolicy)
+	}
+	if _, err := toResourceList(config.ResourceQuota); err != nil {
+		return err
+	}
+	if config.LimitRange != nil {
+		for _, limits := range []map[string]string{config.LimitRange.Default, config.LimitRange.DefaultRequest, config.LimitRange.Max, config.LimitRange.Min} {
+			if _, err := toResourceList(limits); err != nil {
+				return err
+			}
+		}
+	}
+	for k, v := range config.Labels {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return fmt.Errorf("invalid label key %s: %s", k, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+			return fmt.Errorf("invalid value of label %s: %s", k, strings.Join(errs, ", "))
+		}
+	}
+	for k := range config.Annotations {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return fmt.Errorf("invalid annotation key %s: %s", k, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+func marshalNamespaceConfigField(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]string:
+		if len(v) == 0 {
+			return ""
+		}
+	case *LimitRangeBean:
+		if v == nil {
+			return ""
+		}
+	}
+	content, _ := json.Marshal(value)
+	return string(content)
+}
+
+func toResourceList(quantities map[string]string) (v1.ResourceList, error) {
+	if len(quantities) == 0 {
+		return nil, nil
+	}
+	resourceList := v1.ResourceList{}
+	for name, value := range quantities {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity %s of %s: %s", value, name, err.Error())
+		}
+		resourceList[v1.ResourceName(name)] = quantity
+	}
+	return resourceList, nil
+}
+
+func managedObjectMeta(name string, namespace string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: namespace,
+		Labels:    map[string]string{managedByLabelKey: managedByLabelValue},
+	}
+}
+
+func desiredResourceQuota(namespace string, config *NamespaceConfigBean) *v1.ResourceQuota {
+	hard, _ := toResourceList(config.ResourceQuota)
+	if len(hard) == 0 {
+		return nil
+	}
+	return &v1.ResourceQuota{
+		ObjectMeta: managedObjectMeta(NamespaceResourceQuotaName, namespace),
+		Spec:       v1.ResourceQuotaSpec{Hard: hard},
+	}
+}
+
+func desiredLimitRange(namespace string, config *NamespaceConfigBean) *v1.LimitRange {
+	if config.LimitRange == nil {
+		return nil
+	}
+	item := v1.LimitRangeItem{Type: v1.LimitTypeContainer}
+	item.Default, _ = toResourceList(config.LimitRange.Default)
+	item.DefaultRequest, _ = toResourceList(config.LimitRange.DefaultRequest)
+	item.Max, _ = toResourceList(config.LimitRange.Max)
+	item.Min, _ = toResourceList(config.LimitRange.Min)
+	if len(item.Default)+len(item.DefaultRequest)+len(item.Max)+len(item.Min) == 0 {
+		return nil
+	}
+	return &v1.LimitRange{
+		ObjectMeta: managedObjectMeta(NamespaceLimitRangeName, namespace),
+		Spec:       v1.LimitRangeSpec{Limits: []v1.LimitRangeItem{item}},
+	}
+}
+
+func desiredNetworkPolicy(namespace string, mode NetworkPolicyMode) *networkingv1.NetworkPolicy {
+	spec := networkingv1.NetworkPolicySpec{
+		PodSelector: metav1.LabelSelector{},
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+	}
+	switch mode {
+	case NetworkPolicyDenyAllIngress:
+	case NetworkPolicyAllowSameNamespace:
+		spec.Ingress = []networkingv1.NetworkPolicyIngressRule{
+			{From: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}}},
+		}
+	default:
+		return nil
+	}
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: managedObjectMeta(NamespaceNetworkPolicyName, namespace),
+		Spec:       spec,
+	}
+}
+
+// networkPolicyModeOf classifies a policy into the modes devtron creates, policies edited in the cluster are CUSTOM
+func networkPolicyModeOf(policy *networkingv1.NetworkPolicy) NetworkPolicyMode {
+	if policy == nil {
+		return NetworkPolicyNone
+	}
+	spec := policy.Spec
+	if len(spec.PodSelector.MatchLabels) > 0 || len(spec.PodSelector.MatchExpressions) > 0 || len(spec.Egress) > 0 {
+		return networkPolicyCustom
+	}
+	for _, policyType := range spec.PolicyTypes {
+		if policyType != networkingv1.PolicyTypeIngress {
+			return networkPolicyCustom
+		}
+	}
+	switch len(spec.Ingress) {
+	case 0:
+		return NetworkPolicyDenyAllIngress
+	case 1:
+		rule := spec.Ingress[0]
+		if len(rule.Ports) == 0 && len(rule.From) == 1 {
+			peer := rule.From[0]
+			if peer.IPBlock == nil && peer.NamespaceSelector == nil && peer.PodSelector != nil &&
+				len(peer.PodSelector.MatchLabels) == 0 && len(peer.PodSelector.MatchExpressions) == 0 {
+				return NetworkPolicyAllowSameNamespace
+			}
+		}
+	}
+	return networkPolicyCustom
+}
+
+func namespaceMetadataDrift(ns *v1.Namespace, config *NamespaceConfigBean) []NamespaceDrift {
+	var drifts []NamespaceDrift
+	for _, k := range sortedKeys(config.Labels) {
+		if actual, ok := ns.Labels[k]; !ok || actual != config.Labels[k] {
+			drifts = append(drifts, NamespaceDrift{Kind: "Namespace", Name: ns.Name, Field: "labels." + k, Expected: config.Labels[k], Actual: valueOrAbsent(actual, ok)})
+		}
+	}
+	for _, k := range sortedKeys(config.Annotations) {
+		if actual, ok := ns.Annotations[k]; !ok || actual != config.Annotations[k] {
+			drifts = append(drifts, NamespaceDrift{Kind: "Namespace", Name: ns.Name, Field: "annotations." + k, Expected: config.Annotations[k], Actual: valueOrAbsent(actual, ok)})
+		}
+	}
+	return drifts
+}
+
+func resourceQuotaDrift(desired *v1.ResourceQuota, live *v1.ResourceQuota) []NamespaceDrift {
+	if drift := presenceDrift("ResourceQuota", NamespaceResourceQuotaName, desired == nil, live == nil); drift != nil {
+		return drift
+	}
+	if desired == nil {
+		return nil
+	}
+	return resourceListDrift("ResourceQuota", NamespaceResourceQuotaName, "hard", desired.Spec.Hard, live.Spec.Hard)
+}
+
+func limitRangeDrift(desired *v1.LimitRange, live *v1.LimitRange) []NamespaceDrift {
+	if drift := presenceDrift("LimitRange", NamespaceLimitRangeName, desired == nil, live == nil); drift != nil {
+		return drift
+	}
+	if desired == nil {
+		return nil
+	}
+	want := desired.Spec.Limits[0]
+	got := v1.LimitRangeItem{}
+	for _, item := range live.Spec.Limits {
+		if item.Type == v1.LimitTypeContainer {
+			got = item
+		}
+	}
+	var drifts []NamespaceDrift
+	drifts = append(drifts, resourceListDrift("LimitRange", NamespaceLimitRangeName, "default", want.Default, got.Default)...)
+	drifts = append(drifts, resourceListDrift("LimitRange", NamespaceLimitRangeName, "defaultRequest", want.DefaultRequest, got.DefaultRequest)...)
+	drifts = append(drifts, resourceListDrift("LimitRange", NamespaceLimitRangeName, "max", want.Max, got.Max)...)
+	drifts = append(drifts, resourceListDrift("LimitRange", NamespaceLimitRangeName, "min", want.Min, got.Min)...)
+	return drifts
+}
+
+func networkPolicyDrift(mode NetworkPolicyMode, live *networkingv1.NetworkPolicy) []NamespaceDrift {
+	if len(mode) == 0 {
+		mode = NetworkPolicyNone
+	}
+	actual := networkPolicyModeOf(live)
+	if actual == mode {
+		return nil
+	}
+	return []NamespaceDrift{{Kind: "NetworkPolicy", Name: NamespaceNetworkPolicyName, Field: "spec", Expected: string(mode), Actual: string(actual)}}
+}
+
+// presenceDrift reports a managed object which is missing or should not exist, nil when both sides agree
+func presenceDrift(kind string, name string, desiredAbsent bool, liveAbsent bool) []NamespaceDrift {
+	switch {
+	case desiredAbsent && !liveAbsent:
+		return []NamespaceDrift{{Kind: kind, Name: name, Expected: driftAbsent, Actual: driftPresent}}
+	case !desiredAbsent && liveAbsent:
+		return []NamespaceDrift{{Kind: kind, Name: name, Expected: driftPresent, Actual: driftAbsent}}
+	}
+	return nil
+}
+
+func resourceListDrift(kind string, name string, field string, desired v1.ResourceList, live v1.ResourceList) []NamespaceDrift {
+	names := map[string]string{}
+	for resourceName := range desired {
+		names[string(resourceName)] = ""
+	}
+	for resourceName := range live {
+		names[string(resourceName)] = ""
+	}
+	var drifts []NamespaceDrift
+	for _, resourceName := range sortedKeys(names) {
+		want, wanted := desired[v1.ResourceName(resourceName)]
+		got, present := live[v1.ResourceName(resourceName)]
+		if wanted && present && want.Cmp(got) == 0 {
+			continue
+		}
+		drift := NamespaceDrift{Kind: kind, Name: name, Field: field + "." + resourceName, Expected: driftAbsent, Actual: driftAbsent}
+		if wanted {
+			drift.Expected = want.String()
+		}
+		if present {
+			drift.Actual = got.String()
+		}
+		drifts = append(drifts, drift)
+	}
+	return drifts
+}
+
+func valueOrAbsent(value string, ok bool) string {
+	if !ok {
+		return driftAbsent
+	}
+	return value
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,105 @@
+package cluster
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"testing"
+)
+
+func TestValidateNamespaceConfig(t *testing.T) {
+	valid := &NamespaceConfigBean{
+		ResourceQuota: map[string]string{"requests.cpu": "4", "limits.memory": "8Gi"},
+		LimitRange:    &LimitRangeBean{Default: map[string]string{"cpu": "500m"}},
+		NetworkPolicy: NetworkPolicyDenyAllIngress,
+		Labels:        map[string]string{"team": "payments"},
+		Annotations:   map[string]string{"example.com/owner": "Payments Team"},
+	}
+	if err := ValidateNamespaceConfig(valid); err != nil {
+		t.Errorf("ValidateNamespaceConfig() = %v, want nil", err)
+	}
+	invalid := []*NamespaceConfigBean{
+		{ResourceQuota: map[string]string{"requests.cpu": "four"}},
+		{LimitRange: &LimitRangeBean{Max: map[string]string{"memory": "1 GB"}}},
+		{NetworkPolicy: "ALLOW_ALL"},
+		{Labels: map[string]string{"team": "payments team"}},
+		{Annotations: map[string]string{"-owner": "x"}},
+	}
+	for _, config := range invalid {
+		if err := ValidateNamespaceConfig(config); err == nil {
+			t.Errorf("ValidateNamespaceConfig(%+v) = nil, want error", config)
+		}
+	}
+}
+
+func TestResourceQuotaDrift(t *testing.T) {
+	config := &NamespaceConfigBean{ResourceQuota: map[string]string{"requests.cpu": "4", "limits.memory": "8Gi"}}
+	desired := desiredResourceQuota("payments", config)
+	live := &v1.ResourceQuota{Spec: v1.ResourceQuotaSpec{Hard: v1.ResourceList{
+		"requests.cpu":  resource.MustParse("4000m"),
+		"limits.memory": resource.MustParse("4Gi"),
+		"pods":          resource.MustParse("10"),
+	}}}
+	want := []NamespaceDrift{
+		{Kind: "ResourceQuota", Name: NamespaceResourceQuotaName, Field: "hard.limits.memory", Expected: "8Gi", Actual: "4Gi"},
+		{Kind: "ResourceQuota", Name: NamespaceResourceQuotaName, Field: "hard.pods", Expected: driftAbsent, Actual: "10"},
+	}
+	if got := resourceQuotaDrift(desired, live); !reflect.DeepEqual(got, want) {
+		t.Errorf("resourceQuotaDrift() = %+v, want %+v", got, want)
+	}
+	if got := resourceQuotaDrift(desired, nil); len(got) != 1 || got[0].Actual != driftAbsent {
+		t.Errorf("resourceQuotaDrift() of missing quota = %+v", got)
+	}
+	if got := resourceQuotaDrift(nil, live); len(got) != 1 || got[0].Expected != driftAbsent {
+		t.Errorf("resourceQuotaDrift() of unexpected quota = %+v", got)
+	}
+	if got := resourceQuotaDrift(desiredResourceQuota("payments", &NamespaceConfigBean{}), nil); len(got) != 0 {
+		t.Errorf("resourceQuotaDrift() without quota = %+v, want none", got)
+	}
+}
+
+func TestLimitRangeDrift(t *testing.T) {
+	config := &NamespaceConfigBean{LimitRange: &LimitRangeBean{Default: map[string]string{"cpu": "500m"}, Max: map[string]string{"memory": "2Gi"}}}
+	desired := desiredLimitRange("payments", config)
+	live := desired.DeepCopy()
+	if got := limitRangeDrift(desired, live); len(got) != 0 {
+		t.Errorf("limitRangeDrift() of same limits = %+v, want none", got)
+	}
+	live.Spec.Limits[0].Default["cpu"] = resource.MustParse("1")
+	want := []NamespaceDrift{{Kind: "LimitRange", Name: NamespaceLimitRangeName, Field: "default.cpu", Expected: "500m", Actual: "1"}}
+	if got := limitRangeDrift(desired, live); !reflect.DeepEqual(got, want) {
+		t.Errorf("limitRangeDrift() = %+v, want %+v", got, want)
+	}
+}
+
+func TestNetworkPolicyModeOf(t *testing.T) {
+	for _, mode := range []NetworkPolicyMode{NetworkPolicyDenyAllIngress, NetworkPolicyAllowSameNamespace} {
+		if got := networkPolicyModeOf(desiredNetworkPolicy("payments", mode)); got != mode {
+			t.Errorf("networkPolicyModeOf(%s) = %s", mode, got)
+		}
+	}
+	if got := networkPolicyModeOf(desiredNetworkPolicy("payments", NetworkPolicyNone)); got != NetworkPolicyNone {
+		t.Errorf("networkPolicyModeOf(NONE) = %s", got)
+	}
+	edited := desiredNetworkPolicy("payments", NetworkPolicyDenyAllIngress)
+	edited.Spec.PodSelector = metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}}
+	if got := networkPolicyDrift(NetworkPolicyDenyAllIngress, edited); len(got) != 1 || got[0].Actual != string(networkPolicyCustom) {
+		t.Errorf("networkPolicyDrift() of edited policy = %+v", got)
+	}
+}
+
+func TestNamespaceMetadataDrift(t *testing.T) {
+	ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments", Labels: map[string]string{"team": "payments", "extra": "kept"}}}
+	config := &NamespaceConfigBean{
+		Labels:      map[string]string{"team": "payments", "tier": "backend"},
+		Annotations: map[string]string{"owner": "payments"},
+	}
+	want := []NamespaceDrift{
+		{Kind: "Namespace", Name: "payments", Field: "labels.tier", Expected: "backend", Actual: driftAbsent},
+		{Kind: "Namespace", Name: "payments", Field: "annotations.owner", Expected: "payments", Actual: driftAbsent},
+	}
+	if got := namespaceMetadataDrift(ns, config); !reflect.DeepEqual(got, want) {
+		t.Errorf("namespaceMetadataDrift() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package repository
+
+import (
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"time"
+)
+
+// ClusterHealthCheck is the result of one probe of a cluster's api server
+type ClusterHealthCheck struct {
+	TableName      struct{}  `sql:"cluster_health_check" pg:",discard_unknown_columns"`
+	Id             int       `sql:"id,pk"`
+	ClusterId      int       `sql:"cluster_id,notnull"`
+	Reachable      bool      `sql:"reachable,notnull"`
+	ServerVersion  string    `sql:"server_version"`
+	LatencyMs      int64     `sql:"latency_ms,notnull"`
+	Error          string    `sql:"error"`
+	TokenExpiresOn time.Time `sql:"token_expires_on"`
+	CheckedOn      time.Time `sql:"checked_on,notnull"`
+}
+
+type ClusterHealthRepository interface {
+	Save(model *ClusterHealthCheck) error
+	FindLatestByClusterId(clusterId int) (*ClusterHealthCheck, error)
+	FindLatestForAllClusters() ([]*ClusterHealthCheck, error)
+	FindByClusterId(clusterId int, limit int) ([]*ClusterHealthCheck, error)
+	DeleteOlderThan(checkedOn time.Time) error
+	AcquireProbeLock(holder string, ttl time.Duration) (bool, error)
+}
+
+type ClusterHealthRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func NewClusterHealthRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *ClusterHealthRepositoryImpl {
+	return &ClusterHealthRepositoryImpl{
+		dbConnection: dbConnection,
+		logger:       logger,
+	}
+}
+
+func (impl ClusterHealthRepositoryImpl) Save(model *ClusterHealthCheck) error {
+	return impl.dbConnection.Insert(model)
+}
+
+func (impl ClusterHealthRepositoryImpl) FindLatestByClusterId(clusterId int) (*ClusterHealthCheck, error) {
+	model := &ClusterHealthCheck{}
+	err := impl.dbConnection.Model(model).
+		Where("cluster_id = ?", clusterId).
+		Order("checked_on DESC").
+		Limit(1).
+		Select()
+	return model, err
+}
+
+// FindLatestForAllClusters returns the last check of every cluster that has been probed at least once
+func (impl ClusterHealthRepositoryImpl) FindLatestForAllClusters() ([]*ClusterHealthCheck, error) {
+	var models []*ClusterHealthCheck
+	query := "SELECT DISTINCT ON (cluster_id) * FROM cluster_health_check ORDER BY cluster_id, checked_on DESC;"
+	_, err := impl.dbConnection.Query(&models, query)
+	return models, err
+}
+
+// FindByClusterId returns the history of a cluster latest first
+func (impl ClusterHealthRepositoryImpl) FindByClusterId(clusterId int, limit int) ([]*ClusterHealthCheck, error) {
+	var models []*ClusterHealthCheck
+	err := impl.dbConnection.Model(&models).
+		Where("cluster_id = ?", clusterId).
+		Order("checked_on DESC").
+		Limit(limit).
+		Select()
+	return models, err
+}
+
+func (impl ClusterHealthRepositoryImpl) DeleteOlderThan(checkedOn time.Time) error {
+	_, err := impl.dbConnection.Model((*ClusterHealthCheck)(nil)).
+		Where("checked_on < ?", checkedOn).
+		Delete()
+	return err
+}
+
+// AcquireProbeLock takes or renews the lock of the periodic probe for holder, it fails while another holder's lock has
+// not expired. Expiry is checked against the database clock so that replicas with skewed clocks agree
+func (impl ClusterHealthRepositoryImpl) AcquireProbeLock(holder string, ttl time.Duration) (bool, error) {
+	query := "UPDATE cluster_health_probe_lock SET holder = ?, locked_until = now() + ? * interval '1 second'" +
+		" WHERE id = 1 AND (holder = ? OR locked_until < now());"
+	res, err := impl.dbConnection.Exec(query, holder, int(ttl.Seconds()), holder)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() == 1, nil
+}
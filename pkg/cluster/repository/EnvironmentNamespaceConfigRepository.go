@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+)
+
+// EnvironmentNamespaceConfig holds the quota, limits, network policy and metadata devtron keeps on the namespace of an
+// environment, the specs and metadata are stored as json
+type EnvironmentNamespaceConfig struct {
+	TableName     struct{} `sql:"environment_namespace_config" pg:",discard_unknown_columns"`
+	Id            int      `sql:"id,pk"`
+	EnvironmentId int      `sql:"environment_id,notnull"`
+	ResourceQuota string   `sql:"resource_quota"`
+	LimitRange    string   `sql:"limit_range"`
+	NetworkPolicy string   `sql:"network_policy,notnull"`
+	Labels        string   `sql:"labels"`
+	Annotations   string   `sql:"annotations"`
+	Active        bool     `sql:"active,notnull"`
+	sql.AuditLog
+}
+
+type EnvironmentNamespaceConfigRepository interface {
+	Save(model *EnvironmentNamespaceConfig) error
+	Update(model *EnvironmentNamespaceConfig) error
+	FindActiveByEnvironmentId(environmentId int) (*EnvironmentNamespaceConfig, error)
+}
+
+type EnvironmentNamespaceConfigRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func NewEnvironmentNamespaceConfigRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *EnvironmentNamespaceConfigRepositoryImpl {
+	return &EnvironmentNamespaceConfigRepositoryImpl{
+		dbConnection: dbConnection,
+		logger:       logger,
+	}
+}
+
+func (impl EnvironmentNamespaceConfigRepositoryImpl) Save(model *EnvironmentNamespaceConfig) error {
+	return impl.dbConnection.Insert(model)
+}
+
+func (impl EnvironmentNamespaceConfigRepositoryImpl) Update(model *EnvironmentNamespaceConfig) error {
+	return impl.dbConnection.Update(model)
+}
+
+func (impl EnvironmentNamespaceConfigRepositoryImpl) FindActiveByEnvironmentId(environmentId int) (*EnvironmentNamespaceConfig, error) {
+	model := &EnvironmentNamespaceConfig{}
+	err := impl.dbConnection.Model(model).
+		Where("environment_id = ?", environmentId).
+		Where("active = ?", true).
+		Select()
+	return model, err
+}
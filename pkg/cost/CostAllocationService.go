@@ -0,0 +1,594 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cost
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/caarlos0/env"
+	"github.com/devtron-labs/devtron/internal/sql/repository/app"
+	"github.com/devtron-labs/devtron/internal/util"
+	repository2 "github.com/devtron-labs/devtron/pkg/cluster/repository"
+	"github.com/devtron-labs/devtron/pkg/cost/repository"
+	"github.com/devtron-labs/devtron/pkg/prometheus"
+	"github.com/devtron-labs/devtron/pkg/team"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	CostGroupByApp         = "app"
+	CostGroupByEnvironment = "environment"
+	CostGroupByTeam        = "team"
+
+	// UnallocatedCostName is reported for pods which do not carry the label of a devtron app
+	UnallocatedCostName = "unallocated"
+
+	costDateLayout  = "2006-01-02"
+	costMonthLayout = "2006-01"
+	bytesPerGib     = 1024 * 1024 * 1024
+	// the day is sampled every 5 minutes, absent samples count as zero so pods living part of the day pay for that part
+	costSampleStep = "5m"
+	samplesPerDay  = 24 * 12
+)
+
+type CostAllocationConfig struct {
+	CronExpr            string `env:"COST_ALLOCATION_CRON" envDefault:"30 0 * * *"`
+	Currency            string `env:"COST_ALLOCATION_CURRENCY" envDefault:"USD"`
+	QueryTimeoutSeconds int    `env:"COST_ALLOCATION_QUERY_TIMEOUT_SECONDS" envDefault:"60"`
+}
+
+type ClusterCostPriceBean struct {
+	ClusterId      int     `json:"clusterId" validate:"number,required"`
+	ClusterName    string  `json:"clusterName,omitempty"`
+	CpuCoreHour    float64 `json:"cpuCoreHour" validate:"min=0"`
+	MemoryGibHour  float64 `json:"memoryGibHour" validate:"min=0"`
+	StorageGibHour float64 `json:"storageGibHour" validate:"min=0"`
+	UserId         int32   `json:"-"`
+}
+
+type CostTrendRequest struct {
+	GroupBy string    `json:"groupBy"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+}
+
+type CostTrendPoint struct {
+	Date        string  `json:"date"`
+	CpuCost     float64 `json:"cpuCost"`
+	MemoryCost  float64 `json:"memoryCost"`
+	StorageCost float64 `json:"storageCost"`
+	TotalCost   float64 `json:"totalCost"`
+}
+
+// CostTrendSeries is the daily cost of one app, environment or team, Id is 0 for unallocated cost
+type CostTrendSeries struct {
+	Id     int               `json:"id"`
+	Name   string            `json:"name"`
+	Points []*CostTrendPoint `json:"points"`
+}
+
+type CostTrendResponse struct {
+	GroupBy  string             `json:"groupBy"`
+	Currency string             `json:"currency"`
+	Series   []*CostTrendSeries `json:"series"`
+}
+
+type CostReportRow struct {
+	Id          int     `json:"id"`
+	Name        string  `json:"name"`
+	CpuCost     float64 `json:"cpuCost"`
+	MemoryCost  float64 `json:"memoryCost"`
+	StorageCost float64 `json:"storageCost"`
+	TotalCost   float64 `json:"totalCost"`
+}
+
+// CostReport is the cost of a month, rows are ordered by total cost starting with the highest
+type CostReport struct {
+	Month     string           `json:"month"`
+	GroupBy   string           `json:"groupBy"`
+	Currency  string           `json:"currency"`
+	TotalCost float64          `json:"totalCost"`
+	Rows      []*CostReportRow `json:"rows"`
+}
+
+// CostAllocationService computes the daily cost of every app in every environment from the resource requests and
+// usage its pods report to the prometheus of their cluster. Pods are attributed to apps by their app label and
+// are charged for the higher of request and usage
+type CostAllocationService interface {
+	SavePrice(bean *ClusterCostPriceBean) (*ClusterCostPriceBean, error)
+	GetPrices() ([]*ClusterCostPriceBean, error)
+	ComputeDailyCost(day time.Time) error
+	StartComputeDailyCost(day time.Time) bool
+	GetCostTrend(request *CostTrendRequest) (*CostTrendResponse, error)
+	GetMonthlyReport(month time.Time, groupBy string) (*CostReport, error)
+}
+
+type CostAllocationServiceImpl struct {
+	logger                     *zap.SugaredLogger
+	config                     *CostAllocationConfig
+	clusterCostPriceRepository repository.ClusterCostPriceRepository
+	costAllocationRepository   repository.CostAllocationRepository
+	clusterRepository          repository2.ClusterRepository
+	environmentRepository      repository2.EnvironmentRepository
+	appRepository              app.AppRepository
+	teamRepository             team.TeamRepository
+	// computing is set while a day is computed so that manual and scheduled runs do not replace the same
+	// allocations concurrently
+	computing int32
+}
+
+func NewCostAllocationServiceImpl(logger *zap.SugaredLogger,
+	clusterCostPriceRepository repository.ClusterCostPriceRepository,
+	costAllocationRepository repository.CostAllocationRepository,
+	clusterRepository repository2.ClusterRepository,
+	environmentRepository repository2.EnvironmentRepository,
+	appRepository app.AppRepository, teamRepository team.TeamRepository) (*CostAllocationServiceImpl, error) {
+	config := &CostAllocationConfig{}
+	err := env.Parse(config)
+	if err != nil {
+		logger.Errorw("error in parsing cost allocation config", "err", err)
+		return nil, err
+	}
+	impl := &CostAllocationServiceImpl{
+		logger:                     logger,
+		config:                     config,
+		clusterCostPriceRepository: clusterCostPriceRepository,
+		costAllocationRepository:   costAllocationRepository,
+		clusterRepository:          clusterRepository,
+		environmentRepository:      environmentRepository,
+		appRepository:              appRepository,
+		teamRepository:             teamRepository,
+	}
+	cron := cron.New(
+		cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)))
+	cron.Start()
+	_, err = cron.AddFunc(config.CronExpr, impl.computePreviousDay)
+	if err != nil {
+		logger.Errorw("error in starting cost allocation cron", "err", err)
+		return nil, err
+	}
+	return impl, nil
+}
+
+func (impl *CostAllocationServiceImpl) SavePrice(bean *ClusterCostPriceBean) (*ClusterCostPriceBean, error) {
+	cluster, err := impl.clusterRepository.FindById(bean.ClusterId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching cluster", "clusterId", bean.ClusterId, "err", err)
+		return nil, err
+	}
+	model, err := impl.clusterCostPriceRepository.FindActiveByClusterId(bean.ClusterId)
+	if err != nil && !util.IsErrNoRows(err) {
+		impl.logger.Errorw("error in fetching cluster price", "clusterId", bean.ClusterId, "err", err)
+		return nil, err
+	}
+	if model.Id == 0 {
+		model.ClusterId = bean.ClusterId
+		model.Active = true
+		model.CreatedOn = time.Now()
+		model.CreatedBy = bean.UserId
+	}
+	model.CpuCoreHour = bean.CpuCoreHour
+	model.MemoryGibHour = bean.MemoryGibHour
+	model.StorageGibHour = bean.StorageGibHour
+	model.UpdatedOn = time.Now()
+	model.UpdatedBy = bean.UserId
+	if model.Id == 0 {
+		err = impl.clusterCostPriceRepository.Save(model)
+	} else {
+		err = impl.clusterCostPriceRepository.Update(model)
+	}
+	if err != nil {
+		impl.logger.Errorw("error in saving cluster price", "clusterId", bean.ClusterId, "err", err)
+		return nil, err
+	}
+	bean.ClusterName = cluster.ClusterName
+	return bean, nil
+}
+
+func (impl *CostAllocationServiceImpl) GetPrices() ([]*ClusterCostPriceBean, error) {
+	prices, err := impl.clusterCostPriceRepository.FindAllActive()
+	if err != nil {
+		impl.logger.Errorw("error in fetching cluster prices", "err", err)
+		return nil, err
+	}
+	clusters, err := impl.clusterRepository.FindAllActive()
+	if err != nil {
+		impl.logger.Errorw("error in fetching clusters", "err", err)
+		return nil, err
+	}
+	clusterNames := make(map[int]string)
+	for _, cluster := range clusters {
+		clusterNames[cluster.Id] = cluster.ClusterName
+	}
+	beans := make([]*ClusterCostPriceBean, 0)
+	for _, price := range prices {
+		beans = append(beans, &ClusterCostPriceBean{
+			ClusterId:      price.ClusterId,
+			ClusterName:    clusterNames[price.ClusterId],
+			CpuCoreHour:    price.CpuCoreHour,
+			MemoryGibHour:  price.MemoryGibHour,
+			StorageGibHour: price.StorageGibHour,
+		})
+	}
+	return beans, nil
+}
+
+func (impl *CostAllocationServiceImpl) computePreviousDay() {
+	day := time.Now().UTC().AddDate(0, 0, -1)
+	if !atomic.CompareAndSwapInt32(&impl.computing, 0, 1) {
+		impl.logger.Warnw("skipping daily cost computation, another one is running", "day", day.Format(costDateLayout))
+		return
+	}
+	impl.computeDay(day)
+}
+
+// StartComputeDailyCost computes the day in the background as it queries the prometheus of every environment, it
+// returns false without starting when a computation is already running
+func (impl *CostAllocationServiceImpl) StartComputeDailyCost(day time.Time) bool {
+	if !atomic.CompareAndSwapInt32(&impl.computing, 0, 1) {
+		return false
+	}
+	go impl.computeDay(day)
+	return true
+}
+
+func (impl *CostAllocationServiceImpl) computeDay(day time.Time) {
+	defer atomic.StoreInt32(&impl.computing, 0)
+	if err := impl.ComputeDailyCost(day); err != nil {
+		impl.logger.Errorw("error in computing daily cost", "day", day.Format(costDateLayout), "err", err)
+	}
+}
+
+// ComputeDailyCost computes the cost of the utc day of the given time for every environment on a priced cluster,
+// environments which fail are logged and skipped so one unreachable prometheus does not block the others
+func (impl *CostAllocationServiceImpl) ComputeDailyCost(day time.Time) error {
+	day = truncateToDay(day)
+	prices, err := impl.clusterCostPriceRepository.FindAllActive()
+	if err != nil {
+		impl.logger.Errorw("error in fetching cluster prices", "err", err)
+		return err
+	}
+	pricesByCluster := make(map[int]*repository.ClusterCostPrice)
+	for _, price := range prices {
+		pricesByCluster[price.ClusterId] = price
+	}
+	if len(pricesByCluster) == 0 {
+		return nil
+	}
+	envs, err := impl.environmentRepository.FindAllActive()
+	if err != nil {
+		impl.logger.Errorw("error in fetching environments", "err", err)
+		return err
+	}
+	apps, err := impl.appRepository.FindAllActiveAppsWithTeam()
+	if err != nil && !util.IsErrNoRows(err) {
+		impl.logger.Errorw("error in fetching apps", "err", err)
+		return err
+	}
+	appsByName := make(map[string]*app.App)
+	for _, a := range apps {
+		appsByName[a.AppName] = a
+	}
+	for i := range envs {
+		environment := &envs[i]
+		price, ok := pricesByCluster[environment.ClusterId]
+		if !ok || environment.Cluster == nil || len(environment.Cluster.PrometheusEndpoint) == 0 || len(environment.Namespace) == 0 {
+			continue
+		}
+		prometheusAPI, err := prometheus.ContextByEnv(environment.Name, environment.Cluster.PrometheusEndpoint)
+		if err != nil {
+			impl.logger.Errorw("error in getting prometheus api client", "env", environment.Name, "err", err)
+			continue
+		}
+		allocations, err := impl.computeEnvironmentCost(prometheusAPI, environment, price, appsByName, day)
+		if err != nil {
+			impl.logger.Errorw("error in computing environment cost", "env", environment.Name, "day", day.Format(costDateLayout), "err", err)
+			continue
+		}
+		err = impl.costAllocationRepository.ReplaceForEnvironment(day, environment.Id, allocations)
+		if err != nil {
+			impl.logger.Errorw("error in saving environment cost", "env", environment.Name, "err", err)
+			continue
+		}
+		impl.logger.Infow("environment cost computed", "env", environment.Name, "day", day.Format(costDateLayout), "apps", len(allocations))
+	}
+	return nil
+}
+
+func (impl *CostAllocationServiceImpl) computeEnvironmentCost(prometheusAPI v1.API, environment *repository2.Environment,
+	price *repository.ClusterCostPrice, appsByName map[string]*app.App, day time.Time) ([]*repository.CostAllocation, error) {
+	queries := costQueries(environment.Namespace)
+	dayEnd := day.Add(24 * time.Hour)
+	samples := make(map[string][]float64)
+	for i, query := range queries {
+		values, err := impl.queryByAppLabel(prometheusAPI, query, dayEnd)
+		if err != nil {
+			return nil, err
+		}
+		for label, value := range values {
+			if _, ok := samples[label]; !ok {
+				samples[label] = make([]float64, len(queries))
+			}
+			samples[label][i] = value
+		}
+	}
+	var allocations []*repository.CostAllocation
+	for label, values := range samples {
+		allocation := &repository.CostAllocation{
+			CostDate:           day,
+			ClusterId:          environment.ClusterId,
+			EnvironmentId:      environment.Id,
+			AppLabel:           label,
+			CpuRequestCores:    values[cpuRequestQuery],
+			CpuUsageCores:      values[cpuUsageQuery],
+			MemoryRequestBytes: values[memoryRequestQuery],
+			MemoryUsageBytes:   values[memoryUsageQuery],
+			StorageBytes:       values[storageQuery],
+			CreatedOn:          time.Now(),
+		}
+		if a, ok := appsByName[label]; ok {
+			allocation.AppId = a.Id
+			allocation.TeamId = a.TeamId
+		}
+		applyPrice(allocation, price)
+		allocations = append(allocations, allocation)
+	}
+	sort.Slice(allocations, func(i, j int) bool { return allocations[i].AppLabel < allocations[j].AppLabel })
+	return allocations, nil
+}
+
+func (impl *CostAllocationServiceImpl) queryByAppLabel(prometheusAPI v1.API, query string, ts time.Time) (map[string]float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(impl.config.QueryTimeoutSeconds)*time.Second)
+	defer cancel()
+	out, _, err := prometheusAPI.Query(ctx, query, ts)
+	if err != nil {
+		impl.logger.Errorw("error in prometheus query", "query", query, "err", err)
+		return nil, err
+	}
+	vector, ok := out.(model.Vector)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result type %s of cost query", out.Type())
+	}
+	values := make(map[string]float64)
+	for _, sample := range vector {
+		value := float64(sample.Value)
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		values[string(sample.Metric["label_app"])] += value
+	}
+	return values, nil
+}
+
+// indexes of the values of an app in the result of costQueries
+const (
+	cpuRequestQuery = iota
+	cpuUsageQuery
+	memoryRequestQuery
+	memoryUsageQuery
+	storageQuery
+)
+
+// costQueries returns the queries of the average cpu, memory and storage of the pods of a namespace during the day
+// ending at the evaluation time, grouped by app label. Pods deleted during the day are attributed through the
+// labels they had while they were running
+func costQueries(namespace string) []string {
+	podLabels := fmt.Sprintf("max by (pod, label_app) (max_over_time(kube_pod_labels{namespace='%s'}[1d]))", namespace)
+	byAppLabel := func(podExpr string) string {
+		return fmt.Sprintf("sum by (label_app) (sum_over_time((%s)[1d:%s]) * on (pod) group_left(label_app) %s) / %d",
+			podExpr, costSampleStep, podLabels, samplesPerDay)
+	}
+	containers := fmt.Sprintf("namespace='%s',container!='',container!='POD'", namespace)
+	return []string{
+		cpuRequestQuery:    byAppLabel(fmt.Sprintf("sum by (pod) (kube_pod_container_resource_requests_cpu_cores{namespace='%s'})", namespace)),
+		cpuUsageQuery:      byAppLabel(fmt.Sprintf("sum by (pod) (rate(container_cpu_usage_seconds_total{%s}[5m]))", containers)),
+		memoryRequestQuery: byAppLabel(fmt.Sprintf("sum by (pod) (kube_pod_container_resource_requests_memory_bytes{namespace='%s'})", namespace)),
+		memoryUsageQuery:   byAppLabel(fmt.Sprintf("sum by (pod) (container_memory_working_set_bytes{%s})", containers)),
+		storageQuery: byAppLabel(fmt.Sprintf("sum by (pod) (kube_pod_spec_volumes_persistentvolumeclaims_info{namespace='%s'} * on (persistentvolumeclaim) group_left() max by (persistentvolumeclaim) (kube_persistentvolumeclaim_resource_requests_storage_bytes{namespace='%s'}))",
+			namespace, namespace)),
+	}
+}
+
+// applyPrice charges a day of the higher of request and usage of cpu and memory and of the requested storage
+func applyPrice(allocation *repository.CostAllocation, price *repository.ClusterCostPrice) {
+	cpuCores := math.Max(allocation.CpuRequestCores, allocation.CpuUsageCores)
+	memoryGib := math.Max(allocation.MemoryRequestBytes, allocation.MemoryUsageBytes) / bytesPerGib
+	storageGib := allocation.StorageBytes / bytesPerGib
+	allocation.CpuCost = RoundCost(cpuCores * price.CpuCoreHour * 24)
+	allocation.MemoryCost = RoundCost(memoryGib * price.MemoryGibHour * 24)
+	allocation.StorageCost = RoundCost(storageGib * price.StorageGibHour * 24)
+	allocation.TotalCost = RoundCost(allocation.CpuCost + allocation.MemoryCost + allocation.StorageCost)
+}
+
+func (impl *CostAllocationServiceImpl) GetCostTrend(request *CostTrendRequest) (*CostTrendResponse, error) {
+	names, err := impl.groupNames(request.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+	allocations, err := impl.costAllocationRepository.FindByDateRange(truncateToDay(request.From), truncateToDay(request.To))
+	if err != nil {
+		impl.logger.Errorw("error in fetching cost allocations", "request", request, "err", err)
+		return nil, err
+	}
+	return &CostTrendResponse{
+		GroupBy:  request.GroupBy,
+		Currency: impl.config.Currency,
+		Series:   costTrend(allocations, request.GroupBy, names),
+	}, nil
+}
+
+func (impl *CostAllocationServiceImpl) GetMonthlyReport(month time.Time, groupBy string) (*CostReport, error) {
+	names, err := impl.groupNames(groupBy)
+	if err != nil {
+		return nil, err
+	}
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, -1)
+	allocations, err := impl.costAllocationRepository.FindByDateRange(from, to)
+	if err != nil {
+		impl.logger.Errorw("error in fetching cost allocations", "month", from.Format(costMonthLayout), "err", err)
+		return nil, err
+	}
+	report := costReport(allocations, groupBy, names)
+	report.Month = from.Format(costMonthLayout)
+	report.Currency = impl.config.Currency
+	return report, nil
+}
+
+// groupNames returns the names of the apps, environments or teams by id
+func (impl *CostAllocationServiceImpl) groupNames(groupBy string) (map[int]string, error) {
+	names := make(map[int]string)
+	switch groupBy {
+	case CostGroupByApp:
+		apps, err := impl.appRepository.FindAll()
+		if err != nil && !util.IsErrNoRows(err) {
+			return nil, err
+		}
+		for _, a := range apps {
+			names[a.Id] = a.AppName
+		}
+	case CostGroupByEnvironment:
+		envs, err := impl.environmentRepository.FindAll()
+		if err != nil && !util.IsErrNoRows(err) {
+			return nil, err
+		}
+		for _, environment := range envs {
+			names[environment.Id] = environment.Name
+		}
+	case CostGroupByTeam:
+		teams, err := impl.teamRepository.FindAllActive()
+		if err != nil && !util.IsErrNoRows(err) {
+			return nil, err
+		}
+		for _, t := range teams {
+			names[t.Id] = t.Name
+		}
+	default:
+		return nil, fmt.Errorf("unknown group by %s, expected one of %s", groupBy,
+			strings.Join([]string{CostGroupByApp, CostGroupByEnvironment, CostGroupByTeam}, ", "))
+	}
+	return names, nil
+}
+
+func groupId(allocation *repository.CostAllocation, groupBy string) int {
+	switch groupBy {
+	case CostGroupByEnvironment:
+		return allocation.EnvironmentId
+	case CostGroupByTeam:
+		return allocation.TeamId
+	}
+	return allocation.AppId
+}
+
+func groupName(id int, names map[int]string) string {
+	if id == 0 {
+		return UnallocatedCostName
+	}
+	if name, ok := names[id]; ok {
+		return name
+	}
+	return strconv.Itoa(id)
+}
+
+func costTrend(allocations []*repository.CostAllocation, groupBy string, names map[int]string) []*CostTrendSeries {
+	seriesById := make(map[int]*CostTrendSeries)
+	pointsByDate := make(map[int]map[string]*CostTrendPoint)
+	for _, allocation := range allocations {
+		id := groupId(allocation, groupBy)
+		series, ok := seriesById[id]
+		if !ok {
+			series = &CostTrendSeries{Id: id, Name: groupName(id, names)}
+			seriesById[id] = series
+			pointsByDate[id] = make(map[string]*CostTrendPoint)
+		}
+		date := allocation.CostDate.Format(costDateLayout)
+		point, ok := pointsByDate[id][date]
+		if !ok {
+			point = &CostTrendPoint{Date: date}
+			pointsByDate[id][date] = point
+			series.Points = append(series.Points, point)
+		}
+		point.CpuCost = RoundCost(point.CpuCost + allocation.CpuCost)
+		point.MemoryCost = RoundCost(point.MemoryCost + allocation.MemoryCost)
+		point.StorageCost = RoundCost(point.StorageCost + allocation.StorageCost)
+		point.TotalCost = RoundCost(point.TotalCost + allocation.TotalCost)
+	}
+	series := make([]*CostTrendSeries, 0, len(seriesById))
+	for _, s := range seriesById {
+		sort.Slice(s.Points, func(i, j int) bool { return s.Points[i].Date < s.Points[j].Date })
+		series = append(series, s)
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].Name < series[j].Name })
+	return series
+}
+
+func costReport(allocations []*repository.CostAllocation, groupBy string, names map[int]string) *CostReport {
+	rowsById := make(map[int]*CostReportRow)
+	report := &CostReport{GroupBy: groupBy, Rows: make([]*CostReportRow, 0)}
+	for _, allocation := range allocations {
+		id := groupId(allocation, groupBy)
+		row, ok := rowsById[id]
+		if !ok {
+			row = &CostReportRow{Id: id, Name: groupName(id, names)}
+			rowsById[id] = row
+			report.Rows = append(report.Rows, row)
+		}
+		row.CpuCost = RoundCost(row.CpuCost + allocation.CpuCost)
+		row.MemoryCost = RoundCost(row.MemoryCost + allocation.MemoryCost)
+		row.StorageCost = RoundCost(row.StorageCost + allocation.StorageCost)
+		row.TotalCost = RoundCost(row.TotalCost + allocation.TotalCost)
+		report.TotalCost = RoundCost(report.TotalCost + allocation.TotalCost)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].TotalCost == report.Rows[j].TotalCost {
+			return report.Rows[i].Name < report.Rows[j].Name
+		}
+		return report.Rows[i].TotalCost > report.Rows[j].TotalCost
+	})
+	return report
+}
+
+// RoundCost keeps costs at a hundredth of a cent so sums of many days stay stable
+func RoundCost(cost float64) float64 {
+	return math.Round(cost*10000) / 10000
+}
+
+func truncateToDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ParseCostDate parses a day as yyyy-mm-dd
+func ParseCostDate(value string) (time.Time, error) {
+	return time.Parse(costDateLayout, value)
+}
+
+// ParseCostMonth parses a month as yyyy-mm
+func ParseCostMonth(value string) (time.Time, error) {
+	return time.Parse(costMonthLayout, value)
+}
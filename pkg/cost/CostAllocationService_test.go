@@ -0,0 +1,161 @@
+package cost
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devtron-labs/devtron/internal/sql/repository/app"
+	"github.com/devtron-labs/devtron/internal/util"
+	repository2 "github.com/devtron-labs/devtron/pkg/cluster/repository"
+	"github.com/devtron-labs/devtron/pkg/cost/repository"
+	"github.com/devtron-labs/devtron/pkg/prometheus"
+)
+
+// recordedPrometheus answers the cost queries with responses recorded from a cluster running a labelled app and
+// one unlabelled pod, it fails the test on any other query
+func recordedPrometheus(t *testing.T) *httptest.Server {
+	recordings := []struct {
+		metric string
+		file   string
+	}{
+		{"persistentvolumeclaims_info", "storage.json"},
+		{"kube_pod_container_resource_requests_cpu_cores", "cpu_request.json"},
+		{"container_cpu_usage_seconds_total", "cpu_usage.json"},
+		{"kube_pod_container_resource_requests_memory_bytes", "memory_request.json"},
+		{"container_memory_working_set_bytes", "memory_usage.json"},
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.FormValue("query")
+		if !strings.Contains(query, "namespace='payments-prod'") {
+			t.Errorf("query of unexpected namespace %s", query)
+		}
+		for _, recording := range recordings {
+			if strings.Contains(query, recording.metric) {
+				content, err := ioutil.ReadFile(filepath.Join("testdata", recording.file))
+				if err != nil {
+					t.Fatal(err)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write(content)
+				return
+			}
+		}
+		t.Errorf("unexpected query %s", query)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+}
+
+func TestComputeEnvironmentCost(t *testing.T) {
+	server := recordedPrometheus(t)
+	defer server.Close()
+	prometheusAPI, err := prometheus.ContextByEnv("cost-test-payments-prod", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	impl := &CostAllocationServiceImpl{logger: util.NewSugardLogger(), config: &CostAllocationConfig{QueryTimeoutSeconds: 5}}
+	environment := &repository2.Environment{Id: 3, ClusterId: 1, Name: "payments-prod", Namespace: "payments-prod"}
+	price := &repository.ClusterCostPrice{ClusterId: 1, CpuCoreHour: 0.04, MemoryGibHour: 0.005, StorageGibHour: 0.0002}
+	appsByName := map[string]*app.App{"payments": {Id: 7, AppName: "payments", TeamId: 2}}
+	day := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	allocations, err := impl.computeEnvironmentCost(prometheusAPI, environment, price, appsByName, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allocations) != 2 {
+		t.Fatalf("computeEnvironmentCost() returned %d allocations, want 2", len(allocations))
+	}
+	unallocated, payments := allocations[0], allocations[1]
+	if payments.AppId != 7 || payments.TeamId != 2 || payments.EnvironmentId != 3 || !payments.CostDate.Equal(day) {
+		t.Errorf("payments allocation = %+v", payments)
+	}
+	// usage above request is charged for cpu, the request for memory
+	want := []float64{0.72, 0.12, 0.048, 0.888}
+	if got := []float64{payments.CpuCost, payments.MemoryCost, payments.StorageCost, payments.TotalCost}; !reflect.DeepEqual(got, want) {
+		t.Errorf("payments cost = %v, want %v", got, want)
+	}
+	if unallocated.AppId != 0 || unallocated.AppLabel != "" {
+		t.Errorf("unallocated allocation = %+v", unallocated)
+	}
+	want = []float64{0.24, 0.03, 0, 0.27}
+	if got := []float64{unallocated.CpuCost, unallocated.MemoryCost, unallocated.StorageCost, unallocated.TotalCost}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unallocated cost = %v, want %v", got, want)
+	}
+}
+
+func TestCostReport(t *testing.T) {
+	day1 := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	allocations := []*repository.CostAllocation{
+		{CostDate: day1, EnvironmentId: 1, AppId: 7, TeamId: 2, CpuCost: 1, TotalCost: 1},
+		{CostDate: day1, EnvironmentId: 2, AppId: 8, TeamId: 3, MemoryCost: 2, TotalCost: 2},
+		{CostDate: day2, EnvironmentId: 1, AppId: 7, TeamId: 2, CpuCost: 1.5, StorageCost: 0.5, TotalCost: 2},
+		{CostDate: day2, EnvironmentId: 1, CpuCost: 0.25, TotalCost: 0.25},
+	}
+	teams := map[int]string{2: "payments", 3: "search"}
+
+	report := costReport(allocations, CostGroupByTeam, teams)
+	if report.TotalCost != 5.25 {
+		t.Errorf("report total = %v, want 5.25", report.TotalCost)
+	}
+	want := []*CostReportRow{
+		{Id: 2, Name: "payments", CpuCost: 2.5, StorageCost: 0.5, TotalCost: 3},
+		{Id: 3, Name: "search", MemoryCost: 2, TotalCost: 2},
+		{Id: 0, Name: UnallocatedCostName, CpuCost: 0.25, TotalCost: 0.25},
+	}
+	if !reflect.DeepEqual(report.Rows, want) {
+		for _, row := range report.Rows {
+			t.Logf("row %+v", row)
+		}
+		t.Errorf("costReport() rows differ")
+	}
+
+	series := costTrend(allocations, CostGroupByEnvironment, map[int]string{1: "prod", 2: "staging"})
+	if len(series) != 2 || series[0].Name != "prod" || len(series[0].Points) != 2 {
+		t.Fatalf("costTrend() = %+v", series)
+	}
+	if got := series[0].Points[1]; got.Date != "2026-09-02" || got.TotalCost != 2.25 {
+		t.Errorf("prod trend of second day = %+v", got)
+	}
+}
+
+// blockingPriceRepositoryStub holds a computation until release is closed
+type blockingPriceRepositoryStub struct {
+	repository.ClusterCostPriceRepository
+	started chan bool
+	release chan bool
+}
+
+func (impl blockingPriceRepositoryStub) FindAllActive() ([]*repository.ClusterCostPrice, error) {
+	impl.started <- true
+	<-impl.release
+	return nil, nil
+}
+
+func TestStartComputeDailyCost(t *testing.T) {
+	prices := blockingPriceRepositoryStub{started: make(chan bool, 1), release: make(chan bool)}
+	impl := &CostAllocationServiceImpl{logger: util.NewSugardLogger(), clusterCostPriceRepository: prices}
+	day := time.Date(2021, 3, 10, 0, 0, 0, 0, time.UTC)
+	if !impl.StartComputeDailyCost(day) {
+		t.Fatal("StartComputeDailyCost() did not start")
+	}
+	<-prices.started
+	if impl.StartComputeDailyCost(day) {
+		t.Error("StartComputeDailyCost() started while another computation is running")
+	}
+	close(prices.release)
+	restarted := false
+	for i := 0; i < 100 && !restarted; i++ {
+		time.Sleep(10 * time.Millisecond)
+		restarted = impl.StartComputeDailyCost(day)
+	}
+	if !restarted {
+		t.Error("StartComputeDailyCost() did not start after the running computation finished")
+	}
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+)
+
+// ClusterCostPrice holds the unit prices of a cluster, memory and storage are priced per GiB
+type ClusterCostPrice struct {
+	TableName      struct{} `sql:"cluster_cost_price" pg:",discard_unknown_columns"`
+	Id             int      `sql:"id,pk"`
+	ClusterId      int      `sql:"cluster_id,notnull"`
+	CpuCoreHour    float64  `sql:"cpu_core_hour,notnull"`
+	MemoryGibHour  float64  `sql:"memory_gib_hour,notnull"`
+	StorageGibHour float64  `sql:"storage_gib_hour,notnull"`
+	Active         bool     `sql:"active,notnull"`
+	sql.AuditLog
+}
+
+type ClusterCostPriceRepository interface {
+	Save(model *ClusterCostPrice) error
+	Update(model *ClusterCostPrice) error
+	FindActiveByClusterId(clusterId int) (*ClusterCostPrice, error)
+	FindAllActive() ([]*ClusterCostPrice, error)
+}
+
+type ClusterCostPriceRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func NewClusterCostPriceRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *ClusterCostPriceRepositoryImpl {
+	return &ClusterCostPriceRepositoryImpl{
+		dbConnection: dbConnection,
+		logger:       logger,
+	}
+}
+
+func (impl ClusterCostPriceRepositoryImpl) Save(model *ClusterCostPrice) error {
+	return impl.dbConnection.Insert(model)
+}
+
+func (impl ClusterCostPriceRepositoryImpl) Update(model *ClusterCostPrice) error {
+	return impl.dbConnection.Update(model)
+}
+
+func (impl ClusterCostPriceRepositoryImpl) FindActiveByClusterId(clusterId int) (*ClusterCostPrice, error) {
+	model := &ClusterCostPrice{}
+	err := impl.dbConnection.Model(model).
+		Where("cluster_id = ?", clusterId).
+		Where("active = ?", true).
+		Select()
+	return model, err
+}
+
+func (impl ClusterCostPriceRepositoryImpl) FindAllActive() ([]*ClusterCostPrice, error) {
+	var models []*ClusterCostPrice
+	err := impl.dbConnection.Model(&models).
+		Where("active = ?", true).
+		Select()
+	return models, err
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"time"
+)
+
+// CostAllocation is the cost of one app in one environment for a day, pods without an app label are kept with an
+// empty AppLabel and AppId 0. Cpu is in cores and memory and storage in bytes, all averaged over the day
+type CostAllocation struct {
+	TableName          struct{}  `sql:"cost_allocation" pg:",discard_unknown_columns"`
+	Id                 int       `sql:"id,pk"`
+	CostDate           time.Time `sql:"cost_date,notnull"`
+	ClusterId          int       `sql:"cluster_id,notnull"`
+	EnvironmentId      int       `sql:"environment_id,notnull"`
+	AppId              int       `sql:"app_id,notnull"`
+	TeamId             int       `sql:"team_id,notnull"`
+	AppLabel           string    `sql:"app_label,notnull"`
+	CpuRequestCores    float64   `sql:"cpu_request_cores,notnull"`
+	CpuUsageCores      float64   `sql:"cpu_usage_cores,notnull"`
+	MemoryRequestBytes float64   `sql:"memory_request_bytes,notnull"`
+	MemoryUsageBytes   float64   `sql:"memory_usage_bytes,notnull"`
+	StorageBytes       float64   `sql:"storage_bytes,notnull"`
+	CpuCost            float64   `sql:"cpu_cost,notnull"`
+	MemoryCost         float64   `sql:"memory_cost,notnull"`
+	StorageCost        float64   `sql:"storage_cost,notnull"`
+	TotalCost          float64   `sql:"total_cost,notnull"`
+	CreatedOn          time.Time `sql:"created_on,notnull"`
+}
+
+type CostAllocationRepository interface {
+	// ReplaceForEnvironment swaps the allocations of an environment for a day, so a day can be computed again
+	ReplaceForEnvironment(costDate time.Time, environmentId int, models []*CostAllocation) error
+	FindByDateRange(from time.Time, to time.Time) ([]*CostAllocation, error)
+}
+
+type CostAllocationRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func NewCostAllocationRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *CostAllocationRepositoryImpl {
+	return &CostAllocationRepositoryImpl{
+		dbConnection: dbConnection,
+		logger:       logger,
+	}
+}
+
+func (impl CostAllocationRepositoryImpl) ReplaceForEnvironment(costDate time.Time, environmentId int, models []*CostAllocation) error {
+	tx, err := impl.dbConnection.Begin()
+	if err != nil {
+		return err
+	}
+	// Rollback tx on error.
+	defer tx.Rollback()
+	_, err = tx.Model((*CostAllocation)(nil)).
+		Where("cost_date = ?", costDate).
+		Where("environment_id = ?", environmentId).
+		Delete()
+	if err != nil {
+		impl.logger.Errorw("error in deleting cost allocations", "costDate", costDate, "environmentId", environmentId, "err", err)
+		return err
+	}
+	if len(models) > 0 {
+		err = tx.Insert(&models)
+		if err != nil {
+			impl.logger.Errorw("error in saving cost allocations", "costDate", costDate, "environmentId", environmentId, "err", err)
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// FindByDateRange returns the allocations of the days from and to, both inclusive
+func (impl CostAllocationRepositoryImpl) FindByDateRange(from time.Time, to time.Time) ([]*CostAllocation, error) {
+	var models []*CostAllocation
+	err := impl.dbConnection.Model(&models).
+		Where("cost_date >= ?", from).
+		Where("cost_date <= ?", to).
+		Order("cost_date ASC").
+		Select()
+	return models, err
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/devtron-labs/devtron/internal/sql/repository/pipelineConfig"
 	"github.com/devtron-labs/devtron/pkg/bean"
 	"github.com/devtron-labs/devtron/pkg/pipeline"
+	"github.com/devtron-labs/devtron/pkg/previewEnvironment"
 	"go.uber.org/zap"
 )
 
@@ -31,16 +32,19 @@ type GitWebhookService interface {
 }
 
 type GitWebhookServiceImpl struct {
-	logger               *zap.SugaredLogger
-	ciHandler            pipeline.CiHandler
-	gitWebhookRepository repository.GitWebhookRepository
+	logger                    *zap.SugaredLogger
+	ciHandler                 pipeline.CiHandler
+	gitWebhookRepository      repository.GitWebhookRepository
+	previewEnvironmentService previewEnvironment.PreviewEnvironmentService
 }
 
-func NewGitWebhookServiceImpl(Logger *zap.SugaredLogger, ciHandler pipeline.CiHandler, gitWebhookRepository repository.GitWebhookRepository) *GitWebhookServiceImpl {
+func NewGitWebhookServiceImpl(Logger *zap.SugaredLogger, ciHandler pipeline.CiHandler, gitWebhookRepository repository.GitWebhookRepository,
+	previewEnvironmentService previewEnvironment.PreviewEnvironmentService) *GitWebhookServiceImpl {
 	return &GitWebhookServiceImpl{
-		logger:               Logger,
-		ciHandler:            ciHandler,
-		gitWebhookRepository: gitWebhookRepository,
+		logger:                    Logger,
+		ciHandler:                 ciHandler,
+		gitWebhookRepository:      gitWebhookRepository,
+		previewEnvironmentService: previewEnvironmentService,
 	}
 }
 
@@ -67,6 +71,11 @@ func (impl *GitWebhookServiceImpl) HandleGitWebhook(gitWebhookRequest gitSensor.
 			EventActionType: webhookData.EventActionType,
 			Data:            webhookData.Data,
 		}
+		// the preview pipeline has to exist before the build of the pull request completes
+		err := impl.previewEnvironmentService.HandleWebhook(ciPipelineMaterial.Id, ciPipelineMaterial.GitCommit.WebhookData)
+		if err != nil {
+			impl.logger.Errorw("failed to handle preview environment for webhook", "ciPipelineMaterialId", ciPipelineMaterial.Id, "err", err)
+		}
 	}
 
 	resp, err := impl.ciHandler.HandleCIWebhook(bean.GitCiTriggerRequest{
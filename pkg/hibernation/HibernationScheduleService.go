@@ -0,0 +1,742 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hibernation
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/caarlos0/env"
+	"github.com/devtron-labs/devtron/internal/sql/models"
+	"github.com/devtron-labs/devtron/internal/sql/repository/app"
+	"github.com/devtron-labs/devtron/internal/sql/repository/chartConfig"
+	"github.com/devtron-labs/devtron/internal/sql/repository/pipelineConfig"
+	repository2 "github.com/devtron-labs/devtron/pkg/cluster/repository"
+	"github.com/devtron-labs/devtron/pkg/cost"
+	repository3 "github.com/devtron-labs/devtron/pkg/cost/repository"
+	"github.com/devtron-labs/devtron/pkg/hibernation/repository"
+	"github.com/devtron-labs/devtron/pkg/pipeline"
+	"github.com/devtron-labs/devtron/pkg/sql"
+	util3 "github.com/devtron-labs/devtron/pkg/util"
+	"github.com/robfig/cron/v3"
+	"github.com/satori/go.uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	systemUserId = 1
+	// scheduleLookback bounds the search for the last sleep and wake times, a week covers weekday and weekend schedules
+	scheduleLookback = 8 * 24 * time.Hour
+)
+
+type HibernationConfig struct {
+	SchedulerCronExpr string `env:"HIBERNATION_SCHEDULER_INTERVAL" envDefault:"@every 1m"`
+	// SchedulerLockSeconds is how long the replica running the scheduler keeps it before another replica may take
+	// over, it has to be longer than the scheduler interval so that the running replica renews it in time
+	SchedulerLockSeconds int `env:"HIBERNATION_SCHEDULER_LOCK_SECONDS" envDefault:"180"`
+}
+
+// HibernationScheduleDto hibernates the apps of an environment every time SleepCron fires and wakes them when WakeCron
+// fires, e.g. "0 20 * * 1-5" and "0 8 * * 1-5" keep them down every night and over the weekend. AppId 0 applies the
+// schedule to every app of the environment except ExcludedAppIds
+type HibernationScheduleDto struct {
+	Id              int        `json:"id"`
+	Name            string     `json:"name" validate:"required,max=250"`
+	EnvironmentId   int        `json:"environmentId" validate:"number,required"`
+	EnvironmentName string     `json:"environmentName,omitempty"`
+	AppId           int        `json:"appId" validate:"min=0"`
+	SleepCron       string     `json:"sleepCron" validate:"required,max=100"`
+	WakeCron        string     `json:"wakeCron" validate:"required,max=100"`
+	Timezone        string     `json:"timezone" validate:"max=100"`
+	ExcludedAppIds  []int      `json:"excludedAppIds"`
+	KeepAwakeUntil  *time.Time `json:"keepAwakeUntil,omitempty"`
+	Asleep          bool       `json:"asleep"`
+	UserId          int32      `json:"-"`
+}
+
+type KeepAwakeRequest struct {
+	Until time.Time `json:"until" validate:"required"`
+}
+
+type HibernationSavingsRow struct {
+	AppId            int     `json:"appId"`
+	AppName          string  `json:"appName"`
+	EnvironmentId    int     `json:"environmentId"`
+	EnvironmentName  string  `json:"environmentName"`
+	HibernatedHours  float64 `json:"hibernatedHours"`
+	Cost             float64 `json:"cost"`
+	EstimatedSavings float64 `json:"estimatedSavings"`
+}
+
+// HibernationSavingsReport estimates what hibernation saved between two days, both inclusive. The cost an app had
+// per awake hour is assumed for every hour it was hibernated, apps without cost allocations report no savings
+type HibernationSavingsReport struct {
+	From                  string                   `json:"from"`
+	To                    string                   `json:"to"`
+	Currency              string                   `json:"currency"`
+	TotalHibernatedHours  float64                  `json:"totalHibernatedHours"`
+	TotalEstimatedSavings float64                  `json:"totalEstimatedSavings"`
+	Rows                  []*HibernationSavingsRow `json:"rows"`
+}
+
+// HibernationScheduleService hibernates and wakes apps on schedule with the same stop and start deployments as a
+// manual hibernate. Only apps hibernated by a schedule are woken by it, apps which were already hibernated or were
+// started by hand in the meantime are left as they are
+type HibernationScheduleService interface {
+	ApplySchedules()
+
+	SaveSchedule(request *HibernationScheduleDto) (*HibernationScheduleDto, error)
+	GetSchedules(environmentId int) ([]*HibernationScheduleDto, error)
+	GetSchedule(id int) (*HibernationScheduleDto, error)
+	DeleteSchedule(id int, userId int32) error
+	KeepAwake(id int, until time.Time, userId int32) (*HibernationScheduleDto, error)
+	GetSavings(from time.Time, to time.Time) (*HibernationSavingsReport, error)
+}
+
+type HibernationScheduleServiceImpl struct {
+	logger                        *zap.SugaredLogger
+	config                        *HibernationConfig
+	lockHolder                    string
+	currency                      string
+	hibernationScheduleRepository repository.HibernationScheduleRepository
+	hibernationEventRepository    repository.HibernationEventRepository
+	costAllocationRepository      repository3.CostAllocationRepository
+	pipelineRepository            pipelineConfig.PipelineRepository
+	pipelineOverrideRepository    chartConfig.PipelineOverrideRepository
+	environmentRepository         repository2.EnvironmentRepository
+	appRepository                 app.AppRepository
+	workflowDagExecutor           pipeline.WorkflowDagExecutor
+	tokenCache                    *util3.TokenCache
+}
+
+func NewHibernationScheduleServiceImpl(logger *zap.SugaredLogger,
+	hibernationScheduleRepository repository.HibernationScheduleRepository,
+	hibernationEventRepository repository.HibernationEventRepository,
+	costAllocationRepository repository3.CostAllocationRepository,
+	pipelineRepository pipelineConfig.PipelineRepository,
+	pipelineOverrideRepository chartConfig.PipelineOverrideRepository,
+	environmentRepository repository2.EnvironmentRepository, appRepository app.AppRepository,
+	workflowDagExecutor pipeline.WorkflowDagExecutor, tokenCache *util3.TokenCache) (*HibernationScheduleServiceImpl, error) {
+	config := &HibernationConfig{}
+	err := env.Parse(config)
+	if err != nil {
+		logger.Errorw("error in parsing hibernation config", "err", err)
+		return nil, err
+	}
+	costConfig := &cost.CostAllocationConfig{}
+	err = env.Parse(costConfig)
+	if err != nil {
+		logger.Errorw("error in parsing cost allocation config", "err", err)
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	impl := &HibernationScheduleServiceImpl{
+		logger:                        logger,
+		config:                        config,
+		lockHolder:                    fmt.Sprintf("%s-%s", hostname, uuid.NewV4().String()),
+		currency:                      costConfig.Currency,
+		hibernationScheduleRepository: hibernationScheduleRepository,
+		hibernationEventRepository:    hibernationEventRepository,
+		costAllocationRepository:      costAllocationRepository,
+		pipelineRepository:            pipelineRepository,
+		pipelineOverrideRepository:    pipelineOverrideRepository,
+		environmentRepository:         environmentRepository,
+		appRepository:                 appRepository,
+		workflowDagExecutor:           workflowDagExecutor,
+		tokenCache:                    tokenCache,
+	}
+	cron := cron.New(
+		cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)))
+	cron.Start()
+	_, err = cron.AddFunc(config.SchedulerCronExpr, impl.applySchedulesIfLockHolder)
+	if err != nil {
+		logger.Errorw("error in starting hibernation scheduler cron", "err", err)
+		return nil, err
+	}
+	return impl, nil
+}
+
+// applySchedulesIfLockHolder runs the scheduler on one replica only, every replica runs the cron but only the one
+// holding the scheduler lock applies the schedules, so apps are not hibernated and recorded once per replica
+func (impl *HibernationScheduleServiceImpl) applySchedulesIfLockHolder() {
+	acquired, err := impl.hibernationScheduleRepository.AcquireSchedulerLock(impl.lockHolder, time.Duration(impl.config.SchedulerLockSeconds)*time.Second)
+	if err != nil {
+		impl.logger.Errorw("error in acquiring hibernation scheduler lock", "err", err)
+		return
+	}
+	if !acquired {
+		impl.logger.Debugw("hibernation scheduler lock held by another replica, skipping run")
+		return
+	}
+	impl.ApplySchedules()
+}
+
+// ApplySchedules brings every app under a schedule to the state the schedule wants it in right now
+func (impl *HibernationScheduleServiceImpl) ApplySchedules() {
+	schedules, err := impl.hibernationScheduleRepository.FindAllActive()
+	if err != nil {
+		impl.logger.Errorw("error in fetching hibernation schedules", "err", err)
+		return
+	}
+	if len(schedules) == 0 {
+		return
+	}
+	ctx, err := impl.tokenCache.BuildACDSynchContext()
+	if err != nil {
+		impl.logger.Errorw("error in creating acd synch context", "err", err)
+		return
+	}
+	now := time.Now()
+	for _, schedule := range schedules {
+		err = impl.applySchedule(schedule, now, ctx)
+		if err != nil {
+			impl.logger.Errorw("error in applying hibernation schedule", "scheduleId", schedule.Id, "err", err)
+		}
+	}
+}
+
+func (impl *HibernationScheduleServiceImpl) applySchedule(schedule *repository.HibernationSchedule, now time.Time, ctx context.Context) error {
+	asleep, err := isScheduleAsleep(schedule, now)
+	if err != nil {
+		return err
+	}
+	openEvents, err := impl.hibernationEventRepository.FindOpenByScheduleId(schedule.Id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching open hibernation events", "scheduleId", schedule.Id, "err", err)
+		return err
+	}
+	hibernated := make(map[int]*repository.HibernationEvent)
+	for _, event := range openEvents {
+		hibernated[event.AppId] = event
+	}
+	inScope := make(map[int]*pipelineConfig.Pipeline)
+	if asleep {
+		inScope, err = impl.pipelinesInScope(schedule)
+		if err != nil {
+			return err
+		}
+	}
+	for appId, event := range hibernated {
+		if _, ok := inScope[appId]; !ok {
+			impl.wakeApp(event, now, ctx)
+		}
+	}
+	if !asleep {
+		return nil
+	}
+	var pipelineIds []int
+	for appId, p := range inScope {
+		if _, ok := hibernated[appId]; !ok {
+			pipelineIds = append(pipelineIds, p.Id)
+		}
+	}
+	if len(pipelineIds) == 0 {
+		return nil
+	}
+	deploymentTypes, err := impl.latestDeploymentTypes(pipelineIds)
+	if err != nil {
+		return err
+	}
+	for appId, p := range inScope {
+		if _, ok := hibernated[appId]; ok {
+			continue
+		}
+		deploymentType, ok := deploymentTypes[p.Id]
+		if !ok || deploymentType == models.DEPLOYMENTTYPE_STOP {
+			// never deployed or hibernated by hand, either way there is nothing to hibernate or to wake later
+			continue
+		}
+		impl.hibernateApp(schedule, appId, now, ctx)
+	}
+	return nil
+}
+
+// pipelinesInScope returns the cd pipeline of every app the schedule covers by app id
+func (impl *HibernationScheduleServiceImpl) pipelinesInScope(schedule *repository.HibernationSchedule) (map[int]*pipelineConfig.Pipeline, error) {
+	var pipelines []*pipelineConfig.Pipeline
+	var err error
+	if schedule.AppId > 0 {
+		pipelines, err = impl.pipelineRepository.FindActiveByAppIdAndEnvironmentId(schedule.AppId, schedule.EnvironmentId)
+	} else {
+		pipelines, err = impl.pipelineRepository.FindActiveByEnvId(schedule.EnvironmentId)
+	}
+	if err != nil {
+		impl.logger.Errorw("error in fetching pipelines of hibernation schedule", "scheduleId", schedule.Id, "err", err)
+		return nil, err
+	}
+	excluded := make(map[int]bool)
+	for _, appId := range schedule.ExcludedAppIds {
+		excluded[appId] = true
+	}
+	inScope := make(map[int]*pipelineConfig.Pipeline)
+	for _, p := range pipelines {
+		if _, ok := inScope[p.AppId]; ok || excluded[p.AppId] {
+			continue
+		}
+		inScope[p.AppId] = p
+	}
+	return inScope, nil
+}
+
+// latestDeploymentTypes returns the type of the last deployment of each pipeline, pipelines never deployed are absent
+func (impl *HibernationScheduleServiceImpl) latestDeploymentTypes(pipelineIds []int) (map[int]models.DeploymentType, error) {
+	overrides, err := impl.pipelineOverrideRepository.GetLatestReleaseDeploymentType(pipelineIds)
+	if err != nil {
+		impl.logger.Errorw("error in fetching latest deployment types", "pipelineIds", pipelineIds, "err", err)
+		return nil, err
+	}
+	deploymentTypes := make(map[int]models.DeploymentType)
+	// overrides come latest first
+	for _, override := range overrides {
+		if _, ok := deploymentTypes[override.PipelineId]; !ok {
+			deploymentTypes[override.PipelineId] = override.DeploymentType
+		}
+	}
+	return deploymentTypes, nil
+}
+
+func (impl *HibernationScheduleServiceImpl) hibernateApp(schedule *repository.HibernationSchedule, appId int, now time.Time, ctx context.Context) {
+	_, err := impl.workflowDagExecutor.StopStartApp(&pipeline.StopAppRequest{
+		AppId:         appId,
+		EnvironmentId: schedule.EnvironmentId,
+		UserId:        systemUserId,
+		RequestType:   pipeline.STOP,
+	}, ctx)
+	if err != nil {
+		impl.logger.Errorw("error in hibernating app", "scheduleId", schedule.Id, "appId", appId, "envId", schedule.EnvironmentId, "err", err)
+		return
+	}
+	event := &repository.HibernationEvent{
+		ScheduleId:    schedule.Id,
+		AppId:         appId,
+		EnvironmentId: schedule.EnvironmentId,
+		HibernatedOn:  now,
+	}
+	err = impl.hibernationEventRepository.Save(event)
+	if err != nil {
+		impl.logger.Errorw("error in saving hibernation event", "scheduleId", schedule.Id, "appId", appId, "err", err)
+		return
+	}
+	impl.logger.Infow("app hibernated by schedule", "scheduleId", schedule.Id, "appId", appId, "envId", schedule.EnvironmentId)
+}
+
+// wakeApp starts an app hibernated by a schedule unless it was deployed since, the event is kept open when starting
+// fails so that the next run tries again. An app deployed by hand in the meantime was awake from that deployment on,
+// so the event is closed at its time rather than now
+func (impl *HibernationScheduleServiceImpl) wakeApp(event *repository.HibernationEvent, now time.Time, ctx context.Context) {
+	pipelines, err := impl.pipelineRepository.FindActiveByAppIdAndEnvironmentId(event.AppId, event.EnvironmentId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching pipelines", "appId", event.AppId, "envId", event.EnvironmentId, "err", err)
+		return
+	}
+	wokenOn := now
+	if len(pipelines) > 0 {
+		override, err := impl.latestDeployment(pipelines[0].Id)
+		if err != nil {
+			return
+		}
+		if override != nil && override.DeploymentType == models.DEPLOYMENTTYPE_STOP {
+			_, err = impl.workflowDagExecutor.StopStartApp(&pipeline.StopAppRequest{
+				AppId:         event.AppId,
+				EnvironmentId: event.EnvironmentId,
+				UserId:        systemUserId,
+				RequestType:   pipeline.START,
+			}, ctx)
+			if err != nil {
+				impl.logger.Errorw("error in waking app", "scheduleId", event.ScheduleId, "appId", event.AppId, "envId", event.EnvironmentId, "err", err)
+				return
+			}
+			impl.logger.Infow("app woken by schedule", "scheduleId", event.ScheduleId, "appId", event.AppId, "envId", event.EnvironmentId)
+		} else if override != nil {
+			wokenOn = manualWakeTime(event, override, now)
+		}
+	}
+	event.WokenOn = wokenOn
+	err = impl.hibernationEventRepository.Update(event)
+	if err != nil {
+		impl.logger.Errorw("error in updating hibernation event", "eventId", event.Id, "err", err)
+	}
+}
+
+// latestDeployment returns the last deployment of a pipeline with its creation time, nil if it was never deployed
+func (impl *HibernationScheduleServiceImpl) latestDeployment(pipelineId int) (*chartConfig.PipelineOverride, error) {
+	overrides, err := impl.pipelineOverrideRepository.GetLatestReleaseDeploymentType([]int{pipelineId})
+	if err != nil {
+		impl.logger.Errorw("error in fetching latest deployment types", "pipelineId", pipelineId, "err", err)
+		return nil, err
+	}
+	if len(overrides) == 0 {
+		return nil, nil
+	}
+	// overrides come latest first but only carry the id and type
+	override, err := impl.pipelineOverrideRepository.FindById(overrides[0].Id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching latest deployment", "pipelineId", pipelineId, "overrideId", overrides[0].Id, "err", err)
+		return nil, err
+	}
+	return override, nil
+}
+
+// manualWakeTime is when an app hibernated by a schedule was deployed again by hand, bounded by the hibernation and
+// now in case the deployment time is missing or skewed
+func manualWakeTime(event *repository.HibernationEvent, override *chartConfig.PipelineOverride, now time.Time) time.Time {
+	if override.CreatedOn.IsZero() || override.CreatedOn.After(now) {
+		return now
+	}
+	if override.CreatedOn.Before(event.HibernatedOn) {
+		return event.HibernatedOn
+	}
+	return override.CreatedOn
+}
+
+func (impl *HibernationScheduleServiceImpl) SaveSchedule(request *HibernationScheduleDto) (*HibernationScheduleDto, error) {
+	if len(request.Timezone) == 0 {
+		request.Timezone = "UTC"
+	}
+	_, err := parseSchedule(request.SleepCron, request.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sleep cron: %s", err.Error())
+	}
+	_, err = parseSchedule(request.WakeCron, request.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wake cron: %s", err.Error())
+	}
+	_, err = impl.environmentRepository.FindById(request.EnvironmentId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching environment", "envId", request.EnvironmentId, "err", err)
+		return nil, err
+	}
+	model := &repository.HibernationSchedule{}
+	if request.Id > 0 {
+		model, err = impl.hibernationScheduleRepository.FindActiveById(request.Id)
+		if err != nil {
+			impl.logger.Errorw("error in fetching hibernation schedule", "id", request.Id, "err", err)
+			return nil, err
+		}
+	} else {
+		model.Active = true
+		model.AuditLog = sql.AuditLog{CreatedOn: time.Now(), CreatedBy: request.UserId}
+	}
+	model.Name = request.Name
+	model.EnvironmentId = request.EnvironmentId
+	model.AppId = request.AppId
+	model.SleepCron = request.SleepCron
+	model.WakeCron = request.WakeCron
+	model.Timezone = request.Timezone
+	model.ExcludedAppIds = request.ExcludedAppIds
+	model.UpdatedOn = time.Now()
+	model.UpdatedBy = request.UserId
+	if model.Id > 0 {
+		err = impl.hibernationScheduleRepository.Update(model)
+	} else {
+		err = impl.hibernationScheduleRepository.Save(model)
+	}
+	if err != nil {
+		impl.logger.Errorw("error in saving hibernation schedule", "schedule", model, "err", err)
+		return nil, err
+	}
+	return impl.GetSchedule(model.Id)
+}
+
+func (impl *HibernationScheduleServiceImpl) GetSchedules(environmentId int) ([]*HibernationScheduleDto, error) {
+	var schedules []*repository.HibernationSchedule
+	var err error
+	if environmentId > 0 {
+		schedules, err = impl.hibernationScheduleRepository.FindActiveByEnvironmentId(environmentId)
+	} else {
+		schedules, err = impl.hibernationScheduleRepository.FindAllActive()
+	}
+	if err != nil {
+		impl.logger.Errorw("error in fetching hibernation schedules", "envId", environmentId, "err", err)
+		return nil, err
+	}
+	environmentNames, err := impl.environmentNames()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	result := make([]*HibernationScheduleDto, 0)
+	for _, schedule := range schedules {
+		result = append(result, toDto(schedule, environmentNames[schedule.EnvironmentId], now))
+	}
+	return result, nil
+}
+
+func (impl *HibernationScheduleServiceImpl) GetSchedule(id int) (*HibernationScheduleDto, error) {
+	schedule, err := impl.hibernationScheduleRepository.FindActiveById(id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching hibernation schedule", "id", id, "err", err)
+		return nil, err
+	}
+	environment, err := impl.environmentRepository.FindById(schedule.EnvironmentId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching environment", "envId", schedule.EnvironmentId, "err", err)
+		return nil, err
+	}
+	return toDto(schedule, environment.Name, time.Now()), nil
+}
+
+// DeleteSchedule deactivates a schedule and wakes the apps it hibernated
+func (impl *HibernationScheduleServiceImpl) DeleteSchedule(id int, userId int32) error {
+	schedule, err := impl.hibernationScheduleRepository.FindActiveById(id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching hibernation schedule", "id", id, "err", err)
+		return err
+	}
+	schedule.Active = false
+	schedule.UpdatedOn = time.Now()
+	schedule.UpdatedBy = userId
+	err = impl.hibernationScheduleRepository.Update(schedule)
+	if err != nil {
+		impl.logger.Errorw("error in deleting hibernation schedule", "id", id, "err", err)
+		return err
+	}
+	return impl.wakeAll(schedule)
+}
+
+// KeepAwake suspends a schedule until the given time, the apps it hibernated are woken right away
+func (impl *HibernationScheduleServiceImpl) KeepAwake(id int, until time.Time, userId int32) (*HibernationScheduleDto, error) {
+	schedule, err := impl.hibernationScheduleRepository.FindActiveById(id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching hibernation schedule", "id", id, "err", err)
+		return nil, err
+	}
+	schedule.KeepAwakeUntil = until
+	schedule.UpdatedOn = time.Now()
+	schedule.UpdatedBy = userId
+	err = impl.hibernationScheduleRepository.Update(schedule)
+	if err != nil {
+		impl.logger.Errorw("error in updating hibernation schedule", "id", id, "err", err)
+		return nil, err
+	}
+	if until.After(time.Now()) {
+		err = impl.wakeAll(schedule)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return impl.GetSchedule(id)
+}
+
+func (impl *HibernationScheduleServiceImpl) wakeAll(schedule *repository.HibernationSchedule) error {
+	openEvents, err := impl.hibernationEventRepository.FindOpenByScheduleId(schedule.Id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching open hibernation events", "scheduleId", schedule.Id, "err", err)
+		return err
+	}
+	if len(openEvents) == 0 {
+		return nil
+	}
+	ctx, err := impl.tokenCache.BuildACDSynchContext()
+	if err != nil {
+		impl.logger.Errorw("error in creating acd synch context", "err", err)
+		return err
+	}
+	now := time.Now()
+	for _, event := range openEvents {
+		impl.wakeApp(event, now, ctx)
+	}
+	return nil
+}
+
+func (impl *HibernationScheduleServiceImpl) GetSavings(from time.Time, to time.Time) (*HibernationSavingsReport, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("from %s is after to %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+	start := from
+	end := to.AddDate(0, 0, 1)
+	if now := time.Now(); end.After(now) {
+		end = now
+	}
+	events, err := impl.hibernationEventRepository.FindOverlapping(start, end)
+	if err != nil {
+		impl.logger.Errorw("error in fetching hibernation events", "from", from, "to", to, "err", err)
+		return nil, err
+	}
+	allocations, err := impl.costAllocationRepository.FindByDateRange(from, to)
+	if err != nil {
+		impl.logger.Errorw("error in fetching cost allocations", "from", from, "to", to, "err", err)
+		return nil, err
+	}
+	report := hibernationSavings(events, allocations, start, end)
+	report.From = from.Format("2006-01-02")
+	report.To = to.Format("2006-01-02")
+	report.Currency = impl.currency
+	environmentNames, err := impl.environmentNames()
+	if err != nil {
+		return nil, err
+	}
+	var appIds []*int
+	for _, row := range report.Rows {
+		appId := row.AppId
+		appIds = append(appIds, &appId)
+	}
+	appNames := make(map[int]string)
+	if len(appIds) > 0 {
+		apps, err := impl.appRepository.FindByIds(appIds)
+		if err != nil {
+			impl.logger.Errorw("error in fetching apps", "err", err)
+			return nil, err
+		}
+		for _, a := range apps {
+			appNames[a.Id] = a.AppName
+		}
+	}
+	for _, row := range report.Rows {
+		row.AppName = appNames[row.AppId]
+		row.EnvironmentName = environmentNames[row.EnvironmentId]
+	}
+	return report, nil
+}
+
+func (impl *HibernationScheduleServiceImpl) environmentNames() (map[int]string, error) {
+	environments, err := impl.environmentRepository.FindAll()
+	if err != nil {
+		impl.logger.Errorw("error in fetching environments", "err", err)
+		return nil, err
+	}
+	names := make(map[int]string)
+	for _, environment := range environments {
+		names[environment.Id] = environment.Name
+	}
+	return names, nil
+}
+
+func toDto(schedule *repository.HibernationSchedule, environmentName string, now time.Time) *HibernationScheduleDto {
+	dto := &HibernationScheduleDto{
+		Id:              schedule.Id,
+		Name:            schedule.Name,
+		EnvironmentId:   schedule.EnvironmentId,
+		EnvironmentName: environmentName,
+		AppId:           schedule.AppId,
+		SleepCron:       schedule.SleepCron,
+		WakeCron:        schedule.WakeCron,
+		Timezone:        schedule.Timezone,
+		ExcludedAppIds:  schedule.ExcludedAppIds,
+	}
+	if !schedule.KeepAwakeUntil.IsZero() {
+		keepAwakeUntil := schedule.KeepAwakeUntil
+		dto.KeepAwakeUntil = &keepAwakeUntil
+	}
+	dto.Asleep, _ = isScheduleAsleep(schedule, now)
+	return dto
+}
+
+// isScheduleAsleep tells whether the apps of a schedule should be hibernated at the given time, that is when the
+// schedule last went to sleep after it last woke up and it is not kept awake
+func isScheduleAsleep(schedule *repository.HibernationSchedule, now time.Time) (bool, error) {
+	if schedule.KeepAwakeUntil.After(now) {
+		return false, nil
+	}
+	sleep, err := parseSchedule(schedule.SleepCron, schedule.Timezone)
+	if err != nil {
+		return false, err
+	}
+	wake, err := parseSchedule(schedule.WakeCron, schedule.Timezone)
+	if err != nil {
+		return false, err
+	}
+	lastSleep := lastFireTime(sleep, now)
+	if lastSleep.IsZero() {
+		return false, nil
+	}
+	return lastSleep.After(lastFireTime(wake, now)), nil
+}
+
+func parseSchedule(expr string, timezone string) (cron.Schedule, error) {
+	if len(timezone) > 0 {
+		_, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, err
+		}
+		expr = fmt.Sprintf("CRON_TZ=%s %s", timezone, expr)
+	}
+	return cron.ParseStandard(expr)
+}
+
+// lastFireTime returns the last time the schedule fired up to now, zero if it did not fire within the lookback
+func lastFireTime(schedule cron.Schedule, now time.Time) time.Time {
+	var last time.Time
+	for next := schedule.Next(now.Add(-scheduleLookback)); !next.IsZero() && !next.After(now); next = schedule.Next(next) {
+		last = next
+	}
+	return last
+}
+
+// hibernationSavings sums the hibernated hours of every app in every environment between start and end, and
+// estimates the savings from the cost the app had while it was awake
+func hibernationSavings(events []*repository.HibernationEvent, allocations []*repository3.CostAllocation, start time.Time, end time.Time) *HibernationSavingsReport {
+	type appEnv struct {
+		appId int
+		envId int
+	}
+	hibernated := make(map[appEnv]time.Duration)
+	for _, event := range events {
+		from := event.HibernatedOn
+		if from.Before(start) {
+			from = start
+		}
+		to := event.WokenOn
+		if to.IsZero() || to.After(end) {
+			to = end
+		}
+		if to.After(from) {
+			hibernated[appEnv{appId: event.AppId, envId: event.EnvironmentId}] += to.Sub(from)
+		}
+	}
+	costs := make(map[appEnv]float64)
+	for _, allocation := range allocations {
+		costs[appEnv{appId: allocation.AppId, envId: allocation.EnvironmentId}] += allocation.TotalCost
+	}
+	report := &HibernationSavingsReport{Rows: make([]*HibernationSavingsRow, 0)}
+	rangeHours := end.Sub(start).Hours()
+	for key, duration := range hibernated {
+		row := &HibernationSavingsRow{
+			AppId:           key.appId,
+			EnvironmentId:   key.envId,
+			HibernatedHours: roundHours(duration.Hours()),
+			Cost:            cost.RoundCost(costs[key]),
+		}
+		if awakeHours := rangeHours - duration.Hours(); awakeHours > 0 {
+			row.EstimatedSavings = cost.RoundCost(costs[key] / awakeHours * duration.Hours())
+		}
+		report.TotalHibernatedHours += row.HibernatedHours
+		report.TotalEstimatedSavings += row.EstimatedSavings
+		report.Rows = append(report.Rows, row)
+	}
+	report.TotalHibernatedHours = roundHours(report.TotalHibernatedHours)
+	report.TotalEstimatedSavings = cost.RoundCost(report.TotalEstimatedSavings)
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].EstimatedSavings != report.Rows[j].EstimatedSavings {
+			return report.Rows[i].EstimatedSavings > report.Rows[j].EstimatedSavings
+		}
+		if report.Rows[i].HibernatedHours != report.Rows[j].HibernatedHours {
+			return report.Rows[i].HibernatedHours > report.Rows[j].HibernatedHours
+		}
+		return report.Rows[i].AppId < report.Rows[j].AppId
+	})
+	return report
+}
+
+func roundHours(hours float64) float64 {
+	return math.Round(hours*100) / 100
+}
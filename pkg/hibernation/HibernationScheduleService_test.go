@@ -0,0 +1,205 @@
+package hibernation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/devtron-labs/devtron/internal/sql/models"
+	"github.com/devtron-labs/devtron/internal/sql/repository/chartConfig"
+	"github.com/devtron-labs/devtron/internal/sql/repository/pipelineConfig"
+	"github.com/devtron-labs/devtron/internal/util"
+	repository2 "github.com/devtron-labs/devtron/pkg/cost/repository"
+	"github.com/devtron-labs/devtron/pkg/hibernation/repository"
+	"github.com/devtron-labs/devtron/pkg/pipeline"
+	"github.com/devtron-labs/devtron/pkg/sql"
+)
+
+func TestIsScheduleAsleep(t *testing.T) {
+	weeknights := &repository.HibernationSchedule{SleepCron: "0 20 * * 1-5", WakeCron: "0 8 * * 1-5", Timezone: "Asia/Kolkata"}
+	ist, _ := time.LoadLocation("Asia/Kolkata")
+	tests := []struct {
+		name     string
+		schedule *repository.HibernationSchedule
+		now      time.Time
+		want     bool
+	}{
+		{name: "working hours", schedule: weeknights, now: time.Date(2021, 3, 10, 12, 0, 0, 0, ist), want: false},
+		{name: "night", schedule: weeknights, now: time.Date(2021, 3, 10, 23, 0, 0, 0, ist), want: true},
+		{name: "early morning", schedule: weeknights, now: time.Date(2021, 3, 11, 7, 59, 0, 0, ist), want: true},
+		{name: "weekend", schedule: weeknights, now: time.Date(2021, 3, 13, 15, 0, 0, 0, ist), want: true},
+		{name: "monday morning", schedule: weeknights, now: time.Date(2021, 3, 15, 8, 0, 0, 0, ist), want: false},
+		{name: "night in utc", schedule: weeknights, now: time.Date(2021, 3, 10, 16, 0, 0, 0, time.UTC), want: true},
+		{name: "kept awake", schedule: &repository.HibernationSchedule{SleepCron: "0 20 * * 1-5", WakeCron: "0 8 * * 1-5", Timezone: "Asia/Kolkata",
+			KeepAwakeUntil: time.Date(2021, 3, 11, 0, 0, 0, 0, ist)}, now: time.Date(2021, 3, 10, 23, 0, 0, 0, ist), want: false},
+		{name: "keep awake expired", schedule: &repository.HibernationSchedule{SleepCron: "0 20 * * 1-5", WakeCron: "0 8 * * 1-5", Timezone: "Asia/Kolkata",
+			KeepAwakeUntil: time.Date(2021, 3, 10, 22, 0, 0, 0, ist)}, now: time.Date(2021, 3, 10, 23, 0, 0, 0, ist), want: true},
+		{name: "never slept", schedule: &repository.HibernationSchedule{SleepCron: "0 0 1 1 *", WakeCron: "0 8 * * *"}, now: time.Date(2021, 3, 10, 23, 0, 0, 0, time.UTC), want: false},
+	}
+	for _, tt := range tests {
+		got, err := isScheduleAsleep(tt.schedule, tt.now)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: isScheduleAsleep() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	if _, err := parseSchedule("0 20 * *", "UTC"); err == nil {
+		t.Errorf("expected error for cron with missing field")
+	}
+	if _, err := parseSchedule("0 20 * * *", "Mars/Olympus"); err == nil {
+		t.Errorf("expected error for unknown timezone")
+	}
+}
+
+func TestHibernationSavings(t *testing.T) {
+	start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 2)
+	events := []*repository.HibernationEvent{
+		// clipped to the start of the range, 8 hours
+		{AppId: 1, EnvironmentId: 10, HibernatedOn: start.Add(-4 * time.Hour), WokenOn: start.Add(8 * time.Hour)},
+		{AppId: 1, EnvironmentId: 10, HibernatedOn: start.Add(20 * time.Hour), WokenOn: start.Add(32 * time.Hour)},
+		// still hibernated, clipped to the end of the range
+		{AppId: 2, EnvironmentId: 10, HibernatedOn: start.Add(36 * time.Hour)},
+	}
+	allocations := []*repository2.CostAllocation{
+		{AppId: 1, EnvironmentId: 10, TotalCost: 10},
+		{AppId: 1, EnvironmentId: 10, TotalCost: 18},
+		{AppId: 3, EnvironmentId: 10, TotalCost: 50},
+	}
+	report := hibernationSavings(events, allocations, start, end)
+	if len(report.Rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(report.Rows))
+	}
+	// 28 spent in 28 awake hours, 20 hours hibernated
+	first := report.Rows[0]
+	if first.AppId != 1 || first.HibernatedHours != 20 || first.Cost != 28 || first.EstimatedSavings != 20 {
+		t.Errorf("unexpected row for app 1: %+v", first)
+	}
+	second := report.Rows[1]
+	if second.AppId != 2 || second.HibernatedHours != 12 || second.EstimatedSavings != 0 {
+		t.Errorf("unexpected row for app 2: %+v", second)
+	}
+	if report.TotalHibernatedHours != 32 || report.TotalEstimatedSavings != 20 {
+		t.Errorf("unexpected totals %v hours, %v savings", report.TotalHibernatedHours, report.TotalEstimatedSavings)
+	}
+}
+
+type wakePipelineRepositoryStub struct {
+	pipelineConfig.PipelineRepository
+}
+
+func (impl wakePipelineRepositoryStub) FindActiveByAppIdAndEnvironmentId(appId int, environmentId int) ([]*pipelineConfig.Pipeline, error) {
+	return []*pipelineConfig.Pipeline{{Id: 5, AppId: appId, EnvironmentId: environmentId}}, nil
+}
+
+type wakePipelineOverrideRepositoryStub struct {
+	chartConfig.PipelineOverrideRepository
+	latest *chartConfig.PipelineOverride
+}
+
+func (impl wakePipelineOverrideRepositoryStub) GetLatestReleaseDeploymentType(pipelineIds []int) ([]*chartConfig.PipelineOverride, error) {
+	return []*chartConfig.PipelineOverride{{Id: impl.latest.Id, PipelineId: impl.latest.PipelineId, DeploymentType: impl.latest.DeploymentType}}, nil
+}
+
+func (impl wakePipelineOverrideRepositoryStub) FindById(id int) (*chartConfig.PipelineOverride, error) {
+	return impl.latest, nil
+}
+
+type wakeEventRepositoryStub struct {
+	repository.HibernationEventRepository
+	updated []*repository.HibernationEvent
+}
+
+func (impl *wakeEventRepositoryStub) Update(model *repository.HibernationEvent) error {
+	impl.updated = append(impl.updated, model)
+	return nil
+}
+
+type wakeWorkflowDagExecutorStub struct {
+	pipeline.WorkflowDagExecutor
+	requests []*pipeline.StopAppRequest
+}
+
+func (impl *wakeWorkflowDagExecutorStub) StopStartApp(stopRequest *pipeline.StopAppRequest, ctx context.Context) (int, error) {
+	impl.requests = append(impl.requests, stopRequest)
+	return 0, nil
+}
+
+func TestWakeApp(t *testing.T) {
+	hibernatedOn := time.Date(2021, 3, 10, 20, 0, 0, 0, time.UTC)
+	now := time.Date(2021, 3, 11, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		latest     *chartConfig.PipelineOverride
+		wantStart  bool
+		wantWokeOn time.Time
+	}{
+		{name: "still hibernated", latest: &chartConfig.PipelineOverride{Id: 1, PipelineId: 5, DeploymentType: models.DEPLOYMENTTYPE_STOP,
+			AuditLog: sql.AuditLog{CreatedOn: hibernatedOn}}, wantStart: true, wantWokeOn: now},
+		{name: "deployed by hand", latest: &chartConfig.PipelineOverride{Id: 2, PipelineId: 5, DeploymentType: models.DEPLOYMENTTYPE_DEPLOY,
+			AuditLog: sql.AuditLog{CreatedOn: hibernatedOn.Add(3 * time.Hour)}}, wantWokeOn: hibernatedOn.Add(3 * time.Hour)},
+		{name: "started by hand without time", latest: &chartConfig.PipelineOverride{Id: 3, PipelineId: 5, DeploymentType: models.DEPLOYMENTTYPE_START},
+			wantWokeOn: now},
+	}
+	for _, tt := range tests {
+		eventRepository := &wakeEventRepositoryStub{}
+		workflowDagExecutor := &wakeWorkflowDagExecutorStub{}
+		impl := &HibernationScheduleServiceImpl{
+			logger:                     util.NewSugardLogger(),
+			hibernationEventRepository: eventRepository,
+			pipelineRepository:         wakePipelineRepositoryStub{},
+			pipelineOverrideRepository: wakePipelineOverrideRepositoryStub{latest: tt.latest},
+			workflowDagExecutor:        workflowDagExecutor,
+		}
+		event := &repository.HibernationEvent{Id: 1, ScheduleId: 1, AppId: 2, EnvironmentId: 10, HibernatedOn: hibernatedOn}
+		impl.wakeApp(event, now, context.Background())
+		if started := len(workflowDagExecutor.requests) == 1; started != tt.wantStart {
+			t.Errorf("%s: app started = %v, want %v", tt.name, started, tt.wantStart)
+		}
+		if len(eventRepository.updated) != 1 || !event.WokenOn.Equal(tt.wantWokeOn) {
+			t.Errorf("%s: event woken on %v, want %v", tt.name, event.WokenOn, tt.wantWokeOn)
+		}
+	}
+}
+
+type schedulerLockRepositoryStub struct {
+	repository.HibernationScheduleRepository
+	holder string
+	runs   int
+}
+
+// AcquireSchedulerLock hands the lock to the first holder, it never expires in the test
+func (impl *schedulerLockRepositoryStub) AcquireSchedulerLock(holder string, ttl time.Duration) (bool, error) {
+	if impl.holder == "" {
+		impl.holder = holder
+	}
+	return impl.holder == holder, nil
+}
+
+func (impl *schedulerLockRepositoryStub) FindAllActive() ([]*repository.HibernationSchedule, error) {
+	impl.runs++
+	return nil, nil
+}
+
+func TestApplySchedulesOnLockHolderOnly(t *testing.T) {
+	lockRepository := &schedulerLockRepositoryStub{}
+	config := &HibernationConfig{SchedulerLockSeconds: 180}
+	first := &HibernationScheduleServiceImpl{logger: util.NewSugardLogger(), config: config, lockHolder: "replica-1",
+		hibernationScheduleRepository: lockRepository}
+	second := &HibernationScheduleServiceImpl{logger: util.NewSugardLogger(), config: config, lockHolder: "replica-2",
+		hibernationScheduleRepository: lockRepository}
+	for i := 0; i < 2; i++ {
+		first.applySchedulesIfLockHolder()
+		second.applySchedulesIfLockHolder()
+	}
+	// two runs of each replica, only those of the lock holder apply the schedules
+	if lockRepository.runs != 2 {
+		t.Errorf("schedules applied %d times, want 2", lockRepository.runs)
+	}
+}
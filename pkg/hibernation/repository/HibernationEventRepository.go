@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"go.uber.org/zap"
+	"time"
+)
+
+// HibernationEvent records an app hibernated by a schedule, it stays open with a zero WokenOn until the schedule
+// wakes the app again. Apps are only woken by the schedule which hibernated them
+type HibernationEvent struct {
+	TableName     struct{}  `sql:"hibernation_event" pg:",discard_unknown_columns"`
+	Id            int       `sql:"id,pk"`
+	ScheduleId    int       `sql:"schedule_id,notnull"`
+	AppId         int       `sql:"app_id,notnull"`
+	EnvironmentId int       `sql:"environment_id,notnull"`
+	HibernatedOn  time.Time `sql:"hibernated_on,notnull"`
+	WokenOn       time.Time `sql:"woken_on"`
+}
+
+type HibernationEventRepository interface {
+	Save(model *HibernationEvent) error
+	Update(model *HibernationEvent) error
+	FindOpenByScheduleId(scheduleId int) ([]*HibernationEvent, error)
+	// FindOverlapping returns the events which were hibernated at some point between from and to
+	FindOverlapping(from time.Time, to time.Time) ([]*HibernationEvent, error)
+}
+
+type HibernationEventRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func NewHibernationEventRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *HibernationEventRepositoryImpl {
+	return &HibernationEventRepositoryImpl{
+		dbConnection: dbConnection,
+		logger:       logger,
+	}
+}
+
+func (impl HibernationEventRepositoryImpl) Save(model *HibernationEvent) error {
+	return impl.dbConnection.Insert(model)
+}
+
+func (impl HibernationEventRepositoryImpl) Update(model *HibernationEvent) error {
+	return impl.dbConnection.Update(model)
+}
+
+func (impl HibernationEventRepositoryImpl) FindOpenByScheduleId(scheduleId int) ([]*HibernationEvent, error) {
+	var models []*HibernationEvent
+	err := impl.dbConnection.Model(&models).
+		Where("schedule_id = ?", scheduleId).
+		Where("woken_on IS NULL").
+		Select()
+	return models, err
+}
+
+func (impl HibernationEventRepositoryImpl) FindOverlapping(from time.Time, to time.Time) ([]*HibernationEvent, error) {
+	var models []*HibernationEvent
+	err := impl.dbConnection.Model(&models).
+		Where("hibernated_on < ?", to).
+		WhereGroup(func(q *orm.Query) (*orm.Query, error) {
+			q = q.WhereOr("woken_on IS NULL").
+				WhereOr("woken_on > ?", from)
+			return q, nil
+		}).
+		Order("hibernated_on ASC").
+		Select()
+	return models, err
+}
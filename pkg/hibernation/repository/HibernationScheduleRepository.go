@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"time"
+)
+
+// HibernationSchedule hibernates the apps of an environment at SleepCron and wakes them at WakeCron, both evaluated in
+// Timezone. AppId 0 covers every app of the environment except ExcludedAppIds
+type HibernationSchedule struct {
+	TableName      struct{}  `sql:"hibernation_schedule" pg:",discard_unknown_columns"`
+	Id             int       `sql:"id,pk"`
+	Name           string    `sql:"name,notnull"`
+	EnvironmentId  int       `sql:"environment_id,notnull"`
+	AppId          int       `sql:"app_id,notnull"`
+	SleepCron      string    `sql:"sleep_cron,notnull"`
+	WakeCron       string    `sql:"wake_cron,notnull"`
+	Timezone       string    `sql:"timezone,notnull"`
+	ExcludedAppIds []int     `sql:"excluded_app_ids" pg:",array"`
+	KeepAwakeUntil time.Time `sql:"keep_awake_until"`
+	Active         bool      `sql:"active,notnull"`
+	sql.AuditLog
+}
+
+type HibernationScheduleRepository interface {
+	Save(model *HibernationSchedule) error
+	Update(model *HibernationSchedule) error
+	FindActiveById(id int) (*HibernationSchedule, error)
+	FindAllActive() ([]*HibernationSchedule, error)
+	FindActiveByEnvironmentId(environmentId int) ([]*HibernationSchedule, error)
+	AcquireSchedulerLock(holder string, ttl time.Duration) (bool, error)
+}
+
+type HibernationScheduleRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func NewHibernationScheduleRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *HibernationScheduleRepositoryImpl {
+	return &HibernationScheduleRepositoryImpl{
+		dbConnection: dbConnection,
+		logger:       logger,
+	}
+}
+
+func (impl HibernationScheduleRepositoryImpl) Save(model *HibernationSchedule) error {
+	return impl.dbConnection.Insert(model)
+}
+
+func (impl HibernationScheduleRepositoryImpl) Update(model *HibernationSchedule) error {
+	return impl.dbConnection.Update(model)
+}
+
+func (impl HibernationScheduleRepositoryImpl) FindActiveById(id int) (*HibernationSchedule, error) {
+	model := &HibernationSchedule{}
+	err := impl.dbConnection.Model(model).
+		Where("id = ?", id).
+		Where("active = ?", true).
+		Select()
+	return model, err
+}
+
+func (impl HibernationScheduleRepositoryImpl) FindAllActive() ([]*HibernationSchedule, error) {
+	var models []*HibernationSchedule
+	err := impl.dbConnection.Model(&models).
+		Where("active = ?", true).
+		Order("id ASC").
+		Select()
+	return models, err
+}
+
+func (impl HibernationScheduleRepositoryImpl) FindActiveByEnvironmentId(environmentId int) ([]*HibernationSchedule, error) {
+	var models []*HibernationSchedule
+	err := impl.dbConnection.Model(&models).
+		Where("environment_id = ?", environmentId).
+		Where("active = ?", true).
+		Order("id ASC").
+		Select()
+	return models, err
+}
+
+// AcquireSchedulerLock takes or renews the lock of the scheduler for holder, it fails while another holder's lock has
+// not expired. Expiry is checked against the database clock so that replicas with skewed clocks agree
+func (impl HibernationScheduleRepositoryImpl) AcquireSchedulerLock(holder string, ttl time.Duration) (bool, error) {
+	query := "UPDATE hibernation_scheduler_lock SET holder = ?, locked_until = now() + ? * interval '1 second'" +
+		" WHERE id = 1 AND (holder = ? OR locked_until < now());"
+	res, err := impl.dbConnection.Exec(query, holder, int(ttl.Seconds()), holder)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() == 1, nil
+}
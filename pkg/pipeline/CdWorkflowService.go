@@ -28,8 +28,10 @@ import (
 	"github.com/argoproj/argo/workflow/util"
 	"github.com/devtron-labs/devtron/api/bean"
 	"github.com/devtron-labs/devtron/internal/sql/repository/pipelineConfig"
+	util2 "github.com/devtron-labs/devtron/internal/util"
 	"github.com/devtron-labs/devtron/pkg/app"
 	bean2 "github.com/devtron-labs/devtron/pkg/bean"
+	"github.com/devtron-labs/devtron/pkg/variables"
 	"go.uber.org/zap"
 	v12 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -40,20 +42,21 @@ import (
 type CdWorkflowService interface {
 	SubmitWorkflow(workflowRequest *CdWorkflowRequest, pipeline *pipelineConfig.Pipeline, env *repository.Environment) (*v1alpha1.Workflow, error)
 	DeleteWorkflow(wfName string, namespace string) error
-	GetWorkflow(name string, namespace string, url string, token string, isExtRun bool) (*v1alpha1.Workflow, error)
+	GetWorkflow(name string, namespace string, clusterConfig *util2.ClusterConfig, isExtRun bool) (*v1alpha1.Workflow, error)
 	ListAllWorkflows(namespace string) (*v1alpha1.WorkflowList, error)
 	UpdateWorkflow(wf *v1alpha1.Workflow) (*v1alpha1.Workflow, error)
-	TerminateWorkflow(name string, namespace string, url string, token string, isExtRun bool) error
+	TerminateWorkflow(name string, namespace string, clusterConfig *util2.ClusterConfig, isExtRun bool) error
 }
 
 const CD_WORKFLOW_NAME = "cd"
 
 type CdWorkflowServiceImpl struct {
-	Logger        *zap.SugaredLogger
-	config        *rest.Config
-	cdConfig      *CdConfig
-	appService    app.AppService
-	envRepository repository.EnvironmentRepository
+	Logger          *zap.SugaredLogger
+	config          *rest.Config
+	cdConfig        *CdConfig
+	appService      app.AppService
+	envRepository   repository.EnvironmentRepository
+	variableService variables.VariableService
 }
 
 type CdWorkflowRequest struct {
@@ -95,9 +98,10 @@ type CdWorkflowRequest struct {
 const PRE = "PRE"
 const POST = "POST"
 
-func NewCdWorkflowServiceImpl(Logger *zap.SugaredLogger, envRepository repository.EnvironmentRepository, cdConfig *CdConfig, appService app.AppService) *CdWorkflowServiceImpl {
+func NewCdWorkflowServiceImpl(Logger *zap.SugaredLogger, envRepository repository.EnvironmentRepository, cdConfig *CdConfig, appService app.AppService,
+	variableService variables.VariableService) *CdWorkflowServiceImpl {
 	return &CdWorkflowServiceImpl{Logger: Logger, config: cdConfig.ClusterConfig,
-		cdConfig: cdConfig, appService: appService, envRepository: envRepository}
+		cdConfig: cdConfig, appService: appService, envRepository: envRepository, variableService: variableService}
 }
 
 func (impl *CdWorkflowServiceImpl) SubmitWorkflow(workflowRequest *CdWorkflowRequest, pipeline *pipelineConfig.Pipeline, env *repository.Environment) (*v1alpha1.Workflow, error) {
@@ -109,6 +113,13 @@ func (impl *CdWorkflowServiceImpl) SubmitWorkflow(workflowRequest *CdWorkflowReq
 	if (workflowRequest.StageType == PRE && pipeline.RunPreStageInEnv) || (workflowRequest.StageType == POST && pipeline.RunPostStageInEnv) {
 		workflowRequest.IsExtRun = true
 	}
+	variableScope := variables.Scope{AppId: workflowRequest.AppId, EnvironmentId: workflowRequest.EnvironmentId}
+	stageYaml, err := impl.variableService.ResolveText(workflowRequest.StageYaml, variableScope)
+	if err != nil {
+		impl.Logger.Errorw("error in resolving variables of stage yaml", "cdPipelineId", workflowRequest.CdPipelineId, "err", err)
+		return nil, err
+	}
+	workflowRequest.StageYaml = stageYaml
 	ciCdTriggerEvent := CiCdTriggerEvent{
 		CdRequest: workflowRequest,
 	}
@@ -202,6 +213,18 @@ func (impl *CdWorkflowServiceImpl) SubmitWorkflow(workflowRequest *CdWorkflowReq
 	for i := range secrets.Secrets {
 		secrets.Secrets[i].Name = secrets.Secrets[i].Name + "-" + strconv.Itoa(workflowRequest.WorkflowId) + "-" + strconv.Itoa(workflowRequest.WorkflowRunnerId)
 	}
+	for i := range configMaps.Maps {
+		configMaps.Maps[i].Data, err = impl.variableService.ResolveJson(configMaps.Maps[i].Data, variableScope)
+		if err != nil {
+			impl.Logger.Errorw("error in resolving variables of config map", "name", configMaps.Maps[i].Name, "err", err)
+			return nil, err
+		}
+	}
+	err = impl.variableService.ResolveSecrets(secrets.Secrets, variableScope)
+	if err != nil {
+		impl.Logger.Errorw("error in resolving variables of secrets", "cdPipelineId", workflowRequest.CdPipelineId, "err", err)
+		return nil, err
+	}
 
 	configsMapping := make(map[string]string)
 	secretsMapping := make(map[string]string)
@@ -470,10 +493,8 @@ func (impl *CdWorkflowServiceImpl) SubmitWorkflow(workflowRequest *CdWorkflowReq
 	var wfClient v1alpha12.WorkflowInterface
 
 	if workflowRequest.IsExtRun {
-		serverUrl := env.Cluster.ServerUrl
-		configMap := env.Cluster.Config
-		bearerToken := configMap["bearer_token"]
-		wfClient, err = impl.getRuntimeEnvClientInstance(workflowRequest.Namespace, bearerToken, serverUrl)
+		clusterConfig := util2.NewClusterConfig(env.Cluster.ServerUrl, env.Cluster.Config)
+		wfClient, err = impl.getRuntimeEnvClientInstance(workflowRequest.Namespace, clusterConfig)
 	}
 	if wfClient == nil {
 		wfClient, err = impl.getClientInstance(workflowRequest.Namespace)
@@ -493,12 +514,12 @@ func (impl *CdWorkflowServiceImpl) SubmitWorkflow(workflowRequest *CdWorkflowReq
 	return createdWf, err
 }
 
-func (impl *CdWorkflowServiceImpl) GetWorkflow(name string, namespace string, url string, token string, isExtRun bool) (*v1alpha1.Workflow, error) {
+func (impl *CdWorkflowServiceImpl) GetWorkflow(name string, namespace string, clusterConfig *util2.ClusterConfig, isExtRun bool) (*v1alpha1.Workflow, error) {
 	impl.Logger.Debugw("getting wf", "name", name)
 	var wfClient v1alpha12.WorkflowInterface
 	var err error
 	if isExtRun {
-		wfClient, err = impl.getRuntimeEnvClientInstance(namespace, token, url)
+		wfClient, err = impl.getRuntimeEnvClientInstance(namespace, clusterConfig)
 
 	} else {
 		wfClient, err = impl.getClientInstance(namespace)
@@ -511,12 +532,12 @@ func (impl *CdWorkflowServiceImpl) GetWorkflow(name string, namespace string, ur
 	return workflow, err
 }
 
-func (impl *CdWorkflowServiceImpl) TerminateWorkflow(name string, namespace string, url string, token string, isExtRun bool) error {
+func (impl *CdWorkflowServiceImpl) TerminateWorkflow(name string, namespace string, clusterConfig *util2.ClusterConfig, isExtRun bool) error {
 	impl.Logger.Debugw("terminating wf", "name", name)
 	var wfClient v1alpha12.WorkflowInterface
 	var err error
 	if isExtRun {
-		wfClient, err = impl.getRuntimeEnvClientInstance(namespace, token, url)
+		wfClient, err = impl.getRuntimeEnvClientInstance(namespace, clusterConfig)
 
 	} else {
 		wfClient, err = impl.getClientInstance(namespace)
@@ -574,15 +595,8 @@ func (impl *CdWorkflowServiceImpl) getClientInstance(namespace string) (v1alpha1
 	return wfClient, nil
 }
 
-func (impl *CdWorkflowServiceImpl) getRuntimeEnvClientInstance(namespace string, token string, host string) (v1alpha12.WorkflowInterface, error) {
-	config := &rest.Config{
-		Host:        host,
-		BearerToken: token,
-		TLSClientConfig: rest.TLSClientConfig{
-			Insecure: true,
-		},
-	}
-	clientSet, err := versioned.NewForConfig(config)
+func (impl *CdWorkflowServiceImpl) getRuntimeEnvClientInstance(namespace string, clusterConfig *util2.ClusterConfig) (v1alpha12.WorkflowInterface, error) {
+	clientSet, err := versioned.NewForConfig(clusterConfig.RestConfig())
 	if err != nil {
 		impl.Logger.Errorw("err", "err", err)
 		return nil, err
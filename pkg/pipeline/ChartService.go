@@ -63,6 +63,7 @@ type TemplateRequest struct {
 	ChartRefId              int             `json:"chartRefId,omitempty"  validate:"number"`
 	Latest                  bool            `json:"latest"`
 	IsAppMetricsEnabled     bool            `json:"isAppMetricsEnabled"`
+	Comment                 string          `json:"comment,omitempty"`
 	UserId                  int32           `json:"-"`
 }
 
@@ -124,23 +125,24 @@ type ChartService interface {
 	JsonSchemaExtractFromFile(chartRefId int) (map[string]interface{}, error)
 }
 type ChartServiceImpl struct {
-	chartRepository           chartConfig.ChartRepository
-	logger                    *zap.SugaredLogger
-	repoRepository            chartConfig.ChartRepoRepository
-	chartTemplateService      util.ChartTemplateService
-	pipelineGroupRepository   app.AppRepository
-	mergeUtil                 util.MergeUtil
-	repositoryService         repository.ServiceClient
-	refChartDir               RefChartDir
-	defaultChart              DefaultChart
-	chartRefRepository        chartConfig.ChartRefRepository
-	envOverrideRepository     chartConfig.EnvConfigOverrideRepository
-	pipelineConfigRepository  chartConfig.PipelineConfigRepository
-	configMapRepository       chartConfig.ConfigMapRepository
-	environmentRepository     repository4.EnvironmentRepository
-	pipelineRepository        pipelineConfig.PipelineRepository
-	appLevelMetricsRepository repository3.AppLevelMetricsRepository
-	client                    *http.Client
+	chartRepository                  chartConfig.ChartRepository
+	logger                           *zap.SugaredLogger
+	repoRepository                   chartConfig.ChartRepoRepository
+	chartTemplateService             util.ChartTemplateService
+	pipelineGroupRepository          app.AppRepository
+	mergeUtil                        util.MergeUtil
+	repositoryService                repository.ServiceClient
+	refChartDir                      RefChartDir
+	defaultChart                     DefaultChart
+	chartRefRepository               chartConfig.ChartRefRepository
+	envOverrideRepository            chartConfig.EnvConfigOverrideRepository
+	pipelineConfigRepository         chartConfig.PipelineConfigRepository
+	configMapRepository              chartConfig.ConfigMapRepository
+	environmentRepository            repository4.EnvironmentRepository
+	pipelineRepository               pipelineConfig.PipelineRepository
+	appLevelMetricsRepository        repository3.AppLevelMetricsRepository
+	client                           *http.Client
+	deploymentTemplateHistoryService DeploymentTemplateHistoryService
 }
 
 func NewChartServiceImpl(chartRepository chartConfig.ChartRepository,
@@ -161,25 +163,27 @@ func NewChartServiceImpl(chartRepository chartConfig.ChartRepository,
 	appLevelMetricsRepository repository3.AppLevelMetricsRepository,
 	client *http.Client,
 	CustomFormatCheckers *util2.CustomFormatCheckers,
+	deploymentTemplateHistoryService DeploymentTemplateHistoryService,
 ) *ChartServiceImpl {
 	return &ChartServiceImpl{
-		chartRepository:           chartRepository,
-		logger:                    logger,
-		chartTemplateService:      chartTemplateService,
-		repoRepository:            repoRepository,
-		pipelineGroupRepository:   pipelineGroupRepository,
-		mergeUtil:                 mergeUtil,
-		refChartDir:               refChartDir,
-		defaultChart:              defaultChart,
-		repositoryService:         repositoryService,
-		chartRefRepository:        chartRefRepository,
-		envOverrideRepository:     envOverrideRepository,
-		pipelineConfigRepository:  pipelineConfigRepository,
-		configMapRepository:       configMapRepository,
-		environmentRepository:     environmentRepository,
-		pipelineRepository:        pipelineRepository,
-		appLevelMetricsRepository: appLevelMetricsRepository,
-		client:                    client,
+		chartRepository:                  chartRepository,
+		logger:                           logger,
+		chartTemplateService:             chartTemplateService,
+		repoRepository:                   repoRepository,
+		pipelineGroupRepository:          pipelineGroupRepository,
+		mergeUtil:                        mergeUtil,
+		refChartDir:                      refChartDir,
+		defaultChart:                     defaultChart,
+		repositoryService:                repositoryService,
+		chartRefRepository:               chartRefRepository,
+		envOverrideRepository:            envOverrideRepository,
+		pipelineConfigRepository:         pipelineConfigRepository,
+		configMapRepository:              configMapRepository,
+		environmentRepository:            environmentRepository,
+		pipelineRepository:               pipelineRepository,
+		appLevelMetricsRepository:        appLevelMetricsRepository,
+		client:                           client,
+		deploymentTemplateHistoryService: deploymentTemplateHistoryService,
 	}
 }
 
@@ -350,6 +354,7 @@ func (impl ChartServiceImpl) Create(templateRequest TemplateRequest, ctx context
 		//If found any error, rollback chart museum
 		return nil, err
 	}
+	impl.deploymentTemplateHistoryService.SaveAppLevelHistory(chart, templateRequest.Comment)
 
 	appLevelMetrics, err := impl.appLevelMetricsRepository.FindByAppId(templateRequest.AppId)
 	if err != nil && err != pg.ErrNoRows {
@@ -725,6 +730,7 @@ func (impl ChartServiceImpl) UpdateAppOverride(templateRequest *TemplateRequest)
 	if err != nil {
 		return nil, err
 	}
+	impl.deploymentTemplateHistoryService.SaveAppLevelHistory(template, templateRequest.Comment)
 
 	if !(chartMajorVersion >= 3 && chartMinorVersion >= 1) {
 		appMetricRequest := AppMetricEnableDisableRequest{UserId: templateRequest.UserId, AppId: templateRequest.AppId, IsAppMetricsEnabled: false}
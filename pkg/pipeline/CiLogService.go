@@ -28,11 +28,11 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	s32 "github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/devtron-labs/devtron/internal/util"
 	"go.uber.org/zap"
 	"io"
 	v12 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 	"net/url"
 	"os"
 	"strconv"
@@ -40,7 +40,7 @@ import (
 )
 
 type CiLogService interface {
-	FetchRunningWorkflowLogs(ciLogRequest CiLogRequest, token string, host string, isExt bool) (io.ReadCloser, func() error, error)
+	FetchRunningWorkflowLogs(ciLogRequest CiLogRequest, clusterConfig *util.ClusterConfig, isExt bool) (io.ReadCloser, func() error, error)
 	FetchLogs(ciLogRequest CiLogRequest) (*os.File, func() error, error)
 }
 
@@ -78,7 +78,7 @@ func NewCiLogServiceImpl(logger *zap.SugaredLogger, ciService CiService, ciConfi
 	}
 }
 
-func (impl *CiLogServiceImpl) FetchRunningWorkflowLogs(ciLogRequest CiLogRequest, token string, host string, isExt bool) (io.ReadCloser, func() error, error) {
+func (impl *CiLogServiceImpl) FetchRunningWorkflowLogs(ciLogRequest CiLogRequest, clusterConfig *util.ClusterConfig, isExt bool) (io.ReadCloser, func() error, error) {
 	podLogOpts := &v12.PodLogOptions{
 		Container: "main",
 		Follow:    true,
@@ -87,14 +87,7 @@ func (impl *CiLogServiceImpl) FetchRunningWorkflowLogs(ciLogRequest CiLogRequest
 	kubeClient = impl.kubeClient
 	var err error
 	if isExt {
-		config := &rest.Config{
-			Host:        host,
-			BearerToken: token,
-			TLSClientConfig: rest.TLSClientConfig{
-				Insecure: true,
-			},
-		}
-		kubeClient, err = kubernetes.NewForConfig(config)
+		kubeClient, err = kubernetes.NewForConfig(clusterConfig.RestConfig())
 		if err != nil {
 			impl.logger.Errorw("Can not create kubernetes client: ", "err", err)
 			return nil, nil, err
@@ -193,6 +186,10 @@ type AzureBlob struct {
 	logger *zap.SugaredLogger
 }
 
+func NewAzureBlob(logger *zap.SugaredLogger) *AzureBlob {
+	return &AzureBlob{logger: logger}
+}
+
 func (impl *AzureBlob) getSharedCredentials(accountName, accountKey string) (*azblob.SharedKeyCredential, error) {
 	credential, err := azblob.NewSharedKeyCredential(accountName, accountKey)
 	if err != nil {
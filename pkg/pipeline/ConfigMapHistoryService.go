@@ -0,0 +1,474 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/devtron-labs/devtron/internal/sql/repository/chartConfig"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	ConfigDiffAdded    = "ADDED"
+	ConfigDiffDeleted  = "DELETED"
+	ConfigDiffModified = "MODIFIED"
+)
+
+type ConfigMapHistoryDto struct {
+	Id            int           `json:"id"`
+	AppId         int           `json:"appId"`
+	EnvironmentId int           `json:"environmentId"`
+	Type          string        `json:"type"`
+	Version       int           `json:"version"`
+	Comment       string        `json:"comment"`
+	ConfigData    []*ConfigData `json:"configData,omitempty"`
+	CreatedBy     int32         `json:"createdBy"`
+	CreatedOn     time.Time     `json:"createdOn"`
+}
+
+type ConfigMapHistoryRestoreRequest struct {
+	Id            int    `json:"id" validate:"required"`
+	AppId         int    `json:"appId" validate:"required"`
+	EnvironmentId int    `json:"environmentId"`
+	Comment       string `json:"comment"`
+	UserId        int32  `json:"-"`
+}
+
+type ConfigMapHistoryDiff struct {
+	From    *ConfigMapHistoryDto `json:"from"`
+	To      *ConfigMapHistoryDto `json:"to"`
+	Changes []*ConfigDataDiff    `json:"changes"`
+}
+
+type ConfigDataDiff struct {
+	Name   string           `json:"name"`
+	Change string           `json:"change"`
+	Fields []string         `json:"fields,omitempty"`
+	Keys   []*ConfigKeyDiff `json:"keys,omitempty"`
+}
+
+type ConfigKeyDiff struct {
+	Key    string `json:"key"`
+	Change string `json:"change"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+}
+
+type ConfigMapHistoryService interface {
+	SaveAppLevelHistory(model *chartConfig.ConfigMapAppModel, dataType string, comment string)
+	SaveEnvLevelHistory(model *chartConfig.ConfigMapEnvModel, dataType string, comment string)
+	GetVersions(appId int, envId int, dataType string) ([]*ConfigMapHistoryDto, error)
+	GetVersion(appId int, envId int, id int, showSecretValues bool) (*ConfigMapHistoryDto, error)
+	Diff(appId int, envId int, fromId int, toId int, showSecretValues bool) (*ConfigMapHistoryDiff, error)
+	Restore(request *ConfigMapHistoryRestoreRequest) (*ConfigMapHistoryDto, error)
+}
+
+type ConfigMapHistoryServiceImpl struct {
+	logger                     *zap.SugaredLogger
+	configMapHistoryRepository chartConfig.ConfigMapHistoryRepository
+	configMapRepository        chartConfig.ConfigMapRepository
+	auditLogService            auditLog.AuditLogService
+}
+
+func NewConfigMapHistoryServiceImpl(logger *zap.SugaredLogger,
+	configMapHistoryRepository chartConfig.ConfigMapHistoryRepository,
+	configMapRepository chartConfig.ConfigMapRepository,
+	auditLogService auditLog.AuditLogService) *ConfigMapHistoryServiceImpl {
+	return &ConfigMapHistoryServiceImpl{
+		logger:                     logger,
+		configMapHistoryRepository: configMapHistoryRepository,
+		configMapRepository:        configMapRepository,
+		auditLogService:            auditLogService,
+	}
+}
+
+// SaveAppLevelHistory records a new version of app level CM or CS data, failures are only logged as
+// the change itself has already been persisted
+func (impl ConfigMapHistoryServiceImpl) SaveAppLevelHistory(model *chartConfig.ConfigMapAppModel, dataType string, comment string) {
+	data := model.ConfigMapData
+	if dataType == chartConfig.ConfigMapHistoryTypeCS {
+		data = model.SecretData
+	}
+	_, err := impl.saveHistory(model.AppId, 0, dataType, data, comment, model.UpdatedBy)
+	if err != nil {
+		impl.logger.Errorw("error in saving config map history", "appId", model.AppId, "type", dataType, "err", err)
+	}
+}
+
+func (impl ConfigMapHistoryServiceImpl) SaveEnvLevelHistory(model *chartConfig.ConfigMapEnvModel, dataType string, comment string) {
+	data := model.ConfigMapData
+	if dataType == chartConfig.ConfigMapHistoryTypeCS {
+		data = model.SecretData
+	}
+	_, err := impl.saveHistory(model.AppId, model.EnvironmentId, dataType, data, comment, model.UpdatedBy)
+	if err != nil {
+		impl.logger.Errorw("error in saving config map history", "appId", model.AppId, "envId", model.EnvironmentId, "type", dataType, "err", err)
+	}
+}
+
+func (impl ConfigMapHistoryServiceImpl) saveHistory(appId int, envId int, dataType string, data string, comment string, userId int32) (*chartConfig.ConfigMapHistory, error) {
+	latest, err := impl.configMapHistoryRepository.FindLatest(appId, envId, dataType)
+	if err != nil && err != pg.ErrNoRows {
+		return nil, err
+	}
+	history := &chartConfig.ConfigMapHistory{
+		AppId:         appId,
+		EnvironmentId: envId,
+		DataType:      dataType,
+		Version:       latest.Version + 1,
+		Data:          data,
+		Comment:       comment,
+		AuditLog:      sql.AuditLog{CreatedOn: time.Now(), CreatedBy: userId, UpdatedOn: time.Now(), UpdatedBy: userId},
+	}
+	err = impl.configMapHistoryRepository.Save(history)
+	if err != nil {
+		return nil, err
+	}
+	impl.saveAuditEvent(latest, history)
+	return history, nil
+}
+
+// saveAuditEvent records the change between two versions, secret values are never part of the audit log
+func (impl ConfigMapHistoryServiceImpl) saveAuditEvent(previous *chartConfig.ConfigMapHistory, current *chartConfig.ConfigMapHistory) {
+	event := &auditLog.AuditEvent{
+		UserId:        current.CreatedBy,
+		ResourceType:  auditLog.ResourceConfigMap,
+		ResourceId:    strconv.Itoa(current.Id),
+		AppId:         current.AppId,
+		EnvironmentId: current.EnvironmentId,
+		Action:        auditLog.ActionCreate,
+	}
+	if current.DataType == chartConfig.ConfigMapHistoryTypeCS {
+		event.ResourceType = auditLog.ResourceSecret
+	}
+	if previous != nil && previous.Id > 0 {
+		event.Action = auditLog.ActionUpdate
+		event.Before, _ = historyConfigData(previous, false)
+	}
+	event.After, _ = historyConfigData(current, false)
+	impl.auditLogService.SaveEvent(event)
+}
+
+func (impl ConfigMapHistoryServiceImpl) GetVersions(appId int, envId int, dataType string) ([]*ConfigMapHistoryDto, error) {
+	histories, err := impl.configMapHistoryRepository.FindAll(appId, envId, dataType)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching config map history", "appId", appId, "envId", envId, "type", dataType, "err", err)
+		return nil, err
+	}
+	versions := make([]*ConfigMapHistoryDto, 0)
+	for _, history := range histories {
+		versions = append(versions, adaptConfigMapHistory(history))
+	}
+	return versions, nil
+}
+
+func (impl ConfigMapHistoryServiceImpl) GetVersion(appId int, envId int, id int, showSecretValues bool) (*ConfigMapHistoryDto, error) {
+	history, err := impl.findHistory(appId, envId, id)
+	if err != nil {
+		return nil, err
+	}
+	configData, err := historyConfigData(history, showSecretValues)
+	if err != nil {
+		return nil, err
+	}
+	dto := adaptConfigMapHistory(history)
+	dto.ConfigData = configData
+	return dto, nil
+}
+
+func (impl ConfigMapHistoryServiceImpl) Diff(appId int, envId int, fromId int, toId int, showSecretValues bool) (*ConfigMapHistoryDiff, error) {
+	from, err := impl.findHistory(appId, envId, fromId)
+	if err != nil {
+		return nil, err
+	}
+	to, err := impl.findHistory(appId, envId, toId)
+	if err != nil {
+		return nil, err
+	}
+	if from.DataType != to.DataType {
+		return nil, fmt.Errorf("cannot diff %s version with %s version", from.DataType, to.DataType)
+	}
+	fromData, err := historyConfigData(from, true)
+	if err != nil {
+		return nil, err
+	}
+	toData, err := historyConfigData(to, true)
+	if err != nil {
+		return nil, err
+	}
+	redact := from.DataType == chartConfig.ConfigMapHistoryTypeCS && !showSecretValues
+	return &ConfigMapHistoryDiff{
+		From:    adaptConfigMapHistory(from),
+		To:      adaptConfigMapHistory(to),
+		Changes: DiffConfigData(fromData, toData, redact),
+	}, nil
+}
+
+// Restore writes data of a previous version back to the app or env level row and records it as a new version
+func (impl ConfigMapHistoryServiceImpl) Restore(request *ConfigMapHistoryRestoreRequest) (*ConfigMapHistoryDto, error) {
+	history, err := impl.findHistory(request.AppId, request.EnvironmentId, request.Id)
+	if err != nil {
+		return nil, err
+	}
+	comment := fmt.Sprintf("restored version %d", history.Version)
+	if len(request.Comment) > 0 {
+		comment = fmt.Sprintf("%s: %s", comment, request.Comment)
+	}
+	if history.EnvironmentId > 0 {
+		model, err := impl.configMapRepository.GetByAppIdAndEnvIdEnvLevel(history.AppId, history.EnvironmentId)
+		if err != nil && err != pg.ErrNoRows {
+			impl.logger.Errorw("error in fetching env level config map", "appId", history.AppId, "envId", history.EnvironmentId, "err", err)
+			return nil, err
+		}
+		setHistoryData(&model.ConfigMapData, &model.SecretData, history)
+		model.UpdatedBy = request.UserId
+		model.UpdatedOn = time.Now()
+		if model.Id > 0 {
+			_, err = impl.configMapRepository.UpdateEnvLevel(model)
+		} else {
+			model.AppId = history.AppId
+			model.EnvironmentId = history.EnvironmentId
+			model.CreatedBy = request.UserId
+			model.CreatedOn = time.Now()
+			_, err = impl.configMapRepository.CreateEnvLevel(model)
+		}
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		model, err := impl.configMapRepository.GetByAppIdAppLevel(history.AppId)
+		if err != nil && err != pg.ErrNoRows {
+			impl.logger.Errorw("error in fetching app level config map", "appId", history.AppId, "err", err)
+			return nil, err
+		}
+		setHistoryData(&model.ConfigMapData, &model.SecretData, history)
+		model.UpdatedBy = request.UserId
+		model.UpdatedOn = time.Now()
+		if model.Id > 0 {
+			_, err = impl.configMapRepository.UpdateAppLevel(model)
+		} else {
+			model.AppId = history.AppId
+			model.CreatedBy = request.UserId
+			model.CreatedOn = time.Now()
+			_, err = impl.configMapRepository.CreateAppLevel(model)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	restored, err := impl.saveHistory(history.AppId, history.EnvironmentId, history.DataType, history.Data, comment, request.UserId)
+	if err != nil {
+		impl.logger.Errorw("error in saving config map history", "appId", history.AppId, "envId", history.EnvironmentId, "err", err)
+		return nil, err
+	}
+	return adaptConfigMapHistory(restored), nil
+}
+
+// findHistory fetches a version and checks it belongs to the app and env the caller was authorized for
+func (impl ConfigMapHistoryServiceImpl) findHistory(appId int, envId int, id int) (*chartConfig.ConfigMapHistory, error) {
+	history, err := impl.configMapHistoryRepository.FindById(id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching config map history", "id", id, "err", err)
+		return nil, err
+	}
+	if history.AppId != appId || history.EnvironmentId != envId {
+		return nil, fmt.Errorf("version %d not found for app %d and environment %d", id, appId, envId)
+	}
+	return history, nil
+}
+
+func setHistoryData(configMapData *string, secretData *string, history *chartConfig.ConfigMapHistory) {
+	if history.DataType == chartConfig.ConfigMapHistoryTypeCS {
+		*secretData = history.Data
+	} else {
+		*configMapData = history.Data
+	}
+}
+
+func historyConfigData(history *chartConfig.ConfigMapHistory, showSecretValues bool) ([]*ConfigData, error) {
+	var configData []*ConfigData
+	if len(history.Data) > 0 {
+		if history.DataType == chartConfig.ConfigMapHistoryTypeCS {
+			secretsList := &SecretsList{}
+			err := json.Unmarshal([]byte(history.Data), secretsList)
+			if err != nil {
+				return nil, err
+			}
+			configData = secretsList.ConfigData
+		} else {
+			configsList := &ConfigsList{}
+			err := json.Unmarshal([]byte(history.Data), configsList)
+			if err != nil {
+				return nil, err
+			}
+			configData = configsList.ConfigData
+		}
+	}
+	if history.DataType == chartConfig.ConfigMapHistoryTypeCS && !showSecretValues {
+		for _, item := range configData {
+			values := configDataValues(item)
+			for k := range values {
+				values[k] = ""
+			}
+			item.Data, _ = json.Marshal(values)
+		}
+	}
+	return configData, nil
+}
+
+// DiffConfigData compares two lists of config maps or secrets by name, key level changes of data are
+// reported separately from other attributes. Values are blanked when redact is set.
+func DiffConfigData(from []*ConfigData, to []*ConfigData, redact bool) []*ConfigDataDiff {
+	fromByName := make(map[string]*ConfigData)
+	for _, item := range from {
+		fromByName[item.Name] = item
+	}
+	toByName := make(map[string]*ConfigData)
+	for _, item := range to {
+		toByName[item.Name] = item
+	}
+	var names []string
+	for name := range fromByName {
+		names = append(names, name)
+	}
+	for name := range toByName {
+		if _, ok := fromByName[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := make([]*ConfigDataDiff, 0)
+	for _, name := range names {
+		fromItem, inFrom := fromByName[name]
+		toItem, inTo := toByName[name]
+		diff := &ConfigDataDiff{Name: name}
+		if !inFrom {
+			diff.Change = ConfigDiffAdded
+			fromItem = &ConfigData{}
+		} else if !inTo {
+			diff.Change = ConfigDiffDeleted
+			toItem = &ConfigData{}
+		} else {
+			diff.Change = ConfigDiffModified
+			diff.Fields = configDataFieldChanges(fromItem, toItem)
+		}
+		diff.Keys = diffConfigDataValues(configDataValues(fromItem), configDataValues(toItem), redact)
+		if diff.Change == ConfigDiffModified && len(diff.Fields) == 0 && len(diff.Keys) == 0 {
+			continue
+		}
+		changes = append(changes, diff)
+	}
+	return changes
+}
+
+func configDataValues(item *ConfigData) map[string]string {
+	values := make(map[string]string)
+	if len(item.Data) > 0 {
+		_ = json.Unmarshal(item.Data, &values)
+	}
+	return values
+}
+
+func diffConfigDataValues(from map[string]string, to map[string]string, redact bool) []*ConfigKeyDiff {
+	var keys []string
+	for k := range from {
+		keys = append(keys, k)
+	}
+	for k := range to {
+		if _, ok := from[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var diffs []*ConfigKeyDiff
+	for _, k := range keys {
+		fromValue, inFrom := from[k]
+		toValue, inTo := to[k]
+		var change string
+		if !inFrom {
+			change = ConfigDiffAdded
+		} else if !inTo {
+			change = ConfigDiffDeleted
+		} else if fromValue != toValue {
+			change = ConfigDiffModified
+		} else {
+			continue
+		}
+		keyDiff := &ConfigKeyDiff{Key: k, Change: change}
+		if !redact {
+			keyDiff.From = fromValue
+			keyDiff.To = toValue
+		}
+		diffs = append(diffs, keyDiff)
+	}
+	return diffs
+}
+
+func configDataFieldChanges(from *ConfigData, to *ConfigData) []string {
+	var fields []string
+	if from.Type != to.Type {
+		fields = append(fields, "type")
+	}
+	if from.External != to.External {
+		fields = append(fields, "external")
+	}
+	if from.MountPath != to.MountPath {
+		fields = append(fields, "mountPath")
+	}
+	if from.ExternalSecretType != to.ExternalSecretType {
+		fields = append(fields, "externalType")
+	}
+	if !reflect.DeepEqual(from.ExternalSecret, to.ExternalSecret) {
+		fields = append(fields, "secretData")
+	}
+	if from.RoleARN != to.RoleARN {
+		fields = append(fields, "roleARN")
+	}
+	if !reflect.DeepEqual(from.SecretStore, to.SecretStore) {
+		fields = append(fields, "secretStore")
+	}
+	if from.SubPath != to.SubPath {
+		fields = append(fields, "subPath")
+	}
+	if from.FilePermission != to.FilePermission {
+		fields = append(fields, "filePermission")
+	}
+	return fields
+}
+
+func adaptConfigMapHistory(history *chartConfig.ConfigMapHistory) *ConfigMapHistoryDto {
+	return &ConfigMapHistoryDto{
+		Id:            history.Id,
+		AppId:         history.AppId,
+		EnvironmentId: history.EnvironmentId,
+		Type:          history.DataType,
+		Version:       history.Version,
+		Comment:       history.Comment,
+		CreatedBy:     history.CreatedBy,
+		CreatedOn:     history.CreatedOn,
+	}
+}
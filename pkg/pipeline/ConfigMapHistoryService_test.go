@@ -0,0 +1,127 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/devtron-labs/devtron/internal/sql/repository/chartConfig"
+	"github.com/devtron-labs/devtron/internal/util"
+)
+
+func TestDiffConfigData(t *testing.T) {
+	from := []*ConfigData{
+		{Name: "app-config", Type: "environment", Data: json.RawMessage(`{"LOG_LEVEL":"info","PORT":"8080"}`)},
+		{Name: "removed", Type: "environment", Data: json.RawMessage(`{"A":"1"}`)},
+		{Name: "unchanged", Type: "volume", MountPath: "/etc/config", Data: json.RawMessage(`{"B":"2"}`)},
+	}
+	to := []*ConfigData{
+		{Name: "app-config", Type: "volume", MountPath: "/etc/app", Data: json.RawMessage(`{"LOG_LEVEL":"debug","TIMEOUT":"30"}`)},
+		{Name: "added", Type: "environment", Data: json.RawMessage(`{"C":"3"}`)},
+		{Name: "unchanged", Type: "volume", MountPath: "/etc/config", Data: json.RawMessage(`{"B":"2"}`)},
+	}
+	changes := DiffConfigData(from, to, false)
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes, got %d", len(changes))
+	}
+	added, modified, deleted := changes[0], changes[1], changes[2]
+	if added.Name != "added" || added.Change != ConfigDiffAdded || len(added.Keys) != 1 || added.Keys[0].To != "3" {
+		t.Errorf("unexpected added diff %+v", added)
+	}
+	if deleted.Name != "removed" || deleted.Change != ConfigDiffDeleted || len(deleted.Keys) != 1 || deleted.Keys[0].From != "1" {
+		t.Errorf("unexpected deleted diff %+v", deleted)
+	}
+	if modified.Name != "app-config" || modified.Change != ConfigDiffModified {
+		t.Fatalf("unexpected modified diff %+v", modified)
+	}
+	if !reflect.DeepEqual(modified.Fields, []string{"type", "mountPath"}) {
+		t.Errorf("unexpected field changes %v", modified.Fields)
+	}
+	wantKeys := []ConfigKeyDiff{
+		{Key: "LOG_LEVEL", Change: ConfigDiffModified, From: "info", To: "debug"},
+		{Key: "PORT", Change: ConfigDiffDeleted, From: "8080"},
+		{Key: "TIMEOUT", Change: ConfigDiffAdded, To: "30"},
+	}
+	for i, key := range modified.Keys {
+		if *key != wantKeys[i] {
+			t.Errorf("key diff %d = %+v, want %+v", i, *key, wantKeys[i])
+		}
+	}
+
+	for _, change := range DiffConfigData(from, to, true) {
+		for _, key := range change.Keys {
+			if key.From != "" || key.To != "" {
+				t.Errorf("redacted diff of %s shows values of key %s", change.Name, key.Key)
+			}
+		}
+	}
+}
+
+func TestHistoryConfigDataRedaction(t *testing.T) {
+	history := &chartConfig.ConfigMapHistory{
+		DataType: chartConfig.ConfigMapHistoryTypeCS,
+		Data:     `{"secrets":[{"name":"db","type":"environment","data":{"password":"c2VjcmV0"}}]}`,
+	}
+	configData, err := historyConfigData(history, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values := configDataValues(configData[0]); len(values) != 1 || values["password"] != "" {
+		t.Errorf("secret values should be blanked, got %v", values)
+	}
+	configData, err = historyConfigData(history, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values := configDataValues(configData[0]); values["password"] != "c2VjcmV0" {
+		t.Errorf("secret values should be shown, got %v", values)
+	}
+
+	history = &chartConfig.ConfigMapHistory{
+		DataType: chartConfig.ConfigMapHistoryTypeCM,
+		Data:     `{"maps":[{"name":"app-config","type":"environment","data":{"PORT":"8080"}}]}`,
+	}
+	configData, err = historyConfigData(history, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values := configDataValues(configData[0]); values["PORT"] != "8080" {
+		t.Errorf("config map values should never be blanked, got %v", values)
+	}
+}
+
+type configMapHistoryServiceStub struct {
+	ConfigMapHistoryService
+	version  *ConfigMapHistoryDto
+	restored bool
+}
+
+func (stub *configMapHistoryServiceStub) GetVersion(appId int, envId int, id int, showSecretValues bool) (*ConfigMapHistoryDto, error) {
+	return stub.version, nil
+}
+
+func (stub *configMapHistoryServiceStub) Restore(request *ConfigMapHistoryRestoreRequest) (*ConfigMapHistoryDto, error) {
+	stub.restored = true
+	return stub.version, nil
+}
+
+func TestConfigHistoryRestoreValidatesVersion(t *testing.T) {
+	historyService := &configMapHistoryServiceStub{version: &ConfigMapHistoryDto{
+		Id:   5,
+		Type: chartConfig.ConfigMapHistoryTypeCS,
+		ConfigData: []*ConfigData{
+			{Name: "gcp", External: true, ExternalSecretType: GCPSecretManager, ExternalSecret: []ExternalSecret{{Key: "db"}}},
+		},
+	}}
+	impl := ConfigMapServiceImpl{logger: util.NewSugardLogger(), configMapHistoryService: historyService}
+	_, err := impl.ConfigHistoryRestore(&ConfigMapHistoryRestoreRequest{Id: 5, AppId: 1})
+	if err == nil || historyService.restored {
+		t.Errorf("version failing secret validation should not be restored, err %v", err)
+	}
+
+	historyService.version.ConfigData = []*ConfigData{{Name: "db", Type: "environment", Data: json.RawMessage(`{"password":"c2VjcmV0"}`)}}
+	_, err = impl.ConfigHistoryRestore(&ConfigMapHistoryRestoreRequest{Id: 5, AppId: 1})
+	if err != nil || !historyService.restored {
+		t.Errorf("valid version should be restored, err %v", err)
+	}
+}
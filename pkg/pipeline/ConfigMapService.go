@@ -20,6 +20,7 @@ package pipeline
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/devtron-labs/devtron/api/bean"
 	"github.com/devtron-labs/devtron/internal/sql/repository/app"
 	"github.com/devtron-labs/devtron/internal/sql/repository/chartConfig"
 	"github.com/devtron-labs/devtron/internal/util"
@@ -46,6 +47,7 @@ type ConfigDataRequest struct {
 	AppId         int           `json:"appId"`
 	EnvironmentId int           `json:"environmentId,omitempty"`
 	ConfigData    []*ConfigData `json:"configData"`
+	Comment       string        `json:"comment,omitempty"`
 	UserId        int32         `json:"-"`
 }
 
@@ -81,20 +83,21 @@ type ExternalSecret struct {
 }
 
 type ConfigData struct {
-	Name                  string           `json:"name"`
-	Type                  string           `json:"type"`
-	External              bool             `json:"external"`
-	MountPath             string           `json:"mountPath,omitempty"`
-	Data                  json.RawMessage  `json:"data"`
-	DefaultData           json.RawMessage  `json:"defaultData,omitempty"`
-	DefaultMountPath      string           `json:"defaultMountPath,omitempty"`
-	Global                bool             `json:"global"`
-	ExternalSecretType    string           `json:"externalType"`
-	ExternalSecret        []ExternalSecret `json:"secretData"`
-	DefaultExternalSecret []ExternalSecret `json:"defaultSecretData,omitempty"`
-	RoleARN               string           `json:"roleARN"`
-	SubPath               bool             `json:"subPath"`
-	FilePermission        string           `json:"filePermission"`
+	Name                  string            `json:"name"`
+	Type                  string            `json:"type"`
+	External              bool              `json:"external"`
+	MountPath             string            `json:"mountPath,omitempty"`
+	Data                  json.RawMessage   `json:"data"`
+	DefaultData           json.RawMessage   `json:"defaultData,omitempty"`
+	DefaultMountPath      string            `json:"defaultMountPath,omitempty"`
+	Global                bool              `json:"global"`
+	ExternalSecretType    string            `json:"externalType"`
+	ExternalSecret        []ExternalSecret  `json:"secretData"`
+	DefaultExternalSecret []ExternalSecret  `json:"defaultSecretData,omitempty"`
+	RoleARN               string            `json:"roleARN"`
+	SecretStore           *bean.SecretStore `json:"secretStore,omitempty"`
+	SubPath               bool              `json:"subPath"`
+	FilePermission        string            `json:"filePermission"`
 }
 
 const (
@@ -102,6 +105,8 @@ const (
 	AWSSecretsManager string = "AWSSecretsManager"
 	AWSSystemManager  string = "AWSSystemManager"
 	HashiCorpVault    string = "HashiCorpVault"
+	GCPSecretManager  string = "GCPSecretManager"
+	AzureKeyVault     string = "AzureKeyVault"
 )
 
 type ConfigsList struct {
@@ -136,6 +141,8 @@ type ConfigMapService interface {
 	CSEnvironmentFetchForEdit(name string, id int, appId int, envId int) (*ConfigDataRequest, error)
 	ConfigSecretGlobalBulkPatch(bulkPatchRequest *BulkPatchRequest) (*BulkPatchRequest, error)
 	ConfigSecretEnvironmentBulkPatch(bulkPatchRequest *BulkPatchRequest) (*BulkPatchRequest, error)
+
+	ConfigHistoryRestore(request *ConfigMapHistoryRestoreRequest) (*ConfigMapHistoryDto, error)
 }
 
 type ConfigMapServiceImpl struct {
@@ -148,6 +155,7 @@ type ConfigMapServiceImpl struct {
 	environmentConfigRepository chartConfig.EnvConfigOverrideRepository
 	commonService               commonService.CommonService
 	appRepository               app.AppRepository
+	configMapHistoryService     ConfigMapHistoryService
 }
 
 func NewConfigMapServiceImpl(chartRepository chartConfig.ChartRepository,
@@ -156,7 +164,8 @@ func NewConfigMapServiceImpl(chartRepository chartConfig.ChartRepository,
 	mergeUtil util.MergeUtil,
 	pipelineConfigRepository chartConfig.PipelineConfigRepository,
 	configMapRepository chartConfig.ConfigMapRepository, environmentConfigRepository chartConfig.EnvConfigOverrideRepository,
-	commonService commonService.CommonService, appRepository app.AppRepository) *ConfigMapServiceImpl {
+	commonService commonService.CommonService, appRepository app.AppRepository,
+	configMapHistoryService ConfigMapHistoryService) *ConfigMapServiceImpl {
 	return &ConfigMapServiceImpl{
 		chartRepository:             chartRepository,
 		logger:                      logger,
@@ -167,6 +176,7 @@ func NewConfigMapServiceImpl(chartRepository chartConfig.ChartRepository,
 		environmentConfigRepository: environmentConfigRepository,
 		commonService:               commonService,
 		appRepository:               appRepository,
+		configMapHistoryService:     configMapHistoryService,
 	}
 }
 
@@ -246,6 +256,7 @@ func (impl ConfigMapServiceImpl) CMGlobalAddUpdate(configMapRequest *ConfigDataR
 			impl.logger.Errorw("error while fetching from db", "error", err)
 			return nil, err
 		}
+		impl.configMapHistoryService.SaveAppLevelHistory(model, chartConfig.ConfigMapHistoryTypeCM, configMapRequest.Comment)
 		configMapRequest.Id = configMap.Id
 
 	} else {
@@ -271,6 +282,7 @@ func (impl ConfigMapServiceImpl) CMGlobalAddUpdate(configMapRequest *ConfigDataR
 			impl.logger.Errorw("error while creating app level", "error", err)
 			return nil, err
 		}
+		impl.configMapHistoryService.SaveAppLevelHistory(model, chartConfig.ConfigMapHistoryTypeCM, configMapRequest.Comment)
 		configMapRequest.Id = configMap.Id
 	}
 
@@ -369,6 +381,7 @@ func (impl ConfigMapServiceImpl) CMEnvironmentAddUpdate(configMapRequest *Config
 			impl.logger.Errorw("error while fetching from db", "error", err)
 			return nil, err
 		}
+		impl.configMapHistoryService.SaveEnvLevelHistory(model, chartConfig.ConfigMapHistoryTypeCM, configMapRequest.Comment)
 		configMapRequest.Id = configMap.Id
 
 	} else {
@@ -395,6 +408,7 @@ func (impl ConfigMapServiceImpl) CMEnvironmentAddUpdate(configMapRequest *Config
 			impl.logger.Errorw("error while creating app level", "error", err)
 			return nil, err
 		}
+		impl.configMapHistoryService.SaveEnvLevelHistory(model, chartConfig.ConfigMapHistoryTypeCM, configMapRequest.Comment)
 		configMapRequest.Id = configMap.Id
 	}
 
@@ -492,14 +506,8 @@ func (impl ConfigMapServiceImpl) CSGlobalAddUpdate(configMapRequest *ConfigDataR
 		return nil, fmt.Errorf("invalid request multiple config found for add or update")
 	}
 	configData := configMapRequest.ConfigData[0]
-	valid, err := impl.validateConfigData(configData)
-	if err != nil && !valid {
-		impl.logger.Errorw("error in validating", "error", err)
-		return configMapRequest, err
-	}
-
-	valid, err = impl.validateExternalSecretChartCompatibility(configMapRequest.AppId, configMapRequest.EnvironmentId, configData)
-	if err != nil && !valid {
+	err := impl.validateSecret(configMapRequest.AppId, configMapRequest.EnvironmentId, configData)
+	if err != nil {
 		impl.logger.Errorw("error in validating", "error", err)
 		return configMapRequest, err
 	}
@@ -528,6 +536,7 @@ func (impl ConfigMapServiceImpl) CSGlobalAddUpdate(configMapRequest *ConfigDataR
 				item.ExternalSecretType = configData.ExternalSecretType
 				item.ExternalSecret = configData.ExternalSecret
 				item.RoleARN = configData.RoleARN
+				item.SecretStore = configData.SecretStore
 				found = true
 				item.SubPath = configData.SubPath
 				item.FilePermission = configData.FilePermission
@@ -552,6 +561,7 @@ func (impl ConfigMapServiceImpl) CSGlobalAddUpdate(configMapRequest *ConfigDataR
 			impl.logger.Errorw("error while fetching from db", "error", err)
 			return nil, err
 		}
+		impl.configMapHistoryService.SaveAppLevelHistory(model, chartConfig.ConfigMapHistoryTypeCS, configMapRequest.Comment)
 		configMapRequest.Id = secret.Id
 
 	} else {
@@ -577,6 +587,7 @@ func (impl ConfigMapServiceImpl) CSGlobalAddUpdate(configMapRequest *ConfigDataR
 			impl.logger.Errorw("error while creating app level", "error", err)
 			return nil, err
 		}
+		impl.configMapHistoryService.SaveAppLevelHistory(model, chartConfig.ConfigMapHistoryTypeCS, configMapRequest.Comment)
 		configMapRequest.Id = secret.Id
 	}
 
@@ -661,14 +672,8 @@ func (impl ConfigMapServiceImpl) CSEnvironmentAddUpdate(configMapRequest *Config
 	}
 
 	configData := configMapRequest.ConfigData[0]
-	valid, err := impl.validateConfigData(configData)
-	if err != nil && !valid {
-		impl.logger.Errorw("error in validating", "error", err)
-		return configMapRequest, err
-	}
-
-	valid, err = impl.validateExternalSecretChartCompatibility(configMapRequest.AppId, configMapRequest.EnvironmentId, configData)
-	if err != nil && !valid {
+	err := impl.validateSecret(configMapRequest.AppId, configMapRequest.EnvironmentId, configData)
+	if err != nil {
 		impl.logger.Errorw("error in validating", "error", err)
 		return configMapRequest, err
 	}
@@ -697,6 +702,7 @@ func (impl ConfigMapServiceImpl) CSEnvironmentAddUpdate(configMapRequest *Config
 				item.ExternalSecretType = configData.ExternalSecretType
 				item.ExternalSecret = configData.ExternalSecret
 				item.RoleARN = configData.RoleARN
+				item.SecretStore = configData.SecretStore
 				item.SubPath = configData.SubPath
 				item.FilePermission = configData.FilePermission
 				found = true
@@ -721,6 +727,7 @@ func (impl ConfigMapServiceImpl) CSEnvironmentAddUpdate(configMapRequest *Config
 			impl.logger.Errorw("error while fetching from db", "error", err)
 			return nil, err
 		}
+		impl.configMapHistoryService.SaveEnvLevelHistory(model, chartConfig.ConfigMapHistoryTypeCS, configMapRequest.Comment)
 		configMapRequest.Id = configMap.Id
 
 	} else {
@@ -747,6 +754,7 @@ func (impl ConfigMapServiceImpl) CSEnvironmentAddUpdate(configMapRequest *Config
 			impl.logger.Errorw("error while creating app level", "error", err)
 			return nil, err
 		}
+		impl.configMapHistoryService.SaveEnvLevelHistory(model, chartConfig.ConfigMapHistoryTypeCS, configMapRequest.Comment)
 		configMapRequest.Id = configMap.Id
 	}
 
@@ -966,6 +974,7 @@ func (impl ConfigMapServiceImpl) CMGlobalDelete(name string, id int, userId int3
 			impl.logger.Errorw("error while updating at app level", "error", err)
 			return false, err
 		}
+		impl.configMapHistoryService.SaveAppLevelHistory(model, chartConfig.ConfigMapHistoryTypeCM, fmt.Sprintf("deleted %s", name))
 	} else {
 		impl.logger.Debugw("no config map found for delete with this name", "name", name)
 
@@ -1013,6 +1022,7 @@ func (impl ConfigMapServiceImpl) CMEnvironmentDelete(name string, id int, userId
 			impl.logger.Errorw("error while updating at env level", "error", err)
 			return false, err
 		}
+		impl.configMapHistoryService.SaveEnvLevelHistory(model, chartConfig.ConfigMapHistoryTypeCM, fmt.Sprintf("deleted %s", name))
 	} else {
 		impl.logger.Debugw("no config map found for delete with this name", "name", name)
 	}
@@ -1059,6 +1069,7 @@ func (impl ConfigMapServiceImpl) CSGlobalDelete(name string, id int, userId int3
 			impl.logger.Errorw("error while updating at app level", "error", err)
 			return false, err
 		}
+		impl.configMapHistoryService.SaveAppLevelHistory(model, chartConfig.ConfigMapHistoryTypeCS, fmt.Sprintf("deleted %s", name))
 	} else {
 		impl.logger.Debugw("no config map found for delete with this name", "name", name)
 
@@ -1106,6 +1117,7 @@ func (impl ConfigMapServiceImpl) CSEnvironmentDelete(name string, id int, userId
 			impl.logger.Errorw("error while updating at env level ", "error", err)
 			return false, err
 		}
+		impl.configMapHistoryService.SaveEnvLevelHistory(model, chartConfig.ConfigMapHistoryTypeCS, fmt.Sprintf("deleted %s", name))
 	} else {
 		impl.logger.Debugw("no config map found for delete with this name", "name", name)
 	}
@@ -1154,6 +1166,7 @@ func (impl ConfigMapServiceImpl) CMGlobalDeleteByAppId(name string, appId int, u
 			impl.logger.Errorw("error while updating at app level", "error", err)
 			return false, err
 		}
+		impl.configMapHistoryService.SaveAppLevelHistory(model, chartConfig.ConfigMapHistoryTypeCM, fmt.Sprintf("deleted %s", name))
 	} else {
 		impl.logger.Debugw("no config map found for delete with this name", "name", name)
 
@@ -1201,6 +1214,7 @@ func (impl ConfigMapServiceImpl) CMEnvironmentDeleteByAppIdAndEnvId(name string,
 			impl.logger.Errorw("error while updating at env level", "error", err)
 			return false, err
 		}
+		impl.configMapHistoryService.SaveEnvLevelHistory(model, chartConfig.ConfigMapHistoryTypeCM, fmt.Sprintf("deleted %s", name))
 	} else {
 		impl.logger.Debugw("no config map found for delete with this name", "name", name)
 	}
@@ -1247,6 +1261,7 @@ func (impl ConfigMapServiceImpl) CSGlobalDeleteByAppId(name string, appId int, u
 			impl.logger.Errorw("error while updating at app level", "error", err)
 			return false, err
 		}
+		impl.configMapHistoryService.SaveAppLevelHistory(model, chartConfig.ConfigMapHistoryTypeCS, fmt.Sprintf("deleted %s", name))
 	} else {
 		impl.logger.Debugw("no config map found for delete with this name", "name", name)
 
@@ -1294,6 +1309,7 @@ func (impl ConfigMapServiceImpl) CSEnvironmentDeleteByAppIdAndEnvId(name string,
 			impl.logger.Errorw("error while updating at env level ", "error", err)
 			return false, err
 		}
+		impl.configMapHistoryService.SaveEnvLevelHistory(model, chartConfig.ConfigMapHistoryTypeCS, fmt.Sprintf("deleted %s", name))
 	} else {
 		impl.logger.Debugw("no config map found for delete with this name", "name", name)
 	}
@@ -1522,6 +1538,7 @@ func (impl ConfigMapServiceImpl) ConfigSecretGlobalBulkPatch(bulkPatchRequest *B
 			impl.logger.Errorw("error while fetching from db", "error", err)
 			return nil, err
 		}
+		impl.configMapHistoryService.SaveAppLevelHistory(model, bulkPatchRequest.Type, "bulk patch")
 	}
 	return bulkPatchRequest, nil
 }
@@ -1607,11 +1624,64 @@ func (impl ConfigMapServiceImpl) ConfigSecretEnvironmentBulkPatch(bulkPatchReque
 			impl.logger.Errorw("error while fetching from db", "error", err)
 			return nil, err
 		}
+		impl.configMapHistoryService.SaveEnvLevelHistory(model, bulkPatchRequest.Type, "bulk patch")
 	}
 	return bulkPatchRequest, nil
 }
 
+// validateSecret runs the checks every secret goes through before it is saved
+func (impl ConfigMapServiceImpl) validateSecret(appId int, envId int, configData *ConfigData) error {
+	valid, err := impl.validateConfigData(configData)
+	if err != nil && !valid {
+		return err
+	}
+	valid, err = impl.validateExternalSecretType(appId, envId, configData)
+	if err != nil && !valid {
+		return err
+	}
+	valid, err = impl.validateExternalSecretChartCompatibility(appId, envId, configData)
+	if err != nil && !valid {
+		return err
+	}
+	return nil
+}
+
+// ConfigHistoryRestore validates the config maps or secrets of a previous version like they are validated on save
+// before the version is written back
+func (impl ConfigMapServiceImpl) ConfigHistoryRestore(request *ConfigMapHistoryRestoreRequest) (*ConfigMapHistoryDto, error) {
+	version, err := impl.configMapHistoryService.GetVersion(request.AppId, request.EnvironmentId, request.Id, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, configData := range version.ConfigData {
+		if version.Type == chartConfig.ConfigMapHistoryTypeCS {
+			err = impl.validateSecret(request.AppId, request.EnvironmentId, configData)
+		} else {
+			_, err = impl.validateConfigData(configData)
+		}
+		if err != nil {
+			impl.logger.Errorw("error in validating restored version", "id", request.Id, "name", configData.Name, "err", err)
+			return nil, err
+		}
+	}
+	return impl.configMapHistoryService.Restore(request)
+}
+
 func (impl ConfigMapServiceImpl) validateExternalSecretChartCompatibility(appId int, envId int, configData *ConfigData) (bool, error) {
+	if configData.External && (configData.ExternalSecretType == GCPSecretManager || configData.ExternalSecretType == AzureKeyVault) {
+		chart, err := impl.commonService.FetchLatestChart(appId, envId)
+		if err != nil {
+			return false, err
+		}
+		chartMajorVersion, chartMinorVersion, err := util2.ExtractChartVersion(chart.ChartVersion)
+		if err != nil {
+			impl.logger.Errorw("chart version parsing", "err", err)
+			return false, err
+		}
+		if !isCloudSecretStoreSupported(chartMajorVersion, chartMinorVersion) {
+			return false, fmt.Errorf("this chart version dosent support %s, please upgrade chart: %s", configData.ExternalSecretType, configData.Name)
+		}
+	}
 
 	if configData.ExternalSecret != nil && len(configData.ExternalSecret) > 0 {
 		for _, es := range configData.ExternalSecret {
@@ -1635,6 +1705,81 @@ func (impl ConfigMapServiceImpl) validateExternalSecretChartCompatibility(appId
 	return true, nil
 }
 
+// validateExternalSecretType checks the settings of an external secret. Env level GCPSecretManager and AzureKeyVault
+// secrets inherit the secret store of the app level secret of the same name, so their store is checked after the merge
+func (impl ConfigMapServiceImpl) validateExternalSecretType(appId int, envId int, configData *ConfigData) (bool, error) {
+	if !configData.External {
+		return true, nil
+	}
+	secretStore := configData.SecretStore
+	if envId > 0 && (configData.ExternalSecretType == GCPSecretManager || configData.ExternalSecretType == AzureKeyVault) {
+		appLevelStore, err := impl.appLevelSecretStore(appId, configData.Name, configData.ExternalSecretType)
+		if err != nil {
+			return false, err
+		}
+		secretStore = util.MergeSecretStore(appLevelStore, secretStore)
+	}
+	switch configData.ExternalSecretType {
+	case "", KubernetesSecret, AWSSecretsManager, AWSSystemManager, HashiCorpVault:
+		return true, nil
+	case GCPSecretManager:
+		if secretStore == nil || len(secretStore.ProjectId) == 0 {
+			return false, fmt.Errorf("projectId is required for GCPSecretManager secret: %s", configData.Name)
+		}
+	case AzureKeyVault:
+		if secretStore == nil || len(secretStore.VaultUrl) == 0 {
+			return false, fmt.Errorf("vaultUrl is required for AzureKeyVault secret: %s", configData.Name)
+		}
+		if len(secretStore.CredentialSecretName) > 0 && len(secretStore.TenantId) == 0 {
+			return false, fmt.Errorf("tenantId is required for service principal auth of AzureKeyVault secret: %s", configData.Name)
+		}
+	default:
+		return false, fmt.Errorf("unsupported external secret type %s for secret: %s", configData.ExternalSecretType, configData.Name)
+	}
+	if len(configData.ExternalSecret) == 0 {
+		return false, fmt.Errorf("secret data is required for %s secret: %s", configData.ExternalSecretType, configData.Name)
+	}
+	if len(secretStore.ServiceAccountName) > 0 && len(secretStore.CredentialSecretName) > 0 {
+		return false, fmt.Errorf("only one of serviceAccountName or credentialSecretName can be provided for secret: %s", configData.Name)
+	}
+	return true, nil
+}
+
+// appLevelSecretStore returns the secret store of the app level secret an env level secret is merged with
+func (impl ConfigMapServiceImpl) appLevelSecretStore(appId int, name string, externalSecretType string) (*bean.SecretStore, error) {
+	model, err := impl.configMapRepository.GetByAppIdAppLevel(appId)
+	if err == pg.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		impl.logger.Errorw("error while fetching from db", "appId", appId, "error", err)
+		return nil, err
+	}
+	if len(model.SecretData) == 0 {
+		return nil, nil
+	}
+	secretsList := &SecretsList{}
+	err = json.Unmarshal([]byte(model.SecretData), secretsList)
+	if err != nil {
+		impl.logger.Errorw("error while unmarshal", "appId", appId, "error", err)
+		return nil, err
+	}
+	for _, item := range secretsList.ConfigData {
+		if item.Name == name && item.ExternalSecretType == externalSecretType {
+			return item.SecretStore, nil
+		}
+	}
+	return nil, nil
+}
+
+// isCloudSecretStoreSupported reports whether reference chart renders GCPSecretManager and AzureKeyVault
+// secrets, support was added in 4.12.0
+func isCloudSecretStoreSupported(chartMajorVersion int, chartMinorVersion int) bool {
+	if chartMajorVersion == 4 {
+		return chartMinorVersion >= 12
+	}
+	return chartMajorVersion > 4
+}
+
 func (impl ConfigMapServiceImpl) buildBulkPayload(bulkPatchRequest *BulkPatchRequest) (*BulkPatchRequest, error) {
 	var payload []*BulkPatchPayload
 	if bulkPatchRequest.Filter != nil {
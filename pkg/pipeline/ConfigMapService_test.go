@@ -0,0 +1,43 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/devtron-labs/devtron/api/bean"
+	"github.com/devtron-labs/devtron/internal/sql/repository/chartConfig"
+	"github.com/devtron-labs/devtron/internal/util"
+)
+
+type appLevelSecretRepositoryStub struct {
+	chartConfig.ConfigMapRepository
+	secretData string
+}
+
+func (stub appLevelSecretRepositoryStub) GetByAppIdAppLevel(appId int) (*chartConfig.ConfigMapAppModel, error) {
+	return &chartConfig.ConfigMapAppModel{Id: 1, AppId: appId, SecretData: stub.secretData}, nil
+}
+
+func TestValidateExternalSecretTypeInheritsAppLevelStore(t *testing.T) {
+	impl := ConfigMapServiceImpl{
+		logger: util.NewSugardLogger(),
+		configMapRepository: appLevelSecretRepositoryStub{secretData: `{"secrets":[
+			{"name":"azure","external":true,"externalType":"AzureKeyVault","secretStore":{"vaultUrl":"https://kv","tenantId":"t1"}}]}`},
+	}
+	envLevel := &ConfigData{
+		Name:               "azure",
+		External:           true,
+		ExternalSecretType: AzureKeyVault,
+		ExternalSecret:     []ExternalSecret{{Key: "db"}},
+		SecretStore:        &bean.SecretStore{CredentialSecretName: "sp-prod"},
+	}
+	if valid, err := impl.validateExternalSecretType(1, 2, envLevel); !valid || err != nil {
+		t.Errorf("env level secret should inherit vaultUrl and tenantId of app level, err %v", err)
+	}
+	if valid, err := impl.validateExternalSecretType(1, 0, envLevel); valid || err == nil {
+		t.Errorf("app level secret without vaultUrl should be refused")
+	}
+	envLevel.Name = "other"
+	if valid, err := impl.validateExternalSecretType(1, 2, envLevel); valid || err == nil {
+		t.Errorf("env level secret without app level store should need vaultUrl")
+	}
+}
@@ -0,0 +1,370 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/devtron-labs/devtron/internal/sql/repository/chartConfig"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/go-pg/pg"
+	"github.com/juju/errors"
+	"go.uber.org/zap"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	TemplateSourceApp = "APP"
+	TemplateSourceEnv = "ENV"
+)
+
+type DeploymentTemplateHistoryDto struct {
+	Id            int             `json:"id"`
+	AppId         int             `json:"appId"`
+	EnvironmentId int             `json:"environmentId"`
+	ChartId       int             `json:"chartId"`
+	ChartVersion  string          `json:"chartVersion"`
+	IsOverride    bool            `json:"isOverride"`
+	Version       int             `json:"version"`
+	Comment       string          `json:"comment"`
+	Values        json.RawMessage `json:"values,omitempty"`
+	CreatedBy     int32           `json:"createdBy"`
+	CreatedOn     time.Time       `json:"createdOn"`
+}
+
+type DeploymentTemplateHistoryDiff struct {
+	From    *DeploymentTemplateHistoryDto `json:"from"`
+	To      *DeploymentTemplateHistoryDto `json:"to"`
+	Changes []*ValuesDiff                 `json:"changes"`
+}
+
+// EffectiveTemplate describes which values an environment deploys with, env overrides replace app level
+// values entirely so Source tells where they come from
+type EffectiveTemplate struct {
+	EnvironmentId int    `json:"environmentId"`
+	Source        string `json:"source"`
+	ChartId       int    `json:"chartId"`
+	ChartVersion  string `json:"chartVersion"`
+	Values        string `json:"-"`
+}
+
+type DeploymentTemplateEnvDiff struct {
+	AppId   int                `json:"appId"`
+	From    *EffectiveTemplate `json:"from"`
+	To      *EffectiveTemplate `json:"to"`
+	Changes []*ValuesDiff      `json:"changes"`
+}
+
+type ValuesDiff struct {
+	Path   string      `json:"path"`
+	Change string      `json:"change"`
+	From   interface{} `json:"from,omitempty"`
+	To     interface{} `json:"to,omitempty"`
+}
+
+type DeploymentTemplateHistoryService interface {
+	SaveAppLevelHistory(chart *chartConfig.Chart, comment string)
+	SaveEnvLevelHistory(chart *chartConfig.Chart, override *chartConfig.EnvConfigOverride, envId int, comment string)
+	GetVersions(appId int, envId int) ([]*DeploymentTemplateHistoryDto, error)
+	GetVersion(appId int, envId int, id int) (*DeploymentTemplateHistoryDto, error)
+	Diff(appId int, envId int, fromId int, toId int) (*DeploymentTemplateHistoryDiff, error)
+	EnvDiff(appId int, fromEnvId int, toEnvId int) (*DeploymentTemplateEnvDiff, error)
+}
+
+type DeploymentTemplateHistoryServiceImpl struct {
+	logger                              *zap.SugaredLogger
+	deploymentTemplateHistoryRepository chartConfig.DeploymentTemplateHistoryRepository
+	chartRepository                     chartConfig.ChartRepository
+	envConfigRepository                 chartConfig.EnvConfigOverrideRepository
+	auditLogService                     auditLog.AuditLogService
+}
+
+func NewDeploymentTemplateHistoryServiceImpl(logger *zap.SugaredLogger,
+	deploymentTemplateHistoryRepository chartConfig.DeploymentTemplateHistoryRepository,
+	chartRepository chartConfig.ChartRepository,
+	envConfigRepository chartConfig.EnvConfigOverrideRepository,
+	auditLogService auditLog.AuditLogService) *DeploymentTemplateHistoryServiceImpl {
+	return &DeploymentTemplateHistoryServiceImpl{
+		logger:                              logger,
+		deploymentTemplateHistoryRepository: deploymentTemplateHistoryRepository,
+		chartRepository:                     chartRepository,
+		envConfigRepository:                 envConfigRepository,
+		auditLogService:                     auditLogService,
+	}
+}
+
+// SaveAppLevelHistory records the global override of chart as a new version, failures are only logged as
+// the change itself has already been persisted
+func (impl DeploymentTemplateHistoryServiceImpl) SaveAppLevelHistory(chart *chartConfig.Chart, comment string) {
+	history := &chartConfig.DeploymentTemplateHistory{
+		AppId:        chart.AppId,
+		ChartId:      chart.Id,
+		ChartVersion: chart.ChartVersion,
+		Values:       chart.GlobalOverride,
+		Comment:      comment,
+		AuditLog:     sql.AuditLog{CreatedOn: time.Now(), CreatedBy: chart.UpdatedBy, UpdatedOn: time.Now(), UpdatedBy: chart.UpdatedBy},
+	}
+	err := impl.saveHistory(history)
+	if err != nil {
+		impl.logger.Errorw("error in saving deployment template history", "appId", chart.AppId, "chartId", chart.Id, "err", err)
+	}
+}
+
+func (impl DeploymentTemplateHistoryServiceImpl) SaveEnvLevelHistory(chart *chartConfig.Chart, override *chartConfig.EnvConfigOverride, envId int, comment string) {
+	history := &chartConfig.DeploymentTemplateHistory{
+		AppId:         chart.AppId,
+		EnvironmentId: envId,
+		ChartId:       chart.Id,
+		ChartVersion:  chart.ChartVersion,
+		IsOverride:    override.IsOverride,
+		Values:        override.EnvOverrideValues,
+		Comment:       comment,
+		AuditLog:      sql.AuditLog{CreatedOn: time.Now(), CreatedBy: override.UpdatedBy, UpdatedOn: time.Now(), UpdatedBy: override.UpdatedBy},
+	}
+	err := impl.saveHistory(history)
+	if err != nil {
+		impl.logger.Errorw("error in saving deployment template history", "appId", chart.AppId, "envId", envId, "err", err)
+	}
+}
+
+func (impl DeploymentTemplateHistoryServiceImpl) saveHistory(history *chartConfig.DeploymentTemplateHistory) error {
+	latest, err := impl.deploymentTemplateHistoryRepository.FindLatest(history.AppId, history.EnvironmentId)
+	if err != nil && err != pg.ErrNoRows {
+		return err
+	}
+	history.Version = latest.Version + 1
+	err = impl.deploymentTemplateHistoryRepository.Save(history)
+	if err != nil {
+		return err
+	}
+	event := &auditLog.AuditEvent{
+		UserId:        history.CreatedBy,
+		ResourceType:  auditLog.ResourceDeploymentTemplate,
+		ResourceId:    strconv.Itoa(history.Id),
+		AppId:         history.AppId,
+		EnvironmentId: history.EnvironmentId,
+		Action:        auditLog.ActionCreate,
+		After:         history.Values,
+	}
+	if latest.Id > 0 {
+		event.Action = auditLog.ActionUpdate
+		event.Before = latest.Values
+	}
+	impl.auditLogService.SaveEvent(event)
+	return nil
+}
+
+func (impl DeploymentTemplateHistoryServiceImpl) GetVersions(appId int, envId int) ([]*DeploymentTemplateHistoryDto, error) {
+	histories, err := impl.deploymentTemplateHistoryRepository.FindAll(appId, envId)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching deployment template history", "appId", appId, "envId", envId, "err", err)
+		return nil, err
+	}
+	versions := make([]*DeploymentTemplateHistoryDto, 0)
+	for _, history := range histories {
+		versions = append(versions, adaptDeploymentTemplateHistory(history))
+	}
+	return versions, nil
+}
+
+func (impl DeploymentTemplateHistoryServiceImpl) GetVersion(appId int, envId int, id int) (*DeploymentTemplateHistoryDto, error) {
+	history, err := impl.findHistory(appId, envId, id)
+	if err != nil {
+		return nil, err
+	}
+	dto := adaptDeploymentTemplateHistory(history)
+	if len(history.Values) > 0 {
+		dto.Values = json.RawMessage(history.Values)
+	}
+	return dto, nil
+}
+
+func (impl DeploymentTemplateHistoryServiceImpl) Diff(appId int, envId int, fromId int, toId int) (*DeploymentTemplateHistoryDiff, error) {
+	from, err := impl.findHistory(appId, envId, fromId)
+	if err != nil {
+		return nil, err
+	}
+	to, err := impl.findHistory(appId, envId, toId)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := DiffValues(from.Values, to.Values)
+	if err != nil {
+		impl.logger.Errorw("error in diff of deployment template versions", "from", fromId, "to", toId, "err", err)
+		return nil, err
+	}
+	return &DeploymentTemplateHistoryDiff{
+		From:    adaptDeploymentTemplateHistory(from),
+		To:      adaptDeploymentTemplateHistory(to),
+		Changes: changes,
+	}, nil
+}
+
+// EnvDiff compares values two environments of an app are deployed with, env id 0 refers to app level values
+func (impl DeploymentTemplateHistoryServiceImpl) EnvDiff(appId int, fromEnvId int, toEnvId int) (*DeploymentTemplateEnvDiff, error) {
+	from, err := impl.effectiveTemplate(appId, fromEnvId)
+	if err != nil {
+		return nil, err
+	}
+	to, err := impl.effectiveTemplate(appId, toEnvId)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := DiffValues(from.Values, to.Values)
+	if err != nil {
+		impl.logger.Errorw("error in diff of environment templates", "appId", appId, "from", fromEnvId, "to", toEnvId, "err", err)
+		return nil, err
+	}
+	return &DeploymentTemplateEnvDiff{AppId: appId, From: from, To: to, Changes: changes}, nil
+}
+
+// effectiveTemplate mirrors how values are picked at deploy time, env override values if the env is
+// overridden otherwise global override of the chart
+func (impl DeploymentTemplateHistoryServiceImpl) effectiveTemplate(appId int, envId int) (*EffectiveTemplate, error) {
+	if envId > 0 {
+		envOverride, err := impl.envConfigRepository.FindLatestChartForAppByAppIdAndEnvId(appId, envId)
+		if err != nil && !errors.IsNotFound(err) {
+			impl.logger.Errorw("error in fetching env override", "appId", appId, "envId", envId, "err", err)
+			return nil, err
+		}
+		if envOverride != nil && envOverride.Id > 0 && envOverride.Chart != nil {
+			template := &EffectiveTemplate{
+				EnvironmentId: envId,
+				Source:        TemplateSourceApp,
+				ChartId:       envOverride.Chart.Id,
+				ChartVersion:  envOverride.Chart.ChartVersion,
+				Values:        envOverride.Chart.GlobalOverride,
+			}
+			if envOverride.IsOverride {
+				template.Source = TemplateSourceEnv
+				template.Values = envOverride.EnvOverrideValues
+			}
+			return template, nil
+		}
+	}
+	chart, err := impl.chartRepository.FindLatestChartForAppByAppId(appId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching latest chart", "appId", appId, "err", err)
+		return nil, err
+	}
+	return &EffectiveTemplate{
+		EnvironmentId: envId,
+		Source:        TemplateSourceApp,
+		ChartId:       chart.Id,
+		ChartVersion:  chart.ChartVersion,
+		Values:        chart.GlobalOverride,
+	}, nil
+}
+
+func (impl DeploymentTemplateHistoryServiceImpl) findHistory(appId int, envId int, id int) (*chartConfig.DeploymentTemplateHistory, error) {
+	history, err := impl.deploymentTemplateHistoryRepository.FindById(id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching deployment template history", "id", id, "err", err)
+		return nil, err
+	}
+	if history.AppId != appId || history.EnvironmentId != envId {
+		return nil, fmt.Errorf("version %d not found for app %d and environment %d", id, appId, envId)
+	}
+	return history, nil
+}
+
+// DiffValues compares two json documents leaf by leaf, nested objects are walked and reported as dotted
+// paths while arrays are compared as a whole
+func DiffValues(from string, to string) ([]*ValuesDiff, error) {
+	fromValues, err := flattenValuesJson(from)
+	if err != nil {
+		return nil, err
+	}
+	toValues, err := flattenValuesJson(to)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for path := range fromValues {
+		paths = append(paths, path)
+	}
+	for path := range toValues {
+		if _, ok := fromValues[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	diffs := make([]*ValuesDiff, 0)
+	for _, path := range paths {
+		fromValue, inFrom := fromValues[path]
+		toValue, inTo := toValues[path]
+		if !inFrom {
+			diffs = append(diffs, &ValuesDiff{Path: path, Change: ConfigDiffAdded, To: toValue})
+		} else if !inTo {
+			diffs = append(diffs, &ValuesDiff{Path: path, Change: ConfigDiffDeleted, From: fromValue})
+		} else if !reflect.DeepEqual(fromValue, toValue) {
+			diffs = append(diffs, &ValuesDiff{Path: path, Change: ConfigDiffModified, From: fromValue, To: toValue})
+		}
+	}
+	return diffs, nil
+}
+
+func flattenValuesJson(values string) (map[string]interface{}, error) {
+	flat := make(map[string]interface{})
+	if len(values) == 0 {
+		return flat, nil
+	}
+	var root interface{}
+	err := json.Unmarshal([]byte(values), &root)
+	if err != nil {
+		return nil, err
+	}
+	flattenValues("", root, flat)
+	return flat, nil
+}
+
+func flattenValues(prefix string, value interface{}, flat map[string]interface{}) {
+	object, ok := value.(map[string]interface{})
+	if !ok || (len(object) == 0 && len(prefix) > 0) {
+		if len(prefix) > 0 {
+			flat[prefix] = value
+		}
+		return
+	}
+	for k, v := range object {
+		path := k
+		if len(prefix) > 0 {
+			path = prefix + "." + k
+		}
+		flattenValues(path, v, flat)
+	}
+}
+
+func adaptDeploymentTemplateHistory(history *chartConfig.DeploymentTemplateHistory) *DeploymentTemplateHistoryDto {
+	return &DeploymentTemplateHistoryDto{
+		Id:            history.Id,
+		AppId:         history.AppId,
+		EnvironmentId: history.EnvironmentId,
+		ChartId:       history.ChartId,
+		ChartVersion:  history.ChartVersion,
+		IsOverride:    history.IsOverride,
+		Version:       history.Version,
+		Comment:       history.Comment,
+		CreatedBy:     history.CreatedBy,
+		CreatedOn:     history.CreatedOn,
+	}
+}
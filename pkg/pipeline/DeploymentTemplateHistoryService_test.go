@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pipeline
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffValues(t *testing.T) {
+	type args struct {
+		from string
+		to   string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []*ValuesDiff
+		wantErr bool
+	}{
+		{name: "no change",
+			args: args{from: `{"replicaCount":1}`, to: `{"replicaCount":1}`},
+			want: []*ValuesDiff{},
+		},
+		{name: "nested paths",
+			args: args{
+				from: `{"resources":{"limits":{"cpu":"1","memory":"1Gi"}},"replicaCount":1}`,
+				to:   `{"resources":{"limits":{"cpu":"2"}},"replicaCount":1,"autoscaling":{"enabled":true}}`,
+			},
+			want: []*ValuesDiff{
+				{Path: "autoscaling.enabled", Change: ConfigDiffAdded, To: true},
+				{Path: "resources.limits.cpu", Change: ConfigDiffModified, From: "1", To: "2"},
+				{Path: "resources.limits.memory", Change: ConfigDiffDeleted, From: "1Gi"},
+			},
+		},
+		{name: "arrays compared as a whole",
+			args: args{from: `{"args":["a","b"]}`, to: `{"args":["a"]}`},
+			want: []*ValuesDiff{
+				{Path: "args", Change: ConfigDiffModified, From: []interface{}{"a", "b"}, To: []interface{}{"a"}},
+			},
+		},
+		{name: "empty from",
+			args: args{from: "", to: `{"image":{}}`},
+			want: []*ValuesDiff{
+				{Path: "image", Change: ConfigDiffAdded, To: map[string]interface{}{}},
+			},
+		},
+		{name: "invalid json",
+			args:    args{from: `{`, to: `{}`},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiffValues(tt.args.from, tt.args.to)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DiffValues() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffValues() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
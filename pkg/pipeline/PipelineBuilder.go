@@ -45,6 +45,7 @@ import (
 	"github.com/devtron-labs/devtron/internal/util"
 	"github.com/devtron-labs/devtron/pkg/app"
 	"github.com/devtron-labs/devtron/pkg/attributes"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
 	"github.com/devtron-labs/devtron/pkg/bean"
 	util2 "github.com/devtron-labs/devtron/util"
 	"github.com/go-pg/pg"
@@ -128,6 +129,7 @@ type PipelineBuilderImpl struct {
 	attributesService             attributes.AttributesService
 	aCDAuthConfig                 *util3.ACDAuthConfig
 	gitOpsRepository              repository.GitOpsConfigRepository
+	auditLogService               auditLog.AuditLogService
 }
 
 func NewPipelineBuilderImpl(logger *zap.SugaredLogger,
@@ -154,7 +156,8 @@ func NewPipelineBuilderImpl(logger *zap.SugaredLogger,
 	imageScanResultRepository security.ImageScanResultRepository,
 	ArgoK8sClient argocdServer.ArgoK8sClient,
 	GitFactory *util.GitFactory, attributesService attributes.AttributesService,
-	aCDAuthConfig *util3.ACDAuthConfig, gitOpsRepository repository.GitOpsConfigRepository) *PipelineBuilderImpl {
+	aCDAuthConfig *util3.ACDAuthConfig, gitOpsRepository repository.GitOpsConfigRepository,
+	auditLogService auditLog.AuditLogService) *PipelineBuilderImpl {
 	return &PipelineBuilderImpl{
 		logger:                        logger,
 		dbPipelineOrchestrator:        dbPipelineOrchestrator,
@@ -183,6 +186,7 @@ func NewPipelineBuilderImpl(logger *zap.SugaredLogger,
 		attributesService:             attributesService,
 		aCDAuthConfig:                 aCDAuthConfig,
 		gitOpsRepository:              gitOpsRepository,
+		auditLogService:               auditLogService,
 	}
 }
 
@@ -817,14 +821,28 @@ func (impl PipelineBuilderImpl) PatchCiPipeline(request *bean.CiPatchRequest) (c
 			impl.logger.Errorw("error in adding pipeline to template", "ciConf", ciConfig, "err", err)
 			return nil, err
 		}
+		for _, ciPipeline := range res.CiPipelines {
+			impl.saveCiPipelineAuditEvent(auditLog.ActionCreate, request.AppId, nil, ciPipeline, request.UserId)
+		}
 		return res, nil
 	case bean.UPDATE_SOURCE:
-		return impl.patchCiPipelineUpdateSource(ciConfig, request.CiPipeline)
+		before, err := impl.GetCiPipelineById(request.CiPipeline.Id)
+		if err != nil {
+			impl.logger.Errorw("error in fetching ci pipeline", "id", request.CiPipeline.Id, "err", err)
+			return nil, err
+		}
+		res, err := impl.patchCiPipelineUpdateSource(ciConfig, request.CiPipeline)
+		if err != nil {
+			return nil, err
+		}
+		impl.saveCiPipelineAuditEvent(auditLog.ActionUpdate, request.AppId, before, request.CiPipeline, request.UserId)
+		return res, nil
 	case bean.DELETE:
 		pipeline, err := impl.deletePipeline(request)
 		if err != nil {
 			return nil, err
 		}
+		impl.saveCiPipelineAuditEvent(auditLog.ActionDelete, request.AppId, pipeline, nil, request.UserId)
 		ciConfig.CiPipelines = []*bean.CiPipeline{pipeline}
 		return ciConfig, nil
 	default:
@@ -997,6 +1015,7 @@ func (impl PipelineBuilderImpl) CreateCdPipelines(cdPipelines *bean.CdPipelines,
 			return nil, err
 		}
 		pipeline.Id = id
+		impl.saveCdPipelineAuditEvent(auditLog.ActionCreate, cdPipelines.AppId, nil, pipeline, cdPipelines.UserId)
 	}
 
 	return cdPipelines, nil
@@ -1012,16 +1031,70 @@ func (impl PipelineBuilderImpl) PatchCdPipelines(cdPipelines *bean.CDPatchReques
 	case bean.CD_CREATE:
 		return impl.CreateCdPipelines(pipelineRequest, ctx)
 	case bean.CD_UPDATE:
-		err := impl.updateCdPipeline(ctx, cdPipelines.Pipeline, cdPipelines.UserId)
+		before, err := impl.GetCdPipelineById(cdPipelines.Pipeline.Id)
+		if err != nil {
+			impl.logger.Errorw("error in fetching cd pipeline", "id", cdPipelines.Pipeline.Id, "err", err)
+			return nil, err
+		}
+		err = impl.updateCdPipeline(ctx, cdPipelines.Pipeline, cdPipelines.UserId)
+		if err == nil {
+			impl.saveCdPipelineAuditEvent(auditLog.ActionUpdate, cdPipelines.AppId, before, cdPipelines.Pipeline, cdPipelines.UserId)
+		}
 		return pipelineRequest, err
 	case bean.CD_DELETE:
-		err := impl.deleteCdPipeline(cdPipelines.Pipeline.Id, cdPipelines.UserId, ctx, cdPipelines.ForceDelete)
+		before, err := impl.GetCdPipelineById(cdPipelines.Pipeline.Id)
+		if err != nil {
+			impl.logger.Errorw("error in fetching cd pipeline", "id", cdPipelines.Pipeline.Id, "err", err)
+			return nil, err
+		}
+		err = impl.deleteCdPipeline(cdPipelines.Pipeline.Id, cdPipelines.UserId, ctx, cdPipelines.ForceDelete)
+		if err == nil {
+			impl.saveCdPipelineAuditEvent(auditLog.ActionDelete, cdPipelines.AppId, before, nil, cdPipelines.UserId)
+		}
 		return pipelineRequest, err
 	default:
 		return nil, &util.ApiError{Code: "404", HttpStatusCode: 404, UserMessage: "operation not supported"}
 	}
 }
 
+func (impl PipelineBuilderImpl) saveCdPipelineAuditEvent(action string, appId int, before *bean.CDPipelineConfigObject, after *bean.CDPipelineConfigObject, userId int32) {
+	event := &auditLog.AuditEvent{
+		UserId:       userId,
+		ResourceType: auditLog.ResourcePipeline,
+		AppId:        appId,
+		Action:       action,
+	}
+	if before != nil {
+		event.ResourceId = fmt.Sprintf("cd/%d", before.Id)
+		event.EnvironmentId = before.EnvironmentId
+		event.Before = before
+	}
+	if after != nil {
+		event.ResourceId = fmt.Sprintf("cd/%d", after.Id)
+		event.EnvironmentId = after.EnvironmentId
+		event.After = after
+	}
+	impl.auditLogService.SaveEvent(event)
+}
+
+func (impl PipelineBuilderImpl) saveCiPipelineAuditEvent(action string, appId int, before *bean.CiPipeline, after *bean.CiPipeline, userId int32) {
+	event := &auditLog.AuditEvent{
+		UserId:       userId,
+		ResourceType: auditLog.ResourcePipeline,
+		AppId:        appId,
+		Action:       action,
+	}
+	if before != nil {
+		event.ResourceId = fmt.Sprintf("ci/%d", before.Id)
+		event.Before = before
+	}
+	if after != nil {
+		event.ResourceId = fmt.Sprintf("ci/%d", after.Id)
+		event.After = after
+	}
+	impl.auditLogService.SaveEvent(event)
+}
+
 func (impl PipelineBuilderImpl) deleteCdPipeline(pipelineId int, userId int32, ctx context.Context, forceDelete bool) (err error) {
 	//getting children CD pipeline details
 	appWorkflowMapping, err := impl.appWorkflowRepository.FindWFCDMappingByParentCDPipelineId(pipelineId)
@@ -49,6 +49,7 @@ type EnvironmentProperties struct {
 	AppMetrics        *bool              `json:"appMetrics"`
 	ChartRefId        int                `json:"chartRefId,omitempty"  validate:"number"`
 	IsOverride        bool               `sql:"isOverride"`
+	Comment           string             `json:"comment,omitempty"`
 }
 
 type EnvironmentPropertiesResponse struct {
@@ -71,21 +72,22 @@ type PropertiesConfigService interface {
 
 	GetAppIdByChartEnvId(chartEnvId int) (*chartConfig.EnvConfigOverride, error)
 	GetLatestEnvironmentProperties(appId, environmentId int) (*EnvironmentProperties, error)
-	ResetEnvironmentProperties(id int) (bool, error)
+	ResetEnvironmentProperties(id int, userId int32) (bool, error)
 	CreateEnvironmentPropertiesWithNamespace(appId int, propertiesRequest *EnvironmentProperties) (*EnvironmentProperties, error)
 
 	EnvMetricsEnableDisable(appMetricRequest *AppMetricEnableDisableRequest) (*AppMetricEnableDisableRequest, error)
 }
 type PropertiesConfigServiceImpl struct {
-	logger                       *zap.SugaredLogger
-	envConfigRepo                chartConfig.EnvConfigOverrideRepository
-	chartRepo                    chartConfig.ChartRepository
-	mergeUtil                    util.MergeUtil
-	environmentRepository        repository2.EnvironmentRepository
-	dbPipelineOrchestrator       DbPipelineOrchestrator
-	application                  application.ServiceClient
-	envLevelAppMetricsRepository repository.EnvLevelAppMetricsRepository
-	appLevelMetricsRepository    repository.AppLevelMetricsRepository
+	logger                           *zap.SugaredLogger
+	envConfigRepo                    chartConfig.EnvConfigOverrideRepository
+	chartRepo                        chartConfig.ChartRepository
+	mergeUtil                        util.MergeUtil
+	environmentRepository            repository2.EnvironmentRepository
+	dbPipelineOrchestrator           DbPipelineOrchestrator
+	application                      application.ServiceClient
+	envLevelAppMetricsRepository     repository.EnvLevelAppMetricsRepository
+	appLevelMetricsRepository        repository.AppLevelMetricsRepository
+	deploymentTemplateHistoryService DeploymentTemplateHistoryService
 }
 
 func NewPropertiesConfigServiceImpl(logger *zap.SugaredLogger,
@@ -97,17 +99,19 @@ func NewPropertiesConfigServiceImpl(logger *zap.SugaredLogger,
 	application application.ServiceClient,
 	envLevelAppMetricsRepository repository.EnvLevelAppMetricsRepository,
 	appLevelMetricsRepository repository.AppLevelMetricsRepository,
+	deploymentTemplateHistoryService DeploymentTemplateHistoryService,
 ) *PropertiesConfigServiceImpl {
 	return &PropertiesConfigServiceImpl{
-		logger:                       logger,
-		envConfigRepo:                envConfigRepo,
-		chartRepo:                    chartRepo,
-		mergeUtil:                    mergeUtil,
-		environmentRepository:        environmentRepository,
-		dbPipelineOrchestrator:       dbPipelineOrchestrator,
-		application:                  application,
-		envLevelAppMetricsRepository: envLevelAppMetricsRepository,
-		appLevelMetricsRepository:    appLevelMetricsRepository,
+		logger:                           logger,
+		envConfigRepo:                    envConfigRepo,
+		chartRepo:                        chartRepo,
+		mergeUtil:                        mergeUtil,
+		environmentRepository:            environmentRepository,
+		dbPipelineOrchestrator:           dbPipelineOrchestrator,
+		application:                      application,
+		envLevelAppMetricsRepository:     envLevelAppMetricsRepository,
+		appLevelMetricsRepository:        appLevelMetricsRepository,
+		deploymentTemplateHistoryService: deploymentTemplateHistoryService,
 	}
 
 }
@@ -229,6 +233,7 @@ func (impl PropertiesConfigServiceImpl) CreateEnvironmentProperties(appId int, e
 	if err != nil {
 		return nil, err
 	}
+	impl.deploymentTemplateHistoryService.SaveEnvLevelHistory(chart, envOverride, environmentProperties.EnvironmentId, environmentProperties.Comment)
 
 	r := json.RawMessage{}
 	err = r.UnmarshalJSON([]byte(envOverride.EnvOverrideValues))
@@ -321,6 +326,9 @@ func (impl PropertiesConfigServiceImpl) UpdateEnvironmentProperties(appId int, p
 	override.IsOverride = true
 	impl.logger.Debugw("updating environment override ", "value", override)
 	err = impl.envConfigRepo.UpdateProperties(override)
+	if err == nil {
+		impl.deploymentTemplateHistoryService.SaveEnvLevelHistory(oldEnvOverride.Chart, override, oldEnvOverride.TargetEnvironment, propertiesRequest.Comment)
+	}
 
 	if oldEnvOverride.Namespace != override.Namespace {
 		return nil, fmt.Errorf("namespace name update not supported")
@@ -496,7 +504,7 @@ func (impl PropertiesConfigServiceImpl) GetLatestEnvironmentProperties(appId, en
 	return environmentProperties, nil
 }
 
-func (impl PropertiesConfigServiceImpl) ResetEnvironmentProperties(id int) (bool, error) {
+func (impl PropertiesConfigServiceImpl) ResetEnvironmentProperties(id int, userId int32) (bool, error) {
 	envOverride, err := impl.envConfigRepo.Get(id)
 	if err != nil {
 		return false, err
@@ -504,10 +512,14 @@ func (impl PropertiesConfigServiceImpl) ResetEnvironmentProperties(id int) (bool
 	envOverride.EnvOverrideValues = "{}"
 	envOverride.IsOverride = false
 	envOverride.Latest = false
+	envOverride.UpdatedBy = userId
+	envOverride.UpdatedOn = time.Now()
 	impl.logger.Infow("reset environment override ", "value", envOverride)
 	err = impl.envConfigRepo.UpdateProperties(envOverride)
 	if err != nil {
 		impl.logger.Warnw("error in update envOverride", "envOverrideId", id)
+	} else {
+		impl.deploymentTemplateHistoryService.SaveEnvLevelHistory(envOverride.Chart, envOverride, envOverride.TargetEnvironment, "reset to app level values")
 	}
 
 	envLevelAppMetrics, err := impl.envLevelAppMetricsRepository.FindByAppIdAndEnvId(envOverride.Chart.AppId, envOverride.TargetEnvironment)
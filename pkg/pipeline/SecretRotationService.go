@@ -0,0 +1,463 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/caarlos0/env"
+	"github.com/devtron-labs/devtron/api/bean"
+	client "github.com/devtron-labs/devtron/client/events"
+	"github.com/devtron-labs/devtron/internal/sql/repository/chartConfig"
+	"github.com/devtron-labs/devtron/internal/sql/repository/pipelineConfig"
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/app"
+	"github.com/devtron-labs/devtron/pkg/sql"
+	util3 "github.com/devtron-labs/devtron/pkg/util"
+	util4 "github.com/devtron-labs/devtron/util"
+	util2 "github.com/devtron-labs/devtron/util/event"
+	"github.com/go-pg/pg"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+const (
+	SecretRotationCronExpr       = "@every 1m"
+	SecretRotationStage          = "SECRET_ROTATION"
+	SecretRotationAnnotationKey  = "devtron.ai/secret-rotation-id"
+	secretRotationNotFoundFormat = "secret %s not found"
+)
+
+// SecretRotationConfig holds the key scheduled secret data is encrypted with until the rotation runs, scheduling
+// a rotation with data is refused when it is not set
+type SecretRotationConfig struct {
+	EncryptionKey string `env:"SECRET_ROTATION_ENCRYPTION_KEY" envDefault:""`
+}
+
+type SecretRotationRequest struct {
+	AppId         int             `json:"appId" validate:"required"`
+	EnvironmentId int             `json:"environmentId"`
+	SecretName    string          `json:"secretName" validate:"required"`
+	Data          json.RawMessage `json:"data,omitempty"`
+	ScheduledOn   *time.Time      `json:"scheduledOn,omitempty"`
+	Comment       string          `json:"comment"`
+	UserId        int32           `json:"-"`
+}
+
+type SecretRotationDto struct {
+	Id            int                              `json:"id"`
+	AppId         int                              `json:"appId"`
+	EnvironmentId int                              `json:"environmentId"`
+	SecretName    string                           `json:"secretName"`
+	Status        chartConfig.SecretRotationStatus `json:"status"`
+	ScheduledOn   *time.Time                       `json:"scheduledOn,omitempty"`
+	ExecutedOn    *time.Time                       `json:"executedOn,omitempty"`
+	PipelineIds   []int                            `json:"pipelineIds"`
+	Comment       string                           `json:"comment"`
+	Message       string                           `json:"message"`
+	CreatedBy     int32                            `json:"createdBy"`
+	CreatedOn     time.Time                        `json:"createdOn"`
+}
+
+type SecretRotationService interface {
+	RotateSecret(request *SecretRotationRequest) (*SecretRotationDto, error)
+	CancelRotation(appId int, id int, userId int32) (*SecretRotationDto, error)
+	GetRotationHistory(appId int, envId int) ([]*SecretRotationDto, error)
+	GetAffectedPipelines(appId int, envId int, secretName string) ([]*pipelineConfig.Pipeline, error)
+}
+
+type SecretRotationServiceImpl struct {
+	logger                   *zap.SugaredLogger
+	config                   *SecretRotationConfig
+	cron                     *cron.Cron
+	secretRotationRepository chartConfig.SecretRotationRepository
+	configMapRepository      chartConfig.ConfigMapRepository
+	configMapService         ConfigMapService
+	appService               app.AppService
+	pipelineRepository       pipelineConfig.PipelineRepository
+	cdWorkflowRepository     pipelineConfig.CdWorkflowRepository
+	workflowDagExecutor      WorkflowDagExecutor
+	tokenCache               *util3.TokenCache
+	eventClient              client.EventClient
+	eventFactory             client.EventFactory
+}
+
+func NewSecretRotationServiceImpl(logger *zap.SugaredLogger,
+	secretRotationRepository chartConfig.SecretRotationRepository,
+	configMapRepository chartConfig.ConfigMapRepository,
+	configMapService ConfigMapService, appService app.AppService,
+	pipelineRepository pipelineConfig.PipelineRepository,
+	cdWorkflowRepository pipelineConfig.CdWorkflowRepository,
+	workflowDagExecutor WorkflowDagExecutor, tokenCache *util3.TokenCache,
+	eventClient client.EventClient, eventFactory client.EventFactory) (*SecretRotationServiceImpl, error) {
+	config := &SecretRotationConfig{}
+	err := env.Parse(config)
+	if err != nil {
+		logger.Errorw("error in parsing secret rotation config", "err", err)
+		return nil, err
+	}
+	cron := cron.New(
+		cron.WithChain())
+	cron.Start()
+	impl := &SecretRotationServiceImpl{
+		logger:                   logger,
+		config:                   config,
+		cron:                     cron,
+		secretRotationRepository: secretRotationRepository,
+		configMapRepository:      configMapRepository,
+		configMapService:         configMapService,
+		appService:               appService,
+		pipelineRepository:       pipelineRepository,
+		cdWorkflowRepository:     cdWorkflowRepository,
+		workflowDagExecutor:      workflowDagExecutor,
+		tokenCache:               tokenCache,
+		eventClient:              eventClient,
+		eventFactory:             eventFactory,
+	}
+	_, err = cron.AddFunc(SecretRotationCronExpr, impl.executeDueRotations)
+	if err != nil {
+		logger.Errorw("error in starting secret rotation cron", "err", err)
+		return nil, err
+	}
+	return impl, nil
+}
+
+func (impl *SecretRotationServiceImpl) RotateSecret(request *SecretRotationRequest) (*SecretRotationDto, error) {
+	_, configData, err := impl.fetchSecret(request.AppId, request.EnvironmentId, request.SecretName)
+	if err != nil {
+		return nil, err
+	}
+	if len(request.Data) > 0 && configData.External {
+		return nil, fmt.Errorf("data of external secret %s is managed outside devtron, rotate without data to only redeploy", request.SecretName)
+	}
+	rotation := &chartConfig.SecretRotation{
+		AppId:         request.AppId,
+		EnvironmentId: request.EnvironmentId,
+		SecretName:    request.SecretName,
+		Comment:       request.Comment,
+		AuditLog:      sql.AuditLog{CreatedOn: time.Now(), CreatedBy: request.UserId, UpdatedOn: time.Now(), UpdatedBy: request.UserId},
+	}
+	if request.ScheduledOn != nil && request.ScheduledOn.After(time.Now()) {
+		rotation.Status = chartConfig.SecretRotationScheduled
+		rotation.ScheduledOn = *request.ScheduledOn
+		if len(request.Data) > 0 {
+			// new values wait in the rotation until it runs, they are kept encrypted only
+			if len(impl.config.EncryptionKey) == 0 {
+				return nil, fmt.Errorf("SECRET_ROTATION_ENCRYPTION_KEY is not configured, rotate now or schedule without data")
+			}
+			rotation.SecretData, err = util4.EncryptData(impl.config.EncryptionKey, request.Data)
+			if err != nil {
+				impl.logger.Errorw("error in encrypting secret data for rotation", "appId", request.AppId, "secretName", request.SecretName, "err", err)
+				return nil, err
+			}
+		}
+		err = impl.secretRotationRepository.Save(rotation)
+		if err != nil {
+			impl.logger.Errorw("error in saving secret rotation", "request", request.SecretName, "appId", request.AppId, "err", err)
+			return nil, err
+		}
+		return adaptSecretRotation(rotation), nil
+	}
+
+	rotation.Status = chartConfig.SecretRotationInProgress
+	rotation.ScheduledOn = time.Now()
+	err = impl.secretRotationRepository.Save(rotation)
+	if err != nil {
+		impl.logger.Errorw("error in saving secret rotation", "request", request.SecretName, "appId", request.AppId, "err", err)
+		return nil, err
+	}
+	// data is applied in the request itself so that validation errors are returned to the caller,
+	// redeploy of consuming pipelines can take long and happens in background
+	err = impl.applySecretData(rotation, request.Data)
+	if err != nil {
+		impl.markFailed(rotation, nil, err)
+		return nil, err
+	}
+	dto := adaptSecretRotation(rotation)
+	go impl.redeployConsumers(rotation)
+	return dto, nil
+}
+
+func (impl *SecretRotationServiceImpl) CancelRotation(appId int, id int, userId int32) (*SecretRotationDto, error) {
+	rotation, err := impl.secretRotationRepository.FindById(id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching secret rotation", "id", id, "err", err)
+		return nil, err
+	}
+	if rotation.AppId != appId {
+		return nil, fmt.Errorf("secret rotation %d not found for app %d", id, appId)
+	}
+	if rotation.Status != chartConfig.SecretRotationScheduled {
+		return nil, fmt.Errorf("only scheduled rotation can be cancelled, current status %s", rotation.Status)
+	}
+	rotation.Status = chartConfig.SecretRotationCancelled
+	rotation.SecretData = ""
+	rotation.UpdatedBy = userId
+	rotation.UpdatedOn = time.Now()
+	err = impl.secretRotationRepository.Update(rotation)
+	if err != nil {
+		impl.logger.Errorw("error in cancelling secret rotation", "id", id, "err", err)
+		return nil, err
+	}
+	return adaptSecretRotation(rotation), nil
+}
+
+func (impl *SecretRotationServiceImpl) GetRotationHistory(appId int, envId int) ([]*SecretRotationDto, error) {
+	rotations, err := impl.secretRotationRepository.FindByAppIdAndEnvId(appId, envId)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching secret rotation history", "appId", appId, "envId", envId, "err", err)
+		return nil, err
+	}
+	history := make([]*SecretRotationDto, 0)
+	for _, rotation := range rotations {
+		history = append(history, adaptSecretRotation(rotation))
+	}
+	return history, nil
+}
+
+// GetAffectedPipelines returns active cd pipelines whose effective (app + env merged) secrets contain secretName.
+// envId 0 means app level secret which is consumed by pipelines of every environment.
+func (impl *SecretRotationServiceImpl) GetAffectedPipelines(appId int, envId int, secretName string) ([]*pipelineConfig.Pipeline, error) {
+	var pipelines []*pipelineConfig.Pipeline
+	var err error
+	if envId > 0 {
+		pipelines, err = impl.pipelineRepository.FindActiveByAppIdAndEnvironmentId(appId, envId)
+	} else {
+		pipelines, err = impl.pipelineRepository.FindActiveByAppId(appId)
+	}
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching pipelines", "appId", appId, "envId", envId, "err", err)
+		return nil, err
+	}
+	var affected []*pipelineConfig.Pipeline
+	for _, pipeline := range pipelines {
+		_, secrets, err := impl.appService.GetCmSecretNew(appId, pipeline.EnvironmentId)
+		if err != nil {
+			impl.logger.Errorw("error in fetching secrets", "appId", appId, "envId", pipeline.EnvironmentId, "err", err)
+			return nil, err
+		}
+		for _, secret := range secrets.Secrets {
+			if secret.Name == secretName {
+				affected = append(affected, pipeline)
+				break
+			}
+		}
+	}
+	return affected, nil
+}
+
+func (impl *SecretRotationServiceImpl) executeDueRotations() {
+	rotations, err := impl.secretRotationRepository.FindDueScheduled(time.Now())
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching scheduled secret rotations", "err", err)
+		return
+	}
+	for _, rotation := range rotations {
+		picked, err := impl.secretRotationRepository.MarkInProgress(rotation.Id, rotation.CreatedBy)
+		if err != nil || !picked {
+			continue
+		}
+		rotation.Status = chartConfig.SecretRotationInProgress
+		err = impl.applyScheduledSecretData(rotation)
+		if err != nil {
+			impl.markFailed(rotation, nil, err)
+			continue
+		}
+		impl.redeployConsumers(rotation)
+	}
+}
+
+func (impl *SecretRotationServiceImpl) fetchSecret(appId int, envId int, secretName string) (*ConfigDataRequest, *ConfigData, error) {
+	var configDataRequest *ConfigDataRequest
+	if envId > 0 {
+		envLevel, err := impl.configMapRepository.GetByAppIdAndEnvIdEnvLevel(appId, envId)
+		if err != nil && err != pg.ErrNoRows {
+			impl.logger.Errorw("error in fetching env level secrets", "appId", appId, "envId", envId, "err", err)
+			return nil, nil, err
+		}
+		configDataRequest, err = impl.configMapService.CSEnvironmentFetchForEdit(secretName, envLevel.Id, appId, envId)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		appLevel, err := impl.configMapRepository.GetByAppIdAppLevel(appId)
+		if err == pg.ErrNoRows {
+			return nil, nil, fmt.Errorf(secretRotationNotFoundFormat, secretName)
+		} else if err != nil {
+			impl.logger.Errorw("error in fetching app level secrets", "appId", appId, "err", err)
+			return nil, nil, err
+		}
+		configDataRequest, err = impl.configMapService.CSGlobalFetchForEdit(secretName, appLevel.Id)
+		if err != nil {
+			return nil, nil, err
+		}
+		configDataRequest.AppId = appId
+	}
+	if len(configDataRequest.ConfigData) == 0 {
+		return nil, nil, fmt.Errorf(secretRotationNotFoundFormat, secretName)
+	}
+	return configDataRequest, configDataRequest.ConfigData[0], nil
+}
+
+// applyScheduledSecretData decrypts the data kept with a scheduled rotation and applies it
+func (impl *SecretRotationServiceImpl) applyScheduledSecretData(rotation *chartConfig.SecretRotation) error {
+	if len(rotation.SecretData) == 0 {
+		return nil
+	}
+	data, err := util4.DecryptData(impl.config.EncryptionKey, rotation.SecretData)
+	if err != nil {
+		impl.logger.Errorw("error in decrypting secret data for rotation", "id", rotation.Id, "err", err)
+		return fmt.Errorf("scheduled secret data could not be decrypted: %s", err.Error())
+	}
+	return impl.applySecretData(rotation, data)
+}
+
+// applySecretData writes the new data of the rotation into app or env level secret, rotation without
+// data only redeploys consumers (e.g. secret was already rotated in external secret store)
+func (impl *SecretRotationServiceImpl) applySecretData(rotation *chartConfig.SecretRotation, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	configDataRequest, configData, err := impl.fetchSecret(rotation.AppId, rotation.EnvironmentId, rotation.SecretName)
+	if err != nil {
+		return err
+	}
+	configData.Data = json.RawMessage(data)
+	configData.Global = false
+	configDataRequest.EnvironmentId = rotation.EnvironmentId
+	configDataRequest.ConfigData = []*ConfigData{configData}
+	configDataRequest.UserId = rotation.UpdatedBy
+	if rotation.EnvironmentId > 0 {
+		_, err = impl.configMapService.CSEnvironmentAddUpdate(configDataRequest)
+	} else {
+		_, err = impl.configMapService.CSGlobalAddUpdate(configDataRequest)
+	}
+	if err != nil {
+		impl.logger.Errorw("error in updating secret data for rotation", "id", rotation.Id, "err", err)
+		return err
+	}
+	return nil
+}
+
+func (impl *SecretRotationServiceImpl) redeployConsumers(rotation *chartConfig.SecretRotation) {
+	pipelines, err := impl.GetAffectedPipelines(rotation.AppId, rotation.EnvironmentId, rotation.SecretName)
+	if err != nil {
+		impl.markFailed(rotation, nil, err)
+		return
+	}
+	ctx, err := impl.tokenCache.BuildACDSynchContext()
+	if err != nil {
+		impl.logger.Errorw("error in creating acd synch context", "err", err)
+		impl.markFailed(rotation, pipelines, err)
+		return
+	}
+	// pod template annotation changes on every rotation so that pods consuming the secret are rolled
+	override := fmt.Sprintf(`{"podAnnotations":{"%s":"%d"}}`, SecretRotationAnnotationKey, rotation.Id)
+	var redeployed []int
+	var failures []string
+	for _, pipeline := range pipelines {
+		wf, err := impl.cdWorkflowRepository.FindLatestCdWorkflowByPipelineId([]int{pipeline.Id})
+		if util.IsErrNoRows(err) {
+			impl.logger.Infow("skipping redeploy of never deployed pipeline", "pipelineId", pipeline.Id, "rotationId", rotation.Id)
+			continue
+		} else if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", pipeline.Name, err.Error()))
+			continue
+		}
+		overrideRequest := &bean.ValuesOverrideRequest{
+			PipelineId:         pipeline.Id,
+			AppId:              pipeline.AppId,
+			CiArtifactId:       wf.CiArtifactId,
+			AdditionalOverride: json.RawMessage(override),
+			UserId:             rotation.UpdatedBy,
+			CdWorkflowType:     bean.CD_WORKFLOW_TYPE_DEPLOY,
+		}
+		_, err = impl.workflowDagExecutor.ManualCdTrigger(overrideRequest, ctx)
+		if err != nil {
+			impl.logger.Errorw("error in redeploying pipeline for secret rotation", "pipelineId", pipeline.Id, "rotationId", rotation.Id, "err", err)
+			failures = append(failures, fmt.Sprintf("%s: %s", pipeline.Name, err.Error()))
+			continue
+		}
+		redeployed = append(redeployed, pipeline.Id)
+	}
+	rotation.PipelineIds = redeployed
+	if len(failures) > 0 {
+		impl.markFailed(rotation, pipelines, fmt.Errorf("redeploy failed for %s", strings.Join(failures, ", ")))
+		return
+	}
+	rotation.Status = chartConfig.SecretRotationSucceeded
+	rotation.Message = fmt.Sprintf("redeployed %d pipeline(s)", len(redeployed))
+	impl.finish(rotation, pipelines)
+}
+
+func (impl *SecretRotationServiceImpl) markFailed(rotation *chartConfig.SecretRotation, pipelines []*pipelineConfig.Pipeline, err error) {
+	rotation.Status = chartConfig.SecretRotationFailed
+	rotation.Message = err.Error()
+	impl.finish(rotation, pipelines)
+}
+
+func (impl *SecretRotationServiceImpl) finish(rotation *chartConfig.SecretRotation, pipelines []*pipelineConfig.Pipeline) {
+	// applied or not, the rotation is over and history need not keep a copy of the values
+	rotation.SecretData = ""
+	rotation.ExecutedOn = time.Now()
+	rotation.UpdatedOn = time.Now()
+	err := impl.secretRotationRepository.Update(rotation)
+	if err != nil {
+		impl.logger.Errorw("error in updating secret rotation", "id", rotation.Id, "err", err)
+	}
+	eventType := util2.Success
+	if rotation.Status == chartConfig.SecretRotationFailed {
+		eventType = util2.Fail
+	}
+	// owners are the recipients configured on notifications of the consuming cd pipelines
+	for _, pipeline := range pipelines {
+		event := impl.eventFactory.Build(eventType, &pipeline.Id, pipeline.AppId, &pipeline.EnvironmentId, util2.CD)
+		event.Payload = &client.Payload{Stage: SecretRotationStage}
+		event.UserId = int(rotation.UpdatedBy)
+		_, err = impl.eventClient.WriteEvent(event)
+		if err != nil {
+			impl.logger.Errorw("error in sending secret rotation notification", "pipelineId", pipeline.Id, "rotationId", rotation.Id, "err", err)
+		}
+	}
+}
+
+func adaptSecretRotation(rotation *chartConfig.SecretRotation) *SecretRotationDto {
+	dto := &SecretRotationDto{
+		Id:            rotation.Id,
+		AppId:         rotation.AppId,
+		EnvironmentId: rotation.EnvironmentId,
+		SecretName:    rotation.SecretName,
+		Status:        rotation.Status,
+		PipelineIds:   rotation.PipelineIds,
+		Comment:       rotation.Comment,
+		Message:       rotation.Message,
+		CreatedBy:     rotation.CreatedBy,
+		CreatedOn:     rotation.CreatedOn,
+	}
+	if !rotation.ScheduledOn.IsZero() {
+		scheduledOn := rotation.ScheduledOn
+		dto.ScheduledOn = &scheduledOn
+	}
+	if !rotation.ExecutedOn.IsZero() {
+		executedOn := rotation.ExecutedOn
+		dto.ExecutedOn = &executedOn
+	}
+	return dto
+}
@@ -0,0 +1,280 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	bean2 "github.com/devtron-labs/devtron/api/bean"
+	"github.com/devtron-labs/devtron/internal/sql/repository/chartConfig"
+	"github.com/devtron-labs/devtron/internal/sql/repository/pipelineConfig"
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/app"
+	util4 "github.com/devtron-labs/devtron/util"
+	"github.com/go-pg/pg"
+)
+
+const testRotationKey = "rotation-test-key"
+
+type secretRotationRepositoryStub struct {
+	chartConfig.SecretRotationRepository
+	rotations []*chartConfig.SecretRotation
+	updated   []chartConfig.SecretRotation
+}
+
+func (stub *secretRotationRepositoryStub) Save(model *chartConfig.SecretRotation) error {
+	model.Id = len(stub.rotations) + 1
+	stub.rotations = append(stub.rotations, model)
+	return nil
+}
+
+func (stub *secretRotationRepositoryStub) Update(model *chartConfig.SecretRotation) error {
+	stub.updated = append(stub.updated, *model)
+	return nil
+}
+
+func (stub *secretRotationRepositoryStub) FindById(id int) (*chartConfig.SecretRotation, error) {
+	for _, rotation := range stub.rotations {
+		if rotation.Id == id {
+			return rotation, nil
+		}
+	}
+	return nil, pg.ErrNoRows
+}
+
+func (stub *secretRotationRepositoryStub) FindByAppIdAndEnvId(appId int, envId int) ([]*chartConfig.SecretRotation, error) {
+	var rotations []*chartConfig.SecretRotation
+	for _, rotation := range stub.rotations {
+		if rotation.AppId == appId && rotation.EnvironmentId == envId {
+			rotations = append(rotations, rotation)
+		}
+	}
+	return rotations, nil
+}
+
+func (stub *secretRotationRepositoryStub) FindDueScheduled(now time.Time) ([]*chartConfig.SecretRotation, error) {
+	var rotations []*chartConfig.SecretRotation
+	for _, rotation := range stub.rotations {
+		if rotation.Status == chartConfig.SecretRotationScheduled && !rotation.ScheduledOn.After(now) {
+			rotations = append(rotations, rotation)
+		}
+	}
+	return rotations, nil
+}
+
+func (stub *secretRotationRepositoryStub) MarkInProgress(id int, userId int32) (bool, error) {
+	return true, nil
+}
+
+type configMapRepositoryStub struct {
+	chartConfig.ConfigMapRepository
+}
+
+func (stub configMapRepositoryStub) GetByAppIdAppLevel(appId int) (*chartConfig.ConfigMapAppModel, error) {
+	return &chartConfig.ConfigMapAppModel{Id: 1, AppId: appId}, nil
+}
+
+// configMapServiceStub knows a single app level secret, updating it fails when failUpdate is set
+type configMapServiceStub struct {
+	ConfigMapService
+	secretName string
+	external   bool
+	failUpdate bool
+	applied    []json.RawMessage
+}
+
+func (stub *configMapServiceStub) CSGlobalFetchForEdit(name string, id int) (*ConfigDataRequest, error) {
+	if name != stub.secretName {
+		return &ConfigDataRequest{Id: id}, nil
+	}
+	return &ConfigDataRequest{Id: id, ConfigData: []*ConfigData{{Name: name, External: stub.external, Data: json.RawMessage(`{"password":"old"}`)}}}, nil
+}
+
+func (stub *configMapServiceStub) CSGlobalAddUpdate(configMapRequest *ConfigDataRequest) (*ConfigDataRequest, error) {
+	if stub.failUpdate {
+		return nil, errors.New("invalid secret")
+	}
+	stub.applied = append(stub.applied, configMapRequest.ConfigData[0].Data)
+	return configMapRequest, nil
+}
+
+// appServiceStub returns the effective secrets of each environment
+type appServiceStub struct {
+	app.AppService
+	secretsByEnv map[int][]string
+}
+
+func (stub appServiceStub) GetCmSecretNew(appId int, envId int) (*bean2.ConfigMapJson, *bean2.ConfigSecretJson, error) {
+	secrets := &bean2.ConfigSecretJson{}
+	for _, name := range stub.secretsByEnv[envId] {
+		secrets.Secrets = append(secrets.Secrets, &bean2.Map{Name: name})
+	}
+	return &bean2.ConfigMapJson{}, secrets, nil
+}
+
+type rotationPipelineRepositoryStub struct {
+	pipelineConfig.PipelineRepository
+	pipelines []*pipelineConfig.Pipeline
+}
+
+func (stub rotationPipelineRepositoryStub) FindActiveByAppId(appId int) ([]*pipelineConfig.Pipeline, error) {
+	return stub.pipelines, nil
+}
+
+func (stub rotationPipelineRepositoryStub) FindActiveByAppIdAndEnvironmentId(appId int, environmentId int) ([]*pipelineConfig.Pipeline, error) {
+	var pipelines []*pipelineConfig.Pipeline
+	for _, p := range stub.pipelines {
+		if p.EnvironmentId == environmentId {
+			pipelines = append(pipelines, p)
+		}
+	}
+	return pipelines, nil
+}
+
+func newSecretRotationServiceForTest(repository *secretRotationRepositoryStub, configMapService *configMapServiceStub, key string) *SecretRotationServiceImpl {
+	return &SecretRotationServiceImpl{
+		logger:                   util.NewSugardLogger(),
+		config:                   &SecretRotationConfig{EncryptionKey: key},
+		secretRotationRepository: repository,
+		configMapRepository:      configMapRepositoryStub{},
+		configMapService:         configMapService,
+	}
+}
+
+func TestGetAffectedPipelines(t *testing.T) {
+	impl := &SecretRotationServiceImpl{
+		logger: util.NewSugardLogger(),
+		appService: appServiceStub{secretsByEnv: map[int][]string{
+			1: {"db-credentials", "api-key"},
+			2: {"api-key"},
+			3: {"db-credentials"},
+		}},
+		pipelineRepository: rotationPipelineRepositoryStub{pipelines: []*pipelineConfig.Pipeline{
+			{Id: 11, AppId: 1, EnvironmentId: 1},
+			{Id: 12, AppId: 1, EnvironmentId: 2},
+			{Id: 13, AppId: 1, EnvironmentId: 3},
+		}},
+	}
+	affected, err := impl.GetAffectedPipelines(1, 0, "db-credentials")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(affected) != 2 || affected[0].Id != 11 || affected[1].Id != 13 {
+		t.Errorf("app level secret should affect pipelines 11 and 13, got %+v", affected)
+	}
+	affected, err = impl.GetAffectedPipelines(1, 2, "db-credentials")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(affected) != 0 {
+		t.Errorf("environment without the secret should not be affected, got %+v", affected)
+	}
+	affected, err = impl.GetAffectedPipelines(1, 2, "api-key")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(affected) != 1 || affected[0].Id != 12 {
+		t.Errorf("env level secret should affect pipeline 12 only, got %+v", affected)
+	}
+}
+
+func TestScheduleRotationEncryptsData(t *testing.T) {
+	repository := &secretRotationRepositoryStub{}
+	configMapService := &configMapServiceStub{secretName: "db-credentials"}
+	impl := newSecretRotationServiceForTest(repository, configMapService, testRotationKey)
+	scheduledOn := time.Now().Add(time.Hour)
+	data := json.RawMessage(`{"password":"new"}`)
+	dto, err := impl.RotateSecret(&SecretRotationRequest{AppId: 1, SecretName: "db-credentials", Data: data, ScheduledOn: &scheduledOn, UserId: 2})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if dto.Status != chartConfig.SecretRotationScheduled || len(configMapService.applied) != 0 {
+		t.Fatalf("rotation should only be scheduled, got status %s and %d updates", dto.Status, len(configMapService.applied))
+	}
+	stored := repository.rotations[0].SecretData
+	if len(stored) == 0 || stored == string(data) {
+		t.Fatalf("scheduled data should be kept encrypted, got %q", stored)
+	}
+	decrypted, err := util4.DecryptData(testRotationKey, stored)
+	if err != nil || string(decrypted) != string(data) {
+		t.Errorf("stored data should decrypt to the request data, got %q, err %v", decrypted, err)
+	}
+
+	// without a key only scheduling without data is possible
+	impl = newSecretRotationServiceForTest(&secretRotationRepositoryStub{}, configMapService, "")
+	_, err = impl.RotateSecret(&SecretRotationRequest{AppId: 1, SecretName: "db-credentials", Data: data, ScheduledOn: &scheduledOn, UserId: 2})
+	if err == nil {
+		t.Errorf("expected scheduling with data to be refused without encryption key")
+	}
+}
+
+func TestRotateExternalSecretWithDataRefused(t *testing.T) {
+	impl := newSecretRotationServiceForTest(&secretRotationRepositoryStub{}, &configMapServiceStub{secretName: "vault", external: true}, testRotationKey)
+	_, err := impl.RotateSecret(&SecretRotationRequest{AppId: 1, SecretName: "vault", Data: json.RawMessage(`{"a":"b"}`), UserId: 2})
+	if err == nil {
+		t.Errorf("expected data of external secret to be refused")
+	}
+}
+
+func TestExecuteDueRotations(t *testing.T) {
+	repository := &secretRotationRepositoryStub{}
+	configMapService := &configMapServiceStub{secretName: "db-credentials"}
+	impl := newSecretRotationServiceForTest(repository, configMapService, testRotationKey)
+	scheduledOn := time.Now().Add(time.Hour)
+	_, err := impl.RotateSecret(&SecretRotationRequest{AppId: 1, SecretName: "db-credentials", Data: json.RawMessage(`{"password":"new"}`), ScheduledOn: &scheduledOn, UserId: 2})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	impl.executeDueRotations()
+	if len(configMapService.applied) != 0 {
+		t.Fatalf("rotation should not run before it is due")
+	}
+
+	// due now but the secret update fails, the rotation fails and its data is dropped
+	repository.rotations[0].ScheduledOn = time.Now().Add(-time.Minute)
+	configMapService.failUpdate = true
+	impl.executeDueRotations()
+	if len(repository.updated) != 1 {
+		t.Fatalf("expected the rotation to be updated once, got %d", len(repository.updated))
+	}
+	failed := repository.updated[0]
+	if failed.Status != chartConfig.SecretRotationFailed || failed.SecretData != "" || failed.ExecutedOn.IsZero() {
+		t.Errorf("failed rotation should be marked failed with its data cleared, got %+v", failed)
+	}
+}
+
+func TestCancelRotationAndHistory(t *testing.T) {
+	repository := &secretRotationRepositoryStub{}
+	impl := newSecretRotationServiceForTest(repository, &configMapServiceStub{secretName: "db-credentials"}, testRotationKey)
+	scheduledOn := time.Now().Add(time.Hour)
+	scheduled, err := impl.RotateSecret(&SecretRotationRequest{AppId: 1, SecretName: "db-credentials", Data: json.RawMessage(`{"password":"new"}`), ScheduledOn: &scheduledOn, UserId: 2})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err = impl.CancelRotation(2, scheduled.Id, 2); err == nil {
+		t.Errorf("expected cancel of a rotation of another app to fail")
+	}
+	cancelled, err := impl.CancelRotation(1, scheduled.Id, 2)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if cancelled.Status != chartConfig.SecretRotationCancelled || repository.rotations[0].SecretData != "" {
+		t.Errorf("cancelled rotation should drop its data, got %+v", repository.rotations[0])
+	}
+	if _, err = impl.CancelRotation(1, scheduled.Id, 2); err == nil {
+		t.Errorf("expected cancel of a cancelled rotation to fail")
+	}
+
+	history, err := impl.GetRotationHistory(1, 0)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(history) != 1 || history[0].Id != scheduled.Id || history[0].ScheduledOn == nil || history[0].Status != chartConfig.SecretRotationCancelled {
+		t.Errorf("unexpected history %+v", history)
+	}
+	history, err = impl.GetRotationHistory(1, 5)
+	if err != nil || len(history) != 0 {
+		t.Errorf("expected no history for another environment, got %+v, err %v", history, err)
+	}
+}
@@ -65,29 +65,30 @@ type WorkflowDagExecutor interface {
 }
 
 type WorkflowDagExecutorImpl struct {
-	logger                     *zap.SugaredLogger
-	pipelineRepository         pipelineConfig.PipelineRepository
-	cdWorkflowRepository       pipelineConfig.CdWorkflowRepository
-	pubsubClient               *pubsub.PubSubClient
-	appService                 app.AppService
-	cdWorkflowService          CdWorkflowService
-	ciPipelineRepository       pipelineConfig.CiPipelineRepository
-	materialRepository         pipelineConfig.MaterialRepository
-	cdConfig                   *CdConfig
-	pipelineOverrideRepository chartConfig.PipelineOverrideRepository
-	ciArtifactRepository       repository.CiArtifactRepository
-	user                       user.UserService
-	enforcer                   casbin.Enforcer
-	enforcerUtil               rbac.EnforcerUtil
-	groupRepository            repository.DeploymentGroupRepository
-	tokenCache                 *util3.TokenCache
-	acdAuthConfig              *util3.ACDAuthConfig
-	envRepository              repository2.EnvironmentRepository
-	eventFactory               client.EventFactory
-	eventClient                client.EventClient
-	cvePolicyRepository        security.CvePolicyRepository
-	scanResultRepository       security.ImageScanResultRepository
-	appWorkflowRepository      appWorkflow.AppWorkflowRepository
+	logger                       *zap.SugaredLogger
+	pipelineRepository           pipelineConfig.PipelineRepository
+	cdWorkflowRepository         pipelineConfig.CdWorkflowRepository
+	pubsubClient                 *pubsub.PubSubClient
+	appService                   app.AppService
+	cdWorkflowService            CdWorkflowService
+	ciPipelineRepository         pipelineConfig.CiPipelineRepository
+	materialRepository           pipelineConfig.MaterialRepository
+	cdConfig                     *CdConfig
+	pipelineOverrideRepository   chartConfig.PipelineOverrideRepository
+	ciArtifactRepository         repository.CiArtifactRepository
+	user                         user.UserService
+	enforcer                     casbin.Enforcer
+	enforcerUtil                 rbac.EnforcerUtil
+	groupRepository              repository.DeploymentGroupRepository
+	tokenCache                   *util3.TokenCache
+	acdAuthConfig                *util3.ACDAuthConfig
+	envRepository                repository2.EnvironmentRepository
+	eventFactory                 client.EventFactory
+	eventClient                  client.EventClient
+	cvePolicyRepository          security.CvePolicyRepository
+	scanResultRepository         security.ImageScanResultRepository
+	appWorkflowRepository        appWorkflow.AppWorkflowRepository
+	previewEnvironmentRepository pipelineConfig.PreviewEnvironmentRepository
 }
 
 type CiArtifactDTO struct {
@@ -140,30 +141,32 @@ func NewWorkflowDagExecutorImpl(Logger *zap.SugaredLogger, pipelineRepository pi
 	acdAuthConfig *util3.ACDAuthConfig, eventFactory client.EventFactory,
 	eventClient client.EventClient, cvePolicyRepository security.CvePolicyRepository,
 	scanResultRepository security.ImageScanResultRepository,
-	appWorkflowRepository appWorkflow.AppWorkflowRepository) *WorkflowDagExecutorImpl {
+	appWorkflowRepository appWorkflow.AppWorkflowRepository,
+	previewEnvironmentRepository pipelineConfig.PreviewEnvironmentRepository) *WorkflowDagExecutorImpl {
 	wde := &WorkflowDagExecutorImpl{logger: Logger,
-		pipelineRepository:         pipelineRepository,
-		cdWorkflowRepository:       cdWorkflowRepository,
-		pubsubClient:               pubsubClient,
-		appService:                 appService,
-		cdWorkflowService:          cdWorkflowService,
-		ciPipelineRepository:       ciPipelineRepository,
-		cdConfig:                   cdConfig,
-		ciArtifactRepository:       ciArtifactRepository,
-		materialRepository:         materialRepository,
-		pipelineOverrideRepository: pipelineOverrideRepository,
-		user:                       user,
-		enforcer:                   enforcer,
-		enforcerUtil:               enforcerUtil,
-		groupRepository:            groupRepository,
-		tokenCache:                 tokenCache,
-		acdAuthConfig:              acdAuthConfig,
-		envRepository:              envRepository,
-		eventFactory:               eventFactory,
-		eventClient:                eventClient,
-		cvePolicyRepository:        cvePolicyRepository,
-		scanResultRepository:       scanResultRepository,
-		appWorkflowRepository:      appWorkflowRepository,
+		pipelineRepository:           pipelineRepository,
+		cdWorkflowRepository:         cdWorkflowRepository,
+		pubsubClient:                 pubsubClient,
+		appService:                   appService,
+		cdWorkflowService:            cdWorkflowService,
+		ciPipelineRepository:         ciPipelineRepository,
+		cdConfig:                     cdConfig,
+		ciArtifactRepository:         ciArtifactRepository,
+		materialRepository:           materialRepository,
+		pipelineOverrideRepository:   pipelineOverrideRepository,
+		user:                         user,
+		enforcer:                     enforcer,
+		enforcerUtil:                 enforcerUtil,
+		groupRepository:              groupRepository,
+		tokenCache:                   tokenCache,
+		acdAuthConfig:                acdAuthConfig,
+		envRepository:                envRepository,
+		eventFactory:                 eventFactory,
+		eventClient:                  eventClient,
+		cvePolicyRepository:          cvePolicyRepository,
+		scanResultRepository:         scanResultRepository,
+		appWorkflowRepository:        appWorkflowRepository,
+		previewEnvironmentRepository: previewEnvironmentRepository,
 	}
 	err := wde.Subscribe()
 	if err != nil {
@@ -229,6 +232,10 @@ func (impl *WorkflowDagExecutorImpl) HandleCiSuccessEvent(artifact *repository.C
 		return err
 	}
 	for _, pipeline := range pipelines {
+		if !impl.isArtifactForPipeline(pipeline, artifact) {
+			impl.logger.Debugw("skipping artifact of another pull request for preview pipeline", "artifactId", artifact.Id, "pipelineId", pipeline.Id)
+			continue
+		}
 		err = impl.triggerStage(nil, pipeline, artifact, applyAuth, async, triggeredBy)
 		if err != nil {
 			impl.logger.Debugw("err", "err", err)
@@ -237,6 +244,31 @@ func (impl *WorkflowDagExecutorImpl) HandleCiSuccessEvent(artifact *repository.C
 	return nil
 }
 
+// isArtifactForPipeline keeps the builds of other pull requests out of a preview environment, every pull request
+// of the ci pipeline is built by the same pipeline. Nothing is deployed when the preview cannot be looked up
+func (impl *WorkflowDagExecutorImpl) isArtifactForPipeline(pipeline *pipelineConfig.Pipeline, artifact *repository.CiArtifact) bool {
+	previewEnvironment, err := impl.previewEnvironmentRepository.FindActiveByCdPipelineId(pipeline.Id)
+	if err == pg.ErrNoRows {
+		return true
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching preview environment", "pipelineId", pipeline.Id, "err", err)
+		return false
+	}
+	ciMaterials, err := repository.GetCiMaterialInfo(artifact.MaterialInfo, artifact.DataSource)
+	if err != nil {
+		impl.logger.Errorw("error in parsing material info", "artifactId", artifact.Id, "err", err)
+		return false
+	}
+	for _, ciMaterial := range ciMaterials {
+		for _, modification := range ciMaterial.Modifications {
+			if modification.WebhookData.Data[bean2.WEBHOOK_SELECTOR_UNIQUE_ID_NAME] == previewEnvironment.PullRequestId {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (impl *WorkflowDagExecutorImpl) triggerStage(cdWf *pipelineConfig.CdWorkflow, pipeline *pipelineConfig.Pipeline, artifact *repository.CiArtifact, applyAuth bool, async bool, triggeredBy int32) error {
 	var err error
 	if len(pipeline.PreStageConfig) > 0 {
@@ -0,0 +1,655 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package previewEnvironment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env"
+	"github.com/devtron-labs/devtron/internal/sql/repository"
+	"github.com/devtron-labs/devtron/internal/sql/repository/appWorkflow"
+	"github.com/devtron-labs/devtron/internal/sql/repository/pipelineConfig"
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/bean"
+	"github.com/devtron-labs/devtron/pkg/cluster"
+	"github.com/devtron-labs/devtron/pkg/pipeline"
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/go-pg/pg"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	// pullRequestStateSelector is an optional webhook selector, closing a pull request without merging it tears down
+	// its preview when the git host event is configured to send it. Otherwise the ttl takes care of it
+	pullRequestStateSelector = "state"
+	pullRequestUrlSelector   = bean.WEBHOOK_SELECTOR_GIT_URL_NAME
+
+	defaultTtlHours      = 72
+	maxEnvironmentLength = 50
+	systemUserId         = 1
+)
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+type PreviewEnvironmentConfig struct {
+	CleanupCronExpr string `env:"PREVIEW_ENVIRONMENT_CLEANUP_INTERVAL" envDefault:"@every 10m"`
+}
+
+type PreviewEnvironmentConfigDto struct {
+	Id                  int `json:"id"`
+	AppId               int `json:"appId" validate:"number,required"`
+	CiPipelineId        int `json:"ciPipelineId" validate:"number,required"`
+	ReferencePipelineId int `json:"referencePipelineId" validate:"number,required"`
+	ClusterId           int `json:"clusterId" validate:"number,required"`
+	// ValuesOverride is merged into the deployment template of the reference pipeline, {pr}, {namespace} and
+	// {environment} are replaced in it and in UrlTemplate
+	ValuesOverride       json.RawMessage `json:"valuesOverride,omitempty"`
+	UrlTemplate          string          `json:"urlTemplate,omitempty" validate:"max=250"`
+	TtlHours             int             `json:"ttlHours" validate:"min=0"`
+	CommentOnPullRequest bool            `json:"commentOnPullRequest"`
+	UserId               int32           `json:"-"`
+}
+
+type PreviewEnvironmentDto struct {
+	Id             int                                     `json:"id"`
+	ConfigId       int                                     `json:"configId"`
+	AppId          int                                     `json:"appId"`
+	PullRequestId  string                                  `json:"pullRequestId"`
+	PullRequestUrl string                                  `json:"pullRequestUrl,omitempty"`
+	SourceBranch   string                                  `json:"sourceBranch,omitempty"`
+	EnvironmentId  int                                     `json:"environmentId,omitempty"`
+	CdPipelineId   int                                     `json:"cdPipelineId,omitempty"`
+	Namespace      string                                  `json:"namespace,omitempty"`
+	Url            string                                  `json:"url,omitempty"`
+	Status         pipelineConfig.PreviewEnvironmentStatus `json:"status"`
+	Message        string                                  `json:"message,omitempty"`
+	ExpiresOn      time.Time                               `json:"expiresOn"`
+	CreatedOn      time.Time                               `json:"createdOn"`
+}
+
+// PreviewEnvironmentService creates an environment for every pull request built by a ci pipeline with a preview
+// config. The pull request is deployed there with a cd pipeline copied from the reference one, and everything is
+// torn down when the pull request is merged or closed, or when its ttl expires
+type PreviewEnvironmentService interface {
+	HandleWebhook(ciPipelineMaterialId int, webhookData *bean.WebhookData) error
+	CleanupExpired()
+
+	SaveConfig(request *PreviewEnvironmentConfigDto) (*PreviewEnvironmentConfigDto, error)
+	GetConfigs(appId int) ([]*PreviewEnvironmentConfigDto, error)
+	DeleteConfig(id int, userId int32) error
+	GetPreviewEnvironments(appId int) ([]*PreviewEnvironmentDto, error)
+	GetPreviewEnvironment(id int) (*PreviewEnvironmentDto, error)
+	DeletePreviewEnvironment(id int, userId int32) error
+}
+
+type PreviewEnvironmentServiceImpl struct {
+	logger                       *zap.SugaredLogger
+	cron                         *cron.Cron
+	previewEnvironmentRepository pipelineConfig.PreviewEnvironmentRepository
+	ciPipelineMaterialRepository pipelineConfig.CiPipelineMaterialRepository
+	ciPipelineRepository         pipelineConfig.CiPipelineRepository
+	pipelineRepository           pipelineConfig.PipelineRepository
+	appWorkflowRepository        appWorkflow.AppWorkflowRepository
+	gitProviderRepository        repository.GitProviderRepository
+	environmentService           cluster.EnvironmentService
+	clusterService               cluster.ClusterService
+	pipelineBuilder              pipeline.PipelineBuilder
+	chartService                 pipeline.ChartService
+	propertiesConfigService      pipeline.PropertiesConfigService
+	mergeUtil                    util.MergeUtil
+	K8sUtil                      *util.K8sUtil
+}
+
+func NewPreviewEnvironmentServiceImpl(logger *zap.SugaredLogger, previewEnvironmentRepository pipelineConfig.PreviewEnvironmentRepository,
+	ciPipelineMaterialRepository pipelineConfig.CiPipelineMaterialRepository, ciPipelineRepository pipelineConfig.CiPipelineRepository,
+	pipelineRepository pipelineConfig.PipelineRepository, appWorkflowRepository appWorkflow.AppWorkflowRepository,
+	gitProviderRepository repository.GitProviderRepository, environmentService cluster.EnvironmentService,
+	clusterService cluster.ClusterService, pipelineBuilder pipeline.PipelineBuilder, chartService pipeline.ChartService,
+	propertiesConfigService pipeline.PropertiesConfigService, mergeUtil util.MergeUtil, K8sUtil *util.K8sUtil) (*PreviewEnvironmentServiceImpl, error) {
+	config := &PreviewEnvironmentConfig{}
+	err := env.Parse(config)
+	if err != nil {
+		logger.Errorw("error in parsing preview environment config", "err", err)
+		return nil, err
+	}
+	cron := cron.New(
+		cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)))
+	cron.Start()
+	impl := &PreviewEnvironmentServiceImpl{
+		logger:                       logger,
+		cron:                         cron,
+		previewEnvironmentRepository: previewEnvironmentRepository,
+		ciPipelineMaterialRepository: ciPipelineMaterialRepository,
+		ciPipelineRepository:         ciPipelineRepository,
+		pipelineRepository:           pipelineRepository,
+		appWorkflowRepository:        appWorkflowRepository,
+		gitProviderRepository:        gitProviderRepository,
+		environmentService:           environmentService,
+		clusterService:               clusterService,
+		pipelineBuilder:              pipelineBuilder,
+		chartService:                 chartService,
+		propertiesConfigService:      propertiesConfigService,
+		mergeUtil:                    mergeUtil,
+		K8sUtil:                      K8sUtil,
+	}
+	_, err = cron.AddFunc(config.CleanupCronExpr, impl.CleanupExpired)
+	if err != nil {
+		logger.Errorw("error in starting preview environment cleanup cron", "err", err)
+		return nil, err
+	}
+	return impl, nil
+}
+
+func (impl *PreviewEnvironmentServiceImpl) HandleWebhook(ciPipelineMaterialId int, webhookData *bean.WebhookData) error {
+	if webhookData == nil {
+		return nil
+	}
+	ciPipelineMaterial, err := impl.ciPipelineMaterialRepository.GetById(ciPipelineMaterialId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching ci pipeline material", "id", ciPipelineMaterialId, "err", err)
+		return err
+	}
+	config, err := impl.previewEnvironmentRepository.FindActiveConfigByCiPipelineId(ciPipelineMaterial.CiPipelineId)
+	if err == pg.ErrNoRows {
+		return nil
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching preview environment config", "ciPipelineId", ciPipelineMaterial.CiPipelineId, "err", err)
+		return err
+	}
+	pullRequestId := webhookData.Data[bean.WEBHOOK_SELECTOR_UNIQUE_ID_NAME]
+	if len(pullRequestId) == 0 {
+		impl.logger.Warnw("no pull request id in webhook data, skipping preview environment", "webhookDataId", webhookData.Id)
+		return nil
+	}
+	existing, err := impl.previewEnvironmentRepository.FindActiveByConfigIdAndPullRequestId(config.Id, pullRequestId)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching preview environment", "configId", config.Id, "pullRequestId", pullRequestId, "err", err)
+		return err
+	}
+	if isPullRequestClosed(webhookData) {
+		if existing.Id == 0 {
+			return nil
+		}
+		impl.logger.Infow("pull request closed, tearing down preview environment", "id", existing.Id, "pullRequestId", pullRequestId)
+		return impl.teardown(existing, "pull request closed", systemUserId)
+	}
+	if existing.Id > 0 {
+		// a new commit on the pull request, the build is deployed by the existing pipeline
+		existing.ExpiresOn = time.Now().Add(configTtl(config))
+		existing.UpdatedOn = time.Now()
+		existing.UpdatedBy = systemUserId
+		return impl.previewEnvironmentRepository.Update(existing)
+	}
+	previewEnvironment := &pipelineConfig.PreviewEnvironment{
+		ConfigId:       config.Id,
+		AppId:          config.AppId,
+		PullRequestId:  pullRequestId,
+		PullRequestUrl: webhookData.Data[pullRequestUrlSelector],
+		SourceBranch:   webhookData.Data[bean.WEBHOOK_SELECTOR_SOURCE_BRANCH_NAME_NAME],
+		Status:         pipelineConfig.PREVIEW_ENVIRONMENT_ACTIVE,
+		ExpiresOn:      time.Now().Add(configTtl(config)),
+		AuditLog:       sql.AuditLog{CreatedOn: time.Now(), CreatedBy: systemUserId, UpdatedOn: time.Now(), UpdatedBy: systemUserId},
+	}
+	err = impl.create(config, previewEnvironment)
+	if err != nil {
+		impl.logger.Errorw("error in creating preview environment", "configId", config.Id, "pullRequestId", pullRequestId, "err", err)
+		previewEnvironment.Status = pipelineConfig.PREVIEW_ENVIRONMENT_FAILED
+		previewEnvironment.Message = err.Error()
+		impl.cleanup(previewEnvironment, systemUserId)
+	}
+	if previewEnvironment.Id > 0 {
+		err = impl.previewEnvironmentRepository.Update(previewEnvironment)
+	} else {
+		err = impl.previewEnvironmentRepository.Save(previewEnvironment)
+	}
+	if err != nil {
+		impl.logger.Errorw("error in saving preview environment", "pullRequestId", pullRequestId, "err", err)
+		return err
+	}
+	if previewEnvironment.Status == pipelineConfig.PREVIEW_ENVIRONMENT_ACTIVE && config.CommentOnPullRequest {
+		impl.commentOnPullRequest(ciPipelineMaterial, previewEnvironment)
+	}
+	return nil
+}
+
+// create sets up the environment, the deployment template override and the cd pipeline of a preview, the ids of
+// whatever got created are kept on the preview so that a failure can be cleaned up
+func (impl *PreviewEnvironmentServiceImpl) create(config *pipelineConfig.PreviewEnvironmentConfig, previewEnvironment *pipelineConfig.PreviewEnvironment) error {
+	refPipeline, err := impl.pipelineRepository.FindById(config.ReferencePipelineId)
+	if err != nil {
+		return fmt.Errorf("error in fetching reference pipeline: %s", err.Error())
+	}
+	name := previewEnvironmentName(refPipeline.App.AppName, previewEnvironment.PullRequestId)
+	previewEnvironment.Namespace = name
+	replacer := strings.NewReplacer("{pr}", previewEnvironment.PullRequestId, "{namespace}", name, "{environment}", name)
+	previewEnvironment.Url = replacer.Replace(config.UrlTemplate)
+
+	environment, err := impl.environmentService.Create(&cluster.EnvironmentBean{
+		Environment: name,
+		ClusterId:   config.ClusterId,
+		Namespace:   name,
+		Active:      true,
+	}, systemUserId)
+	if err != nil {
+		return fmt.Errorf("error in creating environment: %s", err.Error())
+	}
+	previewEnvironment.EnvironmentId = environment.Id
+
+	chartRefRes, err := impl.chartService.ChartRefAutocompleteForAppOrEnv(config.AppId, refPipeline.EnvironmentId)
+	if err != nil {
+		return fmt.Errorf("error in fetching chart ref: %s", err.Error())
+	}
+	envProperties, err := impl.propertiesConfigService.GetEnvironmentProperties(config.AppId, refPipeline.EnvironmentId, chartRefRes.LatestEnvChartRef)
+	if err != nil {
+		return fmt.Errorf("error in fetching reference deployment template: %s", err.Error())
+	}
+	values := envProperties.GlobalConfig
+	if envProperties.IsOverride {
+		values = envProperties.EnvironmentConfig.EnvOverrideValues
+	}
+	if len(config.ValuesOverride) > 0 {
+		patched, err := impl.mergeUtil.JsonPatch(values, []byte(replacer.Replace(config.ValuesOverride)))
+		if err != nil {
+			return fmt.Errorf("error in applying preview values: %s", err.Error())
+		}
+		values = patched
+	}
+	_, err = impl.propertiesConfigService.CreateEnvironmentProperties(config.AppId, &pipeline.EnvironmentProperties{
+		EnvOverrideValues: values,
+		ManualReviewed:    true,
+		Active:            true,
+		Namespace:         name,
+		EnvironmentId:     environment.Id,
+		EnvironmentName:   name,
+		UserId:            systemUserId,
+		ChartRefId:        chartRefRes.LatestEnvChartRef,
+		IsOverride:        true,
+		Comment:           fmt.Sprintf("preview of pull request %s", previewEnvironment.PullRequestId),
+	})
+	if err != nil {
+		return fmt.Errorf("error in creating deployment template override: %s", err.Error())
+	}
+
+	cdPipelineId, err := impl.createCdPipeline(config, environment, previewEnvironment.PullRequestId)
+	if err != nil {
+		return fmt.Errorf("error in creating cd pipeline: %s", err.Error())
+	}
+	previewEnvironment.CdPipelineId = cdPipelineId
+	return nil
+}
+
+func (impl *PreviewEnvironmentServiceImpl) createCdPipeline(config *pipelineConfig.PreviewEnvironmentConfig, environment *cluster.EnvironmentBean, pullRequestId string) (int, error) {
+	refPipelines, err := impl.pipelineBuilder.GetCdPipelinesForApp(config.AppId)
+	if err != nil {
+		return 0, err
+	}
+	var refCdPipeline *bean.CDPipelineConfigObject
+	for _, refPipeline := range refPipelines.Pipelines {
+		if refPipeline.Id == config.ReferencePipelineId {
+			refCdPipeline = refPipeline
+			break
+		}
+	}
+	if refCdPipeline == nil {
+		return 0, fmt.Errorf("reference pipeline %d not found", config.ReferencePipelineId)
+	}
+	mappings, err := impl.appWorkflowRepository.FindWFCDMappingByCDPipelineId(config.ReferencePipelineId)
+	if err != nil && err != pg.ErrNoRows {
+		return 0, err
+	}
+	if len(mappings) == 0 {
+		return 0, fmt.Errorf("no workflow found for reference pipeline %d", config.ReferencePipelineId)
+	}
+	// pre and post stages are left out, they usually talk to the shared environments of the reference
+	cdPipeline := &bean.CDPipelineConfigObject{
+		EnvironmentId:      environment.Id,
+		CiPipelineId:       config.CiPipelineId,
+		TriggerType:        pipelineConfig.TRIGGER_TYPE_AUTOMATIC,
+		Name:               fmt.Sprintf("preview-pr-%s", sanitizeName(pullRequestId)),
+		Strategies:         refCdPipeline.Strategies,
+		Namespace:          environment.Namespace,
+		DeploymentTemplate: refCdPipeline.DeploymentTemplate,
+		AppWorkflowId:      mappings[0].AppWorkflowId,
+	}
+	created, err := impl.pipelineBuilder.CreateCdPipelines(&bean.CdPipelines{
+		Pipelines: []*bean.CDPipelineConfigObject{cdPipeline},
+		AppId:     config.AppId,
+		UserId:    systemUserId,
+	}, context.Background())
+	if err != nil {
+		return 0, err
+	}
+	return created.Pipelines[0].Id, nil
+}
+
+func (impl *PreviewEnvironmentServiceImpl) teardown(previewEnvironment *pipelineConfig.PreviewEnvironment, reason string, userId int32) error {
+	err := impl.cleanup(previewEnvironment, userId)
+	if err != nil {
+		return err
+	}
+	previewEnvironment.Status = pipelineConfig.PREVIEW_ENVIRONMENT_DELETED
+	previewEnvironment.Message = reason
+	previewEnvironment.UpdatedOn = time.Now()
+	previewEnvironment.UpdatedBy = userId
+	return impl.previewEnvironmentRepository.Update(previewEnvironment)
+}
+
+// cleanup removes the cd pipeline, the environment and its namespace, the pipeline is force deleted so that a
+// broken argo application does not keep the preview around
+func (impl *PreviewEnvironmentServiceImpl) cleanup(previewEnvironment *pipelineConfig.PreviewEnvironment, userId int32) error {
+	if previewEnvironment.CdPipelineId > 0 {
+		_, err := impl.pipelineBuilder.PatchCdPipelines(&bean.CDPatchRequest{
+			Pipeline:    &bean.CDPipelineConfigObject{Id: previewEnvironment.CdPipelineId},
+			AppId:       previewEnvironment.AppId,
+			Action:      bean.CD_DELETE,
+			UserId:      userId,
+			ForceDelete: true,
+		}, context.Background())
+		if err != nil && !util.IsErrNoRows(err) {
+			impl.logger.Errorw("error in deleting preview cd pipeline", "pipelineId", previewEnvironment.CdPipelineId, "err", err)
+			return err
+		}
+	}
+	if previewEnvironment.EnvironmentId > 0 {
+		environment, err := impl.environmentService.FindById(previewEnvironment.EnvironmentId)
+		if err != nil && !util.IsErrNoRows(err) {
+			return err
+		}
+		if err == nil && environment.Active {
+			environment.Active = false
+			_, err = impl.environmentService.Update(environment, userId)
+			if err != nil {
+				impl.logger.Errorw("error in deactivating preview environment", "envId", environment.Id, "err", err)
+				return err
+			}
+			err = impl.deleteNamespace(environment)
+			if err != nil {
+				impl.logger.Errorw("error in deleting preview namespace", "namespace", environment.Namespace, "err", err)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (impl *PreviewEnvironmentServiceImpl) deleteNamespace(environment *cluster.EnvironmentBean) error {
+	clusterBean, err := impl.clusterService.FindById(environment.ClusterId)
+	if err != nil {
+		return err
+	}
+	clusterConfig, err := impl.clusterService.GetClusterConfig(clusterBean)
+	if err != nil {
+		return err
+	}
+	return impl.K8sUtil.DeleteNsIfExists(environment.Namespace, clusterConfig)
+}
+
+func (impl *PreviewEnvironmentServiceImpl) CleanupExpired() {
+	previewEnvironments, err := impl.previewEnvironmentRepository.FindActiveExpiredBefore(time.Now())
+	if err != nil {
+		impl.logger.Errorw("error in fetching expired preview environments", "err", err)
+		return
+	}
+	for _, previewEnvironment := range previewEnvironments {
+		impl.logger.Infow("preview environment expired, tearing down", "id", previewEnvironment.Id, "pullRequestId", previewEnvironment.PullRequestId)
+		err = impl.teardown(previewEnvironment, "ttl expired", systemUserId)
+		if err != nil {
+			impl.logger.Errorw("error in tearing down expired preview environment", "id", previewEnvironment.Id, "err", err)
+		}
+	}
+}
+
+func (impl *PreviewEnvironmentServiceImpl) commentOnPullRequest(ciPipelineMaterial *pipelineConfig.CiPipelineMaterial, previewEnvironment *pipelineConfig.PreviewEnvironment) {
+	if ciPipelineMaterial.GitMaterial == nil || len(previewEnvironment.PullRequestUrl) == 0 {
+		return
+	}
+	gitProvider, err := impl.gitProviderRepository.FindOne(strconv.Itoa(ciPipelineMaterial.GitMaterial.GitProviderId))
+	if err != nil {
+		impl.logger.Errorw("error in fetching git provider", "id", ciPipelineMaterial.GitMaterial.GitProviderId, "err", err)
+		return
+	}
+	body := fmt.Sprintf("Preview environment `%s` is being deployed", previewEnvironment.Namespace)
+	if len(previewEnvironment.Url) > 0 {
+		body = fmt.Sprintf("%s at %s", body, previewEnvironment.Url)
+	}
+	body = fmt.Sprintf("%s. It is removed when the pull request is closed or on %s.", body, previewEnvironment.ExpiresOn.Format(time.RFC1123))
+	err = postPullRequestComment(&gitProvider, previewEnvironment.PullRequestUrl, body)
+	if err != nil {
+		impl.logger.Errorw("error in commenting on pull request", "pullRequestUrl", previewEnvironment.PullRequestUrl, "err", err)
+	}
+}
+
+func (impl *PreviewEnvironmentServiceImpl) SaveConfig(request *PreviewEnvironmentConfigDto) (*PreviewEnvironmentConfigDto, error) {
+	ciPipeline, err := impl.ciPipelineRepository.FindById(request.CiPipelineId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching ci pipeline", "id", request.CiPipelineId, "err", err)
+		return nil, err
+	}
+	refPipeline, err := impl.pipelineRepository.FindById(request.ReferencePipelineId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching reference pipeline", "id", request.ReferencePipelineId, "err", err)
+		return nil, err
+	}
+	if ciPipeline.AppId != request.AppId || refPipeline.AppId != request.AppId || refPipeline.CiPipelineId != request.CiPipelineId {
+		return nil, &util.ApiError{
+			HttpStatusCode:  http.StatusBadRequest,
+			InternalMessage: "pipelines do not belong to the app",
+			UserMessage:     "reference cd pipeline must be a child of the ci pipeline of this app",
+		}
+	}
+	if len(request.ValuesOverride) > 0 {
+		var values map[string]interface{}
+		if err := json.Unmarshal(request.ValuesOverride, &values); err != nil {
+			return nil, &util.ApiError{HttpStatusCode: http.StatusBadRequest, InternalMessage: err.Error(), UserMessage: "values override must be a json object"}
+		}
+	}
+	existing, err := impl.previewEnvironmentRepository.FindActiveConfigByCiPipelineId(request.CiPipelineId)
+	if err != nil && err != pg.ErrNoRows {
+		return nil, err
+	}
+	if existing.Id > 0 && existing.Id != request.Id {
+		return nil, &util.ApiError{
+			HttpStatusCode:  http.StatusConflict,
+			InternalMessage: "preview config already exists",
+			UserMessage:     fmt.Sprintf("ci pipeline %d already has a preview environment config", request.CiPipelineId),
+		}
+	}
+	config := &pipelineConfig.PreviewEnvironmentConfig{}
+	if request.Id > 0 {
+		config, err = impl.previewEnvironmentRepository.FindConfigById(request.Id)
+		if err != nil {
+			impl.logger.Errorw("error in fetching preview environment config", "id", request.Id, "err", err)
+			return nil, err
+		}
+	} else {
+		config.CreatedOn = time.Now()
+		config.CreatedBy = request.UserId
+		config.Active = true
+	}
+	config.AppId = request.AppId
+	config.CiPipelineId = request.CiPipelineId
+	config.ReferencePipelineId = request.ReferencePipelineId
+	config.ClusterId = request.ClusterId
+	config.ValuesOverride = string(request.ValuesOverride)
+	config.UrlTemplate = request.UrlTemplate
+	config.TtlHours = request.TtlHours
+	if config.TtlHours == 0 {
+		config.TtlHours = defaultTtlHours
+	}
+	config.CommentOnPullRequest = request.CommentOnPullRequest
+	config.UpdatedOn = time.Now()
+	config.UpdatedBy = request.UserId
+	if config.Id > 0 {
+		err = impl.previewEnvironmentRepository.UpdateConfig(config)
+	} else {
+		err = impl.previewEnvironmentRepository.SaveConfig(config)
+	}
+	if err != nil {
+		impl.logger.Errorw("error in saving preview environment config", "config", config, "err", err)
+		return nil, err
+	}
+	return configDto(config), nil
+}
+
+func (impl *PreviewEnvironmentServiceImpl) GetConfigs(appId int) ([]*PreviewEnvironmentConfigDto, error) {
+	configs, err := impl.previewEnvironmentRepository.FindActiveConfigsByAppId(appId)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching preview environment configs", "appId", appId, "err", err)
+		return nil, err
+	}
+	result := make([]*PreviewEnvironmentConfigDto, 0, len(configs))
+	for _, config := range configs {
+		result = append(result, configDto(config))
+	}
+	return result, nil
+}
+
+// DeleteConfig disables previews for the ci pipeline and tears down the ones which are still running
+func (impl *PreviewEnvironmentServiceImpl) DeleteConfig(id int, userId int32) error {
+	config, err := impl.previewEnvironmentRepository.FindConfigById(id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching preview environment config", "id", id, "err", err)
+		return err
+	}
+	previewEnvironments, err := impl.previewEnvironmentRepository.FindActiveByConfigId(id)
+	if err != nil && err != pg.ErrNoRows {
+		return err
+	}
+	for _, previewEnvironment := range previewEnvironments {
+		err = impl.teardown(previewEnvironment, "preview config deleted", userId)
+		if err != nil {
+			impl.logger.Errorw("error in tearing down preview environment", "id", previewEnvironment.Id, "err", err)
+			return err
+		}
+	}
+	config.Active = false
+	config.UpdatedOn = time.Now()
+	config.UpdatedBy = userId
+	return impl.previewEnvironmentRepository.UpdateConfig(config)
+}
+
+func (impl *PreviewEnvironmentServiceImpl) GetPreviewEnvironments(appId int) ([]*PreviewEnvironmentDto, error) {
+	previewEnvironments, err := impl.previewEnvironmentRepository.FindActiveByAppId(appId)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching preview environments", "appId", appId, "err", err)
+		return nil, err
+	}
+	result := make([]*PreviewEnvironmentDto, 0, len(previewEnvironments))
+	for _, previewEnvironment := range previewEnvironments {
+		result = append(result, previewEnvironmentDto(previewEnvironment))
+	}
+	return result, nil
+}
+
+func (impl *PreviewEnvironmentServiceImpl) GetPreviewEnvironment(id int) (*PreviewEnvironmentDto, error) {
+	previewEnvironment, err := impl.previewEnvironmentRepository.FindById(id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching preview environment", "id", id, "err", err)
+		return nil, err
+	}
+	return previewEnvironmentDto(previewEnvironment), nil
+}
+
+func (impl *PreviewEnvironmentServiceImpl) DeletePreviewEnvironment(id int, userId int32) error {
+	previewEnvironment, err := impl.previewEnvironmentRepository.FindById(id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching preview environment", "id", id, "err", err)
+		return err
+	}
+	if previewEnvironment.Status == pipelineConfig.PREVIEW_ENVIRONMENT_DELETED {
+		return nil
+	}
+	return impl.teardown(previewEnvironment, "deleted manually", userId)
+}
+
+// isPullRequestClosed only looks at the state of the pull request, the merged action type is what every pull
+// request event carries and does not tell whether the pull request was merged
+func isPullRequestClosed(webhookData *bean.WebhookData) bool {
+	state := strings.ToLower(webhookData.Data[pullRequestStateSelector])
+	return state == "closed" || state == "declined" || state == "merged"
+}
+
+// previewEnvironmentName is used for both the environment and its namespace, so it has to be a valid dns label. Only
+// the app part is shortened so that every pull request of an app gets its own environment
+func previewEnvironmentName(appName string, pullRequestId string) string {
+	suffix := "-pr-" + sanitizeName(pullRequestId)
+	prefix := sanitizeName(appName)
+	if len(prefix) == 0 || prefix[0] < 'a' || prefix[0] > 'z' {
+		prefix = strings.TrimRight("pr-"+prefix, "-")
+	}
+	maxPrefixLength := maxEnvironmentLength - len(suffix)
+	if maxPrefixLength < 1 {
+		maxPrefixLength = 1
+	}
+	if len(prefix) > maxPrefixLength {
+		prefix = strings.TrimRight(prefix[:maxPrefixLength], "-")
+	}
+	return prefix + suffix
+}
+
+func sanitizeName(name string) string {
+	return strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+func configTtl(config *pipelineConfig.PreviewEnvironmentConfig) time.Duration {
+	if config.TtlHours <= 0 {
+		return defaultTtlHours * time.Hour
+	}
+	return time.Duration(config.TtlHours) * time.Hour
+}
+
+func configDto(config *pipelineConfig.PreviewEnvironmentConfig) *PreviewEnvironmentConfigDto {
+	return &PreviewEnvironmentConfigDto{
+		Id:                   config.Id,
+		AppId:                config.AppId,
+		CiPipelineId:         config.CiPipelineId,
+		ReferencePipelineId:  config.ReferencePipelineId,
+		ClusterId:            config.ClusterId,
+		ValuesOverride:       json.RawMessage(config.ValuesOverride),
+		UrlTemplate:          config.UrlTemplate,
+		TtlHours:             config.TtlHours,
+		CommentOnPullRequest: config.CommentOnPullRequest,
+	}
+}
+
+func previewEnvironmentDto(previewEnvironment *pipelineConfig.PreviewEnvironment) *PreviewEnvironmentDto {
+	return &PreviewEnvironmentDto{
+		Id:             previewEnvironment.Id,
+		ConfigId:       previewEnvironment.ConfigId,
+		AppId:          previewEnvironment.AppId,
+		PullRequestId:  previewEnvironment.PullRequestId,
+		PullRequestUrl: previewEnvironment.PullRequestUrl,
+		SourceBranch:   previewEnvironment.SourceBranch,
+		EnvironmentId:  previewEnvironment.EnvironmentId,
+		CdPipelineId:   previewEnvironment.CdPipelineId,
+		Namespace:      previewEnvironment.Namespace,
+		Url:            previewEnvironment.Url,
+		Status:         previewEnvironment.Status,
+		Message:        previewEnvironment.Message,
+		ExpiresOn:      previewEnvironment.ExpiresOn,
+		CreatedOn:      previewEnvironment.CreatedOn,
+	}
+}
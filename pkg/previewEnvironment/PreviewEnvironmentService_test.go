@@ -0,0 +1,196 @@
+package previewEnvironment
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/devtron-labs/devtron/internal/sql/repository/app"
+	"github.com/devtron-labs/devtron/internal/sql/repository/pipelineConfig"
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/bean"
+	"github.com/devtron-labs/devtron/pkg/cluster"
+	"github.com/go-pg/pg"
+)
+
+func TestPreviewEnvironmentName(t *testing.T) {
+	tests := []struct {
+		appName       string
+		pullRequestId string
+		want          string
+	}{
+		{appName: "payments", pullRequestId: "42", want: "payments-pr-42"},
+		{appName: "Payments_API", pullRequestId: "feature/42", want: "payments-api-pr-feature-42"},
+		{appName: "1app", pullRequestId: "7", want: "pr-1app-pr-7"},
+		{appName: "a-very-long-application-name-which-keeps-going", pullRequestId: "123456", want: "a-very-long-application-name-which-keeps-pr-123456"},
+		{appName: "a-very-long-application-name-which-keeps-going", pullRequestId: "123457", want: "a-very-long-application-name-which-keeps-pr-123457"},
+		{appName: "an-application-name-longer-than-the-environment-limit-of-fifty", pullRequestId: "9", want: "an-application-name-longer-than-the-environme-pr-9"},
+	}
+	for _, tt := range tests {
+		got := previewEnvironmentName(tt.appName, tt.pullRequestId)
+		if got != tt.want {
+			t.Errorf("previewEnvironmentName(%s, %s) = %s, want %s", tt.appName, tt.pullRequestId, got, tt.want)
+		}
+		if len(got) > maxEnvironmentLength {
+			t.Errorf("previewEnvironmentName(%s, %s) is too long", tt.appName, tt.pullRequestId)
+		}
+	}
+}
+
+func TestIsPullRequestClosed(t *testing.T) {
+	tests := []struct {
+		webhookData *bean.WebhookData
+		want        bool
+	}{
+		{webhookData: &bean.WebhookData{EventActionType: bean.WEBHOOK_EVENT_MERGED_ACTION_TYPE}, want: false},
+		{webhookData: &bean.WebhookData{EventActionType: bean.WEBHOOK_EVENT_MERGED_ACTION_TYPE, Data: map[string]string{"state": "open"}}, want: false},
+		{webhookData: &bean.WebhookData{EventActionType: bean.WEBHOOK_EVENT_MERGED_ACTION_TYPE, Data: map[string]string{"state": "merged"}}, want: true},
+		{webhookData: &bean.WebhookData{EventActionType: bean.WEBHOOK_EVENT_NON_MERGED_ACTION_TYPE, Data: map[string]string{"state": "open"}}, want: false},
+		{webhookData: &bean.WebhookData{EventActionType: bean.WEBHOOK_EVENT_NON_MERGED_ACTION_TYPE, Data: map[string]string{"state": "Closed"}}, want: true},
+		{webhookData: &bean.WebhookData{EventActionType: bean.WEBHOOK_EVENT_NON_MERGED_ACTION_TYPE}, want: false},
+	}
+	for i, tt := range tests {
+		if got := isPullRequestClosed(tt.webhookData); got != tt.want {
+			t.Errorf("case %d: isPullRequestClosed() = %v, want %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestParsePullRequestUrl(t *testing.T) {
+	pr, err := parsePullRequestUrl("https://github.com/devtron-labs/devtron/pull/1234")
+	if err != nil || pr.gitlab || pr.owner != "devtron-labs" || pr.project != "devtron" || pr.number != 1234 || pr.hostUrl != "https://github.com" {
+		t.Errorf("unexpected github pull request %+v, err %v", pr, err)
+	}
+	pr, err = parsePullRequestUrl("https://gitlab.example.com/group/sub/repo/-/merge_requests/7")
+	if err != nil || !pr.gitlab || pr.project != "group/sub/repo" || pr.number != 7 || pr.hostUrl != "https://gitlab.example.com" {
+		t.Errorf("unexpected gitlab merge request %+v, err %v", pr, err)
+	}
+	pr, err = parsePullRequestUrl("https://gitlab.com/group/repo/merge_requests/3")
+	if err != nil || pr.project != "group/repo" || pr.number != 3 {
+		t.Errorf("unexpected gitlab merge request %+v, err %v", pr, err)
+	}
+	_, err = parsePullRequestUrl("https://bitbucket.org/team/repo/pull-requests/1")
+	if err == nil {
+		t.Errorf("expected error for unsupported pull request url")
+	}
+}
+
+// the stubs implement only what HandleWebhook calls up to the creation of the environment, the environment stub
+// fails so that creation stops before the chart and pipeline services which are not stubbed
+type previewEnvironmentRepositoryStub struct {
+	pipelineConfig.PreviewEnvironmentRepository
+	config   *pipelineConfig.PreviewEnvironmentConfig
+	existing *pipelineConfig.PreviewEnvironment
+	saved    []*pipelineConfig.PreviewEnvironment
+	updated  []*pipelineConfig.PreviewEnvironment
+}
+
+func (stub *previewEnvironmentRepositoryStub) FindActiveConfigByCiPipelineId(ciPipelineId int) (*pipelineConfig.PreviewEnvironmentConfig, error) {
+	return stub.config, nil
+}
+
+func (stub *previewEnvironmentRepositoryStub) FindActiveByConfigIdAndPullRequestId(configId int, pullRequestId string) (*pipelineConfig.PreviewEnvironment, error) {
+	if stub.existing == nil {
+		return &pipelineConfig.PreviewEnvironment{}, pg.ErrNoRows
+	}
+	return stub.existing, nil
+}
+
+func (stub *previewEnvironmentRepositoryStub) Save(previewEnvironment *pipelineConfig.PreviewEnvironment) error {
+	stub.saved = append(stub.saved, previewEnvironment)
+	return nil
+}
+
+func (stub *previewEnvironmentRepositoryStub) Update(previewEnvironment *pipelineConfig.PreviewEnvironment) error {
+	stub.updated = append(stub.updated, previewEnvironment)
+	return nil
+}
+
+type ciPipelineMaterialRepositoryStub struct {
+	pipelineConfig.CiPipelineMaterialRepository
+}
+
+func (stub ciPipelineMaterialRepositoryStub) GetById(id int) (*pipelineConfig.CiPipelineMaterial, error) {
+	return &pipelineConfig.CiPipelineMaterial{Id: id, CiPipelineId: 3}, nil
+}
+
+type pipelineRepositoryStub struct {
+	pipelineConfig.PipelineRepository
+}
+
+func (stub pipelineRepositoryStub) FindById(id int) (*pipelineConfig.Pipeline, error) {
+	return &pipelineConfig.Pipeline{Id: id, AppId: 1, EnvironmentId: 2, App: app.App{AppName: "payments"}}, nil
+}
+
+type environmentServiceStub struct {
+	cluster.EnvironmentService
+	created []*cluster.EnvironmentBean
+}
+
+func (stub *environmentServiceStub) Create(mappings *cluster.EnvironmentBean, userId int32) (*cluster.EnvironmentBean, error) {
+	stub.created = append(stub.created, mappings)
+	return nil, errors.New("stop after environment")
+}
+
+func TestHandleWebhookCreatesPreviewForOpenPullRequest(t *testing.T) {
+	previewEnvironmentRepository := &previewEnvironmentRepositoryStub{
+		config: &pipelineConfig.PreviewEnvironmentConfig{Id: 5, AppId: 1, CiPipelineId: 3, ReferencePipelineId: 4, ClusterId: 1, Active: true},
+	}
+	environmentService := &environmentServiceStub{}
+	impl := &PreviewEnvironmentServiceImpl{
+		logger:                       util.NewSugardLogger(),
+		previewEnvironmentRepository: previewEnvironmentRepository,
+		ciPipelineMaterialRepository: ciPipelineMaterialRepositoryStub{},
+		pipelineRepository:           pipelineRepositoryStub{},
+		environmentService:           environmentService,
+	}
+	// pull request events carry the merged action type whether the pull request is open or not
+	err := impl.HandleWebhook(7, &bean.WebhookData{
+		Id:              11,
+		EventActionType: bean.WEBHOOK_EVENT_MERGED_ACTION_TYPE,
+		Data: map[string]string{
+			bean.WEBHOOK_SELECTOR_UNIQUE_ID_NAME: "42",
+			pullRequestStateSelector:             "open",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(environmentService.created) != 1 || environmentService.created[0].Namespace != "payments-pr-42" {
+		t.Fatalf("expected the preview environment payments-pr-42 to be created, got %+v", environmentService.created)
+	}
+	if len(previewEnvironmentRepository.saved) != 1 || previewEnvironmentRepository.saved[0].PullRequestId != "42" {
+		t.Fatalf("expected the preview of pull request 42 to be saved, got %+v", previewEnvironmentRepository.saved)
+	}
+}
+
+func TestHandleWebhookTearsDownClosedPullRequest(t *testing.T) {
+	previewEnvironmentRepository := &previewEnvironmentRepositoryStub{
+		config:   &pipelineConfig.PreviewEnvironmentConfig{Id: 5, AppId: 1, CiPipelineId: 3, ReferencePipelineId: 4, ClusterId: 1, Active: true},
+		existing: &pipelineConfig.PreviewEnvironment{Id: 9, ConfigId: 5, AppId: 1, PullRequestId: "42", Status: pipelineConfig.PREVIEW_ENVIRONMENT_ACTIVE},
+	}
+	environmentService := &environmentServiceStub{}
+	impl := &PreviewEnvironmentServiceImpl{
+		logger:                       util.NewSugardLogger(),
+		previewEnvironmentRepository: previewEnvironmentRepository,
+		ciPipelineMaterialRepository: ciPipelineMaterialRepositoryStub{},
+		pipelineRepository:           pipelineRepositoryStub{},
+		environmentService:           environmentService,
+	}
+	err := impl.HandleWebhook(7, &bean.WebhookData{
+		Id:              12,
+		EventActionType: bean.WEBHOOK_EVENT_MERGED_ACTION_TYPE,
+		Data: map[string]string{
+			bean.WEBHOOK_SELECTOR_UNIQUE_ID_NAME: "42",
+			pullRequestStateSelector:             "closed",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(environmentService.created) != 0 {
+		t.Errorf("expected no environment to be created for a closed pull request")
+	}
+	if len(previewEnvironmentRepository.updated) != 1 || previewEnvironmentRepository.updated[0].Status != pipelineConfig.PREVIEW_ENVIRONMENT_DELETED {
+		t.Errorf("expected the preview to be torn down, got %+v", previewEnvironmentRepository.updated)
+	}
+}
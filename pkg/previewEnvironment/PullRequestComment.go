@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package previewEnvironment
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+
+	"github.com/devtron-labs/devtron/internal/sql/repository"
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/google/go-github/github"
+	"github.com/xanzy/go-gitlab"
+	"golang.org/x/oauth2"
+)
+
+var (
+	githubPullRequestUrl  = regexp.MustCompile(`^(https?://[^/]+)/([^/]+)/([^/]+)/pull/(\d+)`)
+	gitlabMergeRequestUrl = regexp.MustCompile(`^(https?://[^/]+)/(.+?)(?:/-)?/merge_requests/(\d+)`)
+)
+
+// pullRequest is the location of a pull request parsed from its web url
+type pullRequest struct {
+	hostUrl string
+	// project is the repository on github and the full project path on gitlab
+	owner   string
+	project string
+	number  int
+	gitlab  bool
+}
+
+func parsePullRequestUrl(pullRequestUrl string) (*pullRequest, error) {
+	if match := githubPullRequestUrl.FindStringSubmatch(pullRequestUrl); match != nil {
+		number, _ := strconv.Atoi(match[4])
+		return &pullRequest{hostUrl: match[1], owner: match[2], project: match[3], number: number}, nil
+	}
+	if match := gitlabMergeRequestUrl.FindStringSubmatch(pullRequestUrl); match != nil {
+		number, _ := strconv.Atoi(match[3])
+		return &pullRequest{hostUrl: match[1], project: match[2], number: number, gitlab: true}, nil
+	}
+	return nil, fmt.Errorf("unsupported pull request url %s", pullRequestUrl)
+}
+
+// postPullRequestComment comments on a github or gitlab pull request with the credentials of the git provider
+// of the material
+func postPullRequestComment(gitProvider *repository.GitProvider, pullRequestUrl string, body string) error {
+	pr, err := parsePullRequestUrl(pullRequestUrl)
+	if err != nil {
+		return err
+	}
+	token := gitProvider.AccessToken
+	if len(token) == 0 {
+		token = gitProvider.Password
+	}
+	if len(token) == 0 {
+		return fmt.Errorf("no token configured for git provider %s", gitProvider.Name)
+	}
+	ctx := context.Background()
+	if pr.gitlab {
+		client := gitlab.NewClient(nil, token)
+		err = client.SetBaseURL(pr.hostUrl)
+		if err != nil {
+			return err
+		}
+		_, _, err = client.Notes.CreateMergeRequestNote(pr.project, pr.number, &gitlab.CreateMergeRequestNoteOptions{Body: &body})
+		return err
+	}
+	httpClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+	hostUrl, err := url.Parse(pr.hostUrl)
+	if err != nil {
+		return err
+	}
+	var client *github.Client
+	if hostUrl.Host == util.GITHUB_HOST {
+		client = github.NewClient(httpClient)
+	} else {
+		hostUrl.Path = path.Join(hostUrl.Path, util.GITHUB_API_V3)
+		client, err = github.NewEnterpriseClient(hostUrl.String(), hostUrl.String(), httpClient)
+		if err != nil {
+			return err
+		}
+	}
+	_, _, err = client.Issues.CreateComment(ctx, pr.owner, pr.project, pr.number, &github.IssueComment{Body: &body})
+	return err
+}
@@ -22,8 +22,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/devtron-labs/devtron/internal/sql/repository/app"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
 	"github.com/devtron-labs/devtron/pkg/sql"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -64,6 +66,7 @@ type PolicyServiceImpl struct {
 	scanHistoryRepository         security.ImageScanHistoryRepository
 	cveStoreRepository            security.CveStoreRepository
 	ciTemplateRepository          pipelineConfig.CiTemplateRepository
+	auditLogService               auditLog.AuditLogService
 }
 
 func NewPolicyServiceImpl(environmentService cluster.EnvironmentService,
@@ -78,7 +81,7 @@ func NewPolicyServiceImpl(environmentService cluster.EnvironmentService,
 	imageScanObjectMetaRepository security.ImageScanObjectMetaRepository, client *http.Client,
 	ciArtifactRepository repository.CiArtifactRepository, ciConfig *pipeline.CiConfig,
 	scanHistoryRepository security.ImageScanHistoryRepository, cveStoreRepository security.CveStoreRepository,
-	ciTemplateRepository pipelineConfig.CiTemplateRepository) *PolicyServiceImpl {
+	ciTemplateRepository pipelineConfig.CiTemplateRepository, auditLogService auditLog.AuditLogService) *PolicyServiceImpl {
 	return &PolicyServiceImpl{
 		environmentService:            environmentService,
 		logger:                        logger,
@@ -96,6 +99,7 @@ func NewPolicyServiceImpl(environmentService cluster.EnvironmentService,
 		scanHistoryRepository:         scanHistoryRepository,
 		cveStoreRepository:            cveStoreRepository,
 		ciTemplateRepository:          ciTemplateRepository,
+		auditLogService:               auditLogService,
 	}
 }
 
@@ -490,6 +494,7 @@ func (impl *PolicyServiceImpl) SavePolicy(request bean.CreateVulnerabilityPolicy
 		impl.logger.Errorw("error in saving policy", "err", err)
 		return nil, fmt.Errorf("error in saving policy")
 	}
+	impl.saveAuditEvent(auditLog.ActionCreate, nil, policy, userId)
 	return &bean.IdVulnerabilityPolicyResult{Id: policy.Id}, nil
 }
 
@@ -510,6 +515,7 @@ func (impl *PolicyServiceImpl) UpdatePolicy(updatePolicyParams bean.UpdatePolicy
 			impl.logger.Errorw("error in fetching policy ", "id", updatePolicyParams.Id)
 			return nil, err
 		}
+		before := *policy
 		policy.Action = policyAction
 		policy.UpdatedOn = time.Now()
 		policy.UpdatedBy = userId
@@ -517,6 +523,7 @@ func (impl *PolicyServiceImpl) UpdatePolicy(updatePolicyParams bean.UpdatePolicy
 		if err != nil {
 			return nil, err
 		} else {
+			impl.saveAuditEvent(auditLog.ActionUpdate, &before, policy, userId)
 			return &bean.IdVulnerabilityPolicyResult{Id: policy.Id}, nil
 		}
 	}
@@ -535,6 +542,7 @@ func (impl *PolicyServiceImpl) DeletePolicy(id int, userId int32) (*bean.IdVulne
 	if policy.Global && policy.CVEStoreId == "" {
 		return nil, fmt.Errorf("global severity policy can't be changed to inherit")
 	}
+	before := *policy
 	policy.Deleted = true
 	policy.UpdatedOn = time.Now()
 	policy.UpdatedBy = userId
@@ -542,10 +550,33 @@ func (impl *PolicyServiceImpl) DeletePolicy(id int, userId int32) (*bean.IdVulne
 	if err != nil {
 		return nil, err
 	} else {
+		impl.saveAuditEvent(auditLog.ActionDelete, &before, nil, userId)
 		return &bean.IdVulnerabilityPolicyResult{Id: policy.Id}, nil
 	}
 }
 
+func (impl *PolicyServiceImpl) saveAuditEvent(action string, before *security.CvePolicy, after *security.CvePolicy, userId int32) {
+	event := &auditLog.AuditEvent{
+		UserId:       userId,
+		ResourceType: auditLog.ResourcePolicy,
+		Action:       action,
+	}
+	for _, policy := range []*security.CvePolicy{before, after} {
+		if policy != nil {
+			event.ResourceId = strconv.Itoa(policy.Id)
+			event.AppId = policy.AppId
+			event.EnvironmentId = policy.EnvironmentId
+		}
+	}
+	if before != nil {
+		event.Before = before
+	}
+	if after != nil {
+		event.After = after
+	}
+	impl.auditLogService.SaveEvent(event)
+}
+
 /*
  global: na
  cluster: clusterId
@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	s32 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/devtron-labs/devtron/pkg/pipeline"
+	"go.uber.org/zap"
+	"os"
+)
+
+// RecordingStore keeps terminal recordings in the blob storage configured for ci logs
+type RecordingStore interface {
+	Upload(key string, fileName string) error
+	Download(key string, file *os.File) error
+}
+
+type RecordingStoreImpl struct {
+	logger   *zap.SugaredLogger
+	ciConfig *pipeline.CiConfig
+	bucket   string
+	region   string
+}
+
+func NewRecordingStoreImpl(logger *zap.SugaredLogger, ciConfig *pipeline.CiConfig, recordingConfig *RecordingConfig) *RecordingStoreImpl {
+	bucket := recordingConfig.Bucket
+	if bucket == "" {
+		bucket = ciConfig.DefaultBuildLogsBucket
+	}
+	region := recordingConfig.BucketRegion
+	if region == "" {
+		region = ciConfig.DefaultCacheBucketRegion
+	}
+	return &RecordingStoreImpl{
+		logger:   logger,
+		ciConfig: ciConfig,
+		bucket:   bucket,
+		region:   region,
+	}
+}
+
+func (impl RecordingStoreImpl) Upload(key string, fileName string) error {
+	if impl.ciConfig.CloudProvider == pipeline.BLOB_STORAGE_AZURE {
+		return pipeline.NewAzureBlob(impl.logger).UploadBlob(context.Background(), key, impl.azureBlobConfig(), fileName)
+	}
+	sess, err := impl.s3Session()
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = s3manager.NewUploader(sess).Upload(&s3manager.UploadInput{
+		Bucket:      aws.String(impl.bucket),
+		Key:         aws.String(key),
+		Body:        file,
+		ContentType: aws.String(AsciicastContentType),
+	})
+	return err
+}
+
+func (impl RecordingStoreImpl) Download(key string, file *os.File) error {
+	if impl.ciConfig.CloudProvider == pipeline.BLOB_STORAGE_AZURE {
+		return pipeline.NewAzureBlob(impl.logger).DownloadBlob(context.Background(), key, impl.azureBlobConfig(), file)
+	}
+	sess, err := impl.s3Session()
+	if err != nil {
+		return err
+	}
+	_, err = s3manager.NewDownloader(sess).Download(file, &s32.GetObjectInput{
+		Bucket: aws.String(impl.bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+func (impl RecordingStoreImpl) s3Session() (*session.Session, error) {
+	switch impl.ciConfig.CloudProvider {
+	case pipeline.BLOB_STORAGE_S3:
+		return session.NewSession(&aws.Config{
+			Region: aws.String(impl.region),
+		})
+	case pipeline.BLOB_STORAGE_MINIO:
+		return session.NewSession(&aws.Config{
+			Region:           aws.String("us-west-2"),
+			Endpoint:         aws.String(impl.ciConfig.MinioEndpoint),
+			DisableSSL:       aws.Bool(true),
+			S3ForcePathStyle: aws.Bool(true),
+			Credentials:      credentials.NewStaticCredentials(impl.ciConfig.MinioAccessKey, impl.ciConfig.MinioSecretKey, ""),
+		})
+	default:
+		return nil, fmt.Errorf("unsupported cloud %s", impl.ciConfig.CloudProvider)
+	}
+}
+
+func (impl RecordingStoreImpl) azureBlobConfig() *pipeline.AzureBlobConfig {
+	return &pipeline.AzureBlobConfig{
+		Enabled:            true,
+		AccountName:        impl.ciConfig.AzureAccountName,
+		BlobContainerCiLog: impl.ciConfig.AzureBlobContainerCiLog,
+		AccountKey:         impl.ciConfig.AzureAccountKey,
+	}
+}
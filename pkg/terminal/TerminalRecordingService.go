@@ -0,0 +1,420 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package terminal
+
+import (
+	"fmt"
+	"github.com/caarlos0/env"
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
+	"github.com/devtron-labs/devtron/pkg/cluster"
+	repository2 "github.com/devtron-labs/devtron/pkg/terminal/repository"
+	userRepository "github.com/devtron-labs/devtron/pkg/user/repository"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const AsciicastContentType = "application/x-asciicast"
+
+type RecordingConfig struct {
+	// MandatoryOnProd records every session on production environments, whatever the environment config says
+	MandatoryOnProd bool   `env:"TERMINAL_RECORDING_MANDATORY_ON_PROD" envDefault:"true"`
+	Bucket          string `env:"TERMINAL_RECORDING_BUCKET"`
+	BucketRegion    string `env:"TERMINAL_RECORDING_BUCKET_REGION"`
+	KeyPrefix       string `env:"TERMINAL_RECORDING_KEY_PREFIX" envDefault:"terminal-recordings"`
+}
+
+func GetRecordingConfig() (*RecordingConfig, error) {
+	cfg := &RecordingConfig{}
+	err := env.Parse(cfg)
+	return cfg, err
+}
+
+type TerminalRecordingDto struct {
+	Id            int        `json:"id"`
+	SessionId     string     `json:"sessionId"`
+	UserId        int32      `json:"userId"`
+	EmailId       string     `json:"emailId,omitempty"`
+	AppId         int        `json:"appId"`
+	EnvironmentId int        `json:"environmentId"`
+	Namespace     string     `json:"namespace"`
+	PodName       string     `json:"podName"`
+	ContainerName string     `json:"containerName"`
+	Shell         string     `json:"shell"`
+	Status        string     `json:"status"`
+	SizeBytes     int64      `json:"sizeBytes"`
+	StartedOn     time.Time  `json:"startedOn"`
+	EndedOn       *time.Time `json:"endedOn,omitempty"`
+}
+
+type TerminalRecordingListDto struct {
+	Recordings []*TerminalRecordingDto `json:"recordings"`
+	TotalCount int                     `json:"totalCount"`
+}
+
+type TerminalRecordingConfigDto struct {
+	EnvironmentId   int    `json:"environmentId" validate:"number,required"`
+	EnvironmentName string `json:"environmentName,omitempty"`
+	Enabled         bool   `json:"enabled"`
+	// Mandatory is set for production environments when recording is enforced on them
+	Mandatory bool  `json:"mandatory"`
+	UserId    int32 `json:"-"`
+}
+
+// SessionRecorder records a terminal session to a local file until the session ends and it is uploaded
+type SessionRecorder struct {
+	writer    *asciicastWriter
+	file      *os.File
+	recording *repository2.TerminalSessionRecording
+}
+
+type TerminalRecordingService interface {
+	// StartSession audits a terminal session and, if the environment needs it, starts recording it. The recorder
+	// is nil for sessions which are not recorded
+	StartSession(request *TerminalSessionRequest) (*SessionRecorder, error)
+	// EndSession uploads the recording of an ended session
+	EndSession(recorder *SessionRecorder)
+	IsRecordingRequired(environmentId int) (bool, error)
+
+	GetRecordings(filter *repository2.TerminalRecordingFilter) (*TerminalRecordingListDto, error)
+	GetRecordingById(id int) (*TerminalRecordingDto, error)
+	// DownloadRecording returns the asciicast of a completed recording, the file is removed by the cleanup func
+	DownloadRecording(id int) (*os.File, func() error, error)
+
+	GetConfigs() ([]*TerminalRecordingConfigDto, error)
+	SaveConfig(request *TerminalRecordingConfigDto) (*TerminalRecordingConfigDto, error)
+}
+
+type TerminalRecordingServiceImpl struct {
+	logger                      *zap.SugaredLogger
+	terminalRecordingRepository repository2.TerminalRecordingRepository
+	recordingStore              RecordingStore
+	environmentService          cluster.EnvironmentService
+	userRepository              userRepository.UserRepository
+	auditLogService             auditLog.AuditLogService
+	recordingConfig             *RecordingConfig
+}
+
+func NewTerminalRecordingServiceImpl(logger *zap.SugaredLogger, terminalRecordingRepository repository2.TerminalRecordingRepository,
+	recordingStore RecordingStore, environmentService cluster.EnvironmentService, userRepository userRepository.UserRepository,
+	auditLogService auditLog.AuditLogService, recordingConfig *RecordingConfig) *TerminalRecordingServiceImpl {
+	return &TerminalRecordingServiceImpl{
+		logger:                      logger,
+		terminalRecordingRepository: terminalRecordingRepository,
+		recordingStore:              recordingStore,
+		environmentService:          environmentService,
+		userRepository:              userRepository,
+		auditLogService:             auditLogService,
+		recordingConfig:             recordingConfig,
+	}
+}
+
+func (impl TerminalRecordingServiceImpl) StartSession(request *TerminalSessionRequest) (*SessionRecorder, error) {
+	required, err := impl.IsRecordingRequired(request.EnvironmentId)
+	if err != nil {
+		return nil, err
+	}
+	impl.auditLogService.SaveEvent(&auditLog.AuditEvent{
+		UserId:        request.UserId,
+		ResourceType:  auditLog.ResourceTerminalSession,
+		ResourceId:    request.SessionId,
+		AppId:         request.AppId,
+		EnvironmentId: request.EnvironmentId,
+		Action:        auditLog.ActionCreate,
+		After: map[string]interface{}{
+			"namespace": request.Namespace,
+			"pod":       request.PodName,
+			"container": request.ContainerName,
+			"recorded":  required,
+			"debug":     request.DebugImage,
+		},
+	})
+	if !required {
+		return nil, nil
+	}
+	file, err := ioutil.TempFile("", "terminal-"+request.SessionId+"-*.cast")
+	if err != nil {
+		impl.logger.Errorw("error in creating recording file", "sessionId", request.SessionId, "err", err)
+		return nil, err
+	}
+	title := fmt.Sprintf("%s/%s/%s", request.Namespace, request.PodName, request.ContainerName)
+	writer, err := newAsciicastWriter(file, title, request.Shell, time.Now)
+	if err != nil {
+		impl.logger.Errorw("error in starting recording", "sessionId", request.SessionId, "err", err)
+		impl.removeFile(file)
+		return nil, err
+	}
+	now := time.Now()
+	recording := &repository2.TerminalSessionRecording{
+		SessionId:     request.SessionId,
+		UserId:        request.UserId,
+		AppId:         request.AppId,
+		EnvironmentId: request.EnvironmentId,
+		Namespace:     request.Namespace,
+		PodName:       request.PodName,
+		ContainerName: request.ContainerName,
+		Shell:         request.Shell,
+		Status:        repository2.RecordingStatusRecording,
+		StartedOn:     now,
+	}
+	recording.CreatedBy = request.UserId
+	recording.CreatedOn = now
+	recording.UpdatedBy = request.UserId
+	recording.UpdatedOn = now
+	err = impl.terminalRecordingRepository.Save(recording)
+	if err != nil {
+		impl.logger.Errorw("error in saving recording", "sessionId", request.SessionId, "err", err)
+		impl.removeFile(file)
+		return nil, err
+	}
+	return &SessionRecorder{writer: writer, file: file, recording: recording}, nil
+}
+
+func (impl TerminalRecordingServiceImpl) EndSession(recorder *SessionRecorder) {
+	if recorder == nil {
+		return
+	}
+	defer impl.removeFile(recorder.file)
+	recording := recorder.recording
+	recording.EndedOn = time.Now()
+	recording.UpdatedOn = recording.EndedOn
+	recording.Status = repository2.RecordingStatusCompleted
+	size, err := recorder.writer.result()
+	recording.SizeBytes = size
+	if err == nil {
+		err = recorder.file.Sync()
+	}
+	if err == nil {
+		recording.BlobKey = fmt.Sprintf("%s/%d/%d/%s.cast", impl.recordingConfig.KeyPrefix, recording.AppId, recording.EnvironmentId, recording.SessionId)
+		err = impl.recordingStore.Upload(recording.BlobKey, recorder.file.Name())
+	}
+	if err != nil {
+		impl.logger.Errorw("error in storing recording", "sessionId", recording.SessionId, "err", err)
+		recording.Status = repository2.RecordingStatusFailed
+		recording.BlobKey = ""
+	}
+	err = impl.terminalRecordingRepository.Update(recording)
+	if err != nil {
+		impl.logger.Errorw("error in updating recording", "sessionId", recording.SessionId, "err", err)
+	}
+}
+
+func (impl TerminalRecordingServiceImpl) removeFile(file *os.File) {
+	_ = file.Close()
+	err := os.Remove(file.Name())
+	if err != nil {
+		impl.logger.Warnw("error in removing recording file", "file", file.Name(), "err", err)
+	}
+}
+
+func (impl TerminalRecordingServiceImpl) IsRecordingRequired(environmentId int) (bool, error) {
+	if impl.recordingConfig.MandatoryOnProd {
+		environment, err := impl.environmentService.FindById(environmentId)
+		if err != nil {
+			impl.logger.Errorw("error in fetching environment", "environmentId", environmentId, "err", err)
+			return false, err
+		}
+		if environment.Default {
+			return true, nil
+		}
+	}
+	config, err := impl.terminalRecordingRepository.FindConfigByEnvironmentId(environmentId)
+	if err == pg.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching recording config", "environmentId", environmentId, "err", err)
+		return false, err
+	}
+	return config.Enabled, nil
+}
+
+func (impl TerminalRecordingServiceImpl) GetRecordings(filter *repository2.TerminalRecordingFilter) (*TerminalRecordingListDto, error) {
+	models, count, err := impl.terminalRecordingRepository.FindByFilter(filter)
+	if err != nil {
+		impl.logger.Errorw("error in fetching recordings", "filter", filter, "err", err)
+		return nil, err
+	}
+	var userIds []int32
+	for _, model := range models {
+		userIds = append(userIds, model.UserId)
+	}
+	emails := make(map[int32]string)
+	if len(userIds) > 0 {
+		users, err := impl.userRepository.GetByIds(userIds)
+		if err != nil && err != pg.ErrNoRows {
+			impl.logger.Errorw("error in fetching users of recordings", "err", err)
+			return nil, err
+		}
+		for _, user := range users {
+			emails[user.Id] = user.EmailId
+		}
+	}
+	recordings := make([]*TerminalRecordingDto, 0, len(models))
+	for _, model := range models {
+		recording := adaptRecording(model)
+		recording.EmailId = emails[model.UserId]
+		recordings = append(recordings, recording)
+	}
+	return &TerminalRecordingListDto{Recordings: recordings, TotalCount: count}, nil
+}
+
+func (impl TerminalRecordingServiceImpl) GetRecordingById(id int) (*TerminalRecordingDto, error) {
+	model, err := impl.findRecording(id)
+	if err != nil {
+		return nil, err
+	}
+	recording := adaptRecording(model)
+	user, err := impl.userRepository.GetByIdIncludeDeleted(model.UserId)
+	if err == nil {
+		recording.EmailId = user.EmailId
+	}
+	return recording, nil
+}
+
+func (impl TerminalRecordingServiceImpl) DownloadRecording(id int) (*os.File, func() error, error) {
+	model, err := impl.findRecording(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if model.Status != repository2.RecordingStatusCompleted {
+		return nil, nil, &util.ApiError{HttpStatusCode: http.StatusNotFound, UserMessage: fmt.Sprintf("recording %d is %s", id, model.Status)}
+	}
+	file, err := ioutil.TempFile("", "terminal-"+model.SessionId+"-*.cast")
+	if err != nil {
+		impl.logger.Errorw("error in creating recording file", "id", id, "err", err)
+		return nil, nil, err
+	}
+	cleanUpFunc := func() error {
+		impl.removeFile(file)
+		return nil
+	}
+	err = impl.recordingStore.Download(model.BlobKey, file)
+	if err != nil {
+		impl.logger.Errorw("error in downloading recording", "id", id, "key", model.BlobKey, "err", err)
+		_ = cleanUpFunc()
+		return nil, nil, err
+	}
+	return file, cleanUpFunc, nil
+}
+
+func (impl TerminalRecordingServiceImpl) findRecording(id int) (*repository2.TerminalSessionRecording, error) {
+	model, err := impl.terminalRecordingRepository.FindById(id)
+	if err == pg.ErrNoRows {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusNotFound, UserMessage: fmt.Sprintf("recording %d not found", id)}
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching recording", "id", id, "err", err)
+		return nil, err
+	}
+	return model, nil
+}
+
+// GetConfigs lists the recording config of every active environment
+func (impl TerminalRecordingServiceImpl) GetConfigs() ([]*TerminalRecordingConfigDto, error) {
+	environments, err := impl.environmentService.GetAllActive()
+	if err != nil {
+		impl.logger.Errorw("error in fetching environments", "err", err)
+		return nil, err
+	}
+	models, err := impl.terminalRecordingRepository.FindAllConfigs()
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching recording configs", "err", err)
+		return nil, err
+	}
+	enabled := make(map[int]bool)
+	for _, model := range models {
+		enabled[model.EnvironmentId] = model.Enabled
+	}
+	configs := make([]*TerminalRecordingConfigDto, 0, len(environments))
+	for _, environment := range environments {
+		configs = append(configs, &TerminalRecordingConfigDto{
+			EnvironmentId:   environment.Id,
+			EnvironmentName: environment.Environment,
+			Enabled:         enabled[environment.Id],
+			Mandatory:       impl.recordingConfig.MandatoryOnProd && environment.Default,
+		})
+	}
+	return configs, nil
+}
+
+func (impl TerminalRecordingServiceImpl) SaveConfig(request *TerminalRecordingConfigDto) (*TerminalRecordingConfigDto, error) {
+	environment, err := impl.environmentService.FindById(request.EnvironmentId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching environment", "environmentId", request.EnvironmentId, "err", err)
+		return nil, err
+	}
+	now := time.Now()
+	model, err := impl.terminalRecordingRepository.FindConfigByEnvironmentId(request.EnvironmentId)
+	if err == pg.ErrNoRows {
+		model = &repository2.TerminalRecordingConfig{EnvironmentId: request.EnvironmentId, Enabled: request.Enabled}
+		model.CreatedBy = request.UserId
+		model.CreatedOn = now
+		model.UpdatedBy = request.UserId
+		model.UpdatedOn = now
+		err = impl.terminalRecordingRepository.SaveConfig(model)
+	} else if err == nil {
+		model.Enabled = request.Enabled
+		model.UpdatedBy = request.UserId
+		model.UpdatedOn = now
+		err = impl.terminalRecordingRepository.UpdateConfig(model)
+	}
+	if err != nil {
+		impl.logger.Errorw("error in saving recording config", "environmentId", request.EnvironmentId, "err", err)
+		return nil, err
+	}
+	impl.auditLogService.SaveEvent(&auditLog.AuditEvent{
+		UserId:        request.UserId,
+		ResourceType:  auditLog.ResourceTerminalSession,
+		ResourceId:    strconv.Itoa(request.EnvironmentId),
+		EnvironmentId: request.EnvironmentId,
+		Action:        auditLog.ActionUpdate,
+		After:         map[string]interface{}{"recordingEnabled": request.Enabled},
+	})
+	return &TerminalRecordingConfigDto{
+		EnvironmentId:   environment.Id,
+		EnvironmentName: environment.Environment,
+		Enabled:         model.Enabled,
+		Mandatory:       impl.recordingConfig.MandatoryOnProd && environment.Default,
+	}, nil
+}
+
+func adaptRecording(model *repository2.TerminalSessionRecording) *TerminalRecordingDto {
+	recording := &TerminalRecordingDto{
+		Id:            model.Id,
+		SessionId:     model.SessionId,
+		UserId:        model.UserId,
+		AppId:         model.AppId,
+		EnvironmentId: model.EnvironmentId,
+		Namespace:     model.Namespace,
+		PodName:       model.PodName,
+		ContainerName: model.ContainerName,
+		Shell:         model.Shell,
+		Status:        model.Status,
+		SizeBytes:     model.SizeBytes,
+		StartedOn:     model.StartedOn,
+	}
+	if !model.EndedOn.IsZero() {
+		endedOn := model.EndedOn
+		recording.EndedOn = &endedOn
+	}
+	return recording
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package terminal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	asciicastVersion     = 2
+	asciicastOutputEvent = "o"
+	asciicastInputEvent  = "i"
+	asciicastResizeEvent = "r"
+	defaultTerminalCols  = 80
+	defaultTerminalRows  = 24
+)
+
+type asciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     uint16            `json:"width"`
+	Height    uint16            `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// asciicastWriter writes a terminal session in asciicast v2 format, a json header line followed by one
+// [elapsed seconds, event type, data] line per event. Writes are serialised as input and output are recorded
+// from different goroutines.
+type asciicastWriter struct {
+	lock  sync.Mutex
+	w     io.Writer
+	start time.Time
+	now   func() time.Time
+	size  int64
+	err   error
+}
+
+func newAsciicastWriter(w io.Writer, title string, shell string, now func() time.Time) (*asciicastWriter, error) {
+	writer := &asciicastWriter{w: w, now: now, start: now()}
+	header := asciicastHeader{
+		Version:   asciicastVersion,
+		Width:     defaultTerminalCols,
+		Height:    defaultTerminalRows,
+		Timestamp: writer.start.Unix(),
+		Title:     title,
+		Env:       map[string]string{"SHELL": shell, "TERM": "xterm"},
+	}
+	err := writer.writeLine(header)
+	if err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (impl *asciicastWriter) output(data []byte) {
+	impl.event(asciicastOutputEvent, string(data))
+}
+
+func (impl *asciicastWriter) input(data string) {
+	impl.event(asciicastInputEvent, data)
+}
+
+func (impl *asciicastWriter) resize(cols uint16, rows uint16) {
+	impl.event(asciicastResizeEvent, fmt.Sprintf("%dx%d", cols, rows))
+}
+
+// event records an event, after the first failed write the recording stops and the error is kept
+func (impl *asciicastWriter) event(eventType string, data string) {
+	impl.lock.Lock()
+	defer impl.lock.Unlock()
+	if impl.err != nil {
+		return
+	}
+	elapsed := float64(impl.now().Sub(impl.start).Microseconds()) / 1e6
+	impl.err = impl.writeLine([]interface{}{elapsed, eventType, data})
+}
+
+func (impl *asciicastWriter) writeLine(v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	n, err := impl.w.Write(append(line, '\n'))
+	impl.size += int64(n)
+	return err
+}
+
+func (impl *asciicastWriter) result() (int64, error) {
+	impl.lock.Lock()
+	defer impl.lock.Unlock()
+	return impl.size, impl.err
+}
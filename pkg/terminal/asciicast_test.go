@@ -0,0 +1,47 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAsciicastWriter(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	now := start
+	clock := func() time.Time { return now }
+	buf := &bytes.Buffer{}
+	writer, err := newAsciicastWriter(buf, "ns/pod/main", "bash", clock)
+	if err != nil {
+		t.Fatalf("newAsciicastWriter() error = %v", err)
+	}
+	now = start.Add(1500 * time.Millisecond)
+	writer.input("ls\r")
+	now = start.Add(2 * time.Second)
+	writer.output([]byte("file\r\n"))
+	writer.resize(120, 40)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want 4: %q", len(lines), buf.String())
+	}
+	header := asciicastHeader{}
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil {
+		t.Fatalf("invalid header %s: %v", lines[0], err)
+	}
+	if header.Version != 2 || header.Timestamp != start.Unix() || header.Env["SHELL"] != "bash" {
+		t.Errorf("unexpected header %s", lines[0])
+	}
+	want := []string{`[1.5,"i","ls\r"]`, `[2,"o","file\r\n"]`, `[2,"r","120x40"]`}
+	for i, line := range lines[1:] {
+		if line != want[i] {
+			t.Errorf("event %d = %s, want %s", i, line, want[i])
+		}
+	}
+	size, err := writer.result()
+	if err != nil || size != int64(buf.Len()) {
+		t.Errorf("result() = %d, %v, want %d", size, err, buf.Len())
+	}
+}
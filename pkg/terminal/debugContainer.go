@@ -0,0 +1,187 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package terminal
+
+import (
+	"encoding/json"
+	"fmt"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"time"
+)
+
+// the vendored core/v1 types predate ephemeral containers, so pod specs and statuses are handled as raw json
+// with only the fields needed here
+
+const (
+	debugContainerNamePrefix = "devtron-debug-"
+	debugContainerPollPeriod = time.Second
+	defaultDebugShell        = "sh"
+)
+
+type ephemeralContainer struct {
+	Name                     string   `json:"name"`
+	Image                    string   `json:"image"`
+	Command                  []string `json:"command"`
+	ImagePullPolicy          string   `json:"imagePullPolicy"`
+	Stdin                    bool     `json:"stdin"`
+	StdinOnce                bool     `json:"stdinOnce"`
+	TTY                      bool     `json:"tty"`
+	TargetContainerName      string   `json:"targetContainerName,omitempty"`
+	TerminationMessagePolicy string   `json:"terminationMessagePolicy"`
+}
+
+type ephemeralContainerPatch struct {
+	Spec struct {
+		EphemeralContainers []ephemeralContainer `json:"ephemeralContainers"`
+	} `json:"spec"`
+}
+
+type ephemeralContainerPod struct {
+	Status struct {
+		EphemeralContainerStatuses []v1.ContainerStatus `json:"ephemeralContainerStatuses"`
+	} `json:"status"`
+}
+
+func debugContainerName(sessionId string) string {
+	if len(sessionId) > 8 {
+		sessionId = sessionId[:8]
+	}
+	return debugContainerNamePrefix + sessionId
+}
+
+// buildEphemeralContainerPatch is the strategic merge patch adding the debug container of a session. The shell
+// reads stdin once, so it exits and the container terminates when the session detaches.
+func buildEphemeralContainerPatch(request *TerminalSessionRequest, shell string) ([]byte, error) {
+	patch := ephemeralContainerPatch{}
+	patch.Spec.EphemeralContainers = []ephemeralContainer{{
+		Name:                     debugContainerName(request.SessionId),
+		Image:                    request.DebugImage,
+		Command:                  []string{shell},
+		ImagePullPolicy:          string(v1.PullIfNotPresent),
+		Stdin:                    true,
+		StdinOnce:                true,
+		TTY:                      true,
+		TargetContainerName:      request.ContainerName,
+		TerminationMessagePolicy: string(v1.TerminationMessageReadFile),
+	}}
+	return json.Marshal(patch)
+}
+
+// ephemeralContainerState returns the state of the named ephemeral container from a pod in json, nil if the
+// container has no status yet
+func ephemeralContainerState(podJson []byte, name string) (*v1.ContainerState, error) {
+	pod := ephemeralContainerPod{}
+	err := json.Unmarshal(podJson, &pod)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range pod.Status.EphemeralContainerStatuses {
+		if status.Name == name {
+			return &status.State, nil
+		}
+	}
+	return nil, nil
+}
+
+// startDebugSession adds an ephemeral debug container to the pod, sharing the process namespace of the target
+// container, and attaches the session to its shell
+func startDebugSession(k8sClient kubernetes.Interface, cfg *rest.Config, ptyHandler PtyHandler, request *TerminalSessionRequest) error {
+	shell := request.Shell
+	if !isValidShell([]string{"bash", "sh"}, shell) {
+		shell = defaultDebugShell
+	}
+	patch, err := buildEphemeralContainerPatch(request, shell)
+	if err != nil {
+		return err
+	}
+	err = k8sClient.CoreV1().RESTClient().Patch(types.StrategicMergePatchType).
+		Namespace(request.Namespace).
+		Resource("pods").
+		Name(request.PodName).
+		SubResource("ephemeralcontainers").
+		Body(patch).
+		Do().
+		Error()
+	if err != nil {
+		return fmt.Errorf("could not add debug container, ephemeral containers may not be enabled on the cluster: %v", err)
+	}
+	name := debugContainerName(request.SessionId)
+	err = waitForDebugContainer(k8sClient, request, name)
+	if err != nil {
+		return err
+	}
+
+	req := k8sClient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(request.PodName).
+		Namespace(request.Namespace).
+		SubResource("attach")
+	req.VersionedParams(&v1.PodAttachOptions{
+		Container: name,
+		Stdin:     true,
+		Stdout:    true,
+		TTY:       true,
+	}, scheme.ParameterCodec)
+	attach, err := remotecommand.NewSPDYExecutor(cfg, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+	return attach.Stream(remotecommand.StreamOptions{
+		Stdin:             ptyHandler,
+		Stdout:            ptyHandler,
+		TerminalSizeQueue: ptyHandler,
+		Tty:               true,
+	})
+}
+
+func waitForDebugContainer(k8sClient kubernetes.Interface, request *TerminalSessionRequest, name string) error {
+	deadline := time.Now().Add(request.debugStartTimeout)
+	for {
+		podJson, err := k8sClient.CoreV1().RESTClient().Get().
+			Namespace(request.Namespace).
+			Resource("pods").
+			Name(request.PodName).
+			Do().
+			Raw()
+		if err != nil {
+			return err
+		}
+		state, err := ephemeralContainerState(podJson, name)
+		if err != nil {
+			return err
+		}
+		if state != nil && state.Running != nil {
+			return nil
+		} else if state != nil && state.Terminated != nil {
+			return fmt.Errorf("debug container %s terminated: %s", name, state.Terminated.Reason)
+		}
+		if time.Now().After(deadline) {
+			reason := "not started"
+			if state != nil && state.Waiting != nil {
+				reason = state.Waiting.Reason
+			}
+			return fmt.Errorf("debug container %s did not start in %s: %s", name, request.debugStartTimeout, reason)
+		}
+		time.Sleep(debugContainerPollPeriod)
+	}
+}
@@ -0,0 +1,42 @@
+package terminal
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBuildEphemeralContainerPatch(t *testing.T) {
+	request := &TerminalSessionRequest{SessionId: "0123456789abcdef", ContainerName: "app", DebugImage: "busybox:1.36"}
+	patch, err := buildEphemeralContainerPatch(request, "sh")
+	if err != nil {
+		t.Fatalf("buildEphemeralContainerPatch() error = %v", err)
+	}
+	got := ephemeralContainerPatch{}
+	if err := json.Unmarshal(patch, &got); err != nil {
+		t.Fatalf("invalid patch %s: %v", patch, err)
+	}
+	if len(got.Spec.EphemeralContainers) != 1 {
+		t.Fatalf("got %d containers, want 1", len(got.Spec.EphemeralContainers))
+	}
+	container := got.Spec.EphemeralContainers[0]
+	if container.Name != "devtron-debug-01234567" || container.TargetContainerName != "app" || container.Image != "busybox:1.36" {
+		t.Errorf("unexpected container %+v", container)
+	}
+	if !container.Stdin || !container.StdinOnce || !container.TTY {
+		t.Errorf("debug container must be interactive and exit on detach, got %+v", container)
+	}
+}
+
+func TestEphemeralContainerState(t *testing.T) {
+	pod := []byte(`{"status":{"ephemeralContainerStatuses":[
+		{"name":"devtron-debug-old","state":{"terminated":{"exitCode":0,"reason":"Completed"}}},
+		{"name":"devtron-debug-01234567","state":{"running":{"startedAt":"2021-01-01T00:00:00Z"}}}]}}`)
+	state, err := ephemeralContainerState(pod, "devtron-debug-01234567")
+	if err != nil || state == nil || state.Running == nil {
+		t.Errorf("ephemeralContainerState() = %+v, %v, want running", state, err)
+	}
+	state, err = ephemeralContainerState(pod, "devtron-debug-missing")
+	if err != nil || state != nil {
+		t.Errorf("ephemeralContainerState() = %+v, %v, want nil", state, err)
+	}
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package repository
+
+import (
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	RecordingStatusRecording = "RECORDING"
+	RecordingStatusCompleted = "COMPLETED"
+	RecordingStatusFailed    = "FAILED"
+)
+
+// TerminalRecordingConfig turns recording on for terminal sessions of an environment
+type TerminalRecordingConfig struct {
+	TableName     struct{} `sql:"terminal_recording_config" pg:",discard_unknown_columns"`
+	Id            int      `sql:"id,pk"`
+	EnvironmentId int      `sql:"environment_id,notnull"`
+	Enabled       bool     `sql:"enabled,notnull"`
+	sql.AuditLog
+}
+
+// TerminalSessionRecording is the metadata of a recorded terminal session, the asciicast itself is at BlobKey
+// in blob storage
+type TerminalSessionRecording struct {
+	TableName     struct{}  `sql:"terminal_session_recording" pg:",discard_unknown_columns"`
+	Id            int       `sql:"id,pk"`
+	SessionId     string    `sql:"session_id,notnull"`
+	UserId        int32     `sql:"user_id,notnull"`
+	AppId         int       `sql:"app_id,notnull"`
+	EnvironmentId int       `sql:"environment_id,notnull"`
+	Namespace     string    `sql:"namespace,notnull"`
+	PodName       string    `sql:"pod_name,notnull"`
+	ContainerName string    `sql:"container_name"`
+	Shell         string    `sql:"shell"`
+	Status        string    `sql:"status,notnull"`
+	BlobKey       string    `sql:"blob_key"`
+	SizeBytes     int64     `sql:"size_bytes,notnull"`
+	StartedOn     time.Time `sql:"started_on,notnull"`
+	EndedOn       time.Time `sql:"ended_on"`
+	sql.AuditLog
+}
+
+type TerminalRecordingFilter struct {
+	UserId        int32
+	AppId         int
+	EnvironmentId int
+	Offset        int
+	Size          int
+}
+
+type TerminalRecordingRepository interface {
+	FindConfigByEnvironmentId(environmentId int) (*TerminalRecordingConfig, error)
+	FindAllConfigs() ([]*TerminalRecordingConfig, error)
+	SaveConfig(model *TerminalRecordingConfig) error
+	UpdateConfig(model *TerminalRecordingConfig) error
+
+	Save(model *TerminalSessionRecording) error
+	Update(model *TerminalSessionRecording) error
+	FindById(id int) (*TerminalSessionRecording, error)
+	FindByFilter(filter *TerminalRecordingFilter) ([]*TerminalSessionRecording, int, error)
+}
+
+type TerminalRecordingRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func NewTerminalRecordingRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *TerminalRecordingRepositoryImpl {
+	return &TerminalRecordingRepositoryImpl{
+		dbConnection: dbConnection,
+		logger:       logger,
+	}
+}
+
+func (impl TerminalRecordingRepositoryImpl) FindConfigByEnvironmentId(environmentId int) (*TerminalRecordingConfig, error) {
+	model := &TerminalRecordingConfig{}
+	err := impl.dbConnection.Model(model).Where("environment_id = ?", environmentId).Select()
+	return model, err
+}
+
+func (impl TerminalRecordingRepositoryImpl) FindAllConfigs() ([]*TerminalRecordingConfig, error) {
+	var models []*TerminalRecordingConfig
+	err := impl.dbConnection.Model(&models).Order("environment_id").Select()
+	return models, err
+}
+
+func (impl TerminalRecordingRepositoryImpl) SaveConfig(model *TerminalRecordingConfig) error {
+	return impl.dbConnection.Insert(model)
+}
+
+func (impl TerminalRecordingRepositoryImpl) UpdateConfig(model *TerminalRecordingConfig) error {
+	return impl.dbConnection.Update(model)
+}
+
+func (impl TerminalRecordingRepositoryImpl) Save(model *TerminalSessionRecording) error {
+	return impl.dbConnection.Insert(model)
+}
+
+func (impl TerminalRecordingRepositoryImpl) Update(model *TerminalSessionRecording) error {
+	return impl.dbConnection.Update(model)
+}
+
+func (impl TerminalRecordingRepositoryImpl) FindById(id int) (*TerminalSessionRecording, error) {
+	model := &TerminalSessionRecording{}
+	err := impl.dbConnection.Model(model).Where("id = ?", id).Select()
+	return model, err
+}
+
+// FindByFilter returns a page of recordings latest first along with the total count
+func (impl TerminalRecordingRepositoryImpl) FindByFilter(filter *TerminalRecordingFilter) ([]*TerminalSessionRecording, int, error) {
+	var models []*TerminalSessionRecording
+	query := impl.dbConnection.Model(&models).
+		Apply(func(q *orm.Query) (*orm.Query, error) {
+			if filter.UserId > 0 {
+				q = q.Where("user_id = ?", filter.UserId)
+			}
+			if filter.AppId > 0 {
+				q = q.Where("app_id = ?", filter.AppId)
+			}
+			if filter.EnvironmentId > 0 {
+				q = q.Where("environment_id = ?", filter.EnvironmentId)
+			}
+			return q, nil
+		}).
+		Order("id DESC").
+		Offset(filter.Offset).
+		Limit(filter.Size)
+	count, err := query.SelectAndCount()
+	return models, count, err
+}
@@ -18,13 +18,16 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"github.com/caarlos0/env"
 	"github.com/devtron-labs/devtron/pkg/cluster"
 	"go.uber.org/zap"
 	"io"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
+	"time"
 
 	"gopkg.in/igm/sockjs-go.v3/sockjs"
 	v1 "k8s.io/api/core/v1"
@@ -50,6 +53,7 @@ type TerminalSession struct {
 	sockJSSession sockjs.Session
 	sizeChan      chan remotecommand.TerminalSize
 	doneChan      chan struct{}
+	recorder      *SessionRecorder
 }
 
 // TerminalMessage is the messaging protocol between ShellController and TerminalSession.
@@ -93,8 +97,14 @@ func (t TerminalSession) Read(p []byte) (int, error) {
 
 	switch msg.Op {
 	case "stdin":
+		if t.recorder != nil {
+			t.recorder.writer.input(msg.Data)
+		}
 		return copy(p, msg.Data), nil
 	case "resize":
+		if t.recorder != nil {
+			t.recorder.writer.resize(msg.Cols, msg.Rows)
+		}
 		t.sizeChan <- remotecommand.TerminalSize{Width: msg.Cols, Height: msg.Rows}
 		return 0, nil
 	default:
@@ -105,6 +115,9 @@ func (t TerminalSession) Read(p []byte) (int, error) {
 // Write handles process->pty stdout
 // Called from remotecommand whenever there is any output
 func (t TerminalSession) Write(p []byte) (int, error) {
+	if t.recorder != nil {
+		t.recorder.writer.output(p)
+	}
 	msg, err := json.Marshal(TerminalMessage{
 		Op:   "stdout",
 		Data: string(p),
@@ -287,6 +300,12 @@ type TerminalSessionRequest struct {
 	ContainerName string
 	EnvironmentId int
 	AppId         int
+	UserId        int32
+	// Debug attaches the session to an ephemeral container running DebugImage, which shares the process
+	// namespace of ContainerName
+	Debug             bool
+	DebugImage        string
+	debugStartTimeout time.Duration
 }
 
 // WaitForTerminal is called from apihandler.handleAttach as a goroutine
@@ -296,11 +315,22 @@ func WaitForTerminal(k8sClient kubernetes.Interface, cfg *rest.Config, request *
 	select {
 	case <-terminalSessions.Get(request.SessionId).bound:
 		close(terminalSessions.Get(request.SessionId).bound)
+		if session := terminalSessions.Get(request.SessionId); session.recorder != nil {
+			if err := session.Toast("This session is being recorded"); err != nil {
+				log.Println(err)
+			}
+		}
 
 		var err error
 		validShells := []string{"bash", "sh", "powershell", "cmd"}
 
-		if isValidShell(validShells, request.Shell) {
+		if request.Debug {
+			session := terminalSessions.Get(request.SessionId)
+			if err := session.Toast("Starting debug container, if you don't see a command prompt, try pressing enter"); err != nil {
+				log.Println(err)
+			}
+			err = startDebugSession(k8sClient, cfg, session, request)
+		} else if isValidShell(validShells, request.Shell) {
 			cmd := []string{request.Shell}
 
 			err = startProcess(k8sClient, cfg, cmd, terminalSessions.Get(request.SessionId), request)
@@ -327,19 +357,36 @@ func WaitForTerminal(k8sClient kubernetes.Interface, cfg *rest.Config, request *
 type TerminalSessionHandler interface {
 	GetTerminalSession(req *TerminalSessionRequest) (statusCode int, message *TerminalMessage, err error)
 }
+type DebugContainerConfig struct {
+	DefaultImage string `env:"TERMINAL_DEBUG_IMAGE" envDefault:"busybox:1.36"`
+	// AllowedImages can be requested besides the default image
+	AllowedImages       []string `env:"TERMINAL_DEBUG_ALLOWED_IMAGES" envSeparator:","`
+	StartTimeoutSeconds int      `env:"TERMINAL_DEBUG_START_TIMEOUT_SECONDS" envDefault:"120"`
+}
+
 type TerminalSessionHandlerImpl struct {
-	environmentService cluster.EnvironmentService
-	clusterService     cluster.ClusterService
-	logger             *zap.SugaredLogger
+	environmentService       cluster.EnvironmentService
+	clusterService           cluster.ClusterService
+	logger                   *zap.SugaredLogger
+	terminalRecordingService TerminalRecordingService
+	debugContainerConfig     *DebugContainerConfig
 }
 
 func NewTerminalSessionHandlerImpl(environmentService cluster.EnvironmentService, clusterService cluster.ClusterService,
-	logger *zap.SugaredLogger) *TerminalSessionHandlerImpl {
-	return &TerminalSessionHandlerImpl{
-		environmentService: environmentService,
-		clusterService:     clusterService,
-		logger:             logger,
+	logger *zap.SugaredLogger, terminalRecordingService TerminalRecordingService) (*TerminalSessionHandlerImpl, error) {
+	debugContainerConfig := &DebugContainerConfig{}
+	err := env.Parse(debugContainerConfig)
+	if err != nil {
+		logger.Errorw("error in parsing debug container config", "err", err)
+		return nil, err
 	}
+	return &TerminalSessionHandlerImpl{
+		environmentService:       environmentService,
+		clusterService:           clusterService,
+		logger:                   logger,
+		terminalRecordingService: terminalRecordingService,
+		debugContainerConfig:     debugContainerConfig,
+	}, nil
 }
 func (impl *TerminalSessionHandlerImpl) GetTerminalSession(req *TerminalSessionRequest) (statusCode int, message *TerminalMessage, err error) {
 	sessionID, err := genTerminalSessionId()
@@ -352,17 +399,33 @@ func (impl *TerminalSessionHandlerImpl) GetTerminalSession(req *TerminalSessionR
 		return statusCode, nil, err
 	}
 	req.SessionId = sessionID
-	terminalSessions.Set(sessionID, TerminalSession{
-		id:       sessionID,
-		bound:    make(chan error),
-		sizeChan: make(chan remotecommand.TerminalSize),
-	})
+	if req.Debug {
+		err = impl.setDebugImage(req)
+		if err != nil {
+			return http.StatusBadRequest, nil, err
+		}
+	}
 	config, client, err := impl.getClientConfig(req.EnvironmentId)
 	if err != nil {
 		impl.logger.Errorw("error in fetching config", "err", err)
 		return http.StatusInternalServerError, nil, err
 	}
-	go WaitForTerminal(client, config, req)
+	// a session which has to be recorded is refused if its recording can not be started
+	recorder, err := impl.terminalRecordingService.StartSession(req)
+	if err != nil {
+		impl.logger.Errorw("error in starting terminal session recording", "sessionId", sessionID, "err", err)
+		return http.StatusInternalServerError, nil, err
+	}
+	terminalSessions.Set(sessionID, TerminalSession{
+		id:       sessionID,
+		bound:    make(chan error),
+		sizeChan: make(chan remotecommand.TerminalSize),
+		recorder: recorder,
+	})
+	go func() {
+		WaitForTerminal(client, config, req)
+		impl.terminalRecordingService.EndSession(recorder)
+	}()
 	return http.StatusOK, &TerminalMessage{SessionID: sessionID}, nil
 }
 
@@ -377,10 +440,7 @@ func (impl *TerminalSessionHandlerImpl) getClientConfig(envId int) (*rest.Config
 		impl.logger.Errorw("error in config", "err", err)
 		return nil, nil, err
 	}
-	cfg := &rest.Config{}
-	cfg.Host = config.Host
-	cfg.BearerToken = config.BearerToken
-	cfg.Insecure = true
+	cfg := config.RestConfig()
 	clientSet, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
 		impl.logger.Errorw("error in clientSet", "err", err)
@@ -388,3 +448,17 @@ func (impl *TerminalSessionHandlerImpl) getClientConfig(envId int) (*rest.Config
 	}
 	return cfg, clientSet, nil
 }
+
+func (impl *TerminalSessionHandlerImpl) setDebugImage(req *TerminalSessionRequest) error {
+	req.debugStartTimeout = time.Duration(impl.debugContainerConfig.StartTimeoutSeconds) * time.Second
+	if req.DebugImage == "" || req.DebugImage == impl.debugContainerConfig.DefaultImage {
+		req.DebugImage = impl.debugContainerConfig.DefaultImage
+		return nil
+	}
+	for _, image := range impl.debugContainerConfig.AllowedImages {
+		if req.DebugImage == strings.TrimSpace(image) {
+			return nil
+		}
+	}
+	return fmt.Errorf("debug image %s is not allowed", req.DebugImage)
+}
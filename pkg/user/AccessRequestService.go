@@ -0,0 +1,577 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/caarlos0/env"
+	"github.com/devtron-labs/devtron/api/bean"
+	client "github.com/devtron-labs/devtron/client/events"
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
+	"github.com/devtron-labs/devtron/pkg/user/casbin"
+	repository2 "github.com/devtron-labs/devtron/pkg/user/repository"
+	util2 "github.com/devtron-labs/devtron/util/event"
+	"github.com/go-pg/pg"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const accessRequestExpiryCronExpr = "@every 1m"
+
+type AccessRequestConfig struct {
+	// MaxDurationMinutes caps the duration an access request can be granted for
+	MaxDurationMinutes int `env:"ACCESS_REQUEST_MAX_DURATION_MINUTES" envDefault:"480"`
+}
+
+// AccessRequestService grants role filters to users for a limited time. Granted roles are only added as casbin
+// policies, no user role mapping is created, so that expiry takes them back without touching permanent access
+type AccessRequestService interface {
+	CreateAccessRequest(request *bean.AccessRequest) (*bean.AccessRequest, error)
+	ApproveAccessRequest(action *bean.AccessRequestAction) (*bean.AccessRequest, error)
+	RejectAccessRequest(action *bean.AccessRequestAction) (*bean.AccessRequest, error)
+	// RevokeAccessRequest cancels a pending request or takes back the access of an approved one
+	RevokeAccessRequest(action *bean.AccessRequestAction) (*bean.AccessRequest, error)
+	GetAccessRequestById(id int) (*bean.AccessRequest, error)
+	GetAccessRequestsByUserId(userId int32) ([]*bean.AccessRequest, error)
+	GetPendingAccessRequests() ([]*bean.AccessRequest, error)
+	ExpireAccessRequests()
+	// SyncOrchestratorToCasbin expires overdue grants and re-adds the policies of the ones still active
+	SyncOrchestratorToCasbin() (bool, error)
+}
+
+type AccessRequestServiceImpl struct {
+	logger                  *zap.SugaredLogger
+	cron                    *cron.Cron
+	config                  *AccessRequestConfig
+	accessRequestRepository repository2.AccessRequestRepository
+	userAuthRepository      repository2.UserAuthRepository
+	userRepository          repository2.UserRepository
+	customRoleService       CustomRoleService
+	eventFactory            client.EventFactory
+	eventClient             client.EventClient
+	auditLogService         auditLog.AuditLogService
+}
+
+func NewAccessRequestServiceImpl(logger *zap.SugaredLogger, accessRequestRepository repository2.AccessRequestRepository,
+	userAuthRepository repository2.UserAuthRepository, userRepository repository2.UserRepository,
+	customRoleService CustomRoleService, eventFactory client.EventFactory, eventClient client.EventClient,
+	auditLogService auditLog.AuditLogService) (*AccessRequestServiceImpl, error) {
+	config := &AccessRequestConfig{}
+	err := env.Parse(config)
+	if err != nil {
+		logger.Errorw("error in parsing access request config", "err", err)
+		return nil, err
+	}
+	cron := cron.New(
+		cron.WithChain())
+	cron.Start()
+	impl := &AccessRequestServiceImpl{
+		logger:                  logger,
+		cron:                    cron,
+		config:                  config,
+		accessRequestRepository: accessRequestRepository,
+		userAuthRepository:      userAuthRepository,
+		userRepository:          userRepository,
+		customRoleService:       customRoleService,
+		eventFactory:            eventFactory,
+		eventClient:             eventClient,
+		auditLogService:         auditLogService,
+	}
+	_, err = cron.AddFunc(accessRequestExpiryCronExpr, impl.ExpireAccessRequests)
+	if err != nil {
+		logger.Errorw("error in starting access request expiry cron", "err", err)
+		return nil, err
+	}
+	return impl, nil
+}
+
+func (impl AccessRequestServiceImpl) CreateAccessRequest(request *bean.AccessRequest) (*bean.AccessRequest, error) {
+	err := impl.validateAccessRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	roleFilter, err := json.Marshal(request.RoleFilter)
+	if err != nil {
+		return nil, err
+	}
+	model := &repository2.AccessRequest{
+		UserId:          request.UserId,
+		RoleFilter:      string(roleFilter),
+		Reason:          request.Reason,
+		DurationMinutes: request.DurationMinutes,
+		Status:          repository2.AccessRequestPending,
+	}
+	model.CreatedBy = request.UserId
+	model.CreatedOn = time.Now()
+	model.UpdatedBy = request.UserId
+	model.UpdatedOn = time.Now()
+	err = impl.accessRequestRepository.Save(model)
+	if err != nil {
+		impl.logger.Errorw("error in saving access request", "userId", request.UserId, "err", err)
+		return nil, err
+	}
+	return impl.afterStatusChange(model, nil, request.UserId, auditLog.ActionCreate, util2.AccessRequested)
+}
+
+// ApproveAccessRequest grants the roles of the role filter, creating them like user updates do when missing.
+// Access expires DurationMinutes after approval
+func (impl AccessRequestServiceImpl) ApproveAccessRequest(action *bean.AccessRequestAction) (*bean.AccessRequest, error) {
+	model, err := impl.findAccessRequest(action.Id)
+	if err != nil {
+		return nil, err
+	}
+	if model.Status != repository2.AccessRequestPending {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: fmt.Sprintf("access request is %s, only pending requests can be approved", strings.ToLower(model.Status))}
+	}
+	if model.UserId == action.UserId {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusForbidden, UserMessage: "access request can not be approved by the requesting user"}
+	}
+	before, err := impl.adaptAccessRequest(model)
+	if err != nil {
+		return nil, err
+	}
+	user, err := impl.userRepository.GetByIdIncludeDeleted(model.UserId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching requesting user", "userId", model.UserId, "err", err)
+		return nil, err
+	}
+	if !user.Active {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "requesting user is no longer active"}
+	}
+	roles, err := impl.resolveRoles(before.RoleFilter)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "no role found for the requested role filter"}
+	}
+	grantedRoles, err := json.Marshal(roles)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	model.Status = repository2.AccessRequestApproved
+	model.ApproverId = action.UserId
+	model.Comment = action.Comment
+	model.GrantedRoles = string(grantedRoles)
+	model.ApprovedOn = now
+	model.ExpiresOn = now.Add(time.Duration(model.DurationMinutes) * time.Minute)
+	model.UpdatedBy = action.UserId
+	model.UpdatedOn = now
+	err = impl.accessRequestRepository.Update(model)
+	if err != nil {
+		impl.logger.Errorw("error in approving access request", "id", model.Id, "err", err)
+		return nil, err
+	}
+	casbin.AddPolicy(grantPolicies(user.EmailId, roles))
+	return impl.afterStatusChange(model, before, action.UserId, auditLog.ActionUpdate, util2.AccessApproved)
+}
+
+func (impl AccessRequestServiceImpl) RejectAccessRequest(action *bean.AccessRequestAction) (*bean.AccessRequest, error) {
+	model, err := impl.findAccessRequest(action.Id)
+	if err != nil {
+		return nil, err
+	}
+	if model.Status != repository2.AccessRequestPending {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: fmt.Sprintf("access request is %s, only pending requests can be rejected", strings.ToLower(model.Status))}
+	}
+	before, err := impl.adaptAccessRequest(model)
+	if err != nil {
+		return nil, err
+	}
+	model.Status = repository2.AccessRequestRejected
+	model.ApproverId = action.UserId
+	model.Comment = action.Comment
+	model.UpdatedBy = action.UserId
+	model.UpdatedOn = time.Now()
+	err = impl.accessRequestRepository.Update(model)
+	if err != nil {
+		impl.logger.Errorw("error in rejecting access request", "id", model.Id, "err", err)
+		return nil, err
+	}
+	return impl.afterStatusChange(model, before, action.UserId, auditLog.ActionUpdate, util2.AccessRejected)
+}
+
+func (impl AccessRequestServiceImpl) RevokeAccessRequest(action *bean.AccessRequestAction) (*bean.AccessRequest, error) {
+	model, err := impl.findAccessRequest(action.Id)
+	if err != nil {
+		return nil, err
+	}
+	before, err := impl.adaptAccessRequest(model)
+	if err != nil {
+		return nil, err
+	}
+	switch model.Status {
+	case repository2.AccessRequestPending:
+		model.Status = repository2.AccessRequestCancelled
+	case repository2.AccessRequestApproved:
+		err = impl.removeGrant(model)
+		if err != nil {
+			return nil, err
+		}
+		model.Status = repository2.AccessRequestRevoked
+	default:
+		return nil, &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: fmt.Sprintf("access request is already %s", strings.ToLower(model.Status))}
+	}
+	if len(action.Comment) > 0 {
+		model.Comment = action.Comment
+	}
+	model.UpdatedBy = action.UserId
+	model.UpdatedOn = time.Now()
+	err = impl.accessRequestRepository.Update(model)
+	if err != nil {
+		impl.logger.Errorw("error in revoking access request", "id", model.Id, "err", err)
+		return nil, err
+	}
+	return impl.afterStatusChange(model, before, action.UserId, auditLog.ActionUpdate, util2.AccessRevoked)
+}
+
+func (impl AccessRequestServiceImpl) GetAccessRequestById(id int) (*bean.AccessRequest, error) {
+	model, err := impl.findAccessRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	return impl.adaptAccessRequest(model)
+}
+
+func (impl AccessRequestServiceImpl) GetAccessRequestsByUserId(userId int32) ([]*bean.AccessRequest, error) {
+	models, err := impl.accessRequestRepository.FindByUserId(userId)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching access requests", "userId", userId, "err", err)
+		return nil, err
+	}
+	return impl.adaptAccessRequests(models)
+}
+
+func (impl AccessRequestServiceImpl) GetPendingAccessRequests() ([]*bean.AccessRequest, error) {
+	models, err := impl.accessRequestRepository.FindByStatus(repository2.AccessRequestPending)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching pending access requests", "err", err)
+		return nil, err
+	}
+	return impl.adaptAccessRequests(models)
+}
+
+// ExpireAccessRequests takes back the access of approved requests past their expiry, errors are only logged
+// and retried on the next run
+func (impl AccessRequestServiceImpl) ExpireAccessRequests() {
+	models, err := impl.accessRequestRepository.FindApprovedExpiringBefore(time.Now())
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching expired access requests", "err", err)
+		return
+	}
+	for _, model := range models {
+		before, err := impl.adaptAccessRequest(model)
+		if err != nil {
+			impl.logger.Errorw("error in reading access request", "id", model.Id, "err", err)
+			continue
+		}
+		err = impl.removeGrant(model)
+		if err != nil {
+			impl.logger.Errorw("error in removing access of expired request", "id", model.Id, "err", err)
+			continue
+		}
+		model.Status = repository2.AccessRequestExpired
+		model.UpdatedOn = time.Now()
+		err = impl.accessRequestRepository.Update(model)
+		if err != nil {
+			impl.logger.Errorw("error in expiring access request", "id", model.Id, "err", err)
+			continue
+		}
+		_, err = impl.afterStatusChange(model, before, 0, auditLog.ActionUpdate, util2.AccessExpired)
+		if err != nil {
+			impl.logger.Errorw("error in notifying access request expiry", "id", model.Id, "err", err)
+		}
+	}
+}
+
+func (impl AccessRequestServiceImpl) SyncOrchestratorToCasbin() (bool, error) {
+	impl.ExpireAccessRequests()
+	models, err := impl.accessRequestRepository.FindByStatus(repository2.AccessRequestApproved)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching approved access requests", "err", err)
+		return false, err
+	}
+	var policies []casbin.Policy
+	for _, model := range models {
+		user, err := impl.userRepository.GetByIdIncludeDeleted(model.UserId)
+		if err != nil {
+			impl.logger.Errorw("error in fetching requesting user", "userId", model.UserId, "err", err)
+			return false, err
+		}
+		var roles []string
+		err = json.Unmarshal([]byte(model.GrantedRoles), &roles)
+		if err != nil {
+			return false, err
+		}
+		policies = append(policies, grantPolicies(user.EmailId, roles)...)
+	}
+	if len(policies) > 0 {
+		casbin.AddPolicy(policies)
+	}
+	return true, nil
+}
+
+// resolveRoles returns the casbin roles of a role filter, comma separated apps and environments expand to one
+// role each. Missing roles are created along with their default policies
+func (impl AccessRequestServiceImpl) resolveRoles(roleFilter bean.RoleFilter) ([]string, error) {
+	if roleFilter.EntityName == "" {
+		roleFilter.EntityName = "NONE"
+	}
+	if roleFilter.Environment == "" {
+		roleFilter.Environment = "NONE"
+	}
+	var roles []string
+	for _, environment := range strings.Split(roleFilter.Environment, ",") {
+		for _, entityName := range strings.Split(roleFilter.EntityName, ",") {
+			if entityName == "NONE" {
+				entityName = ""
+			}
+			if environment == "NONE" {
+				environment = ""
+			}
+			roleModel, err := impl.userAuthRepository.GetRoleByFilter(roleFilter.Entity, roleFilter.Team, entityName, environment, roleFilter.Action)
+			if err != nil {
+				impl.logger.Errorw("error in fetching role by filter", "filter", roleFilter, "err", err)
+				return nil, err
+			}
+			if roleModel.Id == 0 {
+				if len(roleFilter.Team) > 0 {
+					_, err = impl.userAuthRepository.CreateDefaultPolicies(roleFilter.Team, entityName, environment, nil)
+					if err != nil {
+						return nil, err
+					}
+					_, err = impl.customRoleService.CreatePoliciesForFilter(roleFilter.Action, roleFilter.Team, entityName, environment)
+					if err != nil {
+						return nil, err
+					}
+				} else {
+					_, err = impl.userAuthRepository.CreateDefaultPoliciesForGlobalEntity(roleFilter.Entity, entityName, roleFilter.Action, nil)
+					if err != nil {
+						return nil, err
+					}
+				}
+				roleModel, err = impl.userAuthRepository.GetRoleByFilter(roleFilter.Entity, roleFilter.Team, entityName, environment, roleFilter.Action)
+				if err != nil {
+					impl.logger.Errorw("error in fetching role by filter", "filter", roleFilter, "err", err)
+					return nil, err
+				}
+				if roleModel.Id == 0 {
+					impl.logger.Debugw("no role found for given filter", "filter", roleFilter)
+					continue
+				}
+			}
+			roles = append(roles, roleModel.Role)
+		}
+	}
+	return roles, nil
+}
+
+// removeGrant removes the casbin policies of the roles granted by a request, except roles the user still holds
+// permanently or through another approved request
+func (impl AccessRequestServiceImpl) removeGrant(model *repository2.AccessRequest) error {
+	user, err := impl.userRepository.GetByIdIncludeDeleted(model.UserId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching requesting user", "userId", model.UserId, "err", err)
+		return err
+	}
+	var roles []string
+	err = json.Unmarshal([]byte(model.GrantedRoles), &roles)
+	if err != nil {
+		return err
+	}
+	retained := make(map[string]bool)
+	userRoles, err := impl.userAuthRepository.GetRolesByUserId(model.UserId)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching roles of user", "userId", model.UserId, "err", err)
+		return err
+	}
+	for _, role := range userRoles {
+		retained[strings.ToLower(role.Role)] = true
+	}
+	approved, err := impl.accessRequestRepository.FindApprovedByUserId(model.UserId)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching approved access requests", "userId", model.UserId, "err", err)
+		return err
+	}
+	for _, other := range approved {
+		if other.Id == model.Id || !other.ExpiresOn.After(time.Now()) {
+			continue
+		}
+		var otherRoles []string
+		err = json.Unmarshal([]byte(other.GrantedRoles), &otherRoles)
+		if err != nil {
+			return err
+		}
+		for _, role := range otherRoles {
+			retained[strings.ToLower(role)] = true
+		}
+	}
+	var removed []string
+	for _, role := range roles {
+		if !retained[strings.ToLower(role)] {
+			removed = append(removed, role)
+		}
+	}
+	if len(removed) > 0 {
+		casbin.RemovePolicy(grantPolicies(user.EmailId, removed))
+	}
+	return nil
+}
+
+func (impl AccessRequestServiceImpl) validateAccessRequest(request *bean.AccessRequest) error {
+	roleFilter := request.RoleFilter
+	if len(roleFilter.Action) == 0 || (len(roleFilter.Team) == 0 && len(roleFilter.Entity) == 0) {
+		return &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "role filter needs an action and a team or entity"}
+	}
+	if roleFilter.Action == "super-admin" {
+		return &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "super admin access can not be requested"}
+	}
+	if request.DurationMinutes <= 0 || request.DurationMinutes > impl.config.MaxDurationMinutes {
+		return &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: fmt.Sprintf("duration must be between 1 and %d minutes", impl.config.MaxDurationMinutes)}
+	}
+	if len(strings.TrimSpace(request.Reason)) == 0 {
+		return &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "reason is required"}
+	}
+	return nil
+}
+
+func (impl AccessRequestServiceImpl) findAccessRequest(id int) (*repository2.AccessRequest, error) {
+	model, err := impl.accessRequestRepository.FindById(id)
+	if err == pg.ErrNoRows {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusNotFound, UserMessage: fmt.Sprintf("access request %d not found", id)}
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching access request", "id", id, "err", err)
+		return nil, err
+	}
+	return model, nil
+}
+
+// afterStatusChange audits and notifies a change of an access request, a failed notification does not fail it
+func (impl AccessRequestServiceImpl) afterStatusChange(model *repository2.AccessRequest, before *bean.AccessRequest, userId int32, action string, eventType util2.EventType) (*bean.AccessRequest, error) {
+	after, err := impl.adaptAccessRequest(model)
+	if err != nil {
+		return nil, err
+	}
+	impl.auditLogService.SaveEvent(&auditLog.AuditEvent{
+		UserId:       userId,
+		ResourceType: auditLog.ResourceAccessRequest,
+		ResourceId:   strconv.Itoa(model.Id),
+		Action:       action,
+		Before:       before,
+		After:        after,
+	})
+	event := impl.eventFactory.Build(eventType, nil, 0, nil, util2.AccessRequest)
+	event.UserId = int(userId)
+	event.Payload = &client.Payload{AccessRequest: &client.AccessRequestInfo{
+		Id:              after.Id,
+		RequestedBy:     after.RequestedBy,
+		ApprovedBy:      after.ApprovedBy,
+		Action:          after.RoleFilter.Action,
+		Scope:           accessRequestScope(after.RoleFilter),
+		Reason:          after.Reason,
+		Comment:         after.Comment,
+		DurationMinutes: after.DurationMinutes,
+		Status:          strings.ToLower(after.Status),
+	}}
+	if after.ExpiresOn != nil {
+		event.Payload.AccessRequest.ExpiresOn = after.ExpiresOn.Format(time.RFC1123)
+	}
+	_, evtErr := impl.eventClient.WriteEvent(event)
+	if evtErr != nil {
+		impl.logger.Errorw("error in writing access request event", "id", model.Id, "err", evtErr)
+	}
+	return after, nil
+}
+
+func (impl AccessRequestServiceImpl) adaptAccessRequests(models []*repository2.AccessRequest) ([]*bean.AccessRequest, error) {
+	requests := make([]*bean.AccessRequest, 0, len(models))
+	for _, model := range models {
+		request, err := impl.adaptAccessRequest(model)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+	return requests, nil
+}
+
+func (impl AccessRequestServiceImpl) adaptAccessRequest(model *repository2.AccessRequest) (*bean.AccessRequest, error) {
+	request := &bean.AccessRequest{
+		Id:              model.Id,
+		Reason:          model.Reason,
+		DurationMinutes: model.DurationMinutes,
+		Status:          model.Status,
+		Comment:         model.Comment,
+		CreatedOn:       model.CreatedOn,
+		UserId:          model.UserId,
+	}
+	err := json.Unmarshal([]byte(model.RoleFilter), &request.RoleFilter)
+	if err != nil {
+		return nil, err
+	}
+	request.RequestedBy = impl.emailOf(model.UserId)
+	if model.ApproverId > 0 {
+		request.ApprovedBy = impl.emailOf(model.ApproverId)
+	}
+	if !model.ApprovedOn.IsZero() {
+		approvedOn := model.ApprovedOn
+		request.ApprovedOn = &approvedOn
+	}
+	if !model.ExpiresOn.IsZero() {
+		expiresOn := model.ExpiresOn
+		request.ExpiresOn = &expiresOn
+	}
+	return request, nil
+}
+
+func (impl AccessRequestServiceImpl) emailOf(userId int32) string {
+	user, err := impl.userRepository.GetByIdIncludeDeleted(userId)
+	if err != nil {
+		impl.logger.Warnw("error in fetching user of access request", "userId", userId, "err", err)
+		return ""
+	}
+	return user.EmailId
+}
+
+func grantPolicies(emailId string, roles []string) []casbin.Policy {
+	var policies []casbin.Policy
+	for _, role := range roles {
+		policies = append(policies, casbin.Policy{Type: "g", Sub: casbin.Subject(emailId), Obj: casbin.Object(role)})
+	}
+	return policies
+}
+
+func accessRequestScope(roleFilter bean.RoleFilter) string {
+	var scope []string
+	for _, part := range []string{roleFilter.Entity, roleFilter.Team, roleFilter.Environment, roleFilter.EntityName} {
+		if len(part) > 0 {
+			scope = append(scope, part)
+		}
+	}
+	if len(scope) == 0 {
+		return "all"
+	}
+	return strings.Join(scope, "/")
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/devtron-labs/devtron/api/bean"
+	"github.com/devtron-labs/devtron/pkg/user/casbin"
+)
+
+func TestValidateAccessRequest(t *testing.T) {
+	impl := AccessRequestServiceImpl{config: &AccessRequestConfig{MaxDurationMinutes: 60}}
+	prodAdmin := bean.RoleFilter{Team: "payments", Environment: "prod", Action: "admin"}
+	tests := []struct {
+		name    string
+		request *bean.AccessRequest
+		wantErr bool
+	}{
+		{"valid", &bean.AccessRequest{RoleFilter: prodAdmin, Reason: "incident", DurationMinutes: 30}, false},
+		{"no team or entity", &bean.AccessRequest{RoleFilter: bean.RoleFilter{Action: "admin"}, Reason: "incident", DurationMinutes: 30}, true},
+		{"super admin", &bean.AccessRequest{RoleFilter: bean.RoleFilter{Entity: "chart-group", Action: "super-admin"}, Reason: "incident", DurationMinutes: 30}, true},
+		{"too long", &bean.AccessRequest{RoleFilter: prodAdmin, Reason: "incident", DurationMinutes: 61}, true},
+		{"blank reason", &bean.AccessRequest{RoleFilter: prodAdmin, Reason: " ", DurationMinutes: 30}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := impl.validateAccessRequest(tt.request); (err != nil) != tt.wantErr {
+				t.Errorf("validateAccessRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGrantPolicies(t *testing.T) {
+	got := grantPolicies("oncall@example.com", []string{"role:admin_payments_prod_", "role:admin_payments_prod_api"})
+	want := []casbin.Policy{
+		{Type: "g", Sub: "oncall@example.com", Obj: "role:admin_payments_prod_"},
+		{Type: "g", Sub: "oncall@example.com", Obj: "role:admin_payments_prod_api"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("grantPolicies() = %v, want %v", got, want)
+	}
+	if scope := accessRequestScope(bean.RoleFilter{Team: "payments", Environment: "prod", Action: "admin"}); scope != "payments/prod" {
+		t.Errorf("accessRequestScope() = %v, want payments/prod", scope)
+	}
+}
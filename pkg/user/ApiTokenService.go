@@ -0,0 +1,233 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/devtron-labs/authenticator/middleware"
+	"github.com/devtron-labs/devtron/api/bean"
+	"github.com/devtron-labs/devtron/internal/util"
+	repository2 "github.com/devtron-labs/devtron/pkg/user/repository"
+	"github.com/go-pg/pg"
+	"github.com/satori/go.uuid"
+	"go.uber.org/zap"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var apiTokenNameRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9_-]{0,48}[a-z0-9])?$`)
+
+type ApiTokenService interface {
+	CreateApiToken(request *bean.ApiTokenRequest) (*bean.ApiToken, error)
+	GetAllActiveApiTokens() ([]*bean.ApiToken, error)
+	GetActiveApiTokenById(id int) (*bean.ApiToken, error)
+	RevokeApiToken(id int, userId int32) error
+}
+
+type ApiTokenServiceImpl struct {
+	logger             *zap.SugaredLogger
+	userService        UserService
+	userRepository     repository2.UserRepository
+	apiTokenRepository repository2.ApiTokenRepository
+	sessionManager2    *middleware.SessionManager
+}
+
+func NewApiTokenServiceImpl(logger *zap.SugaredLogger, userService UserService, userRepository repository2.UserRepository,
+	apiTokenRepository repository2.ApiTokenRepository, sessionManager2 *middleware.SessionManager) *ApiTokenServiceImpl {
+	return &ApiTokenServiceImpl{
+		logger:             logger,
+		userService:        userService,
+		userRepository:     userRepository,
+		apiTokenRepository: apiTokenRepository,
+		sessionManager2:    sessionManager2,
+	}
+}
+
+func hashApiToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func IsApiTokenUser(emailId string) bool {
+	return strings.HasPrefix(emailId, repository2.ApiTokenUserPrefix)
+}
+
+func (impl ApiTokenServiceImpl) CreateApiToken(request *bean.ApiTokenRequest) (*bean.ApiToken, error) {
+	request.Name = strings.ToLower(strings.TrimSpace(request.Name))
+	if !apiTokenNameRegex.MatchString(request.Name) {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "invalid token name, only lowercase letters, digits, - and _ are allowed, max 50 characters"}
+	}
+	if !request.ExpiresOn.After(time.Now()) {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "expiresOn must be in future"}
+	}
+	if len(request.RoleFilters) == 0 {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "Invalid request, please provide role filters"}
+	}
+	existing, err := impl.apiTokenRepository.FindActiveByName(request.Name)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching api token", "name", request.Name, "err", err)
+		return nil, err
+	}
+	if existing != nil && existing.Id > 0 {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusConflict, UserMessage: fmt.Sprintf("api token with name %s already exists", request.Name)}
+	}
+
+	emailId := repository2.ApiTokenUserPrefix + request.Name
+	userInfo := &bean.UserInfo{
+		EmailId:     emailId,
+		RoleFilters: request.RoleFilters,
+		Groups:      make([]string, 0),
+		UserId:      request.UserId,
+	}
+	_, err = impl.userService.CreateUser(userInfo)
+	if err != nil {
+		impl.logger.Errorw("error in creating user for api token", "name", request.Name, "err", err)
+		return nil, err
+	}
+	tokenUser, err := impl.userRepository.FetchActiveUserByEmail(emailId)
+	if err != nil || tokenUser.Id == 0 {
+		impl.logger.Errorw("error in fetching user of api token", "name", request.Name, "err", err)
+		return nil, fmt.Errorf("user not found for api token %s", request.Name)
+	}
+
+	secondsBeforeExpiry := int64(time.Until(request.ExpiresOn).Seconds())
+	token, err := impl.sessionManager2.Create(emailId, secondsBeforeExpiry, uuid.NewV4().String())
+	if err != nil {
+		impl.logger.Errorw("error in signing api token", "name", request.Name, "err", err)
+		impl.deleteTokenUser(tokenUser.Id, request.UserId)
+		return nil, err
+	}
+	model := &repository2.ApiToken{
+		UserId:      tokenUser.Id,
+		Name:        request.Name,
+		Description: request.Description,
+		TokenHash:   hashApiToken(token),
+		ExpiresOn:   request.ExpiresOn,
+		Active:      true,
+	}
+	model.CreatedBy = request.UserId
+	model.CreatedOn = time.Now()
+	model.UpdatedBy = request.UserId
+	model.UpdatedOn = time.Now()
+	err = impl.apiTokenRepository.Save(model)
+	if err != nil {
+		impl.logger.Errorw("error in saving api token", "name", request.Name, "err", err)
+		impl.deleteTokenUser(tokenUser.Id, request.UserId)
+		return nil, err
+	}
+
+	apiToken, err := impl.buildApiToken(model)
+	if err != nil {
+		return nil, err
+	}
+	apiToken.Token = token
+	return apiToken, nil
+}
+
+func (impl ApiTokenServiceImpl) deleteTokenUser(tokenUserId int32, userId int32) {
+	_, err := impl.userService.DeleteUser(&bean.UserInfo{Id: tokenUserId, UserId: userId})
+	if err != nil {
+		impl.logger.Errorw("error in deleting user of api token", "tokenUserId", tokenUserId, "err", err)
+	}
+}
+
+func (impl ApiTokenServiceImpl) GetAllActiveApiTokens() ([]*bean.ApiToken, error) {
+	models, err := impl.apiTokenRepository.FindAllActive()
+	if err != nil {
+		impl.logger.Errorw("error in fetching api tokens", "err", err)
+		return nil, err
+	}
+	apiTokens := make([]*bean.ApiToken, 0)
+	for _, model := range models {
+		apiToken, err := impl.buildApiToken(model)
+		if err != nil {
+			return nil, err
+		}
+		apiTokens = append(apiTokens, apiToken)
+	}
+	return apiTokens, nil
+}
+
+func (impl ApiTokenServiceImpl) GetActiveApiTokenById(id int) (*bean.ApiToken, error) {
+	model, err := impl.apiTokenRepository.FindActiveById(id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching api token", "id", id, "err", err)
+		return nil, err
+	}
+	return impl.buildApiToken(model)
+}
+
+// RevokeApiToken deactivates the token and deletes its user, which also removes its casbin roles
+func (impl ApiTokenServiceImpl) RevokeApiToken(id int, userId int32) error {
+	model, err := impl.apiTokenRepository.FindActiveById(id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching api token", "id", id, "err", err)
+		return err
+	}
+	model.Active = false
+	model.UpdatedBy = userId
+	model.UpdatedOn = time.Now()
+	err = impl.apiTokenRepository.Update(model)
+	if err != nil {
+		impl.logger.Errorw("error in revoking api token", "id", id, "err", err)
+		return err
+	}
+	_, err = impl.userService.DeleteUser(&bean.UserInfo{Id: model.UserId, UserId: userId})
+	if err != nil {
+		impl.logger.Errorw("error in deleting user of api token", "id", id, "err", err)
+		return err
+	}
+	return nil
+}
+
+func (impl ApiTokenServiceImpl) buildApiToken(model *repository2.ApiToken) (*bean.ApiToken, error) {
+	apiToken := &bean.ApiToken{
+		Id:          model.Id,
+		UserId:      model.UserId,
+		Name:        model.Name,
+		Description: model.Description,
+		ExpiresOn:   model.ExpiresOn,
+		CreatedOn:   model.CreatedOn,
+		RoleFilters: make([]bean.RoleFilter, 0),
+	}
+	if !model.LastUsedOn.IsZero() {
+		lastUsedOn := model.LastUsedOn
+		apiToken.LastUsedOn = &lastUsedOn
+	}
+	owner, err := impl.userService.GetByIdIncludeDeleted(model.CreatedBy)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching owner of api token", "id", model.Id, "err", err)
+		return nil, err
+	}
+	if owner != nil {
+		apiToken.Owner = owner.EmailId
+	}
+	tokenUser, err := impl.userService.GetById(model.UserId)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching roles of api token", "id", model.Id, "err", err)
+		return nil, err
+	}
+	if tokenUser != nil && tokenUser.RoleFilters != nil {
+		apiToken.RoleFilters = tokenUser.RoleFilters
+	}
+	return apiToken, nil
+}
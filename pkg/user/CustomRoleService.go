@@ -0,0 +1,399 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/devtron-labs/devtron/api/bean"
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
+	"github.com/devtron-labs/devtron/pkg/user/casbin"
+	repository2 "github.com/devtron-labs/devtron/pkg/user/repository"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CustomRoleService interface {
+	CreateCustomRole(request *bean.CustomRole) (*bean.CustomRole, error)
+	UpdateCustomRole(request *bean.CustomRole) (*bean.CustomRole, error)
+	DeleteCustomRole(id int, userId int32) error
+	GetAllCustomRoles() ([]*bean.CustomRole, error)
+	GetCustomRoleById(id int) (*bean.CustomRole, error)
+	// CreatePoliciesForFilter creates the role and casbin policies of a custom role for a team/env/app,
+	// it returns false when action is not the name of a custom role
+	CreatePoliciesForFilter(action string, team string, entityName string, env string) (bool, error)
+	SyncOrchestratorToCasbin() (bool, error)
+}
+
+type CustomRoleServiceImpl struct {
+	logger               *zap.SugaredLogger
+	customRoleRepository repository2.CustomRoleRepository
+	userAuthRepository   repository2.UserAuthRepository
+	auditLogService      auditLog.AuditLogService
+}
+
+func NewCustomRoleServiceImpl(logger *zap.SugaredLogger, customRoleRepository repository2.CustomRoleRepository,
+	userAuthRepository repository2.UserAuthRepository, auditLogService auditLog.AuditLogService) *CustomRoleServiceImpl {
+	return &CustomRoleServiceImpl{
+		logger:               logger,
+		customRoleRepository: customRoleRepository,
+		userAuthRepository:   userAuthRepository,
+		auditLogService:      auditLogService,
+	}
+}
+
+var customRoleNameRegex = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// built-in role filter actions, custom roles can not shadow them
+var reservedRoleActions = map[string]bool{
+	"manager": true, "admin": true, "trigger": true, "view": true, "super-admin": true,
+	bean.RoleActionSecretView: true, bean.RoleActionTemplateEdit: true, bean.RoleActionOverrideEdit: true,
+}
+
+// casbin object of each resource a custom role may grant, same as in the default team/env/app policies
+var customRoleObjects = map[string]string{
+	casbin.ResourceApplications:       "<TEAM_OBJ>/<APP_OBJ>",
+	casbin.ResourceSecret:             "<TEAM_OBJ>/<APP_OBJ>",
+	casbin.ResourceDeploymentTemplate: "<TEAM_OBJ>/<APP_OBJ>",
+	casbin.ResourceEnvironment:        "<ENV_OBJ>/<APP_OBJ>",
+	casbin.ResourceEnvOverride:        "<ENV_OBJ>/<APP_OBJ>",
+	casbin.ResourceGlobalEnvironment:  "<ENV_OBJ>",
+	casbin.ResourceTeam:               "<TEAM_OBJ>",
+}
+
+var customRoleActions = map[string]bool{
+	casbin.ActionGet: true, casbin.ActionCreate: true, casbin.ActionUpdate: true, casbin.ActionDelete: true,
+	casbin.ActionTrigger: true, casbin.ActionRestart: true, casbin.ActionHibernate: true, casbin.ActionDebug: true, "*": true,
+}
+
+func (impl CustomRoleServiceImpl) CreateCustomRole(request *bean.CustomRole) (*bean.CustomRole, error) {
+	err := validateCustomRole(request)
+	if err != nil {
+		return nil, err
+	}
+	_, err = impl.customRoleRepository.FindActiveByName(request.Name)
+	if err == nil {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: fmt.Sprintf("custom role %s already exists", request.Name)}
+	} else if err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching custom role", "name", request.Name, "err", err)
+		return nil, err
+	}
+	permissions, err := json.Marshal(request.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	model := &repository2.CustomRole{
+		Name:        request.Name,
+		Description: request.Description,
+		Permissions: string(permissions),
+		Active:      true,
+	}
+	model.CreatedBy = request.UserId
+	model.CreatedOn = time.Now()
+	model.UpdatedBy = request.UserId
+	model.UpdatedOn = time.Now()
+	err = impl.customRoleRepository.Save(model)
+	if err != nil {
+		impl.logger.Errorw("error in saving custom role", "name", request.Name, "err", err)
+		return nil, err
+	}
+	// roles left behind by a deleted custom role of the same name get their policies back
+	err = impl.syncPolicies(request.Name, nil, request.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	request.Id = model.Id
+	impl.saveAuditEvent(auditLog.ActionCreate, nil, request, request.UserId)
+	return request, nil
+}
+
+// UpdateCustomRole replaces the permissions of a custom role, policies of every team/env/app it is assigned for
+// are re-created. The name can not be changed as it is referenced by roles
+func (impl CustomRoleServiceImpl) UpdateCustomRole(request *bean.CustomRole) (*bean.CustomRole, error) {
+	err := validateCustomRole(request)
+	if err != nil {
+		return nil, err
+	}
+	model, err := impl.customRoleRepository.FindActiveById(request.Id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching custom role", "id", request.Id, "err", err)
+		return nil, err
+	}
+	if model.Name != request.Name {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "custom role name can not be changed"}
+	}
+	before, err := adaptCustomRole(model)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := json.Marshal(request.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	model.Description = request.Description
+	model.Permissions = string(permissions)
+	model.UpdatedBy = request.UserId
+	model.UpdatedOn = time.Now()
+	err = impl.customRoleRepository.Update(model)
+	if err != nil {
+		impl.logger.Errorw("error in updating custom role", "id", request.Id, "err", err)
+		return nil, err
+	}
+	err = impl.syncPolicies(model.Name, before.Permissions, request.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	impl.saveAuditEvent(auditLog.ActionUpdate, before, request, request.UserId)
+	return request, nil
+}
+
+// DeleteCustomRole is only allowed once no user or role group holds the custom role
+func (impl CustomRoleServiceImpl) DeleteCustomRole(id int, userId int32) error {
+	model, err := impl.customRoleRepository.FindActiveById(id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching custom role", "id", id, "err", err)
+		return err
+	}
+	assignments, err := impl.customRoleRepository.CountAssignments(model.Name)
+	if err != nil {
+		impl.logger.Errorw("error in counting custom role assignments", "name", model.Name, "err", err)
+		return err
+	}
+	if assignments > 0 {
+		return &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: fmt.Sprintf("custom role %s is assigned %d times, remove it from users and role groups first", model.Name, assignments)}
+	}
+	before, err := adaptCustomRole(model)
+	if err != nil {
+		return err
+	}
+	model.Active = false
+	model.UpdatedBy = userId
+	model.UpdatedOn = time.Now()
+	err = impl.customRoleRepository.Update(model)
+	if err != nil {
+		impl.logger.Errorw("error in deleting custom role", "id", id, "err", err)
+		return err
+	}
+	err = impl.syncPolicies(model.Name, before.Permissions, nil)
+	if err != nil {
+		return err
+	}
+	impl.saveAuditEvent(auditLog.ActionDelete, before, nil, userId)
+	return nil
+}
+
+func (impl CustomRoleServiceImpl) GetAllCustomRoles() ([]*bean.CustomRole, error) {
+	models, err := impl.customRoleRepository.FindAllActive()
+	if err != nil {
+		impl.logger.Errorw("error in fetching custom roles", "err", err)
+		return nil, err
+	}
+	customRoles := make([]*bean.CustomRole, 0, len(models))
+	for _, model := range models {
+		customRole, err := adaptCustomRole(model)
+		if err != nil {
+			return nil, err
+		}
+		customRoles = append(customRoles, customRole)
+	}
+	return customRoles, nil
+}
+
+func (impl CustomRoleServiceImpl) GetCustomRoleById(id int) (*bean.CustomRole, error) {
+	model, err := impl.customRoleRepository.FindActiveById(id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching custom role", "id", id, "err", err)
+		return nil, err
+	}
+	return adaptCustomRole(model)
+}
+
+func (impl CustomRoleServiceImpl) CreatePoliciesForFilter(action string, team string, entityName string, env string) (bool, error) {
+	if reservedRoleActions[action] {
+		return false, nil
+	}
+	model, err := impl.customRoleRepository.FindActiveByName(action)
+	if err == pg.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching custom role", "name", action, "err", err)
+		return false, err
+	}
+	customRole, err := adaptCustomRole(model)
+	if err != nil {
+		return false, err
+	}
+	roleName := customRoleName(action, team, env, entityName)
+	policies := customRolePolicies(roleName, customRole.Permissions, team, entityName, env)
+	impl.logger.Debugw("add policy request", "policies", policies)
+	casbin.AddPolicy(policies)
+
+	_, err = impl.userAuthRepository.GetRole(roleName)
+	if err == nil {
+		return true, nil
+	} else if err != pg.ErrNoRows {
+		return false, err
+	}
+	dbConnection := impl.customRoleRepository.GetConnection()
+	tx, err := dbConnection.Begin()
+	if err != nil {
+		return false, err
+	}
+	// Rollback tx on error.
+	defer tx.Rollback()
+	roleModel := &repository2.RoleModel{
+		Role:        roleName,
+		Team:        team,
+		EntityName:  entityName,
+		Environment: env,
+		Action:      action,
+	}
+	_, err = impl.userAuthRepository.CreateRole(roleModel, tx)
+	if err != nil {
+		impl.logger.Errorw("error in creating custom role", "role", roleName, "err", err)
+		return false, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SyncOrchestratorToCasbin re-adds the policies of every role created for a custom role
+func (impl CustomRoleServiceImpl) SyncOrchestratorToCasbin() (bool, error) {
+	models, err := impl.customRoleRepository.FindAllActive()
+	if err != nil {
+		impl.logger.Errorw("error in fetching custom roles", "err", err)
+		return false, err
+	}
+	for _, model := range models {
+		customRole, err := adaptCustomRole(model)
+		if err != nil {
+			return false, err
+		}
+		err = impl.syncPolicies(customRole.Name, nil, customRole.Permissions)
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// syncPolicies swaps the casbin policies of all roles of a custom role from old permissions to new ones
+func (impl CustomRoleServiceImpl) syncPolicies(name string, oldPermissions []bean.CustomRolePermission, newPermissions []bean.CustomRolePermission) error {
+	roles, err := impl.customRoleRepository.FindRolesByAction(name)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching roles of custom role", "name", name, "err", err)
+		return err
+	}
+	var removed, added []casbin.Policy
+	for _, role := range roles {
+		removed = append(removed, customRolePolicies(role.Role, oldPermissions, role.Team, role.EntityName, role.Environment)...)
+		added = append(added, customRolePolicies(role.Role, newPermissions, role.Team, role.EntityName, role.Environment)...)
+	}
+	if len(removed) > 0 {
+		casbin.RemovePolicy(removed)
+	}
+	if len(added) > 0 {
+		casbin.AddPolicy(added)
+	}
+	return nil
+}
+
+func (impl CustomRoleServiceImpl) saveAuditEvent(action string, before *bean.CustomRole, after *bean.CustomRole, userId int32) {
+	event := &auditLog.AuditEvent{
+		UserId:       userId,
+		ResourceType: auditLog.ResourceCustomRole,
+		Action:       action,
+	}
+	if before != nil {
+		event.ResourceId = strconv.Itoa(before.Id)
+		event.Before = before
+	}
+	if after != nil {
+		event.ResourceId = strconv.Itoa(after.Id)
+		event.After = after
+	}
+	impl.auditLogService.SaveEvent(event)
+}
+
+func validateCustomRole(request *bean.CustomRole) error {
+	if !customRoleNameRegex.MatchString(request.Name) || reservedRoleActions[request.Name] {
+		return &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: fmt.Sprintf("invalid custom role name %s, use lowercase letters, digits and dashes other than a built-in role", request.Name)}
+	}
+	for _, permission := range request.Permissions {
+		if _, ok := customRoleObjects[permission.Resource]; !ok {
+			return &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: fmt.Sprintf("resource %s can not be granted by a custom role", permission.Resource)}
+		}
+		if !customRoleActions[permission.Action] {
+			return &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: fmt.Sprintf("invalid action %s", permission.Action)}
+		}
+	}
+	return nil
+}
+
+func adaptCustomRole(model *repository2.CustomRole) (*bean.CustomRole, error) {
+	customRole := &bean.CustomRole{
+		Id:          model.Id,
+		Name:        model.Name,
+		Description: model.Description,
+	}
+	err := json.Unmarshal([]byte(model.Permissions), &customRole.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	return customRole, nil
+}
+
+func customRoleName(name string, team string, env string, entityName string) string {
+	return fmt.Sprintf("role:%s_%s_%s_%s", name, team, env, entityName)
+}
+
+// customRolePolicies expands permissions into policies of a role, empty team, env or app stand for all
+func customRolePolicies(roleName string, permissions []bean.CustomRolePermission, team string, entityName string, env string) []casbin.Policy {
+	replacer := strings.NewReplacer("<TEAM_OBJ>", objectOrAll(team), "<ENV_OBJ>", objectOrAll(env), "<APP_OBJ>", objectOrAll(entityName))
+	var policies []casbin.Policy
+	for _, permission := range permissions {
+		object, ok := customRoleObjects[permission.Resource]
+		if !ok {
+			continue
+		}
+		policies = append(policies, casbin.Policy{
+			Type: "p",
+			Sub:  casbin.Subject(roleName),
+			Res:  casbin.Resource(permission.Resource),
+			Act:  casbin.Action(permission.Action),
+			Obj:  casbin.Object(replacer.Replace(object)),
+		})
+	}
+	return policies
+}
+
+func objectOrAll(object string) string {
+	if object == "" {
+		return "*"
+	}
+	return object
+}
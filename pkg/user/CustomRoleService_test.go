@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/devtron-labs/devtron/api/bean"
+	"github.com/devtron-labs/devtron/pkg/user/casbin"
+)
+
+func TestValidateCustomRole(t *testing.T) {
+	permissions := []bean.CustomRolePermission{{Resource: casbin.ResourceApplications, Action: casbin.ActionRestart}}
+	tests := []struct {
+		name    string
+		request *bean.CustomRole
+		wantErr bool
+	}{
+		{"valid", &bean.CustomRole{Name: "sre-operator", Permissions: permissions}, false},
+		{"built-in name", &bean.CustomRole{Name: "trigger", Permissions: permissions}, true},
+		{"invalid name", &bean.CustomRole{Name: "SRE_operator", Permissions: permissions}, true},
+		{"global resource", &bean.CustomRole{Name: "ops", Permissions: []bean.CustomRolePermission{{Resource: casbin.ResourceCluster, Action: casbin.ActionGet}}}, true},
+		{"unknown action", &bean.CustomRole{Name: "ops", Permissions: []bean.CustomRolePermission{{Resource: casbin.ResourceApplications, Action: "scale"}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateCustomRole(tt.request); (err != nil) != tt.wantErr {
+				t.Errorf("validateCustomRole() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCustomRolePolicies(t *testing.T) {
+	permissions := []bean.CustomRolePermission{
+		{Resource: casbin.ResourceApplications, Action: casbin.ActionRestart},
+		{Resource: casbin.ResourceEnvironment, Action: casbin.ActionHibernate},
+		{Resource: casbin.ResourceTeam, Action: casbin.ActionGet},
+	}
+	roleName := customRoleName("sre", "payments", "", "api")
+	got := customRolePolicies(roleName, permissions, "payments", "api", "")
+	want := []casbin.Policy{
+		{Type: "p", Sub: "role:sre_payments__api", Res: casbin.ResourceApplications, Act: casbin.ActionRestart, Obj: "payments/api"},
+		{Type: "p", Sub: "role:sre_payments__api", Res: casbin.ResourceEnvironment, Act: casbin.ActionHibernate, Obj: "*/api"},
+		{Type: "p", Sub: "role:sre_payments__api", Res: casbin.ResourceTeam, Act: casbin.ActionGet, Obj: "payments"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("customRolePolicies() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,417 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"fmt"
+	"github.com/caarlos0/env"
+	"github.com/devtron-labs/authenticator/middleware"
+	"github.com/devtron-labs/authenticator/password"
+	"github.com/devtron-labs/devtron/api/bean"
+	"github.com/devtron-labs/devtron/internal/constants"
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
+	repository2 "github.com/devtron-labs/devtron/pkg/user/repository"
+	util2 "github.com/devtron-labs/devtron/util"
+	"github.com/go-pg/pg"
+	"github.com/satori/go.uuid"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type LocalUserAuthConfig struct {
+	// Enabled lets users other than the built-in admin log in with a local password
+	Enabled             bool   `env:"LOCAL_USER_LOGIN_ENABLED" envDefault:"false"`
+	PasswordMinLength   int    `env:"LOCAL_USER_PASSWORD_MIN_LENGTH" envDefault:"12"`
+	MaxFailedAttempts   int    `env:"LOCAL_USER_LOGIN_MAX_FAILED_ATTEMPTS" envDefault:"5"`
+	LockoutMinutes      int    `env:"LOCAL_USER_LOGIN_LOCKOUT_MINUTES" envDefault:"15"`
+	MfaIssuer           string `env:"LOCAL_USER_MFA_ISSUER" envDefault:"Devtron"`
+	SessionDurationSecs int64  `env:"LOCAL_USER_SESSION_DURATION_SECONDS" envDefault:"86400"`
+	// MfaEncryptionKey encrypts totp secrets at rest, mfa can not be enrolled without it
+	MfaEncryptionKey string `env:"LOCAL_USER_MFA_ENCRYPTION_KEY" envDefault:""`
+}
+
+// LocalUserAuthService logs in users with a bcrypt password and, once enrolled, a TOTP code. Sessions are the
+// same jwt tokens as for sso logins, so rbac of local users works unchanged
+type LocalUserAuthService interface {
+	IsEnabled() bool
+	Login(emailId string, password string, otp string) (string, error)
+	SetPassword(request *bean.LocalPasswordRequest) error
+	ChangePassword(request *bean.LocalPasswordRequest) error
+	EnrollMfa(userId int32) (*bean.MfaEnrollment, error)
+	VerifyMfa(request *bean.MfaVerifyRequest) error
+	ResetMfa(userId int32, actionUserId int32) error
+	Unlock(userId int32, actionUserId int32) error
+}
+
+type LocalUserAuthServiceImpl struct {
+	logger                   *zap.SugaredLogger
+	config                   *LocalUserAuthConfig
+	userRepository           repository2.UserRepository
+	userCredentialRepository repository2.UserCredentialRepository
+	sessionManager           *middleware.SessionManager
+	auditLogService          auditLog.AuditLogService
+}
+
+func NewLocalUserAuthServiceImpl(logger *zap.SugaredLogger, userRepository repository2.UserRepository,
+	userCredentialRepository repository2.UserCredentialRepository, sessionManager *middleware.SessionManager,
+	auditLogService auditLog.AuditLogService) (*LocalUserAuthServiceImpl, error) {
+	config := &LocalUserAuthConfig{}
+	err := env.Parse(config)
+	if err != nil {
+		logger.Errorw("error in parsing local user auth config", "err", err)
+		return nil, err
+	}
+	return &LocalUserAuthServiceImpl{
+		logger:                   logger,
+		config:                   config,
+		userRepository:           userRepository,
+		userCredentialRepository: userCredentialRepository,
+		sessionManager:           sessionManager,
+		auditLogService:          auditLogService,
+	}, nil
+}
+
+func (impl LocalUserAuthServiceImpl) IsEnabled() bool {
+	return impl.config.Enabled
+}
+
+// dummyPasswordHash is checked for unknown users so that they take as long to refuse as users with a password
+const dummyPasswordHash = "$2a$10$ywa94WmS3ObFQLC/ql0IBui0.BVv5bsFEuBCDvsXpzFNrXp.scKRi"
+
+var errLoginLocked = &util.ApiError{HttpStatusCode: http.StatusLocked, Code: constants.UserLoginLocked, UserMessage: "too many failed login attempts, try again later"}
+
+// Login checks password and otp of an active user. Failed attempts, wrong otp codes included, lock the user out
+// for LockoutMinutes once MaxFailedAttempts is reached. The password is checked before the lockout, only the
+// owner of the password learns that the user is locked
+func (impl LocalUserAuthServiceImpl) Login(emailId string, pwd string, otp string) (string, error) {
+	invalidLogin := &util.ApiError{HttpStatusCode: http.StatusForbidden, UserMessage: "invalid username or password"}
+	user, err := impl.userRepository.FetchActiveUserByEmail(emailId)
+	if err != nil || user.Id == 0 {
+		password.VerifyPassword(pwd, dummyPasswordHash)
+		return "", invalidLogin
+	}
+	credential, err := impl.userCredentialRepository.FindByUserId(user.Id)
+	if err == pg.ErrNoRows || (err == nil && len(credential.PasswordHash) == 0) {
+		password.VerifyPassword(pwd, dummyPasswordHash)
+		return "", invalidLogin
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching user credential", "userId", user.Id, "err", err)
+		return "", err
+	}
+	now := time.Now()
+	valid, _ := password.VerifyPassword(pwd, credential.PasswordHash)
+	if credential.LockedUntil.After(now) {
+		if !valid {
+			return "", invalidLogin
+		}
+		return "", errLoginLocked
+	}
+	if !valid {
+		impl.loginFailed(credential.UserId)
+		return "", invalidLogin
+	}
+	if credential.MfaEnabled {
+		if len(otp) == 0 {
+			return "", &util.ApiError{HttpStatusCode: http.StatusUnauthorized, Code: constants.UserLoginMfaRequired, UserMessage: "one time password required"}
+		}
+		secret, err := impl.mfaSecret(credential)
+		if err != nil {
+			return "", err
+		}
+		step, ok := util2.ValidateTotp(secret, otp, now, credential.LastOtpStep)
+		if !ok {
+			impl.loginFailed(credential.UserId)
+			return "", &util.ApiError{HttpStatusCode: http.StatusForbidden, Code: constants.UserLoginMfaRequired, UserMessage: "invalid one time password"}
+		}
+		credential.LastOtpStep = step
+	}
+	credential.FailedAttempts = 0
+	credential.LockedUntil = time.Time{}
+	credential.LastLoginOn = now
+	credential.UpdatedOn = now
+	err = impl.userCredentialRepository.Update(credential)
+	if err != nil {
+		impl.logger.Errorw("error in updating user credential", "userId", user.Id, "err", err)
+		return "", err
+	}
+	return impl.sessionManager.Create(user.EmailId, impl.config.SessionDurationSecs, uuid.NewV4().String())
+}
+
+func (impl LocalUserAuthServiceImpl) loginFailed(userId int32) {
+	lockUntil := time.Now().Add(time.Duration(impl.config.LockoutMinutes) * time.Minute)
+	credential, err := impl.userCredentialRepository.IncrementFailedAttempts(userId, impl.config.MaxFailedAttempts, lockUntil)
+	if err != nil {
+		impl.logger.Errorw("error in recording failed login", "userId", userId, "err", err)
+		return
+	}
+	if credential.LockedUntil.After(time.Now()) {
+		impl.logger.Warnw("locking out user after failed login attempts", "userId", userId, "lockedUntil", credential.LockedUntil)
+	}
+}
+
+// SetPassword is used by admins to set the password of any user, it also lifts a lockout
+func (impl LocalUserAuthServiceImpl) SetPassword(request *bean.LocalPasswordRequest) error {
+	user, err := impl.userRepository.GetById(request.UserId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching user", "userId", request.UserId, "err", err)
+		return err
+	}
+	err = impl.validatePassword(request.Password, user.EmailId)
+	if err != nil {
+		return err
+	}
+	return impl.savePassword(request.UserId, request.Password, request.ActionUserId)
+}
+
+func (impl LocalUserAuthServiceImpl) ChangePassword(request *bean.LocalPasswordRequest) error {
+	user, err := impl.userRepository.GetById(request.UserId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching user", "userId", request.UserId, "err", err)
+		return err
+	}
+	credential, err := impl.userCredentialRepository.FindByUserId(request.UserId)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching user credential", "userId", request.UserId, "err", err)
+		return err
+	}
+	if err == pg.ErrNoRows || len(credential.PasswordHash) == 0 {
+		return &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "no local password is set, ask an admin to set one"}
+	}
+	// guessing the current password counts towards the lockout like a failed login
+	if credential.LockedUntil.After(time.Now()) {
+		return errLoginLocked
+	}
+	if valid, _ := password.VerifyPassword(request.CurrentPassword, credential.PasswordHash); !valid {
+		impl.loginFailed(credential.UserId)
+		return &util.ApiError{HttpStatusCode: http.StatusForbidden, UserMessage: "current password is invalid"}
+	}
+	if request.CurrentPassword == request.Password {
+		return &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "new password must differ from the current one"}
+	}
+	err = impl.validatePassword(request.Password, user.EmailId)
+	if err != nil {
+		return err
+	}
+	return impl.savePassword(request.UserId, request.Password, request.ActionUserId)
+}
+
+func (impl LocalUserAuthServiceImpl) savePassword(userId int32, pwd string, actionUserId int32) error {
+	hash, err := password.HashPassword(pwd)
+	if err != nil {
+		impl.logger.Errorw("error in hashing password", "userId", userId, "err", err)
+		return err
+	}
+	credential, err := impl.findOrNewCredential(userId, actionUserId)
+	if err != nil {
+		return err
+	}
+	credential.PasswordHash = hash
+	credential.PasswordUpdatedOn = time.Now()
+	credential.FailedAttempts = 0
+	credential.LockedUntil = time.Time{}
+	err = impl.saveCredential(credential, actionUserId)
+	if err != nil {
+		return err
+	}
+	impl.saveAuditEvent(userId, actionUserId, "password updated")
+	return nil
+}
+
+// EnrollMfa generates a new secret for the user, it is only used for login once verified with VerifyMfa
+func (impl LocalUserAuthServiceImpl) EnrollMfa(userId int32) (*bean.MfaEnrollment, error) {
+	user, err := impl.userRepository.GetById(userId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching user", "userId", userId, "err", err)
+		return nil, err
+	}
+	credential, err := impl.findOrNewCredential(userId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if credential.MfaEnabled {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "mfa is already enabled, ask an admin to reset it"}
+	}
+	if len(impl.config.MfaEncryptionKey) == 0 {
+		return nil, fmt.Errorf("LOCAL_USER_MFA_ENCRYPTION_KEY is not configured, mfa can not be enrolled")
+	}
+	secret, err := util2.GenerateTotpSecret()
+	if err != nil {
+		return nil, err
+	}
+	credential.MfaSecret, err = util2.EncryptData(impl.config.MfaEncryptionKey, []byte(secret))
+	if err != nil {
+		impl.logger.Errorw("error in encrypting mfa secret", "userId", userId, "err", err)
+		return nil, err
+	}
+	err = impl.saveCredential(credential, userId)
+	if err != nil {
+		return nil, err
+	}
+	return &bean.MfaEnrollment{
+		Secret:     secret,
+		OtpAuthUrl: util2.TotpUrl(impl.config.MfaIssuer, user.EmailId, secret),
+	}, nil
+}
+
+func (impl LocalUserAuthServiceImpl) VerifyMfa(request *bean.MfaVerifyRequest) error {
+	credential, err := impl.userCredentialRepository.FindByUserId(request.UserId)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching user credential", "userId", request.UserId, "err", err)
+		return err
+	}
+	if err == pg.ErrNoRows || len(credential.MfaSecret) == 0 {
+		return &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "mfa enrollment not started"}
+	}
+	if credential.MfaEnabled {
+		return &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "mfa is already enabled"}
+	}
+	secret, err := impl.mfaSecret(credential)
+	if err != nil {
+		return err
+	}
+	step, ok := util2.ValidateTotp(secret, request.Otp, time.Now(), credential.LastOtpStep)
+	if !ok {
+		return &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "invalid one time password"}
+	}
+	credential.MfaEnabled = true
+	credential.LastOtpStep = step
+	err = impl.saveCredential(credential, request.UserId)
+	if err != nil {
+		return err
+	}
+	impl.saveAuditEvent(request.UserId, request.UserId, "mfa enabled")
+	return nil
+}
+
+// ResetMfa disables mfa of a user who lost the authenticator, the user can enroll again after logging in
+func (impl LocalUserAuthServiceImpl) ResetMfa(userId int32, actionUserId int32) error {
+	credential, err := impl.userCredentialRepository.FindByUserId(userId)
+	if err == pg.ErrNoRows {
+		return nil
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching user credential", "userId", userId, "err", err)
+		return err
+	}
+	credential.MfaEnabled = false
+	credential.MfaSecret = ""
+	credential.LastOtpStep = 0
+	err = impl.saveCredential(credential, actionUserId)
+	if err != nil {
+		return err
+	}
+	impl.saveAuditEvent(userId, actionUserId, "mfa reset")
+	return nil
+}
+
+func (impl LocalUserAuthServiceImpl) Unlock(userId int32, actionUserId int32) error {
+	credential, err := impl.userCredentialRepository.FindByUserId(userId)
+	if err == pg.ErrNoRows {
+		return nil
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching user credential", "userId", userId, "err", err)
+		return err
+	}
+	credential.FailedAttempts = 0
+	credential.LockedUntil = time.Time{}
+	err = impl.saveCredential(credential, actionUserId)
+	if err != nil {
+		return err
+	}
+	impl.saveAuditEvent(userId, actionUserId, "login unlocked")
+	return nil
+}
+
+// mfaSecret decrypts the totp secret of the credential
+func (impl LocalUserAuthServiceImpl) mfaSecret(credential *repository2.UserCredential) (string, error) {
+	secret, err := util2.DecryptData(impl.config.MfaEncryptionKey, credential.MfaSecret)
+	if err != nil {
+		impl.logger.Errorw("error in decrypting mfa secret", "userId", credential.UserId, "err", err)
+		return "", err
+	}
+	return string(secret), nil
+}
+
+func (impl LocalUserAuthServiceImpl) findOrNewCredential(userId int32, actionUserId int32) (*repository2.UserCredential, error) {
+	credential, err := impl.userCredentialRepository.FindByUserId(userId)
+	if err == pg.ErrNoRows {
+		credential = &repository2.UserCredential{UserId: userId}
+		credential.CreatedBy = actionUserId
+		credential.CreatedOn = time.Now()
+		return credential, nil
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching user credential", "userId", userId, "err", err)
+		return nil, err
+	}
+	return credential, nil
+}
+
+func (impl LocalUserAuthServiceImpl) saveCredential(credential *repository2.UserCredential, actionUserId int32) error {
+	credential.UpdatedBy = actionUserId
+	credential.UpdatedOn = time.Now()
+	var err error
+	if credential.Id == 0 {
+		err = impl.userCredentialRepository.Save(credential)
+	} else {
+		err = impl.userCredentialRepository.Update(credential)
+	}
+	if err != nil {
+		impl.logger.Errorw("error in saving user credential", "userId", credential.UserId, "err", err)
+	}
+	return err
+}
+
+// validatePassword applies the password policy: a minimum length and a mix of upper and lower case letters,
+// digits and special characters. Passwords containing the email id are refused
+func (impl LocalUserAuthServiceImpl) validatePassword(pwd string, emailId string) error {
+	var upper, lower, digit, special bool
+	for _, c := range pwd {
+		switch {
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsDigit(c):
+			digit = true
+		case unicode.IsPunct(c) || unicode.IsSymbol(c) || unicode.IsSpace(c):
+			special = true
+		}
+	}
+	if len(pwd) < impl.config.PasswordMinLength || !upper || !lower || !digit || !special {
+		return &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: fmt.Sprintf("password must have at least %d characters with upper and lower case letters, digits and special characters", impl.config.PasswordMinLength)}
+	}
+	name := strings.Split(emailId, "@")[0]
+	if len(name) > 0 && strings.Contains(strings.ToLower(pwd), strings.ToLower(name)) {
+		return &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "password must not contain the user name"}
+	}
+	return nil
+}
+
+func (impl LocalUserAuthServiceImpl) saveAuditEvent(userId int32, actionUserId int32, change string) {
+	impl.auditLogService.SaveEvent(&auditLog.AuditEvent{
+		UserId:       actionUserId,
+		ResourceType: auditLog.ResourceUser,
+		ResourceId:   strconv.Itoa(int(userId)),
+		Action:       auditLog.ActionUpdate,
+		After:        change,
+	})
+}
@@ -0,0 +1,237 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/devtron-labs/authenticator/client"
+	"github.com/devtron-labs/authenticator/middleware"
+	"github.com/devtron-labs/authenticator/oidc"
+	"github.com/devtron-labs/authenticator/password"
+	"github.com/devtron-labs/devtron/api/bean"
+	"github.com/devtron-labs/devtron/internal/constants"
+	"github.com/devtron-labs/devtron/internal/util"
+	repository2 "github.com/devtron-labs/devtron/pkg/user/repository"
+	util2 "github.com/devtron-labs/devtron/util"
+)
+
+type localUserRepositoryStub struct {
+	repository2.UserRepository
+	user bean.UserInfo
+}
+
+func (impl localUserRepositoryStub) FetchActiveUserByEmail(email string) (bean.UserInfo, error) {
+	if email != impl.user.EmailId {
+		return bean.UserInfo{}, nil
+	}
+	return impl.user, nil
+}
+
+func (impl localUserRepositoryStub) GetById(id int32) (*repository2.UserModel, error) {
+	return &repository2.UserModel{Id: impl.user.Id, EmailId: impl.user.EmailId}, nil
+}
+
+// userCredentialRepositoryStub holds one credential, IncrementFailedAttempts does what its sql statement does
+type userCredentialRepositoryStub struct {
+	repository2.UserCredentialRepository
+	credential *repository2.UserCredential
+}
+
+func (impl *userCredentialRepositoryStub) FindByUserId(userId int32) (*repository2.UserCredential, error) {
+	copied := *impl.credential
+	return &copied, nil
+}
+
+func (impl *userCredentialRepositoryStub) Update(model *repository2.UserCredential) error {
+	copied := *model
+	impl.credential = &copied
+	return nil
+}
+
+func (impl *userCredentialRepositoryStub) IncrementFailedAttempts(userId int32, maxAttempts int, lockUntil time.Time) (*repository2.UserCredential, error) {
+	if maxAttempts > 0 && impl.credential.FailedAttempts+1 >= maxAttempts {
+		impl.credential.FailedAttempts = 0
+		impl.credential.LockedUntil = lockUntil
+	} else {
+		impl.credential.FailedAttempts++
+	}
+	copied := *impl.credential
+	return &copied, nil
+}
+
+const testMfaKey = "mfa-test-key"
+
+func newLocalUserAuthServiceForTest(t *testing.T, credential *repository2.UserCredential) (*LocalUserAuthServiceImpl, *userCredentialRepositoryStub) {
+	hash, err := password.HashPassword("Correct-Horse-7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	credential.UserId = 2
+	credential.PasswordHash = hash
+	credentials := &userCredentialRepositoryStub{credential: credential}
+	return &LocalUserAuthServiceImpl{
+		logger:                   util.NewSugardLogger(),
+		config:                   &LocalUserAuthConfig{MaxFailedAttempts: 3, LockoutMinutes: 15, SessionDurationSecs: 3600, MfaEncryptionKey: testMfaKey},
+		userRepository:           localUserRepositoryStub{user: bean.UserInfo{Id: 2, EmailId: "jane@example.com"}},
+		userCredentialRepository: credentials,
+		sessionManager:           middleware.NewSessionManager(&oidc.Settings{OIDCConfig: oidc.OIDCConfig{ServerSecret: "local-test"}}, &client.DexConfig{}),
+		auditLogService:          sessionAuditLogServiceStub{},
+	}, credentials
+}
+
+func loginStatus(err error) int {
+	if apiErr, ok := err.(*util.ApiError); ok {
+		return apiErr.HttpStatusCode
+	}
+	return 0
+}
+
+func TestValidatePassword(t *testing.T) {
+	impl := LocalUserAuthServiceImpl{config: &LocalUserAuthConfig{PasswordMinLength: 12}}
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{"valid", "Correct-Horse-7", false},
+		{"too short", "Short-7a", true},
+		{"no digit", "Correct-Horse-Battery", true},
+		{"no special", "CorrectHorse77", true},
+		{"no upper case", "correct-horse-7", true},
+		{"contains user name", "Oncall-Engineer-7", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := impl.validatePassword(tt.password, "oncall@example.com"); (err != nil) != tt.wantErr {
+				t.Errorf("validatePassword() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	impl, credentials := newLocalUserAuthServiceForTest(t, &repository2.UserCredential{})
+	for i := 1; i <= 3; i++ {
+		if _, err := impl.Login("jane@example.com", "Wrong-Horse-7", ""); loginStatus(err) != http.StatusForbidden {
+			t.Fatalf("attempt %d: Login() err = %v, want forbidden", i, err)
+		}
+	}
+	if !credentials.credential.LockedUntil.After(time.Now()) || credentials.credential.FailedAttempts != 0 {
+		t.Fatalf("expected user to be locked after 3 attempts, got %+v", credentials.credential)
+	}
+	// the lockout is only revealed to the owner of the password, guesses while locked are not counted
+	if _, err := impl.Login("jane@example.com", "Wrong-Horse-7", ""); loginStatus(err) != http.StatusForbidden {
+		t.Errorf("Login() with wrong password while locked err = %v, want forbidden", err)
+	}
+	if credentials.credential.FailedAttempts != 0 {
+		t.Errorf("failed attempts counted while locked: %d", credentials.credential.FailedAttempts)
+	}
+	if _, err := impl.Login("jane@example.com", "Correct-Horse-7", ""); loginStatus(err) != http.StatusLocked {
+		t.Errorf("Login() with password while locked err = %v, want locked", err)
+	}
+	err := impl.ChangePassword(&bean.LocalPasswordRequest{UserId: 2, CurrentPassword: "Correct-Horse-7", Password: "Battery-Staple-8", ActionUserId: 2})
+	if loginStatus(err) != http.StatusLocked {
+		t.Errorf("ChangePassword() while locked err = %v, want locked", err)
+	}
+	if _, err := impl.Login("john@example.com", "Correct-Horse-7", ""); loginStatus(err) != http.StatusForbidden {
+		t.Errorf("Login() of unknown user err = %v, want forbidden", err)
+	}
+
+	credentials.credential.LockedUntil = time.Now().Add(-time.Minute)
+	token, err := impl.Login("jane@example.com", "Correct-Horse-7", "")
+	if err != nil || token == "" {
+		t.Fatalf("Login() after lockout = %q, %v", token, err)
+	}
+	err = impl.ChangePassword(&bean.LocalPasswordRequest{UserId: 2, CurrentPassword: "Wrong-Horse-7", Password: "Battery-Staple-8", ActionUserId: 2})
+	if loginStatus(err) != http.StatusForbidden || credentials.credential.FailedAttempts != 1 {
+		t.Errorf("ChangePassword() with wrong password err = %v, failed attempts %d", err, credentials.credential.FailedAttempts)
+	}
+}
+
+func TestLoginTotp(t *testing.T) {
+	secret, err := util2.GenerateTotpSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := util2.EncryptData(testMfaKey, []byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	impl, credentials := newLocalUserAuthServiceForTest(t, &repository2.UserCredential{MfaEnabled: true, MfaSecret: encrypted})
+	_, err = impl.Login("jane@example.com", "Correct-Horse-7", "")
+	if apiErr, ok := err.(*util.ApiError); !ok || apiErr.HttpStatusCode != http.StatusUnauthorized || apiErr.Code != constants.UserLoginMfaRequired {
+		t.Fatalf("Login() without otp err = %v, want otp required", err)
+	}
+	step := util2.TotpStep(time.Now())
+	staleCode, err := util2.TotpCode(secret, step-10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = impl.Login("jane@example.com", "Correct-Horse-7", staleCode); loginStatus(err) != http.StatusForbidden {
+		t.Errorf("Login() with invalid otp err = %v, want forbidden", err)
+	}
+	if credentials.credential.FailedAttempts != 1 {
+		t.Errorf("invalid otp not counted as failed attempt: %d", credentials.credential.FailedAttempts)
+	}
+	code, err := util2.TotpCode(secret, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := impl.Login("jane@example.com", "Correct-Horse-7", code)
+	if err != nil || token == "" {
+		t.Fatalf("Login() with otp = %q, %v", token, err)
+	}
+	if credentials.credential.LastOtpStep < step || credentials.credential.FailedAttempts != 0 {
+		t.Errorf("unexpected credential after login %+v", credentials.credential)
+	}
+	// a code can not be used twice
+	if _, err = impl.Login("jane@example.com", "Correct-Horse-7", code); loginStatus(err) != http.StatusForbidden {
+		t.Errorf("Login() with reused otp err = %v, want forbidden", err)
+	}
+}
+
+func TestEnrollMfaEncryptsSecret(t *testing.T) {
+	impl, credentials := newLocalUserAuthServiceForTest(t, &repository2.UserCredential{Id: 1})
+	enrollment, err := impl.EnrollMfa(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := credentials.credential.MfaSecret
+	if stored == "" || stored == enrollment.Secret {
+		t.Fatalf("mfa secret stored as %q, want it encrypted", stored)
+	}
+	if decrypted, err := util2.DecryptData(testMfaKey, stored); err != nil || string(decrypted) != enrollment.Secret {
+		t.Errorf("DecryptData() of stored secret = %s, %v", decrypted, err)
+	}
+	code, err := util2.TotpCode(enrollment.Secret, util2.TotpStep(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = impl.VerifyMfa(&bean.MfaVerifyRequest{UserId: 2, Otp: code}); err != nil || !credentials.credential.MfaEnabled {
+		t.Errorf("VerifyMfa() err = %v, enabled %v", err, credentials.credential.MfaEnabled)
+	}
+
+	impl.config.MfaEncryptionKey = ""
+	credentials.credential.MfaEnabled = false
+	if _, err = impl.EnrollMfa(2); err == nil {
+		t.Errorf("EnrollMfa() without encryption key should fail")
+	}
+}
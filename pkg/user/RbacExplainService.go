@@ -0,0 +1,259 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	casbinUtil "github.com/casbin/casbin/util"
+	"github.com/devtron-labs/devtron/api/bean"
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/user/casbin"
+	repository2 "github.com/devtron-labs/devtron/pkg/user/repository"
+	"github.com/devtron-labs/devtron/util/rbac"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+// RbacExplainService answers allow or deny for casbin requests of a user along with the policies, roles and
+// role groups behind the decision
+type RbacExplainService interface {
+	Explain(request *bean.RbacExplainRequest) (*bean.RbacExplainResponse, error)
+}
+
+type RbacExplainServiceImpl struct {
+	logger              *zap.SugaredLogger
+	enforcer            casbin.Enforcer
+	enforcerUtil        rbac.EnforcerUtil
+	userRepository      repository2.UserRepository
+	apiTokenRepository  repository2.ApiTokenRepository
+	roleGroupRepository repository2.RoleGroupRepository
+}
+
+func NewRbacExplainServiceImpl(logger *zap.SugaredLogger, enforcer casbin.Enforcer, enforcerUtil rbac.EnforcerUtil,
+	userRepository repository2.UserRepository, apiTokenRepository repository2.ApiTokenRepository,
+	roleGroupRepository repository2.RoleGroupRepository) *RbacExplainServiceImpl {
+	return &RbacExplainServiceImpl{
+		logger:              logger,
+		enforcer:            enforcer,
+		enforcerUtil:        enforcerUtil,
+		userRepository:      userRepository,
+		apiTokenRepository:  apiTokenRepository,
+		roleGroupRepository: roleGroupRepository,
+	}
+}
+
+const (
+	policyEffectAllow = "allow"
+	policyEffectDeny  = "deny"
+)
+
+// actions checked for an app when explaining a whole app
+var appExplainActions = map[string][]string{
+	casbin.ResourceApplications:       {casbin.ActionGet, casbin.ActionCreate, casbin.ActionUpdate, casbin.ActionDelete, casbin.ActionTrigger, casbin.ActionRestart, casbin.ActionHibernate, casbin.ActionDebug},
+	casbin.ResourceSecret:             {casbin.ActionGet, casbin.ActionUpdate},
+	casbin.ResourceDeploymentTemplate: {casbin.ActionCreate, casbin.ActionUpdate},
+}
+
+// actions checked for each environment of an app when explaining a whole app
+var envExplainActions = map[string][]string{
+	casbin.ResourceEnvironment: {casbin.ActionGet, casbin.ActionCreate, casbin.ActionUpdate, casbin.ActionDelete, casbin.ActionTrigger, casbin.ActionRestart, casbin.ActionHibernate, casbin.ActionDebug},
+	casbin.ResourceEnvOverride: {casbin.ActionCreate, casbin.ActionUpdate, casbin.ActionDelete},
+}
+
+func (impl RbacExplainServiceImpl) Explain(request *bean.RbacExplainRequest) (*bean.RbacExplainResponse, error) {
+	emailId, err := impl.resolveSubject(request)
+	if err != nil {
+		return nil, err
+	}
+	checks := request.Checks
+	if request.AppId > 0 {
+		checks = append(checks, impl.appChecks(request.AppId)...)
+	}
+	if len(checks) == 0 {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "provide checks or an appId to explain"}
+	}
+
+	roles, err := impl.subjectRoles(emailId)
+	if err != nil {
+		return nil, err
+	}
+	response := &bean.RbacExplainResponse{EmailId: emailId, Roles: roles}
+	subjects := append([]bean.RbacExplainRole{{Role: emailId}}, roles...)
+	for _, check := range checks {
+		result := &bean.RbacCheckResult{
+			RbacCheck:       check,
+			Allowed:         impl.enforcer.EnforceByEmail(emailId, check.Resource, check.Action, check.Object),
+			MatchedPolicies: make([]bean.RbacMatchedPolicy, 0),
+		}
+		for _, subject := range subjects {
+			result.MatchedPolicies = append(result.MatchedPolicies, matchPolicies(subject, casbin.GetPoliciesForSubject(subject.Role), check)...)
+		}
+		for _, policy := range result.MatchedPolicies {
+			if policy.Effect == policyEffectDeny {
+				result.DeniedBy = append(result.DeniedBy, policy)
+			}
+		}
+		response.Results = append(response.Results, result)
+	}
+	return response, nil
+}
+
+func (impl RbacExplainServiceImpl) resolveSubject(request *bean.RbacExplainRequest) (string, error) {
+	if len(request.EmailId) > 0 {
+		return strings.ToLower(request.EmailId), nil
+	}
+	userId := request.UserId
+	if request.ApiTokenId > 0 {
+		apiToken, err := impl.apiTokenRepository.FindActiveById(request.ApiTokenId)
+		if err != nil {
+			impl.logger.Errorw("error in fetching api token", "id", request.ApiTokenId, "err", err)
+			return "", err
+		}
+		userId = apiToken.UserId
+	}
+	user, err := impl.userRepository.GetById(userId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching user", "id", userId, "err", err)
+		return "", err
+	}
+	return strings.ToLower(user.EmailId), nil
+}
+
+// subjectRoles walks the casbin role links of a user transitively, roles inherited through a role group carry
+// the name of the nearest role group
+func (impl RbacExplainServiceImpl) subjectRoles(emailId string) ([]bean.RbacExplainRole, error) {
+	links, err := expandRoles(emailId, casbin.GetRolesForUser)
+	if err != nil {
+		impl.logger.Errorw("error in fetching roles of user", "emailId", emailId, "err", err)
+		return nil, err
+	}
+	var groupCasbinNames []string
+	for _, link := range links {
+		if strings.HasPrefix(link.role, "group:") {
+			groupCasbinNames = append(groupCasbinNames, link.role)
+		}
+	}
+	groupNames := make(map[string]string)
+	if len(groupCasbinNames) > 0 {
+		roleGroups, err := impl.roleGroupRepository.GetRoleGroupListByCasbinNames(groupCasbinNames)
+		if err != nil {
+			impl.logger.Errorw("error in fetching role groups", "casbinNames", groupCasbinNames, "err", err)
+			return nil, err
+		}
+		for _, roleGroup := range roleGroups {
+			groupNames[strings.ToLower(roleGroup.CasbinName)] = roleGroup.Name
+		}
+	}
+	var roles []bean.RbacExplainRole
+	for _, link := range links {
+		role := bean.RbacExplainRole{Role: link.role}
+		if link.group != "" {
+			role.RoleGroup = groupNames[strings.ToLower(link.group)]
+			if role.RoleGroup == "" {
+				role.RoleGroup = link.group
+			}
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+// roleLink is a role reached from the subject, group being the casbin name of the nearest role group on the way
+type roleLink struct {
+	role  string
+	group string
+}
+
+// expandRoles resolves the roles of a subject breadth first through every level of role inheritance, each
+// role is reported once at its shortest distance
+func expandRoles(subject string, rolesFor func(string) ([]string, error)) ([]roleLink, error) {
+	var links []roleLink
+	seen := map[string]bool{strings.ToLower(subject): true}
+	queue := []roleLink{{role: subject}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		roles, err := rolesFor(current.role)
+		if err != nil {
+			return nil, err
+		}
+		group := current.group
+		if strings.HasPrefix(current.role, "group:") {
+			group = current.role
+		}
+		for _, role := range roles {
+			if seen[strings.ToLower(role)] {
+				continue
+			}
+			seen[strings.ToLower(role)] = true
+			link := roleLink{role: role, group: group}
+			links = append(links, link)
+			queue = append(queue, link)
+		}
+	}
+	return links, nil
+}
+
+func (impl RbacExplainServiceImpl) appChecks(appId int) []bean.RbacCheck {
+	var checks []bean.RbacCheck
+	appObject := impl.enforcerUtil.GetAppRBACNameByAppId(appId)
+	for _, resource := range []string{casbin.ResourceApplications, casbin.ResourceSecret, casbin.ResourceDeploymentTemplate} {
+		for _, action := range appExplainActions[resource] {
+			checks = append(checks, bean.RbacCheck{Resource: resource, Action: action, Object: appObject})
+		}
+	}
+	for _, envObject := range impl.enforcerUtil.GetEnvRBACArrayByAppId(appId) {
+		for _, resource := range []string{casbin.ResourceEnvironment, casbin.ResourceEnvOverride} {
+			for _, action := range envExplainActions[resource] {
+				checks = append(checks, bean.RbacCheck{Resource: resource, Action: action, Object: envObject})
+			}
+		}
+	}
+	return checks
+}
+
+// matchPolicies returns the allow and deny rules of a subject covering the check, using the keyMatch of
+// auth_model.conf, rules without an effect are allow rules
+func matchPolicies(subject bean.RbacExplainRole, policies [][]string, check bean.RbacCheck) []bean.RbacMatchedPolicy {
+	var matched []bean.RbacMatchedPolicy
+	for _, policy := range policies {
+		if len(policy) < 4 {
+			continue
+		}
+		effect := policyEffectAllow
+		if len(policy) > 4 {
+			effect = policy[4]
+		}
+		if effect != policyEffectAllow && effect != policyEffectDeny {
+			continue
+		}
+		if casbinUtil.KeyMatch(check.Resource, policy[1]) &&
+			casbinUtil.KeyMatch(check.Action, policy[2]) &&
+			casbinUtil.KeyMatch(check.Object, policy[3]) {
+			matched = append(matched, bean.RbacMatchedPolicy{
+				Role:      subject.Role,
+				RoleGroup: subject.RoleGroup,
+				Resource:  policy[1],
+				Action:    policy[2],
+				Object:    policy[3],
+				Effect:    effect,
+			})
+		}
+	}
+	return matched
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/devtron-labs/devtron/api/bean"
+)
+
+func TestMatchPolicies(t *testing.T) {
+	subject := bean.RbacExplainRole{Role: "role:trigger_payments__api", RoleGroup: "payments-devs"}
+	policies := [][]string{
+		{"role:trigger_payments__api", "applications", "get", "payments/api", "allow"},
+		{"role:trigger_payments__api", "applications", "trigger", "payments/api", "allow"},
+		{"role:trigger_payments__api", "environment", "*", "*", "allow"},
+		{"role:trigger_payments__api", "applications", "*", "payments/*", "deny"},
+	}
+	got := matchPolicies(subject, policies, bean.RbacCheck{Resource: "applications", Action: "trigger", Object: "payments/api"})
+	want := []bean.RbacMatchedPolicy{
+		{Role: subject.Role, RoleGroup: "payments-devs", Resource: "applications", Action: "trigger", Object: "payments/api", Effect: "allow"},
+		{Role: subject.Role, RoleGroup: "payments-devs", Resource: "applications", Action: "*", Object: "payments/*", Effect: "deny"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("matchPolicies() = %v, want %v", got, want)
+	}
+	if got := matchPolicies(subject, policies, bean.RbacCheck{Resource: "environment", Action: "delete", Object: "prod/api"}); len(got) != 1 || got[0].Effect != "allow" {
+		t.Errorf("expected wildcard policy to match, got %v", got)
+	}
+	if got := matchPolicies(subject, policies, bean.RbacCheck{Resource: "applications", Action: "delete", Object: "billing/api"}); len(got) != 0 {
+		t.Errorf("expected no match, got %v", got)
+	}
+	// rules stored without an effect are allow rules
+	got = matchPolicies(subject, [][]string{{subject.Role, "team", "get", "payments"}}, bean.RbacCheck{Resource: "team", Action: "get", Object: "payments"})
+	if len(got) != 1 || got[0].Effect != "allow" {
+		t.Errorf("expected policy without effect to allow, got %v", got)
+	}
+}
+
+func TestExpandRoles(t *testing.T) {
+	links := map[string][]string{
+		"dev@example.com":            {"group:payments_devs", "role:view_payments"},
+		"group:payments_devs":        {"role:trigger_payments__api", "group:payments_readers"},
+		"group:payments_readers":     {"role:view_payments", "role:view_billing"},
+		"role:view_billing":          {"role:view_billing_base"},
+		"role:trigger_payments__api": {"group:payments_devs"},
+	}
+	got, err := expandRoles("dev@example.com", func(sub string) ([]string, error) {
+		return links[sub], nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []roleLink{
+		{role: "group:payments_devs"},
+		{role: "role:view_payments"},
+		{role: "role:trigger_payments__api", group: "group:payments_devs"},
+		{role: "group:payments_readers", group: "group:payments_devs"},
+		{role: "role:view_billing", group: "group:payments_readers"},
+		{role: "role:view_billing_base", group: "group:payments_readers"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expandRoles() = %v, want %v", got, want)
+	}
+}
@@ -20,12 +20,14 @@ package user
 import (
 	"fmt"
 	repository2 "github.com/devtron-labs/devtron/pkg/user/repository"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/devtron-labs/devtron/api/bean"
 	"github.com/devtron-labs/devtron/internal/constants"
 	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
 	casbin2 "github.com/devtron-labs/devtron/pkg/user/casbin"
 	"github.com/go-pg/pg"
 	"github.com/gorilla/sessions"
@@ -48,16 +50,21 @@ type RoleGroupServiceImpl struct {
 	logger              *zap.SugaredLogger
 	userRepository      repository2.UserRepository
 	roleGroupRepository repository2.RoleGroupRepository
+	auditLogService     auditLog.AuditLogService
+	customRoleService   CustomRoleService
 }
 
 func NewRoleGroupServiceImpl(userAuthRepository repository2.UserAuthRepository,
 	logger *zap.SugaredLogger, userRepository repository2.UserRepository,
-	roleGroupRepository repository2.RoleGroupRepository) *RoleGroupServiceImpl {
+	roleGroupRepository repository2.RoleGroupRepository, auditLogService auditLog.AuditLogService,
+	customRoleService CustomRoleService) *RoleGroupServiceImpl {
 	serviceImpl := &RoleGroupServiceImpl{
 		userAuthRepository:  userAuthRepository,
 		logger:              logger,
 		userRepository:      userRepository,
 		roleGroupRepository: roleGroupRepository,
+		auditLogService:     auditLogService,
+		customRoleService:   customRoleService,
 	}
 	cStore = sessions.NewCookieStore(randKey())
 	return serviceImpl
@@ -140,6 +147,10 @@ func (impl RoleGroupServiceImpl) CreateRoleGroup(request *bean.RoleGroup) (*bean
 							if err != nil || flag == false {
 								return nil, err
 							}
+							_, err = impl.customRoleService.CreatePoliciesForFilter(roleFilter.Action, roleFilter.Team, entityName, environment)
+							if err != nil {
+								return nil, err
+							}
 							roleModel, err = impl.userAuthRepository.GetRoleByFilter(roleFilter.Entity, roleFilter.Team, entityName, environment, roleFilter.Action)
 							if err != nil {
 								impl.logger.Errorw("Error in fetching role by filter", "user", request)
@@ -196,10 +207,16 @@ func (impl RoleGroupServiceImpl) CreateRoleGroup(request *bean.RoleGroup) (*bean
 	if err != nil {
 		return nil, err
 	}
+	impl.saveAuditEvent(auditLog.ActionCreate, nil, request, request.UserId)
 	return request, nil
 }
 
 func (impl RoleGroupServiceImpl) UpdateRoleGroup(request *bean.RoleGroup) (*bean.RoleGroup, error) {
+	before, err := impl.FetchRoleGroupsById(request.Id)
+	if err != nil {
+		impl.logger.Errorw("error while fetching role group from db", "error", err)
+		return nil, err
+	}
 	dbConnection := impl.roleGroupRepository.GetConnection()
 	tx, err := dbConnection.Begin()
 	if err != nil {
@@ -332,6 +349,10 @@ func (impl RoleGroupServiceImpl) UpdateRoleGroup(request *bean.RoleGroup) (*bean
 						if err != nil || flag == false {
 							return nil, err
 						}
+						_, err = impl.customRoleService.CreatePoliciesForFilter(roleFilter.Action, roleFilter.Team, entityName, environment)
+						if err != nil {
+							return nil, err
+						}
 						roleModel, err = impl.userAuthRepository.GetRoleByFilter(roleFilter.Entity, roleFilter.Team, entityName, environment, roleFilter.Action)
 						if err != nil {
 							impl.logger.Errorw("Error in fetching role by filter", "user", request)
@@ -393,10 +414,29 @@ func (impl RoleGroupServiceImpl) UpdateRoleGroup(request *bean.RoleGroup) (*bean
 	if err != nil {
 		return nil, err
 	}
+	impl.saveAuditEvent(auditLog.ActionUpdate, before, request, request.UserId)
 
 	return request, nil
 }
 
+// saveAuditEvent records roles of the group before and after the change
+func (impl RoleGroupServiceImpl) saveAuditEvent(action string, before *bean.RoleGroup, after *bean.RoleGroup, userId int32) {
+	event := &auditLog.AuditEvent{
+		UserId:       userId,
+		ResourceType: auditLog.ResourceRoleGroup,
+		Action:       action,
+	}
+	if before != nil {
+		event.ResourceId = strconv.Itoa(int(before.Id))
+		event.Before = before
+	}
+	if after != nil {
+		event.ResourceId = strconv.Itoa(int(after.Id))
+		event.After = after
+	}
+	impl.auditLogService.SaveEvent(event)
+}
+
 const AllEnvironment string = ""
 
 func (impl RoleGroupServiceImpl) FetchRoleGroupsById(id int32) (*bean.RoleGroup, error) {
@@ -499,6 +539,11 @@ func (impl RoleGroupServiceImpl) FetchRoleGroupsByName(name string) ([]*bean.Rol
 }
 
 func (impl RoleGroupServiceImpl) DeleteRoleGroup(bean *bean.RoleGroup) (bool, error) {
+	before, err := impl.FetchRoleGroupsById(bean.Id)
+	if err != nil {
+		impl.logger.Errorw("error while fetching role group from db", "error", err)
+		return false, err
+	}
 
 	dbConnection := impl.roleGroupRepository.GetConnection()
 	tx, err := dbConnection.Begin()
@@ -525,6 +570,7 @@ func (impl RoleGroupServiceImpl) DeleteRoleGroup(bean *bean.RoleGroup) (bool, er
 	if err != nil {
 		return false, err
 	}
+	impl.saveAuditEvent(auditLog.ActionDelete, before, nil, bean.UserId)
 
 	return true, nil
 }
@@ -0,0 +1,725 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/devtron-labs/devtron/api/bean"
+	casbin2 "github.com/devtron-labs/devtron/pkg/user/casbin"
+	repository2 "github.com/devtron-labs/devtron/pkg/user/repository"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// scimFilterRegex matches the only filter form idps use for lookups, e.g. userName eq "jane@example.com"
+var scimFilterRegex = regexp.MustCompile(`^\s*(\w+)\s+(?i:eq)\s+"((?:[^"\\]|\\.)*)"\s*$`)
+
+// scimMemberPathRegex matches member paths of patch operations, e.g. members[value eq "12"]
+var scimMemberPathRegex = regexp.MustCompile(`^members\[\s*value\s+(?i:eq)\s+"([^"]*)"\s*\]$`)
+
+const scimDefaultCount = 100
+
+// ScimService maps scim 2.0 users and groups to devtron users and role groups, group membership of a user
+// is its role group membership
+type ScimService interface {
+	ListUsers(filter string, startIndex int, count int) (*bean.ScimListResponse, error)
+	GetUser(id int32) (*bean.ScimUser, error)
+	CreateUser(request *bean.ScimUser, userId int32) (*bean.ScimUser, error)
+	ReplaceUser(id int32, request *bean.ScimUser, userId int32) (*bean.ScimUser, error)
+	PatchUser(id int32, request *bean.ScimPatchRequest, userId int32) (*bean.ScimUser, error)
+	DeleteUser(id int32, userId int32) error
+
+	ListGroups(filter string, startIndex int, count int, excludeMembers bool) (*bean.ScimListResponse, error)
+	GetGroup(id int32, excludeMembers bool) (*bean.ScimGroup, error)
+	CreateGroup(request *bean.ScimGroup, userId int32) (*bean.ScimGroup, error)
+	ReplaceGroup(id int32, request *bean.ScimGroup, userId int32) (*bean.ScimGroup, error)
+	PatchGroup(id int32, request *bean.ScimPatchRequest, userId int32) (*bean.ScimGroup, error)
+	DeleteGroup(id int32, userId int32) error
+}
+
+type ScimServiceImpl struct {
+	logger              *zap.SugaredLogger
+	userService         UserService
+	roleGroupService    RoleGroupService
+	userRepository      repository2.UserRepository
+	roleGroupRepository repository2.RoleGroupRepository
+}
+
+func NewScimServiceImpl(logger *zap.SugaredLogger, userService UserService, roleGroupService RoleGroupService,
+	userRepository repository2.UserRepository, roleGroupRepository repository2.RoleGroupRepository) *ScimServiceImpl {
+	return &ScimServiceImpl{
+		logger:              logger,
+		userService:         userService,
+		roleGroupService:    roleGroupService,
+		userRepository:      userRepository,
+		roleGroupRepository: roleGroupRepository,
+	}
+}
+
+func (impl ScimServiceImpl) ListUsers(filter string, startIndex int, count int) (*bean.ScimListResponse, error) {
+	var models []*repository2.UserModel
+	if filter != "" {
+		attribute, value, err := parseScimFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(attribute, "userName") {
+			return nil, bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidFilter, fmt.Sprintf("filtering on %s is not supported", attribute))
+		}
+		// deleted users are listed too, so that the idp re-activates them instead of creating a duplicate
+		model, err := impl.userRepository.FetchActiveOrDeletedUserByEmail(value)
+		if err != nil && err != pg.ErrNoRows {
+			impl.logger.Errorw("error in fetching user", "userName", value, "err", err)
+			return nil, err
+		}
+		if err == nil && !IsApiTokenUser(model.EmailId) {
+			models = append(models, model)
+		}
+	} else {
+		users, err := impl.userRepository.GetAll()
+		if err != nil && err != pg.ErrNoRows {
+			impl.logger.Errorw("error in fetching users", "err", err)
+			return nil, err
+		}
+		for i := range users {
+			if !IsApiTokenUser(users[i].EmailId) {
+				models = append(models, &users[i])
+			}
+		}
+	}
+	page, startIndex := scimPage(len(models), startIndex, count)
+	resources := make([]interface{}, 0)
+	for _, model := range models[page[0]:page[1]] {
+		user, err := impl.adaptScimUser(model)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, user)
+	}
+	return scimListResponse(len(models), startIndex, resources), nil
+}
+
+func (impl ScimServiceImpl) GetUser(id int32) (*bean.ScimUser, error) {
+	model, err := impl.getUserModel(id)
+	if err != nil {
+		return nil, err
+	}
+	return impl.adaptScimUser(model)
+}
+
+func (impl ScimServiceImpl) CreateUser(request *bean.ScimUser, userId int32) (*bean.ScimUser, error) {
+	emailId, err := scimUserEmail(request)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := impl.userRepository.FetchActiveOrDeletedUserByEmail(emailId)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching user", "userName", emailId, "err", err)
+		return nil, err
+	}
+	if err == nil && existing.Active {
+		return nil, bean.NewScimError(http.StatusConflict, bean.ScimTypeUniqueness, fmt.Sprintf("user %s already exists", emailId))
+	}
+	_, err = impl.userService.CreateUser(&bean.UserInfo{
+		EmailId:     emailId,
+		RoleFilters: make([]bean.RoleFilter, 0),
+		Groups:      make([]string, 0),
+		UserId:      userId,
+	})
+	if err != nil {
+		impl.logger.Errorw("error in creating scim user", "userName", emailId, "err", err)
+		return nil, err
+	}
+	model, err := impl.userRepository.FetchActiveOrDeletedUserByEmail(emailId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching created user", "userName", emailId, "err", err)
+		return nil, err
+	}
+	if request.Active != nil && !*request.Active {
+		return impl.setUserActive(model, false, userId)
+	}
+	return impl.adaptScimUser(model)
+}
+
+// ReplaceUser only applies the active flag, devtron does not store the other attributes and the user name is immutable
+func (impl ScimServiceImpl) ReplaceUser(id int32, request *bean.ScimUser, userId int32) (*bean.ScimUser, error) {
+	model, err := impl.getUserModel(id)
+	if err != nil {
+		return nil, err
+	}
+	emailId, err := scimUserEmail(request)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(emailId, model.EmailId) {
+		return nil, bean.NewScimError(http.StatusBadRequest, bean.ScimTypeMutability, "userName can not be changed")
+	}
+	active := request.Active == nil || *request.Active
+	return impl.setUserActive(model, active, userId)
+}
+
+func (impl ScimServiceImpl) PatchUser(id int32, request *bean.ScimPatchRequest, userId int32) (*bean.ScimUser, error) {
+	model, err := impl.getUserModel(id)
+	if err != nil {
+		return nil, err
+	}
+	active := model.Active
+	for _, operation := range request.Operations {
+		op := strings.ToLower(operation.Op)
+		if op != "replace" && op != "add" {
+			continue
+		}
+		values := make(map[string]json.RawMessage)
+		if operation.Path != "" {
+			values[operation.Path] = operation.Value
+		} else if err := json.Unmarshal(operation.Value, &values); err != nil {
+			return nil, bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidValue, "value of operation without path must be an object")
+		}
+		for path, value := range values {
+			switch strings.ToLower(path) {
+			case "active":
+				active, err = parseScimBool(value)
+				if err != nil {
+					return nil, err
+				}
+			case "username":
+				var userName string
+				if err := json.Unmarshal(value, &userName); err != nil || !strings.EqualFold(userName, model.EmailId) {
+					return nil, bean.NewScimError(http.StatusBadRequest, bean.ScimTypeMutability, "userName can not be changed")
+				}
+			}
+		}
+	}
+	return impl.setUserActive(model, active, userId)
+}
+
+// DeleteUser deactivates the user, its roles and role group memberships are removed
+func (impl ScimServiceImpl) DeleteUser(id int32, userId int32) error {
+	model, err := impl.getUserModel(id)
+	if err != nil {
+		return err
+	}
+	if !model.Active {
+		return nil
+	}
+	_, err = impl.userService.DeleteUser(&bean.UserInfo{Id: model.Id, UserId: userId})
+	if err != nil {
+		impl.logger.Errorw("error in deleting scim user", "id", id, "err", err)
+	}
+	return err
+}
+
+func (impl ScimServiceImpl) setUserActive(model *repository2.UserModel, active bool, userId int32) (*bean.ScimUser, error) {
+	if model.Active == active {
+		return impl.adaptScimUser(model)
+	}
+	var err error
+	if active {
+		// creating a deleted user re-activates it
+		_, err = impl.userService.CreateUser(&bean.UserInfo{
+			EmailId:     model.EmailId,
+			RoleFilters: make([]bean.RoleFilter, 0),
+			Groups:      make([]string, 0),
+			UserId:      userId,
+		})
+	} else {
+		_, err = impl.userService.DeleteUser(&bean.UserInfo{Id: model.Id, UserId: userId})
+	}
+	if err != nil {
+		impl.logger.Errorw("error in updating active status of scim user", "id", model.Id, "active", active, "err", err)
+		return nil, err
+	}
+	model, err = impl.userRepository.GetByIdIncludeDeleted(model.Id)
+	if err != nil {
+		return nil, err
+	}
+	return impl.adaptScimUser(model)
+}
+
+func (impl ScimServiceImpl) getUserModel(id int32) (*repository2.UserModel, error) {
+	model, err := impl.userRepository.GetByIdIncludeDeleted(id)
+	if err == pg.ErrNoRows || (err == nil && IsApiTokenUser(model.EmailId)) {
+		return nil, bean.NewScimError(http.StatusNotFound, "", fmt.Sprintf("user %d not found", id))
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching user", "id", id, "err", err)
+		return nil, err
+	}
+	return model, nil
+}
+
+func (impl ScimServiceImpl) adaptScimUser(model *repository2.UserModel) (*bean.ScimUser, error) {
+	active := model.Active
+	user := &bean.ScimUser{
+		Schemas:  []string{bean.ScimUserSchema},
+		Id:       strconv.Itoa(int(model.Id)),
+		UserName: model.EmailId,
+		Emails:   []bean.ScimEmail{{Value: model.EmailId, Type: "work", Primary: true}},
+		Active:   &active,
+		Meta:     &bean.ScimMeta{ResourceType: "User", Location: fmt.Sprintf("Users/%d", model.Id)},
+	}
+	if !model.Active {
+		return user, nil
+	}
+	casbinRoles, err := casbin2.GetRolesForUser(model.EmailId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching casbin roles of user", "emailId", model.EmailId, "err", err)
+		return nil, err
+	}
+	var casbinNames []string
+	for _, role := range casbinRoles {
+		if strings.HasPrefix(role, "group:") {
+			casbinNames = append(casbinNames, role)
+		}
+	}
+	if len(casbinNames) == 0 {
+		return user, nil
+	}
+	roleGroups, err := impl.roleGroupRepository.GetRoleGroupListByCasbinNames(casbinNames)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching role groups of user", "emailId", model.EmailId, "err", err)
+		return nil, err
+	}
+	for _, roleGroup := range roleGroups {
+		user.Groups = append(user.Groups, bean.ScimMember{
+			Value:   strconv.Itoa(int(roleGroup.Id)),
+			Display: roleGroup.Name,
+			Ref:     fmt.Sprintf("Groups/%d", roleGroup.Id),
+		})
+	}
+	return user, nil
+}
+
+func (impl ScimServiceImpl) ListGroups(filter string, startIndex int, count int, excludeMembers bool) (*bean.ScimListResponse, error) {
+	var models []*repository2.RoleGroup
+	if filter != "" {
+		attribute, value, err := parseScimFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(attribute, "displayName") {
+			return nil, bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidFilter, fmt.Sprintf("filtering on %s is not supported", attribute))
+		}
+		model, err := impl.roleGroupRepository.GetRoleGroupByName(value)
+		if err != nil && err != pg.ErrNoRows {
+			impl.logger.Errorw("error in fetching role group", "displayName", value, "err", err)
+			return nil, err
+		}
+		if err == nil {
+			models = append(models, model)
+		}
+	} else {
+		roleGroups, err := impl.roleGroupRepository.GetAllRoleGroup()
+		if err != nil && err != pg.ErrNoRows {
+			impl.logger.Errorw("error in fetching role groups", "err", err)
+			return nil, err
+		}
+		models = roleGroups
+	}
+	page, startIndex := scimPage(len(models), startIndex, count)
+	resources := make([]interface{}, 0)
+	for _, model := range models[page[0]:page[1]] {
+		group, err := impl.adaptScimGroup(model, excludeMembers)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, group)
+	}
+	return scimListResponse(len(models), startIndex, resources), nil
+}
+
+func (impl ScimServiceImpl) GetGroup(id int32, excludeMembers bool) (*bean.ScimGroup, error) {
+	model, err := impl.getRoleGroupModel(id)
+	if err != nil {
+		return nil, err
+	}
+	return impl.adaptScimGroup(model, excludeMembers)
+}
+
+// CreateGroup creates a role group without roles, admins grant roles to it from devtron
+func (impl ScimServiceImpl) CreateGroup(request *bean.ScimGroup, userId int32) (*bean.ScimGroup, error) {
+	name := strings.TrimSpace(request.DisplayName)
+	if name == "" {
+		return nil, bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidValue, "displayName is required")
+	}
+	existing, err := impl.roleGroupRepository.GetRoleGroupByName(name)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching role group", "displayName", name, "err", err)
+		return nil, err
+	}
+	if err == nil && existing.Id > 0 {
+		return nil, bean.NewScimError(http.StatusConflict, bean.ScimTypeUniqueness, fmt.Sprintf("group %s already exists", name))
+	}
+	roleGroup, err := impl.roleGroupService.CreateRoleGroup(&bean.RoleGroup{
+		Name:        name,
+		RoleFilters: make([]bean.RoleFilter, 0),
+		UserId:      userId,
+	})
+	if err != nil {
+		impl.logger.Errorw("error in creating scim group", "displayName", name, "err", err)
+		return nil, err
+	}
+	model, err := impl.getRoleGroupModel(roleGroup.Id)
+	if err != nil {
+		return nil, err
+	}
+	err = impl.setMembers(model, request.Members)
+	if err != nil {
+		return nil, err
+	}
+	return impl.adaptScimGroup(model, false)
+}
+
+func (impl ScimServiceImpl) ReplaceGroup(id int32, request *bean.ScimGroup, userId int32) (*bean.ScimGroup, error) {
+	model, err := impl.getRoleGroupModel(id)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(strings.TrimSpace(request.DisplayName), model.Name) {
+		return nil, bean.NewScimError(http.StatusBadRequest, bean.ScimTypeMutability, "displayName can not be changed")
+	}
+	err = impl.setMembers(model, request.Members)
+	if err != nil {
+		return nil, err
+	}
+	return impl.adaptScimGroup(model, false)
+}
+
+func (impl ScimServiceImpl) PatchGroup(id int32, request *bean.ScimPatchRequest, userId int32) (*bean.ScimGroup, error) {
+	model, err := impl.getRoleGroupModel(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, operation := range request.Operations {
+		err = impl.applyGroupOperation(model, operation)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return impl.adaptScimGroup(model, false)
+}
+
+func (impl ScimServiceImpl) applyGroupOperation(model *repository2.RoleGroup, operation *bean.ScimPatchOperation) error {
+	op := strings.ToLower(operation.Op)
+	path := strings.TrimSpace(operation.Path)
+	if op == "remove" {
+		if matches := scimMemberPathRegex.FindStringSubmatch(path); matches != nil {
+			return impl.removeMembers(model, []bean.ScimMember{{Value: matches[1]}})
+		}
+		if !strings.EqualFold(path, "members") {
+			return bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidPath, fmt.Sprintf("path %s is not supported", path))
+		}
+		if len(operation.Value) == 0 {
+			return impl.setMembers(model, nil)
+		}
+		members, err := parseScimMembers(operation.Value)
+		if err != nil {
+			return err
+		}
+		return impl.removeMembers(model, members)
+	}
+	if op != "add" && op != "replace" {
+		return bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidValue, fmt.Sprintf("operation %s is not supported", operation.Op))
+	}
+	values := make(map[string]json.RawMessage)
+	if path != "" {
+		values[path] = operation.Value
+	} else if err := json.Unmarshal(operation.Value, &values); err != nil {
+		return bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidValue, "value of operation without path must be an object")
+	}
+	for path, value := range values {
+		switch strings.ToLower(path) {
+		case "members":
+			members, err := parseScimMembers(value)
+			if err != nil {
+				return err
+			}
+			if op == "add" {
+				err = impl.addMembers(model, members)
+			} else {
+				err = impl.setMembers(model, members)
+			}
+			if err != nil {
+				return err
+			}
+		case "displayname":
+			var displayName string
+			if err := json.Unmarshal(value, &displayName); err != nil || !strings.EqualFold(strings.TrimSpace(displayName), model.Name) {
+				return bean.NewScimError(http.StatusBadRequest, bean.ScimTypeMutability, "displayName can not be changed")
+			}
+		case "externalid", "id":
+		default:
+			return bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidPath, fmt.Sprintf("path %s is not supported", path))
+		}
+	}
+	return nil
+}
+
+func (impl ScimServiceImpl) DeleteGroup(id int32, userId int32) error {
+	model, err := impl.getRoleGroupModel(id)
+	if err != nil {
+		return err
+	}
+	// memberships are dropped first, a deleted role group must not keep granting its roles
+	err = impl.setMembers(model, nil)
+	if err != nil {
+		return err
+	}
+	_, err = impl.roleGroupService.DeleteRoleGroup(&bean.RoleGroup{Id: model.Id, UserId: userId})
+	if err != nil {
+		impl.logger.Errorw("error in deleting scim group", "id", id, "err", err)
+	}
+	return err
+}
+
+func (impl ScimServiceImpl) getRoleGroupModel(id int32) (*repository2.RoleGroup, error) {
+	model, err := impl.roleGroupRepository.GetRoleGroupById(id)
+	if err == pg.ErrNoRows {
+		return nil, bean.NewScimError(http.StatusNotFound, "", fmt.Sprintf("group %d not found", id))
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching role group", "id", id, "err", err)
+		return nil, err
+	}
+	return model, nil
+}
+
+// memberEmails returns the emails of the users of a role group, keyed by user id
+func (impl ScimServiceImpl) memberEmails(model *repository2.RoleGroup) (map[int32]string, error) {
+	emailIds, err := casbin2.GetUserByRole(model.CasbinName)
+	if err != nil {
+		impl.logger.Errorw("error in fetching users of role group", "casbinName", model.CasbinName, "err", err)
+		return nil, err
+	}
+	members := make(map[int32]string)
+	for _, emailId := range emailIds {
+		user, err := impl.userRepository.FetchActiveUserByEmail(emailId)
+		if err != nil || user.Id == 0 {
+			continue
+		}
+		members[user.Id] = user.EmailId
+	}
+	return members, nil
+}
+
+func (impl ScimServiceImpl) resolveMembers(members []bean.ScimMember) (map[int32]string, error) {
+	users := make(map[int32]string)
+	for _, member := range members {
+		id, err := strconv.Atoi(member.Value)
+		if err != nil {
+			return nil, bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidValue, fmt.Sprintf("invalid member %s", member.Value))
+		}
+		user, err := impl.userRepository.GetById(int32(id))
+		if err == pg.ErrNoRows || (err == nil && IsApiTokenUser(user.EmailId)) {
+			return nil, bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidValue, fmt.Sprintf("member %s not found", member.Value))
+		} else if err != nil {
+			return nil, err
+		}
+		users[user.Id] = user.EmailId
+	}
+	return users, nil
+}
+
+func (impl ScimServiceImpl) addMembers(model *repository2.RoleGroup, members []bean.ScimMember) error {
+	users, err := impl.resolveMembers(members)
+	if err != nil {
+		return err
+	}
+	current, err := impl.memberEmails(model)
+	if err != nil {
+		return err
+	}
+	var policies []casbin2.Policy
+	for id, emailId := range users {
+		if _, ok := current[id]; !ok {
+			policies = append(policies, casbin2.Policy{Type: "g", Sub: casbin2.Subject(emailId), Obj: casbin2.Object(model.CasbinName)})
+		}
+	}
+	if len(policies) > 0 {
+		casbin2.AddPolicy(policies)
+	}
+	return nil
+}
+
+func (impl ScimServiceImpl) removeMembers(model *repository2.RoleGroup, members []bean.ScimMember) error {
+	current, err := impl.memberEmails(model)
+	if err != nil {
+		return err
+	}
+	var policies []casbin2.Policy
+	for _, member := range members {
+		id, err := strconv.Atoi(member.Value)
+		if err != nil {
+			return bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidValue, fmt.Sprintf("invalid member %s", member.Value))
+		}
+		if emailId, ok := current[int32(id)]; ok {
+			policies = append(policies, casbin2.Policy{Type: "g", Sub: casbin2.Subject(emailId), Obj: casbin2.Object(model.CasbinName)})
+		}
+	}
+	if len(policies) > 0 {
+		casbin2.RemovePolicy(policies)
+	}
+	return nil
+}
+
+func (impl ScimServiceImpl) setMembers(model *repository2.RoleGroup, members []bean.ScimMember) error {
+	users, err := impl.resolveMembers(members)
+	if err != nil {
+		return err
+	}
+	current, err := impl.memberEmails(model)
+	if err != nil {
+		return err
+	}
+	var policies, policiesRemove []casbin2.Policy
+	for id, emailId := range users {
+		if _, ok := current[id]; !ok {
+			policies = append(policies, casbin2.Policy{Type: "g", Sub: casbin2.Subject(emailId), Obj: casbin2.Object(model.CasbinName)})
+		}
+	}
+	for id, emailId := range current {
+		if _, ok := users[id]; !ok {
+			policiesRemove = append(policiesRemove, casbin2.Policy{Type: "g", Sub: casbin2.Subject(emailId), Obj: casbin2.Object(model.CasbinName)})
+		}
+	}
+	if len(policiesRemove) > 0 {
+		casbin2.RemovePolicy(policiesRemove)
+	}
+	if len(policies) > 0 {
+		casbin2.AddPolicy(policies)
+	}
+	return nil
+}
+
+func (impl ScimServiceImpl) adaptScimGroup(model *repository2.RoleGroup, excludeMembers bool) (*bean.ScimGroup, error) {
+	group := &bean.ScimGroup{
+		Schemas:     []string{bean.ScimGroupSchema},
+		Id:          strconv.Itoa(int(model.Id)),
+		DisplayName: model.Name,
+		Members:     make([]bean.ScimMember, 0),
+		Meta:        &bean.ScimMeta{ResourceType: "Group", Location: fmt.Sprintf("Groups/%d", model.Id)},
+	}
+	if excludeMembers {
+		return group, nil
+	}
+	members, err := impl.memberEmails(model)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	for id := range members {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		group.Members = append(group.Members, bean.ScimMember{
+			Value:   strconv.Itoa(id),
+			Display: members[int32(id)],
+			Ref:     fmt.Sprintf("Users/%d", id),
+		})
+	}
+	return group, nil
+}
+
+func parseScimFilter(filter string) (attribute string, value string, err error) {
+	matches := scimFilterRegex.FindStringSubmatch(filter)
+	if matches == nil {
+		return "", "", bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidFilter, "only filters of the form <attribute> eq \"<value>\" are supported")
+	}
+	value, err = strconv.Unquote(`"` + matches[2] + `"`)
+	if err != nil {
+		return "", "", bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidFilter, "invalid filter value")
+	}
+	return matches[1], value, nil
+}
+
+// parseScimBool accepts booleans and, as sent by azure ad, their string form
+func parseScimBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		if b, err := strconv.ParseBool(strings.ToLower(s)); err == nil {
+			return b, nil
+		}
+	}
+	return false, bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidValue, "active must be a boolean")
+}
+
+func parseScimMembers(value json.RawMessage) ([]bean.ScimMember, error) {
+	var members []bean.ScimMember
+	if err := json.Unmarshal(value, &members); err != nil {
+		var member bean.ScimMember
+		if err := json.Unmarshal(value, &member); err != nil {
+			return nil, bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidValue, "members must be a list of {\"value\": \"<user id>\"}")
+		}
+		members = []bean.ScimMember{member}
+	}
+	return members, nil
+}
+
+func scimUserEmail(request *bean.ScimUser) (string, error) {
+	emailId := strings.TrimSpace(request.UserName)
+	if emailId == "" {
+		for _, email := range request.Emails {
+			if email.Primary || emailId == "" {
+				emailId = strings.TrimSpace(email.Value)
+			}
+		}
+	}
+	if emailId == "" || strings.Contains(emailId, ",") || IsApiTokenUser(emailId) {
+		return "", bean.NewScimError(http.StatusBadRequest, bean.ScimTypeInvalidValue, "userName must be the email of the user")
+	}
+	return emailId, nil
+}
+
+// scimPage returns bounds of the requested page, startIndex is 1-based as per rfc7644 and a negative count
+// stands for the default page size
+func scimPage(total int, startIndex int, count int) ([2]int, int) {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count < 0 || count > scimDefaultCount {
+		count = scimDefaultCount
+	}
+	from := startIndex - 1
+	if from > total {
+		from = total
+	}
+	to := from + count
+	if to > total {
+		to = total
+	}
+	return [2]int{from, to}, startIndex
+}
+
+func scimListResponse(total int, startIndex int, resources []interface{}) *bean.ScimListResponse {
+	return &bean.ScimListResponse{
+		Schemas:      []string{bean.ScimListSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseScimFilter(t *testing.T) {
+	attribute, value, err := parseScimFilter(`userName eq "jane@example.com"`)
+	if err != nil || attribute != "userName" || value != "jane@example.com" {
+		t.Errorf("unexpected parse result %q %q %v", attribute, value, err)
+	}
+	if _, _, err := parseScimFilter(`userName sw "jane"`); err == nil {
+		t.Errorf("expected error for unsupported operator")
+	}
+}
+
+func TestParseScimBool(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    bool
+		wantErr bool
+	}{
+		{`true`, true, false},
+		{`false`, false, false},
+		{`"False"`, false, false},
+		{`"True"`, true, false},
+		{`"yes please"`, false, true},
+	}
+	for _, tt := range tests {
+		got, err := parseScimBool(json.RawMessage(tt.value))
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseScimBool(%s) = %v, %v", tt.value, got, err)
+		}
+	}
+}
+
+func TestScimPage(t *testing.T) {
+	tests := []struct {
+		total, startIndex, count int
+		want                     [2]int
+	}{
+		{10, 1, -1, [2]int{0, 10}},
+		{10, 0, 3, [2]int{0, 3}},
+		{10, 4, 3, [2]int{3, 6}},
+		{10, 9, 5, [2]int{8, 10}},
+		{10, 20, 5, [2]int{10, 10}},
+		{10, 1, 0, [2]int{0, 0}},
+	}
+	for _, tt := range tests {
+		if got, _ := scimPage(tt.total, tt.startIndex, tt.count); got != tt.want {
+			t.Errorf("scimPage(%d, %d, %d) = %v, want %v", tt.total, tt.startIndex, tt.count, got, tt.want)
+		}
+	}
+}
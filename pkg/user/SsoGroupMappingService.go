@@ -0,0 +1,525 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"fmt"
+	"github.com/devtron-labs/devtron/api/bean"
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
+	casbin2 "github.com/devtron-labs/devtron/pkg/user/casbin"
+	repository2 "github.com/devtron-labs/devtron/pkg/user/repository"
+	"github.com/go-pg/pg"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ssoSyncUserId is recorded as creator of the users provisioned on sso login
+const ssoSyncUserId = 1
+
+type SsoGroupMappingService interface {
+	CreateMapping(request *bean.SsoGroupMapping) (*bean.SsoGroupMapping, error)
+	UpdateMapping(request *bean.SsoGroupMapping) (*bean.SsoGroupMapping, error)
+	DeleteMapping(id int, userId int32) error
+	GetAllMappings() ([]*bean.SsoGroupMapping, error)
+	HasMappings() bool
+	MatchesMapping(ssoGroups []string) bool
+	SyncUserGroups(emailId string, ssoGroups []string) error
+	SyncUserGroupsOnce(tokenHash string, emailId string, ssoGroups []string) error
+}
+
+type SsoGroupMappingServiceImpl struct {
+	logger                       *zap.SugaredLogger
+	ssoGroupMappingRepository    repository2.SsoGroupMappingRepository
+	ssoGroupMembershipRepository repository2.SsoGroupMembershipRepository
+	roleGroupRepository          repository2.RoleGroupRepository
+	userRepository               repository2.UserRepository
+	auditLogService              auditLog.AuditLogService
+	syncedTokens                 *cache.Cache
+}
+
+func NewSsoGroupMappingServiceImpl(logger *zap.SugaredLogger, ssoGroupMappingRepository repository2.SsoGroupMappingRepository,
+	ssoGroupMembershipRepository repository2.SsoGroupMembershipRepository,
+	roleGroupRepository repository2.RoleGroupRepository, userRepository repository2.UserRepository,
+	auditLogService auditLog.AuditLogService) *SsoGroupMappingServiceImpl {
+	return &SsoGroupMappingServiceImpl{
+		logger:                       logger,
+		ssoGroupMappingRepository:    ssoGroupMappingRepository,
+		ssoGroupMembershipRepository: ssoGroupMembershipRepository,
+		roleGroupRepository:          roleGroupRepository,
+		userRepository:               userRepository,
+		auditLogService:              auditLogService,
+		syncedTokens:                 cache.New(24*time.Hour, time.Hour),
+	}
+}
+
+func (impl SsoGroupMappingServiceImpl) CreateMapping(request *bean.SsoGroupMapping) (*bean.SsoGroupMapping, error) {
+	request.SsoGroup = strings.TrimSpace(request.SsoGroup)
+	roleGroup, err := impl.validateMapping(request)
+	if err != nil {
+		return nil, err
+	}
+	model := &repository2.SsoGroupMapping{
+		SsoGroup:    request.SsoGroup,
+		RoleGroupId: request.RoleGroupId,
+		Active:      true,
+	}
+	model.CreatedBy = request.UserId
+	model.CreatedOn = time.Now()
+	model.UpdatedBy = request.UserId
+	model.UpdatedOn = time.Now()
+	err = impl.ssoGroupMappingRepository.Save(model)
+	if err != nil {
+		impl.logger.Errorw("error in saving sso group mapping", "ssoGroup", request.SsoGroup, "err", err)
+		return nil, err
+	}
+	model.RoleGroup = roleGroup
+	return adaptSsoGroupMapping(model), nil
+}
+
+func (impl SsoGroupMappingServiceImpl) UpdateMapping(request *bean.SsoGroupMapping) (*bean.SsoGroupMapping, error) {
+	model, err := impl.ssoGroupMappingRepository.FindActiveById(request.Id)
+	if err == pg.ErrNoRows {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusNotFound, UserMessage: "sso group mapping not found"}
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching sso group mapping", "id", request.Id, "err", err)
+		return nil, err
+	}
+	request.SsoGroup = strings.TrimSpace(request.SsoGroup)
+	roleGroup, err := impl.validateMapping(request)
+	if err != nil {
+		return nil, err
+	}
+	// members of the old sso group or role group lose the role group, members of the new ones get it on login
+	previous := *model
+	retargeted := model.RoleGroupId != request.RoleGroupId || !strings.EqualFold(model.SsoGroup, request.SsoGroup)
+	model.SsoGroup = request.SsoGroup
+	model.RoleGroupId = request.RoleGroupId
+	model.UpdatedBy = request.UserId
+	model.UpdatedOn = time.Now()
+	err = impl.ssoGroupMappingRepository.Update(model)
+	if err != nil {
+		impl.logger.Errorw("error in updating sso group mapping", "id", request.Id, "err", err)
+		return nil, err
+	}
+	if retargeted {
+		err = impl.revokeMemberships(&previous, request.UserId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	model.RoleGroup = roleGroup
+	return adaptSsoGroupMapping(model), nil
+}
+
+// validateMapping checks that the role group exists and the sso group is not already mapped to it
+func (impl SsoGroupMappingServiceImpl) validateMapping(request *bean.SsoGroupMapping) (*repository2.RoleGroup, error) {
+	if request.SsoGroup == "" {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: "ssoGroup is required"}
+	}
+	roleGroup, err := impl.roleGroupRepository.GetRoleGroupById(request.RoleGroupId)
+	if err == pg.ErrNoRows || (err == nil && !roleGroup.Active) {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusBadRequest, UserMessage: fmt.Sprintf("role group %d not found", request.RoleGroupId)}
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching role group", "id", request.RoleGroupId, "err", err)
+		return nil, err
+	}
+	existing, err := impl.ssoGroupMappingRepository.FindActiveBySsoGroupAndRoleGroupId(request.SsoGroup, request.RoleGroupId)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching sso group mapping", "ssoGroup", request.SsoGroup, "err", err)
+		return nil, err
+	}
+	if existing != nil && existing.Id > 0 && existing.Id != request.Id {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusConflict, UserMessage: fmt.Sprintf("sso group %s is already mapped to role group %s", request.SsoGroup, roleGroup.Name)}
+	}
+	return roleGroup, nil
+}
+
+// DeleteMapping deactivates the mapping and removes the role group from the users it was granted to
+func (impl SsoGroupMappingServiceImpl) DeleteMapping(id int, userId int32) error {
+	model, err := impl.ssoGroupMappingRepository.FindActiveById(id)
+	if err == pg.ErrNoRows {
+		return &util.ApiError{HttpStatusCode: http.StatusNotFound, UserMessage: "sso group mapping not found"}
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching sso group mapping", "id", id, "err", err)
+		return err
+	}
+	model.Active = false
+	model.UpdatedBy = userId
+	model.UpdatedOn = time.Now()
+	err = impl.ssoGroupMappingRepository.Update(model)
+	if err != nil {
+		impl.logger.Errorw("error in deleting sso group mapping", "id", id, "err", err)
+		return err
+	}
+	return impl.revokeMemberships(model, userId)
+}
+
+// revokeMemberships removes the role group of the mapping from the users it was granted to through the mapping,
+// users to whom another mapping grants the same role group keep it
+func (impl SsoGroupMappingServiceImpl) revokeMemberships(mapping *repository2.SsoGroupMapping, userId int32) error {
+	memberships, err := impl.ssoGroupMembershipRepository.FindActiveByRoleGroupId(mapping.RoleGroupId)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching sso group memberships", "roleGroupId", mapping.RoleGroupId, "err", err)
+		return err
+	}
+	revoked, kept := splitMemberships(mapping.Id, memberships)
+	if len(revoked) == 0 {
+		return nil
+	}
+	roleGroup := mapping.RoleGroup
+	if roleGroup == nil {
+		roleGroup, err = impl.roleGroupRepository.GetRoleGroupById(mapping.RoleGroupId)
+		if err != nil {
+			impl.logger.Errorw("error in fetching role group", "id", mapping.RoleGroupId, "err", err)
+			return err
+		}
+	}
+	var policies []casbin2.Policy
+	var emailIds []string
+	for _, membership := range revoked {
+		err = impl.deactivateMembership(membership, userId)
+		if err != nil {
+			return err
+		}
+		if kept[membership.UserId] {
+			continue
+		}
+		user, err := impl.userRepository.GetByIdIncludeDeleted(membership.UserId)
+		if err != nil {
+			impl.logger.Errorw("error in fetching user of sso group membership", "userId", membership.UserId, "err", err)
+			return err
+		}
+		policies = append(policies, casbin2.Policy{Type: "g", Sub: casbin2.Subject(user.EmailId), Obj: casbin2.Object(roleGroup.CasbinName)})
+		emailIds = append(emailIds, user.EmailId)
+	}
+	if len(policies) > 0 {
+		casbin2.RemovePolicy(policies)
+		impl.logger.Infow("revoked role group of sso group mapping", "mappingId", mapping.Id, "roleGroup", roleGroup.Name, "users", emailIds)
+	}
+	return nil
+}
+
+func (impl SsoGroupMappingServiceImpl) deactivateMembership(membership *repository2.SsoGroupMembership, userId int32) error {
+	membership.Active = false
+	membership.UpdatedBy = userId
+	membership.UpdatedOn = time.Now()
+	err := impl.ssoGroupMembershipRepository.Update(membership)
+	if err != nil {
+		impl.logger.Errorw("error in deactivating sso group membership", "id", membership.Id, "err", err)
+	}
+	return err
+}
+
+func (impl SsoGroupMappingServiceImpl) GetAllMappings() ([]*bean.SsoGroupMapping, error) {
+	models, err := impl.ssoGroupMappingRepository.FindAllActive()
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching sso group mappings", "err", err)
+		return nil, err
+	}
+	mappings := make([]*bean.SsoGroupMapping, 0, len(models))
+	for _, model := range models {
+		mappings = append(mappings, adaptSsoGroupMapping(model))
+	}
+	return mappings, nil
+}
+
+// HasMappings tells if users unknown to devtron can be provisioned on sso login
+func (impl SsoGroupMappingServiceImpl) HasMappings() bool {
+	models, err := impl.ssoGroupMappingRepository.FindAllActive()
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching sso group mappings", "err", err)
+		return false
+	}
+	return len(models) > 0
+}
+
+// MatchesMapping tells if one of the sso groups is mapped to a role group, users unknown to devtron
+// need such a group to log in
+func (impl SsoGroupMappingServiceImpl) MatchesMapping(ssoGroups []string) bool {
+	models, err := impl.ssoGroupMappingRepository.FindAllActive()
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching sso group mappings", "err", err)
+		return false
+	}
+	return len(matchedMappings(models, ssoGroups)) > 0
+}
+
+// SyncUserGroupsOnce syncs the groups of a login token only the first time the token is seen
+func (impl SsoGroupMappingServiceImpl) SyncUserGroupsOnce(tokenHash string, emailId string, ssoGroups []string) error {
+	if _, found := impl.syncedTokens.Get(tokenHash); found {
+		return nil
+	}
+	err := impl.SyncUserGroups(emailId, ssoGroups)
+	if err != nil {
+		return err
+	}
+	impl.syncedTokens.SetDefault(tokenHash, true)
+	return nil
+}
+
+// SyncUserGroups provisions the user if one of its sso groups is mapped and makes its role group memberships
+// match its sso groups. Only role groups granted through an sso group membership are removed, memberships granted
+// manually are left untouched even when a mapping targets the same role group.
+func (impl SsoGroupMappingServiceImpl) SyncUserGroups(emailId string, ssoGroups []string) error {
+	if emailId == "" || IsApiTokenUser(emailId) {
+		return nil
+	}
+	mappings, err := impl.ssoGroupMappingRepository.FindAllActive()
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching sso group mappings", "err", err)
+		return err
+	}
+	if len(mappings) == 0 {
+		return nil
+	}
+	matched := matchedMappings(mappings, ssoGroups)
+
+	user, err := impl.userRepository.FetchActiveOrDeletedUserByEmail(emailId)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching user", "emailId", emailId, "err", err)
+		return err
+	}
+	if err == pg.ErrNoRows || !user.Active {
+		if len(matched) == 0 {
+			return nil
+		}
+		user, err = impl.provisionUser(emailId, user)
+		if err != nil {
+			return err
+		}
+	}
+
+	memberships, err := impl.ssoGroupMembershipRepository.FindActiveByUserId(user.Id)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching sso group memberships", "userId", user.Id, "err", err)
+		return err
+	}
+	current, err := casbin2.GetRolesForUser(user.EmailId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching casbin roles of user", "emailId", user.EmailId, "err", err)
+		return err
+	}
+	managed, target := mappedRoleGroups(mappings, memberships, ssoGroups)
+	add, remove := diffRoleGroups(current, managed, target)
+	var policies []casbin2.Policy
+	for _, casbinName := range add {
+		policies = append(policies, casbin2.Policy{Type: "g", Sub: casbin2.Subject(user.EmailId), Obj: casbin2.Object(casbinName)})
+	}
+	var policiesRemove []casbin2.Policy
+	for _, casbinName := range remove {
+		policiesRemove = append(policiesRemove, casbin2.Policy{Type: "g", Sub: casbin2.Subject(user.EmailId), Obj: casbin2.Object(casbinName)})
+	}
+	if len(policiesRemove) > 0 {
+		casbin2.RemovePolicy(policiesRemove)
+	}
+	if len(policies) > 0 {
+		casbin2.AddPolicy(policies)
+	}
+	if len(add) > 0 || len(remove) > 0 {
+		impl.logger.Infow("synced role groups of sso user", "emailId", user.EmailId, "added", add, "removed", remove)
+	}
+	return impl.syncMemberships(user.Id, memberships, grantedMappings(matched, current, managed))
+}
+
+// syncMemberships records the memberships granted through the matched mappings and closes the ones
+// of mappings the user no longer matches
+func (impl SsoGroupMappingServiceImpl) syncMemberships(userId int32, memberships []*repository2.SsoGroupMembership, matched []*repository2.SsoGroupMapping) error {
+	existing := make(map[int]bool)
+	for _, membership := range memberships {
+		existing[membership.SsoGroupMappingId] = true
+	}
+	matchedIds := make(map[int]bool)
+	for _, mapping := range matched {
+		matchedIds[mapping.Id] = true
+		if existing[mapping.Id] {
+			continue
+		}
+		model := &repository2.SsoGroupMembership{
+			SsoGroupMappingId: mapping.Id,
+			UserId:            userId,
+			RoleGroupId:       mapping.RoleGroupId,
+			Active:            true,
+		}
+		model.CreatedBy = ssoSyncUserId
+		model.CreatedOn = time.Now()
+		model.UpdatedBy = ssoSyncUserId
+		model.UpdatedOn = time.Now()
+		err := impl.ssoGroupMembershipRepository.Save(model)
+		if err != nil {
+			impl.logger.Errorw("error in saving sso group membership", "userId", userId, "mappingId", mapping.Id, "err", err)
+			return err
+		}
+	}
+	for _, membership := range memberships {
+		if matchedIds[membership.SsoGroupMappingId] {
+			continue
+		}
+		err := impl.deactivateMembership(membership, ssoSyncUserId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// provisionUser creates the user, or re-activates it when it was deleted earlier
+func (impl SsoGroupMappingServiceImpl) provisionUser(emailId string, model *repository2.UserModel) (*repository2.UserModel, error) {
+	dbConnection := impl.userRepository.GetConnection()
+	tx, err := dbConnection.Begin()
+	if err != nil {
+		return nil, err
+	}
+	// Rollback tx on error.
+	defer tx.Rollback()
+
+	if model != nil && model.Id > 0 {
+		model.Active = true
+		model.UpdatedBy = ssoSyncUserId
+		model.UpdatedOn = time.Now()
+		model, err = impl.userRepository.UpdateUser(model, tx)
+	} else {
+		model = &repository2.UserModel{EmailId: emailId, Active: true}
+		model.CreatedBy = ssoSyncUserId
+		model.CreatedOn = time.Now()
+		model.UpdatedBy = ssoSyncUserId
+		model.UpdatedOn = time.Now()
+		model, err = impl.userRepository.CreateUser(model, tx)
+	}
+	if err != nil {
+		impl.logger.Errorw("error in provisioning sso user", "emailId", emailId, "err", err)
+		return nil, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	impl.logger.Infow("provisioned user on sso login", "emailId", emailId, "id", model.Id)
+	impl.auditLogService.SaveEvent(&auditLog.AuditEvent{
+		UserId:       ssoSyncUserId,
+		ResourceType: auditLog.ResourceUser,
+		ResourceId:   strconv.Itoa(int(model.Id)),
+		Action:       auditLog.ActionCreate,
+		After:        &bean.UserInfo{Id: model.Id, EmailId: model.EmailId},
+	})
+	return model, nil
+}
+
+// mappedRoleGroups returns casbin names of the role groups the user holds through its sso group memberships and of
+// those mapped from the given sso groups, sso group names are matched case insensitively
+func mappedRoleGroups(mappings []*repository2.SsoGroupMapping, memberships []*repository2.SsoGroupMembership, ssoGroups []string) (managed map[string]bool, target map[string]bool) {
+	casbinNames := make(map[int32]string)
+	for _, mapping := range mappings {
+		if mapping.RoleGroup != nil {
+			casbinNames[mapping.RoleGroupId] = mapping.RoleGroup.CasbinName
+		}
+	}
+	managed = make(map[string]bool)
+	for _, membership := range memberships {
+		if casbinName, ok := casbinNames[membership.RoleGroupId]; ok {
+			managed[casbinName] = true
+		}
+	}
+	target = make(map[string]bool)
+	for _, mapping := range matchedMappings(mappings, ssoGroups) {
+		target[mapping.RoleGroup.CasbinName] = true
+	}
+	return managed, target
+}
+
+// grantedMappings drops the matched mappings of role groups the user already holds without an sso group membership,
+// recording a membership for those would make a manual grant removable by a later sync
+func grantedMappings(matched []*repository2.SsoGroupMapping, current []string, managed map[string]bool) []*repository2.SsoGroupMapping {
+	manual := make(map[string]bool)
+	for _, role := range current {
+		if !managed[role] {
+			manual[role] = true
+		}
+	}
+	var granted []*repository2.SsoGroupMapping
+	for _, mapping := range matched {
+		if !manual[mapping.RoleGroup.CasbinName] {
+			granted = append(granted, mapping)
+		}
+	}
+	return granted
+}
+
+// matchedMappings returns the mappings of the given sso groups, sso group names are matched case insensitively
+func matchedMappings(mappings []*repository2.SsoGroupMapping, ssoGroups []string) []*repository2.SsoGroupMapping {
+	groups := make(map[string]bool)
+	for _, group := range ssoGroups {
+		groups[strings.ToLower(strings.TrimSpace(group))] = true
+	}
+	var matched []*repository2.SsoGroupMapping
+	for _, mapping := range mappings {
+		if mapping.RoleGroup != nil && groups[strings.ToLower(mapping.SsoGroup)] {
+			matched = append(matched, mapping)
+		}
+	}
+	return matched
+}
+
+// splitMemberships returns the memberships of a role group granted through the mapping and the users
+// to whom another mapping grants the role group as well
+func splitMemberships(mappingId int, memberships []*repository2.SsoGroupMembership) (revoked []*repository2.SsoGroupMembership, kept map[int32]bool) {
+	kept = make(map[int32]bool)
+	for _, membership := range memberships {
+		if membership.SsoGroupMappingId == mappingId {
+			revoked = append(revoked, membership)
+		} else {
+			kept[membership.UserId] = true
+		}
+	}
+	return revoked, kept
+}
+
+// diffRoleGroups returns the target role groups the user is not member of and the managed ones it no longer belongs to
+func diffRoleGroups(current []string, managed map[string]bool, target map[string]bool) (add []string, remove []string) {
+	currentMap := make(map[string]bool)
+	for _, role := range current {
+		currentMap[role] = true
+		if managed[role] && !target[role] {
+			remove = append(remove, role)
+		}
+	}
+	for casbinName := range target {
+		if !currentMap[casbinName] {
+			add = append(add, casbinName)
+		}
+	}
+	sort.Strings(add)
+	return add, remove
+}
+
+func adaptSsoGroupMapping(model *repository2.SsoGroupMapping) *bean.SsoGroupMapping {
+	mapping := &bean.SsoGroupMapping{
+		Id:          model.Id,
+		SsoGroup:    model.SsoGroup,
+		RoleGroupId: model.RoleGroupId,
+	}
+	if model.RoleGroup != nil {
+		mapping.RoleGroupName = model.RoleGroup.Name
+	}
+	return mapping
+}
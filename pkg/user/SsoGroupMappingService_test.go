@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"reflect"
+	"testing"
+
+	repository2 "github.com/devtron-labs/devtron/pkg/user/repository"
+)
+
+func TestSyncRoleGroups(t *testing.T) {
+	mappings := []*repository2.SsoGroupMapping{
+		{Id: 1, SsoGroup: "Platform", RoleGroupId: 1, RoleGroup: &repository2.RoleGroup{CasbinName: "group:platform"}},
+		{Id: 2, SsoGroup: "dev", RoleGroupId: 2, RoleGroup: &repository2.RoleGroup{CasbinName: "group:dev"}},
+		{Id: 3, SsoGroup: "oncall", RoleGroupId: 2, RoleGroup: &repository2.RoleGroup{CasbinName: "group:dev"}},
+		{Id: 4, SsoGroup: "qa", RoleGroupId: 4, RoleGroup: &repository2.RoleGroup{CasbinName: "group:qa"}},
+	}
+	tests := []struct {
+		name        string
+		ssoGroups   []string
+		current     []string
+		memberships []*repository2.SsoGroupMembership
+		wantAdd     []string
+		wantRemove  []string
+		wantGranted []int
+	}{
+		{name: "new user",
+			ssoGroups:   []string{"platform", "dev"},
+			wantAdd:     []string{"group:dev", "group:platform"},
+			wantGranted: []int{1, 2},
+		},
+		{name: "group removed in idp",
+			ssoGroups:   []string{"dev"},
+			current:     []string{"group:dev", "group:qa"},
+			memberships: []*repository2.SsoGroupMembership{{SsoGroupMappingId: 2, RoleGroupId: 2}, {SsoGroupMappingId: 4, RoleGroupId: 4}},
+			wantRemove:  []string{"group:qa"},
+			wantGranted: []int{2},
+		},
+		{name: "manual memberships kept",
+			ssoGroups:   []string{"oncall"},
+			current:     []string{"group:manual", "role:super-admin___"},
+			wantAdd:     []string{"group:dev"},
+			wantGranted: []int{3},
+		},
+		{name: "manually granted mapped group kept",
+			current: []string{"group:qa"},
+		},
+		{name: "manually granted mapped group not recorded as sso membership",
+			ssoGroups: []string{"qa"},
+			current:   []string{"group:qa"},
+		},
+		{name: "no groups claim",
+			current:     []string{"group:platform", "group:manual"},
+			memberships: []*repository2.SsoGroupMembership{{SsoGroupMappingId: 1, RoleGroupId: 1}},
+			wantRemove:  []string{"group:platform"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			managed, target := mappedRoleGroups(mappings, tt.memberships, tt.ssoGroups)
+			add, remove := diffRoleGroups(tt.current, managed, target)
+			if !reflect.DeepEqual(add, tt.wantAdd) {
+				t.Errorf("diffRoleGroups() add = %v, want %v", add, tt.wantAdd)
+			}
+			if !reflect.DeepEqual(remove, tt.wantRemove) {
+				t.Errorf("diffRoleGroups() remove = %v, want %v", remove, tt.wantRemove)
+			}
+			var granted []int
+			for _, mapping := range grantedMappings(matchedMappings(mappings, tt.ssoGroups), tt.current, managed) {
+				granted = append(granted, mapping.Id)
+			}
+			if !reflect.DeepEqual(granted, tt.wantGranted) {
+				t.Errorf("grantedMappings() = %v, want %v", granted, tt.wantGranted)
+			}
+		})
+	}
+}
+
+func TestMatchedMappings(t *testing.T) {
+	mappings := []*repository2.SsoGroupMapping{
+		{Id: 1, SsoGroup: "Platform", RoleGroup: &repository2.RoleGroup{CasbinName: "group:platform"}},
+		{Id: 2, SsoGroup: "dev", RoleGroup: &repository2.RoleGroup{CasbinName: "group:dev"}},
+		{Id: 3, SsoGroup: "qa"},
+	}
+	var ids []int
+	for _, mapping := range matchedMappings(mappings, []string{" platform", "qa", "unmapped"}) {
+		ids = append(ids, mapping.Id)
+	}
+	if !reflect.DeepEqual(ids, []int{1}) {
+		t.Errorf("matchedMappings() = %v, want [1]", ids)
+	}
+	if matched := matchedMappings(mappings, []string{"unmapped"}); len(matched) != 0 {
+		t.Errorf("matchedMappings() of unmapped groups = %v, want none", matched)
+	}
+}
+
+func TestSplitMemberships(t *testing.T) {
+	memberships := []*repository2.SsoGroupMembership{
+		{Id: 1, SsoGroupMappingId: 10, UserId: 1},
+		{Id: 2, SsoGroupMappingId: 10, UserId: 2},
+		{Id: 3, SsoGroupMappingId: 11, UserId: 2},
+		{Id: 4, SsoGroupMappingId: 11, UserId: 3},
+	}
+	revoked, kept := splitMemberships(10, memberships)
+	var ids []int
+	for _, membership := range revoked {
+		ids = append(ids, membership.Id)
+	}
+	if !reflect.DeepEqual(ids, []int{1, 2}) {
+		t.Errorf("splitMemberships() revoked = %v, want [1 2]", ids)
+	}
+	if kept[1] || !kept[2] || !kept[3] {
+		t.Errorf("splitMemberships() kept = %v, want users 2 and 3", kept)
+	}
+}
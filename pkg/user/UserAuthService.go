@@ -40,6 +40,7 @@ import (
 	"github.com/devtron-labs/devtron/internal/constants"
 	"github.com/devtron-labs/devtron/internal/util"
 	"github.com/devtron-labs/devtron/pkg/auth"
+	util2 "github.com/devtron-labs/devtron/util"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/gorilla/sessions"
 	"go.uber.org/zap"
@@ -48,6 +49,8 @@ import (
 
 type UserAuthService interface {
 	HandleLogin(username string, password string) (string, error)
+	// HandleUserLogin logs in users of the session api, see LocalUserAuthService for non admin users
+	HandleUserLogin(username string, password string, otp string) (string, error)
 	HandleDexCallback(w http.ResponseWriter, r *http.Request)
 	HandleRefresh(w http.ResponseWriter, r *http.Request)
 
@@ -62,6 +65,10 @@ type UserAuthServiceImpl struct {
 	logger         *zap.SugaredLogger
 	userRepository repository2.UserRepository
 	sessionManager *middleware.SessionManager
+
+	ssoGroupMappingService SsoGroupMappingService
+	localUserAuthService   LocalUserAuthService
+	userSessionService     UserSessionService
 }
 
 var (
@@ -100,19 +107,27 @@ type DexConfig struct {
 	JwtExpirationTime    int    `env:"JwtExpirationTime" envDefault:"120"`
 }
 
+// AdminUsername is the built-in admin, its password is managed by argocd
+const AdminUsername = "admin"
+
 type WebhookToken struct {
 	WebhookToken string `env:"WEBHOOK_TOKEN" envDefault:""`
 }
 
 func NewUserAuthServiceImpl(userAuthRepository repository2.UserAuthRepository, sessionManager *middleware.SessionManager,
 	client session2.ServiceClient, logger *zap.SugaredLogger, userRepository repository2.UserRepository,
+	ssoGroupMappingService SsoGroupMappingService, localUserAuthService LocalUserAuthService,
+	userSessionService UserSessionService,
 ) *UserAuthServiceImpl {
 	serviceImpl := &UserAuthServiceImpl{
-		userAuthRepository: userAuthRepository,
-		sessionManager:     sessionManager,
-		sessionClient:      client,
-		logger:             logger,
-		userRepository:     userRepository,
+		userAuthRepository:     userAuthRepository,
+		sessionManager:         sessionManager,
+		sessionClient:          client,
+		logger:                 logger,
+		userRepository:         userRepository,
+		ssoGroupMappingService: ssoGroupMappingService,
+		localUserAuthService:   localUserAuthService,
+		userSessionService:     userSessionService,
 	}
 	cStore = sessions.NewCookieStore(randKey())
 	return serviceImpl
@@ -254,6 +269,14 @@ func (impl UserAuthServiceImpl) HandleLogin(username string, password string) (s
 	return impl.sessionClient.Create(context.Background(), username, password)
 }
 
+// HandleUserLogin logs in the built-in admin through argocd, other users through their local password when enabled
+func (impl UserAuthServiceImpl) HandleUserLogin(username string, password string, otp string) (string, error) {
+	if username != AdminUsername && impl.localUserAuthService.IsEnabled() {
+		return impl.localUserAuthService.Login(username, password, otp)
+	}
+	return impl.HandleLogin(username, password)
+}
+
 func (impl UserAuthServiceImpl) HandleDexCallback(w http.ResponseWriter, r *http.Request) {
 	state := r.URL.Query().Get("state")
 	session, _ := cStore.Get(r, "JWT_TOKEN")
@@ -311,6 +334,13 @@ func (impl UserAuthServiceImpl) HandleDexCallback(w http.ResponseWriter, r *http
 	if err != nil {
 		return
 	}
+	// sync role group memberships with the groups claim of the identity provider
+	err = impl.ssoGroupMappingService.SyncUserGroups(Claims.Email, Claims.Groups)
+	if err != nil {
+		impl.logger.Errorw("error in syncing sso groups of user", "email", Claims.Email, "err", err)
+	} else {
+		dbUser, err = impl.userRepository.FetchUserDetailByEmail(Claims.Email)
+	}
 
 	// Declare the expiration time of the token
 	// here, we have kept it as 5 minutes
@@ -518,6 +548,19 @@ func (impl UserAuthServiceImpl) AuthVerification(r *http.Request) (bool, error)
 		}
 		return false, err
 	}
+	valid, err := impl.userSessionService.ValidateSession(token, util2.GetClientIP(r), r.UserAgent())
+	if err != nil {
+		impl.logger.Errorw("failed to validate session", "error", err)
+		return false, err
+	} else if !valid {
+		err := &util.ApiError{
+			HttpStatusCode:  http.StatusUnauthorized,
+			Code:            constants.UserSessionRevoked,
+			InternalMessage: "session revoked",
+			UserMessage:     "session has been revoked, please login again",
+		}
+		return false, err
+	}
 
 	//TODO - extends for other purpose
 	return true, nil
@@ -24,12 +24,14 @@ import (
 	"github.com/devtron-labs/devtron/api/bean"
 	"github.com/devtron-labs/devtron/internal/constants"
 	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
 	casbin2 "github.com/devtron-labs/devtron/pkg/user/casbin"
 	repository2 "github.com/devtron-labs/devtron/pkg/user/repository"
 	"github.com/go-pg/pg"
 	"github.com/gorilla/sessions"
 	"go.uber.org/zap"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -57,19 +59,38 @@ type UserServiceImpl struct {
 	userRepository      repository2.UserRepository
 	roleGroupRepository repository2.RoleGroupRepository
 	sessionManager2     *middleware.SessionManager
+	apiTokenRepository  repository2.ApiTokenRepository
+	auditLogService     auditLog.AuditLogService
+
+	ssoGroupMappingService SsoGroupMappingService
+	customRoleService      CustomRoleService
+	accessRequestService   AccessRequestService
+	userSessionService     UserSessionService
 }
 
 func NewUserServiceImpl(userAuthRepository repository2.UserAuthRepository,
 	logger *zap.SugaredLogger,
 	userRepository repository2.UserRepository,
 	userGroupRepository repository2.RoleGroupRepository,
-	sessionManager2 *middleware.SessionManager) *UserServiceImpl {
+	sessionManager2 *middleware.SessionManager,
+	apiTokenRepository repository2.ApiTokenRepository,
+	auditLogService auditLog.AuditLogService,
+	ssoGroupMappingService SsoGroupMappingService,
+	customRoleService CustomRoleService,
+	accessRequestService AccessRequestService,
+	userSessionService UserSessionService) *UserServiceImpl {
 	serviceImpl := &UserServiceImpl{
-		userAuthRepository:  userAuthRepository,
-		logger:              logger,
-		userRepository:      userRepository,
-		roleGroupRepository: userGroupRepository,
-		sessionManager2:     sessionManager2,
+		userAuthRepository:     userAuthRepository,
+		logger:                 logger,
+		userRepository:         userRepository,
+		roleGroupRepository:    userGroupRepository,
+		sessionManager2:        sessionManager2,
+		apiTokenRepository:     apiTokenRepository,
+		auditLogService:        auditLogService,
+		ssoGroupMappingService: ssoGroupMappingService,
+		customRoleService:      customRoleService,
+		accessRequestService:   accessRequestService,
+		userSessionService:     userSessionService,
 	}
 	cStore = sessions.NewCookieStore(randKey())
 	return serviceImpl
@@ -125,6 +146,7 @@ func (impl UserServiceImpl) CreateUser(userInfo *bean.UserInfo) ([]*bean.UserInf
 				impl.logger.Errorw("error while create user if not exists in db", "error", err)
 				return nil, err
 			}
+			impl.saveAuditEvent(auditLog.ActionCreate, nil, userInfo, userInfo.UserId)
 		}
 
 		pass = append(pass, emailId)
@@ -231,6 +253,10 @@ func (impl UserServiceImpl) createUserIfNotExists(userInfo *bean.UserInfo, email
 							if err != nil || flag == false {
 								return nil, err
 							}
+							_, err = impl.customRoleService.CreatePoliciesForFilter(roleFilter.Action, roleFilter.Team, entityName, environment)
+							if err != nil {
+								return nil, err
+							}
 							roleModel, err = impl.userAuthRepository.GetRoleByFilter(roleFilter.Entity, roleFilter.Team, entityName, environment, roleFilter.Action)
 							if err != nil {
 								impl.logger.Errorw("Error in fetching role by filter", "user", userInfo)
@@ -396,6 +422,11 @@ func (impl UserServiceImpl) UpdateUser(userInfo *bean.UserInfo) (*bean.UserInfo,
 		}
 	}
 
+	before, err := impl.GetById(userInfo.Id)
+	if err != nil && err != pg.ErrNoRows {
+		return nil, err
+	}
+
 	dbConnection := impl.userRepository.GetConnection()
 	tx, err := dbConnection.Begin()
 	if err != nil {
@@ -521,6 +552,10 @@ func (impl UserServiceImpl) UpdateUser(userInfo *bean.UserInfo) (*bean.UserInfo,
 							if err != nil || flag == false {
 								return nil, err
 							}
+							_, err = impl.customRoleService.CreatePoliciesForFilter(roleFilter.Action, roleFilter.Team, entityName, environment)
+							if err != nil {
+								return nil, err
+							}
 							roleModel, err = impl.userAuthRepository.GetRoleByFilter(roleFilter.Entity, roleFilter.Team, entityName, environment, roleFilter.Action)
 							if err != nil {
 								impl.logger.Errorw("Error in fetching role by filter", "user", userInfo)
@@ -644,9 +679,28 @@ func (impl UserServiceImpl) UpdateUser(userInfo *bean.UserInfo) (*bean.UserInfo,
 	if err != nil {
 		return nil, err
 	}
+	impl.saveAuditEvent(auditLog.ActionUpdate, before, userInfo, userInfo.UserId)
 
 	return userInfo, nil
 }
+
+// saveAuditEvent records roles of the user before and after the change
+func (impl UserServiceImpl) saveAuditEvent(action string, before *bean.UserInfo, after *bean.UserInfo, userId int32) {
+	event := &auditLog.AuditEvent{
+		UserId:       userId,
+		ResourceType: auditLog.ResourceUser,
+		Action:       action,
+	}
+	if before != nil {
+		event.ResourceId = strconv.Itoa(int(before.Id))
+		event.Before = &bean.UserInfo{Id: before.Id, EmailId: before.EmailId, RoleFilters: before.RoleFilters, Groups: before.Groups, SuperAdmin: before.SuperAdmin}
+	}
+	if after != nil {
+		event.ResourceId = strconv.Itoa(int(after.Id))
+		event.After = &bean.UserInfo{Id: after.Id, EmailId: after.EmailId, RoleFilters: after.RoleFilters, Groups: after.Groups, SuperAdmin: after.SuperAdmin}
+	}
+	impl.auditLogService.SaveEvent(event)
+}
 func (impl UserServiceImpl) GetById(id int32) (*bean.UserInfo, error) {
 	model, err := impl.userRepository.GetById(id)
 	if err != nil {
@@ -754,6 +808,9 @@ func (impl UserServiceImpl) GetAll() ([]bean.UserInfo, error) {
 	}
 	var response []bean.UserInfo
 	for _, m := range model {
+		if IsApiTokenUser(m.EmailId) {
+			continue
+		}
 		response = append(response, bean.UserInfo{
 			Id:          m.Id,
 			EmailId:     m.EmailId,
@@ -851,6 +908,27 @@ func (impl UserServiceImpl) GetUserByToken(token string) (int32, error) {
 	if email == "" && sub == "admin" {
 		email = sub
 	}
+	if email == "" && IsApiTokenUser(sub) {
+		err = impl.verifyApiToken(token)
+		if err != nil {
+			impl.logger.Errorw("api token verification failed", "sub", sub, "error", err)
+			err := &util.ApiError{
+				Code:            constants.UserNotFoundForToken,
+				InternalMessage: "api token revoked or not found",
+				UserMessage:     "api token revoked or not found",
+			}
+			return http.StatusUnauthorized, err
+		}
+		email = sub
+	}
+	// tokens issued by the identity provider carry the sso groups, first use of such a token is the login
+	if email != "" && jwt.GetField(mapClaims, "iss") != middleware.SessionManagerClaimsIssuer {
+		groups := jwt.GetScopeValues(mapClaims, []string{"groups"})
+		err = impl.ssoGroupMappingService.SyncUserGroupsOnce(hashApiToken(token), email, groups)
+		if err != nil {
+			impl.logger.Errorw("error in syncing sso groups of user", "email", email, "err", err)
+		}
+	}
 
 	userInfo, err := impl.GetUserByEmail(email)
 	if err != nil {
@@ -865,6 +943,21 @@ func (impl UserServiceImpl) GetUserByToken(token string) (int32, error) {
 	return userInfo.Id, nil
 }
 
+// verifyApiToken checks that the token is still active and records its usage, at most once a minute
+func (impl UserServiceImpl) verifyApiToken(token string) error {
+	apiToken, err := impl.apiTokenRepository.FindActiveByTokenHash(hashApiToken(token))
+	if err != nil {
+		return err
+	}
+	if time.Since(apiToken.LastUsedOn) > time.Minute {
+		err = impl.apiTokenRepository.UpdateLastUsedOn(apiToken.Id, time.Now())
+		if err != nil {
+			impl.logger.Warnw("error in updating last used on of api token", "id", apiToken.Id, "err", err)
+		}
+	}
+	return nil
+}
+
 func (impl UserServiceImpl) GetByIds(ids []int32) ([]bean.UserInfo, error) {
 	var beans []bean.UserInfo
 	models, err := impl.userRepository.GetByIds(ids)
@@ -890,6 +983,11 @@ func (impl UserServiceImpl) DeleteUser(bean *bean.UserInfo) (bool, error) {
 	// Rollback tx on error.
 	defer tx.Rollback()
 
+	before, err := impl.GetById(bean.Id)
+	if err != nil {
+		impl.logger.Errorw("error while fetching user from db", "error", err)
+		return false, err
+	}
 	model, err := impl.userRepository.GetById(bean.Id)
 	if err != nil {
 		impl.logger.Errorw("error while fetching user from db", "error", err)
@@ -930,6 +1028,13 @@ func (impl UserServiceImpl) DeleteUser(bean *bean.UserInfo) (bool, error) {
 			impl.logger.Warnw("unable to delete role:", "user", model.EmailId, "role", item)
 		}
 	}
+	// tokens already issued stay valid until expiry otherwise
+	err = impl.userSessionService.RevokeAllSessions(model.Id, bean.UserId)
+	if err != nil {
+		impl.logger.Errorw("error in revoking sessions of deleted user", "id", model.Id, "err", err)
+		return false, err
+	}
+	impl.saveAuditEvent(auditLog.ActionDelete, before, nil, bean.UserId)
 
 	return true, nil
 }
@@ -973,7 +1078,20 @@ func (impl UserServiceImpl) SyncOrchestratorToCasbin() (bool, error) {
 		processed = processed + 1
 	}
 	impl.logger.Infow("total roles processed for sync", "len", processed)
-	return true, nil
+	flag, err := impl.customRoleService.SyncOrchestratorToCasbin()
+	if err != nil {
+		impl.logger.Errorw("error sync custom roles to casbin", "error", err)
+		return false, err
+	}
+	if !flag {
+		return flag, nil
+	}
+	flag, err = impl.accessRequestService.SyncOrchestratorToCasbin()
+	if err != nil {
+		impl.logger.Errorw("error sync access requests to casbin", "error", err)
+		return false, err
+	}
+	return flag, nil
 }
 
 func (impl UserServiceImpl) IsSuperAdmin(userId int) (bool, error) {
@@ -0,0 +1,315 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package user
+
+import (
+	"errors"
+	"fmt"
+	"github.com/caarlos0/env"
+	"github.com/devtron-labs/authenticator/jwt"
+	"github.com/devtron-labs/authenticator/middleware"
+	"github.com/devtron-labs/devtron/api/bean"
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
+	repository2 "github.com/devtron-labs/devtron/pkg/user/repository"
+	"github.com/go-pg/pg"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const sessionTokenCookieName = "argocd.token"
+
+var errSessionUserNotFound = errors.New("user of session not found")
+
+type UserSessionConfig struct {
+	// CacheSeconds is how long a session check is cached, revocations reach other replicas within it
+	CacheSeconds int `env:"USER_SESSION_CACHE_SECONDS" envDefault:"60"`
+}
+
+// UserSessionService tracks the login tokens in use and keeps a revocation list of them. Sessions are recorded
+// when a token is first seen, so tokens of any login flow are covered
+type UserSessionService interface {
+	// ValidateSession returns false for revoked tokens, it also records the token as a session of its user
+	ValidateSession(token string, ip string, userAgent string) (bool, error)
+	GetActiveSessions(userId int32, currentToken string) ([]*bean.UserSession, error)
+	GetSessionById(id int) (*bean.UserSession, error)
+	RevokeSession(id int, actionUserId int32) error
+	// RevokeAllSessions revokes every token of the user issued so far, seen as a session or not
+	RevokeAllSessions(userId int32, actionUserId int32) error
+}
+
+type UserSessionServiceImpl struct {
+	logger                *zap.SugaredLogger
+	userSessionRepository repository2.UserSessionRepository
+	userRepository        repository2.UserRepository
+	sessionManager        *middleware.SessionManager
+	auditLogService       auditLog.AuditLogService
+	// token id to revoked, entries expire so that sessions get their last seen updated
+	cache *cache.Cache
+}
+
+func NewUserSessionServiceImpl(logger *zap.SugaredLogger, userSessionRepository repository2.UserSessionRepository,
+	userRepository repository2.UserRepository, sessionManager *middleware.SessionManager,
+	auditLogService auditLog.AuditLogService) (*UserSessionServiceImpl, error) {
+	config := &UserSessionConfig{}
+	err := env.Parse(config)
+	if err != nil {
+		logger.Errorw("error in parsing user session config", "err", err)
+		return nil, err
+	}
+	ttl := time.Duration(config.CacheSeconds) * time.Second
+	return &UserSessionServiceImpl{
+		logger:                logger,
+		userSessionRepository: userSessionRepository,
+		userRepository:        userRepository,
+		sessionManager:        sessionManager,
+		auditLogService:       auditLogService,
+		cache:                 cache.New(ttl, 2*ttl),
+	}, nil
+}
+
+func (impl UserSessionServiceImpl) ValidateSession(token string, ip string, userAgent string) (bool, error) {
+	tokenId := hashApiToken(token)
+	if revoked, found := impl.cache.Get(tokenId); found {
+		return !revoked.(bool), nil
+	}
+	now := time.Now()
+	session, err := impl.userSessionRepository.FindByTokenId(tokenId)
+	if err == nil {
+		if !session.Revoked {
+			session.Ip = ip
+			session.UserAgent = userAgent
+			session.LastSeenOn = now
+			session.UpdatedOn = now
+			err = impl.userSessionRepository.Update(session)
+			if err != nil {
+				impl.logger.Warnw("error in updating last seen of session", "id", session.Id, "err", err)
+			}
+		}
+		impl.cache.SetDefault(tokenId, session.Revoked)
+		return !session.Revoked, nil
+	} else if err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching session", "err", err)
+		return false, err
+	}
+
+	session, err = impl.newSession(token, tokenId, ip, userAgent, now)
+	if err == errSessionUserNotFound {
+		// tokens of deleted or unknown users are refused
+		impl.cache.SetDefault(tokenId, true)
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if session == nil {
+		// api tokens and tokens without an email are not tracked as sessions
+		impl.cache.SetDefault(tokenId, false)
+		return true, nil
+	}
+	revocation, err := impl.userSessionRepository.FindRevocation(session.UserId)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching session revocation", "userId", session.UserId, "err", err)
+		return false, err
+	}
+	if err == nil && !session.IssuedOn.IsZero() && session.IssuedOn.Unix() < revocation.RevokedBefore.Unix() {
+		session.Revoked = true
+		session.RevokedOn = revocation.RevokedBefore
+		session.RevokedBy = revocation.UpdatedBy
+	}
+	err = impl.userSessionRepository.Save(session)
+	if err != nil {
+		// another replica may have recorded it in the meantime, the next check reads it
+		impl.logger.Warnw("error in saving session", "userId", session.UserId, "err", err)
+		return !session.Revoked, nil
+	}
+	impl.cache.SetDefault(tokenId, session.Revoked)
+	return !session.Revoked, nil
+}
+
+// newSession builds the session of a verified token from its claims, it returns nil if the token is not one
+// of a user login and errSessionUserNotFound if its user is not an active user
+func (impl UserSessionServiceImpl) newSession(token string, tokenId string, ip string, userAgent string, now time.Time) (*repository2.UserSession, error) {
+	claims, err := impl.sessionManager.VerifyToken(token)
+	if err != nil {
+		return nil, err
+	}
+	mapClaims, err := jwt.MapClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+	email := jwt.GetField(mapClaims, "email")
+	sub := jwt.GetField(mapClaims, "sub")
+	if email == "" && sub == AdminUsername {
+		email = sub
+	}
+	if email == "" || IsApiTokenUser(email) {
+		return nil, nil
+	}
+	user, err := impl.userRepository.FetchActiveUserByEmail(email)
+	if err == pg.ErrNoRows || (err == nil && user.Id == 0) {
+		return nil, errSessionUserNotFound
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching user of session", "email", email, "err", err)
+		return nil, err
+	}
+	session := &repository2.UserSession{
+		UserId:     user.Id,
+		TokenId:    tokenId,
+		Ip:         ip,
+		UserAgent:  userAgent,
+		LastSeenOn: now,
+	}
+	if issuedAt, err := jwt.GetIssuedAt(mapClaims); err == nil && issuedAt > 0 {
+		session.IssuedOn = time.Unix(issuedAt, 0)
+	}
+	if exp, ok := mapClaims["exp"].(float64); ok && exp > 0 {
+		session.ExpiresOn = time.Unix(int64(exp), 0)
+	}
+	session.CreatedBy = user.Id
+	session.CreatedOn = now
+	session.UpdatedBy = user.Id
+	session.UpdatedOn = now
+	return session, nil
+}
+
+func (impl UserSessionServiceImpl) GetActiveSessions(userId int32, currentToken string) ([]*bean.UserSession, error) {
+	models, err := impl.userSessionRepository.FindActiveByUserId(userId, time.Now())
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching sessions", "userId", userId, "err", err)
+		return nil, err
+	}
+	currentTokenId := ""
+	if len(currentToken) > 0 {
+		currentTokenId = hashApiToken(currentToken)
+	}
+	sessions := make([]*bean.UserSession, 0, len(models))
+	for _, model := range models {
+		session := adaptUserSession(model)
+		session.Current = model.TokenId == currentTokenId
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+func (impl UserSessionServiceImpl) GetSessionById(id int) (*bean.UserSession, error) {
+	model, err := impl.findSession(id)
+	if err != nil {
+		return nil, err
+	}
+	return adaptUserSession(model), nil
+}
+
+func (impl UserSessionServiceImpl) RevokeSession(id int, actionUserId int32) error {
+	model, err := impl.findSession(id)
+	if err != nil {
+		return err
+	}
+	if model.Revoked {
+		return nil
+	}
+	now := time.Now()
+	model.Revoked = true
+	model.RevokedOn = now
+	model.RevokedBy = actionUserId
+	model.UpdatedBy = actionUserId
+	model.UpdatedOn = now
+	err = impl.userSessionRepository.Update(model)
+	if err != nil {
+		impl.logger.Errorw("error in revoking session", "id", id, "err", err)
+		return err
+	}
+	impl.cache.SetDefault(model.TokenId, true)
+	impl.saveAuditEvent(model.UserId, actionUserId, fmt.Sprintf("session %d revoked", id))
+	return nil
+}
+
+func (impl UserSessionServiceImpl) RevokeAllSessions(userId int32, actionUserId int32) error {
+	now := time.Now()
+	err := impl.userSessionRepository.SaveRevocation(&repository2.UserSessionRevocation{
+		UserId:        userId,
+		RevokedBefore: now,
+		UpdatedOn:     now,
+		UpdatedBy:     actionUserId,
+	})
+	if err != nil {
+		impl.logger.Errorw("error in saving session revocation", "userId", userId, "err", err)
+		return err
+	}
+	tokenIds, err := impl.userSessionRepository.RevokeAllByUserId(userId, actionUserId, now)
+	if err != nil {
+		impl.logger.Errorw("error in revoking sessions", "userId", userId, "err", err)
+		return err
+	}
+	for _, tokenId := range tokenIds {
+		impl.cache.SetDefault(tokenId, true)
+	}
+	impl.saveAuditEvent(userId, actionUserId, "all sessions revoked")
+	return nil
+}
+
+// SessionToken returns the login token of a request, the token cookie takes precedence over the token header
+// as the ui sends both
+func SessionToken(r *http.Request) string {
+	if cookie, err := r.Cookie(sessionTokenCookieName); err == nil && len(cookie.Value) > 0 {
+		return cookie.Value
+	}
+	return r.Header.Get("token")
+}
+
+func (impl UserSessionServiceImpl) findSession(id int) (*repository2.UserSession, error) {
+	model, err := impl.userSessionRepository.FindById(id)
+	if err == pg.ErrNoRows {
+		return nil, &util.ApiError{HttpStatusCode: http.StatusNotFound, UserMessage: fmt.Sprintf("session %d not found", id)}
+	} else if err != nil {
+		impl.logger.Errorw("error in fetching session", "id", id, "err", err)
+		return nil, err
+	}
+	return model, nil
+}
+
+func (impl UserSessionServiceImpl) saveAuditEvent(userId int32, actionUserId int32, change string) {
+	impl.auditLogService.SaveEvent(&auditLog.AuditEvent{
+		UserId:       actionUserId,
+		ResourceType: auditLog.ResourceUser,
+		ResourceId:   strconv.Itoa(int(userId)),
+		Action:       auditLog.ActionUpdate,
+		After:        change,
+	})
+}
+
+func adaptUserSession(model *repository2.UserSession) *bean.UserSession {
+	session := &bean.UserSession{
+		Id:         model.Id,
+		UserId:     model.UserId,
+		Ip:         model.Ip,
+		UserAgent:  model.UserAgent,
+		LastSeenOn: model.LastSeenOn,
+	}
+	if !model.IssuedOn.IsZero() {
+		issuedOn := model.IssuedOn
+		session.IssuedOn = &issuedOn
+	}
+	if !model.ExpiresOn.IsZero() {
+		expiresOn := model.ExpiresOn
+		session.ExpiresOn = &expiresOn
+	}
+	return session
+}
@@ -0,0 +1,231 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/devtron-labs/authenticator/client"
+	"github.com/devtron-labs/authenticator/middleware"
+	"github.com/devtron-labs/authenticator/oidc"
+	"github.com/devtron-labs/devtron/api/bean"
+	"github.com/devtron-labs/devtron/internal/util"
+	"github.com/devtron-labs/devtron/pkg/auditLog"
+	repository2 "github.com/devtron-labs/devtron/pkg/user/repository"
+	"github.com/go-pg/pg"
+	"github.com/patrickmn/go-cache"
+)
+
+type userSessionRepositoryStub struct {
+	repository2.UserSessionRepository
+	sessions    []*repository2.UserSession
+	revocation  *repository2.UserSessionRevocation
+	tokenLookup int
+	saved       []*repository2.UserSession
+}
+
+func (impl *userSessionRepositoryStub) FindByTokenId(tokenId string) (*repository2.UserSession, error) {
+	impl.tokenLookup++
+	for _, session := range impl.sessions {
+		if session.TokenId == tokenId {
+			return session, nil
+		}
+	}
+	return nil, pg.ErrNoRows
+}
+
+func (impl *userSessionRepositoryStub) FindById(id int) (*repository2.UserSession, error) {
+	for _, session := range impl.sessions {
+		if session.Id == id {
+			return session, nil
+		}
+	}
+	return nil, pg.ErrNoRows
+}
+
+func (impl *userSessionRepositoryStub) FindActiveByUserId(userId int32, now time.Time) ([]*repository2.UserSession, error) {
+	var sessions []*repository2.UserSession
+	for _, session := range impl.sessions {
+		if session.UserId == userId && !session.Revoked {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (impl *userSessionRepositoryStub) Update(model *repository2.UserSession) error {
+	return nil
+}
+
+func (impl *userSessionRepositoryStub) Save(model *repository2.UserSession) error {
+	impl.saved = append(impl.saved, model)
+	return nil
+}
+
+func (impl *userSessionRepositoryStub) RevokeAllByUserId(userId int32, revokedBy int32, now time.Time) ([]string, error) {
+	var tokenIds []string
+	for _, session := range impl.sessions {
+		if session.UserId == userId && !session.Revoked {
+			session.Revoked = true
+			tokenIds = append(tokenIds, session.TokenId)
+		}
+	}
+	return tokenIds, nil
+}
+
+func (impl *userSessionRepositoryStub) FindRevocation(userId int32) (*repository2.UserSessionRevocation, error) {
+	if impl.revocation == nil || impl.revocation.UserId != userId {
+		return nil, pg.ErrNoRows
+	}
+	return impl.revocation, nil
+}
+
+func (impl *userSessionRepositoryStub) SaveRevocation(model *repository2.UserSessionRevocation) error {
+	impl.revocation = model
+	return nil
+}
+
+type sessionUserRepositoryStub struct {
+	repository2.UserRepository
+	users map[string]bean.UserInfo
+	err   error
+}
+
+func (impl sessionUserRepositoryStub) FetchActiveUserByEmail(email string) (bean.UserInfo, error) {
+	if impl.err != nil {
+		return bean.UserInfo{}, impl.err
+	}
+	// like the query of the repository, a missing user is an empty result
+	return impl.users[email], nil
+}
+
+type sessionAuditLogServiceStub struct {
+	auditLog.AuditLogService
+}
+
+func (impl sessionAuditLogServiceStub) SaveEvent(event *auditLog.AuditEvent) {
+}
+
+func newUserSessionServiceForTest(repository *userSessionRepositoryStub, userRepository sessionUserRepositoryStub) *UserSessionServiceImpl {
+	sessionManager := middleware.NewSessionManager(&oidc.Settings{OIDCConfig: oidc.OIDCConfig{ServerSecret: "session-test"}}, &client.DexConfig{})
+	return &UserSessionServiceImpl{
+		logger:                util.NewSugardLogger(),
+		userSessionRepository: repository,
+		userRepository:        userRepository,
+		sessionManager:        sessionManager,
+		auditLogService:       sessionAuditLogServiceStub{},
+		cache:                 cache.New(time.Minute, 2*time.Minute),
+	}
+}
+
+func TestValidateSessionIsCached(t *testing.T) {
+	repository := &userSessionRepositoryStub{sessions: []*repository2.UserSession{{Id: 1, UserId: 2, TokenId: hashApiToken("token-1")}}}
+	impl := newUserSessionServiceForTest(repository, sessionUserRepositoryStub{})
+	for i := 0; i < 3; i++ {
+		valid, err := impl.ValidateSession("token-1", "10.0.0.1", "test")
+		if err != nil || !valid {
+			t.Fatalf("ValidateSession() = %v, %v, want valid", valid, err)
+		}
+	}
+	if repository.tokenLookup != 1 {
+		t.Errorf("session looked up %d times, want once", repository.tokenLookup)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	repository := &userSessionRepositoryStub{sessions: []*repository2.UserSession{
+		{Id: 1, UserId: 2, TokenId: hashApiToken("token-1")},
+		{Id: 2, UserId: 2, TokenId: hashApiToken("token-2")},
+	}}
+	impl := newUserSessionServiceForTest(repository, sessionUserRepositoryStub{})
+	// a cached valid session is refused right after its revocation
+	if valid, _ := impl.ValidateSession("token-1", "", ""); !valid {
+		t.Fatal("expected session to be valid before revocation")
+	}
+	if err := impl.RevokeSession(1, 3); err != nil {
+		t.Fatal(err)
+	}
+	if !repository.sessions[0].Revoked || repository.sessions[0].RevokedBy != 3 {
+		t.Errorf("session not revoked: %+v", repository.sessions[0])
+	}
+	if valid, err := impl.ValidateSession("token-1", "", ""); err != nil || valid {
+		t.Errorf("ValidateSession() of revoked session = %v, %v", valid, err)
+	}
+	if valid, err := impl.ValidateSession("token-2", "", ""); err != nil || !valid {
+		t.Errorf("ValidateSession() of other session = %v, %v", valid, err)
+	}
+	err := impl.RevokeSession(5, 3)
+	if apiErr, ok := err.(*util.ApiError); !ok || apiErr.HttpStatusCode != http.StatusNotFound {
+		t.Errorf("RevokeSession() of missing session = %v, want not found", err)
+	}
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	repository := &userSessionRepositoryStub{sessions: []*repository2.UserSession{
+		{Id: 1, UserId: 2, TokenId: hashApiToken("token-1")},
+		{Id: 2, UserId: 4, TokenId: hashApiToken("token-2")},
+	}}
+	users := sessionUserRepositoryStub{users: map[string]bean.UserInfo{AdminUsername: {Id: 2, EmailId: AdminUsername}}}
+	impl := newUserSessionServiceForTest(repository, users)
+	token, err := impl.sessionManager.Create(AdminUsername, 3600, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if valid, _ := impl.ValidateSession("token-1", "", ""); !valid {
+		t.Fatal("expected session to be valid before revocation")
+	}
+	time.Sleep(time.Second)
+	if err = impl.RevokeAllSessions(2, 3); err != nil {
+		t.Fatal(err)
+	}
+	if valid, err := impl.ValidateSession("token-1", "", ""); err != nil || valid {
+		t.Errorf("ValidateSession() of revoked session = %v, %v", valid, err)
+	}
+	if valid, err := impl.ValidateSession("token-2", "", ""); err != nil || !valid {
+		t.Errorf("ValidateSession() of session of other user = %v, %v", valid, err)
+	}
+	// a token issued before the revocation and not seen so far is refused and recorded as revoked
+	if valid, err := impl.ValidateSession(token, "", ""); err != nil || valid {
+		t.Errorf("ValidateSession() of unseen token = %v, %v", valid, err)
+	}
+	if len(repository.saved) != 1 || !repository.saved[0].Revoked || repository.saved[0].UserId != 2 {
+		t.Errorf("unexpected saved sessions %+v", repository.saved)
+	}
+}
+
+func TestValidateSessionOfUnknownUser(t *testing.T) {
+	impl := newUserSessionServiceForTest(&userSessionRepositoryStub{}, sessionUserRepositoryStub{})
+	token, err := impl.sessionManager.Create(AdminUsername, 3600, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if valid, err := impl.ValidateSession(token, "", ""); err != nil || valid {
+		t.Errorf("ValidateSession() of deleted user = %v, %v, want refused", valid, err)
+	}
+
+	impl = newUserSessionServiceForTest(&userSessionRepositoryStub{}, sessionUserRepositoryStub{err: errors.New("connection refused")})
+	if valid, err := impl.ValidateSession(token, "", ""); err == nil || valid {
+		t.Errorf("ValidateSession() with failing user lookup = %v, %v, want refused with error", valid, err)
+	}
+}
+
+func TestGetActiveSessionsFlagsCookieToken(t *testing.T) {
+	repository := &userSessionRepositoryStub{sessions: []*repository2.UserSession{
+		{Id: 1, UserId: 2, TokenId: hashApiToken("header-token")},
+		{Id: 2, UserId: 2, TokenId: hashApiToken("cookie-token")},
+	}}
+	impl := newUserSessionServiceForTest(repository, sessionUserRepositoryStub{})
+	r := httptest.NewRequest(http.MethodGet, "/orchestrator/user/session", nil)
+	r.Header.Set("token", "header-token")
+	r.AddCookie(&http.Cookie{Name: sessionTokenCookieName, Value: "cookie-token"})
+
+	sessions, err := impl.GetActiveSessions(2, SessionToken(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 || sessions[0].Current || !sessions[1].Current {
+		t.Errorf("expected only the cookie session to be current, got %+v %+v", sessions[0], sessions[1])
+	}
+}
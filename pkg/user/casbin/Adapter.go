@@ -139,6 +139,11 @@ func GetRolesForUser(user string) ([]string, error) {
 	return e.GetRolesForUser(user)
 }
 
+// GetPoliciesForSubject returns the p rules of a user, role or role group as sub, res, act, obj, eft
+func GetPoliciesForSubject(sub string) [][]string {
+	return e.GetFilteredPolicy(0, strings.ToLower(sub))
+}
+
 func GetUserByRole(role string) ([]string, error) {
 	role = strings.ToLower(role)
 	return e.GetUsersForRole(role)
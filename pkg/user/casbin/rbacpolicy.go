@@ -40,6 +40,12 @@ const (
 	ResourceAdmin  = "admin"
 	ResourceGlobal = "global-resource"
 
+	// fine grained config resources, secret and deployment-template objects are team/app while env-override
+	// objects are env/app
+	ResourceSecret             = "secret"
+	ResourceDeploymentTemplate = "deployment-template"
+	ResourceEnvOverride        = "env-override"
+
 	ActionGet     = "get"
 	ActionCreate  = "create"
 	ActionUpdate  = "update"
@@ -47,4 +53,10 @@ const (
 	ActionSync    = "sync"
 	ActionTrigger = "trigger"
 	ActionNotify  = "notify"
+
+	// restart allows deleting pods and hibernate allows stopping and starting apps without trigger access
+	ActionRestart   = "restart"
+	ActionHibernate = "hibernate"
+	// debug allows attaching ephemeral debug containers to pods in terminal sessions
+	ActionDebug = "debug"
 )
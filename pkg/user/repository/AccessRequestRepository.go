@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package repository
+
+import (
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	AccessRequestPending   = "PENDING"
+	AccessRequestApproved  = "APPROVED"
+	AccessRequestRejected  = "REJECTED"
+	AccessRequestRevoked   = "REVOKED"
+	AccessRequestCancelled = "CANCELLED"
+	AccessRequestExpired   = "EXPIRED"
+)
+
+// AccessRequest is a time bound request of a user for a role filter. The role filter is stored as json, roles
+// granted on approval are stored as a json list of casbin role names so that exactly those are taken back on expiry
+type AccessRequest struct {
+	TableName       struct{}  `sql:"access_request" pg:",discard_unknown_columns"`
+	Id              int       `sql:"id,pk"`
+	UserId          int32     `sql:"user_id,notnull"`
+	RoleFilter      string    `sql:"role_filter,notnull"`
+	Reason          string    `sql:"reason,notnull"`
+	DurationMinutes int       `sql:"duration_minutes,notnull"`
+	Status          string    `sql:"status,notnull"`
+	ApproverId      int32     `sql:"approver_id"`
+	Comment         string    `sql:"comment"`
+	GrantedRoles    string    `sql:"granted_roles"`
+	ApprovedOn      time.Time `sql:"approved_on"`
+	ExpiresOn       time.Time `sql:"expires_on"`
+	sql.AuditLog
+}
+
+type AccessRequestRepository interface {
+	GetConnection() *pg.DB
+	Save(model *AccessRequest) error
+	Update(model *AccessRequest) error
+	FindById(id int) (*AccessRequest, error)
+	FindByUserId(userId int32) ([]*AccessRequest, error)
+	FindByStatus(status string) ([]*AccessRequest, error)
+	FindApprovedByUserId(userId int32) ([]*AccessRequest, error)
+	FindApprovedExpiringBefore(now time.Time) ([]*AccessRequest, error)
+}
+
+type AccessRequestRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func NewAccessRequestRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *AccessRequestRepositoryImpl {
+	return &AccessRequestRepositoryImpl{dbConnection: dbConnection, logger: logger}
+}
+
+func (impl AccessRequestRepositoryImpl) GetConnection() *pg.DB {
+	return impl.dbConnection
+}
+
+func (impl AccessRequestRepositoryImpl) Save(model *AccessRequest) error {
+	return impl.dbConnection.Insert(model)
+}
+
+func (impl AccessRequestRepositoryImpl) Update(model *AccessRequest) error {
+	return impl.dbConnection.Update(model)
+}
+
+func (impl AccessRequestRepositoryImpl) FindById(id int) (*AccessRequest, error) {
+	model := &AccessRequest{}
+	err := impl.dbConnection.Model(model).
+		Where("id = ?", id).
+		Select()
+	return model, err
+}
+
+func (impl AccessRequestRepositoryImpl) FindByUserId(userId int32) ([]*AccessRequest, error) {
+	var models []*AccessRequest
+	err := impl.dbConnection.Model(&models).
+		Where("user_id = ?", userId).
+		Order("id DESC").
+		Select()
+	return models, err
+}
+
+func (impl AccessRequestRepositoryImpl) FindByStatus(status string) ([]*AccessRequest, error) {
+	var models []*AccessRequest
+	err := impl.dbConnection.Model(&models).
+		Where("status = ?", status).
+		Order("id DESC").
+		Select()
+	return models, err
+}
+
+func (impl AccessRequestRepositoryImpl) FindApprovedByUserId(userId int32) ([]*AccessRequest, error) {
+	var models []*AccessRequest
+	err := impl.dbConnection.Model(&models).
+		Where("user_id = ?", userId).
+		Where("status = ?", AccessRequestApproved).
+		Select()
+	return models, err
+}
+
+func (impl AccessRequestRepositoryImpl) FindApprovedExpiringBefore(now time.Time) ([]*AccessRequest, error) {
+	var models []*AccessRequest
+	err := impl.dbConnection.Model(&models).
+		Where("status = ?", AccessRequestApproved).
+		Where("expires_on <= ?", now).
+		Select()
+	return models, err
+}
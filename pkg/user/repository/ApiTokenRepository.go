@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package repository
+
+import (
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"time"
+)
+
+// ApiTokenUserPrefix is prepended to the token name to form the email of the user backing an api token,
+// role filters of the token are the roles of this user and casbin enforces them like any other user
+const ApiTokenUserPrefix = "api-token:"
+
+// ApiToken holds the sha256 hash of a token issued to the user UserId, the token itself is never stored
+type ApiToken struct {
+	TableName   struct{}  `sql:"api_token" pg:",discard_unknown_columns"`
+	Id          int       `sql:"id,pk"`
+	UserId      int32     `sql:"user_id,notnull"`
+	Name        string    `sql:"name,notnull"`
+	Description string    `sql:"description"`
+	TokenHash   string    `sql:"token_hash,notnull"`
+	ExpiresOn   time.Time `sql:"expires_on,notnull"`
+	LastUsedOn  time.Time `sql:"last_used_on"`
+	Active      bool      `sql:"active,notnull"`
+	sql.AuditLog
+}
+
+type ApiTokenRepository interface {
+	Save(model *ApiToken) error
+	Update(model *ApiToken) error
+	UpdateLastUsedOn(id int, lastUsedOn time.Time) error
+	FindActiveById(id int) (*ApiToken, error)
+	FindActiveByName(name string) (*ApiToken, error)
+	FindActiveByTokenHash(tokenHash string) (*ApiToken, error)
+	FindAllActive() ([]*ApiToken, error)
+}
+
+type ApiTokenRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func NewApiTokenRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *ApiTokenRepositoryImpl {
+	return &ApiTokenRepositoryImpl{dbConnection: dbConnection, logger: logger}
+}
+
+func (impl ApiTokenRepositoryImpl) Save(model *ApiToken) error {
+	return impl.dbConnection.Insert(model)
+}
+
+func (impl ApiTokenRepositoryImpl) Update(model *ApiToken) error {
+	return impl.dbConnection.Update(model)
+}
+
+func (impl ApiTokenRepositoryImpl) UpdateLastUsedOn(id int, lastUsedOn time.Time) error {
+	_, err := impl.dbConnection.Model(&ApiToken{}).
+		Set("last_used_on = ?", lastUsedOn).
+		Where("id = ?", id).
+		Update()
+	return err
+}
+
+func (impl ApiTokenRepositoryImpl) FindActiveById(id int) (*ApiToken, error) {
+	model := &ApiToken{}
+	err := impl.dbConnection.Model(model).
+		Where("id = ?", id).
+		Where("active = ?", true).
+		Select()
+	return model, err
+}
+
+func (impl ApiTokenRepositoryImpl) FindActiveByName(name string) (*ApiToken, error) {
+	model := &ApiToken{}
+	err := impl.dbConnection.Model(model).
+		Where("name = ?", name).
+		Where("active = ?", true).
+		Select()
+	return model, err
+}
+
+func (impl ApiTokenRepositoryImpl) FindActiveByTokenHash(tokenHash string) (*ApiToken, error) {
+	model := &ApiToken{}
+	err := impl.dbConnection.Model(model).
+		Where("token_hash = ?", tokenHash).
+		Where("active = ?", true).
+		Select()
+	return model, err
+}
+
+func (impl ApiTokenRepositoryImpl) FindAllActive() ([]*ApiToken, error) {
+	var models []*ApiToken
+	err := impl.dbConnection.Model(&models).
+		Where("active = ?", true).
+		Order("id DESC").
+		Select()
+	return models, err
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package repository
+
+import (
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+)
+
+// CustomRole is an admin defined bundle of casbin (resource, action) pairs, assigned per team/env/app through
+// role filters having the custom role name as action. Permissions are stored as json
+type CustomRole struct {
+	TableName   struct{} `sql:"custom_role" pg:",discard_unknown_columns"`
+	Id          int      `sql:"id,pk"`
+	Name        string   `sql:"name,notnull"`
+	Description string   `sql:"description"`
+	Permissions string   `sql:"permissions,notnull"`
+	Active      bool     `sql:"active,notnull"`
+	sql.AuditLog
+}
+
+type CustomRoleRepository interface {
+	GetConnection() *pg.DB
+	Save(model *CustomRole) error
+	Update(model *CustomRole) error
+	FindActiveById(id int) (*CustomRole, error)
+	FindActiveByName(name string) (*CustomRole, error)
+	FindAllActive() ([]*CustomRole, error)
+	FindRolesByAction(action string) ([]*RoleModel, error)
+	CountAssignments(action string) (int, error)
+}
+
+type CustomRoleRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func NewCustomRoleRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *CustomRoleRepositoryImpl {
+	return &CustomRoleRepositoryImpl{dbConnection: dbConnection, logger: logger}
+}
+
+func (impl CustomRoleRepositoryImpl) GetConnection() *pg.DB {
+	return impl.dbConnection
+}
+
+func (impl CustomRoleRepositoryImpl) Save(model *CustomRole) error {
+	return impl.dbConnection.Insert(model)
+}
+
+func (impl CustomRoleRepositoryImpl) Update(model *CustomRole) error {
+	return impl.dbConnection.Update(model)
+}
+
+func (impl CustomRoleRepositoryImpl) FindActiveById(id int) (*CustomRole, error) {
+	model := &CustomRole{}
+	err := impl.dbConnection.Model(model).
+		Where("id = ?", id).
+		Where("active = ?", true).
+		Select()
+	return model, err
+}
+
+func (impl CustomRoleRepositoryImpl) FindActiveByName(name string) (*CustomRole, error) {
+	model := &CustomRole{}
+	err := impl.dbConnection.Model(model).
+		Where("name = ?", name).
+		Where("active = ?", true).
+		Select()
+	return model, err
+}
+
+func (impl CustomRoleRepositoryImpl) FindAllActive() ([]*CustomRole, error) {
+	var models []*CustomRole
+	err := impl.dbConnection.Model(&models).
+		Where("active = ?", true).
+		Order("name ASC").
+		Select()
+	return models, err
+}
+
+// FindRolesByAction returns the team scoped roles created for a custom role
+func (impl CustomRoleRepositoryImpl) FindRolesByAction(action string) ([]*RoleModel, error) {
+	var models []*RoleModel
+	err := impl.dbConnection.Model(&models).
+		Where("action = ?", action).
+		Where("coalesce(entity, '') = ?", "").
+		Select()
+	return models, err
+}
+
+// CountAssignments counts users and role groups holding a role of the custom role
+func (impl CustomRoleRepositoryImpl) CountAssignments(action string) (int, error) {
+	var count int
+	query := "SELECT (SELECT count(*) FROM user_roles ur INNER JOIN roles r ON r.id = ur.role_id WHERE r.action = ?)" +
+		" + (SELECT count(*) FROM role_group_role_mapping rgrm INNER JOIN roles r ON r.id = rgrm.role_id" +
+		" INNER JOIN role_group rg ON rg.id = rgrm.role_group_id WHERE r.action = ? AND rg.active = true)"
+	_, err := impl.dbConnection.QueryOne(pg.Scan(&count), query, action, action)
+	return count, err
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package repository
+
+import (
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+)
+
+// SsoGroupMapping maps a group from the groups claim of the identity provider to a role group,
+// users of the sso group are made members of the role group on login
+type SsoGroupMapping struct {
+	TableName   struct{} `sql:"sso_group_mapping" pg:",discard_unknown_columns"`
+	Id          int      `sql:"id,pk"`
+	SsoGroup    string   `sql:"sso_group,notnull"`
+	RoleGroupId int32    `sql:"role_group_id,notnull"`
+	Active      bool     `sql:"active,notnull"`
+	RoleGroup   *RoleGroup
+	sql.AuditLog
+}
+
+type SsoGroupMappingRepository interface {
+	Save(model *SsoGroupMapping) error
+	Update(model *SsoGroupMapping) error
+	FindActiveById(id int) (*SsoGroupMapping, error)
+	FindActiveBySsoGroupAndRoleGroupId(ssoGroup string, roleGroupId int32) (*SsoGroupMapping, error)
+	FindAllActive() ([]*SsoGroupMapping, error)
+}
+
+type SsoGroupMappingRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func NewSsoGroupMappingRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *SsoGroupMappingRepositoryImpl {
+	return &SsoGroupMappingRepositoryImpl{dbConnection: dbConnection, logger: logger}
+}
+
+func (impl SsoGroupMappingRepositoryImpl) Save(model *SsoGroupMapping) error {
+	return impl.dbConnection.Insert(model)
+}
+
+func (impl SsoGroupMappingRepositoryImpl) Update(model *SsoGroupMapping) error {
+	return impl.dbConnection.Update(model)
+}
+
+func (impl SsoGroupMappingRepositoryImpl) FindActiveById(id int) (*SsoGroupMapping, error) {
+	model := &SsoGroupMapping{}
+	err := impl.dbConnection.Model(model).
+		Column("sso_group_mapping.*", "RoleGroup").
+		Where("sso_group_mapping.id = ?", id).
+		Where("sso_group_mapping.active = ?", true).
+		Select()
+	return model, err
+}
+
+func (impl SsoGroupMappingRepositoryImpl) FindActiveBySsoGroupAndRoleGroupId(ssoGroup string, roleGroupId int32) (*SsoGroupMapping, error) {
+	model := &SsoGroupMapping{}
+	err := impl.dbConnection.Model(model).
+		Where("sso_group = ?", ssoGroup).
+		Where("role_group_id = ?", roleGroupId).
+		Where("active = ?", true).
+		Select()
+	return model, err
+}
+
+// FindAllActive returns the active mappings along with their role groups, mappings of deleted role groups are skipped
+func (impl SsoGroupMappingRepositoryImpl) FindAllActive() ([]*SsoGroupMapping, error) {
+	var models []*SsoGroupMapping
+	err := impl.dbConnection.Model(&models).
+		Column("sso_group_mapping.*", "RoleGroup").
+		Where("sso_group_mapping.active = ?", true).
+		Where("role_group.active = ?", true).
+		Order("sso_group_mapping.id DESC").
+		Select()
+	return models, err
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package repository
+
+import (
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+)
+
+// SsoGroupMembership records a role group membership granted to a user through a sso group mapping,
+// it is used to revoke the membership when the mapping is deleted or pointed to another role group
+type SsoGroupMembership struct {
+	TableName         struct{} `sql:"sso_group_membership" pg:",discard_unknown_columns"`
+	Id                int      `sql:"id,pk"`
+	SsoGroupMappingId int      `sql:"sso_group_mapping_id,notnull"`
+	UserId            int32    `sql:"user_id,notnull"`
+	RoleGroupId       int32    `sql:"role_group_id,notnull"`
+	Active            bool     `sql:"active,notnull"`
+	sql.AuditLog
+}
+
+type SsoGroupMembershipRepository interface {
+	Save(model *SsoGroupMembership) error
+	Update(model *SsoGroupMembership) error
+	FindActiveByUserId(userId int32) ([]*SsoGroupMembership, error)
+	FindActiveByMappingId(mappingId int) ([]*SsoGroupMembership, error)
+	FindActiveByRoleGroupId(roleGroupId int32) ([]*SsoGroupMembership, error)
+}
+
+type SsoGroupMembershipRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func NewSsoGroupMembershipRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *SsoGroupMembershipRepositoryImpl {
+	return &SsoGroupMembershipRepositoryImpl{dbConnection: dbConnection, logger: logger}
+}
+
+func (impl SsoGroupMembershipRepositoryImpl) Save(model *SsoGroupMembership) error {
+	return impl.dbConnection.Insert(model)
+}
+
+func (impl SsoGroupMembershipRepositoryImpl) Update(model *SsoGroupMembership) error {
+	return impl.dbConnection.Update(model)
+}
+
+func (impl SsoGroupMembershipRepositoryImpl) FindActiveByUserId(userId int32) ([]*SsoGroupMembership, error) {
+	var models []*SsoGroupMembership
+	err := impl.dbConnection.Model(&models).
+		Where("user_id = ?", userId).
+		Where("active = ?", true).
+		Select()
+	return models, err
+}
+
+func (impl SsoGroupMembershipRepositoryImpl) FindActiveByMappingId(mappingId int) ([]*SsoGroupMembership, error) {
+	var models []*SsoGroupMembership
+	err := impl.dbConnection.Model(&models).
+		Where("sso_group_mapping_id = ?", mappingId).
+		Where("active = ?", true).
+		Select()
+	return models, err
+}
+
+func (impl SsoGroupMembershipRepositoryImpl) FindActiveByRoleGroupId(roleGroupId int32) ([]*SsoGroupMembership, error) {
+	var models []*SsoGroupMembership
+	err := impl.dbConnection.Model(&models).
+		Where("role_group_id = ?", roleGroupId).
+		Where("active = ?", true).
+		Select()
+	return models, err
+}
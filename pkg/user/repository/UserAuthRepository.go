@@ -22,6 +22,7 @@ package repository
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/devtron-labs/devtron/pkg/sql"
 	"strings"
 
@@ -34,6 +35,7 @@ import (
 type UserAuthRepository interface {
 	CreateRole(userModel *RoleModel, tx *pg.Tx) (*RoleModel, error)
 	GetRoleById(id int) (*RoleModel, error)
+	GetRole(role string) (*RoleModel, error)
 	GetRolesByUserId(userId int32) ([]RoleModel, error)
 	GetRolesByGroupId(userId int32) ([]*RoleModel, error)
 	GetAllRole() ([]RoleModel, error)
@@ -209,8 +211,8 @@ func (impl UserAuthRepositoryImpl) CreateDefaultPolicies(team string, entityName
 		return false, err
 	}
 
-	managerPolicies := "{\r\n    \"data\": [\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:manager_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"applications\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<TEAM_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:manager_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"environment\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<ENV_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:manager_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"team\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<TEAM_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:manager_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"user\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<TEAM_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:manager_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"notification\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<TEAM_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:manager_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"global-environment\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<ENV_OBJ>\"\r\n        }\r\n    ]\r\n}"
-	adminPolicies := "{\r\n    \"data\": [\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:admin_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"applications\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<TEAM_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:admin_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"environment\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<ENV_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:admin_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"team\",\r\n            \"act\": \"get\",\r\n            \"obj\": \"<TEAM_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:admin_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"global-environment\",\r\n            \"act\": \"get\",\r\n            \"obj\": \"<ENV_OBJ>\"\r\n        }\r\n    ]\r\n}"
+	managerPolicies := "{\r\n    \"data\": [\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:manager_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"applications\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<TEAM_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:manager_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"environment\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<ENV_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:manager_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"team\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<TEAM_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:manager_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"user\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<TEAM_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:manager_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"notification\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<TEAM_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:manager_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"global-environment\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<ENV_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:manager_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"secret\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<TEAM_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:manager_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"deployment-template\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<TEAM_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:manager_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"env-override\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<ENV_OBJ>/<APP_OBJ>\"\r\n        }\r\n    ]\r\n}"
+	adminPolicies := "{\r\n    \"data\": [\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:admin_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"applications\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<TEAM_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:admin_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"environment\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<ENV_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:admin_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"team\",\r\n            \"act\": \"get\",\r\n            \"obj\": \"<TEAM_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:admin_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"global-environment\",\r\n            \"act\": \"get\",\r\n            \"obj\": \"<ENV_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:admin_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"secret\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<TEAM_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:admin_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"deployment-template\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<TEAM_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:admin_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"env-override\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"<ENV_OBJ>/<APP_OBJ>\"\r\n        }\r\n    ]\r\n}"
 	triggerPolicies := "{\r\n    \"data\": [\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:trigger_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"applications\",\r\n            \"act\": \"get\",\r\n            \"obj\": \"<TEAM_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:trigger_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"applications\",\r\n            \"act\": \"trigger\",\r\n            \"obj\": \"<TEAM_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:trigger_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"environment\",\r\n            \"act\": \"trigger\",\r\n            \"obj\": \"<ENV_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:trigger_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"environment\",\r\n            \"act\": \"get\",\r\n            \"obj\": \"<ENV_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:trigger_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"global-environment\",\r\n            \"act\": \"get\",\r\n            \"obj\": \"<ENV_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:trigger_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"team\",\r\n            \"act\": \"get\",\r\n            \"obj\": \"<TEAM_OBJ>\"\r\n        }\r\n    ]\r\n}"
 	viewPolicies := "{\r\n    \"data\": [\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:view_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"applications\",\r\n            \"act\": \"get\",\r\n            \"obj\": \"<TEAM_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:view_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"environment\",\r\n            \"act\": \"get\",\r\n            \"obj\": \"<ENV_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:view_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"global-environment\",\r\n            \"act\": \"get\",\r\n            \"obj\": \"<ENV_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:view_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"team\",\r\n            \"act\": \"get\",\r\n            \"obj\": \"<TEAM_OBJ>\"\r\n        }\r\n    ]\r\n}"
 
@@ -324,7 +326,7 @@ func (impl UserAuthRepositoryImpl) CreateDefaultPolicies(team string, entityName
 		impl.Logger.Errorw("decode err", "err", err)
 		return false, err
 	}
-	_, err = impl.createRole(&roleManagerData, transaction)
+	_, err = impl.createRoleIfNotExists(&roleManagerData, transaction)
 	if err != nil && strings.Contains("duplicate key value violates unique constraint", err.Error()) {
 		return false, err
 	}
@@ -335,7 +337,7 @@ func (impl UserAuthRepositoryImpl) CreateDefaultPolicies(team string, entityName
 		impl.Logger.Errorw("decode err", "err", err)
 		return false, err
 	}
-	_, err = impl.createRole(&roleAdminData, transaction)
+	_, err = impl.createRoleIfNotExists(&roleAdminData, transaction)
 	if err != nil && strings.Contains("duplicate key value violates unique constraint", err.Error()) {
 		return false, err
 	}
@@ -346,7 +348,7 @@ func (impl UserAuthRepositoryImpl) CreateDefaultPolicies(team string, entityName
 		impl.Logger.Errorw("decode err", "err", err)
 		return false, err
 	}
-	_, err = impl.createRole(&roleTriggerData, transaction)
+	_, err = impl.createRoleIfNotExists(&roleTriggerData, transaction)
 	if err != nil && strings.Contains("duplicate key value violates unique constraint", err.Error()) {
 		return false, err
 	}
@@ -357,11 +359,16 @@ func (impl UserAuthRepositoryImpl) CreateDefaultPolicies(team string, entityName
 		impl.Logger.Errorw("decode err", "err", err)
 		return false, err
 	}
-	_, err = impl.createRole(&roleViewData, transaction)
+	_, err = impl.createRoleIfNotExists(&roleViewData, transaction)
 	if err != nil && strings.Contains("duplicate key value violates unique constraint", err.Error()) {
 		return false, err
 	}
 
+	err = impl.createFineGrainedPolicies(team, entityName, env, transaction)
+	if err != nil {
+		return false, err
+	}
+
 	err = transaction.Commit()
 	if err != nil {
 		return false, err
@@ -480,7 +487,7 @@ func (impl UserAuthRepositoryImpl) CreateUpdateDefaultPoliciesForSuperAdmin(tx *
 		return false, err
 	}
 
-	managerPolicies := "{\r\n    \"data\": [\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"cluster\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"git\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"admin\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"migrate\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"applications\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"environment\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"team\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"user\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"notification\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"global-environment\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"chart-group\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        }\r\n    ]\r\n}"
+	managerPolicies := "{\r\n    \"data\": [\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"cluster\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"git\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"admin\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"migrate\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"applications\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"environment\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"team\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"user\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"notification\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"global-environment\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"chart-group\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"secret\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"deployment-template\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:super-admin___\",\r\n            \"res\": \"env-override\",\r\n            \"act\": \"*\",\r\n            \"obj\": \"*\"\r\n        }\r\n    ]\r\n}"
 
 	var policiesManager bean.PolicyRequest
 	err = json.Unmarshal([]byte(managerPolicies), &policiesManager)
@@ -534,6 +541,60 @@ func (impl UserAuthRepositoryImpl) createRole(roleData *bean.RoleData, tx *pg.Tx
 	return true, nil
 }
 
+// createRoleIfNotExists lets default policies be re-applied for a team/env/app whose roles partly exist, as happens
+// when a fine grained role is first requested for an older combination
+func (impl UserAuthRepositoryImpl) createRoleIfNotExists(roleData *bean.RoleData, tx *pg.Tx) (bool, error) {
+	_, err := impl.GetRole(roleData.Role)
+	if err == nil {
+		return true, nil
+	} else if err != pg.ErrNoRows {
+		return false, err
+	}
+	return impl.createRole(roleData, tx)
+}
+
+// createFineGrainedPolicies adds the secret-view, template-edit and override-edit roles of a team/env/app, each one
+// being view access plus a single permission on secrets, deployment templates or environment overrides
+func (impl UserAuthRepositoryImpl) createFineGrainedPolicies(team string, entityName string, env string, tx *pg.Tx) error {
+	teamObj, envObj, appObj := team, env, entityName
+	if teamObj == "" {
+		teamObj = "*"
+	}
+	if envObj == "" {
+		envObj = "*"
+	}
+	if appObj == "" {
+		appObj = "*"
+	}
+	for _, action := range []string{bean.RoleActionSecretView, bean.RoleActionTemplateEdit, bean.RoleActionOverrideEdit} {
+		sub := casbin.Subject(fmt.Sprintf("role:%s_%s_%s_%s", action, team, env, entityName))
+		policies := []casbin.Policy{
+			{Type: "p", Sub: sub, Res: casbin.ResourceApplications, Act: casbin.ActionGet, Obj: casbin.Object(teamObj + "/" + appObj)},
+			{Type: "p", Sub: sub, Res: casbin.ResourceEnvironment, Act: casbin.ActionGet, Obj: casbin.Object(envObj + "/" + appObj)},
+			{Type: "p", Sub: sub, Res: casbin.ResourceGlobalEnvironment, Act: casbin.ActionGet, Obj: casbin.Object(envObj)},
+			{Type: "p", Sub: sub, Res: casbin.ResourceTeam, Act: casbin.ActionGet, Obj: casbin.Object(teamObj)},
+		}
+		switch action {
+		case bean.RoleActionSecretView:
+			policies = append(policies, casbin.Policy{Type: "p", Sub: sub, Res: casbin.ResourceSecret, Act: casbin.ActionGet, Obj: casbin.Object(teamObj + "/" + appObj)})
+		case bean.RoleActionTemplateEdit:
+			policies = append(policies, casbin.Policy{Type: "p", Sub: sub, Res: casbin.ResourceDeploymentTemplate, Act: "*", Obj: casbin.Object(teamObj + "/" + appObj)})
+		case bean.RoleActionOverrideEdit:
+			policies = append(policies, casbin.Policy{Type: "p", Sub: sub, Res: casbin.ResourceEnvOverride, Act: "*", Obj: casbin.Object(envObj + "/" + appObj)})
+		}
+		impl.Logger.Debugw("add policy request", "policies", policies)
+		casbin.AddPolicy(policies)
+
+		roleData := &bean.RoleData{Role: string(sub), Team: team, EntityName: entityName, Environment: env, Action: action}
+		_, err := impl.createRoleIfNotExists(roleData, tx)
+		if err != nil {
+			impl.Logger.Errorw("error in creating role", "role", roleData.Role, "err", err)
+			return err
+		}
+	}
+	return nil
+}
+
 func (impl UserAuthRepositoryImpl) SyncOrchestratorToCasbin(team string, entityName string, env string, tx *pg.Tx) (bool, error) {
 
 	triggerPolicies := "{\r\n    \"data\": [\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:trigger_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"applications\",\r\n            \"act\": \"get\",\r\n            \"obj\": \"<TEAM_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:trigger_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"applications\",\r\n            \"act\": \"trigger\",\r\n            \"obj\": \"<TEAM_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:trigger_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"environment\",\r\n            \"act\": \"trigger\",\r\n            \"obj\": \"<ENV_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:trigger_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"environment\",\r\n            \"act\": \"get\",\r\n            \"obj\": \"<ENV_OBJ>/<APP_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:trigger_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"global-environment\",\r\n            \"act\": \"get\",\r\n            \"obj\": \"<ENV_OBJ>\"\r\n        },\r\n        {\r\n            \"type\": \"p\",\r\n            \"sub\": \"role:trigger_<TEAM>_<ENV>_<APP>\",\r\n            \"res\": \"team\",\r\n            \"act\": \"get\",\r\n            \"obj\": \"<TEAM_OBJ>\"\r\n        }\r\n    ]\r\n}"
@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package repository
+
+import (
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"time"
+)
+
+// UserCredential holds the local login of a user, a bcrypt password hash and an optional TOTP secret.
+// MfaEnabled is only set once an enrolled secret has been verified
+type UserCredential struct {
+	TableName         struct{}  `sql:"user_credential" pg:",discard_unknown_columns"`
+	Id                int       `sql:"id,pk"`
+	UserId            int32     `sql:"user_id,notnull"`
+	PasswordHash      string    `sql:"password_hash"`
+	PasswordUpdatedOn time.Time `sql:"password_updated_on"`
+	MfaSecret         string    `sql:"mfa_secret"`
+	MfaEnabled        bool      `sql:"mfa_enabled,notnull"`
+	LastOtpStep       int64     `sql:"last_otp_step,notnull"`
+	FailedAttempts    int       `sql:"failed_attempts,notnull"`
+	LockedUntil       time.Time `sql:"locked_until"`
+	LastLoginOn       time.Time `sql:"last_login_on"`
+	sql.AuditLog
+}
+
+type UserCredentialRepository interface {
+	Save(model *UserCredential) error
+	Update(model *UserCredential) error
+	FindByUserId(userId int32) (*UserCredential, error)
+	// IncrementFailedAttempts counts a failed login in one statement so that concurrent attempts are all counted,
+	// reaching maxAttempts resets the count and locks the user until lockUntil
+	IncrementFailedAttempts(userId int32, maxAttempts int, lockUntil time.Time) (*UserCredential, error)
+}
+
+type UserCredentialRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func NewUserCredentialRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *UserCredentialRepositoryImpl {
+	return &UserCredentialRepositoryImpl{dbConnection: dbConnection, logger: logger}
+}
+
+func (impl UserCredentialRepositoryImpl) Save(model *UserCredential) error {
+	return impl.dbConnection.Insert(model)
+}
+
+func (impl UserCredentialRepositoryImpl) Update(model *UserCredential) error {
+	return impl.dbConnection.Update(model)
+}
+
+func (impl UserCredentialRepositoryImpl) FindByUserId(userId int32) (*UserCredential, error) {
+	model := &UserCredential{}
+	err := impl.dbConnection.Model(model).
+		Where("user_id = ?", userId).
+		Select()
+	return model, err
+}
+
+func (impl UserCredentialRepositoryImpl) IncrementFailedAttempts(userId int32, maxAttempts int, lockUntil time.Time) (*UserCredential, error) {
+	model := &UserCredential{}
+	query := "UPDATE user_credential SET" +
+		" locked_until = CASE WHEN ? > 0 AND failed_attempts + 1 >= ? THEN ? ELSE locked_until END," +
+		" failed_attempts = CASE WHEN ? > 0 AND failed_attempts + 1 >= ? THEN 0 ELSE failed_attempts + 1 END," +
+		" updated_on = ? WHERE user_id = ? RETURNING *"
+	_, err := impl.dbConnection.QueryOne(model, query, maxAttempts, maxAttempts, lockUntil, maxAttempts, maxAttempts, time.Now(), userId)
+	return model, err
+}
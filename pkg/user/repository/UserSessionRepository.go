@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package repository
+
+import (
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"time"
+)
+
+// UserSession is a login token of a user as seen by the orchestrator, TokenId is the sha256 of the token
+type UserSession struct {
+	TableName  struct{}  `sql:"user_session" pg:",discard_unknown_columns"`
+	Id         int       `sql:"id,pk"`
+	UserId     int32     `sql:"user_id,notnull"`
+	TokenId    string    `sql:"token_id,notnull"`
+	Ip         string    `sql:"ip"`
+	UserAgent  string    `sql:"user_agent"`
+	IssuedOn   time.Time `sql:"issued_on"`
+	ExpiresOn  time.Time `sql:"expires_on"`
+	LastSeenOn time.Time `sql:"last_seen_on,notnull"`
+	Revoked    bool      `sql:"revoked,notnull"`
+	RevokedOn  time.Time `sql:"revoked_on"`
+	RevokedBy  int32     `sql:"revoked_by"`
+	sql.AuditLog
+}
+
+// UserSessionRevocation refuses all tokens of a user issued before RevokedBefore
+type UserSessionRevocation struct {
+	TableName     struct{}  `sql:"user_session_revocation" pg:",discard_unknown_columns"`
+	UserId        int32     `sql:"user_id,pk"`
+	RevokedBefore time.Time `sql:"revoked_before,notnull"`
+	UpdatedOn     time.Time `sql:"updated_on,notnull"`
+	UpdatedBy     int32     `sql:"updated_by,notnull"`
+}
+
+type UserSessionRepository interface {
+	Save(model *UserSession) error
+	Update(model *UserSession) error
+	FindById(id int) (*UserSession, error)
+	FindByTokenId(tokenId string) (*UserSession, error)
+	FindActiveByUserId(userId int32, now time.Time) ([]*UserSession, error)
+	// RevokeAllByUserId revokes the sessions of a user and returns the token ids of the ones revoked
+	RevokeAllByUserId(userId int32, revokedBy int32, now time.Time) ([]string, error)
+	FindRevocation(userId int32) (*UserSessionRevocation, error)
+	SaveRevocation(model *UserSessionRevocation) error
+}
+
+type UserSessionRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func NewUserSessionRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *UserSessionRepositoryImpl {
+	return &UserSessionRepositoryImpl{dbConnection: dbConnection, logger: logger}
+}
+
+func (impl UserSessionRepositoryImpl) Save(model *UserSession) error {
+	return impl.dbConnection.Insert(model)
+}
+
+func (impl UserSessionRepositoryImpl) Update(model *UserSession) error {
+	return impl.dbConnection.Update(model)
+}
+
+func (impl UserSessionRepositoryImpl) FindById(id int) (*UserSession, error) {
+	model := &UserSession{}
+	err := impl.dbConnection.Model(model).
+		Where("id = ?", id).
+		Select()
+	return model, err
+}
+
+func (impl UserSessionRepositoryImpl) FindByTokenId(tokenId string) (*UserSession, error) {
+	model := &UserSession{}
+	err := impl.dbConnection.Model(model).
+		Where("token_id = ?", tokenId).
+		Select()
+	return model, err
+}
+
+func (impl UserSessionRepositoryImpl) FindActiveByUserId(userId int32, now time.Time) ([]*UserSession, error) {
+	var models []*UserSession
+	err := impl.dbConnection.Model(&models).
+		Where("user_id = ?", userId).
+		Where("revoked = ?", false).
+		Where("expires_on IS NULL OR expires_on > ?", now).
+		Order("last_seen_on DESC").
+		Select()
+	return models, err
+}
+
+func (impl UserSessionRepositoryImpl) RevokeAllByUserId(userId int32, revokedBy int32, now time.Time) ([]string, error) {
+	var tokenIds []string
+	_, err := impl.dbConnection.Query(&tokenIds, "UPDATE user_session SET revoked = true, revoked_on = ?, revoked_by = ?, updated_on = ?, updated_by = ?"+
+		" WHERE user_id = ? AND revoked = false RETURNING token_id", now, revokedBy, now, revokedBy, userId)
+	return tokenIds, err
+}
+
+func (impl UserSessionRepositoryImpl) FindRevocation(userId int32) (*UserSessionRevocation, error) {
+	model := &UserSessionRevocation{}
+	err := impl.dbConnection.Model(model).
+		Where("user_id = ?", userId).
+		Select()
+	return model, err
+}
+
+func (impl UserSessionRepositoryImpl) SaveRevocation(model *UserSessionRevocation) error {
+	_, err := impl.dbConnection.Model(model).
+		OnConflict("(user_id) DO UPDATE").
+		Set("revoked_before = EXCLUDED.revoked_before").
+		Set("updated_on = EXCLUDED.updated_on").
+		Set("updated_by = EXCLUDED.updated_by").
+		Insert()
+	return err
+}
@@ -0,0 +1,414 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package variables
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/devtron-labs/devtron/api/bean"
+	repository2 "github.com/devtron-labs/devtron/pkg/cluster/repository"
+	"github.com/devtron-labs/devtron/pkg/variables/repository"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// placeholders are written as @{{NAME}} in deployment templates, CM/CS data and stage scripts
+var placeholderRegex = regexp.MustCompile(`@\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+var variableNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// scopePrecedence lists scopes from least to most specific, a more specific definition wins
+var scopePrecedence = map[repository.VariableScope]int{
+	repository.VariableScopeGlobal:  0,
+	repository.VariableScopeCluster: 1,
+	repository.VariableScopeEnv:     2,
+	repository.VariableScopeApp:     3,
+	repository.VariableScopeAppEnv:  4,
+}
+
+type VariableDto struct {
+	Id            int                      `json:"id"`
+	Name          string                   `json:"name" validate:"required"`
+	Value         string                   `json:"value"`
+	Description   string                   `json:"description,omitempty"`
+	Scope         repository.VariableScope `json:"scope" validate:"required"`
+	AppId         int                      `json:"appId,omitempty"`
+	EnvironmentId int                      `json:"environmentId,omitempty"`
+	ClusterId     int                      `json:"clusterId,omitempty"`
+	UserId        int32                    `json:"-"`
+}
+
+// Scope identifies what a template is resolved for, ClusterId is looked up from the environment when not set
+type Scope struct {
+	AppId         int
+	EnvironmentId int
+	ClusterId     int
+}
+
+type ResolveRequest struct {
+	AppId         int    `json:"appId"`
+	EnvironmentId int    `json:"environmentId"`
+	Template      string `json:"template"`
+}
+
+type ResolvedVariable struct {
+	Name  string                   `json:"name"`
+	Value string                   `json:"value"`
+	Scope repository.VariableScope `json:"scope"`
+}
+
+type ResolveResponse struct {
+	Resolved  string              `json:"resolved"`
+	Variables []*ResolvedVariable `json:"variables"`
+	Missing   []string            `json:"missing"`
+}
+
+type VariableService interface {
+	CreateVariable(request *VariableDto) (*VariableDto, error)
+	UpdateVariable(request *VariableDto) (*VariableDto, error)
+	DeleteVariable(id int, userId int32) error
+	GetById(id int) (*VariableDto, error)
+	GetAll() ([]*VariableDto, error)
+	Resolve(request *ResolveRequest) (*ResolveResponse, error)
+
+	ResolveJson(data []byte, scope Scope) ([]byte, error)
+	ResolveText(data string, scope Scope) (string, error)
+	ResolveConfigMapSecretJson(data []byte, scope Scope) ([]byte, error)
+	ResolveSecrets(secrets []*bean.Map, scope Scope) error
+}
+
+type VariableServiceImpl struct {
+	logger                *zap.SugaredLogger
+	variableRepository    repository.VariableRepository
+	environmentRepository repository2.EnvironmentRepository
+}
+
+func NewVariableServiceImpl(logger *zap.SugaredLogger, variableRepository repository.VariableRepository,
+	environmentRepository repository2.EnvironmentRepository) *VariableServiceImpl {
+	return &VariableServiceImpl{
+		logger:                logger,
+		variableRepository:    variableRepository,
+		environmentRepository: environmentRepository,
+	}
+}
+
+func (impl VariableServiceImpl) CreateVariable(request *VariableDto) (*VariableDto, error) {
+	err := validateVariable(request)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := impl.variableRepository.FindActiveByNameAndScope(request.Name, request.Scope, request.AppId, request.EnvironmentId, request.ClusterId)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching variable", "name", request.Name, "err", err)
+		return nil, err
+	}
+	if existing != nil && existing.Id > 0 {
+		return nil, fmt.Errorf("variable %s already exists for scope %s", request.Name, request.Scope)
+	}
+	model := &repository.Variable{
+		Name:          request.Name,
+		Value:         request.Value,
+		Description:   request.Description,
+		Scope:         request.Scope,
+		AppId:         request.AppId,
+		EnvironmentId: request.EnvironmentId,
+		ClusterId:     request.ClusterId,
+		Active:        true,
+	}
+	model.CreatedBy = request.UserId
+	model.UpdatedBy = request.UserId
+	model.CreatedOn = time.Now()
+	model.UpdatedOn = time.Now()
+	err = impl.variableRepository.Save(model)
+	if err != nil {
+		impl.logger.Errorw("error in saving variable", "name", request.Name, "err", err)
+		return nil, err
+	}
+	request.Id = model.Id
+	return request, nil
+}
+
+// UpdateVariable changes value and description, name and scope are fixed once created
+func (impl VariableServiceImpl) UpdateVariable(request *VariableDto) (*VariableDto, error) {
+	model, err := impl.variableRepository.FindById(request.Id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching variable", "id", request.Id, "err", err)
+		return nil, err
+	}
+	model.Value = request.Value
+	model.Description = request.Description
+	model.UpdatedBy = request.UserId
+	model.UpdatedOn = time.Now()
+	err = impl.variableRepository.Update(model)
+	if err != nil {
+		impl.logger.Errorw("error in updating variable", "id", request.Id, "err", err)
+		return nil, err
+	}
+	return adaptVariable(model), nil
+}
+
+func (impl VariableServiceImpl) DeleteVariable(id int, userId int32) error {
+	model, err := impl.variableRepository.FindById(id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching variable", "id", id, "err", err)
+		return err
+	}
+	model.Active = false
+	model.UpdatedBy = userId
+	model.UpdatedOn = time.Now()
+	err = impl.variableRepository.Update(model)
+	if err != nil {
+		impl.logger.Errorw("error in deleting variable", "id", id, "err", err)
+		return err
+	}
+	return nil
+}
+
+func (impl VariableServiceImpl) GetById(id int) (*VariableDto, error) {
+	model, err := impl.variableRepository.FindById(id)
+	if err != nil {
+		impl.logger.Errorw("error in fetching variable", "id", id, "err", err)
+		return nil, err
+	}
+	return adaptVariable(model), nil
+}
+
+func (impl VariableServiceImpl) GetAll() ([]*VariableDto, error) {
+	models, err := impl.variableRepository.FindAllActive()
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching variables", "err", err)
+		return nil, err
+	}
+	variables := make([]*VariableDto, 0)
+	for _, model := range models {
+		variables = append(variables, adaptVariable(model))
+	}
+	return variables, nil
+}
+
+// Resolve is a dry run of resolution for the given app and env, missing variables are reported instead of failing
+func (impl VariableServiceImpl) Resolve(request *ResolveRequest) (*ResolveResponse, error) {
+	values, err := impl.getScopedVariables(Scope{AppId: request.AppId, EnvironmentId: request.EnvironmentId})
+	if err != nil {
+		return nil, err
+	}
+	resolved, used, missing := ReplacePlaceholders(request.Template, values, false)
+	response := &ResolveResponse{Resolved: resolved, Variables: make([]*ResolvedVariable, 0), Missing: missing}
+	for _, name := range used {
+		variable := values[name]
+		response.Variables = append(response.Variables, &ResolvedVariable{Name: name, Value: variable.Value, Scope: variable.Scope})
+	}
+	return response, nil
+}
+
+// ResolveJson replaces placeholders inside json string values, values are escaped so the document stays valid
+func (impl VariableServiceImpl) ResolveJson(data []byte, scope Scope) ([]byte, error) {
+	if !placeholderRegex.Match(data) {
+		return data, nil
+	}
+	resolved, err := impl.resolve(string(data), scope, true)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(resolved), nil
+}
+
+func (impl VariableServiceImpl) ResolveText(data string, scope Scope) (string, error) {
+	if !placeholderRegex.MatchString(data) {
+		return data, nil
+	}
+	return impl.resolve(data, scope, false)
+}
+
+// ResolveConfigMapSecretJson resolves the ConfigMaps and ConfigSecrets values passed to the chart, secret data
+// is base64 encoded so it is decoded and encoded again around resolution
+func (impl VariableServiceImpl) ResolveConfigMapSecretJson(data []byte, scope Scope) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	configMapSecret := &struct {
+		bean.ConfigMapRootJson
+		bean.ConfigSecretRootJson
+	}{}
+	err := json.Unmarshal(data, configMapSecret)
+	if err != nil {
+		return nil, err
+	}
+	for i := range configMapSecret.ConfigMapJson.Maps {
+		configMap := &configMapSecret.ConfigMapJson.Maps[i]
+		configMap.Data, err = impl.ResolveJson(configMap.Data, scope)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = impl.ResolveSecrets(configMapSecret.ConfigSecretJson.Secrets, scope)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(configMapSecret)
+}
+
+func (impl VariableServiceImpl) ResolveSecrets(secrets []*bean.Map, scope Scope) error {
+	for _, secret := range secrets {
+		if secret.External || len(secret.Data) == 0 {
+			continue
+		}
+		data := make(map[string]string)
+		err := json.Unmarshal(secret.Data, &data)
+		if err != nil {
+			return err
+		}
+		changed := false
+		for k, v := range data {
+			decoded, err := base64.StdEncoding.DecodeString(v)
+			if err != nil || !placeholderRegex.Match(decoded) {
+				continue
+			}
+			resolved, err := impl.resolve(string(decoded), scope, false)
+			if err != nil {
+				return fmt.Errorf("secret %s: %s", secret.Name, err.Error())
+			}
+			data[k] = base64.StdEncoding.EncodeToString([]byte(resolved))
+			changed = true
+		}
+		if changed {
+			secret.Data, err = json.Marshal(data)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (impl VariableServiceImpl) resolve(data string, scope Scope, escapeJson bool) (string, error) {
+	values, err := impl.getScopedVariables(scope)
+	if err != nil {
+		return "", err
+	}
+	resolved, _, missing := ReplacePlaceholders(data, values, escapeJson)
+	if len(missing) > 0 {
+		return "", fmt.Errorf("variables not defined for app %d env %d: %s", scope.AppId, scope.EnvironmentId, strings.Join(missing, ", "))
+	}
+	return resolved, nil
+}
+
+// getScopedVariables returns, per name, the most specific variable applicable to scope
+func (impl VariableServiceImpl) getScopedVariables(scope Scope) (map[string]*repository.Variable, error) {
+	if scope.ClusterId == 0 && scope.EnvironmentId > 0 {
+		env, err := impl.environmentRepository.FindById(scope.EnvironmentId)
+		if err != nil {
+			impl.logger.Errorw("error in fetching environment", "envId", scope.EnvironmentId, "err", err)
+			return nil, err
+		}
+		scope.ClusterId = env.ClusterId
+	}
+	models, err := impl.variableRepository.FindActiveApplicable(scope.AppId, scope.EnvironmentId, scope.ClusterId)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in fetching variables", "scope", scope, "err", err)
+		return nil, err
+	}
+	values := make(map[string]*repository.Variable)
+	for _, model := range models {
+		existing, ok := values[model.Name]
+		if !ok || scopePrecedence[model.Scope] > scopePrecedence[existing.Scope] {
+			values[model.Name] = model
+		}
+	}
+	return values, nil
+}
+
+// ReplacePlaceholders substitutes every known placeholder, returning the names used and those not defined
+func ReplacePlaceholders(data string, values map[string]*repository.Variable, escapeJson bool) (string, []string, []string) {
+	usedNames := make(map[string]bool)
+	missingNames := make(map[string]bool)
+	resolved := placeholderRegex.ReplaceAllStringFunc(data, func(placeholder string) string {
+		name := placeholderRegex.FindStringSubmatch(placeholder)[1]
+		variable, ok := values[name]
+		if !ok {
+			missingNames[name] = true
+			return placeholder
+		}
+		usedNames[name] = true
+		if escapeJson {
+			escaped, _ := json.Marshal(variable.Value)
+			return string(escaped[1 : len(escaped)-1])
+		}
+		return variable.Value
+	})
+	return resolved, sortedKeys(usedNames), sortedKeys(missingNames)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0)
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func validateVariable(request *VariableDto) error {
+	if !variableNameRegex.MatchString(request.Name) {
+		return fmt.Errorf("invalid variable name %s, only letters, digits and _ are allowed", request.Name)
+	}
+	switch request.Scope {
+	case repository.VariableScopeGlobal:
+		request.AppId, request.EnvironmentId, request.ClusterId = 0, 0, 0
+	case repository.VariableScopeCluster:
+		if request.ClusterId == 0 {
+			return fmt.Errorf("clusterId is required for scope %s", request.Scope)
+		}
+		request.AppId, request.EnvironmentId = 0, 0
+	case repository.VariableScopeEnv:
+		if request.EnvironmentId == 0 {
+			return fmt.Errorf("environmentId is required for scope %s", request.Scope)
+		}
+		request.AppId, request.ClusterId = 0, 0
+	case repository.VariableScopeApp:
+		if request.AppId == 0 {
+			return fmt.Errorf("appId is required for scope %s", request.Scope)
+		}
+		request.EnvironmentId, request.ClusterId = 0, 0
+	case repository.VariableScopeAppEnv:
+		if request.AppId == 0 || request.EnvironmentId == 0 {
+			return fmt.Errorf("appId and environmentId are required for scope %s", request.Scope)
+		}
+		request.ClusterId = 0
+	default:
+		return fmt.Errorf("invalid scope %s", request.Scope)
+	}
+	return nil
+}
+
+func adaptVariable(model *repository.Variable) *VariableDto {
+	return &VariableDto{
+		Id:            model.Id,
+		Name:          model.Name,
+		Value:         model.Value,
+		Description:   model.Description,
+		Scope:         model.Scope,
+		AppId:         model.AppId,
+		EnvironmentId: model.EnvironmentId,
+		ClusterId:     model.ClusterId,
+	}
+}
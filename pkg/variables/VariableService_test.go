@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package variables
+
+import (
+	"github.com/devtron-labs/devtron/pkg/variables/repository"
+	"reflect"
+	"testing"
+)
+
+func TestReplacePlaceholders(t *testing.T) {
+	values := map[string]*repository.Variable{
+		"DB_HOST": {Name: "DB_HOST", Value: "db.internal"},
+		"MOTD":    {Name: "MOTD", Value: `say "hi"`},
+	}
+	type args struct {
+		data       string
+		escapeJson bool
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        string
+		wantUsed    []string
+		wantMissing []string
+	}{
+		{name: "no placeholder",
+			args:        args{data: `{"replicaCount":1}`},
+			want:        `{"replicaCount":1}`,
+			wantUsed:    []string{},
+			wantMissing: []string{},
+		},
+		{name: "resolved with spaces",
+			args:        args{data: `host=@{{ DB_HOST }}:5432`},
+			want:        `host=db.internal:5432`,
+			wantUsed:    []string{"DB_HOST"},
+			wantMissing: []string{},
+		},
+		{name: "json escaped",
+			args:        args{data: `{"motd":"@{{MOTD}}"}`, escapeJson: true},
+			want:        `{"motd":"say \"hi\""}`,
+			wantUsed:    []string{"MOTD"},
+			wantMissing: []string{},
+		},
+		{name: "missing kept as is",
+			args:        args{data: `@{{DB_HOST}}/@{{DB_NAME}}`},
+			want:        `db.internal/@{{DB_NAME}}`,
+			wantUsed:    []string{"DB_HOST"},
+			wantMissing: []string{"DB_NAME"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, used, missing := ReplacePlaceholders(tt.args.data, values, tt.args.escapeJson)
+			if got != tt.want {
+				t.Errorf("ReplacePlaceholders() got = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(used, tt.wantUsed) {
+				t.Errorf("ReplacePlaceholders() used = %v, want %v", used, tt.wantUsed)
+			}
+			if !reflect.DeepEqual(missing, tt.wantMissing) {
+				t.Errorf("ReplacePlaceholders() missing = %v, want %v", missing, tt.wantMissing)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2020 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package repository
+
+import (
+	"github.com/devtron-labs/devtron/pkg/sql"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+)
+
+type VariableScope string
+
+const (
+	VariableScopeGlobal  VariableScope = "GLOBAL"
+	VariableScopeCluster VariableScope = "CLUSTER"
+	VariableScopeEnv     VariableScope = "ENV"
+	VariableScopeApp     VariableScope = "APP"
+	VariableScopeAppEnv  VariableScope = "APP_ENV"
+)
+
+// Variable is a named value defined at one scope, ids not relevant to the scope are 0
+type Variable struct {
+	tableName     struct{}      `sql:"global_variable" pg:",discard_unknown_columns"`
+	Id            int           `sql:"id,pk"`
+	Name          string        `sql:"name,notnull"`
+	Value         string        `sql:"value"`
+	Description   string        `sql:"description"`
+	Scope         VariableScope `sql:"scope,notnull"`
+	AppId         int           `sql:"app_id,notnull"`
+	EnvironmentId int           `sql:"environment_id,notnull"`
+	ClusterId     int           `sql:"cluster_id,notnull"`
+	Active        bool          `sql:"active,notnull"`
+	sql.AuditLog
+}
+
+type VariableRepository interface {
+	Save(model *Variable) error
+	Update(model *Variable) error
+	FindById(id int) (*Variable, error)
+	FindAllActive() ([]*Variable, error)
+	FindActiveByNameAndScope(name string, scope VariableScope, appId int, envId int, clusterId int) (*Variable, error)
+	FindActiveApplicable(appId int, envId int, clusterId int) ([]*Variable, error)
+}
+
+func NewVariableRepositoryImpl(dbConnection *pg.DB, logger *zap.SugaredLogger) *VariableRepositoryImpl {
+	return &VariableRepositoryImpl{
+		dbConnection: dbConnection,
+		logger:       logger,
+	}
+}
+
+type VariableRepositoryImpl struct {
+	dbConnection *pg.DB
+	logger       *zap.SugaredLogger
+}
+
+func (impl VariableRepositoryImpl) Save(model *Variable) error {
+	return impl.dbConnection.Insert(model)
+}
+
+func (impl VariableRepositoryImpl) Update(model *Variable) error {
+	return impl.dbConnection.Update(model)
+}
+
+func (impl VariableRepositoryImpl) FindById(id int) (*Variable, error) {
+	variable := &Variable{}
+	err := impl.dbConnection.
+		Model(variable).
+		Where("id = ?", id).
+		Where("active = ?", true).
+		Select()
+	return variable, err
+}
+
+func (impl VariableRepositoryImpl) FindAllActive() ([]*Variable, error) {
+	var variables []*Variable
+	err := impl.dbConnection.
+		Model(&variables).
+		Where("active = ?", true).
+		Order("name").
+		Select()
+	return variables, err
+}
+
+func (impl VariableRepositoryImpl) FindActiveByNameAndScope(name string, scope VariableScope, appId int, envId int, clusterId int) (*Variable, error) {
+	variable := &Variable{}
+	err := impl.dbConnection.
+		Model(variable).
+		Where("name = ?", name).
+		Where("scope = ?", scope).
+		Where("app_id = ?", appId).
+		Where("environment_id = ?", envId).
+		Where("cluster_id = ?", clusterId).
+		Where("active = ?", true).
+		Select()
+	return variable, err
+}
+
+// FindActiveApplicable returns variables of every scope which apply to the given app, env and cluster
+func (impl VariableRepositoryImpl) FindActiveApplicable(appId int, envId int, clusterId int) ([]*Variable, error) {
+	var variables []*Variable
+	err := impl.dbConnection.
+		Model(&variables).
+		Where("active = ?", true).
+		Where("scope = ? OR (scope = ? AND cluster_id = ?) OR (scope = ? AND environment_id = ?) OR (scope = ? AND app_id = ?) OR (scope = ? AND app_id = ? AND environment_id = ?)",
+			VariableScopeGlobal, VariableScopeCluster, clusterId, VariableScopeEnv, envId, VariableScopeApp, appId, VariableScopeAppEnv, appId, envId).
+		Select()
+	return variables, err
+}
@@ -0,0 +1 @@
+DELETE FROM "public"."casbin_rule" WHERE ("p_type" = 'p' AND "v1" IN ('secret', 'deployment-template', 'env-override'));
@@ -0,0 +1,7 @@
+INSERT INTO "public"."casbin_rule" ("p_type", "v0", "v1", "v2", "v3", "v4", "v5")
+SELECT 'p', cr."v0", res."name", '*', cr."v3", 'allow', ''
+FROM "public"."casbin_rule" cr
+         INNER JOIN (VALUES ('applications', 'secret'), ('applications', 'deployment-template'), ('environment', 'env-override')) AS res ("source", "name")
+                    ON cr."v1" = res."source"
+WHERE cr."p_type" = 'p' AND cr."v2" = '*' AND cr."v4" = 'allow'
+  AND NOT EXISTS (SELECT 1 FROM "public"."casbin_rule" ex WHERE ex."p_type" = 'p' AND ex."v0" = cr."v0" AND ex."v1" = res."name" AND ex."v2" = '*' AND ex."v3" = cr."v3");
@@ -0,0 +1,3 @@
+DROP TABLE "public"."secret_rotation" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_secret_rotation;
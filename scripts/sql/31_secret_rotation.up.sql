@@ -0,0 +1,25 @@
+CREATE SEQUENCE IF NOT EXISTS id_seq_secret_rotation;
+
+-- Table Definition
+CREATE TABLE "public"."secret_rotation"
+(
+    "id"             int4         NOT NULL DEFAULT nextval('id_seq_secret_rotation'::regclass),
+    "app_id"         int4         NOT NULL,
+    "environment_id" int4         NOT NULL DEFAULT 0,
+    "secret_name"    varchar(250) NOT NULL,
+    "secret_data"    text,
+    "status"         varchar(50)  NOT NULL,
+    "scheduled_on"   timestamptz,
+    "executed_on"    timestamptz,
+    "pipeline_ids"   int4[],
+    "comment"        text,
+    "message"        text,
+    "created_on"     timestamptz  NOT NULL,
+    "created_by"     int4         NOT NULL,
+    "updated_on"     timestamptz  NOT NULL,
+    "updated_by"     int4         NOT NULL,
+    CONSTRAINT "secret_rotation_app_id_fkey" FOREIGN KEY ("app_id") REFERENCES "public"."app" ("id"),
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "secret_rotation_app_id_idx" ON "public"."secret_rotation" ("app_id", "environment_id");
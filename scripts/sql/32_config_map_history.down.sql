@@ -0,0 +1,3 @@
+DROP TABLE "public"."config_map_history" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_config_map_history;
@@ -0,0 +1,21 @@
+CREATE SEQUENCE IF NOT EXISTS id_seq_config_map_history;
+
+-- Table Definition
+CREATE TABLE "public"."config_map_history"
+(
+    "id"             int4        NOT NULL DEFAULT nextval('id_seq_config_map_history'::regclass),
+    "app_id"         int4        NOT NULL,
+    "environment_id" int4        NOT NULL DEFAULT 0,
+    "data_type"      varchar(10) NOT NULL,
+    "version"        int4        NOT NULL,
+    "data"           text,
+    "comment"        text,
+    "created_on"     timestamptz NOT NULL,
+    "created_by"     int4        NOT NULL,
+    "updated_on"     timestamptz NOT NULL,
+    "updated_by"     int4        NOT NULL,
+    CONSTRAINT "config_map_history_app_id_fkey" FOREIGN KEY ("app_id") REFERENCES "public"."app" ("id"),
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "config_map_history_version_idx" ON "public"."config_map_history" ("app_id", "environment_id", "data_type", "version");
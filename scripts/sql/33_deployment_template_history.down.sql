@@ -0,0 +1,3 @@
+DROP TABLE "public"."deployment_template_history" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_deployment_template_history;
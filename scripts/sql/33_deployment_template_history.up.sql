@@ -0,0 +1,24 @@
+CREATE SEQUENCE IF NOT EXISTS id_seq_deployment_template_history;
+
+-- Table Definition
+CREATE TABLE "public"."deployment_template_history"
+(
+    "id"             int4        NOT NULL DEFAULT nextval('id_seq_deployment_template_history'::regclass),
+    "app_id"         int4        NOT NULL,
+    "environment_id" int4        NOT NULL DEFAULT 0,
+    "chart_id"       int4        NOT NULL,
+    "chart_version"  varchar(100),
+    "is_override"    bool        NOT NULL DEFAULT FALSE,
+    "version"        int4        NOT NULL,
+    "values"         text,
+    "comment"        text,
+    "created_on"     timestamptz NOT NULL,
+    "created_by"     int4        NOT NULL,
+    "updated_on"     timestamptz NOT NULL,
+    "updated_by"     int4        NOT NULL,
+    CONSTRAINT "deployment_template_history_app_id_fkey" FOREIGN KEY ("app_id") REFERENCES "public"."app" ("id"),
+    CONSTRAINT "deployment_template_history_chart_id_fkey" FOREIGN KEY ("chart_id") REFERENCES "public"."charts" ("id"),
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "deployment_template_history_version_idx" ON "public"."deployment_template_history" ("app_id", "environment_id", "version");
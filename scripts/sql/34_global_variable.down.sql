@@ -0,0 +1,3 @@
+DROP TABLE "public"."global_variable" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_global_variable;
@@ -0,0 +1,22 @@
+CREATE SEQUENCE IF NOT EXISTS id_seq_global_variable;
+
+-- Table Definition
+CREATE TABLE "public"."global_variable"
+(
+    "id"             int4         NOT NULL DEFAULT nextval('id_seq_global_variable'::regclass),
+    "name"           varchar(250) NOT NULL,
+    "value"          text,
+    "description"    text,
+    "scope"          varchar(20)  NOT NULL,
+    "app_id"         int4         NOT NULL DEFAULT 0,
+    "environment_id" int4         NOT NULL DEFAULT 0,
+    "cluster_id"     int4         NOT NULL DEFAULT 0,
+    "active"         bool         NOT NULL,
+    "created_on"     timestamptz  NOT NULL,
+    "created_by"     int4         NOT NULL,
+    "updated_on"     timestamptz  NOT NULL,
+    "updated_by"     int4         NOT NULL,
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "global_variable_scope_idx" ON "public"."global_variable" ("name", "scope", "app_id", "environment_id", "cluster_id") WHERE "active" = true;
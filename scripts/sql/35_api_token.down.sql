@@ -0,0 +1,3 @@
+DROP TABLE "public"."api_token" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_api_token;
@@ -0,0 +1,23 @@
+CREATE SEQUENCE IF NOT EXISTS id_seq_api_token;
+
+-- Table Definition
+CREATE TABLE "public"."api_token"
+(
+    "id"           int4         NOT NULL DEFAULT nextval('id_seq_api_token'::regclass),
+    "user_id"      int4         NOT NULL,
+    "name"         varchar(100) NOT NULL,
+    "description"  text,
+    "token_hash"   varchar(64)  NOT NULL,
+    "expires_on"   timestamptz  NOT NULL,
+    "last_used_on" timestamptz,
+    "active"       bool         NOT NULL,
+    "created_on"   timestamptz  NOT NULL,
+    "created_by"   int4         NOT NULL,
+    "updated_on"   timestamptz  NOT NULL,
+    "updated_by"   int4         NOT NULL,
+    CONSTRAINT "api_token_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id"),
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "api_token_token_hash_idx" ON "public"."api_token" ("token_hash");
+CREATE UNIQUE INDEX IF NOT EXISTS "api_token_name_idx" ON "public"."api_token" ("name") WHERE "active" = true;
@@ -0,0 +1,29 @@
+CREATE SEQUENCE IF NOT EXISTS id_seq_audit_log;
+
+-- Table Definition
+CREATE TABLE "public"."audit_log"
+(
+    "id"             int8         NOT NULL DEFAULT nextval('id_seq_audit_log'::regclass),
+    "request_id"     varchar(50),
+    "user_id"        int4         NOT NULL DEFAULT 0,
+    "email_id"       varchar(250),
+    "api_token"      bool         NOT NULL DEFAULT false,
+    "source"         varchar(10)  NOT NULL,
+    "resource_type"  varchar(50)  NOT NULL,
+    "resource_id"    varchar(250),
+    "app_id"         int4         NOT NULL DEFAULT 0,
+    "environment_id" int4         NOT NULL DEFAULT 0,
+    "action"         varchar(50)  NOT NULL,
+    "method"         varchar(10),
+    "path"           text,
+    "status_code"    int4         NOT NULL DEFAULT 0,
+    "before"         text,
+    "after"          text,
+    "created_on"     timestamptz  NOT NULL,
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "audit_log_created_on_idx" ON "public"."audit_log" ("created_on");
+CREATE INDEX IF NOT EXISTS "audit_log_user_id_idx" ON "public"."audit_log" ("user_id");
+CREATE INDEX IF NOT EXISTS "audit_log_app_id_idx" ON "public"."audit_log" ("app_id");
+CREATE INDEX IF NOT EXISTS "audit_log_resource_idx" ON "public"."audit_log" ("resource_type", "resource_id");
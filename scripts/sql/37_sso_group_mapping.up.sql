@@ -0,0 +1,18 @@
+CREATE SEQUENCE IF NOT EXISTS id_seq_sso_group_mapping;
+
+-- Table Definition
+CREATE TABLE "public"."sso_group_mapping"
+(
+    "id"            int4         NOT NULL DEFAULT nextval('id_seq_sso_group_mapping'::regclass),
+    "sso_group"     varchar(250) NOT NULL,
+    "role_group_id" int4         NOT NULL,
+    "active"        bool         NOT NULL,
+    "created_on"    timestamptz  NOT NULL,
+    "created_by"    int4         NOT NULL,
+    "updated_on"    timestamptz  NOT NULL,
+    "updated_by"    int4         NOT NULL,
+    CONSTRAINT "sso_group_mapping_role_group_id_fkey" FOREIGN KEY ("role_group_id") REFERENCES "public"."role_group" ("id"),
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "sso_group_mapping_group_role_group_idx" ON "public"."sso_group_mapping" ("sso_group", "role_group_id") WHERE "active" = true;
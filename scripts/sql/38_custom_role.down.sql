@@ -0,0 +1,3 @@
+DROP TABLE "public"."custom_role" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_custom_role;
@@ -0,0 +1,18 @@
+CREATE SEQUENCE IF NOT EXISTS id_seq_custom_role;
+
+-- Table Definition
+CREATE TABLE "public"."custom_role"
+(
+    "id"          int4         NOT NULL DEFAULT nextval('id_seq_custom_role'::regclass),
+    "name"        varchar(100) NOT NULL,
+    "description" text,
+    "permissions" text         NOT NULL,
+    "active"      bool         NOT NULL,
+    "created_on"  timestamptz  NOT NULL,
+    "created_by"  int4         NOT NULL,
+    "updated_on"  timestamptz  NOT NULL,
+    "updated_by"  int4         NOT NULL,
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "custom_role_name_idx" ON "public"."custom_role" ("name") WHERE "active" = true;
@@ -0,0 +1,9 @@
+DELETE FROM "public"."notification_templates" WHERE "node_type" = 'ACCESS_REQUEST';
+
+DELETE FROM "public"."event" WHERE "id" IN (4, 5, 6, 7, 8);
+
+SELECT pg_catalog.setval('public.event_id_seq', 3, true);
+
+DROP TABLE "public"."access_request" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_access_request;
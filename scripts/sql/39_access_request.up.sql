@@ -0,0 +1,66 @@
+CREATE SEQUENCE IF NOT EXISTS id_seq_access_request;
+
+-- Table Definition
+CREATE TABLE "public"."access_request"
+(
+    "id"               int4        NOT NULL DEFAULT nextval('id_seq_access_request'::regclass),
+    "user_id"          int4        NOT NULL,
+    "role_filter"      text        NOT NULL,
+    "reason"           text        NOT NULL,
+    "duration_minutes" int4        NOT NULL,
+    "status"           varchar(20) NOT NULL,
+    "approver_id"      int4,
+    "comment"          text,
+    "granted_roles"    text,
+    "approved_on"      timestamptz,
+    "expires_on"       timestamptz,
+    "created_on"       timestamptz NOT NULL,
+    "created_by"       int4        NOT NULL,
+    "updated_on"       timestamptz NOT NULL,
+    "updated_by"       int4        NOT NULL,
+    CONSTRAINT "access_request_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id"),
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "access_request_status_idx" ON "public"."access_request" ("status");
+
+INSERT INTO "public"."event" ("id", "event_type", "description") VALUES
+('4', 'ACCESS_REQUESTED', ''),
+('5', 'ACCESS_APPROVED', ''),
+('6', 'ACCESS_REJECTED', ''),
+('7', 'ACCESS_REVOKED', ''),
+('8', 'ACCESS_EXPIRED', '');
+
+SELECT pg_catalog.setval('public.event_id_seq', 8, true);
+
+INSERT INTO "public"."notification_templates" ("channel_type", "node_type", "event_type_id", "template_name", "template_payload") VALUES
+('slack', 'ACCESS_REQUEST', '4', 'Access requested template', '{"text": ":key: {{#accessRequest}}{{requestedBy}} requested {{action}} access on {{scope}} for {{durationMinutes}} minutes: {{reason}}{{/accessRequest}}"}'),
+('slack', 'ACCESS_REQUEST', '5', 'Access approved template', '{"text": ":white_check_mark: {{#accessRequest}}{{approvedBy}} approved {{action}} access of {{requestedBy}} on {{scope}} until {{expiresOn}}{{/accessRequest}}"}'),
+('slack', 'ACCESS_REQUEST', '6', 'Access rejected template', '{"text": ":x: {{#accessRequest}}{{approvedBy}} rejected {{action}} access of {{requestedBy}} on {{scope}}{{/accessRequest}}"}'),
+('slack', 'ACCESS_REQUEST', '7', 'Access revoked template', '{"text": ":no_entry: {{#accessRequest}}{{action}} access of {{requestedBy}} on {{scope}} was {{status}}{{/accessRequest}}"}'),
+('slack', 'ACCESS_REQUEST', '8', 'Access expired template', '{"text": ":hourglass: {{#accessRequest}}{{action}} access of {{requestedBy}} on {{scope}} expired{{/accessRequest}}"}'),
+('ses', 'ACCESS_REQUEST', '4', 'Access requested ses template', '{"from": "{{fromEmail}}",
+ "to": "{{toEmail}}",
+ "subject": "Access requested by {{#accessRequest}}{{requestedBy}}{{/accessRequest}}",
+ "html": "{{#accessRequest}}<b>{{requestedBy}} requested {{action}} access on {{scope}} for {{durationMinutes}} minutes</b><br/>{{reason}}{{/accessRequest}}"
+}'),
+('ses', 'ACCESS_REQUEST', '5', 'Access approved ses template', '{"from": "{{fromEmail}}",
+ "to": "{{toEmail}}",
+ "subject": "Access approved for {{#accessRequest}}{{requestedBy}}{{/accessRequest}}",
+ "html": "{{#accessRequest}}<b>{{approvedBy}} approved {{action}} access of {{requestedBy}} on {{scope}} until {{expiresOn}}</b>{{/accessRequest}}"
+}'),
+('ses', 'ACCESS_REQUEST', '6', 'Access rejected ses template', '{"from": "{{fromEmail}}",
+ "to": "{{toEmail}}",
+ "subject": "Access rejected for {{#accessRequest}}{{requestedBy}}{{/accessRequest}}",
+ "html": "{{#accessRequest}}<b>{{approvedBy}} rejected {{action}} access of {{requestedBy}} on {{scope}}</b><br/>{{comment}}{{/accessRequest}}"
+}'),
+('ses', 'ACCESS_REQUEST', '7', 'Access revoked ses template', '{"from": "{{fromEmail}}",
+ "to": "{{toEmail}}",
+ "subject": "Access revoked for {{#accessRequest}}{{requestedBy}}{{/accessRequest}}",
+ "html": "{{#accessRequest}}<b>{{action}} access of {{requestedBy}} on {{scope}} was {{status}}</b>{{/accessRequest}}"
+}'),
+('ses', 'ACCESS_REQUEST', '8', 'Access expired ses template', '{"from": "{{fromEmail}}",
+ "to": "{{toEmail}}",
+ "subject": "Access expired for {{#accessRequest}}{{requestedBy}}{{/accessRequest}}",
+ "html": "{{#accessRequest}}<b>{{action}} access of {{requestedBy}} on {{scope}} expired</b>{{/accessRequest}}"
+}');
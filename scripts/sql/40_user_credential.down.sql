@@ -0,0 +1,3 @@
+DROP TABLE "public"."user_credential" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_user_credential;
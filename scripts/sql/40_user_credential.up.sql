@@ -0,0 +1,24 @@
+CREATE SEQUENCE IF NOT EXISTS id_seq_user_credential;
+
+-- Table Definition
+CREATE TABLE "public"."user_credential"
+(
+    "id"                  int4        NOT NULL DEFAULT nextval('id_seq_user_credential'::regclass),
+    "user_id"             int4        NOT NULL,
+    "password_hash"       text,
+    "password_updated_on" timestamptz,
+    "mfa_secret"          varchar(64),
+    "mfa_enabled"         bool        NOT NULL DEFAULT false,
+    "last_otp_step"       int8        NOT NULL DEFAULT 0,
+    "failed_attempts"     int4        NOT NULL DEFAULT 0,
+    "locked_until"        timestamptz,
+    "last_login_on"       timestamptz,
+    "created_on"          timestamptz NOT NULL,
+    "created_by"          int4        NOT NULL,
+    "updated_on"          timestamptz NOT NULL,
+    "updated_by"          int4        NOT NULL,
+    CONSTRAINT "user_credential_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id"),
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "user_credential_user_id_idx" ON "public"."user_credential" ("user_id");
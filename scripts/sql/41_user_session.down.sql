@@ -0,0 +1,5 @@
+DROP TABLE "public"."user_session_revocation" CASCADE;
+
+DROP TABLE "public"."user_session" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_user_session;
@@ -0,0 +1,37 @@
+CREATE SEQUENCE IF NOT EXISTS id_seq_user_session;
+
+-- Table Definition
+CREATE TABLE "public"."user_session"
+(
+    "id"           int4         NOT NULL DEFAULT nextval('id_seq_user_session'::regclass),
+    "user_id"      int4         NOT NULL,
+    "token_id"     varchar(100) NOT NULL,
+    "ip"           varchar(100),
+    "user_agent"   text,
+    "issued_on"    timestamptz,
+    "expires_on"   timestamptz,
+    "last_seen_on" timestamptz  NOT NULL,
+    "revoked"      bool         NOT NULL DEFAULT false,
+    "revoked_on"   timestamptz,
+    "revoked_by"   int4,
+    "created_on"   timestamptz  NOT NULL,
+    "created_by"   int4         NOT NULL,
+    "updated_on"   timestamptz  NOT NULL,
+    "updated_by"   int4         NOT NULL,
+    CONSTRAINT "user_session_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id"),
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "user_session_token_id_idx" ON "public"."user_session" ("token_id");
+CREATE INDEX IF NOT EXISTS "user_session_user_id_idx" ON "public"."user_session" ("user_id");
+
+-- tokens of a user issued before revoked_before are refused, including ones never seen as a session
+CREATE TABLE "public"."user_session_revocation"
+(
+    "user_id"        int4        NOT NULL,
+    "revoked_before" timestamptz NOT NULL,
+    "updated_on"     timestamptz NOT NULL,
+    "updated_by"     int4        NOT NULL,
+    CONSTRAINT "user_session_revocation_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id"),
+    PRIMARY KEY ("user_id")
+);
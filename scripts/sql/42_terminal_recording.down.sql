@@ -0,0 +1,7 @@
+DROP TABLE "public"."terminal_session_recording" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_terminal_session_recording;
+
+DROP TABLE "public"."terminal_recording_config" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_terminal_recording_config;
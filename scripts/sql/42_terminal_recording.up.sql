@@ -0,0 +1,48 @@
+CREATE SEQUENCE IF NOT EXISTS id_seq_terminal_recording_config;
+
+-- Table Definition
+CREATE TABLE "public"."terminal_recording_config"
+(
+    "id"             int4        NOT NULL DEFAULT nextval('id_seq_terminal_recording_config'::regclass),
+    "environment_id" int4        NOT NULL,
+    "enabled"        bool        NOT NULL DEFAULT false,
+    "created_on"     timestamptz NOT NULL,
+    "created_by"     int4        NOT NULL,
+    "updated_on"     timestamptz NOT NULL,
+    "updated_by"     int4        NOT NULL,
+    CONSTRAINT "terminal_recording_config_environment_id_fkey" FOREIGN KEY ("environment_id") REFERENCES "public"."environment" ("id"),
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "terminal_recording_config_environment_id_idx" ON "public"."terminal_recording_config" ("environment_id");
+
+CREATE SEQUENCE IF NOT EXISTS id_seq_terminal_session_recording;
+
+-- Table Definition
+CREATE TABLE "public"."terminal_session_recording"
+(
+    "id"             int4         NOT NULL DEFAULT nextval('id_seq_terminal_session_recording'::regclass),
+    "session_id"     varchar(50)  NOT NULL,
+    "user_id"        int4         NOT NULL,
+    "app_id"         int4         NOT NULL,
+    "environment_id" int4         NOT NULL,
+    "namespace"      varchar(250) NOT NULL,
+    "pod_name"       varchar(250) NOT NULL,
+    "container_name" varchar(250),
+    "shell"          varchar(50),
+    "status"         varchar(20)  NOT NULL,
+    "blob_key"       text,
+    "size_bytes"     int8         NOT NULL DEFAULT 0,
+    "started_on"     timestamptz  NOT NULL,
+    "ended_on"       timestamptz,
+    "created_on"     timestamptz  NOT NULL,
+    "created_by"     int4         NOT NULL,
+    "updated_on"     timestamptz  NOT NULL,
+    "updated_by"     int4         NOT NULL,
+    CONSTRAINT "terminal_session_recording_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id"),
+    CONSTRAINT "terminal_session_recording_environment_id_fkey" FOREIGN KEY ("environment_id") REFERENCES "public"."environment" ("id"),
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "terminal_session_recording_session_id_idx" ON "public"."terminal_session_recording" ("session_id");
+CREATE INDEX IF NOT EXISTS "terminal_session_recording_app_env_idx" ON "public"."terminal_session_recording" ("app_id", "environment_id");
@@ -0,0 +1,9 @@
+DELETE FROM "public"."notification_templates" WHERE "node_type" = 'CLUSTER';
+
+DELETE FROM "public"."event" WHERE "id" IN (9, 10);
+
+SELECT pg_catalog.setval('public.event_id_seq', 8, true);
+
+DROP TABLE "public"."cluster_health_check" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_cluster_health_check;
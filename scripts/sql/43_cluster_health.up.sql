@@ -0,0 +1,38 @@
+CREATE SEQUENCE IF NOT EXISTS id_seq_cluster_health_check;
+
+-- Table Definition
+CREATE TABLE "public"."cluster_health_check"
+(
+    "id"               int4        NOT NULL DEFAULT nextval('id_seq_cluster_health_check'::regclass),
+    "cluster_id"       int4        NOT NULL,
+    "reachable"        bool        NOT NULL DEFAULT false,
+    "server_version"   varchar(100),
+    "latency_ms"       int8        NOT NULL DEFAULT 0,
+    "error"            text,
+    "token_expires_on" timestamptz,
+    "checked_on"       timestamptz NOT NULL,
+    CONSTRAINT "cluster_health_check_cluster_id_fkey" FOREIGN KEY ("cluster_id") REFERENCES "public"."cluster" ("id"),
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "cluster_health_check_cluster_id_checked_on_idx" ON "public"."cluster_health_check" ("cluster_id", "checked_on");
+
+INSERT INTO "public"."event" ("id", "event_type", "description") VALUES
+('9', 'CLUSTER_UNREACHABLE', ''),
+('10', 'CLUSTER_TOKEN_EXPIRING', '');
+
+SELECT pg_catalog.setval('public.event_id_seq', 10, true);
+
+INSERT INTO "public"."notification_templates" ("channel_type", "node_type", "event_type_id", "template_name", "template_payload") VALUES
+('slack', 'CLUSTER', '9', 'Cluster unreachable template', '{"text": ":red_circle: {{#clusterHealth}}Cluster {{clusterName}} is unreachable since {{checkedOn}}: {{error}}{{/clusterHealth}}"}'),
+('slack', 'CLUSTER', '10', 'Cluster token expiring template', '{"text": ":hourglass: {{#clusterHealth}}Bearer token of cluster {{clusterName}} expires on {{tokenExpiresOn}}{{/clusterHealth}}"}'),
+('ses', 'CLUSTER', '9', 'Cluster unreachable ses template', '{"from": "{{fromEmail}}",
+ "to": "{{toEmail}}",
+ "subject": "Cluster {{#clusterHealth}}{{clusterName}}{{/clusterHealth}} is unreachable",
+ "html": "{{#clusterHealth}}<b>Cluster {{clusterName}} ({{serverUrl}}) is unreachable since {{checkedOn}}</b><br/>{{error}}{{/clusterHealth}}"
+}'),
+('ses', 'CLUSTER', '10', 'Cluster token expiring ses template', '{"from": "{{fromEmail}}",
+ "to": "{{toEmail}}",
+ "subject": "Token of cluster {{#clusterHealth}}{{clusterName}}{{/clusterHealth}} is about to expire",
+ "html": "{{#clusterHealth}}<b>Bearer token of cluster {{clusterName}} ({{serverUrl}}) expires on {{tokenExpiresOn}}</b>{{/clusterHealth}}"
+}');
@@ -0,0 +1,7 @@
+DROP TABLE "public"."preview_environment" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_preview_environment;
+
+DROP TABLE "public"."preview_environment_config" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_preview_environment_config;
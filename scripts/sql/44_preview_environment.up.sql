@@ -0,0 +1,55 @@
+CREATE SEQUENCE IF NOT EXISTS id_seq_preview_environment_config;
+
+-- Table Definition
+CREATE TABLE "public"."preview_environment_config"
+(
+    "id"                      int4          NOT NULL DEFAULT nextval('id_seq_preview_environment_config'::regclass),
+    "app_id"                  int4          NOT NULL,
+    "ci_pipeline_id"          int4          NOT NULL,
+    "reference_pipeline_id"   int4          NOT NULL,
+    "cluster_id"              int4          NOT NULL,
+    "values_override"         text,
+    "url_template"            varchar(250),
+    "ttl_hours"               int4          NOT NULL DEFAULT 72,
+    "comment_on_pull_request" bool          NOT NULL DEFAULT true,
+    "active"                  bool          NOT NULL DEFAULT true,
+    "created_on"              timestamptz   NOT NULL,
+    "created_by"              int4          NOT NULL,
+    "updated_on"              timestamptz   NOT NULL,
+    "updated_by"              int4          NOT NULL,
+    CONSTRAINT "preview_environment_config_app_id_fkey" FOREIGN KEY ("app_id") REFERENCES "public"."app" ("id"),
+    CONSTRAINT "preview_environment_config_ci_pipeline_id_fkey" FOREIGN KEY ("ci_pipeline_id") REFERENCES "public"."ci_pipeline" ("id"),
+    CONSTRAINT "preview_environment_config_cluster_id_fkey" FOREIGN KEY ("cluster_id") REFERENCES "public"."cluster" ("id"),
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "preview_environment_config_ci_pipeline_id_active_idx" ON "public"."preview_environment_config" ("ci_pipeline_id") WHERE "active" = true;
+
+CREATE SEQUENCE IF NOT EXISTS id_seq_preview_environment;
+
+-- Table Definition
+CREATE TABLE "public"."preview_environment"
+(
+    "id"               int4          NOT NULL DEFAULT nextval('id_seq_preview_environment'::regclass),
+    "config_id"        int4          NOT NULL,
+    "app_id"           int4          NOT NULL,
+    "pull_request_id"  varchar(100)  NOT NULL,
+    "pull_request_url" varchar(500),
+    "source_branch"    varchar(250),
+    "environment_id"   int4,
+    "cd_pipeline_id"   int4,
+    "namespace"        varchar(250),
+    "url"              varchar(500),
+    "status"           varchar(50)   NOT NULL,
+    "message"          text,
+    "expires_on"       timestamptz   NOT NULL,
+    "created_on"       timestamptz   NOT NULL,
+    "created_by"       int4          NOT NULL,
+    "updated_on"       timestamptz   NOT NULL,
+    "updated_by"       int4          NOT NULL,
+    CONSTRAINT "preview_environment_config_id_fkey" FOREIGN KEY ("config_id") REFERENCES "public"."preview_environment_config" ("id"),
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "preview_environment_config_id_pull_request_id_idx" ON "public"."preview_environment" ("config_id", "pull_request_id");
+CREATE INDEX IF NOT EXISTS "preview_environment_cd_pipeline_id_idx" ON "public"."preview_environment" ("cd_pipeline_id");
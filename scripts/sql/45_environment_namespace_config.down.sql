@@ -0,0 +1,3 @@
+DROP TABLE "public"."environment_namespace_config" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_environment_namespace_config;
@@ -0,0 +1,22 @@
+CREATE SEQUENCE IF NOT EXISTS id_seq_environment_namespace_config;
+
+-- Table Definition
+CREATE TABLE "public"."environment_namespace_config"
+(
+    "id"             int4          NOT NULL DEFAULT nextval('id_seq_environment_namespace_config'::regclass),
+    "environment_id" int4          NOT NULL,
+    "resource_quota" text,
+    "limit_range"    text,
+    "network_policy" varchar(50)   NOT NULL DEFAULT 'NONE',
+    "labels"         text,
+    "annotations"    text,
+    "active"         bool          NOT NULL DEFAULT true,
+    "created_on"     timestamptz   NOT NULL,
+    "created_by"     int4          NOT NULL,
+    "updated_on"     timestamptz   NOT NULL,
+    "updated_by"     int4          NOT NULL,
+    CONSTRAINT "environment_namespace_config_environment_id_fkey" FOREIGN KEY ("environment_id") REFERENCES "public"."environment" ("id"),
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "environment_namespace_config_environment_id_active_idx" ON "public"."environment_namespace_config" ("environment_id") WHERE "active" = true;
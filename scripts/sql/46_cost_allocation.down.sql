@@ -0,0 +1,7 @@
+DROP TABLE "public"."cost_allocation" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_cost_allocation;
+
+DROP TABLE "public"."cluster_cost_price" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_cluster_cost_price;
@@ -0,0 +1,48 @@
+CREATE SEQUENCE IF NOT EXISTS id_seq_cluster_cost_price;
+
+-- Table Definition
+CREATE TABLE "public"."cluster_cost_price"
+(
+    "id"               int4          NOT NULL DEFAULT nextval('id_seq_cluster_cost_price'::regclass),
+    "cluster_id"       int4          NOT NULL,
+    "cpu_core_hour"    float8        NOT NULL DEFAULT 0,
+    "memory_gib_hour"  float8        NOT NULL DEFAULT 0,
+    "storage_gib_hour" float8        NOT NULL DEFAULT 0,
+    "active"           bool          NOT NULL DEFAULT true,
+    "created_on"       timestamptz   NOT NULL,
+    "created_by"       int4          NOT NULL,
+    "updated_on"       timestamptz   NOT NULL,
+    "updated_by"       int4          NOT NULL,
+    CONSTRAINT "cluster_cost_price_cluster_id_fkey" FOREIGN KEY ("cluster_id") REFERENCES "public"."cluster" ("id"),
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "cluster_cost_price_cluster_id_active_idx" ON "public"."cluster_cost_price" ("cluster_id") WHERE "active" = true;
+
+CREATE SEQUENCE IF NOT EXISTS id_seq_cost_allocation;
+
+-- Table Definition
+CREATE TABLE "public"."cost_allocation"
+(
+    "id"                   int4          NOT NULL DEFAULT nextval('id_seq_cost_allocation'::regclass),
+    "cost_date"            date          NOT NULL,
+    "cluster_id"           int4          NOT NULL,
+    "environment_id"       int4          NOT NULL,
+    "app_id"               int4          NOT NULL DEFAULT 0,
+    "team_id"              int4          NOT NULL DEFAULT 0,
+    "app_label"            varchar(250)  NOT NULL DEFAULT '',
+    "cpu_request_cores"    float8        NOT NULL DEFAULT 0,
+    "cpu_usage_cores"      float8        NOT NULL DEFAULT 0,
+    "memory_request_bytes" float8        NOT NULL DEFAULT 0,
+    "memory_usage_bytes"   float8        NOT NULL DEFAULT 0,
+    "storage_bytes"        float8        NOT NULL DEFAULT 0,
+    "cpu_cost"             float8        NOT NULL DEFAULT 0,
+    "memory_cost"          float8        NOT NULL DEFAULT 0,
+    "storage_cost"         float8        NOT NULL DEFAULT 0,
+    "total_cost"           float8        NOT NULL DEFAULT 0,
+    "created_on"           timestamptz   NOT NULL,
+    CONSTRAINT "cost_allocation_environment_id_fkey" FOREIGN KEY ("environment_id") REFERENCES "public"."environment" ("id"),
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "cost_allocation_cost_date_environment_id_app_label_idx" ON "public"."cost_allocation" ("cost_date", "environment_id", "app_label");
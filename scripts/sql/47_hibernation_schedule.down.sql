@@ -0,0 +1,7 @@
+DROP TABLE "public"."hibernation_event" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_hibernation_event;
+
+DROP TABLE "public"."hibernation_schedule" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_hibernation_schedule;
@@ -0,0 +1,41 @@
+CREATE SEQUENCE IF NOT EXISTS id_seq_hibernation_schedule;
+
+-- Table Definition
+CREATE TABLE "public"."hibernation_schedule"
+(
+    "id"               int4          NOT NULL DEFAULT nextval('id_seq_hibernation_schedule'::regclass),
+    "name"             varchar(250)  NOT NULL,
+    "environment_id"   int4          NOT NULL,
+    "app_id"           int4          NOT NULL DEFAULT 0,
+    "sleep_cron"       varchar(100)  NOT NULL,
+    "wake_cron"        varchar(100)  NOT NULL,
+    "timezone"         varchar(100)  NOT NULL DEFAULT 'UTC',
+    "excluded_app_ids" int4[],
+    "keep_awake_until" timestamptz,
+    "active"           bool          NOT NULL DEFAULT true,
+    "created_on"       timestamptz   NOT NULL,
+    "created_by"       int4          NOT NULL,
+    "updated_on"       timestamptz   NOT NULL,
+    "updated_by"       int4          NOT NULL,
+    CONSTRAINT "hibernation_schedule_environment_id_fkey" FOREIGN KEY ("environment_id") REFERENCES "public"."environment" ("id"),
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "hibernation_schedule_environment_id_idx" ON "public"."hibernation_schedule" ("environment_id", "app_id");
+
+CREATE SEQUENCE IF NOT EXISTS id_seq_hibernation_event;
+
+-- Table Definition
+CREATE TABLE "public"."hibernation_event"
+(
+    "id"             int4          NOT NULL DEFAULT nextval('id_seq_hibernation_event'::regclass),
+    "schedule_id"    int4          NOT NULL,
+    "app_id"         int4          NOT NULL,
+    "environment_id" int4          NOT NULL,
+    "hibernated_on"  timestamptz   NOT NULL,
+    "woken_on"       timestamptz,
+    CONSTRAINT "hibernation_event_schedule_id_fkey" FOREIGN KEY ("schedule_id") REFERENCES "public"."hibernation_schedule" ("id"),
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "hibernation_event_app_id_environment_id_idx" ON "public"."hibernation_event" ("app_id", "environment_id");
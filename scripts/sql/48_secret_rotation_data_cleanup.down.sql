@@ -0,0 +1,2 @@
+-- removed secret data can not be restored
+SELECT 1;
@@ -0,0 +1,11 @@
+-- scheduled secret data is kept encrypted from now on, values stored in plain text so far are dropped
+UPDATE "public"."secret_rotation"
+SET "status"  = 'FAILED',
+    "message" = 'scheduled data was stored unencrypted and has been removed, schedule the rotation again'
+WHERE "status" = 'SCHEDULED'
+  AND "secret_data" IS NOT NULL
+  AND "secret_data" <> '';
+
+UPDATE "public"."secret_rotation"
+SET "secret_data" = NULL
+WHERE "secret_data" IS NOT NULL;
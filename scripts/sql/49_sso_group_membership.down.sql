@@ -0,0 +1,3 @@
+DROP TABLE "public"."sso_group_membership" CASCADE;
+
+DROP SEQUENCE IF EXISTS id_seq_sso_group_membership;
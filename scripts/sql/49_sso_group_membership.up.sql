@@ -0,0 +1,22 @@
+CREATE SEQUENCE IF NOT EXISTS id_seq_sso_group_membership;
+
+-- Table Definition
+CREATE TABLE "public"."sso_group_membership"
+(
+    "id"                   int4        NOT NULL DEFAULT nextval('id_seq_sso_group_membership'::regclass),
+    "sso_group_mapping_id" int4        NOT NULL,
+    "user_id"              int4        NOT NULL,
+    "role_group_id"        int4        NOT NULL,
+    "active"               bool        NOT NULL,
+    "created_on"           timestamptz NOT NULL,
+    "created_by"           int4        NOT NULL,
+    "updated_on"           timestamptz NOT NULL,
+    "updated_by"           int4        NOT NULL,
+    CONSTRAINT "sso_group_membership_sso_group_mapping_id_fkey" FOREIGN KEY ("sso_group_mapping_id") REFERENCES "public"."sso_group_mapping" ("id"),
+    CONSTRAINT "sso_group_membership_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id"),
+    CONSTRAINT "sso_group_membership_role_group_id_fkey" FOREIGN KEY ("role_group_id") REFERENCES "public"."role_group" ("id"),
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "sso_group_membership_user_id_idx" ON "public"."sso_group_membership" ("user_id") WHERE "active" = true;
+CREATE INDEX IF NOT EXISTS "sso_group_membership_role_group_id_idx" ON "public"."sso_group_membership" ("role_group_id") WHERE "active" = true;
@@ -0,0 +1 @@
+DROP TABLE "public"."cluster_health_probe_lock" CASCADE;
@@ -0,0 +1,10 @@
+-- Table Definition
+CREATE TABLE "public"."cluster_health_probe_lock"
+(
+    "id"           int4         NOT NULL,
+    "holder"       varchar(250) NOT NULL DEFAULT '',
+    "locked_until" timestamptz  NOT NULL,
+    PRIMARY KEY ("id")
+);
+
+INSERT INTO "public"."cluster_health_probe_lock" ("id", "holder", "locked_until") VALUES (1, '', now());
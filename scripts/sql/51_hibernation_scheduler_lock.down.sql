@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "public"."hibernation_event_open_schedule_id_app_id_idx";
+
+DROP TABLE "public"."hibernation_scheduler_lock" CASCADE;
@@ -0,0 +1,18 @@
+-- Table Definition
+CREATE TABLE "public"."hibernation_scheduler_lock"
+(
+    "id"           int4         NOT NULL,
+    "holder"       varchar(250) NOT NULL DEFAULT '',
+    "locked_until" timestamptz  NOT NULL,
+    PRIMARY KEY ("id")
+);
+
+INSERT INTO "public"."hibernation_scheduler_lock" ("id", "holder", "locked_until") VALUES (1, '', now());
+
+-- close duplicate open events left by schedulers of several replicas, the oldest one of each app stays open
+UPDATE "public"."hibernation_event" SET "woken_on" = "hibernated_on"
+WHERE "woken_on" IS NULL AND "id" NOT IN (
+    SELECT min("id") FROM "public"."hibernation_event" WHERE "woken_on" IS NULL GROUP BY "schedule_id", "app_id"
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "hibernation_event_open_schedule_id_app_id_idx" ON "public"."hibernation_event" ("schedule_id", "app_id") WHERE "woken_on" IS NULL;
@@ -0,0 +1,3 @@
+UPDATE "public"."user_credential" SET "mfa_secret" = NULL, "mfa_enabled" = false, "last_otp_step" = 0 WHERE "mfa_secret" IS NOT NULL;
+
+ALTER TABLE "public"."user_credential" ALTER COLUMN "mfa_secret" TYPE varchar(64);
@@ -0,0 +1,5 @@
+-- totp secrets are kept encrypted, which is longer than the plain secret
+ALTER TABLE "public"."user_credential" ALTER COLUMN "mfa_secret" TYPE text;
+
+-- secrets stored in plain text can not be used any more, their users enroll again
+UPDATE "public"."user_credential" SET "mfa_secret" = NULL, "mfa_enabled" = false, "last_otp_step" = 0 WHERE "mfa_secret" IS NOT NULL;
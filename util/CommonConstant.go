@@ -22,6 +22,8 @@ const (
 	AWSSecretsManager                   string = "AWSSecretsManager"
 	AWSSystemManager                    string = "AWSSystemManager"
 	HashiCorpVault                      string = "HashiCorpVault"
+	GCPSecretManager                    string = "GCPSecretManager"
+	AzureKeyVault                       string = "AzureKeyVault"
 	KubernetesExternalSecret            string = "KubernetesExternalSecret"
 	ConfigMapSecretUsageTypeEnvironment string = "environment"
 	ConfigMapSecretUsageTypeVolume      string = "volume"
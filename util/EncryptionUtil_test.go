@@ -0,0 +1,24 @@
+package util
+
+import "testing"
+
+func TestEncryptData(t *testing.T) {
+	encrypted, err := EncryptData("key", []byte(`{"password":"secret"}`))
+	if err != nil {
+		t.Fatalf("EncryptData() error = %v", err)
+	}
+	again, _ := EncryptData("key", []byte(`{"password":"secret"}`))
+	if encrypted == again {
+		t.Errorf("EncryptData() should use a random nonce")
+	}
+	decrypted, err := DecryptData("key", encrypted)
+	if err != nil || string(decrypted) != `{"password":"secret"}` {
+		t.Errorf("DecryptData() = %s, %v", decrypted, err)
+	}
+	if _, err = DecryptData("other-key", encrypted); err == nil {
+		t.Errorf("DecryptData() with another key should fail")
+	}
+	if _, err = EncryptData("", []byte("data")); err == nil {
+		t.Errorf("EncryptData() without key should fail")
+	}
+}
@@ -0,0 +1,47 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+// secret and codes of the SHA1 test vectors of RFC 6238, truncated to 6 digits
+const rfcTotpSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTotpCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := TotpCode(rfcTotpSecret, TotpStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("TotpCode() error = %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("TotpCode(%d) = %v, want %v", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTotp(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	step, ok := ValidateTotp(rfcTotpSecret, "081804", now, 0)
+	if !ok || step != TotpStep(now) {
+		t.Errorf("ValidateTotp() = %v, %v, want %v, true", step, ok, TotpStep(now))
+	}
+	if _, ok := ValidateTotp(rfcTotpSecret, "081804", now.Add(30*time.Second), 0); !ok {
+		t.Errorf("ValidateTotp() refused a code of the previous period")
+	}
+	if _, ok := ValidateTotp(rfcTotpSecret, "081804", now, step); ok {
+		t.Errorf("ValidateTotp() accepted a used code")
+	}
+	if _, ok := ValidateTotp(rfcTotpSecret, "081804", now.Add(90*time.Second), 0); ok {
+		t.Errorf("ValidateTotp() accepted an outdated code")
+	}
+}
@@ -23,10 +23,23 @@ const Trigger EventType = 1
 const Success EventType = 2
 const Fail EventType = 3
 
+// events of just in time access requests, notified under the AccessRequest pipeline type
+const AccessRequested EventType = 4
+const AccessApproved EventType = 5
+const AccessRejected EventType = 6
+const AccessRevoked EventType = 7
+const AccessExpired EventType = 8
+
+// events of the cluster health prober, notified under the Cluster pipeline type
+const ClusterUnreachable EventType = 9
+const ClusterTokenExpiring EventType = 10
+
 type PipelineType string
 
 const CI PipelineType = "CI"
 const CD PipelineType = "CD"
+const AccessRequest PipelineType = "ACCESS_REQUEST"
+const Cluster PipelineType = "CLUSTER"
 
 type Level string
 
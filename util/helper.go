@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
@@ -119,3 +120,15 @@ func HttpRequest(url string) (map[string]interface{}, error) {
 	}
 	return nil, err
 }
+
+// GetClientIP returns the address of the client of a request, the first X-Forwarded-For entry if it was proxied
+func GetClientIP(r *http.Request) string {
+	if forwardedFor := r.Header.Get("X-Forwarded-For"); len(forwardedFor) > 0 {
+		return strings.TrimSpace(strings.Split(forwardedFor, ",")[0])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
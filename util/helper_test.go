@@ -1,6 +1,9 @@
 package util
 
-import "testing"
+import (
+	"net/http"
+	"testing"
+)
 
 func TestAutoscale(t *testing.T) {
 	type args struct {
@@ -27,3 +30,28 @@ func TestAutoscale(t *testing.T) {
 		})
 	}
 }
+
+func TestGetClientIP(t *testing.T) {
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		want         string
+	}{
+		{name: "remote address", remoteAddr: "10.0.0.4:52314", want: "10.0.0.4"},
+		{name: "remote address without port", remoteAddr: "10.0.0.4", want: "10.0.0.4"},
+		{name: "forwarded", remoteAddr: "10.0.0.4:52314", forwardedFor: "203.0.113.7, 10.0.0.1", want: "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if len(tt.forwardedFor) > 0 {
+				r.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if got := GetClientIP(r); got != tt.want {
+				t.Errorf("GetClientIP() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}